@@ -2,6 +2,8 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
@@ -10,12 +12,16 @@ import (
 	"os/signal"
 	"path/filepath"
 	"runtime"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
 	"syscall"
 	"time"
 
 	"github.com/zephel01/vibe-local-go/internal/agent"
 	"github.com/zephel01/vibe-local-go/internal/config"
+	"github.com/zephel01/vibe-local-go/internal/i18n"
 	"github.com/zephel01/vibe-local-go/internal/llm"
 	"github.com/zephel01/vibe-local-go/internal/sandbox"
 	"github.com/zephel01/vibe-local-go/internal/security"
@@ -39,6 +45,9 @@ type ShutdownManager struct {
 	terminal    *ui.Terminal
 	cancel      context.CancelFunc
 	mcpMgr      *mcp.Manager
+	agent       *agent.Agent
+	cfg         *config.Config
+	toolLog     *agent.ToolCallLogger
 }
 
 // NewShutdownManager creates a new shutdown manager
@@ -67,11 +76,32 @@ func (sm *ShutdownManager) Shutdown(reason string) {
 		sm.mcpMgr.StopAll()
 	}
 
+	// ツール呼び出しログを書き出す
+	if sm.toolLog != nil {
+		if err := sm.toolLog.Close(); err != nil {
+			sm.terminal.PrintColored(ui.ColorRed, fmt.Sprintf("ツールログの書き込みエラー: %v\n", err))
+		}
+	}
+
+	// 入力履歴を保存（次回起動時に ↑ / Ctrl+R で使えるように）
+	if err := sm.terminal.GetLineEditor().SaveHistory(config.HistoryPath()); err != nil {
+		sm.terminal.PrintColored(ui.ColorRed, fmt.Sprintf("入力履歴の保存エラー: %v\n", err))
+	}
+
 	// Save session
 	if sm.session.GetID() != "" {
+		// 復旧時に再適用できるようランタイムモードを記録
+		if sm.agent != nil && sm.cfg != nil {
+			sm.session.SetModes(session.SessionModes{
+				AutoApprove: sm.cfg.AutoApprove,
+				PlanMode:    sm.agent.IsPlanMode(),
+				AutoTest:    sm.agent.IsAutoTestEnabled(),
+			})
+		}
+
 		err := sm.persistence.SaveSession(sm.session)
 		if err != nil {
-			sm.terminal.PrintColored(ui.ColorRed, fmt.Sprintf("セッション保存エラー: %v\n", err))
+			sm.terminal.PrintColored(ui.ColorRed, i18n.T(i18n.MsgErrSessionSave, err))
 		} else {
 			sm.terminal.PrintColored(ui.ColorGreen, "✓ セッション保存完了\n")
 		}
@@ -84,6 +114,7 @@ var (
 	// CLI flags
 	flagModel            string
 	flagSidecar          string
+	flagEmbedModel       string
 	flagHost             string
 	flagProvider         string
 	flagAPIKey           string
@@ -92,6 +123,7 @@ var (
 	flagResume           string
 	flagSessionID        string
 	flagListSessions     bool
+	flagSearchSessions   string
 	flagMaxTokens        int
 	flagTemperature      float64
 	flagContextWindow    int
@@ -102,11 +134,33 @@ var (
 	flagPermissionCheck  bool
 	flagNumCtx           int
 	flagNumGPU           int
+	flagNoBanner         bool
+	flagMinimal          bool
+	flagJSONOutput       bool
+	flagWidth            int
+	flagOffline          bool
+	flagDryRun           bool
+	flagRetryBudget      int
+	flagBudgetTokens     int
+	flagToolOutputMax    int
+	flagLogFile          string
+	flagNoNetwork        bool
+	flagAllowDangerous   bool
+	flagAllowOutside     bool
+	flagSystemPrompt     string
+	flagSystemPromptMode string
+	flagNoVibeIgnore     bool
+	flagAutoTestCmd      string
+	flagLang             string
+	flagCompactAt        float64
+	flagNoAutoCompact    bool
+	flagTimeout          time.Duration
 )
 
 func init() {
 	flag.StringVar(&flagModel, "model", "", "Main model name")
 	flag.StringVar(&flagSidecar, "sidecar", "", "Sidecar model name")
+	flag.StringVar(&flagEmbedModel, "embedding-model", "", "Embedding model for semantic_search (default: main model)")
 	flag.StringVar(&flagHost, "host", "", "Ollama host URL")
 	flag.StringVar(&flagProvider, "provider", "", "LLM provider (ollama, openrouter)")
 	flag.StringVar(&flagAPIKey, "api-key", "", "API key for cloud providers (or use OPENROUTER_API_KEY env)")
@@ -115,6 +169,7 @@ func init() {
 	flag.StringVar(&flagResume, "resume", "", "Resume session (last or session-id)")
 	flag.StringVar(&flagSessionID, "session-id", "", "Specify session ID")
 	flag.BoolVar(&flagListSessions, "list-sessions", false, "List all sessions")
+	flag.StringVar(&flagSearchSessions, "search-sessions", "", "Search saved sessions for a keyword")
 	flag.IntVar(&flagMaxTokens, "max-tokens", 0, "Maximum tokens")
 	flag.Float64Var(&flagTemperature, "temperature", 0, "Temperature (0.0-2.0)")
 	flag.IntVar(&flagContextWindow, "context-window", 0, "Context window size")
@@ -125,6 +180,27 @@ func init() {
 	flag.BoolVar(&flagPermissionCheck, "permission-check", false, "Show permission check dialog at startup")
 	flag.IntVar(&flagNumCtx, "num-ctx", 0, "Ollama num_ctx (context size for KV cache, 0=default)")
 	flag.IntVar(&flagNumGPU, "num-gpu", -1, "Ollama num_gpu (number of GPU layers, -1=not set)")
+	flag.BoolVar(&flagNoBanner, "no-banner", false, "Suppress the startup banner and welcome message")
+	flag.BoolVar(&flagMinimal, "minimal", false, "Show only provider/model on one line at startup")
+	flag.IntVar(&flagWidth, "width", 0, "Max width for wrapping assistant text (0 = terminal width)")
+	flag.BoolVar(&flagDryRun, "dry-run", false, "Preview write/edit/bash tool calls without executing them")
+	flag.IntVar(&flagRetryBudget, "retry-budget", 0, "Max total retries per turn across provider and tool layers (0 = default)")
+	flag.IntVar(&flagBudgetTokens, "budget-tokens", 0, "Ask before further cloud LLM calls once the session has used this many cloud tokens (0 = no limit)")
+	flag.IntVar(&flagToolOutputMax, "tool-output-max", 0, "Max bytes of bash/read/grep/web_fetch output sent to the model (0 = default 30000)")
+	flag.StringVar(&flagLogFile, "log-file", "", "Append one JSON line per tool call to this file")
+	flag.StringVar(&flagLang, "lang", "", "Message language: ja or en (default: from LANG)")
+	flag.Float64Var(&flagCompactAt, "compact-at", 0, "Compact history automatically at this fraction of the context window (e.g. 0.8, 0 = default 0.9, where history trimming has always started)")
+	flag.BoolVar(&flagNoAutoCompact, "no-auto-compact", false, "Disable automatic history compaction (/compact still works)")
+	flag.DurationVar(&flagTimeout, "timeout", 0, "Wall-clock limit for a one-shot run (-p), e.g. 10m (0 = no limit)")
+	flag.BoolVar(&flagAllowOutside, "allow-outside-workdir", false, "Allow write/edit tools to modify files outside the working directory")
+	flag.StringVar(&flagSystemPrompt, "system-prompt-file", "", "Load a custom system prompt from this file")
+	flag.StringVar(&flagSystemPromptMode, "system-prompt-mode", "", "How --system-prompt-file is used: replace (default) or prepend")
+	flag.BoolVar(&flagNoVibeIgnore, "no-vibeignore", false, "Ignore .vibeignore (let tools read and write the paths it lists)")
+	flag.StringVar(&flagAutoTestCmd, "autotest-cmd", "", "Shell command /autotest runs after file edits (default: inferred from go.mod, package.json, ...)")
+	flag.BoolVar(&flagNoNetwork, "no-network", false, "Reject bash commands that reach remote hosts (curl, wget, ssh, pip install, ...)")
+	flag.BoolVar(&flagAllowDangerous, "allow-dangerous", false, "Disable the built-in dangerous-command block (rm -rf /, mkfs, ...); only for disposable environments")
+	flag.BoolVar(&flagOffline, "offline", false, "Disable network tools and cloud providers (local providers only)")
+	flag.BoolVar(&flagJSONOutput, "json-output", false, "With -p, print a single JSON result to stdout (other output goes to stderr)")
 }
 
 func main() {
@@ -136,27 +212,61 @@ func main() {
 		return
 	}
 
+	// 保存先ディレクトリ: VIBE_CONFIG_DIR > ~/.config（書き込めなければ一時ディレクトリへ退避）
+	// ※ スキル・MCP・パーミッションより先に決める
+	if warning := config.SetupConfigDir(); warning != "" {
+		fmt.Fprintf(os.Stderr, "⚠ %s\n", warning)
+	}
+
 	// Load configuration
 	cfg := loadConfig()
 
+	// 表示言語: --lang > 設定ファイル > LANG 等の環境変数
+	if _, ok := i18n.Parse(cfg.Lang); cfg.Lang != "" && !ok {
+		fmt.Fprintf(os.Stderr, "⚠ 未対応の言語 '%s' です (ja / en)。環境変数から判定します\n", cfg.Lang)
+	}
+	i18n.SetLang(i18n.Resolve(cfg.Lang, os.Getenv))
+
 	// List sessions
 	if flagListSessions {
 		listSessions(cfg)
 		return
 	}
 
+	// Search sessions
+	if flagSearchSessions != "" {
+		searchSessions(flagSearchSessions)
+		return
+	}
+
 	// Create context for graceful shutdown
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	// ワンショット実行全体の制限時間（エージェントループとツール実行をまとめて打ち切る）
+	if flagTimeout > 0 && flagPrompt != "" {
+		var cancelTimeout context.CancelFunc
+		ctx, cancelTimeout = context.WithTimeout(ctx, flagTimeout)
+		defer cancelTimeout()
+	}
+
 	// Initialize components
 	terminal := ui.NewTerminal()
+	if flagJSONOutput && flagPrompt != "" {
+		// stdout は JSON 結果専用にする
+		terminal.SetQuiet(true)
+	}
+	terminal.SetOutputWidth(cfg.OutputWidth)
+	if err := terminal.GetLineEditor().LoadHistory(config.HistoryPath()); err != nil {
+		terminal.PrintWarning(fmt.Sprintf("入力履歴の読み込みエラー: %v", err))
+	}
 	provider := createProviderWithChain(ctx, cfg, terminal)
 	router := createModelRouter(provider, cfg)
 	permissionMgr, validator := createSecurityComponents(cfg)
 
 	// スキルマネージャー初期化
 	skillMgr := skill.NewSkillManager()
+	skillMgr.SetGlobalDir(filepath.Join(config.GlobalDir(), "skills"))
 	if err := skillMgr.LoadSkills(); err != nil {
 		terminal.PrintColored(ui.ColorYellow, fmt.Sprintf("スキル読み込み警告: %v\n", err))
 	}
@@ -166,8 +276,15 @@ func main() {
 
 	sess := createSession(cfg, skillMgr)
 
-	// サンドボックスマネージャー（現在はファイルステージング未使用、将来拡張用）
-	var sbMgr *sandbox.Manager
+	// サンドボックスマネージャー（有効時は write_file/edit_file を .vibe-sandbox/ にステージする）
+	// /sandbox on で途中から有効にできるよう常に作成する
+	sbMgr, err := sandbox.NewManager(".", cfg.SandboxMode)
+	if err != nil {
+		terminal.PrintColored(ui.ColorYellow, fmt.Sprintf("サンドボックス初期化警告: %v\n", err))
+		sbMgr = nil
+	} else if sbMgr.IsEnabled() {
+		terminal.PrintColored(ui.ColorGreen, "✓ サンドボックスモード有効 (/diff で確認、/commit で反映)\n")
+	}
 
 	// 自動venv有効時のメッセージ
 	if cfg.AutoVenv {
@@ -175,15 +292,39 @@ func main() {
 	}
 
 	registry := createToolRegistry(terminal, permissionMgr, validator, sbMgr, cfg)
+	registry.Register(tool.NewPlanTool(sess)) // 計画はセッションに保存する
+	if cfg.Offline {
+		// プリフライトのネットワーク疎通確認より前に設定する
+		registry.SetOffline(true)
+		terminal.PrintColored(ui.ColorCyan, "✓ オフラインモード: ネットワークツールとクラウドプロバイダーは無効です\n")
+	}
+
+	// ツールの外部依存（シェル・ネットワーク）を事前チェックし、使えないツールを縮退扱いにする
+	// （ネットワークは起動を待たせないようバックグラウンドで確認し、プロキシ設定に従う）
+	degraded := registry.Preflight()
+	degradedNames := make([]string, 0, len(degraded))
+	for name := range degraded {
+		degradedNames = append(degradedNames, name)
+	}
+	sort.Strings(degradedNames)
+	for _, name := range degradedNames {
+		terminal.PrintColored(ui.ColorYellow, fmt.Sprintf("⚠ ツール %s は利用できません: %s\n", name, degraded[name]))
+	}
 
 	// MCP マネージャー初期化
 	mcpMgr := mcp.NewManager()
+	mcpMgr.SetGlobalConfigDir(config.GlobalDir())
 	if err := mcpMgr.LoadConfig(); err != nil {
 		terminal.PrintColored(ui.ColorYellow, fmt.Sprintf("MCP設定読み込み警告: %v\n", err))
 	}
 	if mcpMgr.ServerCount() > 0 {
-		terminal.PrintColored(ui.ColorCyan, fmt.Sprintf("MCP: %d 件のサーバーを起動中...\n", mcpMgr.ServerCount()))
+		terminal.PrintColored(ui.ColorCyan, fmt.Sprintf("MCP: %d 件のサーバーを起動中...", mcpMgr.ServerCount()))
+		mcpMgr.OnStartProgress(func(name string, done, total int, err error) {
+			terminal.ClearLine()
+			terminal.PrintColored(ui.ColorCyan, fmt.Sprintf("MCP: 起動中 %d/%d (%s)", done, total, name))
+		})
 		errs := mcpMgr.StartAll(ctx)
+		terminal.ClearLine()
 		for _, e := range errs {
 			terminal.PrintColored(ui.ColorYellow, fmt.Sprintf("  ⚠ %v\n", e))
 		}
@@ -202,13 +343,14 @@ func main() {
 	// Setup signal handler with shutdown manager
 	shutdownMgr := NewShutdownManager(provider, sess, persistenceMgr, terminal, cancel)
 	shutdownMgr.mcpMgr = mcpMgr
-	setupSignalHandler(shutdownMgr)
+	interrupter := ui.NewTurnInterrupter(ui.DoubleInterruptWindow)
+	setupSignalHandler(shutdownMgr, interrupter, terminal)
 
 	// パーミッション確認ダイアログ（--permission-check フラグが指定された場合）
 	if flagPermissionCheck && !cfg.AutoApprove {
 		autoApprove, err := terminal.ShowPermissionCheck()
 		if err != nil {
-			terminal.PrintColored(ui.ColorRed, fmt.Sprintf("入力エラー: %v\n", err))
+			terminal.PrintColored(ui.ColorRed, i18n.T(i18n.MsgErrInput, err))
 			os.Exit(1)
 		}
 		if autoApprove {
@@ -234,21 +376,42 @@ func main() {
 	// Show banner
 	showBanner(terminal, cfg, router, provider)
 
-	// Resume session if requested
-	if flagResume != "" {
-		resumeSession(ctx, sess, persistenceMgr, flagResume, cfg)
-	}
-
 	// Initialize agent with LLMProvider
 	agt := agent.NewAgent(provider, registry, permissionMgr, validator, sess, terminal, cfg)
+	agt.SetModelRouter(router)
+	agt.SetSkillManager(skillMgr)
+	if flagDryRun {
+		agt.SetDryRun(true)
+		terminal.PrintColored(ui.ColorYellow, "🔍 Dry-run モード: 読み取り専用以外のツールは実行せずに表示のみ行います\n")
+	}
+	if flagLogFile != "" {
+		toolLog, err := agent.OpenToolCallLogger(flagLogFile)
+		if err != nil {
+			terminal.PrintColored(ui.ColorRed, fmt.Sprintf("ツールログを開けません: %v\n", err))
+		} else {
+			agt.SetToolCallLogger(toolLog)
+			shutdownMgr.toolLog = toolLog
+		}
+	}
+	shutdownMgr.agent = agt
+	shutdownMgr.cfg = cfg
+	setupToolCancelHandler(agt, terminal)
+
+	// Resume session if requested（モード復元のためエージェント作成後に実行）
+	if flagResume != "" {
+		resumeSession(ctx, terminal, sess, persistenceMgr, flagResume, cfg, agt, permissionMgr)
+	}
 
 	// Register parallel_agents tool (requires provider + registry)
 	parallelOrch := agent.NewParallelOrchestrator(provider, registry)
 	parallelBridge := agent.NewParallelBridge(parallelOrch)
 	registry.Register(tool.NewParallelAgentsTool(parallelBridge))
 
+	// Register semantic_search tool (埋め込みは接続確認後のプロバイダーで生成)
+	registry.Register(createSemanticSearchTool(provider, cfg, permissionMgr))
+
 	// Create command handler with provider access
-	cmdHandler := createCommandHandler(terminal, provider, cfg, sbMgr, skillMgr, mcpMgr, agt)
+	cmdHandler := createCommandHandler(terminal, provider, cfg, sbMgr, skillMgr, mcpMgr, agt, registry, persistenceMgr, router)
 
 	// Process initial slash command from command line args
 	args := flag.Args()
@@ -263,7 +426,7 @@ func main() {
 	}
 
 	// Run agent
-	runAgent(ctx, agt, cfg, terminal, shutdownMgr, cmdHandler)
+	runAgent(ctx, agt, cfg, terminal, shutdownMgr, cmdHandler, interrupter)
 }
 
 func loadConfig() *config.Config {
@@ -272,6 +435,13 @@ func loadConfig() *config.Config {
 	// 1. config.json から読み込み（最低優先度）
 	cfg.ParseConfigFile()
 
+	// プロジェクト設定 (.vibe-local/config.json) は config.json より優先
+	if cwd, err := os.Getwd(); err == nil {
+		if err := cfg.ParseProjectConfig(cwd); err != nil {
+			fmt.Fprintf(os.Stderr, "⚠ プロジェクト設定を読み込めません: %v\n", err)
+		}
+	}
+
 	// 2. 環境変数で上書き
 	cfg.ParseEnv()
 
@@ -286,12 +456,19 @@ func loadConfig() *config.Config {
 			}
 		}
 	}
+	if flagOffline {
+		cfg.Offline = true
+		cfg.SetSource("OFFLINE", config.SourceFlag)
+	}
+
 	// provider未指定の場合、環境変数からプロバイダーを自動検出（優先順）
-	if flagProvider == "" && cfg.Provider == "ollama" {
+	// オフラインモードではクラウドを選ばない
+	if flagProvider == "" && cfg.Provider == "ollama" && !cfg.Offline {
 		detectOrder := []string{"openrouter", "openai", "anthropic", "google", "deepseek", "groq", "zai"}
 		for _, key := range detectOrder {
 			if cfg.CloudAPIKeys[key] != "" {
 				cfg.Provider = key
+				cfg.SetSource("PROVIDER", config.SourceEnv)
 				break
 			}
 		}
@@ -300,16 +477,24 @@ func loadConfig() *config.Config {
 	// 4. CLIフラグで上書き（最優先）
 	if flagModel != "" {
 		cfg.Model = flagModel
+		cfg.SetSource("MODEL", config.SourceFlag)
 		cfg.AutoModel = false
 	}
 	if flagSidecar != "" {
 		cfg.SidecarModel = flagSidecar
+		cfg.SetSource("SIDECAR_MODEL", config.SourceFlag)
+	}
+	if flagEmbedModel != "" {
+		cfg.EmbedModel = flagEmbedModel
+		cfg.SetSource("EMBEDDING_MODEL", config.SourceFlag)
 	}
 	if flagHost != "" {
 		cfg.OllamaHost = flagHost
+		cfg.SetSource("OLLAMA_HOST", config.SourceFlag)
 	}
 	if flagProvider != "" {
 		cfg.Provider = flagProvider
+		cfg.SetSource("PROVIDER", config.SourceFlag)
 	}
 	if flagAPIKey != "" {
 		// --api-key は現在のプロバイダーに設定
@@ -317,42 +502,125 @@ func loadConfig() *config.Config {
 	}
 	if flagMaxTokens > 0 {
 		cfg.MaxTokens = flagMaxTokens
+		cfg.SetSource("MAX_TOKENS", config.SourceFlag)
 	}
 	if flagTemperature > 0 {
 		cfg.Temperature = flagTemperature
+		cfg.SetSource("TEMPERATURE", config.SourceFlag)
 	}
 	if flagContextWindow > 0 {
 		cfg.ContextWindow = flagContextWindow
+		cfg.SetSource("CONTEXT_WINDOW", config.SourceFlag)
 	}
 	if flagNumCtx > 0 {
 		cfg.OllamaNumCtx = flagNumCtx
+		cfg.SetSource("OLLAMA_NUM_CTX", config.SourceFlag)
 	}
 	if flagNumGPU >= 0 {
 		cfg.OllamaNumGPU = flagNumGPU
+		cfg.SetSource("OLLAMA_NUM_GPU", config.SourceFlag)
 	}
 	if flagAutoConfirm {
 		cfg.AutoApprove = true
+		cfg.SetSource("AUTO_APPROVE", config.SourceFlag)
+	}
+	if flagMinimal {
+		cfg.Banner = config.BannerMinimal
+		cfg.SetSource("BANNER", config.SourceFlag)
+	}
+	if flagWidth > 0 {
+		cfg.OutputWidth = flagWidth
+		cfg.SetSource("OUTPUT_WIDTH", config.SourceFlag)
+	}
+	if flagRetryBudget > 0 {
+		cfg.RetryBudget = flagRetryBudget
+		cfg.SetSource("RETRY_BUDGET", config.SourceFlag)
+	}
+	if flagBudgetTokens > 0 {
+		cfg.BudgetTokens = flagBudgetTokens
+		cfg.SetSource("BUDGET_TOKENS", config.SourceFlag)
+	}
+	if flagToolOutputMax > 0 {
+		cfg.ToolOutputMaxBytes = flagToolOutputMax
+		cfg.SetSource("TOOL_OUTPUT_MAX_BYTES", config.SourceFlag)
+	}
+	if flagNoNetwork {
+		cfg.NoNetwork = true
+		cfg.SetSource("NO_NETWORK", config.SourceFlag)
+	}
+	if flagAllowDangerous {
+		cfg.AllowDangerous = true
+		cfg.SetSource("ALLOW_DANGEROUS", config.SourceFlag)
+	}
+	if flagAllowOutside {
+		cfg.AllowOutsideWorkdir = true
+		cfg.SetSource("ALLOW_OUTSIDE_WORKDIR", config.SourceFlag)
+	}
+	if flagNoVibeIgnore {
+		cfg.NoVibeIgnore = true
+		cfg.SetSource("NO_VIBEIGNORE", config.SourceFlag)
+	}
+	if flagAutoTestCmd != "" {
+		cfg.AutoTestCommand = flagAutoTestCmd
+		cfg.SetSource("AUTOTEST_COMMAND", config.SourceFlag)
+	}
+	if flagSystemPrompt != "" {
+		cfg.SystemPromptFile = flagSystemPrompt
+		cfg.SetSource("SYSTEM_PROMPT_FILE", config.SourceFlag)
+	}
+	if flagSystemPromptMode != "" {
+		cfg.SystemPromptMode = flagSystemPromptMode
+		cfg.SetSource("SYSTEM_PROMPT_MODE", config.SourceFlag)
+	}
+	if err := config.LoadSystemPromptFile(cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "✗ システムプロンプトファイルを読み込めません: %v\n", err)
+		os.Exit(1)
+	}
+	if flagLang != "" {
+		cfg.Lang = flagLang
+		cfg.SetSource("LANG", config.SourceFlag)
+	}
+	if flagCompactAt != 0 {
+		if flagCompactAt > 0 && flagCompactAt <= 1 {
+			cfg.CompactThreshold = flagCompactAt
+			cfg.SetSource("COMPACT_THRESHOLD", config.SourceFlag)
+		} else {
+			fmt.Fprintf(os.Stderr, "⚠ --compact-at は 0 より大きく 1 以下の値を指定してください (指定値: %g)。デフォルトを使います\n", flagCompactAt)
+		}
+	}
+	if flagNoAutoCompact {
+		cfg.NoAutoCompact = true
+		cfg.SetSource("NO_AUTO_COMPACT", config.SourceFlag)
+	}
+	if flagNoBanner || (flagJSONOutput && flagPrompt != "") {
+		cfg.Banner = config.BannerNone
+		cfg.SetSource("BANNER", config.SourceFlag)
 	}
 	if flagSandbox {
 		cfg.SandboxMode = true
+		cfg.SetSource("SANDBOX", config.SourceFlag)
 	}
 	if flagAutoVenv {
 		cfg.AutoVenv = true
+		cfg.SetSource("AUTO_VENV", config.SourceFlag)
 	}
 	if flagVenvDir != ".venv" {
 		cfg.VenvDir = flagVenvDir
+		cfg.SetSource("VENV_DIR", config.SourceFlag)
 	}
 
 	// 5. モデル自動選択（明示指定がない場合のみ）
 	memoryGB := getMemoryGB()
 	if cfg.AutoModel && cfg.Provider == "ollama" {
 		cfg.Model = config.RecommendModel(memoryGB)
+		cfg.SetSource("MODEL", config.SourceAuto)
 	}
 	// クラウドプロバイダーのデフォルトモデル
 	if cfg.Provider != "ollama" && cfg.Model == "" {
 		def := llm.GetCloudProviderDef(cfg.Provider)
 		if def != nil {
 			cfg.Model = def.DefaultModel
+			cfg.SetSource("MODEL", config.SourceAuto)
 		}
 	}
 
@@ -368,6 +636,11 @@ func createProvider(cfg *config.Config) llm.LLMProvider {
 	case "openrouter", "openai", "anthropic", "google",
 		"deepseek", "mistral", "groq", "together", "fireworks",
 		"perplexity", "cohere", "zai", "zai-coding", "zhipu", "moonshot":
+		if cfg.Offline {
+			fmt.Print(i18n.T(i18n.MsgProviderOfflineCloud, cfg.Provider))
+			fmt.Print(i18n.T(i18n.MsgProviderOfflineHint))
+			os.Exit(1)
+		}
 		apiKey := getAPIKeyForProvider(cfg)
 		if apiKey == "" {
 			def := llm.GetCloudProviderDef(cfg.Provider)
@@ -375,10 +648,11 @@ func createProvider(cfg *config.Config) llm.LLMProvider {
 			if def != nil {
 				envName = def.EnvKey
 			}
-			fmt.Printf("エラー: %s を使用するにはAPIキーが必要です\n", cfg.Provider)
-			fmt.Printf("  --api-key <key> または %s 環境変数を設定してください\n", envName)
+			fmt.Print(i18n.T(i18n.MsgProviderAPIKeyMissing, cfg.Provider))
+			fmt.Print(i18n.T(i18n.MsgProviderAPIKeyHint, envName))
 			os.Exit(1)
 		}
+		cfg.Model = normalizeCloudModel(cfg.Provider, cfg.Model)
 		return llm.NewCloudProvider(cfg.Provider, apiKey, cfg.Model)
 	case "ollama", "lm-studio", "llama-server":
 		// ローカルプロバイダー
@@ -402,6 +676,7 @@ func createProvider(cfg *config.Config) llm.LLMProvider {
 			if cfg.OllamaNumCtx > 0 {
 				p.SetNumCtx(cfg.OllamaNumCtx)
 			}
+			p.SetNumGPU(cfg.OllamaNumGPU)
 			return p
 		}
 		if cfg.Provider == "lm-studio" {
@@ -426,14 +701,33 @@ func createProvider(cfg *config.Config) llm.LLMProvider {
 		if cfg.OllamaNumCtx > 0 {
 			p.SetNumCtx(cfg.OllamaNumCtx)
 		}
+		p.SetNumGPU(cfg.OllamaNumGPU)
 		return p
 	}
 }
 
+// normalizeCloudModel は略称モデル名（gpt4, claude-sonnet 等）を正式IDに置き換える
+// 推測で置き換えた場合は stderr に警告を出す（--json-output でも stdout を汚さない）
+func normalizeCloudModel(providerKey, model string) string {
+	canonical, guessed := llm.NormalizeModelName(providerKey, model)
+	if guessed {
+		fmt.Fprint(os.Stderr, i18n.T(i18n.MsgModelNormalized, model, canonical))
+	}
+	return canonical
+}
+
 // createProviderWithChain ゼロコンフィグ対応のプロバイダー作成
 // プロバイダーが未指定の場合は AutoDetect → ProviderChain を構築
 // 指定されている場合はクラウドフォールバック付きチェーンを構築
 func createProviderWithChain(ctx context.Context, cfg *config.Config, terminal *ui.Terminal) llm.LLMProvider {
+	// CHAIN で順序が固定されている場合は自動構築しない
+	if len(cfg.Chain) > 0 {
+		if chain := createPinnedChain(cfg, terminal); chain != nil {
+			return chain
+		}
+		terminal.PrintColored(ui.ColorYellow, "⚠ CHAIN に使えるプロバイダーがないため、通常の方法で接続します\n")
+	}
+
 	// 明示的にプロバイダーが指定されている場合
 	if cfg.Provider != "" {
 		mainProvider := createProvider(cfg)
@@ -441,32 +735,35 @@ func createProviderWithChain(ctx context.Context, cfg *config.Config, terminal *
 	}
 
 	// ゼロコンフィグ: ローカルサーバーを自動検出
-	terminal.PrintColored(ui.ColorCyan, "🔍 LLMプロバイダーを自動検出中...\n")
+	terminal.PrintColored(ui.ColorCyan, i18n.T(i18n.MsgProviderDetecting))
 	detected := llm.AutoDetect(ctx)
 
 	if len(detected) == 0 {
 		// 検出できなかった場合はクラウドAPIキーをチェック
 		cloudProvider := detectCloudFromEnv(cfg)
 		if cloudProvider != nil {
-			terminal.PrintColored(ui.ColorGreen, fmt.Sprintf("✓ クラウドプロバイダー検出: %s\n", cloudProvider.Info().Name))
+			terminal.PrintColored(ui.ColorGreen, i18n.T(i18n.MsgProviderCloudDetected, cloudProvider.Info().Name))
 			return cloudProvider
 		}
 		// 何も見つからない → デフォルトの Ollama で進む（接続チェックで再設定可能）
-		terminal.PrintColored(ui.ColorYellow, "⚠ LLMプロバイダーが見つかりません。デフォルト(Ollama)で接続を試みます\n")
+		terminal.PrintColored(ui.ColorYellow, i18n.T(i18n.MsgProviderNoneFound))
 		return createProvider(cfg)
 	}
 
 	// 検出されたプロバイダーからメインを選択
 	best := detected[0]
-	terminal.PrintColored(ui.ColorGreen, fmt.Sprintf("✓ %s 検出 (%s, モデル: %d件)\n",
+	terminal.PrintColored(ui.ColorGreen, i18n.T(i18n.MsgProviderDetected,
 		best.Name, best.URL, len(best.Models)))
 
 	// cfg にセット（以降の処理で参照されるため）
 	cfg.Provider = best.Name
+	cfg.SetSource("PROVIDER", config.SourceAuto)
 	if cfg.Model == "" && len(best.Models) > 0 {
 		cfg.Model = best.Models[0]
+		cfg.SetSource("MODEL", config.SourceAuto)
 	}
 	cfg.OllamaHost = best.URL
+	cfg.SetSource("OLLAMA_HOST", config.SourceAuto)
 
 	// メインプロバイダー作成
 	mainProvider := createProvider(cfg)
@@ -477,15 +774,8 @@ func createProviderWithChain(ctx context.Context, cfg *config.Config, terminal *
 	// 他のローカルプロバイダーをサブとして追加
 	for i := 1; i < len(detected); i++ {
 		d := detected[i]
-		subCfg := *cfg
-		subCfg.Provider = d.Name
-		subCfg.OllamaHost = d.URL
-		if len(d.Models) > 0 {
-			subCfg.Model = d.Models[0]
-		}
-		subProvider := createProvider(&subCfg)
-		chain.AddProvider(subProvider, llm.RoleSub)
-		terminal.PrintColored(ui.ColorCyan, fmt.Sprintf("  + %s (%s) をサブプロバイダーに追加\n", d.Name, d.URL))
+		chain.AddProvider(createDetectedProvider(cfg, d), llm.RoleSub)
+		terminal.PrintColored(ui.ColorCyan, i18n.T(i18n.MsgProviderSubAdded, d.Name, d.URL))
 	}
 
 	// クラウドフォールバックを追加
@@ -503,6 +793,89 @@ func createProviderWithChain(ctx context.Context, cfg *config.Config, terminal *
 	return mainProvider
 }
 
+// createPinnedChain config.json の CHAIN の順にプロバイダーチェーンを構築する
+// 先頭のプロバイダーが cfg のプロバイダー・モデルになる（使えるものがなければ nil）
+func createPinnedChain(cfg *config.Config, terminal *ui.Terminal) llm.LLMProvider {
+	members, warnings := cfg.ChainMembers(cfg.GetProviderProfiles(), chainProviderType)
+	for _, w := range warnings {
+		terminal.PrintColored(ui.ColorYellow, "⚠ "+w+"\n")
+	}
+	if len(members) == 0 {
+		return nil
+	}
+
+	byKey := make(map[string]*config.Config, len(members))
+	keys := make([]string, len(members))
+	for i, m := range members {
+		if m.Model == "" {
+			m.Model = defaultModelForProvider(m.Provider)
+		}
+		byKey[m.Provider] = m
+		keys[i] = m.Provider
+	}
+
+	chain := llm.NewPinnedChain(keys, func(key string) llm.LLMProvider {
+		return createProvider(byKey[key])
+	})
+
+	// 以降の処理（モデル切替・表示・保存）はメインプロバイダーの設定を参照する
+	primary := byKey[keys[0]]
+	cfg.Provider = primary.Provider
+	cfg.Model = primary.Model
+	cfg.AutoModel = false
+	if chainProviderType(primary.Provider) == config.ProviderTypeLocal {
+		cfg.OllamaHost = primary.OllamaHost
+		if def := llm.GetLocalProviderDef(primary.Provider); cfg.OllamaHost == "" && def != nil {
+			cfg.OllamaHost = def.DefaultHost
+		}
+	} else {
+		setAPIKeyForProvider(cfg, primary.Provider, primary.CloudAPIKeys[primary.Provider])
+	}
+	cfg.SetSource("PROVIDER", config.SourceConfig)
+	cfg.SetSource("MODEL", config.SourceConfig)
+
+	terminal.PrintColored(ui.ColorCyan, fmt.Sprintf("✓ CHAIN: %s\n", strings.Join(keys, " → ")))
+	chain.SetFallbackCallback(func(from, to string, class llm.ErrorClassification) {
+		msg := llm.ErrorMessage(class, from, to)
+		terminal.PrintColored(ui.ColorYellow, msg+"\n")
+	})
+	return chain
+}
+
+// chainProviderType CHAIN の検証用に createProvider が扱えるプロバイダーの種別を返す（"" = 未知）
+func chainProviderType(key string) string {
+	switch key {
+	case "ollama", "lm-studio", "llama-server":
+		return config.ProviderTypeLocal
+	}
+	if llm.GetCloudProviderDef(key) != nil {
+		return config.ProviderTypeCloud
+	}
+	return ""
+}
+
+// defaultModelForProvider プロバイダー定義の既定モデルを返す
+func defaultModelForProvider(key string) string {
+	if def := llm.GetCloudProviderDef(key); def != nil {
+		return def.DefaultModel
+	}
+	if def := llm.GetLocalProviderDef(key); def != nil {
+		return def.DefaultModel
+	}
+	return ""
+}
+
+// createDetectedProvider 自動検出されたローカルプロバイダーを作成（モデルは検出された先頭のもの）
+func createDetectedProvider(cfg *config.Config, d llm.DetectedProvider) llm.LLMProvider {
+	subCfg := *cfg
+	subCfg.Provider = d.Name
+	subCfg.OllamaHost = d.URL
+	if len(d.Models) > 0 {
+		subCfg.Model = d.Models[0]
+	}
+	return createProvider(&subCfg)
+}
+
 // buildChainWithFallbacks 既存プロバイダーにクラウドフォールバックを付けたチェーンを構築
 func buildChainWithFallbacks(mainProvider llm.LLMProvider, cfg *config.Config, terminal *ui.Terminal) llm.LLMProvider {
 	chain := llm.NewProviderChain(mainProvider)
@@ -527,7 +900,7 @@ func buildChainWithFallbacks(mainProvider llm.LLMProvider, cfg *config.Config, t
 
 // addCloudFallbackToChain 環境変数からクラウドフォールバックを追加
 func addCloudFallbackToChain(chain *llm.ProviderChain, cfg *config.Config, terminal *ui.Terminal) {
-	if cfg.CloudAPIKeys == nil {
+	if cfg.CloudAPIKeys == nil || cfg.Offline {
 		return
 	}
 	// 優先順: openai → anthropic → google → deepseek
@@ -553,7 +926,7 @@ func addCloudFallbackToChain(chain *llm.ProviderChain, cfg *config.Config, termi
 
 // detectCloudFromEnv 環境変数からクラウドプロバイダーを検出
 func detectCloudFromEnv(cfg *config.Config) llm.LLMProvider {
-	if cfg.CloudAPIKeys == nil {
+	if cfg.CloudAPIKeys == nil || cfg.Offline {
 		return nil
 	}
 	// 優先順位で最初に見つかったものを使用
@@ -565,6 +938,7 @@ func detectCloudFromEnv(cfg *config.Config) llm.LLMProvider {
 			if cfg.Model == "" && def != nil {
 				cfg.Model = def.DefaultModel
 			}
+			cfg.Model = normalizeCloudModel(name, cfg.Model)
 			return llm.NewCloudProvider(name, apiKey, cfg.Model)
 		}
 	}
@@ -590,29 +964,33 @@ func setAPIKeyForProvider(cfg *config.Config, provider, apiKey string) {
 func createModelRouter(provider llm.LLMProvider, cfg *config.Config) *llm.ModelRouter {
 	var sidecarProvider llm.LLMProvider
 	if cfg.SidecarModel != "" {
-		// サイドカーも同じホストで別モデル
-		// ローカルプロバイダーの場合はホストを取得
-		host := cfg.OllamaHost
-		if cfg.Provider == "ollama" || cfg.Provider == "lm-studio" || cfg.Provider == "llama-server" {
-			if def := llm.GetLocalProviderDef(cfg.Provider); def != nil {
-				profiles := cfg.GetProviderProfiles()
-				if profiles != nil {
-					if p, ok := profiles[cfg.Provider]; ok && p.Host != "" {
-						host = p.Host
-					}
-				}
-				if host == "" {
-					host = def.DefaultHost
+		sidecarProvider = newSidecarProvider(cfg, cfg.SidecarModel)
+	}
+
+	return llm.NewModelRouter(provider, sidecarProvider, cfg.Model, cfg.SidecarModel)
+}
+
+// newSidecarProvider サイドカー用のプロバイダーを作成する
+func newSidecarProvider(cfg *config.Config, model string) llm.LLMProvider {
+	// サイドカーも同じホストで別モデル
+	// ローカルプロバイダーの場合はホストを取得
+	host := cfg.OllamaHost
+	if cfg.Provider == "ollama" || cfg.Provider == "lm-studio" || cfg.Provider == "llama-server" {
+		if def := llm.GetLocalProviderDef(cfg.Provider); def != nil {
+			profiles := cfg.GetProviderProfiles()
+			if profiles != nil {
+				if p, ok := profiles[cfg.Provider]; ok && p.Host != "" {
+					host = p.Host
 				}
 			}
-			sidecarProvider = llm.NewOllamaProvider(host, cfg.SidecarModel)
-		} else {
-			// クラウドプロバイダーの場合
-			sidecarProvider = llm.NewOllamaProvider(cfg.OllamaHost, cfg.SidecarModel)
+			if host == "" {
+				host = def.DefaultHost
+			}
 		}
+		return llm.NewOllamaProvider(host, model)
 	}
-
-	return llm.NewModelRouter(provider, sidecarProvider, cfg.Model, cfg.SidecarModel)
+	// クラウドプロバイダーの場合
+	return llm.NewOllamaProvider(cfg.OllamaHost, model)
 }
 
 func createSecurityComponents(cfg *config.Config) (*security.PermissionManager, *security.PathValidator) {
@@ -628,6 +1006,11 @@ func createSecurityComponents(cfg *config.Config) (*security.PermissionManager,
 		os.Exit(1)
 	}
 
+	// シンボリックリンクを解決しておく（Validate は解決後のパスで比較する）
+	if resolved, err := filepath.EvalSymlinks(wd); err == nil {
+		wd = resolved
+	}
+
 	validator := security.NewPathValidator(wd)
 	return permMgr, validator
 }
@@ -651,7 +1034,7 @@ func createSession(cfg *config.Config, skillMgr *skill.SkillManager) *session.Se
 	return sess
 }
 
-func createCommandHandler(terminal *ui.Terminal, provider llm.LLMProvider, cfg *config.Config, sbMgr *sandbox.Manager, skillMgr *skill.SkillManager, mcpMgr *mcp.Manager, agt *agent.Agent) *ui.CommandHandler {
+func createCommandHandler(terminal *ui.Terminal, provider llm.LLMProvider, cfg *config.Config, sbMgr *sandbox.Manager, skillMgr *skill.SkillManager, mcpMgr *mcp.Manager, agt *agent.Agent, registry *tool.Registry, persistenceMgr *session.PersistenceManager, router *llm.ModelRouter) *ui.CommandHandler {
 	cmdHandler := ui.NewCommandHandler(terminal)
 
 	cmdHandler.Register(&ui.SlashCommand{
@@ -665,10 +1048,10 @@ func createCommandHandler(terminal *ui.Terminal, provider llm.LLMProvider, cfg *
 				return nil
 			}
 
-			terminal.PrintColored(ui.ColorCyan, "利用可能なモデルを取得中...\n")
+			terminal.PrintColored(ui.ColorCyan, i18n.T(i18n.MsgModelFetching))
 			models, err := mm.ListModels(context.Background())
 			if err != nil {
-				terminal.PrintColored(ui.ColorRed, fmt.Sprintf("モデル一覧取得エラー: %v\n", err))
+				terminal.PrintColored(ui.ColorRed, i18n.T(i18n.MsgModelListError, err))
 				return nil
 			}
 
@@ -678,7 +1061,7 @@ func createCommandHandler(terminal *ui.Terminal, provider llm.LLMProvider, cfg *
 				return nil
 			}
 
-			terminal.PrintColored(ui.ColorGreen, fmt.Sprintf("%d 件のモデルが見つかりました:\n", len(models)))
+			terminal.PrintColored(ui.ColorGreen, i18n.T(i18n.MsgModelFound, len(models)))
 			currentModel := cfg.Model
 			for i, model := range models {
 				marker := ""
@@ -722,7 +1105,7 @@ func createCommandHandler(terminal *ui.Terminal, provider llm.LLMProvider, cfg *
 				}
 			}
 
-			terminal.PrintColored(ui.ColorGreen, fmt.Sprintf("✓ モデルを %s に切り替えました\n", selectedModel))
+			terminal.PrintColored(ui.ColorGreen, i18n.T(i18n.MsgModelSwitched, selectedModel))
 			return nil
 		},
 	})
@@ -730,17 +1113,26 @@ func createCommandHandler(terminal *ui.Terminal, provider llm.LLMProvider, cfg *
 	// /model コマンドを登録（モデル表示/直接切替）
 	cmdHandler.Register(&ui.SlashCommand{
 		Name:        "model",
-		Description: "現在のモデル表示 / モデル名指定で切替",
+		Description: "現在のモデル表示 / モデル名指定で切替 / info で詳細",
 		Handler: func(args string) error {
 			currentModel := cfg.Model
 			if args == "" {
 				// 引数なし: 現在のモデルを表示
-				terminal.PrintColored(ui.ColorCyan, fmt.Sprintf("現在のモデル: %s\n", currentModel))
+				terminal.PrintColored(ui.ColorCyan, i18n.T(i18n.MsgModelCurrent, currentModel))
 				terminal.Println("切り替え: /model <モデル名>  または  /models で一覧から選択")
+				terminal.Println("詳細: /model info")
+				return nil
+			}
+			if strings.TrimSpace(args) == "info" {
+				showModelInfo(terminal, provider, cfg)
 				return nil
 			}
 
 			newModel := strings.TrimSpace(args)
+			if canonical, guessed := llm.NormalizeModelName(cfg.Provider, newModel); guessed {
+				terminal.PrintColored(ui.ColorYellow, i18n.T(i18n.MsgModelNormalized, newModel, canonical))
+				newModel = canonical
+			}
 			if newModel == currentModel {
 				terminal.PrintColored(ui.ColorYellow, fmt.Sprintf("既に %s を使用中です\n", newModel))
 				return nil
@@ -753,7 +1145,8 @@ func createCommandHandler(terminal *ui.Terminal, provider llm.LLMProvider, cfg *
 					terminal.PrintColored(ui.ColorYellow, fmt.Sprintf("モデル確認中にエラー: %v\n", err))
 					// エラーでも切り替えは許可
 				} else if !exists {
-					terminal.PrintColored(ui.ColorYellow, fmt.Sprintf("モデル '%s' が見つかりません\n", newModel))
+					terminal.PrintColored(ui.ColorYellow, i18n.T(i18n.MsgModelNotFound, newModel))
+					printModelSuggestions(context.Background(), mm, newModel, terminal)
 					terminal.Println("利用可能なモデルは /models で確認できます")
 					return nil
 				}
@@ -773,7 +1166,7 @@ func createCommandHandler(terminal *ui.Terminal, provider llm.LLMProvider, cfg *
 				}
 			}
 
-			terminal.PrintColored(ui.ColorGreen, fmt.Sprintf("✓ モデルを %s に切り替えました\n", newModel))
+			terminal.PrintColored(ui.ColorGreen, i18n.T(i18n.MsgModelSwitched, newModel))
 			return nil
 		},
 	})
@@ -852,7 +1245,7 @@ func createCommandHandler(terminal *ui.Terminal, provider llm.LLMProvider, cfg *
 		Handler: func(args string) error {
 			profiles := cfg.GetProviderProfiles()
 			if profiles == nil || len(profiles) == 0 {
-				terminal.PrintColored(ui.ColorYellow, "切替可能なプロバイダーが登録されていません\n")
+				terminal.PrintColored(ui.ColorYellow, i18n.T(i18n.MsgProviderNoSwitchable))
 				terminal.Println("先に /provider add でプロバイダーを追加してください")
 				return nil
 			}
@@ -870,26 +1263,47 @@ func createCommandHandler(terminal *ui.Terminal, provider llm.LLMProvider, cfg *
 	registerSandboxCommands(cmdHandler, terminal, sbMgr)
 
 	// スキルコマンドを登録
-	registerSkillCommands(cmdHandler, terminal, skillMgr)
+	registerSkillCommands(cmdHandler, terminal, skillMgr, agt)
 
 	// MCPコマンドを登録
 	registerMCPCommands(cmdHandler, terminal, mcpMgr)
 
+	// /tools コマンドを登録
+	registerToolsCommands(cmdHandler, terminal, registry)
+
 	// AutoTestコマンドを登録
-	registerAutoTestCommands(cmdHandler, terminal, agt)
+	registerAutoTestCommands(cmdHandler, terminal, agt, cfg)
 
 	// Planコマンドを登録
 	registerPlanCommands(cmdHandler, terminal, agt)
+	registerDryRunCommands(cmdHandler, terminal, agt)
+	registerLoopDetectCommands(cmdHandler, terminal, agt)
 
 	// /providers ステータスコマンドを登録
-	registerProvidersStatusCommand(cmdHandler, terminal, provider)
+	registerProvidersStatusCommand(cmdHandler, terminal, provider, cfg)
 
 	// Watchコマンドを登録
-	registerWatchCommands(cmdHandler, terminal, agt)
+	registerWatchCommands(cmdHandler, terminal, agt, registry)
 
 	// Chain コマンドを登録
 	registerChainCommands(cmdHandler, terminal, provider)
 
+	// Undo コマンドを登録
+	registerUndoCommands(cmdHandler, terminal, registry)
+	registerSearchCommands(cmdHandler, terminal, persistenceMgr)
+	registerSummarizeCommands(cmdHandler, terminal, agt)
+	registerCompactCommands(cmdHandler, terminal, agt)
+	registerStatusCommands(cmdHandler, terminal, cfg, router, provider, agt)
+	registerSidecarCommands(cmdHandler, terminal, cfg, router)
+	registerOllamaCommands(cmdHandler, terminal, provider, cfg)
+	registerSnapshotCommands(cmdHandler, terminal, agt)
+	registerChoicesCommands(cmdHandler, terminal, agt)
+	registerSaveOutputCommands(cmdHandler, terminal)
+	registerTraceCommands(cmdHandler, terminal, agt)
+	registerRetryCommands(cmdHandler, terminal, agt)
+	registerExportCommands(cmdHandler, terminal, agt, cfg)
+	registerUsageCommands(cmdHandler, terminal, agt, cfg)
+
 	// タブ補完候補をLineEditorに設定
 	terminal.GetLineEditor().SetCompletions(cmdHandler.CommandNames())
 
@@ -905,10 +1319,14 @@ func registerConfigCommands(cmdHandler *ui.CommandHandler, terminal *ui.Terminal
 			args = strings.TrimSpace(args)
 
 			switch {
+			case args == "effective":
+				// /config effective — 解決済みの各設定値と出どころを表示
+				showEffectiveConfig(terminal, cfg)
+
 			case args == "save":
 				// /config save — 現在の設定を config.json に保存
 				if err := cfg.SaveConfigFile(); err != nil {
-					terminal.PrintColored(ui.ColorRed, fmt.Sprintf("設定保存エラー: %v\n", err))
+					terminal.PrintColored(ui.ColorRed, i18n.T(i18n.MsgErrConfigSave, err))
 					return nil
 				}
 				terminal.PrintColored(ui.ColorGreen, fmt.Sprintf("✓ 設定を保存しました: %s\n", config.GetConfigFilePath()))
@@ -925,7 +1343,7 @@ func registerConfigCommands(cmdHandler *ui.CommandHandler, terminal *ui.Terminal
 				}
 				profile, ok := profiles[name]
 				if !ok {
-					terminal.PrintColored(ui.ColorRed, fmt.Sprintf("プロバイダー '%s' が見つかりません。\n", name))
+					terminal.PrintColored(ui.ColorRed, i18n.T(i18n.MsgProviderNotFound, name))
 					terminal.Println("設定済みプロバイダー:")
 					for pName := range profiles {
 						marker := ""
@@ -990,6 +1408,7 @@ func registerConfigCommands(cmdHandler *ui.CommandHandler, terminal *ui.Terminal
 				terminal.Printf("  設定ファイル: %s\n", config.GetConfigFilePath())
 				terminal.Print("\n")
 				terminal.Println("使い方:")
+				terminal.Println("  /config effective         — 各設定値と出どころを表示")
 				terminal.Println("  /config save              — 現在の設定をconfig.jsonに保存")
 				terminal.Println("  /config provider <name>   — プロバイダー詳細を表示")
 				terminal.Println("  /provider                 — プロバイダー管理（追加・切替・削除）")
@@ -999,6 +1418,26 @@ func registerConfigCommands(cmdHandler *ui.CommandHandler, terminal *ui.Terminal
 	})
 }
 
+// showEffectiveConfig は解決済みの設定値を出どころ（default/config/env/flag など）付きで表示する
+func showEffectiveConfig(terminal *ui.Terminal, cfg *config.Config) {
+	terminal.PrintColored(ui.ColorCyan, "━━━ 有効な設定 ━━━\n")
+	for _, s := range cfg.EffectiveSettings() {
+		value := s.Value
+		if value == "" {
+			value = "-"
+		}
+		color := ui.ColorGray
+		if s.Source != config.SourceDefault {
+			color = ui.ColorGreen
+		}
+		terminal.Printf("  %-22s %-30s ", s.Key, value)
+		terminal.PrintColored(color, fmt.Sprintf("[%s]\n", s.Source))
+	}
+	terminal.Print("\n")
+	terminal.Println("優先度: default < config < env < flag（auto = 自動選択、runtime = 起動後に変更）")
+	terminal.Printf("設定ファイル: %s\n", config.GetConfigFilePath())
+}
+
 // registerProviderCommands はプロバイダー管理のスラッシュコマンドを登録する
 func registerProviderCommands(cmdHandler *ui.CommandHandler, terminal *ui.Terminal, cfg *config.Config) {
 	cmdHandler.Register(&ui.SlashCommand{
@@ -1112,7 +1551,7 @@ func providerMenu(cfg *config.Config, terminal *ui.Terminal) error {
 					return err
 				}
 			} else {
-				terminal.PrintColored(ui.ColorYellow, "切替可能なプロバイダーが登録されていません\n")
+				terminal.PrintColored(ui.ColorYellow, i18n.T(i18n.MsgProviderNoSwitchable))
 			}
 		case "e":
 			if len(registered) > 0 {
@@ -1167,13 +1606,13 @@ func providerAdd(cfg *config.Config, terminal *ui.Terminal) error {
 	switch choice {
 	case "1":
 		if switchToCloudProvider(cfg, terminal) {
-			terminal.PrintColored(ui.ColorGreen, "✓ プロバイダーが追加されました\n")
-			terminal.PrintColored(ui.ColorYellow, "注意: 新しいプロバイダーで接続するには再起動が必要です\n")
+			terminal.PrintColored(ui.ColorGreen, i18n.T(i18n.MsgProviderAdded))
+			terminal.PrintColored(ui.ColorYellow, i18n.T(i18n.MsgConnRestartNeeded))
 		}
 	case "2":
 		if addLocalProvider(cfg, terminal) {
-			terminal.PrintColored(ui.ColorGreen, "✓ プロバイダーが追加されました\n")
-			terminal.PrintColored(ui.ColorYellow, "注意: 新しいプロバイダーで接続するには再起動が必要です\n")
+			terminal.PrintColored(ui.ColorGreen, i18n.T(i18n.MsgProviderAdded))
+			terminal.PrintColored(ui.ColorYellow, i18n.T(i18n.MsgConnRestartNeeded))
 		}
 	case "3", "":
 		// 戻る
@@ -1203,7 +1642,7 @@ func addLocalProvider(cfg *config.Config, terminal *ui.Terminal) bool {
 
 	choiceStr, err := terminal.ReadLine(fmt.Sprintf("選択 [1-%d]: ", len(providers)+1))
 	if err != nil {
-		terminal.PrintColored(ui.ColorRed, fmt.Sprintf("入力エラー: %v\n", err))
+		terminal.PrintColored(ui.ColorRed, i18n.T(i18n.MsgErrInput, err))
 		return false
 	}
 
@@ -1249,7 +1688,7 @@ func addLocalProvider(cfg *config.Config, terminal *ui.Terminal) bool {
 
 		models, err := llm.FetchLocalProviderModels(host, selectedDef.Key)
 		if err != nil {
-			terminal.PrintColored(ui.ColorRed, fmt.Sprintf("モデルリスト取得エラー: %v\n", err))
+			terminal.PrintColored(ui.ColorRed, i18n.T(i18n.MsgModelListError, err))
 			terminal.Print("手動入力に切り替えます\n")
 			model, err = terminal.ReadLine(fmt.Sprintf("モデル名 [デフォルト: %s]: ", selectedDef.DefaultModel))
 			if err != nil {
@@ -1259,7 +1698,7 @@ func addLocalProvider(cfg *config.Config, terminal *ui.Terminal) bool {
 				model = selectedDef.DefaultModel
 			}
 		} else if len(models) == 0 {
-			terminal.PrintColored(ui.ColorYellow, "利用可能なモデルが見つかりませんでした\n")
+			terminal.PrintColored(ui.ColorYellow, i18n.T(i18n.MsgModelNoneAvailable))
 			terminal.Print("手動入力に切り替えます\n")
 			model, err = terminal.ReadLine(fmt.Sprintf("モデル名 [デフォルト: %s]: ", selectedDef.DefaultModel))
 			if err != nil {
@@ -1347,7 +1786,7 @@ func providerSwitch(cfg *config.Config, terminal *ui.Terminal, key string) error
 
 	profile, ok := profiles[key]
 	if !ok {
-		terminal.PrintColored(ui.ColorRed, fmt.Sprintf("プロバイダー '%s' が見つかりません\n", key))
+		terminal.PrintColored(ui.ColorRed, i18n.T(i18n.MsgProviderNotFound, key))
 		return nil
 	}
 
@@ -1463,7 +1902,7 @@ func providerEdit(cfg *config.Config, terminal *ui.Terminal, key string) error {
 
 	profile, ok := profiles[key]
 	if !ok {
-		terminal.PrintColored(ui.ColorRed, fmt.Sprintf("プロバイダー '%s' が見つかりません\n", key))
+		terminal.PrintColored(ui.ColorRed, i18n.T(i18n.MsgProviderNotFound, key))
 		return nil
 	}
 
@@ -1580,7 +2019,7 @@ func providerEdit(cfg *config.Config, terminal *ui.Terminal, key string) error {
 			terminal.PrintColored(ui.ColorCyan, "モデルリストを取得中...\n")
 			models, err := llm.FetchLocalProviderModels(host, key)
 			if err != nil {
-				terminal.PrintColored(ui.ColorRed, fmt.Sprintf("モデルリスト取得エラー: %v\n", err))
+				terminal.PrintColored(ui.ColorRed, i18n.T(i18n.MsgModelListError, err))
 				terminal.Print("手動入力に切り替えます\n")
 				custom, _ := terminal.ReadLine("  モデル名: ")
 				custom = strings.TrimSpace(custom)
@@ -1588,7 +2027,7 @@ func providerEdit(cfg *config.Config, terminal *ui.Terminal, key string) error {
 					profile.Model = custom
 				}
 			} else if len(models) == 0 {
-				terminal.PrintColored(ui.ColorYellow, "利用可能なモデルが見つかりませんでした\n")
+				terminal.PrintColored(ui.ColorYellow, i18n.T(i18n.MsgModelNoneAvailable))
 				terminal.Print("手動入力に切り替えます\n")
 				custom, _ := terminal.ReadLine("  モデル名: ")
 				custom = strings.TrimSpace(custom)
@@ -1652,10 +2091,40 @@ func providerEdit(cfg *config.Config, terminal *ui.Terminal, key string) error {
 		profile.Model = checkAndPullOllamaModel(host, profile.Model, terminal)
 	}
 
+	// --- LLMパラメータ編集（未設定 = グローバル設定を使う）---
+	var clearedMaxTokens, clearedTemperature bool
+	terminal.Printf("  現在の max_tokens: %s\n", profileParamLabel(profile.MaxTokens > 0, strconv.Itoa(profile.MaxTokens)))
+	if input, _ := terminal.ReadLine("  新しい max_tokens (0 = グローバル設定, 変更しない場合は空Enter): "); strings.TrimSpace(input) != "" {
+		if n, err := config.ParseProfileMaxTokens(input); err != nil {
+			terminal.PrintColored(ui.ColorRed, fmt.Sprintf("  %v（変更しません）\n", err))
+		} else {
+			clearedMaxTokens = n == 0 && profile.MaxTokens > 0
+			profile.MaxTokens = n
+		}
+	}
+	currentTemperature := ""
+	if profile.Temperature != nil {
+		currentTemperature = strconv.FormatFloat(*profile.Temperature, 'g', -1, 64)
+	}
+	terminal.Printf("  現在の temperature: %s\n", profileParamLabel(profile.Temperature != nil, currentTemperature))
+	if input, _ := terminal.ReadLine(fmt.Sprintf("  新しい temperature 0〜%g (%s = グローバル設定, 変更しない場合は空Enter): ", config.MaxProfileTemperature, config.ProfileParamUnset)); strings.TrimSpace(input) != "" {
+		if f, err := config.ParseProfileTemperature(input); err != nil {
+			terminal.PrintColored(ui.ColorRed, fmt.Sprintf("  %v（変更しません）\n", err))
+		} else {
+			clearedTemperature = f == nil && profile.Temperature != nil
+			profile.Temperature = f
+		}
+	}
+
 	// ランタイム cfg に反映（現在のプロバイダーの場合）
 	if key == cfg.Provider && profile.Model != "" {
 		cfg.Model = profile.Model
 	}
+	if key == cfg.Provider {
+		// エージェントは cfg を共有しているので次の応答から反映される
+		// （フラグ・環境変数の値は優先して残し、解除した値はグローバル設定に戻す）
+		cfg.ApplyProfileParams(profile, clearedMaxTokens, clearedTemperature)
+	}
 
 	// config.json に保存
 	if err := cfg.SaveProviderProfile(key, profile); err != nil {
@@ -1670,6 +2139,14 @@ func providerEdit(cfg *config.Config, terminal *ui.Terminal, key string) error {
 	return nil
 }
 
+// profileParamLabel プロファイルの数値設定の表示（未設定ならグローバル設定を使う旨）
+func profileParamLabel(set bool, value string) string {
+	if !set {
+		return "(グローバル設定)"
+	}
+	return value
+}
+
 // providerEditInteractive 編集対象を選択
 func providerEditInteractive(cfg *config.Config, terminal *ui.Terminal) error {
 	profiles := cfg.GetProviderProfiles()
@@ -1972,11 +2449,86 @@ func createToolRegistry(terminal *ui.Terminal, perm *security.PermissionManager,
 	bashTool := tool.NewBashTool()
 	writeTool := tool.NewWriteTool()
 	editTool := tool.NewEditTool()
+	multiEditTool := tool.NewMultiEditTool()
+	multiEditTool.SetWriteTool(writeTool) // /undo で取り消せるよう undo スタックを共有
+	applyPatchTool := tool.NewApplyPatchTool()
+	applyPatchTool.SetWriteTool(writeTool)
+	moveTool := tool.NewMoveTool()
+	moveTool.SetWriteTool(writeTool)
+	editTool.SetWriteTool(writeTool)
+	mkdirTool := tool.NewMakeDirectoryTool()
+	mkdirTool.SetWriteTool(writeTool)
+	globTool := tool.NewGlobTool()
+	globTool.SetMaxDepth(cfg.MaxSearchDepth)
+	grepTool := tool.NewGrepTool()
+	grepTool.SetMaxDepth(cfg.MaxSearchDepth)
+	readTool := tool.NewReadTool()
+	listTool := tool.NewListTool()
+	symbolsTool := tool.NewSymbolsTool()
+	tailTool := tool.NewTailTool()
+	notebookTool := tool.NewNotebookEditTool()
+	webFetchTool := tool.NewWebFetchTool()
+
+	// ツール出力の上限（bash/read_file/grep/web_fetch で共通）
+	bashTool.SetMaxOutput(cfg.ToolOutputMaxBytes)
+	readTool.SetMaxOutput(cfg.ToolOutputMaxBytes)
+	grepTool.SetMaxOutput(cfg.ToolOutputMaxBytes)
+	webFetchTool.SetMaxOutput(cfg.ToolOutputMaxBytes)
+
+	// サンドボックス有効時はファイル書き込みをステージングにリダイレクト
+	// （各ツールが実行時に IsEnabled() を見るので /sandbox on|off にも追従する）
+	if sbMgr != nil {
+		writeTool.SetSandbox(sbMgr)
+		editTool.SetSandbox(sbMgr)
+		multiEditTool.SetSandbox(sbMgr)
+		applyPatchTool.SetSandbox(sbMgr)
+		moveTool.SetSandbox(sbMgr)
+	}
+
+	// 書き込み先を作業ディレクトリ内に制限（--allow-outside-workdir で解除）
+	if validator != nil && !cfg.AllowOutsideWorkdir {
+		writeTool.SetPathValidator(validator)
+		editTool.SetPathValidator(validator)
+		multiEditTool.SetPathValidator(validator)
+		applyPatchTool.SetPathValidator(validator)
+		moveTool.SetPathValidator(validator)
+		mkdirTool.SetPathValidator(validator)
+		notebookTool.SetPathValidator(validator)
+	}
+
+	// .vibeignore に列挙されたパスには読み書き・検索とも触れない（--no-vibeignore で解除）
+	if !cfg.NoVibeIgnore {
+		if cwd, err := os.Getwd(); err == nil {
+			if vibeIgnore := tool.LoadVibeIgnore(cwd); vibeIgnore != nil {
+				readTool.SetVibeIgnore(vibeIgnore)
+				writeTool.SetVibeIgnore(vibeIgnore)
+				editTool.SetVibeIgnore(vibeIgnore)
+				multiEditTool.SetVibeIgnore(vibeIgnore)
+				applyPatchTool.SetVibeIgnore(vibeIgnore)
+				moveTool.SetVibeIgnore(vibeIgnore)
+				mkdirTool.SetVibeIgnore(vibeIgnore)
+				notebookTool.SetVibeIgnore(vibeIgnore)
+				globTool.SetVibeIgnore(vibeIgnore)
+				grepTool.SetVibeIgnore(vibeIgnore)
+				listTool.SetVibeIgnore(vibeIgnore)
+				symbolsTool.SetVibeIgnore(vibeIgnore)
+				tailTool.SetVibeIgnore(vibeIgnore)
+				terminal.PrintColored(ui.ColorCyan, fmt.Sprintf("✓ %s を読み込みました: 列挙されたパスにはツールからアクセスしません\n", tool.VibeIgnoreFile))
+			}
+		}
+	}
+
+	// ネットワーク禁止モード: 外部ホストにアクセスするコマンドを実行前に拒否
+	if cfg.NoNetwork {
+		bashTool.SetNoNetwork(true)
+		terminal.PrintColored(ui.ColorCyan, "✓ ネットワーク禁止モード: bash から外部ホストへのアクセスを拒否します（localhost は許可）\n")
+	}
 
-	// NOTE: ファイルステージング（.vibe-sandbox/経由）は現在無効
-	// サンドボックスの目的はPython仮想環境(.venv/)による隔離のみ
-	// write_file/edit_fileは直接プロジェクトに書き込む
-	_ = sbMgr // 将来の拡張用に引数は維持
+	// 危険コマンドのブロック解除: 起動時に必ず目立つ警告を出す
+	tool.SetAllowDangerous(cfg.AllowDangerous)
+	if cfg.AllowDangerous {
+		terminal.PrintColored(ui.ColorRed, "⚠ --allow-dangerous: 危険なコマンド（rm -rf /, mkfs, dd of=/dev/... 等）のブロックを無効にしています。使い捨ての環境以外では使わないでください\n")
+	}
 
 	// 自動venvが有効な場合、BashToolに設定
 	if cfg.AutoVenv {
@@ -1985,39 +2537,102 @@ func createToolRegistry(terminal *ui.Terminal, perm *security.PermissionManager,
 
 	// Register tools
 	registry.Register(bashTool)
-	registry.Register(tool.NewReadTool())
+	registry.Register(tool.NewAssertCommandTool(bashTool)) // no-network / venv 設定を bash と共有
+	registry.Register(tool.NewBashOutputTool())
+	registry.Register(readTool)
 	registry.Register(writeTool)
 	registry.Register(editTool)
-	registry.Register(tool.NewGlobTool())
-	registry.Register(tool.NewGrepTool())
-	registry.Register(tool.NewWebFetchTool())
-	registry.Register(tool.NewWebSearchTool())
-	registry.Register(tool.NewNotebookEditTool())
+	registry.Register(multiEditTool)
+	registry.Register(applyPatchTool)
+	registry.Register(mkdirTool)
+	registry.Register(moveTool)
+	registry.Register(globTool)
+	registry.Register(grepTool)
+	registry.Register(listTool)
+	registry.Register(symbolsTool)
+	registry.Register(tailTool)
+	envTool := tool.NewEnvironmentTool()
+	envTool.SetMemoryGB(getMemoryGB())
+	registry.Register(envTool)
+	registry.Register(webFetchTool)
+	webSearchTool := tool.NewWebSearchTool()
+	if cfg.WebSearchCacheSize != 0 {
+		webSearchTool.SetCacheSize(cfg.WebSearchCacheSize)
+	}
+	registry.Register(webSearchTool)
+	registry.Register(notebookTool)
 
 	return registry
 }
 
+// createSemanticSearchTool は provider の埋め込みでファイルを検索するツールを作る
+// （キャッシュはプロジェクト間で共有し、内容のハッシュで引く）
+func createSemanticSearchTool(provider llm.LLMProvider, cfg *config.Config, perm *security.PermissionManager) *tool.SemanticSearchTool {
+	model := cfg.EmbedModel
+	if model != "" {
+		for _, p := range embeddingProviders(provider) {
+			if setter, ok := p.(interface{ SetEmbeddingModel(string) }); ok {
+				setter.SetEmbeddingModel(model)
+			}
+		}
+	} else {
+		model = provider.Info().Name + ":" + cfg.Model
+	}
+
+	semanticTool := tool.NewSemanticSearchTool()
+	semanticTool.SetEmbedder(model, provider.Embed)
+	semanticTool.SetCacheDir(filepath.Join(config.SessionBaseDir(), "cache"))
+	semanticTool.SetMaxDepth(cfg.MaxSearchDepth)
+	if !cfg.NoVibeIgnore {
+		if cwd, err := os.Getwd(); err == nil {
+			semanticTool.SetVibeIgnore(tool.LoadVibeIgnore(cwd))
+		}
+	}
+
+	// クラウドのプロバイダーで埋め込む場合はファイル内容が外部に送られるため実行前に確認する
+	for _, p := range embeddingProviders(provider) {
+		if p.Info().Type != llm.ProviderTypeLocal {
+			perm.MarkNetworkTool(semanticTool.Name())
+			break
+		}
+	}
+	return semanticTool
+}
+
+// embeddingProviders はチェーンの場合は全エントリ、それ以外は provider 自身を返す
+func embeddingProviders(provider llm.LLMProvider) []llm.LLMProvider {
+	chain, ok := provider.(*llm.ProviderChain)
+	if !ok {
+		return []llm.LLMProvider{provider}
+	}
+	var providers []llm.LLMProvider
+	for _, entry := range chain.GetEntries() {
+		providers = append(providers, entry.Provider)
+	}
+	return providers
+}
+
 // checkProviderConnection checks the LLM provider connection
 // 接続失敗時はリトライ/再設定/終了を選択できる
 // プロバイダーが再設定された場合は新しいプロバイダーを返す
 func checkProviderConnection(ctx context.Context, provider llm.LLMProvider, cfg *config.Config, terminal *ui.Terminal) llm.LLMProvider {
 	for {
 		info := provider.Info()
-		terminal.PrintColored(ui.ColorCyan, fmt.Sprintf("%s (%s) 接続を確認中...\n", info.Name, info.BaseURL))
+		terminal.PrintColored(ui.ColorCyan, i18n.T(i18n.MsgConnChecking, info.Name, info.BaseURL))
 
 		err := provider.CheckHealth(ctx)
 		if err == nil {
-			terminal.PrintColored(ui.ColorGreen, fmt.Sprintf("✓ %s 接続確認\n", info.Name))
+			terminal.PrintColored(ui.ColorGreen, i18n.T(i18n.MsgConnOK, info.Name))
 			return provider
 		}
 
-		terminal.PrintColored(ui.ColorRed, fmt.Sprintf("接続エラー: %v\n", err))
+		terminal.PrintColored(ui.ColorRed, i18n.T(i18n.MsgConnError, err))
 		terminal.Print("\n")
-		terminal.Println("  1. リトライ")
-		terminal.Println("  2. プロバイダーを再設定")
-		terminal.Println("  3. 終了")
+		terminal.Println(i18n.T(i18n.MsgConnRetry))
+		terminal.Println(i18n.T(i18n.MsgConnReconfigure))
+		terminal.Println(i18n.T(i18n.MsgConnQuit))
 
-		choice, readErr := terminal.ReadLine("選択 [1-3]: ")
+		choice, readErr := terminal.ReadLine(i18n.T(i18n.MsgConnChoose))
 		if readErr != nil {
 			os.Exit(1)
 		}
@@ -2052,6 +2667,17 @@ func checkProviderConnection(ctx context.Context, provider llm.LLMProvider, cfg
 	}
 }
 
+// printModelSuggestions 見つからなかったモデル名に近いインストール済みモデルを「もしかして」として表示する
+func printModelSuggestions(ctx context.Context, mm llm.ModelManager, name string, terminal *ui.Terminal) {
+	available, err := mm.ListModels(ctx)
+	if err != nil {
+		return
+	}
+	if suggestions := llm.SuggestModels(name, available, llm.MaxModelSuggestions); len(suggestions) > 0 {
+		terminal.PrintColored(ui.ColorCyan, i18n.T(i18n.MsgModelSuggest, strings.Join(suggestions, ", ")))
+	}
+}
+
 // pullModelIfNeeded checks and pulls model if needed (ModelManager対応プロバイダーのみ)
 // クラウドプロバイダーへの切替が選択された場合は true を返す
 func pullModelIfNeeded(ctx context.Context, provider llm.LLMProvider, cfg *config.Config, terminal *ui.Terminal) bool {
@@ -2062,10 +2688,10 @@ func pullModelIfNeeded(ctx context.Context, provider llm.LLMProvider, cfg *confi
 	}
 
 	modelName := cfg.Model
-	terminal.Printf("モデル '%s' を確認中...\n", modelName)
+	terminal.Print(i18n.T(i18n.MsgModelChecking, modelName))
 	exists, err := mm.CheckModel(ctx, modelName)
 	if err != nil {
-		terminal.PrintColored(ui.ColorRed, fmt.Sprintf("モデル確認エラー: %v\n", err))
+		terminal.PrintColored(ui.ColorRed, i18n.T(i18n.MsgModelCheckError, err))
 		os.Exit(1)
 	}
 
@@ -2076,8 +2702,14 @@ func pullModelIfNeeded(ctx context.Context, provider llm.LLMProvider, cfg *confi
 
 	terminal.PrintColored(ui.ColorYellow, fmt.Sprintf("モデル '%s' が見つかりません\n", modelName))
 
+	printModelSuggestions(ctx, mm, modelName, terminal)
+
 	availableModels, err := mm.ListModels(ctx)
 	if err != nil || len(availableModels) == 0 {
+		if cfg.Offline {
+			terminal.PrintColored(ui.ColorRed, "利用可能なモデルがありません（オフラインモードのためダウンロードできません）\n")
+			os.Exit(1)
+		}
 		terminal.PrintColored(ui.ColorYellow, "利用可能なモデルがありません。ダウンロードを試みます...\n")
 		terminal.Printf("モデル '%s' をダウンロード中...\n", modelName)
 		// OllamaProvider の場合はプログレス表示付きでpull
@@ -2087,7 +2719,7 @@ func pullModelIfNeeded(ctx context.Context, provider llm.LLMProvider, cfg *confi
 			err = mm.PullModel(ctx, modelName)
 		}
 		if err != nil {
-			terminal.PrintColored(ui.ColorRed, fmt.Sprintf("\nモデルダウンロードエラー: %v\n", err))
+			terminal.PrintColored(ui.ColorRed, i18n.T(i18n.MsgModelPullError, err))
 			terminal.Println("以下の方法でモデルをインストールしてください：")
 			terminal.Println("  1. 別のモデルを使用する: ./vibe-local-go -model <model-name>")
 			terminal.Println("  2. モデルを手動でインストール: ollama pull <model-name>")
@@ -2111,7 +2743,7 @@ func pullModelIfNeeded(ctx context.Context, provider llm.LLMProvider, cfg *confi
 
 	choice, err := terminal.ReadLine("選択してください [1-4]: ")
 	if err != nil {
-		terminal.PrintColored(ui.ColorRed, fmt.Sprintf("入力エラー: %v\n", err))
+		terminal.PrintColored(ui.ColorRed, i18n.T(i18n.MsgErrInput, err))
 		os.Exit(1)
 	}
 
@@ -2119,7 +2751,7 @@ func pullModelIfNeeded(ctx context.Context, provider llm.LLMProvider, cfg *confi
 	case "1":
 		idx, err := terminal.ReadLine("モデル番号を入力: ")
 		if err != nil {
-			terminal.PrintColored(ui.ColorRed, fmt.Sprintf("入力エラー: %v\n", err))
+			terminal.PrintColored(ui.ColorRed, i18n.T(i18n.MsgErrInput, err))
 			os.Exit(1)
 		}
 		var num int
@@ -2135,10 +2767,14 @@ func pullModelIfNeeded(ctx context.Context, provider llm.LLMProvider, cfg *confi
 		return false
 
 	case "2":
+		if cfg.Offline {
+			terminal.PrintColored(ui.ColorRed, "オフラインモードではモデルをダウンロードできません\n")
+			os.Exit(1)
+		}
 		// モデル名を入力（デフォルトは設定のモデル）
 		input, err := terminal.ReadLine(fmt.Sprintf("ダウンロードするモデル名 [%s]: ", modelName))
 		if err != nil {
-			terminal.PrintColored(ui.ColorRed, fmt.Sprintf("入力エラー: %v\n", err))
+			terminal.PrintColored(ui.ColorRed, i18n.T(i18n.MsgErrInput, err))
 			os.Exit(1)
 		}
 		if input != "" {
@@ -2152,7 +2788,7 @@ func pullModelIfNeeded(ctx context.Context, provider llm.LLMProvider, cfg *confi
 			err = mm.PullModel(ctx, modelName)
 		}
 		if err != nil {
-			terminal.PrintColored(ui.ColorRed, fmt.Sprintf("\nモデルダウンロードエラー: %v\n", err))
+			terminal.PrintColored(ui.ColorRed, i18n.T(i18n.MsgModelPullError, err))
 			os.Exit(1)
 		}
 		terminal.PrintColored(ui.ColorGreen, "\n✓ モデルダウンロード完了\n")
@@ -2171,6 +2807,10 @@ func pullModelIfNeeded(ctx context.Context, provider llm.LLMProvider, cfg *confi
 
 // switchToCloudProvider クラウドプロバイダーへの切替処理
 func switchToCloudProvider(cfg *config.Config, terminal *ui.Terminal) bool {
+	if cfg.Offline {
+		terminal.PrintColored(ui.ColorYellow, "オフラインモードではクラウドプロバイダーは使えません\n")
+		return false
+	}
 	terminal.Print("\n")
 	terminal.PrintColored(ui.ColorCyan, "━━━ クラウドプロバイダー セットアップ ━━━\n")
 
@@ -2199,7 +2839,7 @@ func switchToCloudProvider(cfg *config.Config, terminal *ui.Terminal) bool {
 
 	choiceStr, err := terminal.ReadLine(fmt.Sprintf("選択 [1-%d]: ", num))
 	if err != nil {
-		terminal.PrintColored(ui.ColorRed, fmt.Sprintf("入力エラー: %v\n", err))
+		terminal.PrintColored(ui.ColorRed, i18n.T(i18n.MsgErrInput, err))
 		return false
 	}
 	var choiceNum int
@@ -2306,6 +2946,13 @@ func switchToCloudProvider(cfg *config.Config, terminal *ui.Terminal) bool {
 }
 
 func showBanner(terminal *ui.Terminal, cfg *config.Config, router *llm.ModelRouter, provider llm.LLMProvider) {
+	opts := bannerOptions(cfg, router, provider)
+	opts.Mode = cfg.Banner
+	terminal.ShowBanner(opts)
+}
+
+// bannerOptions バナー/ステータス表示用の情報を組み立てる
+func bannerOptions(cfg *config.Config, router *llm.ModelRouter, provider llm.LLMProvider) ui.BannerOptions {
 	tier := router.GetModelTier(cfg.Model)
 	cwd, _ := os.Getwd()
 
@@ -2330,7 +2977,7 @@ func showBanner(terminal *ui.Terminal, cfg *config.Config, router *llm.ModelRout
 		}
 	}
 
-	opts := ui.BannerOptions{
+	return ui.BannerOptions{
 		Version:       Version,
 		ModelName:     cfg.Model,
 		ModelTier:     tier,
@@ -2344,12 +2991,9 @@ func showBanner(terminal *ui.Terminal, cfg *config.Config, router *llm.ModelRout
 		ChainInfo:     chainInfo,
 		OllamaNumCtx:  cfg.OllamaNumCtx,
 	}
-	terminal.ShowBanner(opts)
 }
 
-func resumeSession(ctx context.Context, sess *session.Session, persistenceMgr *session.PersistenceManager, resumeFlag string, cfg *config.Config) {
-	terminal := ui.NewTerminal()
-
+func resumeSession(ctx context.Context, terminal *ui.Terminal, sess *session.Session, persistenceMgr *session.PersistenceManager, resumeFlag string, cfg *config.Config, agt *agent.Agent, permissionMgr *security.PermissionManager) {
 	var sessionID string
 	if resumeFlag == "last" {
 		lastID := getLastSessionID(persistenceMgr)
@@ -2387,37 +3031,50 @@ func resumeSession(ctx context.Context, sess *session.Session, persistenceMgr *s
 	// Copy from loaded session
 	sess.SetID(loadedSess.GetID())
 	sess.SetSystemPrompt(loadedSess.SystemPrompt)
-	for _, msg := range loadedSess.Messages {
-		if msg.Role == session.RoleUser {
-			sess.AddUserMessage(msg.Content)
-		} else if msg.Role == session.RoleAssistant {
-			if len(msg.ToolCalls) > 0 {
-				sess.AddToolCall(msg.ToolCalls)
-			} else {
-				sess.AddAssistantMessage(msg.Content)
-			}
-		} else if msg.Role == session.RoleTool {
-			result := session.ToolResult{
-				Content:    msg.Content,
-				ToolCallID: msg.ToolID,
-			}
-			sess.AddToolResults([]session.ToolResult{result})
-		}
+	sess.ReplayMessages(loadedSess.Messages)
+
+	// ランタイムモードを復元（-y 指定時は自動承認を維持）
+	modes := loadedSess.GetModes()
+	sess.SetModes(modes)
+	if modes.AutoApprove && !cfg.AutoApprove {
+		cfg.AutoApprove = true
+		permissionMgr.SetAutoApprove(true)
 	}
+	sess.SetPlan(loadedSess.GetPlan())
+	sess.SetTokenUsage(loadedSess.GetTokenUsage())
+	agt.SetPlanMode(modes.PlanMode)
+	agt.SetAutoTestEnabled(modes.AutoTest)
 
 	terminal.PrintColored(ui.ColorGreen, fmt.Sprintf("✓ セッション '%s' を復旧しました\n", sessionID))
+	if modes.AutoApprove || modes.PlanMode || modes.AutoTest {
+		terminal.PrintInfo(fmt.Sprintf("復元したモード: auto-approve=%v, plan=%v, autotest=%v", cfg.AutoApprove, modes.PlanMode, modes.AutoTest))
+	}
 }
 
-func runAgent(ctx context.Context, agt *agent.Agent, cfg *config.Config, terminal *ui.Terminal, shutdownMgr *ShutdownManager, cmdHandler *ui.CommandHandler) {
+func runAgent(ctx context.Context, agt *agent.Agent, cfg *config.Config, terminal *ui.Terminal, shutdownMgr *ShutdownManager, cmdHandler *ui.CommandHandler, interrupter *ui.TurnInterrupter) {
 	// One-shot mode
 	if flagPrompt != "" {
-		runOneShot(ctx, agt, flagPrompt, terminal)
+		turnCtx, endTurn := interrupter.BeginTurn(ctx)
+		status := runOneShot(turnCtx, agt, flagPrompt, terminal)
+		endTurn()
+		// 失敗・タイムアウト時もセッションを保存してから終了コードを返す
 		shutdownMgr.Shutdown("one-shot complete")
+		if status != 0 {
+			os.Exit(status)
+		}
 		return
 	}
 
 	// Interactive mode
-	terminal.ShowWelcome(Version)
+	if cfg.Banner == config.BannerFull {
+		terminal.ShowWelcome(Version)
+	}
+
+	// 入力中の Ctrl+C は raw モードのため SIGINT にならないので、同じ状態管理に通す
+	promptInterrupt := ui.InterruptNone
+	terminal.GetLineEditor().SetInterruptHandler(func() {
+		promptInterrupt = interrupter.Interrupt()
+	})
 
 	for {
 		select {
@@ -2428,13 +3085,21 @@ func runAgent(ctx context.Context, agt *agent.Agent, cfg *config.Config, termina
 			contextUsagePct := agt.GetContextUsagePercent()
 			prompt := ui.FormatPrompt(contextUsagePct)
 
+			promptInterrupt = ui.InterruptNone
 			input, err := terminal.ReadMultilineAware(prompt)
+			switch promptInterrupt {
+			case ui.InterruptExit:
+				shutdownMgr.Shutdown("Ctrl+C")
+				return
+			case ui.InterruptArmed:
+				terminal.PrintColored(ui.ColorGray, "(もう一度 Ctrl+C で終了)\n")
+			}
 			if err != nil {
 				if err == io.EOF {
 					shutdownMgr.Shutdown("EOF")
 					return
 				}
-				terminal.PrintColored(ui.ColorRed, fmt.Sprintf("入力エラー: %v\n", err))
+				terminal.PrintColored(ui.ColorRed, i18n.T(i18n.MsgErrInput, err))
 				continue
 			}
 
@@ -2452,79 +3117,244 @@ func runAgent(ctx context.Context, agt *agent.Agent, cfg *config.Config, termina
 					shutdownMgr.Shutdown("user request")
 					return
 				}
-				continue
+				// /retry などコマンドが入力を予約した場合はそのままエージェントに渡す
+				submitted, ok := cmdHandler.TakeSubmitted()
+				if !ok {
+					continue
+				}
+				input = submitted
 			}
 
-			// Run agent
-			err = agt.Run(ctx, input)
+			// Run agent（Ctrl+C はこのターンだけを中断する）
+			turnCtx, endTurn := interrupter.BeginTurn(ctx)
+			err = agt.Run(turnCtx, input)
+			endTurn()
 			if err != nil {
+				if errors.Is(err, context.Canceled) && ctx.Err() == nil {
+					terminal.PrintColored(ui.ColorYellow, "⏹ ターンを中断しました\n")
+					continue
+				}
 				terminal.PrintColored(ui.ColorRed, fmt.Sprintf("エージェントエラー: %v\n", err))
 			}
 		}
 	}
 }
 
-func runOneShot(ctx context.Context, agt *agent.Agent, prompt string, terminal *ui.Terminal) {
-	err := agt.Run(ctx, prompt)
+// runOneShot はワンショット実行し、終了コードを返す
+func runOneShot(ctx context.Context, agt *agent.Agent, prompt string, terminal *ui.Terminal) int {
+	if flagJSONOutput {
+		return runOneShotJSON(ctx, agt, prompt)
+	}
+
+	err := oneShotError(ctx, agt.Run(ctx, prompt))
 	if err != nil {
 		terminal.PrintColored(ui.ColorRed, fmt.Sprintf("エージェントエラー: %v\n", err))
-		os.Exit(1)
+		return 1
 	}
+	return 0
 }
 
-func setupSignalHandler(shutdownMgr *ShutdownManager) {
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+// oneShotError は --timeout の期限切れで終わった場合にその旨が分かるエラーにする
+func oneShotError(ctx context.Context, err error) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		if err == nil {
+			err = ctx.Err()
+		}
+		return fmt.Errorf("global timeout exceeded (--timeout %s): %w", flagTimeout, err)
+	}
+	return err
+}
 
-	go func() {
-		sig := <-sigChan
-		sigName := ""
-		switch sig {
-		case syscall.SIGINT:
-			sigName = "SIGINT"
-		case syscall.SIGTERM:
-			sigName = "SIGTERM"
-		}
-		shutdownMgr.Shutdown(sigName)
-	}()
+// oneShotResult は --json-output で stdout に出力する実行結果
+type oneShotResult struct {
+	Response   string            `json:"response"`
+	ToolCalls  []oneShotToolCall `json:"tool_calls"`
+	Usage      oneShotUsage      `json:"usage"`
+	ExitStatus int               `json:"exit_status"`
+	Error      string            `json:"error,omitempty"`
 }
 
-func showVersion() {
-	fmt.Printf("vibe-local-go v%s\n", Version)
-	fmt.Printf("Go %s (%s/%s)\n", runtime.Version(), runtime.GOOS, runtime.GOARCH)
+// oneShotToolCall は実行されたツール呼び出し1件
+type oneShotToolCall struct {
+	ID        string          `json:"id,omitempty"`
+	Name      string          `json:"name"`
+	Arguments json.RawMessage `json:"arguments"`
 }
 
-func listSessions(cfg *config.Config) {
-	terminal := ui.NewTerminal()
-	persistenceMgr, err := session.NewPersistenceManager(getSessionDir())
+// oneShotUsage はトークン使用量
+type oneShotUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// runOneShotJSON はワンショット実行し、結果を単一の JSON オブジェクトとして出力する
+// エージェントがエラーで終了した場合も error フィールド付きの JSON を出力し、終了コード 1 を返す
+func runOneShotJSON(ctx context.Context, agt *agent.Agent, prompt string) int {
+	start := agt.GetSession().GetMessageCount()
+	runErr := oneShotError(ctx, agt.Run(ctx, prompt))
+
+	result := buildOneShotResult(agt.GetSession().GetMessages(), start, agt.GetTokenUsage(), runErr)
+
+	data, err := json.Marshal(result)
 	if err != nil {
-		terminal.PrintColored(ui.ColorRed, fmt.Sprintf("パーシスタンスマネージャー作成エラー: %v\n", err))
-		os.Exit(1)
+		// フィールドは全て文字列/数値なので通常は起こらない
+		data = []byte(fmt.Sprintf(`{"response":"","tool_calls":[],"exit_status":1,"error":%q}`, err.Error()))
+		result.ExitStatus = 1
 	}
+	fmt.Fprintln(os.Stdout, string(data))
+	return result.ExitStatus
+}
 
-	sessions, err := persistenceMgr.ListSessions()
-	if err != nil {
-		terminal.PrintColored(ui.ColorRed, fmt.Sprintf("セッション一覧エラー: %v\n", err))
-		os.Exit(1)
+// buildOneShotResult は messages[start:] から最終応答とツール呼び出しを集める
+func buildOneShotResult(messages []session.Message, start int, usage agent.TokenUsage, runErr error) oneShotResult {
+	result := oneShotResult{
+		ToolCalls: make([]oneShotToolCall, 0),
+		Usage: oneShotUsage{
+			PromptTokens:     usage.PromptTokens,
+			CompletionTokens: usage.CompletionTokens,
+			TotalTokens:      usage.Total(),
+		},
 	}
 
-	terminal.PrintColored(ui.ColorCyan, "═══ セッション一覧 ═══\n")
-	for i, sessID := range sessions {
-		terminal.Printf("%3d. %s\n", i+1, sessID)
+	if start > len(messages) {
+		start = len(messages)
 	}
-	if len(sessions) == 0 {
-		terminal.Println("  セッションが見つかりません")
+	for _, msg := range messages[start:] {
+		if msg.Role != session.RoleAssistant {
+			continue
+		}
+		if len(msg.ToolCalls) == 0 {
+			result.Response = msg.Content
+			continue
+		}
+		for _, tc := range msg.ToolCalls {
+			args := json.RawMessage(tc.Function.Arguments)
+			if !json.Valid(args) {
+				args, _ = json.Marshal(tc.Function.Arguments)
+			}
+			result.ToolCalls = append(result.ToolCalls, oneShotToolCall{
+				ID:        tc.ID,
+				Name:      tc.Function.Name,
+				Arguments: args,
+			})
+		}
 	}
-}
 
-// Helper functions
+	if runErr != nil {
+		result.ExitStatus = 1
+		result.Error = runErr.Error()
+	}
+	return result
+}
 
-func getSessionDir() string {
-	homeDir, err := os.UserHomeDir()
+// setupSignalHandler SIGTERM で終了する。SIGINT (Ctrl+C) の1回目は実行中のターンだけを
+// 中断し、ui.DoubleInterruptWindow 内の2回目で終了する
+func setupSignalHandler(shutdownMgr *ShutdownManager, interrupter *ui.TurnInterrupter, terminal *ui.Terminal) {
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+
+	go func() {
+		for sig := range sigChan {
+			if sig == syscall.SIGTERM {
+				shutdownMgr.Shutdown("SIGTERM")
+				return
+			}
+			switch interrupter.Interrupt() {
+			case ui.InterruptCancelTurn:
+				terminal.PrintColored(ui.ColorYellow, "\n⏹ 中断中...（もう一度 Ctrl+C で終了）\n")
+			case ui.InterruptArmed:
+				terminal.PrintColored(ui.ColorGray, "\n(もう一度 Ctrl+C で終了)\n")
+			case ui.InterruptExit:
+				shutdownMgr.Shutdown("SIGINT")
+				return
+			}
+		}
+	}()
+}
+
+// setupToolCancelHandler Ctrl+\ (SIGQUIT) で実行中のツールだけを中断する。
+// ターンは継続し、エージェントには "cancelled by user" の結果が返る
+func setupToolCancelHandler(agt *agent.Agent, terminal *ui.Terminal) {
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGQUIT)
+
+	go func() {
+		for range sigChan {
+			if _, ok := agt.CancelCurrentTool(); !ok {
+				terminal.PrintColored(ui.ColorGray, "\n(実行中のツールはありません)\n")
+			}
+		}
+	}()
+}
+
+func showVersion() {
+	fmt.Printf("vibe-local-go v%s\n", Version)
+	fmt.Printf("Go %s (%s/%s)\n", runtime.Version(), runtime.GOOS, runtime.GOARCH)
+}
+
+func listSessions(cfg *config.Config) {
+	terminal := ui.NewTerminal()
+	persistenceMgr, err := session.NewPersistenceManager(getSessionDir())
+	if err != nil {
+		terminal.PrintColored(ui.ColorRed, fmt.Sprintf("パーシスタンスマネージャー作成エラー: %v\n", err))
+		os.Exit(1)
+	}
+
+	sessions, err := persistenceMgr.ListSessions()
+	if err != nil {
+		terminal.PrintColored(ui.ColorRed, fmt.Sprintf("セッション一覧エラー: %v\n", err))
+		os.Exit(1)
+	}
+
+	terminal.PrintColored(ui.ColorCyan, "═══ セッション一覧 ═══\n")
+	for i, sessID := range sessions {
+		terminal.Printf("%3d. %s\n", i+1, sessID)
+	}
+	if len(sessions) == 0 {
+		terminal.Println("  セッションが見つかりません")
+	}
+}
+
+func searchSessions(query string) {
+	terminal := ui.NewTerminal()
+	persistenceMgr, err := session.NewPersistenceManager(getSessionDir())
+	if err != nil {
+		terminal.PrintColored(ui.ColorRed, fmt.Sprintf("パーシスタンスマネージャー作成エラー: %v\n", err))
+		os.Exit(1)
+	}
+
+	hits, err := persistenceMgr.SearchSessions(query)
 	if err != nil {
-		return "."
+		terminal.PrintColored(ui.ColorRed, fmt.Sprintf("セッション検索エラー: %v\n", err))
+		os.Exit(1)
+	}
+
+	printSearchHits(terminal, query, hits)
+}
+
+// printSearchHits はセッション検索結果を表示する（--search-sessions と /search で共通）
+func printSearchHits(terminal *ui.Terminal, query string, hits []session.SearchHit) {
+	terminal.PrintColored(ui.ColorCyan, fmt.Sprintf("═══ セッション検索: %q ═══\n", query))
+	if len(hits) == 0 {
+		terminal.Println("  一致するメッセージが見つかりません")
+		return
+	}
+
+	for _, hit := range hits {
+		terminal.Printf("  %s #%d [%s] %s\n", hit.SessionID, hit.MessageIndex, hit.Role, hit.Snippet)
+	}
+
+	if len(hits) >= session.MaxSearchHits {
+		terminal.PrintColored(ui.ColorYellow, fmt.Sprintf("  (上位 %d 件のみ表示。キーワードを絞り込んでください)\n", session.MaxSearchHits))
 	}
-	return filepath.Join(homeDir, ".config", "vibe-local")
+	terminal.Println("\n使用例: ./vibe --resume <session-id>")
+}
+
+// Helper functions
+
+func getSessionDir() string {
+	return config.SessionBaseDir()
 }
 
 func generateSessionID() string {
@@ -2705,7 +3535,7 @@ func checkAndPullOllamaModel(host, model string, terminal *ui.Terminal) string {
 }
 
 // registerSkillCommands スキル関連のスラッシュコマンドを登録
-func registerSkillCommands(cmdHandler *ui.CommandHandler, terminal *ui.Terminal, skillMgr *skill.SkillManager) {
+func registerSkillCommands(cmdHandler *ui.CommandHandler, terminal *ui.Terminal, skillMgr *skill.SkillManager, agt *agent.Agent) {
 	cmdHandler.Register(&ui.SlashCommand{
 		Name:        "skills",
 		Description: "利用可能なスキル一覧",
@@ -2742,21 +3572,71 @@ func registerSkillCommands(cmdHandler *ui.CommandHandler, terminal *ui.Terminal,
 			return nil
 		},
 	})
+
+	cmdHandler.Register(&ui.SlashCommand{
+		Name:        "skill",
+		Description: "スキルの SKILL.md を以降の会話に読み込む（/skill <name>, /skill off）",
+		Handler: func(args string) error {
+			name := strings.TrimSpace(args)
+			switch name {
+			case "":
+				if current := agt.PinnedSkill(); current != "" {
+					terminal.Printf("読み込み中のスキル: %s（/skill off で解除）\n", current)
+				} else {
+					terminal.Println("使い方: /skill <name> | /skill off")
+				}
+				return nil
+			case "off":
+				if cleared := agt.ClearSkill(); cleared != "" {
+					terminal.PrintColored(ui.ColorGreen, fmt.Sprintf("✓ スキル %s の読み込みを解除しました\n", cleared))
+				} else {
+					terminal.Println("読み込み中のスキルはありません")
+				}
+				return nil
+			}
+
+			s, err := skillMgr.Lookup(name)
+			if err != nil {
+				terminal.PrintColored(ui.ColorRed, err.Error()+"\n")
+				return nil
+			}
+			if err := agt.UseSkill(s); err != nil {
+				terminal.PrintColored(ui.ColorRed, err.Error()+"\n")
+				return nil
+			}
+			terminal.PrintColored(ui.ColorGreen, fmt.Sprintf("✓ スキル %s を読み込みました（以降の会話に適用、/skill off で解除）\n", s.Name))
+			return nil
+		},
+	})
 }
 
 // registerMCPCommands MCP関連のスラッシュコマンドを登録
 func registerMCPCommands(cmdHandler *ui.CommandHandler, terminal *ui.Terminal, mcpMgr *mcp.Manager) {
 	cmdHandler.Register(&ui.SlashCommand{
 		Name:        "mcp",
-		Description: "MCPサーバー接続状況・ツール一覧",
+		Description: "MCPサーバー接続状況・ツール一覧 [restart <name>]",
 		Handler: func(args string) error {
+			if fields := strings.Fields(args); len(fields) > 0 && fields[0] == "restart" {
+				if len(fields) != 2 {
+					terminal.PrintColored(ui.ColorYellow, "使い方: /mcp restart <name>\n")
+					return nil
+				}
+				name := fields[1]
+				terminal.PrintColored(ui.ColorCyan, fmt.Sprintf("MCP '%s' を再起動中...\n", name))
+				if err := mcpMgr.RestartServer(name); err != nil {
+					terminal.PrintColored(ui.ColorRed, fmt.Sprintf("再起動エラー: %v\n", err))
+					return nil
+				}
+				terminal.PrintColored(ui.ColorGreen, fmt.Sprintf("✓ MCP '%s' を再起動しました\n", name))
+				return nil
+			}
+
 			serverNames := mcpMgr.GetServerNames()
 
 			if len(serverNames) == 0 {
 				terminal.PrintColored(ui.ColorYellow, "MCPサーバーが設定されていません\n\n")
 				terminal.Printf("設定ファイルの配置場所:\n")
-				homeDir, _ := os.UserHomeDir()
-				terminal.Printf("  グローバル: %s/.config/vibe-local-go/mcp.json\n", homeDir)
+				terminal.Printf("  グローバル: %s\n", mcpMgr.GlobalConfigPath())
 				terminal.Printf("  プロジェクト: .vibe-local/mcp.json\n\n")
 				terminal.Printf("設定例:\n")
 				terminal.PrintColored(ui.ColorGray, "  {\n")
@@ -2783,7 +3663,14 @@ func registerMCPCommands(cmdHandler *ui.CommandHandler, terminal *ui.Terminal, m
 				}
 				terminal.Printf("  ")
 				terminal.PrintColored(statusColor, status)
-				terminal.Printf(" %s\n", name)
+				terminal.Printf(" %s", name)
+				if exitErr := mcpMgr.ExitError(name); exitErr != nil {
+					terminal.PrintColored(ui.ColorYellow, fmt.Sprintf(" (異常終了: %v)", exitErr))
+				}
+				if n := mcpMgr.RestartCount(name); n > 0 {
+					terminal.PrintColored(ui.ColorGray, fmt.Sprintf(" [自動再起動 %d 回]", n))
+				}
+				terminal.Println("")
 
 				if tools, ok := allTools[name]; ok {
 					for _, t := range tools {
@@ -2802,8 +3689,41 @@ func registerMCPCommands(cmdHandler *ui.CommandHandler, terminal *ui.Terminal, m
 	})
 }
 
+// registerToolsCommands 登録済みツールと利用可否を表示するコマンドを登録
+func registerToolsCommands(cmdHandler *ui.CommandHandler, terminal *ui.Terminal, registry *tool.Registry) {
+	cmdHandler.Register(&ui.SlashCommand{
+		Name:        "tools",
+		Description: "登録済みツールと利用可否を表示",
+		Handler: func(args string) error {
+			names := registry.Names()
+			sort.Strings(names)
+
+			available := 0
+			for _, name := range names {
+				if _, degraded := registry.DegradedReason(name); !degraded {
+					available++
+				}
+			}
+			terminal.PrintColored(ui.ColorCyan, fmt.Sprintf("━━ Tools (%d/%d 利用可能) ━━━━━━━━━━━━━━\n", available, len(names)))
+
+			for _, name := range names {
+				terminal.Printf("  ")
+				if reason, degraded := registry.DegradedReason(name); degraded {
+					terminal.PrintColored(ui.ColorYellow, "⚠ ")
+					terminal.Printf("%-16s", name)
+					terminal.PrintColored(ui.ColorYellow, fmt.Sprintf(" 利用不可: %s\n", reason))
+					continue
+				}
+				terminal.PrintColored(ui.ColorGreen, "✓ ")
+				terminal.Printf("%s\n", name)
+			}
+			return nil
+		},
+	})
+}
+
 // registerAutoTestCommands AutoTest関連のスラッシュコマンドを登録
-func registerAutoTestCommands(cmdHandler *ui.CommandHandler, terminal *ui.Terminal, agt *agent.Agent) {
+func registerAutoTestCommands(cmdHandler *ui.CommandHandler, terminal *ui.Terminal, agt *agent.Agent, cfg *config.Config) {
 	cmdHandler.Register(&ui.SlashCommand{
 		Name:        "autotest",
 		Description: "ファイル編集後の自動テスト実行 [on|off]",
@@ -2817,6 +3737,13 @@ func registerAutoTestCommands(cmdHandler *ui.CommandHandler, terminal *ui.Termin
 					status = "ON"
 				}
 				terminal.PrintColored(ui.ColorCyan, fmt.Sprintf("Auto Test: %s\n", status))
+				if cfg.AutoTestCommand != "" && cfg.Source("AUTOTEST_COMMAND") == config.SourceProject {
+					terminal.Printf("  コマンド: %s (プロジェクト設定: 初回実行前に確認します)\n", cfg.AutoTestCommand)
+				} else if cfg.AutoTestCommand != "" {
+					terminal.Printf("  コマンド: %s\n", cfg.AutoTestCommand)
+				} else {
+					terminal.Println("  コマンド: 自動検出 (go.mod / package.json / pytest / Cargo.toml)")
+				}
 				terminal.Println("  使用方法: /autotest [on|off]")
 				return nil
 			}
@@ -2838,6 +3765,69 @@ func registerAutoTestCommands(cmdHandler *ui.CommandHandler, terminal *ui.Termin
 	})
 }
 
+// registerDryRunCommands /dryrun コマンドを登録
+func registerDryRunCommands(cmdHandler *ui.CommandHandler, terminal *ui.Terminal, agt *agent.Agent) {
+	cmdHandler.Register(&ui.SlashCommand{
+		Name:        "dryrun",
+		Description: "Dry-run モード [on|off] - ツール呼び出しを実行せずに表示",
+		Handler: func(args string) error {
+			switch strings.ToLower(strings.TrimSpace(args)) {
+			case "":
+				status := "OFF"
+				if agt.IsDryRun() {
+					status = "ON"
+				}
+				terminal.PrintColored(ui.ColorCyan, fmt.Sprintf("Dry-run Mode: %s\n", status))
+				terminal.Println("  使用方法: /dryrun [on|off]")
+				return nil
+			case "on":
+				agt.SetDryRun(true)
+				terminal.PrintColored(ui.ColorYellow, "🔍 Dry-run Mode: ON\n")
+				terminal.PrintInfo("read_file, glob, grep などの読み取りツールのみ実行し、それ以外は内容を表示するだけです")
+				return nil
+			case "off":
+				agt.SetDryRun(false)
+				terminal.PrintColored(ui.ColorGreen, "✓ Dry-run Mode: OFF (実行モード)\n")
+				return nil
+			default:
+				terminal.PrintError(fmt.Sprintf("不正な引数: %s\n  使用方法: /dryrun [on|off]", args))
+				return nil
+			}
+		},
+	})
+}
+
+// registerLoopDetectCommands /loopdetect コマンドを登録
+func registerLoopDetectCommands(cmdHandler *ui.CommandHandler, terminal *ui.Terminal, agt *agent.Agent) {
+	cmdHandler.Register(&ui.SlashCommand{
+		Name:        "loopdetect",
+		Description: "ループ検出 [on|off] - 同じツール・同じ引数の繰り返しでターンを止める",
+		Handler: func(args string) error {
+			switch strings.ToLower(strings.TrimSpace(args)) {
+			case "":
+				status := "OFF"
+				if agt.IsLoopDetectionEnabled() {
+					status = "ON"
+				}
+				terminal.PrintColored(ui.ColorCyan, fmt.Sprintf("Loop Detection: %s (同じ呼び出し %d 回でループと判定)\n", status, agt.LoopThreshold()))
+				terminal.Println("  使用方法: /loopdetect [on|off]")
+				return nil
+			case "on":
+				agt.SetLoopDetection(true)
+				terminal.PrintColored(ui.ColorGreen, "✓ Loop Detection: ON\n")
+				return nil
+			case "off":
+				agt.SetLoopDetection(false)
+				terminal.PrintColored(ui.ColorYellow, "⚠️ Loop Detection: OFF（繰り返しても止まりません。Ctrl+C で中断できます）\n")
+				return nil
+			default:
+				terminal.PrintError(fmt.Sprintf("不正な引数: %s\n  使用方法: /loopdetect [on|off]", args))
+				return nil
+			}
+		},
+	})
+}
+
 // registerPlanCommands Plan関連のスラッシュコマンドを登録
 func registerPlanCommands(cmdHandler *ui.CommandHandler, terminal *ui.Terminal, agt *agent.Agent) {
 	cmdHandler.Register(&ui.SlashCommand{
@@ -2852,8 +3842,8 @@ func registerPlanCommands(cmdHandler *ui.CommandHandler, terminal *ui.Terminal,
 				if agt.IsPlanMode() {
 					status = "ON"
 					terminal.PrintColored(ui.ColorCyan, fmt.Sprintf("Plan Mode: %s\n", status))
-					terminal.Println("  ✓ read_file, glob, grep は許可")
-					terminal.Println("  ✗ write_file, edit_file, bash は禁止")
+					terminal.Println("  ✓ read_file, glob, grep, symbols, tail は許可")
+					terminal.Println("  ✗ write_file, edit_file, multi_edit, apply_patch, bash は禁止")
 					terminal.PrintInfo("計画を確認したら '/plan off' で実行モードに切り替えてください")
 					return nil
 				}
@@ -2866,7 +3856,7 @@ func registerPlanCommands(cmdHandler *ui.CommandHandler, terminal *ui.Terminal,
 			case "on":
 				agt.SetPlanMode(true)
 				terminal.PrintColored(ui.ColorYellow, "🔒 Plan Mode: ON\n")
-				terminal.PrintInfo("write_file, edit_file, bash は実行できません")
+				terminal.PrintInfo("write_file, edit_file, multi_edit, apply_patch, bash は実行できません")
 				terminal.PrintInfo("計画が完成したら '/plan off' で実行モードに切り替えてください")
 				return nil
 			case "off":
@@ -2882,12 +3872,77 @@ func registerPlanCommands(cmdHandler *ui.CommandHandler, terminal *ui.Terminal,
 	})
 }
 
+// refreshDetectedProviders ローカルプロバイダーを再検出し、新しく見つかったものをチェーンに追加する
+// 起動後に Ollama などを立ち上げた場合に再起動せず使えるようにする
+func refreshDetectedProviders(terminal *ui.Terminal, provider llm.LLMProvider, cfg *config.Config) {
+	terminal.PrintColored(ui.ColorCyan, "🔍 LLMプロバイダーを再検出中...\n")
+	detected := llm.AutoDetect(context.Background())
+	if len(detected) == 0 {
+		terminal.PrintColored(ui.ColorYellow, "ローカルプロバイダーは見つかりませんでした\n")
+		return
+	}
+
+	chain, ok := provider.(*llm.ProviderChain)
+	if !ok {
+		// 単一プロバイダーモードではチェーンに追加できないため検出結果のみ表示
+		terminal.PrintColored(ui.ColorYellow, "プロバイダーチェーンは無効です（単一プロバイダーモード）\n")
+		for _, d := range detected {
+			terminal.Printf("  %s %s (%s, モデル: %d件)\n", ui.ProviderIcon(d.Name), d.Name, d.URL, len(d.Models))
+		}
+		terminal.PrintColored(ui.ColorGray, "  /provider で切り替えるか、再起動するとチェーンに追加されます\n")
+		return
+	}
+
+	fresh := chain.NewlyDetected(detected)
+	if len(fresh) == 0 {
+		terminal.PrintColored(ui.ColorGreen, fmt.Sprintf("✓ 新しいプロバイダーはありません（検出: %d件、すべて登録済み）\n", len(detected)))
+		return
+	}
+
+	terminal.PrintColored(ui.ColorGreen, fmt.Sprintf("✓ 新しく %d 件のプロバイダーを検出しました\n", len(fresh)))
+	for _, d := range fresh {
+		model := "(モデルなし)"
+		if len(d.Models) > 0 {
+			model = d.Models[0]
+		}
+		terminal.Printf("  + %s %s (%s, モデル: %s)\n", ui.ProviderIcon(d.Name), d.Name, d.URL, model)
+	}
+
+	answer, _ := terminal.ReadLine("サブプロバイダーとしてチェーンに追加しますか？ [Y/n]: ")
+	if answer == "n" || answer == "N" {
+		terminal.PrintColored(ui.ColorGray, "追加をキャンセルしました\n")
+		return
+	}
+
+	added := chain.AddDetected(fresh, func(d llm.DetectedProvider) llm.LLMProvider {
+		return createDetectedProvider(cfg, d)
+	})
+	terminal.PrintColored(ui.ColorGreen, fmt.Sprintf("✓ %d 件をチェーンに追加しました（/chain で確認）\n", len(added)))
+}
+
 // registerProvidersStatusCommand プロバイダー状態確認コマンドを登録（T-8503）
-func registerProvidersStatusCommand(cmdHandler *ui.CommandHandler, terminal *ui.Terminal, provider llm.LLMProvider) {
+func registerProvidersStatusCommand(cmdHandler *ui.CommandHandler, terminal *ui.Terminal, provider llm.LLMProvider, cfg *config.Config) {
+	// 単一プロバイダー用の接続確認キャッシュ（チェーンはチェーン自身のキャッシュを使う）
+	health := llm.NewHealthCache(llm.DefaultHealthCacheTTL)
+
 	cmdHandler.Register(&ui.SlashCommand{
 		Name:        "providers",
 		Description: "登録済みプロバイダーの接続状況と一覧を表示",
 		Handler: func(args string) error {
+			// --force: キャッシュ（15秒）を使わずに接続を再確認する
+			force := false
+			for _, arg := range strings.Fields(args) {
+				switch arg {
+				case "refresh":
+					refreshDetectedProviders(terminal, provider, cfg)
+				case "--force":
+					force = true
+				default:
+					terminal.PrintError(fmt.Sprintf("不正な引数: %s\n  使用方法: /providers [refresh] [--force]", args))
+					return nil
+				}
+			}
+
 			terminal.PrintColored(ui.ColorCyan, "━━ Providers ━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n")
 
 			// ProviderChain の場合は全エントリを表示
@@ -2895,6 +3950,7 @@ func registerProvidersStatusCommand(cmdHandler *ui.CommandHandler, terminal *ui.
 				entries := chain.GetEntries()
 				currentProvider := chain.GetCurrentProvider()
 				currentInfo := currentProvider.Info()
+				healthErrs := chain.CheckAllHealth(context.Background(), force)
 
 				for i, e := range entries {
 					info := e.Provider.Info()
@@ -2907,11 +3963,10 @@ func registerProvidersStatusCommand(cmdHandler *ui.CommandHandler, terminal *ui.
 						marker = "▶ "
 					}
 
-					// 接続チェック
-					ctx := context.Background()
+					// 接続チェック（並行実行・キャッシュ済み）
 					status := "✅"
 					statusMsg := "接続OK"
-					if err := e.Provider.CheckHealth(ctx); err != nil {
+					if i < len(healthErrs) && healthErrs[i] != nil {
 						status = "❌"
 						statusMsg = "接続不可"
 					}
@@ -2943,9 +3998,8 @@ func registerProvidersStatusCommand(cmdHandler *ui.CommandHandler, terminal *ui.
 				info := provider.Info()
 				icon := ui.ProviderIcon(info.Name)
 
-				ctx := context.Background()
 				status := "✅ 接続OK"
-				if err := provider.CheckHealth(ctx); err != nil {
+				if err := health.Check(context.Background(), []llm.LLMProvider{provider}, force)[0]; err != nil {
 					status = fmt.Sprintf("❌ 接続不可: %v", err)
 				}
 
@@ -2963,13 +4017,19 @@ func registerProvidersStatusCommand(cmdHandler *ui.CommandHandler, terminal *ui.
 }
 
 // registerWatchCommands はファイル監視関連のスラッシュコマンドを登録する（T-14203）
-func registerWatchCommands(cmdHandler *ui.CommandHandler, terminal *ui.Terminal, agt *agent.Agent) {
+func registerWatchCommands(cmdHandler *ui.CommandHandler, terminal *ui.Terminal, agt *agent.Agent, registry *tool.Registry) {
 	var fw *watcher.FileWatcher
 	var injector *watcher.Injector
 
+	// 変更ファイルを symbols ツールのインデックスから無効化する
+	var symbolsTool *tool.SymbolsTool
+	if t, ok := registry.GetTool("symbols"); ok {
+		symbolsTool, _ = t.(*tool.SymbolsTool)
+	}
+
 	cmdHandler.Register(&ui.SlashCommand{
 		Name:        "watch",
-		Description: "ファイル監視（/watch *.go で開始, /watch off で停止）",
+		Description: "ファイル監視（/watch [--debounce ms] *.go で開始, /watch off で停止）",
 		Handler: func(args string) error {
 			args = strings.TrimSpace(args)
 
@@ -2982,6 +4042,7 @@ func registerWatchCommands(cmdHandler *ui.CommandHandler, terminal *ui.Terminal,
 					terminal.PrintColored(ui.ColorGreen, "ファイル監視: ON\n")
 					terminal.Printf("  パターン: %s\n", strings.Join(fw.Patterns(), ", "))
 					terminal.Printf("  監視ファイル数: %d\n", fw.WatchedFileCount())
+					terminal.Printf("  デバウンス: %dms\n", fw.Debounce().Milliseconds())
 				}
 				return nil
 			}
@@ -2997,7 +4058,14 @@ func registerWatchCommands(cmdHandler *ui.CommandHandler, terminal *ui.Terminal,
 				return nil
 			}
 
-			// /watch <patterns> — 開始
+			// /watch [--debounce ms] <patterns> — 開始
+			debounce, patterns, err := parseWatchArgs(args)
+			if err != nil {
+				terminal.PrintColored(ui.ColorRed, fmt.Sprintf("エラー: %v\n", err))
+				terminal.Printf("  使い方: /watch [--debounce ms] <pattern>...\n")
+				return nil
+			}
+
 			// 既存の watcher があれば停止
 			if fw != nil && fw.IsRunning() {
 				fw.Stop()
@@ -3011,16 +4079,16 @@ func registerWatchCommands(cmdHandler *ui.CommandHandler, terminal *ui.Terminal,
 			}
 
 			fw = watcher.NewFileWatcher(cwd)
+			fw.SetDebounce(debounce)
 			injector = watcher.NewInjector(agt.GetSession())
 
-			patterns := strings.Fields(args)
 			if err := fw.Start(patterns); err != nil {
 				terminal.PrintColored(ui.ColorRed, fmt.Sprintf("監視開始エラー: %v\n", err))
 				return nil
 			}
 
 			terminal.PrintColored(ui.ColorGreen, fmt.Sprintf("ファイル監視を開始しました: %s\n", strings.Join(patterns, ", ")))
-			terminal.Printf("  監視ファイル数: %d\n", fw.WatchedFileCount())
+			terminal.Printf("  監視ファイル数: %d（デバウンス %dms）\n", fw.WatchedFileCount(), fw.Debounce().Milliseconds())
 
 			// イベントリスナー goroutine
 			go func() {
@@ -3030,6 +4098,13 @@ func registerWatchCommands(cmdHandler *ui.CommandHandler, terminal *ui.Terminal,
 						for _, ev := range events {
 							terminal.Printf("  %s: %s\n", ev.EventType, ev.Path)
 						}
+						if symbolsTool != nil {
+							paths := make([]string, 0, len(events))
+							for _, ev := range events {
+								paths = append(paths, ev.Path)
+							}
+							symbolsTool.Invalidate(paths...)
+						}
 						injector.InjectChanges(events)
 					}
 				}
@@ -3040,6 +4115,40 @@ func registerWatchCommands(cmdHandler *ui.CommandHandler, terminal *ui.Terminal,
 	})
 }
 
+// parseWatchArgs は /watch の引数から --debounce（ミリ秒）とパターンを取り出す
+// --debounce 省略時は 0（watcher のデフォルト）を返す
+func parseWatchArgs(args string) (time.Duration, []string, error) {
+	var debounce time.Duration
+	var patterns []string
+
+	fields := strings.Fields(args)
+	for i := 0; i < len(fields); i++ {
+		f := fields[i]
+		value, ok := strings.CutPrefix(f, "--debounce=")
+		if !ok && f != "--debounce" {
+			patterns = append(patterns, f)
+			continue
+		}
+		if !ok {
+			if i+1 >= len(fields) {
+				return 0, nil, fmt.Errorf("--debounce にはミリ秒を指定してください")
+			}
+			i++
+			value = fields[i]
+		}
+		ms, err := strconv.Atoi(value)
+		if err != nil || ms <= 0 {
+			return 0, nil, fmt.Errorf("--debounce の値が不正です: %s", value)
+		}
+		debounce = time.Duration(ms) * time.Millisecond
+	}
+
+	if len(patterns) == 0 {
+		return 0, nil, fmt.Errorf("監視するパターンを指定してください")
+	}
+	return debounce, patterns, nil
+}
+
 // registerChainCommands は /chain コマンドを登録する
 func registerChainCommands(cmdHandler *ui.CommandHandler, terminal *ui.Terminal, provider llm.LLMProvider) {
 	cmdHandler.Register(&ui.SlashCommand{
@@ -3056,10 +4165,11 @@ func registerChainCommands(cmdHandler *ui.CommandHandler, terminal *ui.Terminal,
 
 			args = strings.TrimSpace(args)
 
-			// /chain — 状態表示
-			if args == "" {
+			// /chain [--force] — 状態表示（接続確認は /providers とキャッシュを共有）
+			if args == "" || args == "--force" {
 				entries := chain.GetEntries()
 				current := chain.CurrentIndex()
+				healthErrs := chain.CheckAllHealth(context.Background(), args == "--force")
 				terminal.PrintColored(ui.ColorCyan, "━━━ プロバイダーチェーン ━━━\n")
 				for i, e := range entries {
 					info := e.Provider.Info()
@@ -3074,8 +4184,12 @@ func registerChainCommands(cmdHandler *ui.CommandHandler, terminal *ui.Terminal,
 						failTime := chain.GetFailureTime(i)
 						failInfo = fmt.Sprintf(" (失敗: %d回, 最終: %s)", failCount, failTime.Format("15:04:05"))
 					}
-					terminal.Printf("  %s%s %s [%s] model=%s%s\n",
-						marker, icon, info.Name, string(e.Role), info.Model, failInfo)
+					status := "✅"
+					if i < len(healthErrs) && healthErrs[i] != nil {
+						status = "❌"
+					}
+					terminal.Printf("  %s%s %s %s [%s] model=%s%s\n",
+						marker, status, icon, info.Name, string(e.Role), info.Model, failInfo)
 				}
 				terminal.Printf("\n  フォールバック: 有効\n")
 				if lastErr := chain.GetLastError(); lastErr != nil {
@@ -3096,7 +4210,848 @@ func registerChainCommands(cmdHandler *ui.CommandHandler, terminal *ui.Terminal,
 				return nil
 			}
 
-			terminal.PrintColored(ui.ColorYellow, "使い方: /chain (状態表示) | /chain <番号> (切替)\n")
+			terminal.PrintColored(ui.ColorYellow, "使い方: /chain [--force] (状態表示) | /chain <番号> (切替)\n")
+			return nil
+		},
+	})
+}
+
+// registerUndoCommands は /undo, /redo コマンドを登録する
+func registerUndoCommands(cmdHandler *ui.CommandHandler, terminal *ui.Terminal, registry *tool.Registry) {
+	cmdHandler.Register(&ui.SlashCommand{
+		Name:        "undo",
+		Description: "直前の write_file / edit_file / make_directory による変更を取り消す",
+		Handler: func(args string) error {
+			writeTool, ok := registry.GetWriteTool()
+			if !ok {
+				terminal.PrintColored(ui.ColorYellow, "write_file ツールが登録されていません\n")
+				return nil
+			}
+
+			stack := writeTool.GetUndoStack()
+			if len(stack) == 0 {
+				terminal.PrintColored(ui.ColorYellow, "取り消せる変更はありません\n")
+				return nil
+			}
+
+			// Undo() は最新のエントリを取り出すので、事前に対象を控えておく
+			entry := stack[len(stack)-1]
+			if err := writeTool.Undo(); err != nil {
+				terminal.PrintColored(ui.ColorRed, fmt.Sprintf("取り消しエラー: %v\n", err))
+				return nil
+			}
+
+			if entry.MovedFrom != "" {
+				terminal.PrintColored(ui.ColorGreen, fmt.Sprintf("✓ 移動を元に戻しました: %s → %s\n", entry.Path, entry.MovedFrom))
+			} else if len(entry.Files) > 0 {
+				terminal.PrintColored(ui.ColorGreen, fmt.Sprintf("✓ パッチの変更を元に戻しました (%d ファイル)\n", len(entry.Files)))
+			} else if entry.IsDir {
+				terminal.PrintColored(ui.ColorGreen, fmt.Sprintf("✓ 作成されたディレクトリを削除しました: %s\n", entry.Path))
+			} else if entry.Deleted {
+				terminal.PrintColored(ui.ColorGreen, fmt.Sprintf("✓ 削除されたファイルを復元しました: %s\n", entry.Path))
+			} else if entry.OldContent == "" {
+				terminal.PrintColored(ui.ColorGreen, fmt.Sprintf("✓ 新規作成されたファイルを削除しました: %s\n", entry.Path))
+			} else {
+				terminal.PrintColored(ui.ColorGreen, fmt.Sprintf("✓ ファイルを元に戻しました: %s\n", entry.Path))
+			}
+			if remaining := len(stack) - 1; remaining > 0 {
+				terminal.PrintColored(ui.ColorGray, fmt.Sprintf("  (残り %d 件取り消し可能)\n", remaining))
+			}
+			return nil
+		},
+	})
+
+	cmdHandler.Register(&ui.SlashCommand{
+		Name:        "redo",
+		Description: "/undo で取り消した変更をやり直す",
+		Handler: func(args string) error {
+			writeTool, ok := registry.GetWriteTool()
+			if !ok {
+				terminal.PrintColored(ui.ColorYellow, "write_file ツールが登録されていません\n")
+				return nil
+			}
+
+			stack := writeTool.GetRedoStack()
+			if len(stack) == 0 {
+				terminal.PrintColored(ui.ColorYellow, "やり直せる変更はありません\n")
+				return nil
+			}
+
+			entry := stack[len(stack)-1]
+			if err := writeTool.Redo(); err != nil {
+				terminal.PrintColored(ui.ColorRed, fmt.Sprintf("やり直しエラー: %v\n", err))
+				return nil
+			}
+
+			if entry.MovedFrom != "" {
+				terminal.PrintColored(ui.ColorGreen, fmt.Sprintf("✓ 移動をやり直しました: %s → %s\n", entry.MovedFrom, entry.Path))
+			} else if len(entry.Files) > 0 {
+				terminal.PrintColored(ui.ColorGreen, fmt.Sprintf("✓ パッチの変更をやり直しました (%d ファイル)\n", len(entry.Files)))
+			} else if entry.IsDir {
+				terminal.PrintColored(ui.ColorGreen, fmt.Sprintf("✓ ディレクトリを再作成しました: %s\n", entry.Path))
+			} else {
+				terminal.PrintColored(ui.ColorGreen, fmt.Sprintf("✓ 変更をやり直しました: %s\n", entry.Path))
+			}
+			if remaining := len(stack) - 1; remaining > 0 {
+				terminal.PrintColored(ui.ColorGray, fmt.Sprintf("  (残り %d 件やり直し可能)\n", remaining))
+			}
+			return nil
+		},
+	})
+}
+
+// registerSearchCommands は /search コマンドを登録する
+func registerSearchCommands(cmdHandler *ui.CommandHandler, terminal *ui.Terminal, persistenceMgr *session.PersistenceManager) {
+	cmdHandler.Register(&ui.SlashCommand{
+		Name:        "search",
+		Description: "保存済みセッションをキーワード検索",
+		Handler: func(args string) error {
+			query := strings.TrimSpace(args)
+			if query == "" {
+				terminal.Println("使用方法: /search <キーワード>")
+				return nil
+			}
+
+			hits, err := persistenceMgr.SearchSessions(query)
+			if err != nil {
+				terminal.PrintColored(ui.ColorRed, fmt.Sprintf("セッション検索エラー: %v\n", err))
+				return nil
+			}
+
+			printSearchHits(terminal, query, hits)
+			return nil
+		},
+	})
+}
+
+// registerSnapshotCommands は /snapshot, /restore を登録する
+// スナップショットはメモリ上のみで保持し、/clear で破棄する
+func registerSnapshotCommands(cmdHandler *ui.CommandHandler, terminal *ui.Terminal, agt *agent.Agent) {
+	store := session.NewSnapshotStore()
+
+	cmdHandler.Register(&ui.SlashCommand{
+		Name:        "snapshot",
+		Description: "会話状態をメモリに保存（/snapshot [name], /snapshot list）",
+		Handler: func(args string) error {
+			name := strings.TrimSpace(args)
+
+			if name == "list" {
+				snaps := store.List()
+				if len(snaps) == 0 {
+					terminal.Println("スナップショットはありません")
+					return nil
+				}
+				terminal.PrintColored(ui.ColorCyan, "═══ スナップショット ═══\n")
+				for _, snap := range snaps {
+					terminal.Printf("  %-16s %3d messages, ~%d tokens (%s)\n",
+						snap.Name, len(snap.Messages), snap.TokenEstimate, snap.CreatedAt.Format("15:04:05"))
+				}
+				return nil
+			}
+
+			snap := store.Save(agt.GetSession(), name)
+			terminal.PrintColored(ui.ColorGreen, fmt.Sprintf("✓ スナップショット '%s' を保存しました（%d messages）\n", snap.Name, len(snap.Messages)))
+			return nil
+		},
+	})
+
+	cmdHandler.Register(&ui.SlashCommand{
+		Name:        "restore",
+		Description: "スナップショットに戻す（/restore [name]）",
+		Handler: func(args string) error {
+			name := strings.TrimSpace(args)
+
+			snap, ok := store.Get(name)
+			if !ok {
+				if name == "" {
+					terminal.PrintColored(ui.ColorYellow, "スナップショットがありません（/snapshot で保存）\n")
+				} else {
+					terminal.PrintColored(ui.ColorYellow, fmt.Sprintf("スナップショット '%s' が見つかりません\n", name))
+				}
+				return nil
+			}
+
+			agt.GetSession().Restore(snap)
+			terminal.PrintColored(ui.ColorGreen, fmt.Sprintf("✓ スナップショット '%s' に戻しました（%d messages）\n", snap.Name, len(snap.Messages)))
+			return nil
+		},
+	})
+
+	// /clear は会話とスナップショットの両方を破棄する（既定のスタブを上書き）
+	// システムプロンプトは現在の設定から作り直すので、指示は失われない
+	cmdHandler.Register(&ui.SlashCommand{
+		Name:        "clear",
+		Description: "セッションをクリア（/clear -y で確認なし）",
+		Handler: func(args string) error {
+			count := agt.GetSession().GetMessageCount()
+			if count == 0 {
+				terminal.Println("クリアする会話はありません")
+				return nil
+			}
+
+			if arg := strings.TrimSpace(args); arg != "-y" && arg != "--yes" {
+				confirm, _ := terminal.ReadLine(fmt.Sprintf("%d 件のメッセージを削除します。よろしいですか？ [y/N]: ", count))
+				if confirm != "y" && confirm != "Y" {
+					terminal.Println("キャンセルしました")
+					return nil
+				}
+			}
+
+			cleared := agt.Clear()
+			store.Clear()
+			terminal.PrintColored(ui.ColorGreen, fmt.Sprintf("✓ セッションをクリアしました（%d 件のメッセージを削除）\n", cleared))
+			return nil
+		},
+	})
+}
+
+// registerSaveOutputCommands は /save-output を登録する
+// ターミナルが保持している直近の出力（色コードなし）をファイルに書き出す
+func registerSaveOutputCommands(cmdHandler *ui.CommandHandler, terminal *ui.Terminal) {
+	cmdHandler.Register(&ui.SlashCommand{
+		Name:        "save-output",
+		Description: "直近のターミナル出力をファイルに保存（/save-output [file] [N行]）",
+		Handler: func(args string) error {
+			history := terminal.GetOutputHistory()
+			if history == nil {
+				terminal.PrintColored(ui.ColorYellow, "出力履歴が記録されていません\n")
+				return nil
+			}
+
+			// 引数は順不同: 数値なら行数、それ以外はファイル名
+			path := ""
+			lastN := 0
+			for _, field := range strings.Fields(args) {
+				if n, err := strconv.Atoi(field); err == nil && n > 0 {
+					lastN = n
+				} else {
+					path = field
+				}
+			}
+			if path == "" {
+				path = fmt.Sprintf("vibe-output-%s.txt", time.Now().Format("20060102-150405"))
+			}
+
+			lines := history.Lines(lastN)
+			if len(lines) == 0 {
+				terminal.PrintColored(ui.ColorYellow, "保存する出力がありません\n")
+				return nil
+			}
+
+			content := strings.Join(lines, "\n") + "\n"
+			if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+				terminal.PrintColored(ui.ColorRed, fmt.Sprintf("出力の保存に失敗しました: %v\n", err))
+				return nil
+			}
+			terminal.PrintColored(ui.ColorGreen, fmt.Sprintf("✓ %d 行を %s に保存しました\n", len(lines), path))
+			return nil
+		},
+	})
+}
+
+// registerChoicesCommands は /choices を登録する
+// 次の1ターンだけ N 個の候補を生成し、ユーザーが選んだ1つで会話を続ける
+func registerChoicesCommands(cmdHandler *ui.CommandHandler, terminal *ui.Terminal, agt *agent.Agent) {
+	cmdHandler.Register(&ui.SlashCommand{
+		Name:        "choices",
+		Description: "次の応答で複数候補を生成して選択（/choices <N>, /choices off）",
+		Handler: func(args string) error {
+			arg := strings.TrimSpace(args)
+			if arg == "" {
+				if n := agt.GetChoices(); n > 1 {
+					terminal.Printf("次のターンで %d 個の候補を生成します\n", n)
+				} else {
+					terminal.Println("複数候補モード: OFF（/choices <N> で有効化）")
+				}
+				return nil
+			}
+
+			n := 0
+			if arg != "off" {
+				v, err := strconv.Atoi(arg)
+				if err != nil || v < 1 {
+					terminal.PrintColored(ui.ColorYellow, "使い方: /choices <N>（2以上）または /choices off\n")
+					return nil
+				}
+				n = v
+			}
+
+			if err := agt.SetChoices(n); err != nil {
+				terminal.PrintColored(ui.ColorYellow, fmt.Sprintf("複数候補は使えません: %v\n", err))
+				return nil
+			}
+			if n > 1 {
+				terminal.PrintColored(ui.ColorGreen, fmt.Sprintf("✓ 次のターンで %d 個の候補を生成します\n", n))
+			} else {
+				terminal.Println("複数候補モード: OFF")
+			}
+			return nil
+		},
+	})
+}
+
+// registerTraceCommands は /trace（直近のターンのツール呼び出し一覧）を登録する
+func registerTraceCommands(cmdHandler *ui.CommandHandler, terminal *ui.Terminal, agt *agent.Agent) {
+	cmdHandler.Register(&ui.SlashCommand{
+		Name:        "trace",
+		Description: "直近のターンのツール呼び出しと成否を順に表示",
+		Handler: func(args string) error {
+			trace := agt.GetSession().LastTurnTrace()
+			if len(trace) == 0 {
+				terminal.Println("ツール呼び出しはまだありません")
+				return nil
+			}
+
+			terminal.Printf("ツール呼び出し (%d 件):\n", len(trace))
+			for i, entry := range trace {
+				mark, color := "✓", ui.ColorGreen
+				switch {
+				case !entry.HasResult:
+					mark, color = "…", ui.ColorGray
+				case entry.IsError:
+					mark, color = "✗", ui.ColorRed
+				}
+				terminal.PrintColored(color, fmt.Sprintf("  %2d. %s %s", i+1, mark, entry.Name))
+				terminal.Printf(" %s\n", traceSnippet(entry.Arguments, 80))
+				if entry.HasResult && entry.Result != "" {
+					terminal.PrintColored(ui.ColorGray, fmt.Sprintf("      → %s\n", traceSnippet(entry.Result, 80)))
+				}
+			}
+			return nil
+		},
+	})
+}
+
+// registerRetryCommands は /retry（直前のメッセージを再実行）を登録する
+func registerRetryCommands(cmdHandler *ui.CommandHandler, terminal *ui.Terminal, agt *agent.Agent) {
+	cmdHandler.Register(&ui.SlashCommand{
+		Name:        "retry",
+		Description: "直前のメッセージを再実行（keep で失敗したやり取りを履歴に残す）",
+		Handler: func(args string) error {
+			args = strings.TrimSpace(args)
+			if args != "" && args != "keep" {
+				terminal.Println("使い方: /retry [keep]")
+				return nil
+			}
+
+			input, err := agt.PrepareRetry(args == "keep")
+			if errors.Is(err, agent.ErrNothingToRetry) {
+				terminal.PrintColored(ui.ColorYellow, "再実行できるメッセージがありません。まず指示を入力してください\n")
+				return nil
+			}
+			terminal.PrintColored(ui.ColorCyan, fmt.Sprintf("↻ 再実行: %s\n", traceSnippet(input, 80)))
+			cmdHandler.Submit(input)
+			return nil
+		},
+	})
+}
+
+// registerExportCommands は /export（会話を Markdown / JSON で書き出す）を登録する
+func registerExportCommands(cmdHandler *ui.CommandHandler, terminal *ui.Terminal, agt *agent.Agent, cfg *config.Config) {
+	cmdHandler.Register(&ui.SlashCommand{
+		Name:        "export",
+		Description: "会話をファイルに書き出す（/export <file> [--format json]）",
+		Handler: func(args string) error {
+			path, format := "", "markdown"
+			fields := strings.Fields(args)
+			for i := 0; i < len(fields); i++ {
+				switch field := fields[i]; {
+				case field == "--format" && i+1 < len(fields):
+					i++
+					format = fields[i]
+				case strings.HasPrefix(field, "--format="):
+					format = strings.TrimPrefix(field, "--format=")
+				default:
+					path = field
+				}
+			}
+			if format == "md" {
+				format = "markdown"
+			}
+			if format != "markdown" && format != "json" {
+				terminal.PrintColored(ui.ColorYellow, "使い方: /export <file> [--format markdown|json]\n")
+				return nil
+			}
+
+			sess := agt.GetSession()
+			if sess.GetMessageCount() == 0 {
+				terminal.PrintColored(ui.ColorYellow, "書き出す会話がありません\n")
+				return nil
+			}
+			if path == "" {
+				ext := ".md"
+				if format == "json" {
+					ext = ".json"
+				}
+				path = fmt.Sprintf("vibe-session-%s%s", time.Now().Format("20060102-150405"), ext)
+			}
+
+			if _, err := os.Stat(path); err == nil {
+				answer, _ := terminal.ReadLine(fmt.Sprintf("%s は既に存在します。上書きしますか？ [y/N]: ", path))
+				if a := strings.ToLower(strings.TrimSpace(answer)); a != "y" && a != "yes" {
+					terminal.Println("書き出しを中止しました")
+					return nil
+				}
+			}
+
+			info := session.ExportInfo{Model: cfg.Model, Date: time.Now()}
+			var data []byte
+			if format == "json" {
+				var err error
+				if data, err = sess.ExportJSON(info); err != nil {
+					terminal.PrintColored(ui.ColorRed, fmt.Sprintf("書き出しに失敗しました: %v\n", err))
+					return nil
+				}
+			} else {
+				data = []byte(sess.ExportMarkdown(info))
+			}
+
+			if err := os.WriteFile(path, data, 0644); err != nil {
+				terminal.PrintColored(ui.ColorRed, fmt.Sprintf("書き出しに失敗しました: %v\n", err))
+				return nil
+			}
+			terminal.PrintColored(ui.ColorGreen, fmt.Sprintf("✓ %d 件のメッセージを %s に書き出しました\n", sess.GetMessageCount(), path))
+			return nil
+		},
+	})
+}
+
+// registerUsageCommands は /usage と /cost を登録する（/tokens の既定のスタブも上書き）
+func registerUsageCommands(cmdHandler *ui.CommandHandler, terminal *ui.Terminal, agt *agent.Agent, cfg *config.Config) {
+	handler := func(args string) error {
+		showTokenUsage(terminal, agt.GetSession())
+		showTokenBudget(terminal, agt, cfg)
+		return nil
+	}
+	cmdHandler.Register(&ui.SlashCommand{
+		Name:        "cost",
+		Description: "コストの概算とクラウドのトークン予算（--budget-tokens）を表示",
+		Handler:     handler,
+	})
+	cmdHandler.Register(&ui.SlashCommand{
+		Name:        "usage",
+		Description: "セッションの累計トークン数とコストの概算を表示",
+		Handler:     handler,
+	})
+	cmdHandler.Register(&ui.SlashCommand{
+		Name:        "tokens",
+		Description: "トークン使用量を表示",
+		Handler:     handler,
+	})
+}
+
+// showTokenUsage はモデルごとの累計トークン数と、料金表にあるモデルのコスト概算を表示する
+func showTokenUsage(terminal *ui.Terminal, sess *session.Session) {
+	usage := sess.GetTokenUsage()
+	if len(usage) == 0 {
+		terminal.Println("このセッションのトークン使用量はまだありません")
+		return
+	}
+
+	terminal.PrintColored(ui.ColorCyan, "━━━ トークン使用量（このセッション） ━━━\n")
+	var totalCost float64
+	unpriced := 0
+	for _, u := range usage {
+		name := u.Model
+		if u.Provider != "" {
+			name = u.Provider + "/" + u.Model
+		}
+		terminal.Printf("  %-40s %4d 回  入力 %9d  出力 %9d  ", name, u.Calls, u.PromptTokens, u.CompletionTokens)
+		if cost, ok := llm.EstimateCost(u.Provider, u.Model, u.PromptTokens, u.CompletionTokens); ok {
+			totalCost += cost
+			terminal.Printf("$%.4f\n", cost)
+		} else {
+			unpriced++
+			terminal.PrintColored(ui.ColorGray, "コスト不明\n")
+		}
+	}
+
+	total := sess.TotalTokenUsage()
+	terminal.Printf("\n合計: %d トークン（入力 %d / 出力 %d、%d 回）\n", total.Total(), total.PromptTokens, total.CompletionTokens, total.Calls)
+	switch {
+	case unpriced == len(usage):
+		terminal.Println("概算コスト: 不明（料金表にないモデル）")
+	case unpriced > 0:
+		terminal.Printf("概算コスト: $%.4f（料金表にないモデルを除く）\n", totalCost)
+	default:
+		terminal.Printf("概算コスト: $%.4f\n", totalCost)
+	}
+}
+
+// showTokenBudget はクラウドプロバイダーのトークン予算の使用状況を表示する（予算未設定なら何もしない）
+func showTokenBudget(terminal *ui.Terminal, agt *agent.Agent, cfg *config.Config) {
+	if cfg.BudgetTokens <= 0 {
+		return
+	}
+	used := agt.CloudTokensUsed()
+	color := ui.ColorGreen
+	if used > cfg.BudgetTokens {
+		color = ui.ColorYellow
+	}
+	terminal.PrintColored(color, fmt.Sprintf("クラウド予算: %d / %d トークン（%d%%、超えるとクラウド呼び出しの前に確認）\n",
+		used, cfg.BudgetTokens, used*100/cfg.BudgetTokens))
+}
+
+// showModelInfo は現在のモデルのコンテキスト長・対応機能をカード形式で表示する。
+// プロバイダーがメタ情報を返せない場合は Features と CloudProviders の定義から分かる範囲を表示する
+func showModelInfo(terminal *ui.Terminal, provider llm.LLMProvider, cfg *config.Config) {
+	if chain, ok := provider.(*llm.ProviderChain); ok {
+		if current := chain.GetCurrentProvider(); current != nil {
+			provider = current
+		}
+	}
+	info := provider.Info()
+	model := cfg.Model
+	if model == "" {
+		model = info.Model
+	}
+
+	var meta *llm.ModelInfo
+	if mip, ok := provider.(llm.ModelInfoProvider); ok {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		m, err := mip.ModelInfo(ctx, model)
+		cancel()
+		if err != nil {
+			terminal.PrintColored(ui.ColorYellow, fmt.Sprintf("⚠ モデル情報を取得できませんでした: %v\n", err))
+		} else {
+			meta = m
+		}
+	}
+
+	// 対応機能: メタ情報に capabilities があればそれを、なければ Features を使う
+	yesNo := func(ok bool) string {
+		if ok {
+			return "✓ 対応"
+		}
+		return "✗ 非対応"
+	}
+	capability := func(name string, fallback string) string {
+		if meta != nil && len(meta.Capabilities) > 0 {
+			return yesNo(meta.HasCapability(name))
+		}
+		return fallback
+	}
+
+	terminal.PrintColored(ui.ColorCyan, "━━━ モデル情報 ━━━\n")
+	terminal.Printf("  モデル:           %s\n", model)
+	terminal.Printf("  プロバイダー:     %s (%s)\n", info.Name, info.Type)
+	if meta != nil {
+		details := make([]string, 0, 3)
+		for _, d := range []string{meta.Family, meta.ParameterSize, meta.Quantization} {
+			if d != "" {
+				details = append(details, d)
+			}
+		}
+		if len(details) > 0 {
+			terminal.Printf("  詳細:             %s\n", strings.Join(details, " / "))
+		}
+	}
+
+	switch {
+	case meta != nil && meta.ContextLength > 0:
+		terminal.Printf("  コンテキスト長:   %d トークン（設定値 %d）\n", meta.ContextLength, cfg.ContextWindow)
+	default:
+		terminal.Printf("  コンテキスト長:   不明（設定値 %d）\n", cfg.ContextWindow)
+	}
+
+	terminal.Printf("  関数呼び出し:     %s\n", capability("tools", yesNo(info.Features.NativeFunctionCalling)))
+	terminal.Printf("  画像入力:         %s\n", capability("vision", "不明"))
+	terminal.Printf("  ストリーミング:   %s\n", yesNo(info.Features.Streaming))
+	terminal.Printf("  複数候補 (n>1):   %s\n", yesNo(info.Features.MultipleChoices))
+	if meta != nil && meta.HasCapability("thinking") {
+		terminal.Printf("  思考モード:       ✓ 対応\n")
+	}
+
+	// クラウドプロバイダーは定義にある推奨モデル・料金も表示
+	if def := llm.GetCloudProviderDef(cfg.Provider); def != nil {
+		canonical, _ := llm.NormalizeModelName(cfg.Provider, model)
+		if slices.Contains(def.Models, canonical) {
+			terminal.Printf("  推奨モデル:       ✓ %s の推奨モデル\n", def.Name)
+		}
+		if price, ok := def.Prices[canonical]; ok {
+			terminal.Printf("  料金 (100万tok):  入力 $%.2f / 出力 $%.2f\n", price.Input, price.Output)
+		}
+	}
+	if meta == nil {
+		terminal.PrintColored(ui.ColorGray, "  ※ プロバイダーがメタ情報を返さないため、既知の設定から表示しています\n")
+	}
+}
+
+// traceSnippet は改行を詰めて max 文字で切り詰めた 1 行表示を返す
+func traceSnippet(s string, max int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if r := []rune(s); len(r) > max {
+		return string(r[:max]) + "…"
+	}
+	return s
+}
+
+// registerStatusCommands は /status を登録する（既定のスタブを上書き）
+// --no-banner / --minimal で省略したバナー情報もここで確認できる
+func registerStatusCommands(cmdHandler *ui.CommandHandler, terminal *ui.Terminal, cfg *config.Config, router *llm.ModelRouter, provider llm.LLMProvider, agt *agent.Agent) {
+	cmdHandler.Register(&ui.SlashCommand{
+		Name:        "status",
+		Description: "ステータスを表示",
+		Handler: func(args string) error {
+			opts := bannerOptions(cfg, router, provider)
+			if sw, ok := provider.(llm.ModelSwitcher); ok && sw.GetModel() != "" {
+				opts.ModelName = sw.GetModel()
+			}
+			opts.AutoApprove = cfg.AutoApprove
+
+			terminal.Println("ステータス:")
+			terminal.ShowBannerInfo(opts)
+			terminal.Printf("  メッセージ数: %d\n", agt.GetSession().GetMessageCount())
+			terminal.Printf("  コンテキスト使用率: %d%%\n", agt.GetContextUsagePercent())
+			if sess := agt.GetSession(); sess.AutoCompactEnabled() {
+				terminal.Printf("  自動圧縮: 使用率 %.0f%% で実行\n", sess.CompactionThreshold()*100)
+			} else {
+				terminal.Println("  自動圧縮: OFF（/compact で手動圧縮）")
+			}
+			return nil
+		},
+	})
+}
+
+// largeNumCtx これを超える num_ctx はメモリ不足の警告を出す（自動エスカレーションの最大段階）
+var largeNumCtx = llm.DefaultNumCtxStages[len(llm.DefaultNumCtxStages)-1]
+
+// registerSidecarCommands /sidecar（サイドカーモデルの設定・解除）を登録する
+func registerSidecarCommands(cmdHandler *ui.CommandHandler, terminal *ui.Terminal, cfg *config.Config, router *llm.ModelRouter) {
+	cmdHandler.Register(&ui.SlashCommand{
+		Name:        "sidecar",
+		Description: "サイドカーモデルを表示/設定（/sidecar <モデル名>, /sidecar off）",
+		Handler: func(args string) error {
+			model := strings.TrimSpace(args)
+			switch model {
+			case "":
+				showSidecarRouting(terminal, router)
+				terminal.Println("  変更: /sidecar <モデル名>  解除: /sidecar off")
+				return nil
+			case "off":
+				if !router.HasSidecar() {
+					terminal.PrintColored(ui.ColorYellow, "サイドカーは設定されていません\n")
+					return nil
+				}
+				router.SetSidecar(nil, "")
+				cfg.SidecarModel = ""
+				terminal.PrintColored(ui.ColorGreen, "✓ サイドカーを解除しました\n")
+			default:
+				if model == cfg.SidecarModel && router.HasSidecar() {
+					terminal.PrintColored(ui.ColorYellow, fmt.Sprintf("既に %s をサイドカーに使用中です\n", model))
+					return nil
+				}
+				sidecar := newSidecarProvider(cfg, model)
+				// ModelManager があればモデル存在チェック
+				if mm, ok := sidecar.(llm.ModelManager); ok {
+					exists, err := mm.CheckModel(context.Background(), model)
+					if err != nil {
+						terminal.PrintColored(ui.ColorYellow, fmt.Sprintf("モデル確認中にエラー: %v\n", err))
+						// エラーでも設定は許可
+					} else if !exists {
+						terminal.PrintColored(ui.ColorYellow, i18n.T(i18n.MsgModelNotFound, model))
+						printModelSuggestions(context.Background(), mm, model, terminal)
+						return nil
+					}
+				}
+				router.SetSidecar(sidecar, model)
+				cfg.SidecarModel = model
+				terminal.PrintColored(ui.ColorGreen, fmt.Sprintf("✓ サイドカーを %s に設定しました\n", model))
+			}
+			cfg.SetSource("SIDECAR_MODEL", config.SourceRuntime)
+			showSidecarRouting(terminal, router)
+
+			// 確認したらプロバイダープロファイルにも保存
+			profiles := cfg.GetProviderProfiles()
+			profile, exists := profiles[cfg.Provider]
+			if !exists {
+				return nil
+			}
+			confirm, _ := terminal.ReadLine(fmt.Sprintf("%s のプロファイルに保存しますか？ [y/N]: ", cfg.Provider))
+			if confirm != "y" && confirm != "Y" {
+				return nil
+			}
+			profile.Sidecar = cfg.SidecarModel
+			if profile.Sidecar == "" {
+				profile.Sidecar = config.SidecarOff // 空だとグローバルの SIDECAR_MODEL が次回復活する
+			}
+			if err := cfg.SaveProviderProfile(cfg.Provider, profile); err != nil {
+				terminal.PrintColored(ui.ColorRed, fmt.Sprintf("保存エラー: %v\n", err))
+				return nil
+			}
+			terminal.PrintColored(ui.ColorGreen, "✓ プロファイルに保存しました\n")
+			return nil
+		},
+	})
+}
+
+// showSidecarRouting タスク種別ごとに使うモデルを表示する
+func showSidecarRouting(terminal *ui.Terminal, router *llm.ModelRouter) {
+	status := router.GetStatus()
+	terminal.PrintColored(ui.ColorCyan, "━━━ モデルルーティング ━━━\n")
+	terminal.Printf("  推論:                 %s\n", status.MainModel)
+	if router.HasSidecar() {
+		terminal.Printf("  軽い処理（要約・検証）: %s（サイドカー）\n", status.SidecarModel)
+	} else {
+		terminal.Printf("  軽い処理（要約・検証）: %s（サイドカーなし）\n", status.MainModel)
+	}
+}
+
+// registerOllamaCommands は /ollama コマンド（num_ctx / num_gpu の表示・変更）を登録する
+// 変更は次のリクエストから反映される
+func registerOllamaCommands(cmdHandler *ui.CommandHandler, terminal *ui.Terminal, provider llm.LLMProvider, cfg *config.Config) {
+	cmdHandler.Register(&ui.SlashCommand{
+		Name:        "ollama",
+		Description: "Ollama のオプションを表示/変更（/ollama set num_ctx|num_gpu <n>）",
+		Handler: func(args string) error {
+			ollamaPs := ollamaProviders(provider)
+			if len(ollamaPs) == 0 {
+				terminal.PrintColored(ui.ColorYellow, "/ollama は Ollama プロバイダー使用時のみ利用できます\n")
+				return nil
+			}
+			ollamaP := ollamaPs[0]
+
+			fields := strings.Fields(args)
+			if len(fields) == 0 {
+				terminal.PrintColored(ui.ColorCyan, "━━━ Ollama オプション ━━━\n")
+				if n := ollamaP.GetCurrentNumCtx(); n > 0 {
+					terminal.Printf("  num_ctx: %d\n", n)
+				} else {
+					terminal.Println("  num_ctx: (Ollama デフォルト)")
+				}
+				if n := ollamaP.GetNumGPU(); n >= 0 {
+					terminal.Printf("  num_gpu: %d\n", n)
+				} else {
+					terminal.Println("  num_gpu: (Ollama デフォルト)")
+				}
+				terminal.Println("  変更: /ollama set num_ctx <n>（0 でデフォルト）, /ollama set num_gpu <n>（-1 でデフォルト）")
+				return nil
+			}
+
+			if len(fields) != 3 || fields[0] != "set" {
+				terminal.PrintColored(ui.ColorYellow, "使い方: /ollama set num_ctx <n> | /ollama set num_gpu <n>\n")
+				return nil
+			}
+			n, err := strconv.Atoi(fields[2])
+			if err != nil {
+				terminal.PrintColored(ui.ColorRed, fmt.Sprintf("整数を指定してください: %s\n", fields[2]))
+				return nil
+			}
+
+			switch fields[1] {
+			case "num_ctx":
+				if n < 0 {
+					terminal.PrintColored(ui.ColorRed, "num_ctx は 0 以上を指定してください（0 = Ollama デフォルト）\n")
+					return nil
+				}
+				for _, p := range ollamaPs {
+					p.SetNumCtx(n)
+				}
+				cfg.OllamaNumCtx = n
+				cfg.SetSource("OLLAMA_NUM_CTX", config.SourceRuntime)
+				terminal.PrintColored(ui.ColorGreen, fmt.Sprintf("✓ num_ctx を %d に設定しました（次のリクエストから反映）\n", n))
+				if n > largeNumCtx {
+					terminal.PrintColored(ui.ColorYellow, "⚠ 大きな num_ctx は KV キャッシュが増え、メモリ不足 (OOM) になる場合があります\n")
+				}
+			case "num_gpu":
+				if n < -1 {
+					terminal.PrintColored(ui.ColorRed, "num_gpu は -1 以上を指定してください（-1 = Ollama デフォルト）\n")
+					return nil
+				}
+				for _, p := range ollamaPs {
+					p.SetNumGPU(n)
+				}
+				cfg.OllamaNumGPU = n
+				cfg.SetSource("OLLAMA_NUM_GPU", config.SourceRuntime)
+				terminal.PrintColored(ui.ColorGreen, fmt.Sprintf("✓ num_gpu を %d に設定しました（次のリクエストから反映）\n", n))
+			default:
+				terminal.PrintColored(ui.ColorYellow, fmt.Sprintf("不明なオプション: %s（num_ctx / num_gpu）\n", fields[1]))
+			}
+			return nil
+		},
+	})
+}
+
+// ollamaProviders は provider のうち Ollama のものを返す。ProviderChain なら
+// 各エントリから集め、現在使用中のプロバイダーを先頭にする
+func ollamaProviders(provider llm.LLMProvider) []*llm.OllamaProvider {
+	current := provider
+	if chain, ok := provider.(*llm.ProviderChain); ok {
+		current = chain.GetCurrentProvider()
+	}
+
+	var result []*llm.OllamaProvider
+	if ollamaP, ok := current.(*llm.OllamaProvider); ok {
+		result = append(result, ollamaP)
+	}
+	for _, p := range embeddingProviders(provider) {
+		if ollamaP, ok := p.(*llm.OllamaProvider); ok && p != current {
+			result = append(result, ollamaP)
+		}
+	}
+	return result
+}
+
+// registerCompactCommands は /compact コマンド（手動で会話履歴を圧縮）を登録する
+func registerCompactCommands(cmdHandler *ui.CommandHandler, terminal *ui.Terminal, agt *agent.Agent) {
+	cmdHandler.Register(&ui.SlashCommand{
+		Name:        "compact",
+		Description: "古いやり取りを要約メモに置き換えて履歴を圧縮 [aggressive]",
+		Handler: func(args string) error {
+			aggressive := false
+			switch strings.TrimSpace(args) {
+			case "":
+			case "aggressive":
+				aggressive = true
+			default:
+				terminal.PrintColored(ui.ColorYellow, "使い方: /compact [aggressive]\n")
+				return nil
+			}
+
+			result := agt.CompactNow(aggressive)
+			if result == nil {
+				terminal.Printf("圧縮の必要はありません (%d メッセージ)\n", agt.GetSession().GetMessageCount())
+				return nil
+			}
+
+			terminal.PrintColored(ui.ColorGreen, fmt.Sprintf("✓ %d 件のメッセージを圧縮しました (約 %d → %d トークン、%d トークン削減、残り %d 件)\n",
+				result.CompactedMessages, result.OriginalTokenCount, result.NewTokenCount,
+				result.OriginalTokenCount-result.NewTokenCount, result.RemainingMessages))
+			return nil
+		},
+	})
+}
+
+// registerSummarizeCommands は /summarize コマンドを登録する
+func registerSummarizeCommands(cmdHandler *ui.CommandHandler, terminal *ui.Terminal, agt *agent.Agent) {
+	cmdHandler.Register(&ui.SlashCommand{
+		Name:        "summarize",
+		Description: "会話履歴を要約で置き換えてリセット",
+		Handler: func(args string) error {
+			sess := agt.GetSession()
+			count := sess.GetMessageCount()
+			if count == 0 {
+				terminal.Println("要約する会話がありません")
+				return nil
+			}
+
+			confirm, _ := terminal.ReadLine(fmt.Sprintf("%d 件のメッセージを要約で置き換えますか？ [y/N]: ", count))
+			if confirm != "y" && confirm != "Y" {
+				terminal.Println("キャンセルしました")
+				return nil
+			}
+
+			terminal.PrintColored(ui.ColorCyan, fmt.Sprintf("%s で要約中...\n", agt.ModelFor(llm.TaskLightweight)))
+
+			result, err := agt.Summarize(context.Background())
+			if err != nil {
+				terminal.PrintColored(ui.ColorRed, fmt.Sprintf("要約エラー: %v（履歴は変更されていません）\n", err))
+				return nil
+			}
+
+			terminal.PrintColored(ui.ColorGreen, fmt.Sprintf("✓ %d 件のメッセージを要約しました (約 %d → %d トークン)\n",
+				result.CompactedMessages+result.RemainingMessages, result.OriginalTokenCount, result.NewTokenCount))
+			terminal.Println(result.Summary)
 			return nil
 		},
 	})
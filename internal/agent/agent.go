@@ -3,15 +3,19 @@ package agent
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/zephel01/vibe-local-go/internal/config"
 	"github.com/zephel01/vibe-local-go/internal/llm"
 	"github.com/zephel01/vibe-local-go/internal/security"
 	"github.com/zephel01/vibe-local-go/internal/session"
+	"github.com/zephel01/vibe-local-go/internal/skill"
 	"github.com/zephel01/vibe-local-go/internal/tool"
 	"github.com/zephel01/vibe-local-go/internal/ui"
 )
@@ -27,6 +31,18 @@ const (
 	MaxValidationAttempts = 3
 	// ScriptValidationTimeout is the timeout for script validation
 	ScriptValidationTimeout = 30 * time.Second
+	// ContextTrimThreshold is the context usage ratio that triggers history trimming
+	ContextTrimThreshold = 0.9
+	// ContextTrimTarget is the context usage ratio history is trimmed down to
+	ContextTrimTarget = 0.7
+	// MaxLLMRetries is the maximum number of retries for transient LLM errors
+	MaxLLMRetries = 3
+	// ManualCompactTarget is the context usage ratio /compact trims history down to
+	ManualCompactTarget = 0.25
+	// AggressiveCompactTarget is the ratio for /compact aggressive (keeps only the latest exchange)
+	AggressiveCompactTarget = 0.0
+	// FallbackTemperature is used when neither the config nor the model router sets a temperature
+	FallbackTemperature = 0.7
 )
 
 // Agent represents the main agent loop
@@ -39,12 +55,39 @@ type Agent struct {
 	terminal              *ui.Terminal
 	config                *config.Config
 	loopDetector          *LoopDetector
+	dispatcher            *Dispatcher // Groups tool calls into batches that may run in parallel
 	spinner               *ui.ToolSpinner
 	statusLine            *ui.StatusLineUpdater
 	scriptValidationCount int // Track number of script validation attempts
 	autoTestEnabled       bool // Enable automatic test execution after file edits
+	autoTestPrompt        func(command string) (bool, error) // Confirms a project-supplied AUTOTEST_COMMAND (nil = terminal)
+	autoTestDecided       string                             // Project AUTOTEST_COMMAND the user already answered for
+	autoTestAllowed       bool                               // Answer given for autoTestDecided
 	planMode              bool // When true, reject write_file/edit_file/bash
+	dryRun                bool // When true, preview non-read-only tool calls instead of running them
+	toolLog               *ToolCallLogger // Records every tool call as a JSON line (optional)
 	cachedLLMTools        []llm.ToolDef // Cached tool schema conversion (computed once)
+	usage                 TokenUsage    // Token usage accumulated across all LLM calls
+	choicesNext           int           // Completions to request on the next user turn (/choices)
+	turnChoices           int           // Completions requested for the current turn
+	choose                func(candidates []string) (int, error) // Picks one of several completions
+	budgetPrompt          BudgetPrompt                           // Asks whether to go on past BudgetTokens (nil = terminal)
+	budgetApproved        bool                                   // User chose to continue past the budget
+	router                *llm.ModelRouter                       // Supplies per-model sampling defaults (optional)
+	skills                *skill.SkillManager                    // Auto-activates matching skills per turn (optional)
+	turnSkill             string                                 // Skill context injected into this turn's LLM calls
+	pinnedSkill           string                                 // Skill loaded with /skill (kept across turns)
+	pinnedSkillContext    string                                 // System message for pinnedSkill
+
+	toolMu   sync.Mutex            // Guards toolRuns
+	toolRuns map[*toolRun]struct{} // In-flight tools (several while a read-only batch runs)
+}
+
+// toolRun is a tool execution that CancelCurrentTool can reach
+type toolRun struct {
+	name      string
+	cancel    context.CancelFunc
+	cancelled bool // Set when the user cancelled the tool
 }
 
 // NewAgent creates a new agent
@@ -60,7 +103,20 @@ func NewAgent(
 	// Pre-convert tool schemas once (they don't change during a session)
 	cachedTools := convertTools(registry.GetSchemas())
 
-	return &Agent{
+	// Automatic compaction follows the config. The default stays at ContextTrimThreshold,
+	// the usage at which history has always been trimmed; session.CompactThreshold (0.5)
+	// only applied to the unused CompactIfNeeded path.
+	if cfg.ContextWindow > 0 {
+		sess.SetContextWindow(cfg.ContextWindow)
+	}
+	threshold := ContextTrimThreshold
+	if cfg.CompactThreshold > 0 {
+		threshold = cfg.CompactThreshold
+	}
+	sess.SetCompactThreshold(threshold)
+	sess.SetAutoCompact(!cfg.NoAutoCompact)
+
+	a := &Agent{
 		provider:        provider,
 		registry:        registry,
 		permissionMgr:   permissionMgr,
@@ -68,13 +124,21 @@ func NewAgent(
 		session:         sess,
 		terminal:        term,
 		config:          cfg,
-		loopDetector:    NewLoopDetector(),
+		loopDetector:    NewLoopDetectorWithLimits(cfg.LoopHistorySize, cfg.LoopThreshold),
+		dispatcher:      NewDispatcher(registry, permissionMgr, validator, term),
 		spinner:         ui.NewToolSpinner(term),
 		statusLine:      ui.NewStatusLineUpdater(term),
 		autoTestEnabled: false, // Disabled by default, enable with /autotest on
 		planMode:        false, // Disabled by default, enable with /plan on
 		cachedLLMTools:  cachedTools,
+		choose:          term.AskChoice,
 	}
+
+	// Falling back to a cloud provider is subject to the token budget too
+	if chain, ok := provider.(*llm.ProviderChain); ok {
+		chain.SetFallbackGuard(a.guardFallback)
+	}
+	return a
 }
 
 // SetAutoTestEnabled sets whether auto test is enabled
@@ -92,11 +156,122 @@ func (a *Agent) SetPlanMode(enabled bool) {
 	a.planMode = enabled
 }
 
+// SetModelRouter sets the router used for per-model temperature/max_tokens defaults
+func (a *Agent) SetModelRouter(router *llm.ModelRouter) {
+	a.router = router
+}
+
+// SetSkillManager sets the skills matched against each user message
+func (a *Agent) SetSkillManager(sm *skill.SkillManager) {
+	a.skills = sm
+}
+
 // IsPlanMode returns whether plan mode is enabled
 func (a *Agent) IsPlanMode() bool {
 	return a.planMode
 }
 
+// SetDryRun sets whether dry-run mode is enabled (tool calls are previewed, not executed)
+func (a *Agent) SetDryRun(enabled bool) {
+	a.dryRun = enabled
+}
+
+// IsDryRun returns whether dry-run mode is enabled
+func (a *Agent) IsDryRun() bool {
+	return a.dryRun
+}
+
+// SetLoopDetection turns loop detection on or off (/loopdetect)
+func (a *Agent) SetLoopDetection(enabled bool) {
+	a.loopDetector.SetEnabled(enabled)
+}
+
+// IsLoopDetectionEnabled returns whether loop detection is on
+func (a *Agent) IsLoopDetectionEnabled() bool {
+	return a.loopDetector.IsEnabled()
+}
+
+// LoopThreshold returns the number of identical tool calls treated as a loop
+func (a *Agent) LoopThreshold() int {
+	return a.loopDetector.Threshold()
+}
+
+// SetToolCallLogger records every tool call to logger (nil disables logging)
+func (a *Agent) SetToolCallLogger(logger *ToolCallLogger) {
+	a.toolLog = logger
+}
+
+// SetChoices requests n completions for the next user turn only; the user picks
+// one to continue with and the rest are discarded. n <= 1 turns the mode off.
+func (a *Agent) SetChoices(n int) error {
+	if n <= 1 {
+		a.choicesNext = 0
+		return nil
+	}
+	info := a.provider.Info()
+	if !info.Features.MultipleChoices {
+		return fmt.Errorf("provider %s does not support multiple choices", info.Name)
+	}
+	a.choicesNext = n
+	return nil
+}
+
+// GetChoices returns the number of completions pending for the next user turn (0 = off)
+func (a *Agent) GetChoices() int {
+	return a.choicesNext
+}
+
+// CancelCurrentTool cancels the tools that are currently executing, if any.
+// Each returns a "cancelled by user" result and the turn continues, so the
+// LLM can adapt. Returns the cancelled tools' names and whether one was running.
+func (a *Agent) CancelCurrentTool() (string, bool) {
+	a.toolMu.Lock()
+	defer a.toolMu.Unlock()
+
+	if len(a.toolRuns) == 0 {
+		return "", false
+	}
+	names := make([]string, 0, len(a.toolRuns))
+	for run := range a.toolRuns {
+		run.cancelled = true
+		run.cancel()
+		names = append(names, run.name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", "), true
+}
+
+// MaxStreamedLineLength is the longest tool output line shown live (longer lines are cut)
+const MaxStreamedLineLength = 200
+
+// showToolOutputLine prints one line of live tool output above the spinner
+func (a *Agent) showToolOutputLine(line string) {
+	if runes := []rune(line); len(runes) > MaxStreamedLineLength {
+		line = string(runes[:MaxStreamedLineLength]) + "…"
+	}
+	a.spinner.PrintLine("  │ " + line)
+}
+
+// beginTool records an in-flight tool so CancelCurrentTool can reach it
+func (a *Agent) beginTool(name string, cancel context.CancelFunc) *toolRun {
+	a.toolMu.Lock()
+	defer a.toolMu.Unlock()
+	if a.toolRuns == nil {
+		a.toolRuns = make(map[*toolRun]struct{})
+	}
+	run := &toolRun{name: name, cancel: cancel}
+	a.toolRuns[run] = struct{}{}
+	return run
+}
+
+// endTool clears an in-flight tool and reports whether the user cancelled it
+func (a *Agent) endTool(run *toolRun) bool {
+	a.toolMu.Lock()
+	defer a.toolMu.Unlock()
+	delete(a.toolRuns, run)
+	return run.cancelled
+}
+
 // Run executes the agent loop
 func (a *Agent) Run(ctx context.Context, userInput string) error {
 	// Reset loop detector and validation counter for each new user request
@@ -104,9 +279,18 @@ func (a *Agent) Run(ctx context.Context, userInput string) error {
 	a.loopDetector.Reset()
 	a.scriptValidationCount = 0
 
+	// Retries across the LLM, provider chain and tools share one budget per turn
+	ctx = llm.WithRetryBudget(ctx, llm.NewRetryBudget(a.retryBudget()))
+
+	// /choices applies to this turn only
+	a.turnChoices, a.choicesNext = a.choicesNext, 0
+
 	// Add user input to session
 	a.session.AddUserMessage(userInput)
 
+	// The best matching skill's SKILL.md is shown to the LLM for this turn only
+	a.turnSkill = a.activateSkill(userInput)
+
 	// ReAct loop
 	iteration := 0
 	for iteration < MaxIterations {
@@ -127,10 +311,18 @@ func (a *Agent) Run(ctx context.Context, userInput string) error {
 			break
 		}
 
+		// Trim oldest exchanges before the context window overflows
+		a.trimHistoryIfNeeded()
+
 		// Prepare chat request
-		messages := a.session.GetMessagesForLLM()
+		messages := a.withTurnSkill(a.session.GetMessagesForLLM())
 		tools := a.registry.GetSchemas()
 
+		// Pause before the next cloud call once the token budget is used up
+		if err := a.checkBudget(); err != nil {
+			return err
+		}
+
 		// Call LLM (ステータス行表示)
 		a.statusLine.Start("💭 Thinking...")
 		response, err := a.callLLM(ctx, messages, tools, iteration)
@@ -139,6 +331,10 @@ func (a *Agent) Run(ctx context.Context, userInput string) error {
 			return fmt.Errorf("LLM call failed: %w", err)
 		}
 
+		a.usage.PromptTokens += response.PromptTokens
+		a.usage.CompletionTokens += response.CompletionTokens
+		a.session.AddTokenUsage(a.provider.Info().Name, a.servedModel(response.Model), isCloudProvider(a.provider), response.PromptTokens, response.CompletionTokens)
+
 		// Update status line with token count
 		if response.PromptTokens > 0 || response.CompletionTokens > 0 {
 			a.statusLine.SetTokenCount(response.PromptTokens + response.CompletionTokens)
@@ -151,7 +347,7 @@ func (a *Agent) Run(ctx context.Context, userInput string) error {
 		if len(response.ToolCalls) == 0 {
 			// No tool calls, just assistant response
 			a.session.AddAssistantMessage(response.Content)
-			a.terminal.Println(response.Content)
+			a.terminal.PrintAssistant(response.Content)
 			break
 		}
 
@@ -168,7 +364,11 @@ func (a *Agent) Run(ctx context.Context, userInput string) error {
 		a.session.AddToolResults(results)
 
 		// Script validation phase: Check if any write_file tool was called
-		validationErr := a.validateGeneratedScripts(ctx, response.ToolCalls)
+		// (nothing was written in dry-run mode)
+		var validationErr error
+		if !a.dryRun {
+			validationErr = a.validateGeneratedScripts(ctx, response.ToolCalls)
+		}
 		if validationErr != nil {
 			// Increment validation counter
 			a.scriptValidationCount++
@@ -239,26 +439,62 @@ func dynamicMaxTokens(base int, iteration int) int {
 	}
 }
 
+// samplingParams resolves temperature and max_tokens for the main model.
+// Values set explicitly (flag, config.json, provider profile or at runtime)
+// win; otherwise the model router's per-model defaults replace the built-in ones.
+func (a *Agent) samplingParams() (float64, int) {
+	var params llm.ModelParams
+	if a.router != nil {
+		params = a.router.ParamsForModel(a.config.Model)
+	}
+
+	// An explicit value is kept as is, including 0 (e.g. deterministic output)
+	temperature := a.config.Temperature
+	if !a.explicitSetting("TEMPERATURE") {
+		if params.Temperature > 0 {
+			temperature = params.Temperature
+		}
+		if temperature <= 0 {
+			temperature = FallbackTemperature
+		}
+	}
+
+	maxTokens := a.config.MaxTokens
+	if !a.explicitSetting("MAX_TOKENS") {
+		if params.MaxTokens > 0 {
+			maxTokens = params.MaxTokens
+		}
+		if maxTokens <= 0 {
+			maxTokens = config.DefaultMaxTokens
+		}
+	}
+	return temperature, maxTokens
+}
+
+// explicitSetting reports whether the user chose the value of a config key
+// rather than leaving DefaultConfig's
+func (a *Agent) explicitSetting(key string) bool {
+	switch a.config.Source(key) {
+	case config.SourceDefault, config.SourceAuto:
+		return false
+	}
+	return true
+}
+
 // callLLM calls the LLM with the current messages
 func (a *Agent) callLLM(ctx context.Context, messages []map[string]interface{}, tools []*tool.FunctionSchema, iteration int) (*ChatResponse, error) {
 	// Convert messages to llm.Message format
-	llmMessages := make([]llm.Message, len(messages))
-	for i, msg := range messages {
-		llmMessages[i] = llm.Message{
-			Role:      msg["role"].(string),
-			Content:   msg["content"].(string),
-			ToolID:    getString(msg, "tool_id"),
-		}
-	}
+	llmMessages := toLLMMessages(messages)
 
 	// Build request with dynamic MaxTokens based on iteration
-	maxTokens := dynamicMaxTokens(a.config.MaxTokens, iteration)
+	temperature, baseMaxTokens := a.samplingParams()
+	maxTokens := dynamicMaxTokens(baseMaxTokens, iteration)
 	req := &llm.ChatRequest{
 		Model:       a.config.Model,
 		Messages:    llmMessages,
 		Tools:       a.cachedLLMTools,
 		Stream:      false,
-		Temperature: a.config.Temperature,
+		Temperature: temperature,
 		MaxTokens:   maxTokens,
 	}
 
@@ -268,46 +504,143 @@ func (a *Agent) callLLM(ctx context.Context, messages []map[string]interface{},
 		req.Options = ollamaOpts
 	}
 
-	// Call LLM via provider
-	resp, err := a.provider.Chat(ctx, req)
+	// Multiple completions only for the first call of a /choices turn
+	if iteration == 1 && a.turnChoices > 1 && a.provider.Info().Features.MultipleChoices {
+		req.N = a.turnChoices
+	}
+
+	// Call LLM via provider (一時的なエラーはリトライ)
+	resp, err := a.chatWithRetry(ctx, req)
 	if err != nil {
 		return nil, err
 	}
 
 	// Parse response
+	if req.N > 1 {
+		return a.pickChoice(resp)
+	}
 	return parseChatResponse(resp)
 }
 
-// executeToolCalls executes tool calls
-func (a *Agent) executeToolCalls(ctx context.Context, toolCalls []session.ToolCall) ([]session.ToolResult, error) {
-	sessionResults := make([]session.ToolResult, 0, len(toolCalls))
+// pickChoice presents every returned completion and continues with the one the user picks
+func (a *Agent) pickChoice(resp *llm.ChatResponse) (*ChatResponse, error) {
+	candidates, err := parseChatChoices(resp)
+	if err != nil {
+		return nil, err
+	}
+	if len(candidates) == 1 {
+		return candidates[0], nil
+	}
 
-	for _, tc := range toolCalls {
-		result := a.executeSingleTool(ctx, &tc)
-		sessionResults = append(sessionResults, session.ToolResult{
-			Content:   result.Content,
-			ToolCallID: result.ToolCallID,
-		})
+	previews := make([]string, len(candidates))
+	for i, c := range candidates {
+		previews[i] = describeChoice(c)
+	}
 
-		// Track tool calls for loop detection
-		a.loopDetector.RecordToolCall(tc.Function.Name, tc.Function.Arguments)
+	idx, err := a.choose(previews)
+	if err != nil || idx < 0 || idx >= len(candidates) {
+		a.terminal.PrintWarning(fmt.Sprintf("Invalid choice, continuing with [1]: %v", err))
+		idx = 0
+	}
+	return candidates[idx], nil
+}
+
+// describeChoice renders a completion candidate for selection
+func describeChoice(c *ChatResponse) string {
+	var sb strings.Builder
+	sb.WriteString(c.Content)
+	for _, tc := range c.ToolCalls {
+		if sb.Len() > 0 {
+			sb.WriteString("\n")
+		}
+		sb.WriteString(fmt.Sprintf("→ %s %s", tc.Function.Name, tc.Function.Arguments))
+	}
+	return sb.String()
+}
+
+// retryBudget returns the per-turn retry budget from the config (DefaultRetryBudget when unset)
+func (a *Agent) retryBudget() int {
+	if a.config != nil && a.config.RetryBudget > 0 {
+		return a.config.RetryBudget
+	}
+	return config.DefaultRetryBudget
+}
+
+// chatWithRetry calls provider.Chat, retrying transient errors with exponential backoff
+func (a *Agent) chatWithRetry(ctx context.Context, req *llm.ChatRequest) (*llm.ChatResponse, error) {
+	for attempt := 0; ; attempt++ {
+		resp, err := a.provider.Chat(ctx, req)
+		if err == nil {
+			return resp, nil
+		}
+
+		classification := llm.ClassifyError(err)
+		if attempt >= MaxLLMRetries || ctx.Err() != nil || !isRetryableLLMError(err, classification) {
+			return nil, err
+		}
+		// Retries are shared with the provider chain and tools for this turn
+		if budget := llm.RetryBudgetFrom(ctx); !budget.Take(fmt.Sprintf("LLM (%s): %v", classification, err)) {
+			return nil, budget.Exhausted(err)
+		}
+
+		delay := delayForRetry(attempt)
+		if d := llm.GetRetryDelay(classification, attempt); d > delay {
+			delay = d
+		}
+		a.terminal.PrintWarning(fmt.Sprintf("LLM request failed (%s), retrying in %s (%d/%d): %v",
+			classification, delay, attempt+1, MaxLLMRetries, err))
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
 	}
+}
 
-	return sessionResults, nil
+// isRetryableLLMError reports whether an LLM error is transient.
+// Auth errors, unknown models and context overflows fail fast.
+func isRetryableLLMError(err error, classification llm.ErrorClassification) bool {
+	if shouldNotRetry(err.Error()) {
+		return false
+	}
+	switch classification {
+	case llm.ErrorClassNetwork, llm.ErrorClassTimeout, llm.ErrorClassServerError, llm.ErrorClassRateLimit:
+		return true
+	default:
+		return false
+	}
+}
+
+// executeToolCalls executes tool calls
+func (a *Agent) executeToolCalls(ctx context.Context, toolCalls []session.ToolCall) ([]session.ToolResult, error) {
+	sessionResults, _, err := a.executeToolCallsWithResults(ctx, toolCalls)
+	return sessionResults, err
 }
 
-// executeToolCallsWithResults executes tool calls and returns agent ToolResults for error checking
+// executeToolCallsWithResults executes tool calls and returns agent ToolResults for error checking.
+// Consecutive read-only calls run concurrently; everything else runs alone, in order.
+// Results are returned in the original tool-call order.
 func (a *Agent) executeToolCallsWithResults(ctx context.Context, toolCalls []session.ToolCall) ([]session.ToolResult, []ToolResult, error) {
-	sessionResults := make([]session.ToolResult, 0, len(toolCalls))
 	agentResults := make([]ToolResult, 0, len(toolCalls))
+	for _, batch := range a.dispatcher.GroupForParallelExecution(toolCalls) {
+		if len(batch) > 1 && a.dispatcher.canExecuteInParallel(batch) {
+			agentResults = append(agentResults, a.executeParallel(ctx, batch)...)
+			continue
+		}
+		for i := range batch {
+			agentResults = append(agentResults, a.executeSingleTool(ctx, &batch[i]))
+		}
+	}
 
-	for _, tc := range toolCalls {
-		result := a.executeSingleTool(ctx, &tc)
+	sessionResults := make([]session.ToolResult, 0, len(toolCalls))
+	for i, tc := range toolCalls {
+		result := agentResults[i]
 		sessionResults = append(sessionResults, session.ToolResult{
-			Content:   result.Content,
+			Content:    result.Content,
 			ToolCallID: result.ToolCallID,
+			IsError:    !result.IsSuccess,
 		})
-		agentResults = append(agentResults, result)
 
 		// Track tool calls for loop detection
 		a.loopDetector.RecordToolCall(tc.Function.Name, tc.Function.Arguments)
@@ -316,17 +649,125 @@ func (a *Agent) executeToolCallsWithResults(ctx context.Context, toolCalls []ses
 	return sessionResults, agentResults, nil
 }
 
-// executeSingleTool executes a single tool
+// executeParallel runs a batch of read-only tool calls concurrently.
+// Calls that need a permission prompt (e.g. web_fetch) run one at a time
+// first so only one prompt is open at once; the rest run concurrently.
+// results[i] belongs to toolCalls[i], keeping the tool_call_id pairing.
+func (a *Agent) executeParallel(ctx context.Context, toolCalls []session.ToolCall) []ToolResult {
+	results := make([]ToolResult, len(toolCalls))
+	var wg sync.WaitGroup
+
+	var approved []int
+	for i := range toolCalls {
+		if a.needsPermissionPrompt(&toolCalls[i]) {
+			results[i] = a.executeSingleTool(ctx, &toolCalls[i])
+			continue
+		}
+		approved = append(approved, i)
+	}
+
+	for _, i := range approved {
+		wg.Add(1)
+		go func(idx int) {
+			defer wg.Done()
+			results[idx] = a.executeSingleTool(ctx, &toolCalls[idx])
+		}(i)
+	}
+
+	wg.Wait()
+	return results
+}
+
+// needsPermissionPrompt reports whether running the call would ask the user first
+func (a *Agent) needsPermissionPrompt(toolCall *session.ToolCall) bool {
+	var permParams map[string]interface{}
+	_ = json.Unmarshal([]byte(toolCall.Function.Arguments), &permParams)
+	allowed, _, err := a.permissionMgr.CheckPermission(toolCall.Function.Name, permParams)
+	return err == nil && !allowed
+}
+
+// dryRunSafeTools are read-only tools that still run in dry-run mode so the model has real context
+var dryRunSafeTools = map[string]bool{
+	"read_file":       true,
+	"glob":            true,
+	"grep":            true,
+	"semantic_search": true,
+	"list_directory":  true,
+	"symbols":         true,
+	"tail":            true,
+	"environment":     true,
+	"bash_output":     true,
+	"web_fetch":       true,
+	"web_search":      true,
+	"plan":            true,
+}
+
+// planTrackedTools write a single file given by their "path" argument; the
+// session plan gates and tracks them
+var planTrackedTools = map[string]bool{
+	"write_file":    true,
+	"edit_file":     true,
+	"multi_edit":    true,
+	"notebook_edit": true,
+}
+
+// planTargetPath returns the file a plan-tracked tool call writes ("" for other tools)
+func planTargetPath(toolName, arguments string) string {
+	if !planTrackedTools[toolName] {
+		return ""
+	}
+	var args struct {
+		Path string `json:"path"`
+	}
+	if err := json.Unmarshal(json.RawMessage(arguments), &args); err != nil {
+		return ""
+	}
+	return args.Path
+}
+
+// previewToolCall shows a tool call that dry-run mode skipped and returns a synthetic
+// success, so the model keeps going and the user sees the whole plan
+func (a *Agent) previewToolCall(toolCall *session.ToolCall) ToolResult {
+	toolName := toolCall.Function.Name
+	a.terminal.PrintColored(ui.ColorYellow, "[dry-run] ")
+	a.terminal.ShowToolCall(toolName, json.RawMessage(toolCall.Function.Arguments))
+
+	return ToolResult{
+		ToolCallID: toolCall.ID,
+		IsSuccess:  true,
+		Content: fmt.Sprintf("[dry-run] Would execute %s with arguments %s. It was NOT executed (dry-run mode); "+
+			"continue as if it succeeded so the user can review the full plan.", toolName, toolCall.Function.Arguments),
+	}
+}
+
+// executeSingleTool executes a single tool and records it in the tool call log
 func (a *Agent) executeSingleTool(ctx context.Context, toolCall *session.ToolCall) ToolResult {
+	start := time.Now()
+	result := a.runSingleTool(ctx, toolCall)
+	if a.toolLog != nil {
+		if err := a.toolLog.Record(toolCall.Function.Name, toolCall.Function.Arguments, result, time.Since(start)); err != nil {
+			a.terminal.PrintWarning(fmt.Sprintf("tool log: %v", err))
+		}
+	}
+	return result
+}
+
+// runSingleTool executes a single tool
+func (a *Agent) runSingleTool(ctx context.Context, toolCall *session.ToolCall) ToolResult {
 	toolName := toolCall.Function.Name
 	arguments := toolCall.Function.Arguments
 
 	// Check plan mode first (before permission check)
 	if a.planMode {
 		writeTools := map[string]bool{
-			"write_file": true,
-			"edit_file":  true,
-			"bash":       true,
+			"write_file":     true,
+			"edit_file":      true,
+			"multi_edit":     true,
+			"apply_patch":    true,
+			"move_file":      true,
+			"make_directory": true,
+			"bash":           true,
+			"assert_command": true,
 		}
 		if writeTools[toolName] {
 			return ToolResult{
@@ -348,8 +789,33 @@ func (a *Agent) executeSingleTool(ctx context.Context, toolCall *session.ToolCal
 	}
 	toolInst := toolCfg.Tool
 
-	// Check permission
-	allowed, reason, err := a.permissionMgr.CheckPermission(toolName, nil)
+	// Reject calls that don't match the tool's schema with a message the model can correct from
+	if result := invalidArgumentsResult(toolCall, toolInst.Schema()); result != nil {
+		return *result
+	}
+
+	// Dry-run: preview anything that isn't read-only instead of running it
+	if a.dryRun && !dryRunSafeTools[toolName] {
+		return a.previewToolCall(toolCall)
+	}
+
+	// Enforce the registered plan's order: a step's files wait for its dependencies
+	planPath := planTargetPath(toolName, arguments)
+	if planPath != "" {
+		if err := a.session.CheckPlanFile(planPath); err != nil {
+			return ToolResult{
+				ToolCallID: toolCall.ID,
+				IsSuccess:  false,
+				Content:    err.Error(),
+				Error:      err.Error(),
+			}
+		}
+	}
+
+	// Check permission (bash command rules need the arguments)
+	var permParams map[string]interface{}
+	_ = json.Unmarshal([]byte(arguments), &permParams)
+	allowed, reason, err := a.permissionMgr.CheckPermission(toolName, permParams)
 	if err != nil {
 		a.LogToolError(toolName, err, arguments, 0)
 		return ToolResult{
@@ -387,10 +853,29 @@ func (a *Agent) executeSingleTool(ctx context.Context, toolCall *session.ToolCal
 	ctx, cancel := context.WithTimeout(ctx, ToolExecutionTimeout)
 	defer cancel()
 
+	run := a.beginTool(toolName, cancel)
+	if !a.terminal.IsQuiet() {
+		// Long commands show their output live instead of only a spinner
+		ctx = tool.WithOutputLines(ctx, a.showToolOutputLine)
+	}
 	a.spinner.Start(fmt.Sprintf("⚡ %s...", toolName))
 	toolResult, err := toolInst.Execute(ctx, json.RawMessage(arguments))
 	a.spinner.Stop()
 
+	if a.endTool(run) {
+		a.terminal.PrintWarning(fmt.Sprintf("⏹ %s cancelled by user", toolName))
+		content := fmt.Sprintf("Tool %s was cancelled by user before it finished. Do not retry it unchanged; try a different approach or ask the user.", toolName)
+		if err == nil && toolResult != nil && toolResult.Output != "" {
+			content += "\n\nPartial output:\n" + toolResult.Output
+		}
+		return ToolResult{
+			ToolCallID: toolCall.ID,
+			IsSuccess:  false,
+			Content:    content,
+			Error:      "cancelled by user",
+		}
+	}
+
 	if err != nil {
 		// Enhanced error logging
 		a.LogToolError(toolName, err, arguments, 0)
@@ -434,18 +919,23 @@ func (a *Agent) executeSingleTool(ctx context.Context, toolCall *session.ToolCal
 	// Show tool result
 	a.terminal.ShowToolResult(toolResult)
 
-	// Run auto test if enabled and this is a file write operation
-	if a.autoTestEnabled && (toolName == "write_file" || toolName == "edit_file") && !toolResult.IsError {
-		// Extract file path from arguments
-		var args map[string]interface{}
-		if err := json.Unmarshal(json.RawMessage(arguments), &args); err == nil {
-			if filePath, ok := args["path"].(string); ok {
-				a.terminal.Println("🔄 Running auto tests...")
-				if !a.runAutoTestIfNeeded(filePath) {
-					// Tests failed - the error has been added to session
-					a.terminal.PrintWarning("⚠️  Auto tests failed - LLM will attempt to fix")
-				}
-			}
+	// Mark plan steps done once all their files have been written
+	if planPath != "" && !toolResult.IsError {
+		for _, id := range a.session.RecordPlanFile(planPath) {
+			a.terminal.PrintColored(ui.ColorGreen, fmt.Sprintf("✓ Plan step %s done\n", id))
+		}
+	}
+
+	// Run auto test if enabled and the tool changed files on disk (any file-editing tool)
+	if a.autoTestEnabled && !toolResult.IsError && len(toolResult.ChangedPaths) > 0 {
+		if command := a.autoTestCommand(); command != "" {
+			a.terminal.Println("🔄 Running auto tests: " + command)
+		} else {
+			a.terminal.Println("🔄 Running auto tests...")
+		}
+		if !a.runAutoTestIfNeeded(toolResult.ChangedPaths[0]) {
+			// Tests failed - the error has been added to session
+			a.terminal.PrintWarning("⚠️  Auto tests failed - LLM will attempt to fix")
 		}
 	}
 
@@ -463,7 +953,7 @@ func (a *Agent) askUserPermission(toolName string, arguments string) (bool, erro
 		return true, nil
 	}
 
-	permResult, err := a.terminal.AskPermission(toolName, arguments)
+	permResult, err := a.terminal.AskPermission(toolName, arguments, a.previewDiff(toolName, arguments))
 	if err != nil {
 		return false, err
 	}
@@ -480,69 +970,87 @@ func (a *Agent) askUserPermission(toolName string, arguments string) (bool, erro
 	return permResult.Allowed, nil
 }
 
+// previewDiff returns the change a write/edit tool call would make, for the
+// permission prompt ("" when the tool can't preview or the preview fails)
+func (a *Agent) previewDiff(toolName string, arguments string) string {
+	toolCfg, ok := a.registry.Get(toolName)
+	if !ok {
+		return ""
+	}
+	previewer, ok := toolCfg.Tool.(tool.Previewer)
+	if !ok {
+		return ""
+	}
+	diff, err := previewer.Preview(json.RawMessage(arguments))
+	if err != nil {
+		return ""
+	}
+	return diff
+}
+
+// TokenUsage holds token counts reported by the provider
+type TokenUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+}
+
+// Total returns prompt + completion tokens
+func (u TokenUsage) Total() int {
+	return u.PromptTokens + u.CompletionTokens
+}
+
+// GetTokenUsage returns the token usage accumulated by this agent
+func (a *Agent) GetTokenUsage() TokenUsage {
+	return a.usage
+}
+
 // ChatResponse represents a chat response
 type ChatResponse struct {
 	Content          string
 	ToolCalls        []session.ToolCall
 	PromptTokens     int
 	CompletionTokens int
+	Model            string // Model that served the call, as reported by the provider ("" if unknown)
 }
 
-// normalizeJSONArgs normalizes tool call arguments to a valid JSON object string.
-// Handles multiple levels of JSON string encoding that some LLMs produce.
-// e.g., `{"command":"ls"}` → `{"command":"ls"}` (already correct)
-// e.g., `"{\"command\":\"ls\"}"` → `{"command":"ls"}` (single string encoding)
-// e.g., `"\"{\\\"command\\\":\\\"ls\\\"}\""` → `{"command":"ls"}` (double string encoding)
-func normalizeJSONArgs(raw json.RawMessage) string {
-	data := string(raw)
-
-	// Try to unwrap string encoding layers (max 3 levels)
-	for i := 0; i < 3; i++ {
-		// Check if it's already a valid JSON object
-		trimmed := strings.TrimSpace(data)
-		if len(trimmed) > 0 && trimmed[0] == '{' {
-			// Validate it's proper JSON
-			var obj map[string]interface{}
-			if err := json.Unmarshal([]byte(trimmed), &obj); err == nil {
-				// Re-marshal to normalize (clean up any encoding artifacts)
-				argsBytes, _ := json.Marshal(obj)
-				return string(argsBytes)
-			}
-		}
-
-		// Try to unquote one layer of JSON string encoding
-		var unquoted string
-		if err := json.Unmarshal([]byte(data), &unquoted); err == nil {
-			data = unquoted
-			continue
-		}
-
-		// Can't unquote further, return what we have
-		break
+// parseChatResponse parses LLM response (first choice only)
+func parseChatResponse(resp *llm.ChatResponse) (*ChatResponse, error) {
+	if len(resp.Choices) == 0 {
+		return nil, fmt.Errorf("no choices in response")
 	}
 
-	// Return the best we got
-	return data
+	result := parseChoice(resp.Choices[0], resp.Usage)
+	result.Model = resp.Model
+	return result, nil
 }
 
-// parseChatResponse parses LLM response
-func parseChatResponse(resp *llm.ChatResponse) (*ChatResponse, error) {
+// parseChatChoices parses every choice of an n>1 response
+func parseChatChoices(resp *llm.ChatResponse) ([]*ChatResponse, error) {
 	if len(resp.Choices) == 0 {
 		return nil, fmt.Errorf("no choices in response")
 	}
 
-	choice := resp.Choices[0]
+	results := make([]*ChatResponse, 0, len(resp.Choices))
+	for _, choice := range resp.Choices {
+		result := parseChoice(choice, resp.Usage)
+		result.Model = resp.Model
+		results = append(results, result)
+	}
+	return results, nil
+}
 
+// parseChoice converts a single choice; usage is shared by all choices of a response
+func parseChoice(choice llm.Choice, usage llm.Usage) *ChatResponse {
 	result := &ChatResponse{
 		Content:          choice.Message.Content,
 		ToolCalls:        make([]session.ToolCall, 0),
-		PromptTokens:     resp.Usage.PromptTokens,
-		CompletionTokens: resp.Usage.CompletionTokens,
+		PromptTokens:     usage.PromptTokens,
+		CompletionTokens: usage.CompletionTokens,
 	}
 
 	// Parse tool calls from message
 	for _, tc := range choice.Message.ToolCalls {
-		argsStr := normalizeJSONArgs(tc.Function.Arguments)
+		argsStr := string(llm.NormalizeToolArguments(tc.Function.Arguments))
 
 		result.ToolCalls = append(result.ToolCalls, session.ToolCall{
 			ID:   tc.ID,
@@ -554,7 +1062,7 @@ func parseChatResponse(resp *llm.ChatResponse) (*ChatResponse, error) {
 		})
 	}
 
-	return result, nil
+	return result
 }
 
 // ToolResult represents a tool execution result
@@ -648,7 +1156,7 @@ func (a *Agent) ExtractToolCallsFromXML(text string, knownTools []string) ([]ses
 	// Convert to session tool calls
 	sessionToolCalls := make([]session.ToolCall, 0, len(toolCalls))
 	for _, tc := range toolCalls {
-		argsStr := normalizeJSONArgs(tc.Function.Arguments)
+		argsStr := string(llm.NormalizeToolArguments(tc.Function.Arguments))
 
 		sessionToolCalls = append(sessionToolCalls, session.ToolCall{
 			ID:   tc.ID,
@@ -709,6 +1217,38 @@ func (a *Agent) GetSession() *session.Session {
 	return a.session
 }
 
+// toLLMMessages converts session messages (GetMessagesForLLM format) to llm.Message.
+// Tool call IDs are carried over so providers can pair tool results with their calls.
+func toLLMMessages(messages []map[string]interface{}) []llm.Message {
+	llmMessages := make([]llm.Message, len(messages))
+	for i, msg := range messages {
+		llmMessages[i] = llm.Message{
+			Role:    msg["role"].(string),
+			Content: msg["content"].(string),
+			ToolID:  getString(msg, "tool_call_id"),
+		}
+		if llmMessages[i].ToolID == "" {
+			llmMessages[i].ToolID = getString(msg, "tool_id")
+		}
+
+		if toolCalls, ok := msg["tool_calls"].([]session.ToolCall); ok {
+			for _, tc := range toolCalls {
+				// OpenAI形式に合わせ arguments は JSON 文字列として渡す
+				args, _ := json.Marshal(tc.Function.Arguments)
+				llmMessages[i].ToolCalls = append(llmMessages[i].ToolCalls, llm.ToolCall{
+					ID:   tc.ID,
+					Type: "function",
+					Function: llm.FunctionCall{
+						Name:      tc.Function.Name,
+						Arguments: args,
+					},
+				})
+			}
+		}
+	}
+	return llmMessages
+}
+
 // Helper function to safely get string from map
 // buildOllamaOptions builds Ollama-specific options from config
 // Note: num_ctx は OllamaProvider の自動エスカレーションが管理するため、ここでは設定しない
@@ -790,10 +1330,166 @@ func (a *Agent) UpdateSystemPrompt(prompt string) {
 	a.session.SetSystemPrompt(prompt)
 }
 
-// Clear clears the session
-func (a *Agent) Clear() {
+// Clear clears the conversation and returns the number of messages removed.
+// The system prompt is rebuilt from the current config so the agent keeps its
+// instructions (including settings changed during the session).
+func (a *Agent) Clear() int {
+	cleared := a.session.GetMessageCount()
 	a.session.Clear()
+	a.session.SetSystemPrompt(a.buildSystemPrompt())
 	a.loopDetector.Reset()
+	return cleared
+}
+
+// buildSystemPrompt builds the system prompt the same way a new session does
+func (a *Agent) buildSystemPrompt() string {
+	if a.skills != nil && a.skills.Count() > 0 {
+		return config.BuildSystemPrompt(a.config, a.skills.GetSkillMetadata())
+	}
+	return config.BuildSystemPrompt(a.config)
+}
+
+// ErrNothingToRetry is returned by PrepareRetry when the user hasn't sent a message yet
+var ErrNothingToRetry = errors.New("no previous user message to retry")
+
+// PrepareRetry returns the most recent user message so it can be run again.
+// Unless keepHistory is set, that message and the turns that followed it are
+// removed first, so the retried run starts from the same context as the original.
+func (a *Agent) PrepareRetry(keepHistory bool) (string, error) {
+	index, input, ok := a.session.LastUserInput()
+	if !ok {
+		return "", ErrNothingToRetry
+	}
+	if !keepHistory {
+		a.session.TruncateMessages(index)
+	}
+	return input, nil
+}
+
+// trimHistoryIfNeeded drops the oldest exchanges when estimated usage exceeds the
+// session's compaction threshold (ContextTrimThreshold unless configured). It does
+// nothing when automatic compaction is disabled.
+func (a *Agent) trimHistoryIfNeeded() {
+	contextWindow := a.contextWindow()
+
+	if !a.session.ShouldAutoCompact(contextWindow) {
+		return
+	}
+
+	result := a.session.TrimToFit(int(float64(contextWindow) * a.trimTarget()))
+	if result != nil {
+		a.terminal.PrintInfo(fmt.Sprintf("Context nearly full: trimmed %d old messages (~%d → %d tokens)",
+			result.CompactedMessages, result.OriginalTokenCount, result.NewTokenCount))
+	}
+}
+
+// trimTarget returns the usage ratio automatic trimming goes down to. It is
+// ContextTrimTarget, scaled along with a configured compaction threshold so the
+// target always stays below the trigger.
+func (a *Agent) trimTarget() float64 {
+	return a.session.CompactionThreshold() * ContextTrimTarget / ContextTrimThreshold
+}
+
+// CompactNow compacts the session on demand (/compact). Old exchanges are replaced
+// by a summary note until history fits ManualCompactTarget of the context window,
+// or AggressiveCompactTarget when aggressive. The system prompt and the latest
+// exchange are always kept. Returns nil when there is nothing to compact.
+func (a *Agent) CompactNow(aggressive bool) *session.CompactionResult {
+	target := ManualCompactTarget
+	if aggressive {
+		target = AggressiveCompactTarget
+	}
+	return a.session.TrimToFit(int(float64(a.contextWindow()) * target))
+}
+
+// SummarizePrompt instructs the model that replaces history with a summary (/summarize)
+const SummarizePrompt = `Summarize the following conversation between a user and a coding assistant.
+Keep: the user's goals, decisions made, files created or modified, unresolved problems, and next steps.
+Be concise (at most 20 bullet points). Do not invent details.
+
+Conversation:
+`
+
+// Summarize replaces the whole history with an LLM-written summary. The call is
+// routed as a lightweight task, so it goes to the sidecar model when one is configured.
+// The session is left unchanged on error.
+func (a *Agent) Summarize(ctx context.Context) (*session.CompactionResult, error) {
+	return a.session.SummarizeWith(func(transcript string) (string, error) {
+		return a.completeTask(ctx, llm.TaskLightweight, SummarizePrompt+transcript)
+	})
+}
+
+// ModelFor returns the model name a task of the given role is routed to
+func (a *Agent) ModelFor(role llm.TaskRole) string {
+	_, model := a.providerFor(role)
+	return model
+}
+
+// providerFor picks the provider and model for a task; without a router everything uses the main model
+func (a *Agent) providerFor(role llm.TaskRole) (llm.LLMProvider, string) {
+	if a.router == nil {
+		return a.provider, a.config.Model
+	}
+	provider, model := a.router.ForTask(role)
+	if provider == nil {
+		return a.provider, a.config.Model
+	}
+	return provider, model
+}
+
+// completeTask sends a single prompt without tools to the model chosen for role
+// and returns the reply text. Used for side tasks outside the main conversation.
+func (a *Agent) completeTask(ctx context.Context, role llm.TaskRole, prompt string) (string, error) {
+	provider, model := a.providerFor(role)
+	req := &llm.ChatRequest{
+		Model:    model,
+		Messages: []llm.Message{{Role: "user", Content: prompt}},
+		Stream:   false,
+	}
+	if a.router != nil {
+		params := a.router.ParamsForModel(model)
+		req.Temperature = params.Temperature
+		req.MaxTokens = params.MaxTokens
+	}
+
+	// Side tasks on a cloud provider count toward the budget, so they need the same confirmation
+	if err := a.confirmBudget(provider, false); err != nil {
+		return "", err
+	}
+	resp, err := provider.Chat(ctx, req)
+	if err != nil {
+		return "", err
+	}
+	served := resp.Model
+	if served == "" {
+		served = model
+	}
+	a.session.AddTokenUsage(provider.Info().Name, served, isCloudProvider(provider), resp.Usage.PromptTokens, resp.Usage.CompletionTokens)
+	if len(resp.Choices) == 0 {
+		return "", fmt.Errorf("empty response from %s", model)
+	}
+	return resp.Choices[0].Message.Content, nil
+}
+
+// servedModel names the model a main-loop call was billed to: the one the
+// response reports, else the model of the provider that answered (the chain's
+// current entry after a fallback), else the configured model
+func (a *Agent) servedModel(reported string) string {
+	if reported != "" {
+		return reported
+	}
+	if model := a.provider.Info().Model; model != "" {
+		return model
+	}
+	return a.config.Model
+}
+
+// contextWindow returns the configured context window, falling back to the session default
+func (a *Agent) contextWindow() int {
+	if a.config.ContextWindow > 0 {
+		return a.config.ContextWindow
+	}
+	return a.session.GetContextWindow()
 }
 
 // GetContextUsagePercent コンテキスト使用率を取得 (0-100)
@@ -852,6 +1548,10 @@ func (a *Agent) validateGeneratedScripts(ctx context.Context, toolCalls []sessio
 	}
 
 	if !result.IsSuccess {
+		if hint := a.suggestScriptFix(ctx, args.FilePath, result); hint != "" {
+			result.Suggestion = hint
+		}
+
 		// Build detailed error message for LLM
 		errorMsg := fmt.Sprintf(
 			"Script validation failed for %s:\n"+
@@ -879,3 +1579,29 @@ func (a *Agent) validateGeneratedScripts(ctx context.Context, toolCalls []sessio
 	a.terminal.PrintColored(ui.ColorGreen, fmt.Sprintf("✓ Script validation passed for %s\n", args.FilePath))
 	return nil
 }
+
+// suggestScriptFix asks the sidecar model for a short fix hint for a failed script.
+// It returns "" when no sidecar is configured or the call fails, so the main
+// model is never used for this.
+func (a *Agent) suggestScriptFix(ctx context.Context, path string, result *CodeValidationResult) string {
+	if a.router == nil || !a.router.HasSidecar() {
+		return ""
+	}
+	prompt := fmt.Sprintf(
+		"The script %s failed when run.\nError type: %s\nError: %s\nStderr:\n%s\n\nIn one or two sentences, suggest the most likely fix. Reply with the suggestion only.",
+		path, result.ErrorType, result.ErrorMessage, tailString(result.StdErr, 2000))
+
+	hint, err := a.completeTask(ctx, llm.TaskLightweight, prompt)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(hint)
+}
+
+// tailString keeps the last max bytes of s (the end of stderr usually has the error)
+func tailString(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	return s[len(s)-max:]
+}
@@ -1,13 +1,22 @@
 package agent
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/zephel01/vibe-local-go/internal/config"
 	"github.com/zephel01/vibe-local-go/internal/llm"
 	"github.com/zephel01/vibe-local-go/internal/security"
 	"github.com/zephel01/vibe-local-go/internal/session"
+	"github.com/zephel01/vibe-local-go/internal/skill"
 	"github.com/zephel01/vibe-local-go/internal/tool"
 	"github.com/zephel01/vibe-local-go/internal/ui"
 )
@@ -236,6 +245,32 @@ func TestClear(t *testing.T) {
 	}
 }
 
+func TestAgentClear_KeepsSystemPrompt(t *testing.T) {
+	agent := createSimpleTestAgent()
+	sess := agent.GetSession()
+	sess.AddUserMessage("first")
+	sess.AddAssistantMessage("reply")
+	sess.AddUserMessage("second")
+	agent.loopDetector.RecordToolCall("bash", `{"command":"ls"}`)
+
+	if cleared := agent.Clear(); cleared != 3 {
+		t.Errorf("Clear() = %d, want 3 cleared messages", cleared)
+	}
+	if n := sess.GetMessageCount(); n != 0 {
+		t.Errorf("message count after clear = %d, want 0", n)
+	}
+	want := config.BuildSystemPrompt(agent.config)
+	if sess.SystemPrompt == "" || sess.SystemPrompt != want {
+		t.Errorf("system prompt should be rebuilt from the config after clear, got %q", sess.SystemPrompt)
+	}
+	if len(sess.GetMessagesForLLM()) != 1 {
+		t.Error("the LLM should still receive the system prompt after clear")
+	}
+	if agent.loopDetector.GetHistorySize() != 0 {
+		t.Error("loop detector should be reset by clear")
+	}
+}
+
 func TestLoopDetectorInAgent(t *testing.T) {
 	agent := createSimpleTestAgent()
 
@@ -433,74 +468,6 @@ func TestLoopDetectorClearToolCount(t *testing.T) {
 	}
 }
 
-func TestNormalizeJSONArgs(t *testing.T) {
-	tests := []struct {
-		name     string
-		input    string
-		expected string
-	}{
-		{
-			name:     "already a JSON object",
-			input:    `{"command":"ls"}`,
-			expected: `{"command":"ls"}`,
-		},
-		{
-			name:     "single string encoding",
-			input:    `"{\"command\":\"ls\"}"`,
-			expected: `{"command":"ls"}`,
-		},
-		{
-			name:     "double string encoding",
-			input:    `"\"{\\\"command\\\":\\\"ls\\\"}\""`,
-			expected: `{"command":"ls"}`,
-		},
-		{
-			name:     "string with unicode escapes",
-			input:    `"{\"command\":\"mkdir tetris \\u0026\\u0026 cd tetris\"}"`,
-			expected: `{"command":"mkdir tetris \u0026\u0026 cd tetris"}`,
-		},
-		{
-			name:     "complex object with newlines",
-			input:    `"{\"path\":\"test.py\",\"content\":\"print(\\\"hello\\\")\\n\"}"`,
-			expected: `{"content":"print(\"hello\")\n","path":"test.py"}`,
-		},
-		{
-			name:     "empty object",
-			input:    `{}`,
-			expected: `{}`,
-		},
-		{
-			name:     "empty string encoded",
-			input:    `"{}"`,
-			expected: `{}`,
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			result := normalizeJSONArgs([]byte(tt.input))
-			// Parse both expected and result as JSON for comparison
-			// (key ordering may differ)
-			var expectedMap, resultMap map[string]interface{}
-			if err1 := json.Unmarshal([]byte(tt.expected), &expectedMap); err1 == nil {
-				if err2 := json.Unmarshal([]byte(result), &resultMap); err2 == nil {
-					// Compare maps
-					expectedBytes, _ := json.Marshal(expectedMap)
-					resultBytes, _ := json.Marshal(resultMap)
-					if string(expectedBytes) != string(resultBytes) {
-						t.Errorf("normalizeJSONArgs(%s) = %s, want %s", tt.input, result, tt.expected)
-					}
-					return
-				}
-			}
-			// Fallback: string comparison
-			if result != tt.expected {
-				t.Errorf("normalizeJSONArgs(%s) = %s, want %s", tt.input, result, tt.expected)
-			}
-		})
-	}
-}
-
 func TestParseChatResponseWithStringArgs(t *testing.T) {
 	// Simulate LLM returning arguments as a JSON string (common with Ollama)
 	resp := &llm.ChatResponse{
@@ -623,6 +590,64 @@ func TestDynamicMaxTokens(t *testing.T) {
 	}
 }
 
+func TestSamplingParams(t *testing.T) {
+	agent := createSimpleTestAgent()
+	agent.config = config.DefaultConfig()
+
+	// Nothing configured and no router: DefaultConfig's values
+	temperature, maxTokens := agent.samplingParams()
+	if temperature != config.DefaultTemperature || maxTokens != config.DefaultMaxTokens {
+		t.Errorf("samplingParams() = (%v, %d), want (%v, %d)", temperature, maxTokens, config.DefaultTemperature, config.DefaultMaxTokens)
+	}
+
+	// Router fills in per-tier defaults for unset values
+	agent.config.Model = "qwen3:4b"
+	agent.SetModelRouter(llm.NewModelRouter(agent.provider, nil, agent.config.Model, ""))
+	temperature, maxTokens = agent.samplingParams()
+	if temperature != 0.2 || maxTokens != 2048 {
+		t.Errorf("samplingParams() with router = (%v, %d), want (0.2, 2048)", temperature, maxTokens)
+	}
+
+	// Explicit config values win over the router
+	agent.config.Temperature = 0.9
+	agent.config.MaxTokens = 1000
+	temperature, maxTokens = agent.samplingParams()
+	if temperature != 0.9 || maxTokens != 1000 {
+		t.Errorf("samplingParams() with config = (%v, %d), want (0.9, 1000)", temperature, maxTokens)
+	}
+}
+
+func TestSamplingParams_RouterOverridesBuiltInDefaults(t *testing.T) {
+	agent := createSimpleTestAgent()
+	cfg := config.DefaultConfig()
+	cfg.Model = "qwen3:8b"
+	agent.config = cfg
+	agent.SetModelRouter(llm.NewModelRouter(agent.provider, nil, cfg.Model, ""))
+
+	// DefaultConfig's temperature and max_tokens are not a user choice
+	temperature, maxTokens := agent.samplingParams()
+	if temperature != 0.3 || maxTokens != 4096 {
+		t.Errorf("samplingParams() with defaults = (%v, %d), want the router's (0.3, 4096)", temperature, maxTokens)
+	}
+
+	// A value from config.json or a flag wins, even if it equals the default
+	cfg.SetSource("MAX_TOKENS", config.SourceConfig)
+	cfg.Temperature = 0.6
+	cfg.SetSource("TEMPERATURE", config.SourceFlag)
+	temperature, maxTokens = agent.samplingParams()
+	if temperature != 0.6 || maxTokens != config.DefaultMaxTokens {
+		t.Errorf("samplingParams() with explicit values = (%v, %d), want (0.6, %d)", temperature, maxTokens, config.DefaultMaxTokens)
+	}
+
+	// An explicit 0 is kept rather than treated as unset
+	cfg.Temperature = 0
+	cfg.SetSource("TEMPERATURE", config.SourceEnv)
+	temperature, _ = agent.samplingParams()
+	if temperature != 0 {
+		t.Errorf("samplingParams() with TEMPERATURE=0 = %v, want 0", temperature)
+	}
+}
+
 func TestLoopDetectorStuckLoop(t *testing.T) {
 	agent := createSimpleTestAgent()
 
@@ -643,3 +668,884 @@ func TestLoopDetectorStuckLoop(t *testing.T) {
 		t.Error("Should suggest abort for stuck loop")
 	}
 }
+
+func TestToLLMMessages_CarriesToolCallIDs(t *testing.T) {
+	sess := session.NewSession("test", "system")
+	sess.AddUserMessage("read main.go")
+	sess.AddToolCall([]session.ToolCall{
+		{ID: "call_1", Type: "function", Function: session.FunctionCall{Name: "read_file", Arguments: `{"path":"main.go"}`}},
+	})
+	sess.AddToolResults([]session.ToolResult{{ToolCallID: "call_1", Content: "package main"}})
+
+	msgs := toLLMMessages(sess.GetMessagesForLLM())
+	if len(msgs) != 4 {
+		t.Fatalf("expected 4 messages, got %d", len(msgs))
+	}
+
+	assistant := msgs[2]
+	if len(assistant.ToolCalls) != 1 || assistant.ToolCalls[0].ID != "call_1" {
+		t.Fatalf("expected assistant tool call call_1, got %+v", assistant.ToolCalls)
+	}
+
+	var args string
+	if err := json.Unmarshal(assistant.ToolCalls[0].Function.Arguments, &args); err != nil || args != `{"path":"main.go"}` {
+		t.Errorf("expected arguments as JSON string, got %s", assistant.ToolCalls[0].Function.Arguments)
+	}
+
+	if msgs[3].Role != "tool" || msgs[3].ToolID != "call_1" {
+		t.Errorf("expected tool result paired with call_1, got %+v", msgs[3])
+	}
+}
+
+func TestTrimHistoryIfNeeded(t *testing.T) {
+	agent := createSimpleTestAgent()
+	agent.config.ContextWindow = 2000
+
+	sess := agent.GetSession()
+	long := strings.Repeat("some fairly long content ", 60)
+	for i := 0; i < 10; i++ {
+		sess.AddUserMessage(long)
+		sess.AddAssistantMessage(long)
+	}
+	sess.AddUserMessage("latest request")
+
+	agent.trimHistoryIfNeeded()
+
+	if tokens := sess.EstimateTotalTokens(); float64(tokens) > float64(agent.config.ContextWindow)*ContextTrimTarget {
+		t.Errorf("tokens = %d, want <= %.0f after trimming", tokens, float64(agent.config.ContextWindow)*ContextTrimTarget)
+	}
+
+	messages := sess.GetMessages()
+	if messages[len(messages)-1].Content != "latest request" {
+		t.Errorf("latest request should be kept, got %q", messages[len(messages)-1].Content)
+	}
+}
+
+// flakyProvider fails the first `failures` Chat calls with err, then succeeds
+type flakyProvider struct {
+	failures int
+	err      error
+	calls    int
+	usage    llm.Usage
+}
+
+func (p *flakyProvider) Chat(ctx context.Context, req *llm.ChatRequest) (*llm.ChatResponse, error) {
+	p.calls++
+	if p.calls <= p.failures {
+		return nil, p.err
+	}
+	return &llm.ChatResponse{
+		Choices: []llm.Choice{{Message: llm.Message{Role: "assistant", Content: "recovered"}}},
+		Usage:   p.usage,
+	}, nil
+}
+
+func (p *flakyProvider) ChatStream(ctx context.Context, req *llm.ChatRequest) (<-chan llm.StreamEvent, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (p *flakyProvider) CheckHealth(ctx context.Context) error { return nil }
+
+func (p *flakyProvider) Info() llm.ProviderInfo { return llm.ProviderInfo{Name: "flaky"} }
+
+func (p *flakyProvider) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	return nil, llm.ErrEmbeddingsUnsupported
+}
+
+func TestCallLLM_RetriesTransientErrors(t *testing.T) {
+	agent := createSimpleTestAgent()
+	provider := &flakyProvider{failures: 2, err: fmt.Errorf("request failed with status 503: overloaded")}
+	agent.provider = provider
+
+	resp, err := agent.callLLM(context.Background(), nil, nil, 0)
+	if err != nil {
+		t.Fatalf("expected success after retries, got %v", err)
+	}
+	if resp.Content != "recovered" {
+		t.Errorf("Content = %q, want %q", resp.Content, "recovered")
+	}
+	if provider.calls != 3 {
+		t.Errorf("calls = %d, want 3", provider.calls)
+	}
+}
+
+func TestCallLLM_NonRetryableFailsFast(t *testing.T) {
+	agent := createSimpleTestAgent()
+	provider := &flakyProvider{failures: 5, err: fmt.Errorf("LLM error: model 'nope' not found")}
+	agent.provider = provider
+
+	if _, err := agent.callLLM(context.Background(), nil, nil, 0); err == nil {
+		t.Fatal("expected error")
+	}
+	if provider.calls != 1 {
+		t.Errorf("calls = %d, want 1 (no retry)", provider.calls)
+	}
+}
+
+func TestCallLLM_GivesUpAfterMaxRetries(t *testing.T) {
+	agent := createSimpleTestAgent()
+	provider := &flakyProvider{failures: 10, err: fmt.Errorf("connection reset by peer")}
+	agent.provider = provider
+
+	if _, err := agent.callLLM(context.Background(), nil, nil, 0); err == nil {
+		t.Fatal("expected error")
+	}
+	if provider.calls != MaxLLMRetries+1 {
+		t.Errorf("calls = %d, want %d", provider.calls, MaxLLMRetries+1)
+	}
+}
+
+func TestRetryBudget_SharedAcrossProviderAndTools(t *testing.T) {
+	agent := createSimpleTestAgent()
+	provider := &flakyProvider{failures: 2, err: fmt.Errorf("connection reset by peer")}
+	agent.provider = provider
+
+	toolCalls := 0
+	agent.registry.RegisterWithOptions("flaky_tool", &mockTool{
+		name: "flaky_tool",
+		execute: func(ctx context.Context, args json.RawMessage) (*tool.Result, error) {
+			toolCalls++
+			return nil, fmt.Errorf("request timeout")
+		},
+		schema: &tool.FunctionSchema{Name: "flaky_tool"},
+	}, tool.WithMaxRetries(5), tool.WithRetryBackoff(0))
+	dispatcher := NewDispatcher(agent.registry, nil, nil, nil)
+
+	ctx := llm.WithRetryBudget(context.Background(), llm.NewRetryBudget(3))
+
+	// The provider recovers after 2 retries, leaving 1 for the tool
+	if _, err := agent.callLLM(ctx, nil, nil, 0); err != nil {
+		t.Fatalf("callLLM: %v", err)
+	}
+	if provider.calls != 3 {
+		t.Errorf("provider calls = %d, want 3", provider.calls)
+	}
+
+	result := dispatcher.executeSingleTool(ctx, &session.ToolCall{
+		ID:       "1",
+		Function: session.FunctionCall{Name: "flaky_tool", Arguments: `{}`},
+	})
+	if result.IsSuccess {
+		t.Fatal("expected tool failure")
+	}
+	if toolCalls != 2 {
+		t.Errorf("tool calls = %d, want 2 (one retry left in the budget)", toolCalls)
+	}
+	if !strings.Contains(result.Error, "retry budget exhausted") {
+		t.Errorf("error = %q, want aggregate budget error", result.Error)
+	}
+	if !strings.Contains(result.Error, "connection reset by peer") || !strings.Contains(result.Error, "request timeout") {
+		t.Errorf("error = %q, want it to list provider and tool retries", result.Error)
+	}
+
+	// Once spent, the provider layer stops retrying as well
+	provider.calls, provider.failures = 0, 10
+	_, err := agent.callLLM(ctx, nil, nil, 0)
+	if err == nil || !strings.Contains(err.Error(), "retry budget exhausted") {
+		t.Fatalf("err = %v, want retry budget exhausted", err)
+	}
+	if provider.calls != 1 {
+		t.Errorf("provider calls = %d, want 1 (no retries left)", provider.calls)
+	}
+}
+
+func TestRun_AccumulatesTokenUsage(t *testing.T) {
+	agent := createSimpleTestAgent()
+	agent.provider = &flakyProvider{usage: llm.Usage{PromptTokens: 100, CompletionTokens: 20}}
+
+	for i := 0; i < 2; i++ {
+		if err := agent.Run(context.Background(), "hello"); err != nil {
+			t.Fatalf("Run: %v", err)
+		}
+	}
+
+	usage := agent.GetTokenUsage()
+	if usage.PromptTokens != 200 || usage.CompletionTokens != 40 {
+		t.Errorf("usage = %+v, want prompt=200 completion=40", usage)
+	}
+	if usage.Total() != 240 {
+		t.Errorf("Total() = %d, want 240", usage.Total())
+	}
+
+	// The session keeps the same totals per provider/model for /usage
+	perModel := agent.GetSession().GetTokenUsage()
+	if len(perModel) != 1 {
+		t.Fatalf("session usage = %+v, want one model", perModel)
+	}
+	if got := perModel[0]; got.Provider != "flaky" || got.Model != agent.config.Model ||
+		got.PromptTokens != 200 || got.CompletionTokens != 40 || got.Calls != 2 {
+		t.Errorf("session usage = %+v, want flaky/%s prompt=200 completion=40 calls=2", got, agent.config.Model)
+	}
+}
+
+// multiChoiceProvider returns one choice per requested completion
+type multiChoiceProvider struct {
+	requestedN []int
+}
+
+func (p *multiChoiceProvider) Chat(ctx context.Context, req *llm.ChatRequest) (*llm.ChatResponse, error) {
+	p.requestedN = append(p.requestedN, req.N)
+	n := req.N
+	if n < 1 {
+		n = 1
+	}
+	choices := make([]llm.Choice, 0, n)
+	for i := 0; i < n; i++ {
+		choices = append(choices, llm.Choice{
+			Index:   i,
+			Message: llm.Message{Role: "assistant", Content: fmt.Sprintf("answer %d", i+1)},
+		})
+	}
+	return &llm.ChatResponse{Choices: choices}, nil
+}
+
+func (p *multiChoiceProvider) ChatStream(ctx context.Context, req *llm.ChatRequest) (<-chan llm.StreamEvent, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (p *multiChoiceProvider) CheckHealth(ctx context.Context) error { return nil }
+
+func (p *multiChoiceProvider) Info() llm.ProviderInfo {
+	return llm.ProviderInfo{Name: "multi", Features: llm.Features{MultipleChoices: true}}
+}
+
+func (p *multiChoiceProvider) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	return nil, llm.ErrEmbeddingsUnsupported
+}
+
+func TestParseChatChoices(t *testing.T) {
+	resp := &llm.ChatResponse{
+		Choices: []llm.Choice{
+			{Message: llm.Message{Content: "first"}},
+			{Message: llm.Message{ToolCalls: []llm.ToolCall{{
+				ID:       "call_1",
+				Type:     "function",
+				Function: llm.FunctionCall{Name: "bash", Arguments: json.RawMessage(`{"command":"ls"}`)},
+			}}}},
+		},
+		Usage: llm.Usage{PromptTokens: 10, CompletionTokens: 5},
+	}
+
+	choices, err := parseChatChoices(resp)
+	if err != nil {
+		t.Fatalf("parseChatChoices: %v", err)
+	}
+	if len(choices) != 2 {
+		t.Fatalf("len(choices) = %d, want 2", len(choices))
+	}
+	if choices[0].Content != "first" {
+		t.Errorf("choices[0].Content = %q, want %q", choices[0].Content, "first")
+	}
+	if len(choices[1].ToolCalls) != 1 || choices[1].ToolCalls[0].Function.Name != "bash" {
+		t.Errorf("choices[1].ToolCalls = %+v, want one bash call", choices[1].ToolCalls)
+	}
+
+	if _, err := parseChatChoices(&llm.ChatResponse{}); err == nil {
+		t.Error("expected error for response without choices")
+	}
+}
+
+func TestRun_ChoicesPresentsCandidatesAndContinuesWithPick(t *testing.T) {
+	agent := createSimpleTestAgent()
+	provider := &multiChoiceProvider{}
+	agent.provider = provider
+
+	var presented []string
+	agent.choose = func(candidates []string) (int, error) {
+		presented = candidates
+		return 1, nil
+	}
+
+	if err := agent.SetChoices(3); err != nil {
+		t.Fatalf("SetChoices: %v", err)
+	}
+	if err := agent.Run(context.Background(), "hello"); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	if len(presented) != 3 || presented[0] != "answer 1" || presented[2] != "answer 3" {
+		t.Errorf("presented = %v, want answer 1..3", presented)
+	}
+	msgs := agent.GetSession().GetMessages()
+	if last := msgs[len(msgs)-1]; last.Content != "answer 2" {
+		t.Errorf("last message = %q, want %q", last.Content, "answer 2")
+	}
+
+	// The mode applies to a single turn only
+	if err := agent.Run(context.Background(), "again"); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if len(provider.requestedN) != 2 || provider.requestedN[0] != 3 || provider.requestedN[1] != 0 {
+		t.Errorf("requested N = %v, want [3 0]", provider.requestedN)
+	}
+}
+
+func TestSetChoices_RequiresProviderSupport(t *testing.T) {
+	agent := createSimpleTestAgent()
+	agent.provider = &flakyProvider{}
+
+	if err := agent.SetChoices(2); err == nil {
+		t.Error("expected error for provider without MultipleChoices")
+	}
+	if err := agent.SetChoices(0); err != nil {
+		t.Errorf("SetChoices(0): %v", err)
+	}
+}
+
+// blockingTool blocks until its context is cancelled
+type blockingTool struct {
+	started chan struct{}
+}
+
+func (b *blockingTool) Name() string { return "block" }
+
+func (b *blockingTool) Execute(ctx context.Context, params json.RawMessage) (*tool.Result, error) {
+	close(b.started)
+	<-ctx.Done()
+	return tool.NewResult("partial line"), nil
+}
+
+func (b *blockingTool) Schema() *tool.FunctionSchema {
+	return &tool.FunctionSchema{Name: "block", Parameters: &tool.ParameterSchema{Type: "object"}}
+}
+
+// toolThenTextProvider calls the block tool once, then answers with text and
+// records the tool result it was sent
+type toolThenTextProvider struct {
+	calls      int
+	toolResult string
+	toolName   string // Tool called on the first turn (default "block")
+	arguments  string // Its JSON arguments (default "{}")
+}
+
+func (p *toolThenTextProvider) Chat(ctx context.Context, req *llm.ChatRequest) (*llm.ChatResponse, error) {
+	p.calls++
+	if p.calls == 1 {
+		name, args := p.toolName, p.arguments
+		if name == "" {
+			name, args = "block", `{}`
+		}
+		return &llm.ChatResponse{Choices: []llm.Choice{{Message: llm.Message{
+			Role: "assistant",
+			ToolCalls: []llm.ToolCall{{
+				ID:       "call_1",
+				Type:     "function",
+				Function: llm.FunctionCall{Name: name, Arguments: json.RawMessage(args)},
+			}},
+		}}}}, nil
+	}
+	for _, m := range req.Messages {
+		if m.Role == "tool" {
+			p.toolResult = m.Content
+		}
+	}
+	return &llm.ChatResponse{Choices: []llm.Choice{{Message: llm.Message{Role: "assistant", Content: "adapted"}}}}, nil
+}
+
+func (p *toolThenTextProvider) ChatStream(ctx context.Context, req *llm.ChatRequest) (<-chan llm.StreamEvent, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (p *toolThenTextProvider) CheckHealth(ctx context.Context) error { return nil }
+
+func (p *toolThenTextProvider) Info() llm.ProviderInfo { return llm.ProviderInfo{Name: "scripted"} }
+
+func (p *toolThenTextProvider) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	return nil, llm.ErrEmbeddingsUnsupported
+}
+
+func TestRun_CancelCurrentToolContinuesTurn(t *testing.T) {
+	agent := createSimpleTestAgent()
+	provider := &toolThenTextProvider{}
+	agent.provider = provider
+	blocker := &blockingTool{started: make(chan struct{})}
+	agent.registry.Register(blocker)
+
+	if _, ok := agent.CancelCurrentTool(); ok {
+		t.Error("expected no tool to cancel before Run")
+	}
+
+	go func() {
+		<-blocker.started
+		if name, ok := agent.CancelCurrentTool(); !ok || name != "block" {
+			t.Errorf("CancelCurrentTool() = %q, %v; want block, true", name, ok)
+		}
+	}()
+
+	if err := agent.Run(context.Background(), "run the slow thing"); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	if provider.calls != 2 {
+		t.Errorf("LLM calls = %d, want 2 (turn continues after cancel)", provider.calls)
+	}
+	if !strings.Contains(provider.toolResult, "cancelled by user") || !strings.Contains(provider.toolResult, "partial line") {
+		t.Errorf("tool result sent to LLM = %q, want cancellation with partial output", provider.toolResult)
+	}
+	msgs := agent.GetSession().GetMessages()
+	if last := msgs[len(msgs)-1]; last.Content != "adapted" {
+		t.Errorf("last message = %q, want %q", last.Content, "adapted")
+	}
+	if _, ok := agent.CancelCurrentTool(); ok {
+		t.Error("expected no tool in flight after Run")
+	}
+}
+
+func TestRun_DryRunLeavesWriteTargetUntouched(t *testing.T) {
+	agent := createSimpleTestAgent()
+	agent.registry.Register(tool.NewWriteTool())
+	agent.SetDryRun(true)
+
+	path := filepath.Join(t.TempDir(), "out.txt")
+	if err := os.WriteFile(path, []byte("original"), 0644); err != nil {
+		t.Fatalf("failed to create target: %v", err)
+	}
+	args, _ := json.Marshal(map[string]string{"path": path, "content": "overwritten"})
+	provider := &toolThenTextProvider{toolName: "write_file", arguments: string(args)}
+	agent.provider = provider
+
+	if err := agent.Run(context.Background(), "overwrite the file"); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil || string(data) != "original" {
+		t.Errorf("target = %q (err %v), want it untouched in dry-run mode", data, err)
+	}
+	if provider.calls != 2 {
+		t.Errorf("LLM calls = %d, want 2 (agent keeps looping after the preview)", provider.calls)
+	}
+	if !strings.Contains(provider.toolResult, "Would execute write_file") {
+		t.Errorf("tool result sent to LLM = %q, want a dry-run preview", provider.toolResult)
+	}
+}
+
+// concurrencyTool records how many of its calls run at the same time
+type concurrencyTool struct {
+	name     string
+	mu       sync.Mutex
+	inFlight int
+	maxSeen  int
+}
+
+func (c *concurrencyTool) Name() string { return c.name }
+
+func (c *concurrencyTool) Execute(ctx context.Context, params json.RawMessage) (*tool.Result, error) {
+	c.mu.Lock()
+	c.inFlight++
+	if c.inFlight > c.maxSeen {
+		c.maxSeen = c.inFlight
+	}
+	c.mu.Unlock()
+	time.Sleep(20 * time.Millisecond)
+	c.mu.Lock()
+	c.inFlight--
+	c.mu.Unlock()
+	return tool.NewResult(string(params)), nil
+}
+
+func (c *concurrencyTool) Schema() *tool.FunctionSchema {
+	return &tool.FunctionSchema{Name: c.name, Parameters: &tool.ParameterSchema{Type: "object"}}
+}
+
+func TestExecuteParallel_PromptedCallsRunOneAtATime(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	agent := createSimpleTestAgent()
+	permMgr, err := security.NewPermissionManager(false)
+	if err != nil {
+		t.Fatalf("NewPermissionManager: %v", err)
+	}
+	agent.permissionMgr = permMgr
+	agent.config.AutoApprove = true // answer the prompts without a terminal
+
+	fetch := &concurrencyTool{name: "web_fetch"}
+	agent.registry.Register(fetch)
+
+	calls := make([]session.ToolCall, 3)
+	for i := range calls {
+		calls[i] = session.ToolCall{ID: fmt.Sprintf("call_%d", i), Type: "function"}
+		calls[i].Function.Name = "web_fetch"
+		calls[i].Function.Arguments = fmt.Sprintf(`{"n": %d}`, i)
+	}
+
+	results := agent.executeParallel(context.Background(), calls)
+	if fetch.maxSeen != 1 {
+		t.Errorf("web_fetch calls needing a prompt ran %d at once, want 1", fetch.maxSeen)
+	}
+	for i, r := range results {
+		if r.ToolCallID != calls[i].ID || r.Content != calls[i].Function.Arguments {
+			t.Errorf("results[%d] = %+v, want the result of %s", i, r, calls[i].ID)
+		}
+	}
+}
+
+func TestCompactNow_KeepsSystemPromptAndLastExchange(t *testing.T) {
+	agent := createSimpleTestAgent()
+	agent.config.ContextWindow = 8000
+	agent.UpdateSystemPrompt("You are a helpful assistant.")
+	sess := agent.GetSession()
+
+	for i := 0; i < 6; i++ {
+		sess.AddUserMessage(fmt.Sprintf("question %d %s", i, strings.Repeat("context ", 60)))
+		sess.AddAssistantMessage(fmt.Sprintf("answer %d %s", i, strings.Repeat("details ", 60)))
+	}
+
+	// Normal /compact: history (~1500 tokens) already fits 25% of 8000
+	if result := agent.CompactNow(false); result != nil {
+		t.Errorf("expected nothing to compact, got %+v", result)
+	}
+
+	result := agent.CompactNow(true)
+	if result == nil {
+		t.Fatal("expected aggressive compaction to compact")
+	}
+	if result.CompactedMessages <= 0 || result.NewTokenCount >= result.OriginalTokenCount {
+		t.Errorf("expected messages removed and tokens saved, got %+v", result)
+	}
+
+	if sess.SystemPrompt != "You are a helpful assistant." {
+		t.Errorf("system prompt changed: %q", sess.SystemPrompt)
+	}
+	msgs := sess.GetMessages()
+	if len(msgs) != 3 {
+		t.Fatalf("expected summary note + last exchange, got %d messages", len(msgs))
+	}
+	if !strings.HasPrefix(msgs[1].Content, "question 5") || !strings.HasPrefix(msgs[2].Content, "answer 5") {
+		t.Errorf("expected last exchange kept, got %q / %q", msgs[1].Content, msgs[2].Content)
+	}
+}
+
+// recordingProvider records the model of every request and replies with a fixed text
+type recordingProvider struct {
+	name   string
+	reply  string
+	models []string
+}
+
+func (p *recordingProvider) Chat(ctx context.Context, req *llm.ChatRequest) (*llm.ChatResponse, error) {
+	p.models = append(p.models, req.Model)
+	return &llm.ChatResponse{Choices: []llm.Choice{{Message: llm.Message{Role: "assistant", Content: p.reply}}}}, nil
+}
+
+func (p *recordingProvider) ChatStream(ctx context.Context, req *llm.ChatRequest) (<-chan llm.StreamEvent, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (p *recordingProvider) CheckHealth(ctx context.Context) error { return nil }
+
+func (p *recordingProvider) Info() llm.ProviderInfo { return llm.ProviderInfo{Name: p.name} }
+
+func (p *recordingProvider) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	return nil, llm.ErrEmbeddingsUnsupported
+}
+
+func TestSummarize_RoutesToSidecar(t *testing.T) {
+	agent := createSimpleTestAgent()
+	mainProvider := &recordingProvider{name: "main", reply: "main summary"}
+	sidecarProvider := &recordingProvider{name: "sidecar", reply: "- user wants a CLI"}
+	agent.provider = mainProvider
+	agent.config.Model = "main-model"
+	agent.SetModelRouter(llm.NewModelRouter(mainProvider, sidecarProvider, "main-model", "sidecar-model"))
+
+	agent.session.AddUserMessage("build me a CLI")
+	agent.session.AddAssistantMessage("sure")
+
+	result, err := agent.Summarize(context.Background())
+	if err != nil {
+		t.Fatalf("Summarize() error = %v", err)
+	}
+
+	if len(sidecarProvider.models) != 1 || sidecarProvider.models[0] != "sidecar-model" {
+		t.Errorf("sidecar requests = %v, want one request for sidecar-model", sidecarProvider.models)
+	}
+	if len(mainProvider.models) != 0 {
+		t.Errorf("main model should not be used for summaries, got requests %v", mainProvider.models)
+	}
+	if !strings.Contains(result.Summary, "user wants a CLI") {
+		t.Errorf("summary = %q, want the sidecar's reply", result.Summary)
+	}
+	if got := agent.ModelFor(llm.TaskReasoning); got != "main-model" {
+		t.Errorf("ModelFor(TaskReasoning) = %q, want main-model", got)
+	}
+}
+
+func TestSummarize_UsesMainModelWithoutSidecar(t *testing.T) {
+	agent := createSimpleTestAgent()
+	mainProvider := &recordingProvider{name: "main", reply: "main summary"}
+	agent.provider = mainProvider
+	agent.config.Model = "main-model"
+	agent.SetModelRouter(llm.NewModelRouter(mainProvider, nil, "main-model", ""))
+
+	agent.session.AddUserMessage("hello")
+
+	if _, err := agent.Summarize(context.Background()); err != nil {
+		t.Fatalf("Summarize() error = %v", err)
+	}
+	if len(mainProvider.models) != 1 || mainProvider.models[0] != "main-model" {
+		t.Errorf("main requests = %v, want one request for main-model", mainProvider.models)
+	}
+}
+
+func TestWithTurnSkill(t *testing.T) {
+	agent := createSimpleTestAgent()
+	messages := []map[string]interface{}{
+		{"role": "system", "content": "base prompt"},
+		{"role": "user", "content": "make a pdf report"},
+	}
+
+	if got := agent.withTurnSkill(messages); len(got) != 2 {
+		t.Fatalf("no active skill: got %d messages, want 2", len(got))
+	}
+
+	agent.turnSkill = "## Active skill: pdf-report"
+	got := agent.withTurnSkill(messages)
+	if len(got) != 3 {
+		t.Fatalf("got %d messages, want 3", len(got))
+	}
+	if got[0]["content"] != "base prompt" || got[1]["role"] != "system" || got[1]["content"] != agent.turnSkill || got[2]["role"] != "user" {
+		t.Errorf("skill should follow the system prompt: %v", got)
+	}
+	if len(messages) != 2 {
+		t.Error("the session's messages must not be modified")
+	}
+}
+
+func TestUseSkillAndClear(t *testing.T) {
+	agent := createSimpleTestAgent()
+	path := filepath.Join(t.TempDir(), "SKILL.md")
+	if err := os.WriteFile(path, []byte("---\nname: lint\ndescription: Run linters\n---\nAlways run golangci-lint.\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := agent.UseSkill(&skill.SkillMeta{Name: "lint", SkillFile: path}); err != nil {
+		t.Fatalf("UseSkill: %v", err)
+	}
+	if agent.PinnedSkill() != "lint" {
+		t.Errorf("PinnedSkill = %q, want lint", agent.PinnedSkill())
+	}
+
+	messages := []map[string]interface{}{{"role": "user", "content": "hi"}}
+	got := agent.withTurnSkill(messages)
+	if len(got) != 2 || got[0]["role"] != "system" || !strings.Contains(got[0]["content"].(string), "Always run golangci-lint.") {
+		t.Fatalf("loaded skill should be injected before the conversation: %v", got)
+	}
+	if strings.Contains(got[0]["content"].(string), "description:") {
+		t.Error("frontmatter should not be injected")
+	}
+
+	if cleared := agent.ClearSkill(); cleared != "lint" {
+		t.Errorf("ClearSkill = %q, want lint", cleared)
+	}
+	if len(agent.withTurnSkill(messages)) != 1 {
+		t.Error("cleared skill should no longer be injected")
+	}
+	if cleared := agent.ClearSkill(); cleared != "" {
+		t.Errorf("second ClearSkill = %q, want empty", cleared)
+	}
+
+	if err := agent.UseSkill(&skill.SkillMeta{Name: "gone", SkillFile: filepath.Join(t.TempDir(), "missing.md")}); err == nil {
+		t.Error("expected an error for an unreadable SKILL.md")
+	}
+	if agent.PinnedSkill() != "" {
+		t.Error("a failed UseSkill must not pin the skill")
+	}
+}
+
+func TestTrimHistoryIfNeeded_ConfiguredThreshold(t *testing.T) {
+	agent := createSimpleTestAgent()
+	agent.config.ContextWindow = 2000
+	sess := agent.GetSession()
+
+	// ~60% of the window: below the default trigger, above a 0.5 trigger
+	for sess.EstimateTotalTokens() < 1200 {
+		sess.AddUserMessage(strings.Repeat("some fairly long content ", 10))
+		sess.AddAssistantMessage("ok")
+	}
+	sess.AddUserMessage("latest request")
+	before := sess.GetMessageCount()
+
+	agent.trimHistoryIfNeeded()
+	if sess.GetMessageCount() != before {
+		t.Fatal("history below the default threshold should not be trimmed")
+	}
+
+	sess.SetCompactThreshold(0.5)
+	agent.trimHistoryIfNeeded()
+	if tokens := sess.EstimateTotalTokens(); float64(tokens) > 2000*0.5 {
+		t.Errorf("tokens = %d, want <= 1000 after trimming at the 0.5 threshold", tokens)
+	}
+
+	sess.SetAutoCompact(false)
+	for sess.EstimateTotalTokens() < 1800 {
+		sess.AddUserMessage(strings.Repeat("some fairly long content ", 10))
+	}
+	before = sess.GetMessageCount()
+	agent.trimHistoryIfNeeded()
+	if sess.GetMessageCount() != before {
+		t.Error("history should not be trimmed with auto compaction disabled")
+	}
+}
+
+func TestNewAgent_AppliesCompactionConfig(t *testing.T) {
+	cfg := &config.Config{ContextWindow: 4096, CompactThreshold: 0.6, NoAutoCompact: true}
+	sess := session.NewSession("test-session", "")
+	NewAgent(llm.NewOllamaProvider("http://localhost:11434", "m"), tool.NewRegistry(), nil, nil, sess, ui.NewTerminal(), cfg)
+
+	if sess.GetContextWindow() != 4096 || sess.CompactionThreshold() != 0.6 || sess.AutoCompactEnabled() {
+		t.Errorf("session settings = window %d, threshold %v, auto %v", sess.GetContextWindow(), sess.CompactionThreshold(), sess.AutoCompactEnabled())
+	}
+
+	// Without --compact-at, trimming starts where it always has
+	sess = session.NewSession("test-session", "")
+	NewAgent(llm.NewOllamaProvider("http://localhost:11434", "m"), tool.NewRegistry(), nil, nil, sess, ui.NewTerminal(), &config.Config{})
+	if sess.CompactionThreshold() != ContextTrimThreshold {
+		t.Errorf("default threshold = %v, want ContextTrimThreshold (%v)", sess.CompactionThreshold(), ContextTrimThreshold)
+	}
+}
+
+// barrierReadTool is a read_file stand-in whose calls all wait until n of them
+// are running at once, so it only completes when executed concurrently
+type barrierReadTool struct {
+	n       int
+	mu      sync.Mutex
+	running int
+	ready   chan struct{}
+}
+
+func (b *barrierReadTool) Name() string { return "read_file" }
+
+func (b *barrierReadTool) Execute(ctx context.Context, params json.RawMessage) (*tool.Result, error) {
+	b.mu.Lock()
+	b.running++
+	if b.running == b.n {
+		close(b.ready)
+	}
+	b.mu.Unlock()
+
+	select {
+	case <-b.ready:
+	case <-time.After(2 * time.Second):
+		return tool.NewErrorResult(fmt.Errorf("calls did not run concurrently")), nil
+	}
+
+	var args struct {
+		Path string `json:"path"`
+	}
+	json.Unmarshal(params, &args)
+	return tool.NewResult("contents of " + args.Path), nil
+}
+
+func (b *barrierReadTool) Schema() *tool.FunctionSchema {
+	return &tool.FunctionSchema{Name: "read_file", Parameters: &tool.ParameterSchema{Type: "object"}}
+}
+
+func TestExecuteToolCalls_ReadOnlyBatchRunsConcurrently(t *testing.T) {
+	agent := createSimpleTestAgent()
+	agent.registry.Register(&barrierReadTool{n: 3, ready: make(chan struct{})})
+
+	var calls []session.ToolCall
+	for _, id := range []string{"a", "b", "c"} {
+		calls = append(calls, session.ToolCall{
+			ID:       "call_" + id,
+			Type:     "function",
+			Function: session.FunctionCall{Name: "read_file", Arguments: fmt.Sprintf(`{"path":"%s.txt"}`, id)},
+		})
+	}
+
+	sessionResults, agentResults, err := agent.executeToolCallsWithResults(context.Background(), calls)
+	if err != nil {
+		t.Fatalf("executeToolCallsWithResults: %v", err)
+	}
+	if len(sessionResults) != 3 || len(agentResults) != 3 {
+		t.Fatalf("got %d/%d results, want 3", len(sessionResults), len(agentResults))
+	}
+	for i, id := range []string{"a", "b", "c"} {
+		r := sessionResults[i]
+		if r.ToolCallID != "call_"+id || r.Content != "contents of "+id+".txt" || r.IsError {
+			t.Errorf("result %d = %+v, want call_%s with contents of %s.txt", i, r, id, id)
+		}
+	}
+}
+
+func TestRun_DeadlineExceededStopsTurn(t *testing.T) {
+	agent := createSimpleTestAgent()
+	provider := &toolThenTextProvider{}
+	agent.provider = provider
+	agent.registry.Register(&blockingTool{started: make(chan struct{})})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() { done <- agent.Run(ctx, "run the slow thing") }()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Run error = %v, want context.DeadlineExceeded", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return after the deadline")
+	}
+	if provider.calls != 1 {
+		t.Errorf("LLM calls = %d, want 1 (no further turns after the deadline)", provider.calls)
+	}
+}
+
+func TestPrepareRetry_RerunsLastMessageAfterFailure(t *testing.T) {
+	agent := createSimpleTestAgent()
+	if _, err := agent.PrepareRetry(false); !errors.Is(err, ErrNothingToRetry) {
+		t.Fatalf("PrepareRetry on an empty session = %v, want ErrNothingToRetry", err)
+	}
+
+	provider := &flakyProvider{failures: 1, err: fmt.Errorf("LLM error: model 'nope' not found")}
+	agent.provider = provider
+	if err := agent.Run(context.Background(), "fix the build"); err == nil {
+		t.Fatal("expected the first run to fail")
+	}
+
+	input, err := agent.PrepareRetry(false)
+	if err != nil {
+		t.Fatalf("PrepareRetry: %v", err)
+	}
+	if input != "fix the build" {
+		t.Errorf("retry input = %q, want the last user message", input)
+	}
+	if n := agent.GetSession().GetMessageCount(); n != 0 {
+		t.Errorf("failed turn should be trimmed before the retry, %d messages left", n)
+	}
+
+	if err := agent.Run(context.Background(), input); err != nil {
+		t.Fatalf("retried Run: %v", err)
+	}
+	if provider.calls != 2 {
+		t.Errorf("provider calls = %d, want 2 (a new run)", provider.calls)
+	}
+	messages := agent.GetSession().GetMessages()
+	if len(messages) != 2 || messages[0].Role != session.RoleUser || messages[0].Content != "fix the build" ||
+		messages[1].Content != "recovered" {
+		t.Errorf("session after retry = %+v, want the same user message and a new answer", messages)
+	}
+}
+
+func TestExecuteSingleTool_RejectsArgumentsNotMatchingSchema(t *testing.T) {
+	agent := createSimpleTestAgent()
+	agent.registry.Register(tool.NewReadTool())
+
+	result := agent.executeSingleTool(context.Background(), &session.ToolCall{
+		ID:       "call-1",
+		Function: session.FunctionCall{Name: "read_file", Arguments: `{"file": "main.go"}`},
+	})
+	if result.IsSuccess {
+		t.Fatal("read_file without path should not succeed")
+	}
+	if result.ToolCallID != "call-1" {
+		t.Errorf("ToolCallID = %q, want call-1", result.ToolCallID)
+	}
+	// The result tells the model what to fix instead of surfacing a runtime error
+	for _, want := range []string{`missing required property "path"`, "path (string, required)", "call the tool again"} {
+		if !strings.Contains(result.Content, want) {
+			t.Errorf("content %q should contain %q", result.Content, want)
+		}
+	}
+}
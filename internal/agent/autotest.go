@@ -6,8 +6,11 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"runtime"
+	"strings"
 	"time"
 
+	"github.com/zephel01/vibe-local-go/internal/config"
 	"github.com/zephel01/vibe-local-go/internal/session"
 )
 
@@ -15,6 +18,9 @@ import (
 type AutoTestConfig struct {
 	Enabled    bool
 	MaxTimeout time.Duration
+	// Command is run through the shell instead of the detected framework's
+	// test command when set (AUTOTEST_COMMAND, e.g. "make test")
+	Command string
 }
 
 // TestFramework represents a supported testing framework
@@ -132,16 +138,22 @@ func RunAutoTest(ctx context.Context, projectRoot string, filePath string, confi
 		return "", true, nil
 	}
 
-	detector := NewTestFrameworkDetector(projectRoot)
-	framework := detector.DetectFramework(filePath)
+	var cmd string
+	var args []string
+	if config.Command != "" {
+		cmd, args = shellTestCommand(config.Command)
+	} else {
+		detector := NewTestFrameworkDetector(projectRoot)
+		framework := detector.DetectFramework(filePath)
 
-	if framework == FrameworkNone {
-		return "", true, nil // No test framework found, skip silently
-	}
+		if framework == FrameworkNone {
+			return "", true, nil // No test framework found, skip silently
+		}
 
-	cmd, args := getTestCommand(framework, projectRoot)
-	if cmd == "" {
-		return "", true, nil // Unable to get test command
+		cmd, args = getTestCommand(framework, projectRoot)
+		if cmd == "" {
+			return "", true, nil // Unable to get test command
+		}
 	}
 
 	// Create context with timeout
@@ -192,7 +204,60 @@ func getTestCommand(framework TestFramework, projectRoot string) (string, []stri
 	}
 }
 
-// runAutoTestIfNeeded is called after write_file/edit_file operations
+// shellTestCommand wraps a configured test command so pipes, && and make targets work
+func shellTestCommand(command string) (string, []string) {
+	if runtime.GOOS == "windows" {
+		return "cmd.exe", []string{"/c", command}
+	}
+	return "sh", []string{"-c", command}
+}
+
+// SetAutoTestPrompt replaces the confirmation asked before a project-supplied
+// AUTOTEST_COMMAND runs for the first time
+func (a *Agent) SetAutoTestPrompt(prompt func(command string) (bool, error)) {
+	a.autoTestPrompt = prompt
+}
+
+// autoTestCommand returns the configured test command ("" = detect one).
+// A command from the project's .vibe-local/config.json comes with the repository,
+// so it only runs once the user confirms it; a declined one falls back to detection.
+func (a *Agent) autoTestCommand() string {
+	command := a.config.AutoTestCommand
+	if command == "" || a.config.Source("AUTOTEST_COMMAND") != config.SourceProject {
+		return command
+	}
+	if a.autoTestDecided != command {
+		prompt := a.autoTestPrompt
+		if prompt == nil {
+			prompt = a.askAutoTestCommand
+		}
+		allowed, err := prompt(command)
+		a.autoTestDecided, a.autoTestAllowed = command, allowed && err == nil
+		if !a.autoTestAllowed {
+			a.terminal.PrintWarning("Project auto-test command declined; detecting the test command instead")
+		}
+	}
+	if !a.autoTestAllowed {
+		return ""
+	}
+	return command
+}
+
+// askAutoTestCommand is the default auto-test prompt; it reads the answer from the terminal
+func (a *Agent) askAutoTestCommand(command string) (bool, error) {
+	a.terminal.PrintWarning("This project's .vibe-local/config.json sets an auto-test command: " + command)
+	answer, err := a.terminal.ReadLine("Run it after file edits? [y/N]: ")
+	if err != nil {
+		return false, err
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true, nil
+	}
+	return false, nil
+}
+
+// runAutoTestIfNeeded is called after a tool changes files (Result.ChangedPaths)
 // Returns true if tests passed or were skipped, false if tests failed
 func (a *Agent) runAutoTestIfNeeded(filePath string) bool {
 	if !a.autoTestEnabled {
@@ -208,6 +273,7 @@ func (a *Agent) runAutoTestIfNeeded(filePath string) bool {
 	config := AutoTestConfig{
 		Enabled:    true,
 		MaxTimeout: 60 * time.Second,
+		Command:    a.autoTestCommand(),
 	}
 
 	output, passed, err := RunAutoTest(context.Background(), projectRoot, filePath, config)
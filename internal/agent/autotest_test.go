@@ -0,0 +1,153 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zephel01/vibe-local-go/internal/config"
+	"github.com/zephel01/vibe-local-go/internal/session"
+	"github.com/zephel01/vibe-local-go/internal/tool"
+)
+
+func TestRunAutoTest_ConfiguredCommandOverridesDetection(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh syntax")
+	}
+	dir := t.TempDir()
+	// go.mod alone would make the detector pick "go test ./..."
+	os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example\n"), 0644)
+
+	output, passed, err := RunAutoTest(context.Background(), dir, filepath.Join(dir, "main.go"), AutoTestConfig{
+		Enabled:    true,
+		MaxTimeout: 10 * time.Second,
+		Command:    "echo custom-suite && pwd && exit 3",
+	})
+	if err != nil {
+		t.Fatalf("RunAutoTest: %v", err)
+	}
+	if passed {
+		t.Error("a non-zero exit should count as a failure")
+	}
+	if !strings.Contains(output, "custom-suite") {
+		t.Errorf("output = %q, want the configured command's output", output)
+	}
+	if !strings.Contains(output, filepath.Base(dir)) {
+		t.Errorf("output = %q, the command should run in the project root", output)
+	}
+}
+
+func TestRunAutoTestIfNeeded_RunsConfiguredCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh syntax")
+	}
+	dir := t.TempDir()
+	t.Chdir(dir)
+
+	agent := createSimpleTestAgent()
+	agent.SetAutoTestEnabled(true)
+	agent.config.AutoTestCommand = "touch ran.marker; echo 'FAIL: TestParse'; exit 1"
+
+	if agent.runAutoTestIfNeeded(filepath.Join(dir, "parse.go")) {
+		t.Fatal("expected the failing test command to report a failure")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "ran.marker")); err != nil {
+		t.Errorf("configured command was not executed: %v", err)
+	}
+
+	// The failure output goes back to the LLM
+	messages := agent.GetSession().GetMessages()
+	if len(messages) == 0 || !strings.Contains(messages[len(messages)-1].Content, "FAIL: TestParse") {
+		t.Errorf("session should end with the test failure, got %+v", messages)
+	}
+}
+
+func TestRunAutoTestIfNeeded_ConfirmsProjectCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh syntax")
+	}
+	dir := t.TempDir()
+	t.Chdir(dir)
+
+	agent := createSimpleTestAgent()
+	agent.SetAutoTestEnabled(true)
+	agent.config.AutoTestCommand = "touch ran.marker"
+	agent.config.SetSource("AUTOTEST_COMMAND", config.SourceProject)
+
+	asked := 0
+	allow := false
+	agent.SetAutoTestPrompt(func(command string) (bool, error) {
+		asked++
+		return allow, nil
+	})
+
+	// Declined: the project command never runs, and the answer is remembered
+	agent.runAutoTestIfNeeded(filepath.Join(dir, "a.go"))
+	agent.runAutoTestIfNeeded(filepath.Join(dir, "a.go"))
+	if _, err := os.Stat(filepath.Join(dir, "ran.marker")); err == nil {
+		t.Fatal("declined project command was executed")
+	}
+	if asked != 1 {
+		t.Errorf("prompt shown %d times, want once", asked)
+	}
+
+	// A changed command is asked about again
+	allow = true
+	agent.config.AutoTestCommand = "touch ran.marker; true"
+	agent.config.SetSource("AUTOTEST_COMMAND", config.SourceProject)
+	agent.runAutoTestIfNeeded(filepath.Join(dir, "a.go"))
+	if _, err := os.Stat(filepath.Join(dir, "ran.marker")); err != nil {
+		t.Errorf("confirmed project command was not executed: %v", err)
+	}
+	if asked != 2 {
+		t.Errorf("prompt shown %d times, want twice", asked)
+	}
+
+	// The user's own settings run without asking
+	agent.config.AutoTestCommand = "true"
+	agent.config.SetSource("AUTOTEST_COMMAND", config.SourceConfig)
+	agent.runAutoTestIfNeeded(filepath.Join(dir, "a.go"))
+	if asked != 2 {
+		t.Errorf("prompt shown for a global command")
+	}
+}
+
+func TestRunSingleTool_AutoTestFollowsChangedPaths(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh syntax")
+	}
+	dir := t.TempDir()
+	t.Chdir(dir)
+
+	agent := createSimpleTestAgent()
+	agent.config.AutoApprove = true
+	agent.SetAutoTestEnabled(true)
+	agent.config.AutoTestCommand = "echo run >> runs.log"
+
+	// Any tool reporting changed files triggers the auto test, whatever its name
+	for name, changed := range map[string][]string{
+		"apply_patch": {filepath.Join(dir, "a.go"), filepath.Join(dir, "b.go")},
+		"read_file":   nil,
+	} {
+		mock := newMockTool(name)
+		paths := changed
+		mock.execute = func(ctx context.Context, args json.RawMessage) (*tool.Result, error) {
+			return tool.NewResult("ok").WithChangedPaths(paths...), nil
+		}
+		agent.registry.Register(mock)
+		agent.runSingleTool(context.Background(), &session.ToolCall{
+			ID:       name,
+			Function: session.FunctionCall{Name: name, Arguments: `{}`},
+		})
+	}
+
+	data, _ := os.ReadFile(filepath.Join(dir, "runs.log"))
+	if runs := strings.Count(string(data), "run"); runs != 1 {
+		t.Errorf("auto test ran %d times, want once (only for the tool that changed files)", runs)
+	}
+}
@@ -0,0 +1,144 @@
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/zephel01/vibe-local-go/internal/llm"
+	"github.com/zephel01/vibe-local-go/internal/ui"
+)
+
+// BudgetDecision is the user's answer when the cloud token budget is exceeded
+type BudgetDecision int
+
+const (
+	// BudgetContinue keeps using the cloud provider without asking again this session
+	BudgetContinue BudgetDecision = iota
+	// BudgetSwitchLocal switches the provider chain to its first local provider
+	BudgetSwitchLocal
+	// BudgetStop ends the turn before the next cloud call
+	BudgetStop
+)
+
+// ErrBudgetExceeded is returned when the user stops at the token budget prompt
+var ErrBudgetExceeded = errors.New("cloud token budget exceeded")
+
+// BudgetPrompt asks what to do once used cloud tokens exceed the budget.
+// canSwitch reports whether the provider chain has a local provider to switch to.
+type BudgetPrompt func(used, budget int, canSwitch bool) (BudgetDecision, error)
+
+// SetBudgetPrompt replaces the prompt shown when the token budget is exceeded
+func (a *Agent) SetBudgetPrompt(prompt BudgetPrompt) {
+	a.budgetPrompt = prompt
+}
+
+// isCloudProvider is the rule for what BudgetTokens limits, used both to count
+// usage and to decide which calls to pause
+func isCloudProvider(p llm.LLMProvider) bool {
+	return p.Info().Type == llm.ProviderTypeCloud
+}
+
+// checkBudget pauses before a cloud LLM call once the session's cloud token usage
+// exceeds BudgetTokens. Local providers are never paused.
+func (a *Agent) checkBudget() error {
+	return a.confirmBudget(a.provider, true)
+}
+
+// guardFallback is the provider chain's FallbackGuard: falling back to a cloud
+// provider past the budget needs the same confirmation as calling it directly
+func (a *Agent) guardFallback(to llm.LLMProvider) error {
+	return a.confirmBudget(to, false)
+}
+
+// confirmBudget asks the user before a call to p once the budget is exceeded.
+// allowSwitch offers switching the chain to a local provider instead.
+func (a *Agent) confirmBudget(p llm.LLMProvider, allowSwitch bool) error {
+	budget := a.config.BudgetTokens
+	if budget <= 0 || a.budgetApproved || !isCloudProvider(p) {
+		return nil
+	}
+	used := a.CloudTokensUsed()
+	if used <= budget {
+		return nil
+	}
+
+	var chain *llm.ProviderChain
+	localIndex := -1
+	if allowSwitch {
+		chain, localIndex = a.localChainEntry()
+	}
+	prompt := a.budgetPrompt
+	if prompt == nil {
+		prompt = a.askBudget
+	}
+	decision, err := prompt(used, budget, localIndex >= 0)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrBudgetExceeded, err)
+	}
+
+	switch decision {
+	case BudgetContinue:
+		a.budgetApproved = true
+		return nil
+	case BudgetSwitchLocal:
+		if localIndex < 0 {
+			break
+		}
+		if err := chain.SwitchTo(localIndex); err != nil {
+			return err
+		}
+		a.terminal.PrintColored(ui.ColorGreen, fmt.Sprintf("✓ Switched to local provider %s\n", chain.Info().Name))
+		return nil
+	}
+	return ErrBudgetExceeded
+}
+
+// CloudTokensUsed sums the session's token usage on cloud providers (what BudgetTokens limits)
+func (a *Agent) CloudTokensUsed() int {
+	total := 0
+	for _, u := range a.session.GetTokenUsage() {
+		if u.Cloud {
+			total += u.Total()
+		}
+	}
+	return total
+}
+
+// localChainEntry returns the provider chain and the index of its first local
+// provider (index -1 when the provider is not a chain or has no local entry)
+func (a *Agent) localChainEntry() (*llm.ProviderChain, int) {
+	chain, ok := a.provider.(*llm.ProviderChain)
+	if !ok {
+		return nil, -1
+	}
+	for i, entry := range chain.GetEntries() {
+		if entry.Provider.Info().Type == llm.ProviderTypeLocal {
+			return chain, i
+		}
+	}
+	return chain, -1
+}
+
+// askBudget is the default BudgetPrompt; it reads the answer from the terminal
+func (a *Agent) askBudget(used, budget int, canSwitch bool) (BudgetDecision, error) {
+	a.terminal.PrintWarning(fmt.Sprintf("Cloud token budget exceeded: %d / %d tokens used this session", used, budget))
+	options := "[c]ontinue / [s]top"
+	if canSwitch {
+		options = "[c]ontinue / switch to [l]ocal / [s]top"
+	}
+	answer, err := a.terminal.ReadLine(fmt.Sprintf("Continue with the cloud provider? %s: ", options))
+	if err != nil {
+		return BudgetStop, err
+	}
+
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "c", "continue", "y", "yes":
+		return BudgetContinue, nil
+	case "l", "local":
+		if canSwitch {
+			return BudgetSwitchLocal, nil
+		}
+	}
+	return BudgetStop, nil
+}
@@ -0,0 +1,225 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/zephel01/vibe-local-go/internal/llm"
+)
+
+// meteredProvider replies with a fixed text and reports the same usage for every call
+type meteredProvider struct {
+	name   string
+	kind   llm.ProviderType
+	model  string
+	tokens int
+	calls  int
+}
+
+func (p *meteredProvider) Chat(ctx context.Context, req *llm.ChatRequest) (*llm.ChatResponse, error) {
+	p.calls++
+	return &llm.ChatResponse{
+		Choices: []llm.Choice{{Message: llm.Message{Role: "assistant", Content: "done"}}},
+		Usage:   llm.Usage{PromptTokens: p.tokens / 2, CompletionTokens: p.tokens - p.tokens/2},
+	}, nil
+}
+
+func (p *meteredProvider) ChatStream(ctx context.Context, req *llm.ChatRequest) (<-chan llm.StreamEvent, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (p *meteredProvider) CheckHealth(ctx context.Context) error { return nil }
+
+func (p *meteredProvider) Info() llm.ProviderInfo {
+	return llm.ProviderInfo{Name: p.name, Type: p.kind, Model: p.model}
+}
+
+func (p *meteredProvider) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	return nil, llm.ErrEmbeddingsUnsupported
+}
+
+// budgetPromptRecorder answers every budget prompt with decision and records the calls
+type budgetPromptRecorder struct {
+	decision BudgetDecision
+	calls    []string
+}
+
+func (r *budgetPromptRecorder) prompt(used, budget int, canSwitch bool) (BudgetDecision, error) {
+	r.calls = append(r.calls, fmt.Sprintf("%d/%d switch=%v", used, budget, canSwitch))
+	return r.decision, nil
+}
+
+func TestBudget_PausesCloudCallsPastThreshold(t *testing.T) {
+	agent := createSimpleTestAgent()
+	cloud := &meteredProvider{name: "openai", kind: llm.ProviderTypeCloud, tokens: 60}
+	agent.provider = cloud
+	agent.config.BudgetTokens = 100
+	recorder := &budgetPromptRecorder{decision: BudgetStop}
+	agent.SetBudgetPrompt(recorder.prompt)
+	ctx := context.Background()
+
+	// 0 and then 60 tokens used: still within the budget
+	for i := 0; i < 2; i++ {
+		if err := agent.Run(ctx, "task"); err != nil {
+			t.Fatalf("Run %d: %v", i+1, err)
+		}
+	}
+	if len(recorder.calls) != 0 {
+		t.Fatalf("prompted before the budget was exceeded: %v", recorder.calls)
+	}
+
+	// 120 tokens used: the next cloud call waits for the user, who stops
+	err := agent.Run(ctx, "task")
+	if !errors.Is(err, ErrBudgetExceeded) {
+		t.Fatalf("Run past the budget = %v, want ErrBudgetExceeded", err)
+	}
+	if len(recorder.calls) != 1 || recorder.calls[0] != "120/100 switch=false" {
+		t.Errorf("prompt calls = %v, want one for 120/100 without a local provider", recorder.calls)
+	}
+	if cloud.calls != 2 {
+		t.Errorf("cloud calls = %d, the stopped turn should not reach the provider", cloud.calls)
+	}
+
+	// Continuing is asked once per session
+	recorder.decision = BudgetContinue
+	for i := 0; i < 2; i++ {
+		if err := agent.Run(ctx, "task"); err != nil {
+			t.Fatalf("Run after continuing: %v", err)
+		}
+	}
+	if len(recorder.calls) != 2 || cloud.calls != 4 {
+		t.Errorf("prompt calls = %v, cloud calls = %d; want one more prompt and two more calls", recorder.calls, cloud.calls)
+	}
+}
+
+func TestBudget_SwitchesChainToLocalProvider(t *testing.T) {
+	agent := createSimpleTestAgent()
+	cloud := &meteredProvider{name: "openai", kind: llm.ProviderTypeCloud, tokens: 200}
+	local := &meteredProvider{name: "ollama", kind: llm.ProviderTypeLocal, tokens: 200}
+	agent.provider = llm.NewProviderChain(cloud, local)
+	agent.config.BudgetTokens = 100
+	recorder := &budgetPromptRecorder{decision: BudgetSwitchLocal}
+	agent.SetBudgetPrompt(recorder.prompt)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if err := agent.Run(ctx, "task"); err != nil {
+			t.Fatalf("Run %d: %v", i+1, err)
+		}
+	}
+
+	// Local providers are exempt, so only the first call past the budget asks
+	if len(recorder.calls) != 1 || recorder.calls[0] != "200/100 switch=true" {
+		t.Errorf("prompt calls = %v, want one offering the local provider", recorder.calls)
+	}
+	if cloud.calls != 1 || local.calls != 2 {
+		t.Errorf("cloud calls = %d, local calls = %d; want 1 and 2", cloud.calls, local.calls)
+	}
+}
+
+func TestBudget_LocalUsageDoesNotCount(t *testing.T) {
+	agent := createSimpleTestAgent()
+	agent.provider = &meteredProvider{name: "openai", kind: llm.ProviderTypeCloud, tokens: 10}
+	agent.config.BudgetTokens = 100
+	agent.session.AddTokenUsage("ollama", "qwen3:8b", false, 5000, 5000)
+	recorder := &budgetPromptRecorder{decision: BudgetStop}
+	agent.SetBudgetPrompt(recorder.prompt)
+
+	if err := agent.Run(context.Background(), "task"); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if len(recorder.calls) != 0 {
+		t.Errorf("local tokens should not count toward the cloud budget, prompted: %v", recorder.calls)
+	}
+}
+
+// downProvider fails every call with a connection error, which makes a chain fall back
+type downProvider struct {
+	meteredProvider
+}
+
+func (p *downProvider) Chat(ctx context.Context, req *llm.ChatRequest) (*llm.ChatResponse, error) {
+	p.calls++
+	return nil, fmt.Errorf("dial tcp 127.0.0.1:11434: connect: connection refused")
+}
+
+func TestBudget_AsksBeforeFallingBackToCloud(t *testing.T) {
+	local := &downProvider{meteredProvider{name: "ollama", kind: llm.ProviderTypeLocal}}
+	cloud := &meteredProvider{name: "work-openai", kind: llm.ProviderTypeCloud, tokens: 10}
+	base := createSimpleTestAgent()
+	agent := NewAgent(llm.NewProviderChain(local, cloud), base.registry, base.permissionMgr, base.validator, base.session, base.terminal, base.config)
+	agent.config.BudgetTokens = 100
+	agent.session.AddTokenUsage("work-openai", "gpt-4.1", true, 100, 100)
+	recorder := &budgetPromptRecorder{decision: BudgetStop}
+	agent.SetBudgetPrompt(recorder.prompt)
+
+	err := agent.Run(context.Background(), "task")
+	if !errors.Is(err, ErrBudgetExceeded) {
+		t.Fatalf("Run = %v, want ErrBudgetExceeded", err)
+	}
+	if len(recorder.calls) != 1 || recorder.calls[0] != "200/100 switch=false" {
+		t.Errorf("prompt calls = %v, want one before the fallback", recorder.calls)
+	}
+	if cloud.calls != 0 {
+		t.Errorf("cloud calls = %d, the fallback should wait for the user", cloud.calls)
+	}
+	if got := agent.provider.Info().Name; got != "ollama" {
+		t.Errorf("current provider = %s, a refused fallback should not switch", got)
+	}
+}
+
+func TestBudget_RecordsUsageUnderServingModel(t *testing.T) {
+	local := &downProvider{meteredProvider{name: "ollama", kind: llm.ProviderTypeLocal, model: "qwen3:8b"}}
+	cloud := &meteredProvider{name: "work-openai", kind: llm.ProviderTypeCloud, model: "gpt-4.1", tokens: 10}
+	base := createSimpleTestAgent()
+	agent := NewAgent(llm.NewProviderChain(local, cloud), base.registry, base.permissionMgr, base.validator, base.session, base.terminal, base.config)
+	agent.config.Model = "qwen3:8b"
+
+	if err := agent.Run(context.Background(), "task"); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	usage := agent.session.GetTokenUsage()
+	if len(usage) != 1 || usage[0].Provider != "work-openai" || usage[0].Model != "gpt-4.1" || !usage[0].Cloud {
+		t.Errorf("usage = %+v, want the fallback's gpt-4.1 rather than the configured model", usage)
+	}
+}
+
+func TestBudget_CountsCustomNamedCloudProviders(t *testing.T) {
+	agent := createSimpleTestAgent()
+	agent.provider = &meteredProvider{name: "work-openai", kind: llm.ProviderTypeCloud, tokens: 150}
+	agent.config.BudgetTokens = 100
+
+	if err := agent.Run(context.Background(), "task"); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if used := agent.CloudTokensUsed(); used != 150 {
+		t.Errorf("CloudTokensUsed() = %d, want 150 for a cloud profile with a custom name", used)
+	}
+}
+
+func TestBudget_SideTasksNeedConfirmation(t *testing.T) {
+	agent := createSimpleTestAgent()
+	cloud := &meteredProvider{name: "openai", kind: llm.ProviderTypeCloud, tokens: 60}
+	agent.provider = cloud
+	agent.config.BudgetTokens = 100
+	recorder := &budgetPromptRecorder{decision: BudgetStop}
+	agent.SetBudgetPrompt(recorder.prompt)
+	ctx := context.Background()
+
+	// Summaries and fix hints count toward the budget...
+	for i := 0; i < 2; i++ {
+		if _, err := agent.completeTask(ctx, llm.TaskLightweight, "summarize"); err != nil {
+			t.Fatalf("completeTask %d: %v", i+1, err)
+		}
+	}
+
+	// ...and past it they wait for the user like any other cloud call
+	if _, err := agent.completeTask(ctx, llm.TaskLightweight, "summarize"); !errors.Is(err, ErrBudgetExceeded) {
+		t.Fatalf("completeTask past the budget = %v, want ErrBudgetExceeded", err)
+	}
+	if len(recorder.calls) != 1 || cloud.calls != 2 {
+		t.Errorf("prompts = %v, cloud calls = %d; want one prompt and no third call", recorder.calls, cloud.calls)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/zephel01/vibe-local-go/internal/llm"
 	"github.com/zephel01/vibe-local-go/internal/session"
 	"github.com/zephel01/vibe-local-go/internal/tool"
 )
@@ -106,6 +107,21 @@ func (d *Dispatcher) executeSequential(ctx context.Context, toolCalls []session.
 	return results
 }
 
+// invalidArgumentsResult validates a call against the tool's schema and returns
+// a corrective result the model can fix the call from, or nil when the arguments are valid
+func invalidArgumentsResult(toolCall *session.ToolCall, schema *tool.FunctionSchema) *ToolResult {
+	err := tool.ValidateArguments(schema, toolCall.Function.Arguments)
+	if err == nil {
+		return nil
+	}
+	return &ToolResult{
+		ToolCallID: toolCall.ID,
+		IsSuccess:  false,
+		Content:    err.Error() + ". Fix the arguments and call the tool again.",
+		Error:      err.Error(),
+	}
+}
+
 // executeSingleTool executes a single tool with retry logic and failure strategy
 func (d *Dispatcher) executeSingleTool(ctx context.Context, toolCall *session.ToolCall) ToolResult {
 	toolName := toolCall.Function.Name
@@ -122,6 +138,11 @@ func (d *Dispatcher) executeSingleTool(ctx context.Context, toolCall *session.To
 	}
 	toolInst := toolCfg.Tool
 
+	// Invalid arguments won't succeed on retry: return a corrective result instead
+	if result := invalidArgumentsResult(toolCall, toolInst.Schema()); result != nil {
+		return *result
+	}
+
 	var lastErr error
 
 	// Retry loop
@@ -146,6 +167,10 @@ func (d *Dispatcher) executeSingleTool(ctx context.Context, toolCall *session.To
 		// Check if we should retry
 		if attempt < toolCfg.MaxRetries {
 			if isRetryable(lastErr) {
+				if budget := llm.RetryBudgetFrom(ctx); !budget.Take(fmt.Sprintf("tool %s: %v", toolName, lastErr)) {
+					lastErr = budget.Exhausted(lastErr)
+					break
+				}
 				time.Sleep(toolCfg.RetryBackoff)
 				continue
 			}
@@ -245,6 +270,11 @@ func isReadOnlyTool(toolName string) bool {
 		"read_file",
 		"glob",
 		"grep",
+		"semantic_search",
+		"list_directory",
+		"symbols",
+		"tail",
+		"environment",
 		"web_search",
 		"web_fetch",
 	}
@@ -263,7 +293,12 @@ func isWriteTool(toolName string) bool {
 	writeTools := []string{
 		"write_file",
 		"edit_file",
+		"multi_edit",
+		"apply_patch",
+		"move_file",
+		"make_directory",
 		"bash",
+		"assert_command",
 	}
 
 	for _, t := range writeTools {
@@ -296,6 +331,10 @@ func (d *Dispatcher) ExecuteWithRetry(ctx context.Context, toolCall *session.Too
 
 		// Wait before retry (exponential backoff)
 		if attempt < maxRetries {
+			if budget := llm.RetryBudgetFrom(ctx); !budget.Take(fmt.Sprintf("tool %s: %s", toolCall.Function.Name, result.Error)) {
+				result.Error = budget.Exhausted(fmt.Errorf("%s", result.Error)).Error()
+				return result
+			}
 			delay := delayForRetry(attempt)
 			select {
 			case <-ctx.Done():
@@ -357,29 +396,33 @@ func (d *Dispatcher) ExecuteBatch(ctx context.Context, batches [][]session.ToolC
 	return allResults
 }
 
-// GroupForParallelExecution groups tool calls for parallel execution
+// GroupForParallelExecution splits tool calls into batches that keep the original
+// order: consecutive read-only calls share a batch (up to MaxParallelTools) and
+// every other call gets a batch of its own. A read after a write therefore still
+// sees the write.
 func (d *Dispatcher) GroupForParallelExecution(toolCalls []session.ToolCall) [][]session.ToolCall {
-	var readOnly, writeOps []session.ToolCall
-
-	for _, tc := range toolCalls {
-		if isReadOnlyTool(tc.Function.Name) {
-			readOnly = append(readOnly, tc)
-		} else {
-			writeOps = append(writeOps, tc)
-		}
-	}
-
 	batches := make([][]session.ToolCall, 0)
+	var readOnly []session.ToolCall
 
-	// Add read-only batch if non-empty
-	if len(readOnly) > 0 {
-		batches = append(batches, readOnly)
+	flushReadOnly := func() {
+		if len(readOnly) > 0 {
+			batches = append(batches, readOnly)
+			readOnly = nil
+		}
 	}
 
-	// Add write operations as individual batches
-	for _, op := range writeOps {
-		batches = append(batches, []session.ToolCall{op})
+	for _, tc := range toolCalls {
+		if !isReadOnlyTool(tc.Function.Name) {
+			flushReadOnly()
+			batches = append(batches, []session.ToolCall{tc})
+			continue
+		}
+		readOnly = append(readOnly, tc)
+		if len(readOnly) == MaxParallelTools {
+			flushReadOnly()
+		}
 	}
+	flushReadOnly()
 
 	return batches
 }
@@ -415,6 +458,11 @@ func isSafeTool(toolName string) bool {
 		"read_file",
 		"glob",
 		"grep",
+		"semantic_search",
+		"list_directory",
+		"symbols",
+		"tail",
+		"environment",
 	}
 
 	for _, t := range safeTools {
@@ -431,21 +479,13 @@ func (d *Dispatcher) ValidateToolCall(toolCall *session.ToolCall) error {
 	toolName := toolCall.Function.Name
 
 	// Check if tool exists
-	if _, exists := d.registry.GetTool(toolName); !exists {
+	toolInst, exists := d.registry.GetTool(toolName)
+	if !exists {
 		return fmt.Errorf("tool not found: %s", toolName)
 	}
 
-	// Validate arguments
-	// This would need to validate against tool schema
-	// For now, just check if arguments are valid JSON
-	if toolCall.Function.Arguments != "" {
-		var js interface{}
-		if err := json.Unmarshal([]byte(toolCall.Function.Arguments), &js); err != nil {
-			return fmt.Errorf("invalid JSON arguments: %w", err)
-		}
-	}
-
-	return nil
+	// Validate arguments against the tool's schema
+	return tool.ValidateArguments(toolInst.Schema(), toolCall.Function.Arguments)
 }
 
 // GetExecutionSummary returns a summary of tool execution
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"testing"
 	"time"
 
@@ -234,6 +235,38 @@ func TestGroupForParallelExecution(t *testing.T) {
 	}
 }
 
+func TestGroupForParallelExecution_PreservesOrder(t *testing.T) {
+	dispatcher := NewDispatcher(tool.NewRegistry(), nil, nil, nil)
+
+	var toolCalls []session.ToolCall
+	for i, name := range []string{"read_file", "grep", "write_file", "read_file", "bash", "glob", "glob"} {
+		toolCalls = append(toolCalls, session.ToolCall{ID: fmt.Sprint(i), Function: session.FunctionCall{Name: name, Arguments: `{}`}})
+	}
+
+	var got [][]string
+	for _, batch := range dispatcher.GroupForParallelExecution(toolCalls) {
+		var ids []string
+		for _, tc := range batch {
+			ids = append(ids, tc.ID)
+		}
+		got = append(got, ids)
+	}
+
+	want := [][]string{{"0", "1"}, {"2"}, {"3"}, {"4"}, {"5", "6"}}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("batches = %v, want %v", got, want)
+	}
+
+	// Read-only runs are split at MaxParallelTools
+	many := make([]session.ToolCall, MaxParallelTools+1)
+	for i := range many {
+		many[i] = session.ToolCall{ID: fmt.Sprint(i), Function: session.FunctionCall{Name: "read_file", Arguments: `{}`}}
+	}
+	if batches := dispatcher.GroupForParallelExecution(many); len(batches) != 2 || len(batches[0]) != MaxParallelTools {
+		t.Errorf("got %d batches, want %d + 1", len(batches), MaxParallelTools)
+	}
+}
+
 func TestValidateToolCall(t *testing.T) {
 	registry := tool.NewRegistry()
 	mockTool := newMockTool("read_file")
@@ -167,6 +167,8 @@ func TestIntegration_SessionResume(t *testing.T) {
 	sess.AddUserMessage("Hello")
 	sess.AddAssistantMessage("Hi there!")
 	sess.AddUserMessage("How are you?")
+	sess.AddToolCall([]session.ToolCall{{ID: "call_1", Type: "function", Function: session.FunctionCall{Name: "bash", Arguments: `{"command":"false"}`}}})
+	sess.AddToolResults([]session.ToolResult{{Content: "Command failed", ToolCallID: "call_1", IsError: true}})
 
 	// セッションを永続化
 	persistMgr, err := session.NewPersistenceManager(tmpDir)
@@ -196,6 +198,14 @@ func TestIntegration_SessionResume(t *testing.T) {
 	if loaded.ID != sess.ID {
 		t.Errorf("expected session ID %q, got %q", sess.ID, loaded.ID)
 	}
+
+	// --resume と同じく新しいセッションに再生しても、失敗したツール結果は失敗のまま
+	resumed := session.NewSession("new-session", "Test system prompt")
+	resumed.ReplayMessages(loaded.Messages)
+	last := resumed.GetMessages()[len(resumed.GetMessages())-1]
+	if last.Role != session.RoleTool || last.ToolID != "call_1" || !last.IsError {
+		t.Errorf("expected the failed tool result to stay failed after resume, got %+v", last)
+	}
 }
 
 // TestIntegration_SessionList セッション一覧取得
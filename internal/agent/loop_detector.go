@@ -1,16 +1,14 @@
 package agent
 
 import (
-	"encoding/json"
 	"fmt"
 	"hash/fnv"
-	"strings"
 )
 
 const (
-	// MaxSameToolRepeat is the maximum number of times to repeat the same (tool, args) pair
+	// MaxSameToolRepeat is the default number of identical (tool, args) calls treated as a loop
 	MaxSameToolRepeat = 3
-	// LoopHistorySize is the number of recent tool calls to track
+	// LoopHistorySize is the default number of recent tool calls to track
 	LoopHistorySize = 20
 )
 
@@ -21,24 +19,57 @@ type ToolCallRecord struct {
 	Timestamp  int64
 }
 
-// LoopDetector detects repeated tool call patterns
+// LoopDetector detects repeated tool call patterns. Calls are compared by
+// tool name and exact arguments, so the same tool with different arguments
+// (reading many files, running different commands) never counts as a loop
 type LoopDetector struct {
 	history       []ToolCallRecord
 	toolCounts    map[string]int // ツール名ごとの総呼び出し数（参考値）
-	hashCounts    map[string]int // (ツール名+引数)ハッシュごとの呼び出し数（ループ判定用）
+	hashCounts    map[string]int // 履歴内の(ツール名+引数)ハッシュごとの呼び出し数（ループ判定用）
 	historySize   int
+	threshold     int  // Identical (tool, args) calls treated as a loop
+	disabled      bool // DetectLoop always reports false (/loopdetect off)
 }
 
-// NewLoopDetector creates a new loop detector
+// NewLoopDetector creates a new loop detector with the default limits
 func NewLoopDetector() *LoopDetector {
+	return NewLoopDetectorWithLimits(LoopHistorySize, MaxSameToolRepeat)
+}
+
+// NewLoopDetectorWithLimits creates a loop detector that tracks historySize
+// recent calls and reports a loop after threshold identical calls
+// (values <= 0 use LoopHistorySize and MaxSameToolRepeat)
+func NewLoopDetectorWithLimits(historySize, threshold int) *LoopDetector {
+	if historySize <= 0 {
+		historySize = LoopHistorySize
+	}
+	if threshold <= 0 {
+		threshold = MaxSameToolRepeat
+	}
 	return &LoopDetector{
-		history:     make([]ToolCallRecord, 0, LoopHistorySize),
+		history:     make([]ToolCallRecord, 0, historySize),
 		toolCounts:  make(map[string]int),
 		hashCounts:  make(map[string]int),
-		historySize: LoopHistorySize,
+		historySize: historySize,
+		threshold:   threshold,
 	}
 }
 
+// SetEnabled turns loop detection on or off (calls are still recorded)
+func (ld *LoopDetector) SetEnabled(enabled bool) {
+	ld.disabled = !enabled
+}
+
+// IsEnabled returns whether loop detection is on
+func (ld *LoopDetector) IsEnabled() bool {
+	return !ld.disabled
+}
+
+// Threshold returns the number of identical calls treated as a loop
+func (ld *LoopDetector) Threshold() int {
+	return ld.threshold
+}
+
 // RecordToolCall records a tool call for loop detection
 func (ld *LoopDetector) RecordToolCall(toolName string, arguments string) {
 	record := ToolCallRecord{
@@ -47,9 +78,14 @@ func (ld *LoopDetector) RecordToolCall(toolName string, arguments string) {
 		Timestamp: getCurrentTimestamp(),
 	}
 
-	// Add to history
+	// Add to history; a call that falls out of the window no longer counts toward a loop
 	if len(ld.history) >= ld.historySize {
+		dropped := ld.history[0]
 		ld.history = ld.history[1:]
+		hash := GenerateToolCallHash(dropped.ToolName, dropped.Arguments)
+		if ld.hashCounts[hash]--; ld.hashCounts[hash] <= 0 {
+			delete(ld.hashCounts, hash)
+		}
 	}
 	ld.history = append(ld.history, record)
 
@@ -62,14 +98,14 @@ func (ld *LoopDetector) RecordToolCall(toolName string, arguments string) {
 
 // DetectLoop checks if a loop pattern is detected
 func (ld *LoopDetector) DetectLoop() bool {
-	if len(ld.history) < 3 {
+	if ld.disabled || len(ld.history) < 3 {
 		return false
 	}
 
-	// 同じ(ツール名+引数)ペアが MaxSameToolRepeat 回以上呼ばれた場合はループ
+	// 直近の履歴内で同じ(ツール名+引数)ペアが threshold 回以上呼ばれた場合はループ
 	// ※ ツール名だけでなく引数も含めて判定することで、異なるbashコマンドを誤検知しない
 	for _, count := range ld.hashCounts {
-		if count >= MaxSameToolRepeat {
+		if count >= ld.threshold {
 			return true
 		}
 	}
@@ -80,129 +116,71 @@ func (ld *LoopDetector) DetectLoop() bool {
 	}
 
 	// Check for repeating patterns
-	if ld.hasRepeatingPattern() {
-		return true
-	}
-
-	// Check for similar bash commands (e.g., "npm test" and "npx jest" are similar test attempts)
-	if ld.hasSimilarBashLoop() {
-		return true
-	}
-
-	return false
+	return ld.hasRepeatingPattern()
 }
 
 // hasIdenticalSequence checks for identical tool calls in sequence
-// 同じ(ツール+引数)が3回以上連続した場合にループ判定（2回は誤検知しやすいため緩和）
+// 同じ(ツール+引数)が threshold 回以上連続した場合にループ判定
 func (ld *LoopDetector) hasIdenticalSequence() bool {
-	if len(ld.history) < 3 {
-		return false
-	}
-
-	// 直近3回が全て同じ(ツール名+引数)かチェック
-	n := len(ld.history)
-	last := ld.history[n-1]
-	prev1 := ld.history[n-2]
-	prev2 := ld.history[n-3]
-
-	if last.ToolName == prev1.ToolName &&
-		last.Arguments == prev1.Arguments &&
-		last.ToolName == prev2.ToolName &&
-		last.Arguments == prev2.Arguments {
-		return true
-	}
-
-	return false
+	return ld.trailingIdenticalCalls() >= ld.threshold
 }
 
-// hasRepeatingPattern checks for repeating patterns in tool calls
-func (ld *LoopDetector) hasRepeatingPattern() bool {
-	if len(ld.history) < 3 {
-		return false
-	}
-
-	// Check for ABA pattern (Tool A, Tool B, Tool A again)
-	// Only flag as loop if both the tool name AND arguments are similar
-	lastThree := ld.history[len(ld.history)-3:]
-	if lastThree[0].ToolName == lastThree[2].ToolName {
-		// Additional check: Only flag as loop if arguments are similar
-		// This avoids false positives for legitimate patterns like:
-		// bash (setup) -> write_file (script) -> bash (run)
-		// vs actual loops: bash (run X fails) -> write (fix X) -> bash (run X)
-		if lastThree[0].Arguments == lastThree[2].Arguments {
-			return true
-		}
+// trailingIdenticalCalls counts how many of the most recent calls have the
+// same tool name and arguments as the last one
+func (ld *LoopDetector) trailingIdenticalCalls() int {
+	if len(ld.history) == 0 {
+		return 0
 	}
-
-	// Check for simple repetition of same tool with same arguments
-	recentCount := 0
-	lastToolName := ld.history[len(ld.history)-1].ToolName
-	lastArguments := ld.history[len(ld.history)-1].Arguments
-
+	last := ld.history[len(ld.history)-1]
+	count := 0
 	for i := len(ld.history) - 1; i >= 0; i-- {
-		if ld.history[i].ToolName == lastToolName &&
-			ld.history[i].Arguments == lastArguments {
-			recentCount++
-		} else {
+		if ld.history[i].ToolName != last.ToolName || ld.history[i].Arguments != last.Arguments {
 			break
 		}
+		count++
 	}
-
-	if recentCount >= MaxSameToolRepeat {
-		return true
-	}
-
-	return false
+	return count
 }
 
-// hasSimilarBashLoop checks if the agent is repeatedly calling bash with similar test/install commands
-// This catches patterns like: npm test → npx jest → npm test → npx jest (all failing)
-func (ld *LoopDetector) hasSimilarBashLoop() bool {
-	if len(ld.history) < 4 {
+// hasRepeatingPattern checks for repeating patterns in tool calls
+func (ld *LoopDetector) hasRepeatingPattern() bool {
+	if len(ld.history) < 3 {
 		return false
 	}
 
-	// Count recent bash calls that are test/install related
-	testCommandCount := 0
-	for i := len(ld.history) - 1; i >= 0 && i >= len(ld.history)-8; i-- {
-		record := ld.history[i]
-		if record.ToolName == "bash" {
-			cmd := extractBashCommand(record.Arguments)
-			if isTestOrInstallCommand(cmd) {
-				testCommandCount++
-			}
-		}
+	// Check for an alternating pattern (A, B, A, B, A) where A has come back
+	// threshold times. Tool name AND arguments must match, so legitimate
+	// sequences like bash (setup) -> write_file (script) -> bash (run) are
+	// not flagged, while bash (run X fails) -> write (fix X) -> bash (run X)
+	// repeated is (A, A, A is left to the identical-sequence check)
+	if ld.trailingAlternatingCalls() >= ld.threshold {
+		return true
 	}
 
-	// If 4+ similar test commands in recent history, it's a loop
-	return testCommandCount >= 4
+	// Check for simple repetition of same tool with same arguments
+	return ld.trailingIdenticalCalls() >= ld.threshold
 }
 
-// extractBashCommand extracts the command string from bash tool arguments JSON
-func extractBashCommand(arguments string) string {
-	var args struct {
-		Command string `json:"command"`
+// trailingAlternatingCalls counts how many times the last call appears in the
+// trailing A, B, A, B, ... run of two different calls (0 when there is none)
+func (ld *LoopDetector) trailingAlternatingCalls() int {
+	n := len(ld.history)
+	if n < 3 || sameCall(ld.history[n-1], ld.history[n-2]) {
+		return 0
+	}
+	length := 2
+	for i := n - 3; i >= 0 && sameCall(ld.history[i], ld.history[i+2]); i-- {
+		length++
 	}
-	if err := json.Unmarshal([]byte(arguments), &args); err != nil {
-		return arguments
+	if length < 3 {
+		return 0
 	}
-	return args.Command
+	return (length + 1) / 2
 }
 
-// isTestOrInstallCommand checks if a bash command is a test or install related command
-func isTestOrInstallCommand(cmd string) bool {
-	cmdLower := strings.ToLower(strings.TrimSpace(cmd))
-	testPatterns := []string{
-		"npm test", "npx jest", "yarn test", "pytest",
-		"go test", "cargo test", "make test",
-		"npm run test", "yarn run test",
-	}
-	for _, pattern := range testPatterns {
-		if strings.HasPrefix(cmdLower, pattern) || strings.Contains(cmdLower, pattern) {
-			return true
-		}
-	}
-	return false
+// sameCall reports whether two records have the same tool name and arguments
+func sameCall(a, b ToolCallRecord) bool {
+	return a.ToolName == b.ToolName && a.Arguments == b.Arguments
 }
 
 // GetLoopInfo returns information about detected loops
@@ -256,7 +234,7 @@ func (ld *LoopDetector) findRepeatingPattern() ToolCallRecord {
 func (ld *LoopDetector) getDescription(pattern ToolCallRecord) string {
 	count := ld.toolCounts[pattern.ToolName]
 
-	if count >= MaxSameToolRepeat {
+	if count >= ld.threshold {
 		return fmt.Sprintf("Tool '%s' called %d times consecutively", pattern.ToolName, count)
 	}
 
@@ -302,23 +280,10 @@ func GenerateToolCallHash(toolName string, arguments string) string {
 	return fmt.Sprintf("%016x", h.Sum64())
 }
 
-// CheckForStuckLoop checks if the agent is stuck in a loop
+// CheckForStuckLoop checks if the last threshold calls were identical
+// (same tool and arguments)
 func (ld *LoopDetector) CheckForStuckLoop() bool {
-	if len(ld.history) < MaxSameToolRepeat {
-		return false
-	}
-
-	// Check if all recent calls are the same tool
-	lastCalls := ld.history[len(ld.history)-MaxSameToolRepeat:]
-	firstTool := lastCalls[0].ToolName
-
-	for _, call := range lastCalls {
-		if call.ToolName != firstTool {
-			return false
-		}
-	}
-
-	return true
+	return ld.trailingIdenticalCalls() >= ld.threshold
 }
 
 // GetCurrentLoopIteration returns the current iteration count
@@ -119,13 +119,21 @@ func TestDetectLoop_IdenticalSequence(t *testing.T) {
 func TestDetectLoop_RepeatingPattern(t *testing.T) {
 	ld := NewLoopDetector()
 
-	// ABA pattern
+	// ABA pattern: A has only been called twice, below the threshold
 	ld.RecordToolCall("read_file", `{"path": "a.txt"}`)
 	ld.RecordToolCall("grep", `{"pattern": "test"}`)
 	ld.RecordToolCall("read_file", `{"path": "a.txt"}`)
 
+	if ld.DetectLoop() {
+		t.Error("Should not detect ABA pattern below the threshold")
+	}
+
+	// ABABA pattern
+	ld.RecordToolCall("grep", `{"pattern": "test"}`)
+	ld.RecordToolCall("read_file", `{"path": "a.txt"}`)
+
 	if !ld.DetectLoop() {
-		t.Error("Should detect ABA pattern")
+		t.Error("Should detect ABABA pattern")
 	}
 }
 
@@ -150,8 +158,26 @@ func TestHasRepeatingPattern(t *testing.T) {
 	ld.RecordToolCall("read_file", `{}`)
 	ld.RecordToolCall("grep", `{}`)
 	ld.RecordToolCall("read_file", `{}`)
+	if ld.hasRepeatingPattern() {
+		t.Error("Should not detect ABA pattern below the threshold")
+	}
+
+	// ABABA pattern
+	ld.RecordToolCall("grep", `{}`)
+	ld.RecordToolCall("read_file", `{}`)
+	if !ld.hasRepeatingPattern() {
+		t.Error("Should detect ABABA pattern")
+	}
+
+	// A higher threshold needs more repetitions
+	ld = NewLoopDetectorWithLimits(LoopHistorySize, 4)
+	for i := 0; i < 3; i++ {
+		ld.RecordToolCall("bash", `{"command": "make"}`)
+		ld.RecordToolCall("edit_file", `{}`)
+	}
+	ld.RecordToolCall("bash", `{"command": "make"}`)
 	if !ld.hasRepeatingPattern() {
-		t.Error("Should detect ABA pattern")
+		t.Error("Should detect 4 alternations with a threshold of 4")
 	}
 }
 
@@ -463,3 +489,108 @@ func TestGetMostCalledTool(t *testing.T) {
 		t.Errorf("Count = %v, want 3", count)
 	}
 }
+
+func TestDetectLoop_SameToolDifferentArgs(t *testing.T) {
+	ld := NewLoopDetector()
+
+	// Reading many files is not a loop, even well past the threshold
+	for _, path := range []string{"a.go", "b.go", "c.go", "d.go", "e.go"} {
+		ld.RecordToolCall("read_file", `{"path": "`+path+`"}`)
+	}
+	if ld.DetectLoop() {
+		t.Error("5 calls of the same tool with different arguments should not be a loop")
+	}
+
+	// Different test commands are not a loop either
+	ld.Reset()
+	for _, cmd := range []string{"go test ./a", "go test ./b", "go test ./c", "go test ./d", "go test ./e"} {
+		ld.RecordToolCall("bash", `{"command": "`+cmd+`"}`)
+	}
+	if ld.DetectLoop() || ld.CheckForStuckLoop() {
+		t.Error("5 different bash commands should not be a loop")
+	}
+}
+
+func TestDetectLoop_IdenticalCallsTrip(t *testing.T) {
+	ld := NewLoopDetector()
+	ld.RecordToolCall("read_file", `{"path": "a.go"}`)
+	ld.RecordToolCall("read_file", `{"path": "b.go"}`)
+	ld.RecordToolCall("read_file", `{"path": "a.go"}`)
+	ld.RecordToolCall("glob", `{"pattern": "*.go"}`)
+	if ld.DetectLoop() {
+		t.Fatal("2 identical calls should not be a loop yet")
+	}
+	ld.RecordToolCall("read_file", `{"path": "a.go"}`)
+	if !ld.DetectLoop() {
+		t.Error("3 identical calls should be a loop")
+	}
+}
+
+func TestNewLoopDetectorWithLimits(t *testing.T) {
+	ld := NewLoopDetectorWithLimits(5, 4)
+	for i := 0; i < 3; i++ {
+		ld.RecordToolCall("bash", `{"command": "make"}`)
+	}
+	if ld.DetectLoop() {
+		t.Error("3 identical calls should not trip a threshold of 4")
+	}
+	ld.RecordToolCall("bash", `{"command": "make"}`)
+	if !ld.DetectLoop() {
+		t.Error("4 identical calls should trip a threshold of 4")
+	}
+
+	for i := 0; i < 10; i++ {
+		ld.RecordToolCall("glob", `{}`)
+	}
+	if ld.GetHistorySize() != 5 {
+		t.Errorf("history = %d, want the configured 5", ld.GetHistorySize())
+	}
+
+	if d := NewLoopDetectorWithLimits(0, 0); d.historySize != LoopHistorySize || d.Threshold() != MaxSameToolRepeat {
+		t.Errorf("zero limits = %d/%d, want the defaults", d.historySize, d.Threshold())
+	}
+}
+
+func TestLoopDetector_Disabled(t *testing.T) {
+	ld := NewLoopDetector()
+	ld.SetEnabled(false)
+	for i := 0; i < 5; i++ {
+		ld.RecordToolCall("bash", `{"command": "make"}`)
+	}
+	if ld.DetectLoop() {
+		t.Error("disabled detector should never report a loop")
+	}
+
+	// Reset between turns keeps the setting
+	ld.Reset()
+	if ld.IsEnabled() {
+		t.Error("Reset should not re-enable detection")
+	}
+
+	ld.SetEnabled(true)
+	for i := 0; i < 3; i++ {
+		ld.RecordToolCall("bash", `{"command": "make"}`)
+	}
+	if !ld.DetectLoop() {
+		t.Error("re-enabled detector should report the loop")
+	}
+}
+
+func TestDetectLoop_ForgetsCallsOutsideHistory(t *testing.T) {
+	ld := NewLoopDetectorWithLimits(4, 3)
+
+	// Two identical calls, then enough other calls to push them out of the window
+	ld.RecordToolCall("bash", `{"command": "make"}`)
+	ld.RecordToolCall("bash", `{"command": "make"}`)
+	for _, path := range []string{"a.go", "b.go", "c.go", "d.go"} {
+		ld.RecordToolCall("read_file", `{"path": "`+path+`"}`)
+	}
+	if n := ld.hashCounts[GenerateToolCallHash("bash", `{"command": "make"}`)]; n != 0 {
+		t.Errorf("count of calls outside the history = %d, want 0", n)
+	}
+
+	ld.RecordToolCall("bash", `{"command": "make"}`)
+	if ld.DetectLoop() {
+		t.Error("calls that left the history should not count toward a loop")
+	}
+}
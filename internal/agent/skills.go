@@ -0,0 +1,88 @@
+package agent
+
+import (
+	"fmt"
+
+	"github.com/zephel01/vibe-local-go/internal/session"
+	"github.com/zephel01/vibe-local-go/internal/skill"
+	"github.com/zephel01/vibe-local-go/internal/ui"
+)
+
+// UseSkill loads a skill's SKILL.md into the context of every following turn
+// until ClearSkill is called. It replaces any skill loaded before.
+func (a *Agent) UseSkill(s *skill.SkillMeta) error {
+	body, err := s.LoadBody()
+	if err != nil {
+		return err
+	}
+	a.pinnedSkill = s.Name
+	a.pinnedSkillContext = skillContext(s, body)
+	return nil
+}
+
+// ClearSkill removes the skill loaded with UseSkill and returns its name ("" if none)
+func (a *Agent) ClearSkill() string {
+	name := a.pinnedSkill
+	a.pinnedSkill, a.pinnedSkillContext = "", ""
+	return name
+}
+
+// PinnedSkill returns the name of the skill loaded with UseSkill ("" if none)
+func (a *Agent) PinnedSkill() string {
+	return a.pinnedSkill
+}
+
+// activateSkill returns the context for the skill that best matches the user's
+// message ("" when no skill clears the match threshold or it is already loaded)
+func (a *Agent) activateSkill(userInput string) string {
+	if a.skills == nil {
+		return ""
+	}
+	matches := a.skills.MatchSkills(userInput)
+	if len(matches) == 0 || matches[0].Name == a.pinnedSkill {
+		return ""
+	}
+
+	top := matches[0]
+	body, err := top.LoadBody()
+	if err != nil {
+		a.terminal.PrintWarning(err.Error())
+		return ""
+	}
+	a.terminal.PrintColored(ui.ColorCyan, fmt.Sprintf("📘 Using skill: %s\n", top.Name))
+	return skillContext(top, body)
+}
+
+// skillContext formats a skill body as a system message
+func skillContext(s *skill.SkillMeta, body string) string {
+	return fmt.Sprintf("## Active skill: %s\n\nThis skill matches the user's request. Follow its instructions for this task.\n(Source: %s)\n\n%s",
+		s.Name, s.SkillFile, body)
+}
+
+// withTurnSkill inserts the loaded and auto-activated skills as system messages
+// after the system prompt. The session and its cached messages are left untouched.
+func (a *Agent) withTurnSkill(messages []map[string]interface{}) []map[string]interface{} {
+	var contexts []string
+	for _, c := range []string{a.pinnedSkillContext, a.turnSkill} {
+		if c != "" {
+			contexts = append(contexts, c)
+		}
+	}
+	if len(contexts) == 0 {
+		return messages
+	}
+
+	at := 0
+	if len(messages) > 0 && messages[0]["role"] == string(session.RoleSystem) {
+		at = 1
+	}
+	out := make([]map[string]interface{}, 0, len(messages)+len(contexts))
+	out = append(out, messages[:at]...)
+	for _, c := range contexts {
+		out = append(out, map[string]interface{}{
+			"role":    string(session.RoleSystem),
+			"content": c,
+		})
+	}
+	return append(out, messages[at:]...)
+}
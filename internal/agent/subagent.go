@@ -168,9 +168,13 @@ func (sa *SubAgent) getFilteredSchemas() []*tool.FunctionSchema {
 
 	// Read-only mode: filter out write tools
 	writeToolNames := map[string]bool{
-		"write_file":    true,
-		"edit_file":     true,
-		"notebook_edit": true,
+		"write_file":     true,
+		"edit_file":      true,
+		"multi_edit":     true,
+		"apply_patch":    true,
+		"move_file":      true,
+		"notebook_edit":  true,
+		"make_directory": true,
 	}
 
 	filtered := make([]*tool.FunctionSchema, 0, len(allSchemas))
@@ -187,14 +191,7 @@ func (sa *SubAgent) getFilteredSchemas() []*tool.FunctionSchema {
 // callLLM calls the LLM provider using the same pattern as the main Agent
 func (sa *SubAgent) callLLM(ctx context.Context, messages []map[string]interface{}, tools []*tool.FunctionSchema) (*ChatResponse, error) {
 	// Convert messages to llm.Message format
-	llmMessages := make([]llm.Message, len(messages))
-	for i, msg := range messages {
-		llmMessages[i] = llm.Message{
-			Role:    msg["role"].(string),
-			Content: msg["content"].(string),
-			ToolID:  getString(msg, "tool_id"),
-		}
-	}
+	llmMessages := toLLMMessages(messages)
 
 	// Build request
 	req := &llm.ChatRequest{
@@ -226,6 +223,7 @@ func (sa *SubAgent) executeSubAgentTools(ctx context.Context, toolCalls []sessio
 			results = append(results, session.ToolResult{
 				Content:    "Error: write operations are not allowed in read-only sub-agent mode",
 				ToolCallID: tc.ID,
+				IsError:    true,
 			})
 			continue
 		}
@@ -236,6 +234,7 @@ func (sa *SubAgent) executeSubAgentTools(ctx context.Context, toolCalls []sessio
 			results = append(results, session.ToolResult{
 				Content:    fmt.Sprintf("Error: unknown tool '%s'", toolName),
 				ToolCallID: tc.ID,
+				IsError:    true,
 			})
 			continue
 		}
@@ -252,6 +251,7 @@ func (sa *SubAgent) executeSubAgentTools(ctx context.Context, toolCalls []sessio
 			results = append(results, session.ToolResult{
 				Content:    fmt.Sprintf("Error executing %s: %v", toolName, err),
 				ToolCallID: tc.ID,
+				IsError:    true,
 			})
 			continue
 		}
@@ -264,6 +264,7 @@ func (sa *SubAgent) executeSubAgentTools(ctx context.Context, toolCalls []sessio
 		results = append(results, session.ToolResult{
 			Content:    output,
 			ToolCallID: tc.ID,
+			IsError:    result.IsError,
 		})
 	}
 
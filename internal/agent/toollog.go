@@ -0,0 +1,159 @@
+package agent
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// MaxToolLogOutput is the longest tool output kept in a tool call log entry
+const MaxToolLogOutput = 2000
+
+// redactedValue replaces the value of a key-like argument in the log
+const redactedValue = "[REDACTED]"
+
+// sensitiveArgPatterns mark argument names whose values are never logged
+// (the same patterns bash uses to drop environment variables)
+var sensitiveArgPatterns = []string{"TOKEN", "SECRET", "PASSWORD", "KEY", "AUTH", "PRIVATE", "CREDENTIAL"}
+
+// ToolCallLogEntry is one line of the tool call log
+type ToolCallLogEntry struct {
+	Timestamp  time.Time       `json:"timestamp"`
+	Tool       string          `json:"tool"`
+	Arguments  json.RawMessage `json:"arguments"`
+	Success    bool            `json:"success"`
+	DurationMs int64           `json:"duration_ms"`
+	Output     string          `json:"output,omitempty"`
+	Error      string          `json:"error,omitempty"`
+	Truncated  bool            `json:"truncated,omitempty"`
+}
+
+// ToolCallLogger appends one JSON line per tool call to a file.
+// Writes are buffered; Close flushes them
+type ToolCallLogger struct {
+	mu   sync.Mutex
+	file *os.File
+	w    *bufio.Writer
+	now  func() time.Time
+}
+
+// OpenToolCallLogger opens (or creates) path for appending
+func OpenToolCallLogger(path string) (*ToolCallLogger, error) {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open tool log: %w", err)
+	}
+	return &ToolCallLogger{file: f, w: bufio.NewWriter(f), now: time.Now}, nil
+}
+
+// Record writes the entry for a finished tool call. Key-like arguments are
+// redacted and the output is truncated to MaxToolLogOutput bytes
+func (l *ToolCallLogger) Record(toolName, arguments string, result ToolResult, duration time.Duration) error {
+	output, truncated := result.Content, false
+	if len(output) > MaxToolLogOutput {
+		cut := MaxToolLogOutput
+		for cut > 0 && !utf8.RuneStart(output[cut]) {
+			cut--
+		}
+		output, truncated = output[:cut], true
+	}
+
+	entry := ToolCallLogEntry{
+		Tool:       toolName,
+		Arguments:  redactArguments(arguments),
+		Success:    result.IsSuccess,
+		DurationMs: duration.Milliseconds(),
+		Output:     output,
+		Error:      result.Error,
+		Truncated:  truncated,
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file == nil {
+		return fmt.Errorf("tool log is closed")
+	}
+	entry.Timestamp = l.now()
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	_, err = l.w.Write(data)
+	return err
+}
+
+// Flush writes buffered entries to the file
+func (l *ToolCallLogger) Flush() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file == nil {
+		return nil
+	}
+	return l.w.Flush()
+}
+
+// Close flushes buffered entries and closes the file
+func (l *ToolCallLogger) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file == nil {
+		return nil
+	}
+	err := l.w.Flush()
+	if cerr := l.file.Close(); err == nil {
+		err = cerr
+	}
+	l.file = nil
+	return err
+}
+
+// redactArguments returns the arguments as JSON with the values of key-like
+// fields replaced. Arguments that aren't JSON are logged as a string
+func redactArguments(arguments string) json.RawMessage {
+	var v interface{}
+	if err := json.Unmarshal([]byte(arguments), &v); err != nil {
+		data, _ := json.Marshal(arguments)
+		return data
+	}
+	data, err := json.Marshal(redactValue(v))
+	if err != nil {
+		data, _ = json.Marshal(arguments)
+	}
+	return data
+}
+
+// redactValue redacts key-like fields in nested objects and arrays
+func redactValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for k, child := range val {
+			if isSensitiveArg(k) {
+				val[k] = redactedValue
+			} else {
+				val[k] = redactValue(child)
+			}
+		}
+	case []interface{}:
+		for i, child := range val {
+			val[i] = redactValue(child)
+		}
+	}
+	return v
+}
+
+// isSensitiveArg reports whether an argument name looks like it holds a secret
+func isSensitiveArg(name string) bool {
+	upper := strings.ToUpper(name)
+	for _, pattern := range sensitiveArgPatterns {
+		if strings.Contains(upper, pattern) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,120 @@
+package agent
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/zephel01/vibe-local-go/internal/session"
+	"github.com/zephel01/vibe-local-go/internal/tool"
+)
+
+// echoTool returns its arguments as output
+type echoTool struct{}
+
+func (e *echoTool) Name() string { return "echo" }
+
+func (e *echoTool) Execute(ctx context.Context, params json.RawMessage) (*tool.Result, error) {
+	return tool.NewResult("echo " + string(params)), nil
+}
+
+func (e *echoTool) Schema() *tool.FunctionSchema {
+	return &tool.FunctionSchema{Name: "echo", Parameters: &tool.ParameterSchema{Type: "object"}}
+}
+
+func readToolLog(t *testing.T, path string) []ToolCallLogEntry {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("failed to open tool log: %v", err)
+	}
+	defer f.Close()
+
+	var entries []ToolCallLogEntry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var entry ToolCallLogEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatalf("line %q is not JSON: %v", scanner.Text(), err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func TestToolCallLogger_OneLinePerCall(t *testing.T) {
+	agent := createSimpleTestAgent()
+	agent.registry.Register(&echoTool{})
+
+	path := filepath.Join(t.TempDir(), "tools.jsonl")
+	logger, err := OpenToolCallLogger(path)
+	if err != nil {
+		t.Fatalf("OpenToolCallLogger: %v", err)
+	}
+	agent.SetToolCallLogger(logger)
+
+	calls := []session.ToolCall{
+		{ID: "1", Function: session.FunctionCall{Name: "echo", Arguments: `{"text":"hi","api_key":"sk-secret","headers":{"Authorization":"Bearer x"}}`}},
+		{ID: "2", Function: session.FunctionCall{Name: "missing", Arguments: `{}`}},
+	}
+	for i := range calls {
+		agent.executeSingleTool(context.Background(), &calls[i])
+	}
+
+	// Entries are buffered until the logger is closed
+	if data, _ := os.ReadFile(path); len(data) != 0 {
+		t.Errorf("expected buffered writes before Close, file has %q", data)
+	}
+	if err := logger.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	entries := readToolLog(t, path)
+	if len(entries) != 2 {
+		t.Fatalf("log lines = %d, want one per tool call", len(entries))
+	}
+
+	echo := entries[0]
+	if echo.Tool != "echo" || !echo.Success || echo.Timestamp.IsZero() || !strings.HasPrefix(echo.Output, "echo ") {
+		t.Errorf("echo entry = %+v", echo)
+	}
+	args := string(echo.Arguments)
+	if strings.Contains(args, "sk-secret") || strings.Contains(args, "Bearer") || !strings.Contains(args, `"text":"hi"`) {
+		t.Errorf("arguments = %s, want key-like fields redacted and the rest kept", args)
+	}
+
+	if missing := entries[1]; missing.Tool != "missing" || missing.Success || !strings.Contains(missing.Error, "Tool not found") {
+		t.Errorf("failed call entry = %+v", missing)
+	}
+}
+
+func TestToolCallLogger_TruncatesOutputAndAppends(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tools.jsonl")
+	for i := 0; i < 2; i++ {
+		logger, err := OpenToolCallLogger(path)
+		if err != nil {
+			t.Fatalf("OpenToolCallLogger: %v", err)
+		}
+		result := ToolResult{IsSuccess: true, Content: strings.Repeat("あ", MaxToolLogOutput)}
+		if err := logger.Record("read_file", "not json", result, 0); err != nil {
+			t.Fatalf("Record: %v", err)
+		}
+		logger.Close()
+	}
+
+	entries := readToolLog(t, path)
+	if len(entries) != 2 {
+		t.Fatalf("log lines = %d, want 2 (reopening appends)", len(entries))
+	}
+	entry := entries[0]
+	if !entry.Truncated || len(entry.Output) > MaxToolLogOutput || !strings.HasSuffix(entry.Output, "あ") {
+		t.Errorf("output = %d bytes, truncated %v; want at most %d bytes on a rune boundary", len(entry.Output), entry.Truncated, MaxToolLogOutput)
+	}
+	if string(entry.Arguments) != `"not json"` {
+		t.Errorf("arguments = %s, want non-JSON arguments logged as a string", entry.Arguments)
+	}
+}
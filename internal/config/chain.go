@@ -0,0 +1,63 @@
+package config
+
+import (
+	"fmt"
+)
+
+// CHAIN の検証で使うプロバイダーの種別（llm.ProviderType と同じ値）
+const (
+	ProviderTypeLocal = "local"
+	ProviderTypeCloud = "cloud"
+)
+
+// ChainMembers は CHAIN の各エントリを、そのプロバイダー用の設定に解決する（CHAIN の順）。
+// providerType はプロバイダーキーの種別を返す（"" = 未知のプロバイダー）。
+// 未知・APIキー未設定・オフライン中のクラウド・重複のエントリはスキップし、理由を warnings に返す。
+// Model が "" のメンバーはプロバイダーの既定モデルを使う
+func (c *Config) ChainMembers(profiles map[string]ProviderProfile, providerType func(key string) string) (members []*Config, warnings []string) {
+	seen := make(map[string]bool)
+	for _, key := range c.Chain {
+		if seen[key] {
+			warnings = append(warnings, fmt.Sprintf("CHAIN: %s が重複しています（2つ目以降をスキップ）", key))
+			continue
+		}
+		seen[key] = true
+
+		profile := profiles[key]
+		member := *c
+		member.Provider = key
+		member.Model = profile.Model
+		if key == c.Provider && c.Model != "" {
+			member.Model = c.Model // アクティブプロバイダーは解決済みのモデルを使う
+		}
+
+		switch providerType(key) {
+		case ProviderTypeLocal:
+			if profile.Host != "" {
+				member.OllamaHost = profile.Host
+			} else if key != c.Provider {
+				member.OllamaHost = "" // プロバイダーの既定ホスト
+			}
+		case ProviderTypeCloud:
+			if c.Offline {
+				warnings = append(warnings, fmt.Sprintf("CHAIN: オフラインモードのためクラウドプロバイダー %s をスキップします", key))
+				continue
+			}
+			apiKey := profile.APIKey
+			if apiKey == "" {
+				apiKey = c.CloudAPIKeys[key]
+			}
+			if apiKey == "" {
+				warnings = append(warnings, fmt.Sprintf("CHAIN: %s の APIキーが設定されていないためスキップします", key))
+				continue
+			}
+			member.CloudAPIKeys = map[string]string{key: apiKey}
+		default:
+			warnings = append(warnings, fmt.Sprintf("CHAIN: 不明なプロバイダー %s をスキップします", key))
+			continue
+		}
+
+		members = append(members, &member)
+	}
+	return members, warnings
+}
@@ -0,0 +1,94 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+// testProviderType mimics the provider keys main can create
+func testProviderType(key string) string {
+	switch key {
+	case "ollama", "lm-studio":
+		return ProviderTypeLocal
+	case "openai", "anthropic":
+		return ProviderTypeCloud
+	}
+	return ""
+}
+
+func TestParseConfigFile_Chain(t *testing.T) {
+	cfg, _ := setupTestConfig(t, `{
+		"PROVIDER": "ollama",
+		"CHAIN": ["anthropic", "ollama", "openai"],
+		"PROVIDERS": {
+			"anthropic": {"type": "anthropic", "api_key": "sk-ant", "model": "claude-sonnet-4-5"}
+		}
+	}`)
+
+	if strings.Join(cfg.Chain, ",") != "anthropic,ollama,openai" {
+		t.Errorf("Chain = %v", cfg.Chain)
+	}
+	if cfg.Source("CHAIN") != SourceConfig {
+		t.Errorf("Source(CHAIN) = %q, want %q", cfg.Source("CHAIN"), SourceConfig)
+	}
+
+	if cfg, _ := setupTestConfig(t, `{"MODEL": "qwen3:8b"}`); cfg.Chain != nil {
+		t.Errorf("Chain without CHAIN = %v, want nil (automatic chain)", cfg.Chain)
+	}
+}
+
+func TestChainMembers_OrderAndValidation(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Provider = "ollama"
+	cfg.Model = "qwen3:8b"
+	cfg.CloudAPIKeys["openai"] = "sk-env"
+	cfg.Chain = []string{"anthropic", "bogus", "openai", "ollama", "lm-studio", "openai"}
+	profiles := map[string]ProviderProfile{
+		"anthropic": {Type: "anthropic", Model: "claude-sonnet-4-5"}, // No API key anywhere
+		"lm-studio": {Type: "lm-studio", Host: "http://studio:1234", Model: "gemma"},
+	}
+
+	members, warnings := cfg.ChainMembers(profiles, testProviderType)
+
+	var keys []string
+	for _, m := range members {
+		keys = append(keys, m.Provider)
+	}
+	if strings.Join(keys, ",") != "openai,ollama,lm-studio" {
+		t.Fatalf("members = %v, want the configured providers in CHAIN order", keys)
+	}
+	if len(warnings) != 3 {
+		t.Errorf("warnings = %q, want anthropic (no key), bogus (unknown) and the duplicate", warnings)
+	}
+	for _, want := range []string{"anthropic", "bogus", "重複"} {
+		if !strings.Contains(strings.Join(warnings, "\n"), want) {
+			t.Errorf("warnings %q should mention %q", warnings, want)
+		}
+	}
+
+	openai, ollama, studio := members[0], members[1], members[2]
+	if openai.CloudAPIKeys["openai"] != "sk-env" || openai.Model != "" {
+		t.Errorf("openai member = key %q model %q, want the env key and the default model", openai.CloudAPIKeys["openai"], openai.Model)
+	}
+	if ollama.Model != "qwen3:8b" || ollama.OllamaHost != DefaultOllamaHost {
+		t.Errorf("active provider member = %q at %q, want the resolved model and host", ollama.Model, ollama.OllamaHost)
+	}
+	if studio.Model != "gemma" || studio.OllamaHost != "http://studio:1234" {
+		t.Errorf("lm-studio member = %q at %q, want its profile", studio.Model, studio.OllamaHost)
+	}
+	if cfg.Provider != "ollama" || cfg.Model != "qwen3:8b" {
+		t.Error("ChainMembers must not modify the receiver")
+	}
+}
+
+func TestChainMembers_OfflineSkipsCloud(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Offline = true
+	cfg.CloudAPIKeys["openai"] = "sk"
+	cfg.Chain = []string{"openai", "ollama"}
+
+	members, warnings := cfg.ChainMembers(nil, testProviderType)
+	if len(members) != 1 || members[0].Provider != "ollama" || len(warnings) != 1 {
+		t.Errorf("members = %d, warnings = %q, want only ollama", len(members), warnings)
+	}
+}
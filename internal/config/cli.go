@@ -52,34 +52,42 @@ func (c *Config) ParseEnv() {
 	// Environment variables override config file defaults but CLI args take priority
 	if v := os.Getenv("VIBE_CODER_MODEL"); v != "" {
 		c.Model = v
+		c.SetSource("MODEL", SourceEnv)
 		c.AutoModel = false
 	}
 	if v := os.Getenv("VIBE_LOCAL_MODEL"); v != "" && c.Model == "" {
 		c.Model = v
+		c.SetSource("MODEL", SourceEnv)
 		c.AutoModel = false
 	}
 	if v := os.Getenv("VIBE_CODER_SIDECAR"); v != "" {
 		c.SidecarModel = v
+		c.SetSource("SIDECAR_MODEL", SourceEnv)
 	}
 	if v := os.Getenv("VIBE_LOCAL_SIDECAR_MODEL"); v != "" && c.SidecarModel == "" {
 		c.SidecarModel = v
+		c.SetSource("SIDECAR_MODEL", SourceEnv)
 	}
 	if v := os.Getenv("OLLAMA_HOST"); v != "" {
 		c.OllamaHost = v
+		c.SetSource("OLLAMA_HOST", SourceEnv)
 	}
 	if v := os.Getenv("VIBE_CODER_MAX_TOKENS"); v != "" {
 		if n, err := strconv.Atoi(v); err == nil {
 			c.MaxTokens = n
+			c.SetSource("MAX_TOKENS", SourceEnv)
 		}
 	}
 	if v := os.Getenv("VIBE_CODER_TEMPERATURE"); v != "" {
 		if f, err := strconv.ParseFloat(v, 64); err == nil {
 			c.Temperature = f
+			c.SetSource("TEMPERATURE", SourceEnv)
 		}
 	}
 	if v := os.Getenv("VIBE_CODER_CONTEXT_WINDOW"); v != "" {
 		if n, err := strconv.Atoi(v); err == nil {
 			c.ContextWindow = n
+			c.SetSource("CONTEXT_WINDOW", SourceEnv)
 		}
 	}
 
@@ -87,11 +95,13 @@ func (c *Config) ParseEnv() {
 	if v := os.Getenv("OLLAMA_NUM_CTX"); v != "" {
 		if n, err := strconv.Atoi(v); err == nil {
 			c.OllamaNumCtx = n
+			c.SetSource("OLLAMA_NUM_CTX", SourceEnv)
 		}
 	}
 	if v := os.Getenv("OLLAMA_NUM_GPU"); v != "" {
 		if n, err := strconv.Atoi(v); err == nil {
 			c.OllamaNumGPU = n
+			c.SetSource("OLLAMA_NUM_GPU", SourceEnv)
 		}
 	}
 }
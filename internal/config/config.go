@@ -8,6 +8,7 @@ const (
 	DefaultMaxTokens     = 8192
 	DefaultTemperature  = 0.2
 	DefaultContextWindow = 32768
+	DefaultRetryBudget   = 6 // 1ターンで許可するリトライの合計（LLM・チェーン・ツール）
 )
 
 // Model tiers based on available RAM
@@ -20,12 +21,26 @@ const (
 	TierE = "E" // 4GB+   - Minimal models (1.7b)
 )
 
+// Banner display modes
+const (
+	BannerFull    = "full"    // ASCII logo + info + welcome (default)
+	BannerMinimal = "minimal" // provider/model on one line
+	BannerNone    = "none"    // no banner, no welcome
+)
+
+// System prompt file modes
+const (
+	SystemPromptReplace = "replace" // ファイルの内容で生成プロンプトを置き換える（デフォルト）
+	SystemPromptPrepend = "prepend" // ファイルの内容を生成プロンプトの前に置く
+)
+
 // Config holds all configuration for the agent
 type Config struct {
 	// Model settings
 	Model        string
 	SidecarModel string
-	AutoModel    bool // true = auto-select based on RAM
+	AutoModel    bool   // true = auto-select based on RAM
+	EmbedModel   string // semantic_search の埋め込みモデル（"" = メインモデル）
 
 	// LLM settings
 	MaxTokens     int
@@ -33,7 +48,8 @@ type Config struct {
 	ContextWindow int
 
 	// Provider selection
-	Provider string // "ollama" (default), "openrouter", "openai", "anthropic", "google", etc.
+	Provider string   // "ollama" (default), "openrouter", "openai", "anthropic", "google", etc.
+	Chain    []string // CHAIN: プロバイダーチェーンの固定順序（空 = 自動構築）
 
 	// Ollama settings
 	OllamaHost    string
@@ -58,6 +74,64 @@ type Config struct {
 	// Debug mode
 	Debug bool
 
+	// Banner 起動時バナーの表示モード（BannerFull / BannerMinimal / BannerNone）
+	Banner string
+
+	// OutputWidth アシスタント出力の折り返し幅（0 = 端末幅、端末幅より広い値は端末幅に丸める）
+	OutputWidth int
+
+	// Offline ネットワークを使うツールとクラウドプロバイダーを無効化（ローカルプロバイダーのみ）
+	Offline bool
+
+	// RetryBudget 1ターン内のリトライ合計の上限（プロバイダー・チェーン・ツールで共有）
+	RetryBudget int
+
+	// BudgetTokens クラウドプロバイダーで使うトークン数の目安（超えたら次の呼び出し前に確認、0 = 無制限）
+	BudgetTokens int
+
+	// NoNetwork bash からネットワークにアクセスするコマンド（curl, wget, ssh, pip install 等）を拒否
+	NoNetwork bool
+
+	// AllowDangerous 危険なコマンドのパターン（rm -rf /, mkfs 等）によるブロックを無効化（既定は無効 = ブロックする）
+	AllowDangerous bool
+
+	// Lang 表示言語（"ja" / "en"、空なら LANG 等の環境変数から判定）
+	Lang string
+
+	// AllowOutsideWorkdir write/edit ツールに作業ディレクトリ外への書き込みを許可
+	AllowOutsideWorkdir bool
+	// NoVibeIgnore .vibeignore を読み込まない（列挙されたパスへのアクセスも許可）
+	NoVibeIgnore bool
+
+	// SystemPromptFile 独自のシステムプロンプトを読み込むファイル（"" = 生成プロンプトのみ）
+	SystemPromptFile string
+	// SystemPromptMode SystemPromptFile の使い方（"replace" / "prepend"、"" = replace）
+	SystemPromptMode string
+	// SystemPromptText SystemPromptFile から読み込んだ内容（LoadSystemPromptFile が設定、保存しない）
+	SystemPromptText string
+
+	// AutoTestCommand 自動テストで実行するシェルコマンド（"" = go.mod / package.json などから推測）
+	AutoTestCommand string
+
+	// MaxSearchDepth glob/grep が再帰的にたどるディレクトリの深さ上限（0 = ツールのデフォルト）
+	MaxSearchDepth int
+
+	// ToolOutputMaxBytes bash/read_file/grep/web_fetch の出力の上限バイト数（0 = ツールのデフォルトの 30000）
+	ToolOutputMaxBytes int
+
+	// CompactThreshold 自動圧縮を始めるコンテキスト使用率（0 < x <= 1、0 = デフォルトの 0.9）
+	CompactThreshold float64
+	// NoAutoCompact 自動圧縮を無効化（/compact による手動圧縮のみ）
+	NoAutoCompact bool
+
+	// LoopHistorySize ループ検出で追跡する直近のツール呼び出し数（0 = デフォルトの 20）
+	LoopHistorySize int
+	// LoopThreshold 同じツール・同じ引数の呼び出しを何回でループとみなすか（0 = デフォルトの 3）
+	LoopThreshold int
+
+	// WebSearchCacheSize web_search の結果をキャッシュするクエリ数（0 = ツールのデフォルト、負の値 = 無効）
+	WebSearchCacheSize int
+
 	// Sandbox mode — ファイル書き込みをステージングディレクトリで行う
 	SandboxMode bool
 
@@ -75,6 +149,11 @@ type Config struct {
 
 	// OS hints (injected into system prompt)
 	OSHints []string
+
+	// sources 各設定値の出どころ（/config effective 用、SetSource で記録）
+	sources map[string]settingSource
+	// overrides 環境変数・フラグで指定した値（プロファイルを編集しても優先順位を保つため、SetSource で記録）
+	overrides map[string]settingSource
 }
 
 // DefaultConfig returns a configuration with default values
@@ -87,11 +166,13 @@ func DefaultConfig() *Config {
 		MaxTokens:     DefaultMaxTokens,
 		Temperature:   DefaultTemperature,
 		ContextWindow: DefaultContextWindow,
+		RetryBudget:   DefaultRetryBudget,
 		OllamaHost:    DefaultOllamaHost,
 		OllamaNumCtx:  0,
 		OllamaNumGPU:  -1, // -1 = not set
 		CloudAPIKeys:  make(map[string]string),
 		VenvDir:       ".venv",
+		Banner:        BannerFull,
 		OS:            detectOS(),
 		Arch:          detectArch(),
 	}
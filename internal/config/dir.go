@@ -0,0 +1,160 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// EnvConfigDir 設定のルートディレクトリを上書きする環境変数。
+// 指定すると config.json, mcp.json, skills/, sessions/, permissions.json, history を全てこの下で扱う
+// （既定では ~/.config/vibe-local-go と ~/.config/vibe-local に分かれている）
+const EnvConfigDir = "VIBE_CONFIG_DIR"
+
+// defaultSessionBaseDir セッションの既定の保存先（sessions/ はこの下に作られる）
+const defaultSessionBaseDir = "~/.config/vibe-local"
+
+var (
+	dirMu sync.RWMutex
+	// configDir VIBE_CONFIG_DIR または退避先（"" = 既定のディレクトリを使う）
+	configDir string
+)
+
+// SetupConfigDir は起動時に保存先ディレクトリを決める。
+// VIBE_CONFIG_DIR が書き込み可能ならそれを使い、既定のディレクトリ
+// (~/.config/vibe-local-go, ~/.config/vibe-local) が書き込めない場合は一時ディレクトリへ退避する。
+// 退避した場合は警告メッセージを返す（"" = 警告なし）
+func SetupConfigDir() string {
+	var warning string
+	if dir := os.Getenv(EnvConfigDir); dir != "" {
+		dir = expandPath(dir)
+		err := checkWritableDir(dir)
+		if err == nil {
+			UseConfigDir(dir)
+			return ""
+		}
+		warning = fmt.Sprintf("%s (%s) に書き込めません: %v", EnvConfigDir, dir, err)
+	} else {
+		for _, dir := range []string{filepath.Dir(expandPath(defaultConfigPath)), expandPath(defaultSessionBaseDir)} {
+			if err := checkWritableDir(dir); err != nil {
+				warning = fmt.Sprintf("設定ディレクトリに書き込めません: %v", err)
+				break
+			}
+		}
+		if warning == "" {
+			UseConfigDir("")
+			return ""
+		}
+	}
+
+	fallback := filepath.Join(os.TempDir(), fmt.Sprintf("vibe-local-go-%d", os.Getuid()))
+	if err := checkPrivateDir(fallback); err != nil {
+		// 退避先も使えない場合は既定のまま（保存時にエラーを表示する）
+		UseConfigDir("")
+		return fmt.Sprintf("%s。退避先 %s も使えないため、設定とセッションは保存できません", warning, fallback)
+	}
+	UseConfigDir(fallback)
+	return fmt.Sprintf("%s。設定とセッションは一時ディレクトリ %s に保存します（%s で変更可能）", warning, fallback, EnvConfigDir)
+}
+
+// UseConfigDir は設定ファイルとセッションの保存先を dir に切り替える（"" = 既定）
+func UseConfigDir(dir string) {
+	dirMu.Lock()
+	defer dirMu.Unlock()
+	configDir = dir
+}
+
+// ConfigDir は VIBE_CONFIG_DIR または退避先のディレクトリを返す（既定を使う場合は ""）
+func ConfigDir() string {
+	dirMu.RLock()
+	defer dirMu.RUnlock()
+	return configDir
+}
+
+// GlobalDir はグローバル設定 (mcp.json, skills/) を読み込むディレクトリを返す。
+// 一時ディレクトリへの退避は書き込み先だけなので、ここには影響しない
+func GlobalDir() string {
+	if dir := os.Getenv(EnvConfigDir); dir != "" {
+		return expandPath(dir)
+	}
+	return filepath.Dir(expandPath(defaultConfigPath))
+}
+
+// SessionBaseDir はセッションの保存先を返す
+func SessionBaseDir() string {
+	if dir := ConfigDir(); dir != "" {
+		return dir
+	}
+	return expandPath(defaultSessionBaseDir)
+}
+
+// HistoryPath は入力履歴ファイルの保存先を返す
+func HistoryPath() string {
+	return filepath.Join(SessionBaseDir(), "history")
+}
+
+// configSavePath は config.json の保存先を返す
+func configSavePath() string {
+	if dir := ConfigDir(); dir != "" {
+		return filepath.Join(dir, "config.json")
+	}
+	return expandPath(defaultConfigPath)
+}
+
+// configSearchPaths は config.json の探索パス（展開済み、優先順）を返す。
+// VIBE_CONFIG_DIR 指定時は既定の場所を読まない
+func configSearchPaths() []string {
+	paths := make([]string, 0, len(configFilePaths)+2)
+	if dir := ConfigDir(); dir != "" {
+		paths = append(paths, filepath.Join(dir, "config.json"))
+	}
+	if os.Getenv(EnvConfigDir) != "" {
+		if p := filepath.Join(GlobalDir(), "config.json"); len(paths) == 0 || paths[0] != p {
+			paths = append(paths, p)
+		}
+		return paths
+	}
+	for _, p := range configFilePaths {
+		paths = append(paths, expandPath(p))
+	}
+	return paths
+}
+
+// checkPrivateDir は一時ディレクトリの退避先を 0700 で作成し、書き込めることを確認する。
+// 名前が予測できるため、既存のものは自分の所有で他のユーザーが書き込めない場合だけ使う
+// （シンボリックリンクは使わない）
+func checkPrivateDir(dir string) error {
+	if err := os.Mkdir(dir, 0700); err != nil && !os.IsExist(err) {
+		return err
+	}
+	info, err := os.Lstat(dir)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("%s はディレクトリではありません", dir)
+	}
+	if err := checkDirOwner(dir, info); err != nil {
+		return err
+	}
+	return checkWritableDir(dir)
+}
+
+// checkWritableDir は dir を作成し、実際にファイルを書けるか確認する
+func checkWritableDir(dir string) error {
+	if strings.HasPrefix(dir, "~") {
+		return fmt.Errorf("ホームディレクトリが見つかりません (%s)", dir)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(dir, ".write-test-*")
+	if err != nil {
+		return err
+	}
+	name := f.Name()
+	f.Close()
+	return os.Remove(name)
+}
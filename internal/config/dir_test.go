@@ -0,0 +1,181 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// setupUnwritableHome は ~/.config をファイルにして既定の設定ディレクトリを作れなくする
+// （root でもパーミッションに関係なく書き込みに失敗する）
+func setupUnwritableHome(t *testing.T) (home, tmp string) {
+	t.Helper()
+	home, tmp = t.TempDir(), t.TempDir()
+	if err := os.WriteFile(filepath.Join(home, ".config"), []byte("not a directory"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("HOME", home)
+	t.Setenv("TMPDIR", tmp)
+	t.Setenv(EnvConfigDir, "")
+	t.Cleanup(func() { UseConfigDir("") })
+	return home, tmp
+}
+
+func TestSetupConfigDir_FallsBackWhenDefaultUnwritable(t *testing.T) {
+	_, tmp := setupUnwritableHome(t)
+
+	warning := SetupConfigDir()
+	dir := ConfigDir()
+	if dir == "" || !strings.HasPrefix(dir, tmp) {
+		t.Fatalf("ConfigDir() = %q, want a fallback under %s", dir, tmp)
+	}
+	if !strings.Contains(warning, dir) || !strings.Contains(warning, EnvConfigDir) {
+		t.Errorf("warning = %q, want the fallback path and a hint about %s", warning, EnvConfigDir)
+	}
+
+	// 設定の保存と読み込みは退避先で動く
+	cfg := DefaultConfig()
+	cfg.Model = "qwen3:8b"
+	if err := cfg.SaveConfigFile(); err != nil {
+		t.Fatalf("SaveConfigFile: %v", err)
+	}
+	if got := GetConfigFilePath(); got != filepath.Join(dir, "config.json") {
+		t.Errorf("GetConfigFilePath() = %q, want the fallback config.json", got)
+	}
+	loaded := DefaultConfig()
+	loaded.ParseConfigFile()
+	if loaded.Model != "qwen3:8b" {
+		t.Errorf("reloaded Model = %q, want qwen3:8b", loaded.Model)
+	}
+
+	// セッションと履歴も退避先に保存する
+	if SessionBaseDir() != dir || HistoryPath() != filepath.Join(dir, "history") {
+		t.Errorf("SessionBaseDir() = %q, HistoryPath() = %q, want them under %s", SessionBaseDir(), HistoryPath(), dir)
+	}
+
+	// 退避先は他のユーザーから読み書きできない
+	info, err := os.Stat(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if runtime.GOOS != "windows" && info.Mode().Perm() != 0700 {
+		t.Errorf("fallback mode = %o, want 700", info.Mode().Perm())
+	}
+}
+
+func TestSetupConfigDir_RejectsSharedFallback(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fallback permissions are not checked on Windows")
+	}
+	_, tmp := setupUnwritableHome(t)
+
+	// 他のユーザーが先に作った（書き込める）ディレクトリは使わない
+	shared := filepath.Join(tmp, fmt.Sprintf("vibe-local-go-%d", os.Getuid()))
+	if err := os.Mkdir(shared, 0777); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(shared, 0777); err != nil {
+		t.Fatal(err)
+	}
+
+	warning := SetupConfigDir()
+	if ConfigDir() != "" || !strings.Contains(warning, "保存できません") {
+		t.Errorf("ConfigDir() = %q, warning = %q; want the shared directory rejected", ConfigDir(), warning)
+	}
+
+	// シンボリックリンクも使わない
+	if err := os.Remove(shared); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(t.TempDir(), shared); err != nil {
+		t.Fatal(err)
+	}
+	if SetupConfigDir(); ConfigDir() != "" {
+		t.Errorf("ConfigDir() = %q, want a symlinked fallback rejected", ConfigDir())
+	}
+}
+
+func TestSetupConfigDir_EnvOverride(t *testing.T) {
+	setupUnwritableHome(t)
+	override := filepath.Join(t.TempDir(), "vibe")
+	t.Setenv(EnvConfigDir, override)
+
+	if warning := SetupConfigDir(); warning != "" {
+		t.Errorf("unexpected warning %q", warning)
+	}
+	if ConfigDir() != override || SessionBaseDir() != override {
+		t.Errorf("ConfigDir() = %q, SessionBaseDir() = %q, want %q", ConfigDir(), SessionBaseDir(), override)
+	}
+}
+
+func TestSetupConfigDir_UnwritableEnvOverrideFallsBack(t *testing.T) {
+	home, tmp := setupUnwritableHome(t)
+	t.Setenv(EnvConfigDir, filepath.Join(home, ".config", "vibe"))
+
+	warning := SetupConfigDir()
+	if !strings.Contains(warning, EnvConfigDir) || !strings.HasPrefix(ConfigDir(), tmp) {
+		t.Errorf("warning = %q, ConfigDir() = %q; want a fallback under %s", warning, ConfigDir(), tmp)
+	}
+}
+
+func TestSetupConfigDir_WritableDefault(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv(EnvConfigDir, "")
+	t.Cleanup(func() { UseConfigDir("") })
+
+	if warning := SetupConfigDir(); warning != "" {
+		t.Errorf("unexpected warning %q", warning)
+	}
+	if ConfigDir() != "" {
+		t.Errorf("ConfigDir() = %q, want the default", ConfigDir())
+	}
+}
+
+func TestVibeConfigDir_RedirectsConfigAndSessions(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Cleanup(func() { UseConfigDir("") })
+
+	// The default location must not be read once the root is relocated
+	defaultDir := filepath.Join(home, ".config", "vibe-local-go")
+	if err := os.MkdirAll(defaultDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(defaultDir, "config.json"), []byte(`{"MODEL": "from-home"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	root := filepath.Join(t.TempDir(), "vibe")
+	t.Setenv(EnvConfigDir, root)
+	if warning := SetupConfigDir(); warning != "" {
+		t.Fatalf("unexpected warning %q", warning)
+	}
+
+	if cfg := DefaultConfig(); cfg.ParseConfigFile() == nil && cfg.Model == "from-home" {
+		t.Error("config.json under ~/.config was read despite VIBE_CONFIG_DIR")
+	}
+
+	cfg := DefaultConfig()
+	cfg.Model = "qwen3:8b"
+	if err := cfg.SaveConfigFile(); err != nil {
+		t.Fatalf("SaveConfigFile: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "config.json")); err != nil {
+		t.Errorf("config.json not written under %s: %v", root, err)
+	}
+	if got := GetConfigFilePath(); got != filepath.Join(root, "config.json") {
+		t.Errorf("GetConfigFilePath() = %q", got)
+	}
+	loaded := DefaultConfig()
+	loaded.ParseConfigFile()
+	if loaded.Model != "qwen3:8b" {
+		t.Errorf("reloaded Model = %q, want qwen3:8b", loaded.Model)
+	}
+
+	if GlobalDir() != root || SessionBaseDir() != root {
+		t.Errorf("GlobalDir() = %q, SessionBaseDir() = %q, want both %q", GlobalDir(), SessionBaseDir(), root)
+	}
+}
@@ -0,0 +1,20 @@
+//go:build !windows
+
+package config
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// checkDirOwner 退避先が自分の所有で、他のユーザーから書き込めないことを確認する
+func checkDirOwner(dir string, info os.FileInfo) error {
+	if st, ok := info.Sys().(*syscall.Stat_t); ok && int(st.Uid) != os.Getuid() {
+		return fmt.Errorf("%s は他のユーザーが所有しています", dir)
+	}
+	if perm := info.Mode().Perm(); perm&0077 != 0 {
+		return fmt.Errorf("%s の権限が %o です（0700 が必要）", dir, perm)
+	}
+	return nil
+}
@@ -5,16 +5,56 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 )
 
 // ProviderProfile プロバイダー固有の設定プロファイル
 type ProviderProfile struct {
-	Type        string  `json:"type"`                  // "ollama", "openrouter", "openai", "anthropic", "google"
-	Host        string  `json:"host,omitempty"`        // ベースURL（Ollama等）
-	APIKey      string  `json:"api_key,omitempty"`     // クラウドプロバイダー用APIキー
-	Model       string  `json:"model,omitempty"`       // デフォルトモデル名
-	MaxTokens   int     `json:"max_tokens,omitempty"`  // プロバイダー固有のmax_tokens
-	Temperature float64 `json:"temperature,omitempty"` // プロバイダー固有のtemperature
+	Type        string   `json:"type"`                  // "ollama", "openrouter", "openai", "anthropic", "google"
+	Host        string   `json:"host,omitempty"`        // ベースURL（Ollama等）
+	APIKey      string   `json:"api_key,omitempty"`     // クラウドプロバイダー用APIキー
+	Model       string   `json:"model,omitempty"`       // デフォルトモデル名
+	Sidecar     string   `json:"sidecar,omitempty"`     // サイドカーモデル名（/sidecar で保存、SidecarOff = 使わない）
+	MaxTokens   int      `json:"max_tokens,omitempty"`  // プロバイダー固有のmax_tokens
+	Temperature *float64 `json:"temperature,omitempty"` // プロバイダー固有のtemperature（nil = グローバル設定、0 も指定可）
+}
+
+// SidecarOff プロファイルの sidecar に保存すると、グローバルの SIDECAR_MODEL があってもサイドカーを使わない
+const SidecarOff = "off"
+
+// MaxProfileTemperature プロバイダープロファイルに設定できる temperature の上限
+const MaxProfileTemperature = 2.0
+
+// ParseProfileMaxTokens プロファイルの max_tokens 入力を検証する（0 = グローバル設定を使う）
+func ParseProfileMaxTokens(s string) (int, error) {
+	n, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("max_tokens は整数で指定してください: %q", s)
+	}
+	if n < 0 {
+		return 0, fmt.Errorf("max_tokens は正の数で指定してください（0 = グローバル設定）: %d", n)
+	}
+	return n, nil
+}
+
+// ProfileParamUnset プロファイルの temperature 入力でグローバル設定に戻す指定
+const ProfileParamUnset = "-"
+
+// ParseProfileTemperature プロファイルの temperature 入力を検証する（"-" = グローバル設定を使う、nil を返す）
+func ParseProfileTemperature(s string) (*float64, error) {
+	s = strings.TrimSpace(s)
+	if s == ProfileParamUnset {
+		return nil, nil
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return nil, fmt.Errorf("temperature は数値で指定してください: %q", s)
+	}
+	if !(f >= 0 && f <= MaxProfileTemperature) { // NaN も弾く
+		return nil, fmt.Errorf("temperature は 0〜%g の範囲で指定してください: %g", MaxProfileTemperature, f)
+	}
+	return &f, nil
 }
 
 // ConfigFile represents the JSON config file structure
@@ -22,6 +62,7 @@ type ConfigFile struct {
 	// 既存フィールド（後方互換）
 	Model         string  `json:"MODEL,omitempty"`
 	SidecarModel  string  `json:"SIDECAR_MODEL,omitempty"`
+	EmbedModel    string  `json:"EMBEDDING_MODEL,omitempty"`
 	OllamaHost    string  `json:"OLLAMA_HOST,omitempty"`
 	MaxTokens     int     `json:"MAX_TOKENS,omitempty"`
 	Temperature   float64 `json:"TEMPERATURE,omitempty"`
@@ -31,9 +72,51 @@ type ConfigFile struct {
 	OllamaNumCtx int `json:"OLLAMA_NUM_CTX,omitempty"`
 	OllamaNumGPU int `json:"OLLAMA_NUM_GPU,omitempty"`
 
+	// 表示設定: "full" / "minimal" / "none"
+	Banner string `json:"BANNER,omitempty"`
+	// アシスタント出力の折り返し幅（0 = 端末幅）
+	OutputWidth int `json:"OUTPUT_WIDTH,omitempty"`
+	// オフラインモード（ネットワークツール・クラウドプロバイダーを無効化）
+	Offline bool `json:"OFFLINE,omitempty"`
+	// 1ターンのリトライ合計の上限
+	RetryBudget int `json:"RETRY_BUDGET,omitempty"`
+	// クラウドプロバイダーのトークン予算（超えたら確認）
+	BudgetTokens int `json:"BUDGET_TOKENS,omitempty"`
+	// bash のネットワークアクセスを禁止
+	NoNetwork bool `json:"NO_NETWORK,omitempty"`
+	// 危険なコマンドのパターンによるブロックを無効化（使い捨て環境向け）
+	AllowDangerous bool `json:"ALLOW_DANGEROUS,omitempty"`
+	// 表示言語 (ja / en)
+	Lang string `json:"LANG,omitempty"`
+	// 作業ディレクトリ外への書き込みを許可
+	AllowOutsideWorkdir bool `json:"ALLOW_OUTSIDE_WORKDIR,omitempty"`
+	// .vibeignore を無視
+	NoVibeIgnore bool `json:"NO_VIBEIGNORE,omitempty"`
+	// 独自のシステムプロンプトファイルと使い方 (replace / prepend)
+	SystemPromptFile string `json:"SYSTEM_PROMPT_FILE,omitempty"`
+	SystemPromptMode string `json:"SYSTEM_PROMPT_MODE,omitempty"`
+	// 自動テストのコマンド（プロジェクト設定でも指定可）
+	AutoTestCommand string `json:"AUTOTEST_COMMAND,omitempty"`
+	// glob/grep の再帰探索の深さ上限
+	MaxSearchDepth int `json:"MAX_SEARCH_DEPTH,omitempty"`
+	// bash/read_file/grep/web_fetch の出力の上限バイト数
+	ToolOutputMaxBytes int `json:"TOOL_OUTPUT_MAX_BYTES,omitempty"`
+	// 自動圧縮を始めるコンテキスト使用率 (例: 0.8)
+	CompactThreshold float64 `json:"COMPACT_THRESHOLD,omitempty"`
+	// 自動圧縮を無効化
+	NoAutoCompact bool `json:"NO_AUTO_COMPACT,omitempty"`
+	// web_search のキャッシュ件数（負の値で無効）
+	WebSearchCacheSize int `json:"WEB_SEARCH_CACHE_SIZE,omitempty"`
+	// ループ検出で追跡するツール呼び出し数
+	LoopHistorySize int `json:"LOOP_HISTORY_SIZE,omitempty"`
+	// 同じツール・同じ引数の呼び出しをループとみなす回数
+	LoopThreshold int `json:"LOOP_THRESHOLD,omitempty"`
+
 	// マルチプロバイダー設定
 	Provider  string                     `json:"PROVIDER,omitempty"`
 	Providers map[string]ProviderProfile `json:"PROVIDERS,omitempty"`
+	// プロバイダーチェーンの順序（先頭がメイン、以降がフォールバック）
+	Chain []string `json:"CHAIN,omitempty"`
 }
 
 // configFilePaths config.json の探索パス（優先順）
@@ -51,8 +134,7 @@ const defaultConfigPath = "~/.config/vibe-local-go/config.json"
 // ParseConfigFile reads and parses the config file
 func (c *Config) ParseConfigFile() error {
 	var lastErr error
-	for _, configPath := range configFilePaths {
-		expandedPath := expandPath(configPath)
+	for _, expandedPath := range configSearchPaths() {
 		if _, err := os.Stat(expandedPath); os.IsNotExist(err) {
 			continue
 		}
@@ -65,7 +147,7 @@ func (c *Config) ParseConfigFile() error {
 
 		var cf ConfigFile
 		if err := json.Unmarshal(file, &cf); err != nil {
-			lastErr = fmt.Errorf("failed to parse config file %s: %w", configPath, err)
+			lastErr = fmt.Errorf("failed to parse config file %s: %w", expandedPath, err)
 			continue
 		}
 
@@ -88,33 +170,132 @@ func (c *Config) applyConfigFile(cf *ConfigFile) {
 	// --- 既存フィールド（後方互換） ---
 	if cf.Model != "" {
 		c.Model = cf.Model
+		c.SetSource("MODEL", SourceConfig)
 		c.AutoModel = false
 	}
 	if cf.SidecarModel != "" {
 		c.SidecarModel = cf.SidecarModel
+		c.SetSource("SIDECAR_MODEL", SourceConfig)
+	}
+	if cf.EmbedModel != "" {
+		c.EmbedModel = cf.EmbedModel
+		c.SetSource("EMBEDDING_MODEL", SourceConfig)
 	}
 	if cf.OllamaHost != "" {
 		c.OllamaHost = cf.OllamaHost
+		c.SetSource("OLLAMA_HOST", SourceConfig)
 	}
 	if cf.MaxTokens > 0 {
 		c.MaxTokens = cf.MaxTokens
+		c.SetSource("MAX_TOKENS", SourceConfig)
 	}
 	if cf.Temperature > 0 {
 		c.Temperature = cf.Temperature
+		c.SetSource("TEMPERATURE", SourceConfig)
 	}
 	if cf.ContextWindow > 0 {
 		c.ContextWindow = cf.ContextWindow
+		c.SetSource("CONTEXT_WINDOW", SourceConfig)
 	}
 	if cf.OllamaNumCtx > 0 {
 		c.OllamaNumCtx = cf.OllamaNumCtx
+		c.SetSource("OLLAMA_NUM_CTX", SourceConfig)
 	}
 	if cf.OllamaNumGPU > 0 {
 		c.OllamaNumGPU = cf.OllamaNumGPU
+		c.SetSource("OLLAMA_NUM_GPU", SourceConfig)
+	}
+
+	switch cf.Banner {
+	case BannerFull, BannerMinimal, BannerNone:
+		c.Banner = cf.Banner
+		c.SetSource("BANNER", SourceConfig)
+	}
+	if cf.OutputWidth > 0 {
+		c.OutputWidth = cf.OutputWidth
+		c.SetSource("OUTPUT_WIDTH", SourceConfig)
+	}
+	if cf.Offline {
+		c.Offline = true
+		c.SetSource("OFFLINE", SourceConfig)
+	}
+	if cf.RetryBudget > 0 {
+		c.RetryBudget = cf.RetryBudget
+		c.SetSource("RETRY_BUDGET", SourceConfig)
+	}
+	if cf.BudgetTokens > 0 {
+		c.BudgetTokens = cf.BudgetTokens
+		c.SetSource("BUDGET_TOKENS", SourceConfig)
+	}
+	if cf.NoNetwork {
+		c.NoNetwork = true
+		c.SetSource("NO_NETWORK", SourceConfig)
+	}
+	if cf.AllowDangerous {
+		c.AllowDangerous = true
+		c.SetSource("ALLOW_DANGEROUS", SourceConfig)
+	}
+	if cf.Lang != "" {
+		c.Lang = cf.Lang
+		c.SetSource("LANG", SourceConfig)
+	}
+	if cf.AllowOutsideWorkdir {
+		c.AllowOutsideWorkdir = true
+		c.SetSource("ALLOW_OUTSIDE_WORKDIR", SourceConfig)
+	}
+	if cf.NoVibeIgnore {
+		c.NoVibeIgnore = true
+		c.SetSource("NO_VIBEIGNORE", SourceConfig)
+	}
+	if cf.SystemPromptFile != "" {
+		c.SystemPromptFile = cf.SystemPromptFile
+		c.SetSource("SYSTEM_PROMPT_FILE", SourceConfig)
+	}
+	if cf.SystemPromptMode != "" {
+		c.SystemPromptMode = cf.SystemPromptMode
+		c.SetSource("SYSTEM_PROMPT_MODE", SourceConfig)
+	}
+	if cf.AutoTestCommand != "" {
+		c.AutoTestCommand = cf.AutoTestCommand
+		c.SetSource("AUTOTEST_COMMAND", SourceConfig)
+	}
+	if cf.MaxSearchDepth > 0 {
+		c.MaxSearchDepth = cf.MaxSearchDepth
+		c.SetSource("MAX_SEARCH_DEPTH", SourceConfig)
+	}
+	if cf.ToolOutputMaxBytes > 0 {
+		c.ToolOutputMaxBytes = cf.ToolOutputMaxBytes
+		c.SetSource("TOOL_OUTPUT_MAX_BYTES", SourceConfig)
+	}
+	if cf.CompactThreshold > 0 && cf.CompactThreshold <= 1 {
+		c.CompactThreshold = cf.CompactThreshold
+		c.SetSource("COMPACT_THRESHOLD", SourceConfig)
+	}
+	if cf.NoAutoCompact {
+		c.NoAutoCompact = true
+		c.SetSource("NO_AUTO_COMPACT", SourceConfig)
+	}
+	if cf.WebSearchCacheSize != 0 {
+		c.WebSearchCacheSize = cf.WebSearchCacheSize
+		c.SetSource("WEB_SEARCH_CACHE_SIZE", SourceConfig)
+	}
+	if cf.LoopHistorySize > 0 {
+		c.LoopHistorySize = cf.LoopHistorySize
+		c.SetSource("LOOP_HISTORY_SIZE", SourceConfig)
+	}
+	if cf.LoopThreshold > 0 {
+		c.LoopThreshold = cf.LoopThreshold
+		c.SetSource("LOOP_THRESHOLD", SourceConfig)
 	}
 
 	// --- プロバイダー設定 ---
 	if cf.Provider != "" {
 		c.Provider = cf.Provider
+		c.SetSource("PROVIDER", SourceConfig)
+	}
+	if len(cf.Chain) > 0 {
+		c.Chain = cf.Chain
+		c.SetSource("CHAIN", SourceConfig)
 	}
 
 	// アクティブプロバイダーのプロファイルを適用
@@ -126,18 +307,54 @@ func (c *Config) applyConfigFile(cf *ConfigFile) {
 	}
 }
 
+// ProjectConfigFile プロジェクト固有の設定ファイル（作業ディレクトリからの相対パス）
+var ProjectConfigFile = filepath.Join(".vibe-local", "config.json")
+
+// ParseProjectConfig dir のプロジェクト設定を読み込む（ファイルがなければ何もしない）
+// グローバルの config.json より優先する。読むのはプロジェクトごとに異なる項目（AUTOTEST_COMMAND）だけ
+func (c *Config) ParseProjectConfig(dir string) error {
+	path := filepath.Join(dir, ProjectConfigFile)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	var cf ConfigFile
+	if err := json.Unmarshal(data, &cf); err != nil {
+		return fmt.Errorf("failed to parse project config %s: %w", path, err)
+	}
+
+	if cf.AutoTestCommand != "" {
+		c.AutoTestCommand = cf.AutoTestCommand
+		c.SetSource("AUTOTEST_COMMAND", SourceProject)
+	}
+	return nil
+}
+
 // applyProviderProfile プロバイダープロファイルの値を Config に反映
 func (c *Config) applyProviderProfile(p *ProviderProfile) {
 	// モデル設定（全プロバイダー共通）
 	if p.Model != "" {
 		c.Model = p.Model
+		c.SetSource("MODEL", SourceConfig)
 		c.AutoModel = false
 	}
+	if p.Sidecar == SidecarOff {
+		c.SidecarModel = ""
+		c.SetSource("SIDECAR_MODEL", SourceConfig)
+	} else if p.Sidecar != "" {
+		c.SidecarModel = p.Sidecar
+		c.SetSource("SIDECAR_MODEL", SourceConfig)
+	}
 
 	// プロバイダー固有設定
 	if p.Type == "ollama" {
 		if p.Host != "" {
 			c.OllamaHost = p.Host
+			c.SetSource("OLLAMA_HOST", SourceConfig)
 		}
 	} else {
 		// クラウドプロバイダー: APIキーをmapに格納
@@ -152,15 +369,88 @@ func (c *Config) applyProviderProfile(p *ProviderProfile) {
 	// プロバイダー固有のLLMパラメータ（グローバル設定より優先）
 	if p.MaxTokens > 0 {
 		c.MaxTokens = p.MaxTokens
+		c.SetSource("MAX_TOKENS", SourceConfig)
+	}
+	if p.Temperature != nil {
+		c.Temperature = *p.Temperature
+		c.SetSource("TEMPERATURE", SourceConfig)
+	}
+}
+
+// ApplyProfileParams は編集したアクティブプロバイダーのプロファイルの max_tokens / temperature を
+// 実行中の設定に反映する（cleared* = プロファイルの値を解除した）。
+// 起動時と同じ優先順位で決めるので、環境変数・フラグで指定した値はプロファイルより優先して残し、
+// 解除した値は config.json のグローバル設定（なければ既定値）に戻す
+func (c *Config) ApplyProfileParams(p ProviderProfile, clearedMaxTokens, clearedTemperature bool) {
+	var cf *ConfigFile
+	global := func() *ConfigFile {
+		if cf == nil {
+			saved := readConfigForSave(configSavePath())
+			cf = &saved
+		}
+		return cf
+	}
+
+	if p.MaxTokens > 0 || clearedMaxTokens {
+		switch {
+		case c.restoreOverride("MAX_TOKENS"):
+		case p.MaxTokens > 0:
+			c.MaxTokens = p.MaxTokens
+			c.SetSource("MAX_TOKENS", SourceConfig)
+		case global().MaxTokens > 0:
+			c.MaxTokens = global().MaxTokens
+			c.SetSource("MAX_TOKENS", SourceConfig)
+		default:
+			c.MaxTokens = DefaultMaxTokens
+			delete(c.sources, "MAX_TOKENS")
+		}
+	}
+
+	if p.Temperature != nil || clearedTemperature {
+		switch {
+		case c.restoreOverride("TEMPERATURE"):
+		case p.Temperature != nil:
+			c.Temperature = *p.Temperature
+			c.SetSource("TEMPERATURE", SourceConfig)
+		case global().Temperature > 0:
+			c.Temperature = global().Temperature
+			c.SetSource("TEMPERATURE", SourceConfig)
+		default:
+			c.Temperature = DefaultTemperature
+			delete(c.sources, "TEMPERATURE")
+		}
 	}
-	if p.Temperature > 0 {
-		c.Temperature = p.Temperature
+}
+
+// restoreOverride は環境変数・フラグで指定した key の値に戻す（指定がなければ false）
+func (c *Config) restoreOverride(key string) bool {
+	rec, ok := c.overrides[key]
+	if !ok {
+		return false
+	}
+	switch key {
+	case "MAX_TOKENS":
+		n, err := strconv.Atoi(rec.value)
+		if err != nil {
+			return false
+		}
+		c.MaxTokens = n
+	case "TEMPERATURE":
+		f, err := strconv.ParseFloat(rec.value, 64)
+		if err != nil {
+			return false
+		}
+		c.Temperature = f
+	default:
+		return false
 	}
+	c.SetSource(key, rec.source)
+	return true
 }
 
 // SaveConfigFile 現在の設定を config.json に保存
 func (c *Config) SaveConfigFile() error {
-	savePath := expandPath(defaultConfigPath)
+	savePath := configSavePath()
 
 	// ディレクトリを作成
 	dir := filepath.Dir(savePath)
@@ -169,10 +459,7 @@ func (c *Config) SaveConfigFile() error {
 	}
 
 	// 既存ファイルを読み込んでマージ（存在する場合）
-	var cf ConfigFile
-	if data, err := os.ReadFile(savePath); err == nil {
-		json.Unmarshal(data, &cf) // エラーは無視（新規作成扱い）
-	}
+	cf := readConfigForSave(savePath)
 
 	// Providers マップの初期化
 	if cf.Providers == nil {
@@ -221,19 +508,31 @@ func (c *Config) SaveConfigFile() error {
 
 // GetConfigFilePath 現在使用中の設定ファイルパスを返す
 func GetConfigFilePath() string {
-	for _, configPath := range configFilePaths {
-		expandedPath := expandPath(configPath)
+	for _, expandedPath := range configSearchPaths() {
 		if _, err := os.Stat(expandedPath); err == nil {
 			return expandedPath
 		}
 	}
-	return expandPath(defaultConfigPath)
+	return configSavePath()
+}
+
+// readConfigForSave は保存前に既存の設定を読み込む。
+// 保存先にまだ無い場合（退避先へ切り替えた直後など）は現在使用中のファイルを引き継ぐ
+func readConfigForSave(savePath string) ConfigFile {
+	var cf ConfigFile
+	data, err := os.ReadFile(savePath)
+	if err != nil {
+		data, err = os.ReadFile(GetConfigFilePath())
+	}
+	if err == nil {
+		json.Unmarshal(data, &cf) // エラーは無視（新規作成扱い）
+	}
+	return cf
 }
 
 // GetProviderProfiles config.json からプロバイダー一覧を取得
 func (c *Config) GetProviderProfiles() map[string]ProviderProfile {
-	for _, configPath := range configFilePaths {
-		expandedPath := expandPath(configPath)
+	for _, expandedPath := range configSearchPaths() {
 		data, err := os.ReadFile(expandedPath)
 		if err != nil {
 			continue
@@ -254,7 +553,7 @@ func (c *Config) GetProviderProfiles() map[string]ProviderProfile {
 
 // DeleteProviderProfile config.json からプロバイダープロファイルを削除
 func (c *Config) DeleteProviderProfile(key string) error {
-	savePath := expandPath(defaultConfigPath)
+	savePath := configSavePath()
 
 	data, err := os.ReadFile(savePath)
 	if err != nil {
@@ -291,17 +590,14 @@ func (c *Config) DeleteProviderProfile(key string) error {
 
 // SaveProviderProfile 指定プロバイダーのプロファイルを config.json に保存（他を上書きしない）
 func (c *Config) SaveProviderProfile(key string, profile ProviderProfile) error {
-	savePath := expandPath(defaultConfigPath)
+	savePath := configSavePath()
 
 	dir := filepath.Dir(savePath)
 	if err := os.MkdirAll(dir, 0755); err != nil {
 		return fmt.Errorf("failed to create config directory: %w", err)
 	}
 
-	var cf ConfigFile
-	if data, err := os.ReadFile(savePath); err == nil {
-		json.Unmarshal(data, &cf)
-	}
+	cf := readConfigForSave(savePath)
 
 	if cf.Providers == nil {
 		cf.Providers = make(map[string]ProviderProfile)
@@ -331,6 +331,7 @@ func TestParseConfigFile_InvalidJSON(t *testing.T) {
 
 func TestApplyConfigFile_ProviderOverridesGlobal(t *testing.T) {
 	cfg := DefaultConfig()
+	temperature := 0.2
 
 	cf := &ConfigFile{
 		MaxTokens:   8192,
@@ -342,7 +343,7 @@ func TestApplyConfigFile_ProviderOverridesGlobal(t *testing.T) {
 				APIKey:      "test-key",
 				Model:       "meta-llama/llama-3.1-70b",
 				MaxTokens:   65536,
-				Temperature: 0.2,
+				Temperature: &temperature,
 			},
 		},
 	}
@@ -363,6 +364,24 @@ func TestApplyConfigFile_ProviderOverridesGlobal(t *testing.T) {
 
 // --- SaveConfigFile → ParseConfigFile ラウンドトリップ ---
 
+func TestApplyConfigFile_Banner(t *testing.T) {
+	cfg := DefaultConfig()
+	if cfg.Banner != BannerFull {
+		t.Fatalf("default Banner = %q, want %q", cfg.Banner, BannerFull)
+	}
+
+	cfg.applyConfigFile(&ConfigFile{Banner: BannerMinimal})
+	if cfg.Banner != BannerMinimal {
+		t.Errorf("Banner = %q, want %q", cfg.Banner, BannerMinimal)
+	}
+
+	// 不正な値は無視
+	cfg.applyConfigFile(&ConfigFile{Banner: "loud"})
+	if cfg.Banner != BannerMinimal {
+		t.Errorf("Banner = %q, want %q (invalid value ignored)", cfg.Banner, BannerMinimal)
+	}
+}
+
 func TestSaveAndReload_RoundTrip(t *testing.T) {
 	tmpDir := t.TempDir()
 	savePath := filepath.Join(tmpDir, "config.json")
@@ -476,3 +495,270 @@ func TestGetProviderProfiles(t *testing.T) {
 		t.Errorf("openrouter api_key = %q, want %q", or.APIKey, "sk-test")
 	}
 }
+
+// --- 設定値の出どころ ---
+
+func TestEffectiveSettings_Sources(t *testing.T) {
+	t.Setenv("VIBE_CODER_TEMPERATURE", "0.4")
+	cfg, _ := setupTestConfig(t, `{
+		"MODEL": "qwen3:8b",
+		"MAX_TOKENS": 4096
+	}`)
+	cfg.ParseEnv()
+
+	// main の loadConfig と同じ手順でフラグを反映
+	cfg.MaxTokens = 2048
+	cfg.SetSource("MAX_TOKENS", SourceFlag)
+
+	want := map[string][2]string{
+		"MODEL":          {"qwen3:8b", SourceConfig},
+		"MAX_TOKENS":     {"2048", SourceFlag},
+		"TEMPERATURE":    {"0.4", SourceEnv},
+		"CONTEXT_WINDOW": {"32768", SourceDefault},
+	}
+	for _, s := range cfg.EffectiveSettings() {
+		if w, ok := want[s.Key]; ok {
+			if s.Value != w[0] || s.Source != w[1] {
+				t.Errorf("%s = %q [%s], want %q [%s]", s.Key, s.Value, s.Source, w[0], w[1])
+			}
+			delete(want, s.Key)
+		}
+	}
+	for key := range want {
+		t.Errorf("%s missing from EffectiveSettings", key)
+	}
+
+	// 起動後の変更は runtime
+	cfg.Model = "llama3:8b"
+	if got := cfg.Source("MODEL"); got != SourceRuntime {
+		t.Errorf("Source(MODEL) after change = %q, want %q", got, SourceRuntime)
+	}
+	cfg.AutoApprove = true
+	if got := cfg.Source("AUTO_APPROVE"); got != SourceRuntime {
+		t.Errorf("Source(AUTO_APPROVE) after change = %q, want %q", got, SourceRuntime)
+	}
+}
+
+func TestAllowDangerous_ConfigFile(t *testing.T) {
+	cfg, _ := setupTestConfig(t, `{}`)
+	if cfg.AllowDangerous || cfg.Source("ALLOW_DANGEROUS") != SourceDefault {
+		t.Errorf("ALLOW_DANGEROUS = %v [%s], want off by default", cfg.AllowDangerous, cfg.Source("ALLOW_DANGEROUS"))
+	}
+
+	cfg, _ = setupTestConfig(t, `{"ALLOW_DANGEROUS": true}`)
+	if !cfg.AllowDangerous || cfg.Source("ALLOW_DANGEROUS") != SourceConfig {
+		t.Errorf("ALLOW_DANGEROUS = %v [%s], want true from config", cfg.AllowDangerous, cfg.Source("ALLOW_DANGEROUS"))
+	}
+}
+
+func TestEffectiveSettings_ProviderProfileIsConfig(t *testing.T) {
+	cfg, _ := setupTestConfig(t, `{
+		"PROVIDER": "openai",
+		"PROVIDERS": {"openai": {"type": "openai", "model": "gpt-4o", "temperature": 0.3}}
+	}`)
+
+	for key, want := range map[string]string{"PROVIDER": SourceConfig, "MODEL": SourceConfig, "TEMPERATURE": SourceConfig, "MAX_TOKENS": SourceDefault} {
+		if got := cfg.Source(key); got != want {
+			t.Errorf("Source(%s) = %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestProviderProfile_SidecarOffOverridesGlobal(t *testing.T) {
+	cfg, _ := setupTestConfig(t, `{
+		"PROVIDER": "ollama",
+		"SIDECAR_MODEL": "qwen3:1.7b",
+		"PROVIDERS": {"ollama": {"type": "ollama", "sidecar": "off"}}
+	}`)
+
+	if cfg.SidecarModel != "" {
+		t.Errorf("SidecarModel = %q, want empty (profile turned the sidecar off)", cfg.SidecarModel)
+	}
+}
+
+func TestSaveProviderProfile_ParamsRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	UseConfigDir(dir)
+	t.Cleanup(func() { UseConfigDir("") })
+
+	cfg := DefaultConfig()
+	cfg.Provider = "openai"
+	if err := cfg.SaveConfigFile(); err != nil {
+		t.Fatalf("SaveConfigFile: %v", err)
+	}
+
+	// /provider edit で入力された値を検証して保存する
+	maxTokens, err := ParseProfileMaxTokens(" 16384 ")
+	if err != nil {
+		t.Fatalf("ParseProfileMaxTokens: %v", err)
+	}
+	temperature, err := ParseProfileTemperature("1.5")
+	if err != nil {
+		t.Fatalf("ParseProfileTemperature: %v", err)
+	}
+	profile := ProviderProfile{Type: "openai", Model: "gpt-4o", MaxTokens: maxTokens, Temperature: temperature}
+	if err := cfg.SaveProviderProfile("openai", profile); err != nil {
+		t.Fatalf("SaveProviderProfile: %v", err)
+	}
+
+	got := cfg.GetProviderProfiles()["openai"]
+	if got.Model != profile.Model || got.MaxTokens != profile.MaxTokens || got.Temperature == nil || *got.Temperature != 1.5 {
+		t.Errorf("saved profile = %+v, want %+v", got, profile)
+	}
+
+	reloaded := DefaultConfig()
+	if err := reloaded.ParseConfigFile(); err != nil {
+		t.Fatalf("ParseConfigFile: %v", err)
+	}
+	if reloaded.MaxTokens != 16384 {
+		t.Errorf("MaxTokens after reload = %d, want 16384", reloaded.MaxTokens)
+	}
+	if reloaded.Temperature != 1.5 {
+		t.Errorf("Temperature after reload = %g, want 1.5", reloaded.Temperature)
+	}
+}
+
+func TestParseProfileParams_Ranges(t *testing.T) {
+	for _, input := range []string{"-1", "abc", "1.5"} {
+		if _, err := ParseProfileMaxTokens(input); err == nil {
+			t.Errorf("ParseProfileMaxTokens(%q) should fail", input)
+		}
+	}
+	if n, err := ParseProfileMaxTokens("0"); err != nil || n != 0 {
+		t.Errorf("ParseProfileMaxTokens(0) = %d, %v (0 resets to the global setting)", n, err)
+	}
+
+	for _, input := range []string{"-0.1", "2.01", "NaN", "hot"} {
+		if _, err := ParseProfileTemperature(input); err == nil {
+			t.Errorf("ParseProfileTemperature(%q) should fail", input)
+		}
+	}
+	for _, input := range []string{"0", "0.7", "2"} {
+		if f, err := ParseProfileTemperature(input); err != nil || f == nil {
+			t.Errorf("ParseProfileTemperature(%q) = %v, %v", input, f, err)
+		}
+	}
+	if f, err := ParseProfileTemperature(ProfileParamUnset); err != nil || f != nil {
+		t.Errorf("ParseProfileTemperature(%q) = %v, %v (resets to the global setting)", ProfileParamUnset, f, err)
+	}
+}
+
+func TestProviderProfile_TemperatureZero(t *testing.T) {
+	// 0 はグローバル設定ではなく temperature 0 として適用される
+	zero := 0.0
+	cfg := DefaultConfig()
+	cfg.applyConfigFile(&ConfigFile{
+		Temperature: 0.7,
+		Provider:    "openai",
+		Providers:   map[string]ProviderProfile{"openai": {Type: "openai", Temperature: &zero}},
+	})
+	if cfg.Temperature != 0 || cfg.Source("TEMPERATURE") != SourceConfig {
+		t.Errorf("Temperature = %g (%s), want 0 from the profile", cfg.Temperature, cfg.Source("TEMPERATURE"))
+	}
+}
+
+func TestApplyProfileParams(t *testing.T) {
+	dir := t.TempDir()
+	UseConfigDir(dir)
+	t.Cleanup(func() { UseConfigDir("") })
+
+	if err := os.WriteFile(filepath.Join(dir, "config.json"), []byte(`{"TEMPERATURE": 0.5}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// プロファイルの値はそのまま反映される
+	cfg := DefaultConfig()
+	temp := 1.5
+	cfg.ApplyProfileParams(ProviderProfile{MaxTokens: 32768, Temperature: &temp}, false, false)
+	if cfg.MaxTokens != 32768 || cfg.Temperature != 1.5 {
+		t.Errorf("after apply = (%d, %g), want (32768, 1.5)", cfg.MaxTokens, cfg.Temperature)
+	}
+	if cfg.Source("TEMPERATURE") != SourceConfig {
+		t.Errorf("TEMPERATURE source = %s, want %s", cfg.Source("TEMPERATURE"), SourceConfig)
+	}
+
+	// プロファイルの値を解除すると config.json（なければ既定値）に戻る
+	cfg.ApplyProfileParams(ProviderProfile{}, true, true)
+	if cfg.Temperature != 0.5 || cfg.MaxTokens != DefaultMaxTokens {
+		t.Errorf("after restore = (%d, %g), want (%d, 0.5)", cfg.MaxTokens, cfg.Temperature, DefaultMaxTokens)
+	}
+	if cfg.Source("MAX_TOKENS") != SourceDefault {
+		t.Errorf("MAX_TOKENS source = %s, want %s", cfg.Source("MAX_TOKENS"), SourceDefault)
+	}
+
+	// 解除していない値はそのまま
+	cfg.MaxTokens = 1000
+	cfg.ApplyProfileParams(ProviderProfile{}, false, true)
+	if cfg.MaxTokens != 1000 {
+		t.Errorf("MaxTokens = %d, want it untouched", cfg.MaxTokens)
+	}
+}
+
+func TestApplyProfileParams_KeepsFlagAndEnv(t *testing.T) {
+	dir := t.TempDir()
+	UseConfigDir(dir)
+	t.Cleanup(func() { UseConfigDir("") })
+
+	if err := os.WriteFile(filepath.Join(dir, "config.json"), []byte(`{"MAX_TOKENS": 4096, "TEMPERATURE": 0.5}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// 起動時と同じ順序: 環境変数 → フラグ
+	cfg := DefaultConfig()
+	cfg.Temperature = 0.2
+	cfg.SetSource("TEMPERATURE", SourceEnv)
+	cfg.MaxTokens = 2048
+	cfg.SetSource("MAX_TOKENS", SourceFlag)
+
+	// プロファイルで値を設定してもフラグ・環境変数が優先される
+	temp := 1.5
+	cfg.ApplyProfileParams(ProviderProfile{MaxTokens: 32768, Temperature: &temp}, false, false)
+	if cfg.MaxTokens != 2048 || cfg.Temperature != 0.2 {
+		t.Errorf("after set = (%d, %g), want (2048, 0.2)", cfg.MaxTokens, cfg.Temperature)
+	}
+	if cfg.Source("MAX_TOKENS") != SourceFlag || cfg.Source("TEMPERATURE") != SourceEnv {
+		t.Errorf("sources = (%s, %s), want (%s, %s)", cfg.Source("MAX_TOKENS"), cfg.Source("TEMPERATURE"), SourceFlag, SourceEnv)
+	}
+
+	// 解除しても config.json ではなくフラグ・環境変数の値に戻る
+	cfg.MaxTokens, cfg.Temperature = 1, 1
+	cfg.ApplyProfileParams(ProviderProfile{}, true, true)
+	if cfg.MaxTokens != 2048 || cfg.Temperature != 0.2 {
+		t.Errorf("after clear = (%d, %g), want (2048, 0.2)", cfg.MaxTokens, cfg.Temperature)
+	}
+	if cfg.Source("MAX_TOKENS") != SourceFlag || cfg.Source("TEMPERATURE") != SourceEnv {
+		t.Errorf("sources = (%s, %s), want (%s, %s)", cfg.Source("MAX_TOKENS"), cfg.Source("TEMPERATURE"), SourceFlag, SourceEnv)
+	}
+}
+
+func TestParseProjectConfig_AutoTestCommand(t *testing.T) {
+	cfg, _ := setupTestConfig(t, `{"AUTOTEST_COMMAND": "go test ./..."}`)
+	if cfg.AutoTestCommand != "go test ./..." {
+		t.Fatalf("AutoTestCommand from config.json = %q", cfg.AutoTestCommand)
+	}
+
+	// Missing project config leaves the global value alone
+	project := t.TempDir()
+	if err := cfg.ParseProjectConfig(project); err != nil {
+		t.Fatalf("ParseProjectConfig without a file: %v", err)
+	}
+	if cfg.AutoTestCommand != "go test ./..." {
+		t.Errorf("AutoTestCommand = %q, want the global value", cfg.AutoTestCommand)
+	}
+
+	os.MkdirAll(filepath.Join(project, ".vibe-local"), 0755)
+	os.WriteFile(filepath.Join(project, ProjectConfigFile), []byte(`{"AUTOTEST_COMMAND": "make test", "MODEL": "ignored"}`), 0644)
+	if err := cfg.ParseProjectConfig(project); err != nil {
+		t.Fatalf("ParseProjectConfig: %v", err)
+	}
+	if cfg.AutoTestCommand != "make test" {
+		t.Errorf("AutoTestCommand = %q, want the project value", cfg.AutoTestCommand)
+	}
+	if cfg.Model == "ignored" {
+		t.Error("project config should only override project-specific settings")
+	}
+	// リポジトリ由来の値はユーザーの config.json と区別する
+	if got := cfg.Source("AUTOTEST_COMMAND"); got != SourceProject {
+		t.Errorf("Source(AUTOTEST_COMMAND) = %q, want %q", got, SourceProject)
+	}
+}
@@ -8,9 +8,68 @@ import (
 	"strings"
 )
 
+// MaxSystemPromptFileSize システムプロンプトファイルの上限サイズ
+const MaxSystemPromptFileSize = 100 * 1024
+
+// LoadSystemPromptFile cfg.SystemPromptFile を読み込み cfg.SystemPromptText に設定する。
+// ファイルがない・空・大きすぎる場合と、モードが不正な場合はエラー
+func LoadSystemPromptFile(cfg *Config) error {
+	if cfg.SystemPromptFile == "" {
+		return nil
+	}
+	switch cfg.SystemPromptMode {
+	case "", SystemPromptReplace, SystemPromptPrepend:
+	default:
+		return fmt.Errorf("system prompt mode must be %s or %s: %q", SystemPromptReplace, SystemPromptPrepend, cfg.SystemPromptMode)
+	}
+
+	info, err := os.Stat(cfg.SystemPromptFile)
+	if err != nil {
+		return fmt.Errorf("system prompt file: %w", err)
+	}
+	if info.IsDir() {
+		return fmt.Errorf("system prompt file is a directory: %s", cfg.SystemPromptFile)
+	}
+	if info.Size() > MaxSystemPromptFileSize {
+		return fmt.Errorf("system prompt file too large (%d bytes, max %d): %s", info.Size(), MaxSystemPromptFileSize, cfg.SystemPromptFile)
+	}
+	data, err := os.ReadFile(cfg.SystemPromptFile)
+	if err != nil {
+		return fmt.Errorf("system prompt file: %w", err)
+	}
+	text := strings.TrimSpace(string(data))
+	if text == "" {
+		return fmt.Errorf("system prompt file is empty: %s", cfg.SystemPromptFile)
+	}
+	cfg.SystemPromptText = text
+	return nil
+}
+
 // BuildSystemPrompt builds system prompt
 // skillMetadata: スキルマネージャーから生成されたメタデータ文字列（空文字なら無視）
+// cfg.SystemPromptText があれば、モードに応じて生成プロンプトを置き換えるか前に置く
 func BuildSystemPrompt(cfg *Config, skillMetadata ...string) string {
+	if cfg.SystemPromptText == "" {
+		return buildGeneratedPrompt(cfg, skillMetadata...)
+	}
+
+	var prompt strings.Builder
+	prompt.WriteString(cfg.SystemPromptText)
+	prompt.WriteString("\n\n")
+	if cfg.SystemPromptMode == SystemPromptPrepend {
+		prompt.WriteString(buildGeneratedPrompt(cfg, skillMetadata...))
+		return prompt.String()
+	}
+
+	// replace: 独自プロンプト + スキル一覧のみ
+	if len(skillMetadata) > 0 && skillMetadata[0] != "" {
+		prompt.WriteString(skillMetadata[0])
+	}
+	return prompt.String()
+}
+
+// buildGeneratedPrompt builds the default system prompt
+func buildGeneratedPrompt(cfg *Config, skillMetadata ...string) string {
 	var prompt strings.Builder
 
 	// Core identity & rules (compact)
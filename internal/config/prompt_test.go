@@ -0,0 +1,86 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writePromptFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "prompt.md")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestBuildSystemPrompt_FileReplace(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.SystemPromptFile = writePromptFile(t, "You are a terse Go reviewer.\n")
+	if err := LoadSystemPromptFile(cfg); err != nil {
+		t.Fatalf("LoadSystemPromptFile: %v", err)
+	}
+
+	prompt := BuildSystemPrompt(cfg, "## 利用可能なスキル\n- deploy\n")
+	if !strings.HasPrefix(prompt, "You are a terse Go reviewer.") {
+		t.Errorf("replace mode should start with the file contents:\n%s", prompt)
+	}
+	if strings.Contains(prompt, "## 基本ルール") {
+		t.Errorf("replace mode should drop the generated prompt:\n%s", prompt)
+	}
+	if !strings.Contains(prompt, "- deploy") {
+		t.Errorf("skill metadata should still be appended:\n%s", prompt)
+	}
+}
+
+func TestBuildSystemPrompt_FilePrepend(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.SystemPromptFile = writePromptFile(t, "Always answer in English.")
+	cfg.SystemPromptMode = SystemPromptPrepend
+	if err := LoadSystemPromptFile(cfg); err != nil {
+		t.Fatalf("LoadSystemPromptFile: %v", err)
+	}
+
+	prompt := BuildSystemPrompt(cfg, "## 利用可能なスキル\n- deploy\n")
+	custom := strings.Index(prompt, "Always answer in English.")
+	generated := strings.Index(prompt, "## 基本ルール")
+	if custom != 0 || generated < 0 {
+		t.Fatalf("prepend mode should put the file before the generated prompt:\n%s", prompt)
+	}
+	if !strings.Contains(prompt, "- deploy") {
+		t.Errorf("skill metadata should still be included:\n%s", prompt)
+	}
+}
+
+func TestLoadSystemPromptFile_Validation(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		mode string
+	}{
+		{"missing file", filepath.Join(t.TempDir(), "nope.md"), ""},
+		{"empty file", writePromptFile(t, "  \n\n"), ""},
+		{"directory", t.TempDir(), ""},
+		{"bad mode", writePromptFile(t, "hi"), "append"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.SystemPromptFile = tt.file
+			cfg.SystemPromptMode = tt.mode
+			if err := LoadSystemPromptFile(cfg); err == nil {
+				t.Error("expected an error")
+			}
+			if cfg.SystemPromptText != "" {
+				t.Error("nothing should be loaded on error")
+			}
+		})
+	}
+
+	// No file configured: nothing to do
+	if err := LoadSystemPromptFile(DefaultConfig()); err != nil {
+		t.Errorf("expected no error without a file, got %v", err)
+	}
+}
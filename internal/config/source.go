@@ -0,0 +1,133 @@
+package config
+
+import (
+	"strconv"
+	"strings"
+)
+
+// 設定値の出どころ（優先度の低い順）
+const (
+	SourceDefault = "default" // DefaultConfig の値
+	SourceConfig  = "config"  // config.json（PROVIDERS のプロファイルを含む）
+	SourceProject = "project" // 作業ディレクトリの .vibe-local/config.json（リポジトリ由来なので信頼しない）
+	SourceEnv     = "env"     // 環境変数
+	SourceFlag    = "flag"    // コマンドラインフラグ
+	SourceAuto    = "auto"    // 自動選択（RAM によるモデル選択・プロバイダー検出）
+	SourceRuntime = "runtime" // 起動後に /model や /provider などで変更
+)
+
+// settingSource は設定値の出どころと、その時点の値
+type settingSource struct {
+	source string
+	value  string
+}
+
+// Setting は解決済みの設定値1件
+type Setting struct {
+	Key    string // config.json のキー名（MODEL, MAX_TOKENS など）
+	Value  string
+	Source string // SourceDefault / SourceConfig / SourceProject / SourceEnv / SourceFlag / SourceAuto / SourceRuntime
+}
+
+// settingDefs は /config effective で表示する設定（表示順）
+var settingDefs = []struct {
+	key   string
+	value func(c *Config) string
+}{
+	{"PROVIDER", func(c *Config) string { return c.Provider }},
+	{"CHAIN", func(c *Config) string { return strings.Join(c.Chain, ",") }},
+	{"MODEL", func(c *Config) string { return c.Model }},
+	{"SIDECAR_MODEL", func(c *Config) string { return c.SidecarModel }},
+	{"EMBEDDING_MODEL", func(c *Config) string { return c.EmbedModel }},
+	{"OLLAMA_HOST", func(c *Config) string { return c.OllamaHost }},
+	{"MAX_TOKENS", func(c *Config) string { return strconv.Itoa(c.MaxTokens) }},
+	{"TEMPERATURE", func(c *Config) string { return strconv.FormatFloat(c.Temperature, 'g', -1, 64) }},
+	{"CONTEXT_WINDOW", func(c *Config) string { return strconv.Itoa(c.ContextWindow) }},
+	{"OLLAMA_NUM_CTX", func(c *Config) string { return strconv.Itoa(c.OllamaNumCtx) }},
+	{"OLLAMA_NUM_GPU", func(c *Config) string { return strconv.Itoa(c.OllamaNumGPU) }},
+	{"BANNER", func(c *Config) string { return c.Banner }},
+	{"OUTPUT_WIDTH", func(c *Config) string { return strconv.Itoa(c.OutputWidth) }},
+	{"LANG", func(c *Config) string { return c.Lang }},
+	{"OFFLINE", func(c *Config) string { return strconv.FormatBool(c.Offline) }},
+	{"NO_NETWORK", func(c *Config) string { return strconv.FormatBool(c.NoNetwork) }},
+	{"ALLOW_DANGEROUS", func(c *Config) string { return strconv.FormatBool(c.AllowDangerous) }},
+	{"ALLOW_OUTSIDE_WORKDIR", func(c *Config) string { return strconv.FormatBool(c.AllowOutsideWorkdir) }},
+	{"NO_VIBEIGNORE", func(c *Config) string { return strconv.FormatBool(c.NoVibeIgnore) }},
+	{"RETRY_BUDGET", func(c *Config) string { return strconv.Itoa(c.RetryBudget) }},
+	{"BUDGET_TOKENS", func(c *Config) string { return strconv.Itoa(c.BudgetTokens) }},
+	{"SYSTEM_PROMPT_FILE", func(c *Config) string { return c.SystemPromptFile }},
+	{"SYSTEM_PROMPT_MODE", func(c *Config) string { return c.SystemPromptMode }},
+	{"AUTOTEST_COMMAND", func(c *Config) string { return c.AutoTestCommand }},
+	{"MAX_SEARCH_DEPTH", func(c *Config) string { return strconv.Itoa(c.MaxSearchDepth) }},
+	{"TOOL_OUTPUT_MAX_BYTES", func(c *Config) string { return strconv.Itoa(c.ToolOutputMaxBytes) }},
+	{"COMPACT_THRESHOLD", func(c *Config) string { return strconv.FormatFloat(c.CompactThreshold, 'g', -1, 64) }},
+	{"NO_AUTO_COMPACT", func(c *Config) string { return strconv.FormatBool(c.NoAutoCompact) }},
+	{"WEB_SEARCH_CACHE_SIZE", func(c *Config) string { return strconv.Itoa(c.WebSearchCacheSize) }},
+	{"LOOP_HISTORY_SIZE", func(c *Config) string { return strconv.Itoa(c.LoopHistorySize) }},
+	{"LOOP_THRESHOLD", func(c *Config) string { return strconv.Itoa(c.LoopThreshold) }},
+	{"AUTO_APPROVE", func(c *Config) string { return strconv.FormatBool(c.AutoApprove) }},
+	{"SANDBOX", func(c *Config) string { return strconv.FormatBool(c.SandboxMode) }},
+	{"AUTO_VENV", func(c *Config) string { return strconv.FormatBool(c.AutoVenv) }},
+	{"VENV_DIR", func(c *Config) string { return c.VenvDir }},
+}
+
+// SetSource は key の現在値が source から来たことを記録する。
+// 値を反映した直後に呼ぶ（後から値が変わると SourceRuntime として扱われる）
+func (c *Config) SetSource(key, source string) {
+	if c.sources == nil {
+		c.sources = make(map[string]settingSource)
+	}
+	value := ""
+	for _, def := range settingDefs {
+		if def.key == key {
+			value = def.value(c)
+			break
+		}
+	}
+	c.sources[key] = settingSource{source: source, value: value}
+	if source == SourceEnv || source == SourceFlag {
+		if c.overrides == nil {
+			c.overrides = make(map[string]settingSource)
+		}
+		c.overrides[key] = c.sources[key]
+	}
+}
+
+// Source は key の現在値の出どころを返す
+func (c *Config) Source(key string) string {
+	for _, def := range settingDefs {
+		if def.key == key {
+			return c.sourceOf(key, def.value(c), def.value(DefaultConfig()))
+		}
+	}
+	return SourceDefault
+}
+
+// sourceOf は記録と現在値を比べて出どころを決める
+func (c *Config) sourceOf(key, current, defaultValue string) string {
+	if rec, ok := c.sources[key]; ok {
+		if rec.value == current {
+			return rec.source
+		}
+		return SourceRuntime
+	}
+	if current == defaultValue {
+		return SourceDefault
+	}
+	return SourceRuntime
+}
+
+// EffectiveSettings は解決済みの設定値を出どころ付きで返す
+func (c *Config) EffectiveSettings() []Setting {
+	defaults := DefaultConfig()
+	settings := make([]Setting, 0, len(settingDefs))
+	for _, def := range settingDefs {
+		value := def.value(c)
+		settings = append(settings, Setting{
+			Key:    def.key,
+			Value:  value,
+			Source: c.sourceOf(def.key, value, def.value(defaults)),
+		})
+	}
+	return settings
+}
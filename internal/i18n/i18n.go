@@ -0,0 +1,96 @@
+package i18n
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Lang 表示言語
+type Lang string
+
+const (
+	// JA 日本語（デフォルト）
+	JA Lang = "ja"
+	// EN 英語
+	EN Lang = "en"
+)
+
+var (
+	mu      sync.RWMutex
+	current = JA
+)
+
+// SetLang 表示言語を設定
+func SetLang(lang Lang) {
+	mu.Lock()
+	defer mu.Unlock()
+	current = lang
+}
+
+// Current 現在の表示言語
+func Current() Lang {
+	mu.RLock()
+	defer mu.RUnlock()
+	return current
+}
+
+// Parse 言語指定（"ja", "en", "ja_JP.UTF-8", "english" など）を Lang に変換
+func Parse(s string) (Lang, bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	switch {
+	case s == "ja" || strings.HasPrefix(s, "ja_") || strings.HasPrefix(s, "ja-") || strings.HasPrefix(s, "ja.") || s == "japanese":
+		return JA, true
+	case s == "en" || strings.HasPrefix(s, "en_") || strings.HasPrefix(s, "en-") || strings.HasPrefix(s, "en.") || s == "english":
+		return EN, true
+	}
+	return "", false
+}
+
+// Detect ロケール環境変数（LC_ALL → LC_MESSAGES → LANG の順）から言語を決める
+// 日本語ロケールまたは未設定・C/POSIX なら日本語、それ以外のロケールは英語
+func Detect(getenv func(string) string) Lang {
+	for _, key := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		v := strings.TrimSpace(getenv(key))
+		if v == "" {
+			continue
+		}
+		if v == "C" || v == "POSIX" || strings.HasPrefix(v, "C.") {
+			return JA
+		}
+		if lang, ok := Parse(v); ok {
+			return lang
+		}
+		return EN
+	}
+	return JA
+}
+
+// Resolve 明示指定（--lang / 設定ファイル）があればそれを、なければ環境変数から言語を決める
+func Resolve(explicit string, getenv func(string) string) Lang {
+	if lang, ok := Parse(explicit); ok {
+		return lang
+	}
+	return Detect(getenv)
+}
+
+// T 現在の言語でメッセージを取得（args があれば fmt.Sprintf で整形）
+func T(key Key, args ...interface{}) string {
+	return Message(Current(), key, args...)
+}
+
+// Message 指定した言語でメッセージを取得
+// 訳がなければ日本語、それもなければキー自体を返す
+func Message(lang Lang, key Key, args ...interface{}) string {
+	format, ok := catalog[lang][key]
+	if !ok {
+		format, ok = catalog[JA][key]
+	}
+	if !ok {
+		format = string(key)
+	}
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
@@ -0,0 +1,75 @@
+package i18n
+
+import "testing"
+
+func TestMessage_SameKeyPerLocale(t *testing.T) {
+	ja := Message(JA, MsgModelSwitched, "qwen3:8b")
+	en := Message(EN, MsgModelSwitched, "qwen3:8b")
+
+	if ja != "✓ モデルを qwen3:8b に切り替えました\n" {
+		t.Errorf("ja = %q", ja)
+	}
+	if en != "✓ Switched model to qwen3:8b\n" {
+		t.Errorf("en = %q", en)
+	}
+}
+
+func TestT_UsesCurrentLang(t *testing.T) {
+	defer SetLang(Current())
+
+	SetLang(EN)
+	if got := T(MsgConnError, "refused"); got != "Connection error: refused\n" {
+		t.Errorf("T in en = %q", got)
+	}
+	SetLang(JA)
+	if got := T(MsgConnError, "refused"); got != "接続エラー: refused\n" {
+		t.Errorf("T in ja = %q", got)
+	}
+}
+
+func TestMessage_Fallback(t *testing.T) {
+	if got := Message(Lang("fr"), MsgProviderAdded); got != catalog[JA][MsgProviderAdded] {
+		t.Errorf("unknown language should fall back to Japanese, got %q", got)
+	}
+	if got := Message(EN, Key("no.such.key")); got != "no.such.key" {
+		t.Errorf("unknown key should render as the key, got %q", got)
+	}
+}
+
+func TestCatalog_EnglishCoversJapanese(t *testing.T) {
+	for key := range catalog[JA] {
+		if _, ok := catalog[EN][key]; !ok {
+			t.Errorf("missing English message for %s", key)
+		}
+	}
+}
+
+func TestResolve(t *testing.T) {
+	env := func(vars map[string]string) func(string) string {
+		return func(key string) string { return vars[key] }
+	}
+
+	tests := []struct {
+		name     string
+		explicit string
+		vars     map[string]string
+		want     Lang
+	}{
+		{"japanese locale", "", map[string]string{"LANG": "ja_JP.UTF-8"}, JA},
+		{"english locale", "", map[string]string{"LANG": "en_US.UTF-8"}, EN},
+		{"other locale", "", map[string]string{"LANG": "de_DE.UTF-8"}, EN},
+		{"unset", "", nil, JA},
+		{"C locale", "", map[string]string{"LANG": "C.UTF-8"}, JA},
+		{"LC_ALL wins", "", map[string]string{"LC_ALL": "en_GB.UTF-8", "LANG": "ja_JP.UTF-8"}, EN},
+		{"flag wins", "ja", map[string]string{"LANG": "en_US.UTF-8"}, JA},
+		{"invalid flag", "xx", map[string]string{"LANG": "en_US.UTF-8"}, EN},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Resolve(tt.explicit, env(tt.vars)); got != tt.want {
+				t.Errorf("Resolve(%q) = %q, want %q", tt.explicit, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package i18n
+
+// Key メッセージカタログのキー
+type Key string
+
+// 接続
+const (
+	MsgConnChecking      Key = "conn.checking"
+	MsgConnOK            Key = "conn.ok"
+	MsgConnError         Key = "conn.error"
+	MsgConnRetry         Key = "conn.retry"
+	MsgConnReconfigure   Key = "conn.reconfigure"
+	MsgConnQuit          Key = "conn.quit"
+	MsgConnChoose        Key = "conn.choose"
+	MsgConnRestartNeeded Key = "conn.restart_needed"
+)
+
+// プロバイダー
+const (
+	MsgProviderDetecting     Key = "provider.detecting"
+	MsgProviderCloudDetected Key = "provider.cloud_detected"
+	MsgProviderDetected      Key = "provider.detected"
+	MsgProviderNoneFound     Key = "provider.none_found"
+	MsgProviderSubAdded      Key = "provider.sub_added"
+	MsgProviderNotFound      Key = "provider.not_found"
+	MsgProviderAdded         Key = "provider.added"
+	MsgProviderNoSwitchable  Key = "provider.no_switchable"
+	MsgProviderOfflineCloud  Key = "provider.offline_cloud"
+	MsgProviderOfflineHint   Key = "provider.offline_hint"
+	MsgProviderAPIKeyMissing Key = "provider.api_key_missing"
+	MsgProviderAPIKeyHint    Key = "provider.api_key_hint"
+)
+
+// モデル
+const (
+	MsgModelFetching      Key = "model.fetching"
+	MsgModelListError     Key = "model.list_error"
+	MsgModelNoneAvailable Key = "model.none_available"
+	MsgModelFound         Key = "model.found"
+	MsgModelSwitched      Key = "model.switched"
+	MsgModelCurrent       Key = "model.current"
+	MsgModelNotFound      Key = "model.not_found"
+	MsgModelSuggest       Key = "model.suggest"
+	MsgModelNormalized    Key = "model.normalized"
+	MsgModelChecking      Key = "model.checking"
+	MsgModelCheckError    Key = "model.check_error"
+	MsgModelPullError     Key = "model.pull_error"
+)
+
+// エラー
+const (
+	MsgErrInput       Key = "err.input"
+	MsgErrConfigSave  Key = "err.config_save"
+	MsgErrSessionSave Key = "err.session_save"
+)
+
+// catalog 言語ごとのメッセージ
+var catalog = map[Lang]map[Key]string{
+	JA: {
+		MsgConnChecking:      "%s (%s) 接続を確認中...\n",
+		MsgConnOK:            "✓ %s 接続確認\n",
+		MsgConnError:         "接続エラー: %v\n",
+		MsgConnRetry:         "  1. リトライ",
+		MsgConnReconfigure:   "  2. プロバイダーを再設定",
+		MsgConnQuit:          "  3. 終了",
+		MsgConnChoose:        "選択 [1-3]: ",
+		MsgConnRestartNeeded: "注意: 新しいプロバイダーで接続するには再起動が必要です\n",
+
+		MsgProviderDetecting:     "🔍 LLMプロバイダーを自動検出中...\n",
+		MsgProviderCloudDetected: "✓ クラウドプロバイダー検出: %s\n",
+		MsgProviderDetected:      "✓ %s 検出 (%s, モデル: %d件)\n",
+		MsgProviderNoneFound:     "⚠ LLMプロバイダーが見つかりません。デフォルト(Ollama)で接続を試みます\n",
+		MsgProviderSubAdded:      "  + %s (%s) をサブプロバイダーに追加\n",
+		MsgProviderNotFound:      "プロバイダー '%s' が見つかりません\n",
+		MsgProviderAdded:         "✓ プロバイダーが追加されました\n",
+		MsgProviderNoSwitchable:  "切替可能なプロバイダーが登録されていません\n",
+		MsgProviderOfflineCloud:  "エラー: オフラインモードではクラウドプロバイダー %s は使えません\n",
+		MsgProviderOfflineHint:   "  ローカルプロバイダー (ollama, lm-studio, llama-server) を指定するか --offline を外してください\n",
+		MsgProviderAPIKeyMissing: "エラー: %s を使用するにはAPIキーが必要です\n",
+		MsgProviderAPIKeyHint:    "  --api-key <key> または %s 環境変数を設定してください\n",
+
+		MsgModelFetching:      "利用可能なモデルを取得中...\n",
+		MsgModelListError:     "モデル一覧取得エラー: %v\n",
+		MsgModelNoneAvailable: "利用可能なモデルが見つかりませんでした\n",
+		MsgModelFound:         "%d 件のモデルが見つかりました:\n",
+		MsgModelSwitched:      "✓ モデルを %s に切り替えました\n",
+		MsgModelCurrent:       "現在のモデル: %s\n",
+		MsgModelNotFound:      "モデル '%s' が見つかりません\n",
+		MsgModelSuggest:       "もしかして: %s\n",
+		MsgModelNormalized:    "⚠ モデル名 '%s' を '%s' として使用します\n",
+		MsgModelChecking:      "モデル '%s' を確認中...\n",
+		MsgModelCheckError:    "モデル確認エラー: %v\n",
+		MsgModelPullError:     "\nモデルダウンロードエラー: %v\n",
+
+		MsgErrInput:       "入力エラー: %v\n",
+		MsgErrConfigSave:  "設定保存エラー: %v\n",
+		MsgErrSessionSave: "セッション保存エラー: %v\n",
+	},
+	EN: {
+		MsgConnChecking:      "Checking connection to %s (%s)...\n",
+		MsgConnOK:            "✓ Connected to %s\n",
+		MsgConnError:         "Connection error: %v\n",
+		MsgConnRetry:         "  1. Retry",
+		MsgConnReconfigure:   "  2. Reconfigure provider",
+		MsgConnQuit:          "  3. Quit",
+		MsgConnChoose:        "Choose [1-3]: ",
+		MsgConnRestartNeeded: "Note: restart to connect with the new provider\n",
+
+		MsgProviderDetecting:     "🔍 Detecting LLM providers...\n",
+		MsgProviderCloudDetected: "✓ Cloud provider detected: %s\n",
+		MsgProviderDetected:      "✓ Detected %s (%s, %d models)\n",
+		MsgProviderNoneFound:     "⚠ No LLM provider found. Trying the default (Ollama)\n",
+		MsgProviderSubAdded:      "  + Added %s (%s) as a sub provider\n",
+		MsgProviderNotFound:      "Provider '%s' not found\n",
+		MsgProviderAdded:         "✓ Provider added\n",
+		MsgProviderNoSwitchable:  "No providers registered to switch to\n",
+		MsgProviderOfflineCloud:  "Error: cloud provider %s is not available in offline mode\n",
+		MsgProviderOfflineHint:   "  Use a local provider (ollama, lm-studio, llama-server) or remove --offline\n",
+		MsgProviderAPIKeyMissing: "Error: an API key is required to use %s\n",
+		MsgProviderAPIKeyHint:    "  Set --api-key <key> or the %s environment variable\n",
+
+		MsgModelFetching:      "Fetching available models...\n",
+		MsgModelListError:     "Failed to list models: %v\n",
+		MsgModelNoneAvailable: "No available models found\n",
+		MsgModelFound:         "Found %d models:\n",
+		MsgModelSwitched:      "✓ Switched model to %s\n",
+		MsgModelCurrent:       "Current model: %s\n",
+		MsgModelNotFound:      "Model '%s' not found\n",
+		MsgModelSuggest:       "Did you mean: %s\n",
+		MsgModelNormalized:    "⚠ Using model name '%s' as '%s'\n",
+		MsgModelChecking:      "Checking model '%s'...\n",
+		MsgModelCheckError:    "Model check error: %v\n",
+		MsgModelPullError:     "\nModel download error: %v\n",
+
+		MsgErrInput:       "Input error: %v\n",
+		MsgErrConfigSave:  "Failed to save config: %v\n",
+		MsgErrSessionSave: "Failed to save session: %v\n",
+	},
+}
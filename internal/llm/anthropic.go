@@ -0,0 +1,395 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// AnthropicBaseURL Anthropic Messages API の基盤URL（/v1含む）
+	AnthropicBaseURL = "https://api.anthropic.com/v1"
+	// AnthropicAPIVersion anthropic-version ヘッダーの値
+	AnthropicAPIVersion = "2023-06-01"
+	// AnthropicDefaultMaxTokens max_tokens 未指定時のデフォルト（Messages APIでは必須）
+	AnthropicDefaultMaxTokens = 4096
+)
+
+// AnthropicProvider Anthropic ネイティブ Messages API プロバイダー
+// ツール呼び出しは tool_use ブロック、ツール結果は user メッセージ内の tool_result ブロックに変換する
+type AnthropicProvider struct {
+	baseURL    string
+	apiKey     string
+	model      string
+	httpClient *http.Client
+	info       ProviderInfo
+}
+
+// NewAnthropicProvider 新しいAnthropicプロバイダーを作成
+func NewAnthropicProvider(apiKey, model string) *AnthropicProvider {
+	info := ProviderInfo{
+		Name:    "anthropic",
+		Type:    ProviderTypeCloud,
+		BaseURL: AnthropicBaseURL,
+		Model:   model,
+		Features: Features{
+			NativeFunctionCalling: true,
+			ModelManagement:       false,
+			Streaming:             false,
+		},
+	}
+
+	return &AnthropicProvider{
+		baseURL: AnthropicBaseURL,
+		apiKey:  apiKey,
+		model:   model,
+		httpClient: &http.Client{
+			Timeout: 300 * time.Second,
+		},
+		info: info,
+	}
+}
+
+// anthropicRequest Messages API リクエスト
+type anthropicRequest struct {
+	Model       string             `json:"model"`
+	System      string             `json:"system,omitempty"`
+	Messages    []anthropicMessage `json:"messages"`
+	Tools       []anthropicTool    `json:"tools,omitempty"`
+	MaxTokens   int                `json:"max_tokens"`
+	Temperature float64            `json:"temperature"` // 0 も有効な値なので常に送る（省略すると API 既定の 1.0 になる）
+}
+
+// anthropicMessage Messages API のメッセージ（content はブロック配列）
+type anthropicMessage struct {
+	Role    string                  `json:"role"`
+	Content []anthropicContentBlock `json:"content"`
+}
+
+// anthropicContentBlock text / tool_use / tool_result ブロック
+type anthropicContentBlock struct {
+	Type      string          `json:"type"`
+	Text      string          `json:"text,omitempty"`
+	ID        string          `json:"id,omitempty"`
+	Name      string          `json:"name,omitempty"`
+	Input     json.RawMessage `json:"input,omitempty"`
+	ToolUseID string          `json:"tool_use_id,omitempty"`
+	Content   string          `json:"content,omitempty"`
+}
+
+// anthropicTool Messages API のツール定義
+type anthropicTool struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description,omitempty"`
+	InputSchema map[string]interface{} `json:"input_schema"`
+}
+
+// anthropicResponse Messages API レスポンス
+type anthropicResponse struct {
+	ID         string                  `json:"id"`
+	Model      string                  `json:"model"`
+	Content    []anthropicContentBlock `json:"content"`
+	StopReason string                  `json:"stop_reason"`
+	Usage      struct {
+		InputTokens  int `json:"input_tokens"`
+		OutputTokens int `json:"output_tokens"`
+	} `json:"usage"`
+}
+
+// anthropicErrorResponse Messages API エラーレスポンス
+type anthropicErrorResponse struct {
+	Error struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// Chat 同期チャットリクエスト（ツール使用対応）
+func (p *AnthropicProvider) Chat(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
+	model := req.Model
+	if model == "" {
+		model = p.model
+	}
+
+	jsonData, err := json.Marshal(buildAnthropicRequest(req, model))
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", p.baseURL+"/messages", bytes.NewReader(jsonData))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	p.setHeaders(httpReq)
+
+	resp, err := p.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := readResponseBody(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var errResp anthropicErrorResponse
+		if jsonErr := json.Unmarshal(body, &errResp); jsonErr == nil && errResp.Error.Message != "" {
+			return nil, fmt.Errorf("LLM error: %s", errResp.Error.Message)
+		}
+		return nil, fmt.Errorf("request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var response anthropicResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	return convertAnthropicResponse(&response), nil
+}
+
+// ChatStream ストリーミングチャット
+// Messages API のSSEは未対応のため、同期レスポンスを1イベントとして返す
+func (p *AnthropicProvider) ChatStream(ctx context.Context, req *ChatRequest) (<-chan StreamEvent, error) {
+	resp, err := p.Chat(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	eventChan := make(chan StreamEvent, 2)
+	if len(resp.Choices) > 0 {
+		choice := resp.Choices[0]
+		eventChan <- StreamEvent{
+			Delta: &Delta{
+				Role:      choice.Message.Role,
+				Content:   choice.Message.Content,
+				ToolCalls: choice.Message.ToolCalls,
+			},
+			Tokens: []Token{
+				{
+					Text:         choice.Message.Content,
+					FinishReason: choice.FinishReason,
+				},
+			},
+		}
+	}
+	eventChan <- StreamEvent{Done: true}
+	close(eventChan)
+	return eventChan, nil
+}
+
+// CheckHealth プロバイダーの生存確認
+func (p *AnthropicProvider) CheckHealth(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", p.baseURL+"/models", nil)
+	if err != nil {
+		return err
+	}
+	p.setHeaders(req)
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to connect: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("health check failed with status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// Info プロバイダー情報を返す
+func (p *AnthropicProvider) Info() ProviderInfo {
+	return p.info
+}
+
+// SetTimeout タイムアウトを設定
+func (p *AnthropicProvider) SetTimeout(timeout time.Duration) {
+	p.httpClient.Timeout = timeout
+}
+
+// GetModel 使用中のモデル名を返す
+func (p *AnthropicProvider) GetModel() string {
+	return p.model
+}
+
+// SetModel モデルを変更
+func (p *AnthropicProvider) SetModel(model string) {
+	p.model = model
+	p.info.Model = model
+}
+
+// setHeaders Anthropic 固有の認証ヘッダーを設定
+func (p *AnthropicProvider) setHeaders(req *http.Request) {
+	if p.apiKey != "" {
+		req.Header.Set("x-api-key", p.apiKey)
+	}
+	req.Header.Set("anthropic-version", AnthropicAPIVersion)
+}
+
+// buildAnthropicRequest OpenAI形式のリクエストを Messages API 形式に変換
+// - system メッセージは top-level の system に集約
+// - assistant のツール呼び出しは tool_use ブロック
+// - role: tool の結果は user メッセージ内の tool_result ブロック（同じ tool_use ID）
+// 同じロールが連続する場合は1つのメッセージにブロックをまとめる
+func buildAnthropicRequest(req *ChatRequest, model string) *anthropicRequest {
+	out := &anthropicRequest{
+		Model:       model,
+		Messages:    make([]anthropicMessage, 0, len(req.Messages)),
+		MaxTokens:   req.MaxTokens,
+		Temperature: req.Temperature,
+	}
+	if out.MaxTokens <= 0 {
+		out.MaxTokens = AnthropicDefaultMaxTokens
+	}
+
+	var systemParts []string
+
+	appendBlocks := func(role string, blocks ...anthropicContentBlock) {
+		if len(blocks) == 0 {
+			return
+		}
+		if n := len(out.Messages); n > 0 && out.Messages[n-1].Role == role {
+			out.Messages[n-1].Content = append(out.Messages[n-1].Content, blocks...)
+			return
+		}
+		out.Messages = append(out.Messages, anthropicMessage{Role: role, Content: blocks})
+	}
+
+	for _, msg := range req.Messages {
+		switch msg.Role {
+		case "system":
+			if msg.Content != "" {
+				systemParts = append(systemParts, msg.Content)
+			}
+
+		case "assistant":
+			blocks := make([]anthropicContentBlock, 0, len(msg.ToolCalls)+1)
+			if msg.Content != "" {
+				blocks = append(blocks, anthropicContentBlock{Type: "text", Text: msg.Content})
+			}
+			for _, tc := range msg.ToolCalls {
+				blocks = append(blocks, anthropicContentBlock{
+					Type:  "tool_use",
+					ID:    tc.ID,
+					Name:  tc.Function.Name,
+					Input: toolInputJSON(tc.Function.Arguments),
+				})
+			}
+			appendBlocks("assistant", blocks...)
+
+		case "tool":
+			content := msg.Content
+			if content == "" {
+				content = "(no output)"
+			}
+			appendBlocks("user", anthropicContentBlock{
+				Type:      "tool_result",
+				ToolUseID: msg.ToolID,
+				Content:   content,
+			})
+
+		default:
+			if msg.Content != "" {
+				appendBlocks("user", anthropicContentBlock{Type: "text", Text: msg.Content})
+			}
+		}
+	}
+
+	out.System = strings.Join(systemParts, "\n\n")
+
+	for _, t := range req.Tools {
+		schema := t.Function.Parameters
+		if schema == nil {
+			schema = map[string]interface{}{"type": "object", "properties": map[string]interface{}{}}
+		}
+		out.Tools = append(out.Tools, anthropicTool{
+			Name:        t.Function.Name,
+			Description: t.Function.Description,
+			InputSchema: schema,
+		})
+	}
+
+	return out
+}
+
+// toolInputJSON ツール引数を tool_use の input（JSONオブジェクト）に変換
+// OpenAI形式では arguments が JSON 文字列として入っているため展開する
+func toolInputJSON(args json.RawMessage) json.RawMessage {
+	trimmed := bytes.TrimSpace(args)
+	if len(trimmed) == 0 {
+		return json.RawMessage("{}")
+	}
+
+	if trimmed[0] == '"' {
+		var s string
+		if err := json.Unmarshal(trimmed, &s); err != nil {
+			return json.RawMessage("{}")
+		}
+		trimmed = bytes.TrimSpace([]byte(s))
+	}
+
+	if len(trimmed) == 0 || trimmed[0] != '{' || !json.Valid(trimmed) {
+		return json.RawMessage("{}")
+	}
+	return json.RawMessage(trimmed)
+}
+
+// convertAnthropicResponse Messages API レスポンスを共通の ChatResponse に変換
+func convertAnthropicResponse(resp *anthropicResponse) *ChatResponse {
+	var text strings.Builder
+	toolCalls := make([]ToolCall, 0)
+
+	for _, block := range resp.Content {
+		switch block.Type {
+		case "text":
+			text.WriteString(block.Text)
+		case "tool_use":
+			toolCalls = append(toolCalls, ToolCall{
+				ID:   block.ID,
+				Type: "function",
+				Function: FunctionCall{
+					Name:      block.Name,
+					Arguments: NormalizeToolArguments(block.Input),
+				},
+			})
+		}
+	}
+
+	finishReason := "stop"
+	switch resp.StopReason {
+	case "tool_use":
+		finishReason = "tool_calls"
+	case "max_tokens":
+		finishReason = "length"
+	}
+
+	return &ChatResponse{
+		ID:     resp.ID,
+		Object: "chat.completion",
+		Model:  resp.Model,
+		Choices: []Choice{
+			{
+				Index: 0,
+				Message: Message{
+					Role:      "assistant",
+					Content:   text.String(),
+					ToolCalls: toolCalls,
+				},
+				FinishReason: finishReason,
+			},
+		},
+		Usage: Usage{
+			PromptTokens:     resp.Usage.InputTokens,
+			CompletionTokens: resp.Usage.OutputTokens,
+			TotalTokens:      resp.Usage.InputTokens + resp.Usage.OutputTokens,
+		},
+	}
+}
@@ -0,0 +1,235 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// toolRoundTripRequest assistant のツール呼び出しとその結果を含むリクエスト
+func toolRoundTripRequest() *ChatRequest {
+	return &ChatRequest{
+		Messages: []Message{
+			{Role: "system", Content: "You are a coding assistant"},
+			{Role: "user", Content: "Read main.go"},
+			{
+				Role: "assistant",
+				ToolCalls: []ToolCall{
+					{
+						ID:   "toolu_01",
+						Type: "function",
+						Function: FunctionCall{
+							Name:      "read_file",
+							Arguments: json.RawMessage(`"{\"path\":\"main.go\"}"`),
+						},
+					},
+					{
+						ID:   "toolu_02",
+						Type: "function",
+						Function: FunctionCall{
+							Name:      "glob",
+							Arguments: json.RawMessage(`{"pattern":"*.go"}`),
+						},
+					},
+				},
+			},
+			{Role: "tool", Content: "package main", ToolID: "toolu_01"},
+			{Role: "tool", Content: "main.go", ToolID: "toolu_02"},
+		},
+		Tools: []ToolDef{
+			{
+				Type: "function",
+				Function: FunctionDef{
+					Name:        "read_file",
+					Description: "Read a file",
+					Parameters:  map[string]interface{}{"type": "object"},
+				},
+			},
+		},
+	}
+}
+
+func TestBuildAnthropicRequest_ToolCallAndResult(t *testing.T) {
+	out := buildAnthropicRequest(toolRoundTripRequest(), "claude-test")
+
+	if out.System != "You are a coding assistant" {
+		t.Errorf("expected system prompt to be hoisted, got %q", out.System)
+	}
+	if out.MaxTokens != AnthropicDefaultMaxTokens {
+		t.Errorf("expected default max_tokens %d, got %d", AnthropicDefaultMaxTokens, out.MaxTokens)
+	}
+	if len(out.Messages) != 3 {
+		t.Fatalf("expected 3 messages (user, assistant, user), got %d: %+v", len(out.Messages), out.Messages)
+	}
+
+	assistant := out.Messages[1]
+	if assistant.Role != "assistant" || len(assistant.Content) != 2 {
+		t.Fatalf("expected assistant with 2 tool_use blocks, got %+v", assistant)
+	}
+	for i, wantID := range []string{"toolu_01", "toolu_02"} {
+		block := assistant.Content[i]
+		if block.Type != "tool_use" || block.ID != wantID {
+			t.Errorf("block %d: expected tool_use %s, got %+v", i, wantID, block)
+		}
+	}
+
+	// JSON文字列・オブジェクトどちらの arguments もオブジェクトの input になる
+	var input map[string]string
+	if err := json.Unmarshal(assistant.Content[0].Input, &input); err != nil || input["path"] != "main.go" {
+		t.Errorf("expected input object with path, got %s (%v)", assistant.Content[0].Input, err)
+	}
+	if string(assistant.Content[1].Input) != `{"pattern":"*.go"}` {
+		t.Errorf("expected object input to pass through, got %s", assistant.Content[1].Input)
+	}
+
+	// 連続するツール結果は1つの user メッセージにまとめる
+	results := out.Messages[2]
+	if results.Role != "user" || len(results.Content) != 2 {
+		t.Fatalf("expected user message with 2 tool_result blocks, got %+v", results)
+	}
+	for i, wantID := range []string{"toolu_01", "toolu_02"} {
+		block := results.Content[i]
+		if block.Type != "tool_result" || block.ToolUseID != wantID {
+			t.Errorf("block %d: expected tool_result for %s, got %+v", i, wantID, block)
+		}
+	}
+
+	if len(out.Tools) != 1 || out.Tools[0].Name != "read_file" || out.Tools[0].InputSchema["type"] != "object" {
+		t.Errorf("unexpected tools mapping: %+v", out.Tools)
+	}
+}
+
+func TestBuildAnthropicRequest_WireFormat(t *testing.T) {
+	data, err := json.Marshal(buildAnthropicRequest(toolRoundTripRequest(), "claude-test"))
+	if err != nil {
+		t.Fatalf("marshal failed: %v", err)
+	}
+
+	var wire struct {
+		Messages []struct {
+			Role    string                   `json:"role"`
+			Content []map[string]interface{} `json:"content"`
+		} `json:"messages"`
+	}
+	if err := json.Unmarshal(data, &wire); err != nil {
+		t.Fatalf("unmarshal failed: %v", err)
+	}
+
+	for _, msg := range wire.Messages {
+		if msg.Role == "tool" || msg.Role == "system" {
+			t.Errorf("role %q must not appear in Messages API requests", msg.Role)
+		}
+	}
+
+	result := wire.Messages[2].Content[0]
+	if result["type"] != "tool_result" || result["tool_use_id"] != "toolu_01" || result["content"] != "package main" {
+		t.Errorf("unexpected tool_result wire format: %v", result)
+	}
+	if _, ok := result["input"]; ok {
+		t.Errorf("tool_result must not carry input: %v", result)
+	}
+}
+
+func TestBuildAnthropicRequest_ZeroTemperature(t *testing.T) {
+	req := toolRoundTripRequest()
+	req.Temperature = 0
+	data, err := json.Marshal(buildAnthropicRequest(req, "claude-test"))
+	if err != nil {
+		t.Fatalf("marshal failed: %v", err)
+	}
+
+	var wire map[string]interface{}
+	if err := json.Unmarshal(data, &wire); err != nil {
+		t.Fatalf("unmarshal failed: %v", err)
+	}
+	if temp, ok := wire["temperature"]; !ok || temp != 0.0 {
+		t.Errorf("temperature = %v (present %v), want 0 to be sent", temp, ok)
+	}
+}
+
+func TestConvertAnthropicResponse_ToolUse(t *testing.T) {
+	var resp anthropicResponse
+	body := `{
+		"id": "msg_01",
+		"model": "claude-test",
+		"stop_reason": "tool_use",
+		"content": [
+			{"type": "text", "text": "Let me read it."},
+			{"type": "tool_use", "id": "toolu_09", "name": "read_file", "input": {"path": "a.go"}}
+		],
+		"usage": {"input_tokens": 12, "output_tokens": 5}
+	}`
+	if err := json.Unmarshal([]byte(body), &resp); err != nil {
+		t.Fatalf("unmarshal failed: %v", err)
+	}
+
+	out := convertAnthropicResponse(&resp)
+	choice := out.Choices[0]
+
+	if choice.Message.Content != "Let me read it." {
+		t.Errorf("unexpected content %q", choice.Message.Content)
+	}
+	if choice.FinishReason != "tool_calls" {
+		t.Errorf("expected finish_reason tool_calls, got %q", choice.FinishReason)
+	}
+	if len(choice.Message.ToolCalls) != 1 {
+		t.Fatalf("expected 1 tool call, got %d", len(choice.Message.ToolCalls))
+	}
+	tc := choice.Message.ToolCalls[0]
+	if tc.ID != "toolu_09" || tc.Function.Name != "read_file" || string(tc.Function.Arguments) != `{"path": "a.go"}` {
+		t.Errorf("unexpected tool call %+v (args %s)", tc, tc.Function.Arguments)
+	}
+	if out.Usage.PromptTokens != 12 || out.Usage.CompletionTokens != 5 {
+		t.Errorf("unexpected usage %+v", out.Usage)
+	}
+}
+
+func TestAnthropicProvider_Chat(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/messages" {
+			t.Errorf("expected /messages, got %s", r.URL.Path)
+		}
+		if r.Header.Get("x-api-key") != "test-key" {
+			t.Errorf("expected x-api-key header, got %q", r.Header.Get("x-api-key"))
+		}
+		if r.Header.Get("anthropic-version") != AnthropicAPIVersion {
+			t.Errorf("expected anthropic-version header, got %q", r.Header.Get("anthropic-version"))
+		}
+
+		var req anthropicRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatal(err)
+		}
+		if req.Model != "claude-test" {
+			t.Errorf("expected provider model fallback, got %q", req.Model)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":"msg_1","model":"claude-test","stop_reason":"end_turn","content":[{"type":"text","text":"done"}],"usage":{"input_tokens":1,"output_tokens":1}}`))
+	}))
+	defer server.Close()
+
+	p := NewAnthropicProvider("test-key", "claude-test")
+	p.baseURL = server.URL
+
+	resp, err := p.Chat(context.Background(), toolRoundTripRequest())
+	if err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+	if resp.Choices[0].Message.Content != "done" || resp.Choices[0].FinishReason != "stop" {
+		t.Errorf("unexpected response %+v", resp.Choices[0])
+	}
+}
+
+func TestNewCloudProvider_AnthropicIsNative(t *testing.T) {
+	p := NewCloudProvider("anthropic", "key", "")
+	ap, ok := p.(*AnthropicProvider)
+	if !ok {
+		t.Fatalf("expected *AnthropicProvider, got %T", p)
+	}
+	if ap.GetModel() != "claude-sonnet-4-20250514" {
+		t.Errorf("expected default model, got %q", ap.GetModel())
+	}
+}
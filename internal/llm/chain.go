@@ -3,6 +3,7 @@ package llm
 import (
 	"context"
 	"fmt"
+	"strings"
 	"sync"
 	"time"
 )
@@ -29,6 +30,9 @@ type ChainEntry struct {
 // FallbackCallback フォールバック発生時のコールバック
 type FallbackCallback func(fromProvider, toProvider string, classification ErrorClassification)
 
+// FallbackGuard フォールバック先に切り替える前に呼ばれる。エラーを返すと切り替えを中止し、そのエラーで呼び出しを終える
+type FallbackGuard func(to LLMProvider) error
+
 // ProviderChain フォールバック付きプロバイダーチェーン
 // Phase 4: フォールバック機能対応
 type ProviderChain struct {
@@ -41,6 +45,8 @@ type ProviderChain struct {
 	maxRetries   int                      // 最大リトライ数
 	condition    FallbackCondition        // フォールバック条件
 	onFallback   FallbackCallback         // フォールバック通知コールバック
+	guard        FallbackGuard            // フォールバック前の確認（nil = 常に許可）
+	health       *HealthCache             // 状態表示用の接続確認キャッシュ
 	mu           sync.RWMutex
 }
 
@@ -68,7 +74,27 @@ func NewProviderChain(providers ...LLMProvider) *ProviderChain {
 		fallbackOn:   len(providers) > 1, // 複数プロバイダーの場合のみ有効化
 		maxRetries:   3,
 		condition:    DefaultFallbackCondition,
+		health:       NewHealthCache(DefaultHealthCacheTTL),
+	}
+}
+
+// NewPinnedChain keys の順にプロバイダーを作成してチェーンを構築する（config の CHAIN 用）
+// 先頭がメイン、以降はその順のフォールバックになる。create が nil を返したキーは飛ばす。
+// 1つも作成できなければ nil を返す
+func NewPinnedChain(keys []string, create func(key string) LLMProvider) *ProviderChain {
+	var chain *ProviderChain
+	for _, key := range keys {
+		p := create(key)
+		if p == nil {
+			continue
+		}
+		if chain == nil {
+			chain = NewProviderChain(p)
+			continue
+		}
+		chain.AddProvider(p, RoleFallback)
 	}
+	return chain
 }
 
 // SetFallbackCondition フォールバック条件を設定
@@ -85,6 +111,13 @@ func (c *ProviderChain) SetFallbackCallback(cb FallbackCallback) {
 	c.onFallback = cb
 }
 
+// SetFallbackGuard フォールバック先への切り替え前の確認を設定
+func (c *ProviderChain) SetFallbackGuard(guard FallbackGuard) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.guard = guard
+}
+
 // AddProvider チェーンにプロバイダーを追加
 func (c *ProviderChain) AddProvider(provider LLMProvider, role ChainRole) {
 	c.mu.Lock()
@@ -99,6 +132,37 @@ func (c *ProviderChain) AddProvider(provider LLMProvider, role ChainRole) {
 	}
 }
 
+// NewlyDetected チェーンに未登録の検出済みプロバイダーを返す（ベースURLで比較）
+func (c *ProviderChain) NewlyDetected(detected []DetectedProvider) []DetectedProvider {
+	c.mu.RLock()
+	known := make(map[string]bool, len(c.entries))
+	for _, e := range c.entries {
+		known[normalizeBaseURL(e.Provider.Info().BaseURL)] = true
+	}
+	c.mu.RUnlock()
+
+	var fresh []DetectedProvider
+	for _, d := range detected {
+		url := normalizeBaseURL(d.URL)
+		if known[url] {
+			continue
+		}
+		known[url] = true
+		fresh = append(fresh, d)
+	}
+	return fresh
+}
+
+// AddDetected 未登録の検出済みプロバイダーをサブプロバイダーとして追加し、追加したものを返す
+// create は検出結果からプロバイダーを作成する（モデル選択などは呼び出し側の設定に従う）
+func (c *ProviderChain) AddDetected(detected []DetectedProvider, create func(DetectedProvider) LLMProvider) []DetectedProvider {
+	fresh := c.NewlyDetected(detected)
+	for _, d := range fresh {
+		c.AddProvider(create(d), RoleSub)
+	}
+	return fresh
+}
+
 // Len チェーンのプロバイダー数を返す
 func (c *ProviderChain) Len() int {
 	c.mu.RLock()
@@ -148,6 +212,7 @@ func (c *ProviderChain) Chat(ctx context.Context, req *ChatRequest) (*ChatRespon
 }
 
 // chatWithFallback プロバイダーチェーンでフォールバック付きチャット
+// 有用な出力（本文・ツール呼び出し）を1つも返さずに失敗した場合は、同じ呼び出しの中で次のプロバイダーに再送する
 func (c *ProviderChain) chatWithFallback(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
 	// 現在のプロバイダーから開始してリトライ
 	for attempt := 0; attempt < len(c.entries); attempt++ {
@@ -163,6 +228,11 @@ func (c *ProviderChain) chatWithFallback(ctx context.Context, req *ChatRequest)
 		// チャット実行
 		resp, err := provider.Chat(ctx, req)
 
+		// エラーなしでも中身が空なら失敗扱い（途中で応答が途切れたケース）
+		if err == nil && !hasUsefulContent(resp) {
+			err = fmt.Errorf("empty response from LLM (%s returned no content)", providerInfo.Name)
+		}
+
 		// 成功 → 失敗カウントをリセット
 		if err == nil {
 			c.mu.Lock()
@@ -172,43 +242,82 @@ func (c *ProviderChain) chatWithFallback(ctx context.Context, req *ChatRequest)
 			return resp, nil
 		}
 
-		// エラー発生 → Fallback 判定
-		if !c.shouldFallback(err) {
+		// 部分的な出力がある場合は再送すると重複するためそのまま返す
+		if hasUsefulContent(resp) || !c.shouldFallback(err) {
 			c.mu.Lock()
 			c.lastError = err
 			c.mu.Unlock()
-			return nil, err
+			return resp, err
 		}
 
 		// Fallback 発動 → 次のプロバイダーへ
-		classification := ClassifyError(err)
-		c.mu.Lock()
-		c.failureCount[c.current]++
-		c.failureTime[c.current] = time.Now()
-		c.lastError = err
-		c.mu.Unlock()
-
-		// リトライ前の待機
-		if delay := GetRetryDelay(classification, attempt); delay > 0 {
-			time.Sleep(delay)
+		if fbErr := c.fallbackFrom(ctx, providerInfo.Name, err, attempt); fbErr != nil {
+			return nil, fbErr
 		}
+	}
 
-		// 次のプロバイダーに切り替え
-		if !c.switchToNext() {
-			return nil, fmt.Errorf("all providers failed, last error: %w", err)
-		}
+	return nil, fmt.Errorf("all providers exhausted")
+}
 
-		// コールバック通知
-		c.mu.RLock()
-		nextProviderInfo := c.entries[c.current].Provider.Info()
-		cb := c.onFallback
-		c.mu.RUnlock()
-		if cb != nil {
-			cb(providerInfo.Name, nextProviderInfo.Name, classification)
+// fallbackFrom 失敗を記録して次のプロバイダーに切り替え、コールバックを通知する
+// 切り替え先がない場合、またはターンのリトライ予算を使い切った場合はエラーを返す
+func (c *ProviderChain) fallbackFrom(ctx context.Context, fromName string, err error, attempt int) error {
+	classification := ClassifyError(err)
+	c.mu.Lock()
+	c.failureCount[c.current]++
+	c.failureTime[c.current] = time.Now()
+	c.lastError = err
+	c.mu.Unlock()
+
+	if budget := RetryBudgetFrom(ctx); !budget.Take(fmt.Sprintf("provider %s: %v", fromName, err)) {
+		return budget.Exhausted(err)
+	}
+
+	// リトライ前の待機
+	if delay := GetRetryDelay(classification, attempt); delay > 0 {
+		time.Sleep(delay)
+	}
+
+	// 次のプロバイダーに切り替え
+	if switchErr := c.switchToNextGuarded(err); switchErr != nil {
+		return switchErr
+	}
+
+	// コールバック通知
+	c.mu.RLock()
+	nextProviderInfo := c.entries[c.current].Provider.Info()
+	cb := c.onFallback
+	c.mu.RUnlock()
+	if cb != nil {
+		cb(fromName, nextProviderInfo.Name, classification)
+	}
+	return nil
+}
+
+// hasUsefulContent レスポンスに本文またはツール呼び出しが含まれるか
+func hasUsefulContent(resp *ChatResponse) bool {
+	if resp == nil {
+		return false
+	}
+	for _, choice := range resp.Choices {
+		if strings.TrimSpace(choice.Message.Content) != "" || len(choice.Message.ToolCalls) > 0 {
+			return true
 		}
 	}
+	return false
+}
 
-	return nil, fmt.Errorf("all providers exhausted")
+// streamEventHasContent ストリームイベントに本文またはツール呼び出しが含まれるか
+func streamEventHasContent(event StreamEvent) bool {
+	if event.Delta != nil && (event.Delta.Content != "" || len(event.Delta.ToolCalls) > 0) {
+		return true
+	}
+	for _, tok := range event.Tokens {
+		if tok.Text != "" {
+			return true
+		}
+	}
+	return false
 }
 
 // ChatStream ストリーミングチャット（フォールバック対応）
@@ -255,7 +364,7 @@ func (c *ProviderChain) chatStreamWithFallback(ctx context.Context, req *ChatReq
 			c.mu.Unlock()
 
 			// イベントチャネルをラップして返す
-			return c.wrapStreamWithFallback(ctx, eventChan), nil
+			return c.wrapStreamWithFallback(ctx, req, provider.Info().Name, eventChan), nil
 		}
 
 		// エラー発生 → Fallback 判定
@@ -273,8 +382,12 @@ func (c *ProviderChain) chatStreamWithFallback(ctx context.Context, req *ChatReq
 		c.lastError = err
 		c.mu.Unlock()
 
-		if !c.switchToNext() {
-			return nil, fmt.Errorf("all providers failed, last error: %w", err)
+		if budget := RetryBudgetFrom(ctx); !budget.Take(fmt.Sprintf("provider %s: %v", provider.Info().Name, err)) {
+			return nil, budget.Exhausted(err)
+		}
+
+		if switchErr := c.switchToNextGuarded(err); switchErr != nil {
+			return nil, switchErr
 		}
 	}
 
@@ -282,16 +395,59 @@ func (c *ProviderChain) chatStreamWithFallback(ctx context.Context, req *ChatReq
 }
 
 // wrapStreamWithFallback ストリーミングをラップしてエラーをハンドル
-func (c *ProviderChain) wrapStreamWithFallback(ctx context.Context, eventChan <-chan StreamEvent) <-chan StreamEvent {
+// 有用な出力の前にエラーイベントが届いた場合は次のプロバイダーでストリームをやり直す
+func (c *ProviderChain) wrapStreamWithFallback(ctx context.Context, req *ChatRequest, providerName string, eventChan <-chan StreamEvent) <-chan StreamEvent {
 	outChan := make(chan StreamEvent, 1)
 	go func() {
 		defer close(outChan)
-		for event := range eventChan {
-			select {
-			case <-ctx.Done():
-				outChan <- StreamEvent{Error: ctx.Err()}
+		for attempt := 0; ; attempt++ {
+			useful := false
+			var streamErr error
+			for event := range eventChan {
+				if event.Error != nil && !useful && c.shouldFallback(event.Error) {
+					streamErr = event.Error
+					// 残りのイベントを読み捨てて送信側をブロックさせない
+					go func(ch <-chan StreamEvent) {
+						for range ch {
+						}
+					}(eventChan)
+					break
+				}
+				if streamEventHasContent(event) {
+					useful = true
+				}
+				select {
+				case <-ctx.Done():
+					outChan <- StreamEvent{Error: ctx.Err()}
+					return
+				case outChan <- event:
+				}
+			}
+
+			if streamErr == nil {
+				return
+			}
+
+			// 全プロバイダーを一巡したら諦める
+			if attempt+1 >= c.Len() {
+				outChan <- StreamEvent{Error: fmt.Errorf("all providers failed, last error: %w", streamErr)}
+				return
+			}
+			if err := c.fallbackFrom(ctx, providerName, streamErr, attempt); err != nil {
+				outChan <- StreamEvent{Error: err}
+				return
+			}
+
+			c.mu.RLock()
+			next := c.entries[c.current].Provider
+			c.mu.RUnlock()
+			providerName = next.Info().Name
+
+			var err error
+			eventChan, err = next.ChatStream(ctx, req)
+			if err != nil {
+				outChan <- StreamEvent{Error: err}
 				return
-			case outChan <- event:
 			}
 		}
 	}()
@@ -388,6 +544,33 @@ func (c *ProviderChain) switchToNext() bool {
 	}
 }
 
+// switchToNextGuarded 次のプロバイダーに切り替え、ガードが拒否したら元に戻す
+// err は切り替えの原因となったエラー（切り替え先がない場合のメッセージに使う）
+func (c *ProviderChain) switchToNextGuarded(err error) error {
+	c.mu.RLock()
+	prev := c.current
+	guard := c.guard
+	c.mu.RUnlock()
+
+	if !c.switchToNext() {
+		return fmt.Errorf("all providers failed, last error: %w", err)
+	}
+	if guard == nil {
+		return nil
+	}
+
+	c.mu.RLock()
+	next := c.entries[c.current].Provider
+	c.mu.RUnlock()
+	if guardErr := guard(next); guardErr != nil {
+		c.mu.Lock()
+		c.current = prev
+		c.mu.Unlock()
+		return guardErr
+	}
+	return nil
+}
+
 // GetLastError 最後のエラーを返す
 func (c *ProviderChain) GetLastError() error {
 	c.mu.RLock()
@@ -2,7 +2,10 @@ package llm
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"strings"
 	"testing"
 )
 
@@ -10,6 +13,7 @@ import (
 type mockChainProvider struct {
 	name      string
 	model     string
+	baseURL   string
 	chatErr   error
 	chatResp  *ChatResponse
 	healthErr error
@@ -37,12 +41,17 @@ func (m *mockChainProvider) CheckHealth(ctx context.Context) error {
 
 func (m *mockChainProvider) Info() ProviderInfo {
 	return ProviderInfo{
-		Name:  m.name,
-		Model: m.model,
-		Type:  ProviderTypeLocal,
+		Name:    m.name,
+		Model:   m.model,
+		BaseURL: m.baseURL,
+		Type:    ProviderTypeLocal,
 	}
 }
 
+func (m *mockChainProvider) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	return nil, embeddingsUnsupported(m.name)
+}
+
 func TestNewProviderChain(t *testing.T) {
 	p1 := &mockChainProvider{name: "main", model: "m1"}
 	p2 := &mockChainProvider{name: "sub", model: "m2"}
@@ -90,6 +99,72 @@ func TestProviderChain_FallbackOnError(t *testing.T) {
 	}
 }
 
+func TestProviderChain_FallbackStopsWhenRetryBudgetSpent(t *testing.T) {
+	p1 := &mockChainProvider{name: "main", model: "m1", chatErr: fmt.Errorf("connection refused")}
+	p2 := &mockChainProvider{name: "fallback", model: "m2"}
+
+	chain := NewProviderChain(p1, p2)
+
+	budget := NewRetryBudget(1)
+	budget.Take("earlier tool retry")
+	ctx := WithRetryBudget(context.Background(), budget)
+
+	_, err := chain.Chat(ctx, &ChatRequest{})
+	var budgetErr *RetryBudgetError
+	if !errors.As(err, &budgetErr) {
+		t.Fatalf("expected RetryBudgetError, got %v", err)
+	}
+	if len(budgetErr.Reasons) != 1 || budgetErr.Reasons[0] != "earlier tool retry" {
+		t.Errorf("Reasons = %v, want the earlier retry", budgetErr.Reasons)
+	}
+	if chain.CurrentIndex() != 0 {
+		t.Errorf("expected no switch to fallback, current = %d", chain.CurrentIndex())
+	}
+}
+
+func TestProviderChain_AddDetectedIncorporatesNewProvider(t *testing.T) {
+	lmStudio := &mockChainProvider{name: "lm-studio", model: "m1", baseURL: "http://localhost:1234", chatErr: fmt.Errorf("connection refused")}
+	chain := NewProviderChain(lmStudio)
+
+	// Ollama started after launch: detected alongside the already-registered provider
+	detected := []DetectedProvider{
+		{Name: "ollama", URL: "http://localhost:11434", Models: []string{"qwen3:8b"}, Health: true},
+		{Name: "lm-studio", URL: "http://localhost:1234/v1", Models: []string{"m1"}, Health: true},
+	}
+
+	fresh := chain.NewlyDetected(detected)
+	if len(fresh) != 1 || fresh[0].Name != "ollama" {
+		t.Fatalf("NewlyDetected = %+v, want only ollama", fresh)
+	}
+
+	added := chain.AddDetected(detected, func(d DetectedProvider) LLMProvider {
+		return &mockChainProvider{name: d.Name, model: d.Models[0], baseURL: d.URL}
+	})
+	if len(added) != 1 || added[0].Name != "ollama" {
+		t.Fatalf("AddDetected = %+v, want only ollama", added)
+	}
+	if chain.Len() != 2 {
+		t.Fatalf("expected 2 entries, got %d", chain.Len())
+	}
+	if role := chain.GetEntries()[1].Role; role != RoleSub {
+		t.Errorf("expected new entry role=sub, got %s", role)
+	}
+
+	// The new provider takes over when the main one fails
+	resp, err := chain.Chat(context.Background(), &ChatRequest{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Choices[0].Message.Content != "ok from ollama" {
+		t.Errorf("expected 'ok from ollama', got '%s'", resp.Choices[0].Message.Content)
+	}
+
+	// A second refresh finds nothing new
+	if again := chain.NewlyDetected(detected); len(again) != 0 {
+		t.Errorf("expected nothing new after adding, got %+v", again)
+	}
+}
+
 func TestProviderChain_NoFallbackOn4xx(t *testing.T) {
 	p1 := &mockChainProvider{name: "main", model: "m1", chatErr: fmt.Errorf("HTTP 401 Unauthorized")}
 	p2 := &mockChainProvider{name: "fallback", model: "m2"}
@@ -106,6 +181,131 @@ func TestProviderChain_NoFallbackOn4xx(t *testing.T) {
 	}
 }
 
+func TestProviderChain_FallbackAfterInterruptedResponse(t *testing.T) {
+	// 応答本文の途中で接続が切れたケース（有用な出力なし）
+	p1 := &mockChainProvider{name: "main", chatErr: fmt.Errorf("failed to read response: %w", io.ErrUnexpectedEOF)}
+	p2 := &mockChainProvider{name: "fallback"}
+
+	chain := NewProviderChain(p1, p2)
+
+	var cbFrom, cbTo string
+	chain.SetFallbackCallback(func(from, to string, class ErrorClassification) {
+		cbFrom, cbTo = from, to
+	})
+
+	resp, err := chain.Chat(context.Background(), &ChatRequest{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Choices[0].Message.Content != "ok from fallback" {
+		t.Errorf("expected content from second provider, got %q", resp.Choices[0].Message.Content)
+	}
+	if chain.GetFailureCount(0) != 1 {
+		t.Errorf("expected failure count=1 for provider 0, got %d", chain.GetFailureCount(0))
+	}
+	if cbFrom != "main" || cbTo != "fallback" {
+		t.Errorf("expected callback main→fallback, got %s→%s", cbFrom, cbTo)
+	}
+}
+
+func TestProviderChain_FallbackOnEmptyResponse(t *testing.T) {
+	p1 := &mockChainProvider{name: "main", chatResp: &ChatResponse{
+		Choices: []Choice{{Message: Message{Role: "assistant"}}},
+	}}
+	p2 := &mockChainProvider{name: "fallback"}
+
+	chain := NewProviderChain(p1, p2)
+
+	resp, err := chain.Chat(context.Background(), &ChatRequest{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Choices[0].Message.Content != "ok from fallback" {
+		t.Errorf("expected content from second provider, got %q", resp.Choices[0].Message.Content)
+	}
+	if chain.CurrentIndex() != 1 {
+		t.Errorf("expected chain to switch to provider 1, got %d", chain.CurrentIndex())
+	}
+}
+
+// partialErrProvider 部分的な出力とエラーを同時に返すプロバイダー
+type partialErrProvider struct {
+	mockChainProvider
+}
+
+func (p *partialErrProvider) Chat(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
+	return &ChatResponse{
+		Choices: []Choice{{Message: Message{Role: "assistant", Content: "partial"}}},
+	}, fmt.Errorf("connection reset by peer")
+}
+
+func TestProviderChain_NoFallbackAfterPartialOutput(t *testing.T) {
+	p1 := &partialErrProvider{mockChainProvider{name: "main"}}
+	p2 := &mockChainProvider{name: "fallback"}
+
+	chain := NewProviderChain(p1, p2)
+
+	resp, err := chain.Chat(context.Background(), &ChatRequest{})
+	if err == nil {
+		t.Fatal("expected error to be returned with partial output")
+	}
+	if resp == nil || resp.Choices[0].Message.Content != "partial" {
+		t.Errorf("expected partial response to be returned, got %+v", resp)
+	}
+	if chain.CurrentIndex() != 0 {
+		t.Errorf("chain should not switch after partial output, got %d", chain.CurrentIndex())
+	}
+}
+
+// streamMockProvider 指定イベントを流すストリーミング用モック
+type streamMockProvider struct {
+	mockChainProvider
+	events []StreamEvent
+}
+
+func (p *streamMockProvider) ChatStream(ctx context.Context, req *ChatRequest) (<-chan StreamEvent, error) {
+	ch := make(chan StreamEvent, len(p.events))
+	for _, ev := range p.events {
+		ch <- ev
+	}
+	close(ch)
+	return ch, nil
+}
+
+func TestProviderChain_StreamFallbackBeforeContent(t *testing.T) {
+	p1 := &streamMockProvider{
+		mockChainProvider: mockChainProvider{name: "main"},
+		events:            []StreamEvent{{Error: fmt.Errorf("connection reset by peer")}},
+	}
+	p2 := &streamMockProvider{
+		mockChainProvider: mockChainProvider{name: "fallback"},
+		events:            []StreamEvent{{Delta: &Delta{Content: "hello"}}, {Done: true}},
+	}
+
+	chain := NewProviderChain(p1, p2)
+
+	events, err := chain.ChatStream(context.Background(), &ChatRequest{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var content string
+	for ev := range events {
+		if ev.Error != nil {
+			t.Fatalf("unexpected stream error: %v", ev.Error)
+		}
+		if ev.Delta != nil {
+			content += ev.Delta.Content
+		}
+	}
+	if content != "hello" {
+		t.Errorf("expected content from second provider, got %q", content)
+	}
+	if chain.GetFailureCount(0) != 1 {
+		t.Errorf("expected failure count=1 for provider 0, got %d", chain.GetFailureCount(0))
+	}
+}
+
 func TestProviderChain_AllFail(t *testing.T) {
 	p1 := &mockChainProvider{name: "p1", chatErr: fmt.Errorf("connection refused")}
 	p2 := &mockChainProvider{name: "p2", chatErr: fmt.Errorf("connection refused")}
@@ -134,6 +334,47 @@ func TestProviderChain_AddProvider(t *testing.T) {
 	}
 }
 
+func TestNewPinnedChain_HonorsOrder(t *testing.T) {
+	providers := map[string]*mockChainProvider{
+		"anthropic": {name: "anthropic", chatErr: fmt.Errorf("connection refused")},
+		"ollama":    {name: "ollama", chatErr: fmt.Errorf("connection refused")},
+		"openai":    {name: "openai"},
+	}
+	var created []string
+	chain := NewPinnedChain([]string{"anthropic", "missing", "ollama", "openai"}, func(key string) LLMProvider {
+		created = append(created, key)
+		if p, ok := providers[key]; ok {
+			return p
+		}
+		return nil
+	})
+
+	if strings.Join(created, ",") != "anthropic,missing,ollama,openai" {
+		t.Errorf("providers created in order %v", created)
+	}
+	entries := chain.GetEntries()
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Provider.Info().Name)
+	}
+	if strings.Join(names, ",") != "anthropic,ollama,openai" {
+		t.Fatalf("chain order = %v, want the pinned order without the missing provider", names)
+	}
+	if entries[0].Role != RoleMain || entries[1].Role != RoleFallback || entries[2].Role != RoleFallback {
+		t.Errorf("roles = %s, %s, %s; want main then fallbacks", entries[0].Role, entries[1].Role, entries[2].Role)
+	}
+
+	// Failures fall through the providers in the pinned order
+	resp, err := chain.Chat(context.Background(), &ChatRequest{})
+	if err != nil || resp.Choices[0].Message.Content != "ok from openai" {
+		t.Errorf("Chat = %v, %v; want the third provider after two failures", resp, err)
+	}
+
+	if NewPinnedChain([]string{"missing"}, func(string) LLMProvider { return nil }) != nil {
+		t.Error("a chain with no providers should be nil")
+	}
+}
+
 func TestProviderChain_SwitchTo(t *testing.T) {
 	p1 := &mockChainProvider{name: "p1"}
 	p2 := &mockChainProvider{name: "p2"}
@@ -245,6 +486,9 @@ func TestClassifyError(t *testing.T) {
 		{"timeout", fmt.Errorf("context deadline exceeded"), ErrorClassTimeout},
 		{"network", fmt.Errorf("connection refused"), ErrorClassNetwork},
 		{"server 500", fmt.Errorf("HTTP 500 Internal Server Error"), ErrorClassServerError},
+		{"provider status 503", fmt.Errorf("request failed with status 503: overloaded"), ErrorClassServerError},
+		{"unexpected EOF", fmt.Errorf("failed to read response: %w", io.ErrUnexpectedEOF), ErrorClassNetwork},
+		{"connection reset", fmt.Errorf("read tcp 127.0.0.1:1234: connection reset by peer"), ErrorClassNetwork},
 		{"client 401", fmt.Errorf("HTTP 401 Unauthorized"), ErrorClassClientError},
 		{"context window", fmt.Errorf("context length exceeds maximum"), ErrorClassContextWindow},
 		{"context window exceeded", fmt.Errorf("context length exceeded"), ErrorClassContextWindow},
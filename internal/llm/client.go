@@ -21,6 +21,7 @@ type ChatRequest struct {
 	Stream      bool                   `json:"stream"`
 	Temperature float64                `json:"temperature,omitempty"`
 	MaxTokens   int                    `json:"max_tokens,omitempty"`
+	N           int                    `json:"n,omitempty"` // 生成する候補数（Features.MultipleChoices 対応プロバイダーのみ）
 	Options     map[string]interface{} `json:"options,omitempty"`
 }
 
@@ -29,7 +30,7 @@ type Message struct {
 	Role      string     `json:"role"`
 	Content   string     `json:"content"`
 	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
-	ToolID    string     `json:"tool_id,omitempty"`
+	ToolID    string     `json:"tool_call_id,omitempty"`
 }
 
 // ToolCall represents a tool call request
@@ -3,6 +3,7 @@ package llm
 import (
 	"context"
 	"encoding/json"
+	"strings"
 	"testing"
 	"time"
 )
@@ -322,6 +323,11 @@ func TestMessage_WithToolID(t *testing.T) {
 		t.Fatalf("failed to marshal: %v", err)
 	}
 
+	// OpenAI-compatible APIs pair tool results with calls by tool_call_id
+	if !strings.Contains(string(data), `"tool_call_id":"call_123"`) {
+		t.Errorf("expected tool_call_id in %s", data)
+	}
+
 	var unmarshaled Message
 	if err := json.Unmarshal(data, &unmarshaled); err != nil {
 		t.Fatalf("failed to unmarshal: %v", err)
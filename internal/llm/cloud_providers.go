@@ -1,17 +1,27 @@
 package llm
 
+import "strings"
+
 // クラウドプロバイダー定義
 // 各プロバイダーの接続情報とデフォルト設定を一元管理する
 
 // CloudProviderDef クラウドプロバイダーの定義
 type CloudProviderDef struct {
-	Name         string   // 表示名
-	Key          string   // config内キー ("openrouter", "openai", etc.)
-	Category     string   // カテゴリ ("major", "aggregator", "fast", "specialized")
-	BaseURL      string   // API基盤URL
-	EnvKey       string   // 環境変数名
-	DefaultModel string   // デフォルトモデル
-	Models       []string // 推奨モデル一覧
+	Name         string                // 表示名
+	Key          string                // config内キー ("openrouter", "openai", etc.)
+	Category     string                // カテゴリ ("major", "aggregator", "fast", "specialized")
+	BaseURL      string                // API基盤URL
+	EnvKey       string                // 環境変数名
+	DefaultModel string                // デフォルトモデル
+	Models       []string              // 推奨モデル一覧
+	ModelAliases map[string]string     // 略称 → 正式モデルID（小文字で登録、NormalizeModelName で使用）
+	Prices       map[string]ModelPrice // 正式モデルID → 料金（/usage のコスト概算用、未登録のモデルはコスト不明）
+}
+
+// ModelPrice 100万トークンあたりの料金（USD、公開価格の目安）
+type ModelPrice struct {
+	Input  float64 // プロンプト
+	Output float64 // 補完
 }
 
 // LocalProviderDef ローカルプロバイダーの定義
@@ -86,6 +96,21 @@ var CloudProviders = []CloudProviderDef{
 			"deepseek/deepseek-chat-v3-0324",
 			"moonshotai/kimi-k2-instruct",
 		},
+		ModelAliases: map[string]string{
+			"gemini-flash":  "google/gemini-2.5-flash",
+			"claude-sonnet": "anthropic/claude-sonnet-4",
+			"sonnet":        "anthropic/claude-sonnet-4",
+			"gpt4":          "openai/gpt-4.1",
+			"gpt-4.1":       "openai/gpt-4.1",
+			"llama-4":       "meta-llama/llama-4-maverick",
+			"deepseek":      "deepseek/deepseek-chat-v3-0324",
+			"kimi":          "moonshotai/kimi-k2-instruct",
+		},
+		Prices: map[string]ModelPrice{
+			"google/gemini-2.5-flash":   {Input: 0.30, Output: 2.50},
+			"anthropic/claude-sonnet-4": {Input: 3, Output: 15},
+			"openai/gpt-4.1":            {Input: 2, Output: 8},
+		},
 	},
 	// === 主要プロバイダー ===
 	{
@@ -103,6 +128,23 @@ var CloudProviders = []CloudProviderDef{
 			"o4-mini",
 			"gpt-4o",
 		},
+		ModelAliases: map[string]string{
+			"gpt4":      "gpt-4.1",
+			"gpt4.1":    "gpt-4.1",
+			"gpt4-mini": "gpt-4.1-mini",
+			"gpt4-nano": "gpt-4.1-nano",
+			"gpt4o":     "gpt-4o",
+			"4o":        "gpt-4o",
+			"o4mini":    "o4-mini",
+		},
+		Prices: map[string]ModelPrice{
+			"gpt-4.1":      {Input: 2, Output: 8},
+			"gpt-4.1-mini": {Input: 0.40, Output: 1.60},
+			"gpt-4.1-nano": {Input: 0.10, Output: 0.40},
+			"o3":           {Input: 2, Output: 8},
+			"o4-mini":      {Input: 1.10, Output: 4.40},
+			"gpt-4o":       {Input: 2.50, Output: 10},
+		},
 	},
 	{
 		Name:         "Anthropic (Claude)",
@@ -117,6 +159,25 @@ var CloudProviders = []CloudProviderDef{
 			"claude-sonnet-4-5-20250929",
 			"claude-haiku-4-5-20251001",
 		},
+		ModelAliases: map[string]string{
+			"sonnet":            "claude-sonnet-4-5-20250929",
+			"claude-sonnet":     "claude-sonnet-4-5-20250929",
+			"claude-sonnet-4.5": "claude-sonnet-4-5-20250929",
+			"claude-sonnet-4-5": "claude-sonnet-4-5-20250929",
+			"claude-sonnet-4":   "claude-sonnet-4-20250514",
+			"opus":              "claude-opus-4-20250514",
+			"claude-opus":       "claude-opus-4-20250514",
+			"claude-opus-4":     "claude-opus-4-20250514",
+			"haiku":             "claude-haiku-4-5-20251001",
+			"claude-haiku":      "claude-haiku-4-5-20251001",
+			"claude-haiku-4-5":  "claude-haiku-4-5-20251001",
+		},
+		Prices: map[string]ModelPrice{
+			"claude-sonnet-4-20250514":   {Input: 3, Output: 15},
+			"claude-opus-4-20250514":     {Input: 15, Output: 75},
+			"claude-sonnet-4-5-20250929": {Input: 3, Output: 15},
+			"claude-haiku-4-5-20251001":  {Input: 1, Output: 5},
+		},
 	},
 	{
 		Name:         "Google (Gemini)",
@@ -130,6 +191,18 @@ var CloudProviders = []CloudProviderDef{
 			"gemini-2.5-pro",
 			"gemini-2.0-flash",
 		},
+		ModelAliases: map[string]string{
+			"gemini":       "gemini-2.5-flash",
+			"gemini-flash": "gemini-2.5-flash",
+			"flash":        "gemini-2.5-flash",
+			"gemini-pro":   "gemini-2.5-pro",
+			"pro":          "gemini-2.5-pro",
+		},
+		Prices: map[string]ModelPrice{
+			"gemini-2.5-flash": {Input: 0.30, Output: 2.50},
+			"gemini-2.5-pro":   {Input: 1.25, Output: 10},
+			"gemini-2.0-flash": {Input: 0.10, Output: 0.40},
+		},
 	},
 	{
 		Name:         "DeepSeek",
@@ -142,6 +215,17 @@ var CloudProviders = []CloudProviderDef{
 			"deepseek-chat",
 			"deepseek-reasoner",
 		},
+		ModelAliases: map[string]string{
+			"deepseek":    "deepseek-chat",
+			"deepseek-v3": "deepseek-chat",
+			"v3":          "deepseek-chat",
+			"deepseek-r1": "deepseek-reasoner",
+			"r1":          "deepseek-reasoner",
+		},
+		Prices: map[string]ModelPrice{
+			"deepseek-chat":     {Input: 0.27, Output: 1.10},
+			"deepseek-reasoner": {Input: 0.55, Output: 2.19},
+		},
 	},
 	{
 		Name:         "Mistral",
@@ -157,6 +241,11 @@ var CloudProviders = []CloudProviderDef{
 			"magistral-medium-latest",
 			"mistral-small-latest",
 		},
+		Prices: map[string]ModelPrice{
+			"mistral-large-latest": {Input: 2, Output: 6},
+			"codestral-latest":     {Input: 0.30, Output: 0.90},
+			"mistral-small-latest": {Input: 0.10, Output: 0.30},
+		},
 	},
 	// === 高速推論 ===
 	{
@@ -173,6 +262,10 @@ var CloudProviders = []CloudProviderDef{
 			"llama-3.1-8b-instant",
 			"gemma2-9b-it",
 		},
+		Prices: map[string]ModelPrice{
+			"llama-3.3-70b-versatile": {Input: 0.59, Output: 0.79},
+			"llama-3.1-8b-instant":    {Input: 0.05, Output: 0.08},
+		},
 	},
 	{
 		Name:         "Together AI",
@@ -297,6 +390,62 @@ func GetCloudProviderDef(key string) *CloudProviderDef {
 	return nil
 }
 
+// EstimateCost はプロバイダーの料金表からコストの概算（USD）を返す。
+// 応答に含まれる日付付きのスナップショットID（gpt-4o-2024-08-06 等）は元のモデルの料金で計算する。
+// 料金表に無いモデル（ローカルプロバイダーを含む）は false
+func EstimateCost(providerKey, model string, promptTokens, completionTokens int) (float64, bool) {
+	def := GetCloudProviderDef(providerKey)
+	if def == nil {
+		return 0, false
+	}
+	model, _ = NormalizeModelName(providerKey, model)
+	price, ok := def.Prices[model]
+	if !ok {
+		price, ok = snapshotPrice(def.Prices, model)
+	}
+	if !ok {
+		return 0, false
+	}
+	return (float64(promptTokens)*price.Input + float64(completionTokens)*price.Output) / 1_000_000, true
+}
+
+// snapshotPrice 料金表のモデルIDに日付の接尾辞 (-2024-08-06, -20250514, -2411 等) が付いたモデルの料金を返す。
+// 該当するキーが複数あれば最も長いものを使う（gpt-4o-mini を gpt-4o の料金にしないよう、接尾辞は数字とハイフンのみ）
+func snapshotPrice(prices map[string]ModelPrice, model string) (ModelPrice, bool) {
+	var best string
+	for key := range prices {
+		rest, ok := strings.CutPrefix(model, key+"-")
+		if !ok || rest == "" || strings.Trim(rest, "0123456789-") != "" {
+			continue
+		}
+		if len(key) > len(best) {
+			best = key
+		}
+	}
+	if best == "" {
+		return ModelPrice{}, false
+	}
+	return prices[best], true
+}
+
+// NormalizeModelName 略称モデル名をプロバイダーの正式モデルIDに変換する
+// 推奨モデル一覧にある名前や略称に該当しない名前はそのまま返す。略称を変換した場合は true
+func NormalizeModelName(providerKey, model string) (string, bool) {
+	def := GetCloudProviderDef(providerKey)
+	if def == nil || model == "" {
+		return model, false
+	}
+	for _, m := range def.Models {
+		if m == model {
+			return model, false
+		}
+	}
+	if canonical, ok := def.ModelAliases[strings.ToLower(strings.TrimSpace(model))]; ok && canonical != model {
+		return canonical, true
+	}
+	return model, false
+}
+
 // GetProvidersByCategory カテゴリ別にプロバイダーを取得
 func GetProvidersByCategory(category string) []CloudProviderDef {
 	var result []CloudProviderDef
@@ -325,7 +474,7 @@ func GetLocalProviders() []LocalProviderDef {
 
 // NewCloudProvider クラウドプロバイダーを作成（OpenAI互換）
 // BaseURL にはバージョンパスまで含まれている前提（例: /v1, /v4, /v1beta/openai）
-// OpenRouter のみ固有ヘッダー付きの専用実装を使用
+// OpenRouter は固有ヘッダー付き、Anthropic はネイティブ Messages API の専用実装を使用
 func NewCloudProvider(providerKey, apiKey, model string) LLMProvider {
 	def := GetCloudProviderDef(providerKey)
 	if def == nil {
@@ -342,6 +491,11 @@ func NewCloudProvider(providerKey, apiKey, model string) LLMProvider {
 		return NewOpenRouterProvider(apiKey, model)
 	}
 
+	// Anthropic は tool_use / tool_result ブロック形式のためネイティブ実装
+	if providerKey == "anthropic" {
+		return NewAnthropicProvider(apiKey, model)
+	}
+
 	// それ以外は全て汎用 OpenAI互換プロバイダー
 	// BaseURL + "/chat/completions" でエンドポイントが構築される
 	info := ProviderInfo{
@@ -353,6 +507,8 @@ func NewCloudProvider(providerKey, apiKey, model string) LLMProvider {
 			NativeFunctionCalling: true,
 			ModelManagement:       false,
 			Streaming:             true,
+			// n>1 は OpenAI 本家のみ確実に対応（互換APIは無視するものが多い）
+			MultipleChoices: providerKey == "openai",
 		},
 	}
 	return NewOpenAICompatProvider(def.BaseURL, apiKey, model, info)
@@ -1,6 +1,7 @@
 package llm
 
 import (
+	"slices"
 	"strings"
 	"testing"
 )
@@ -200,3 +201,105 @@ func TestCloudProviderDef_EnvKeyFormat(t *testing.T) {
 		})
 	}
 }
+
+// TestNormalizeModelName 略称が各プロバイダーの正式モデルIDに解決されるか
+func TestNormalizeModelName(t *testing.T) {
+	tests := []struct {
+		provider    string
+		model       string
+		want        string
+		wantGuessed bool
+	}{
+		{"openai", "gpt4", "gpt-4.1", true},
+		{"openai", "GPT4o", "gpt-4o", true},
+		{"anthropic", "claude-sonnet", "claude-sonnet-4-5-20250929", true},
+		{"anthropic", "opus", "claude-opus-4-20250514", true},
+		{"openrouter", "claude-sonnet", "anthropic/claude-sonnet-4", true},
+		{"deepseek", "r1", "deepseek-reasoner", true},
+		// 正式名・未知の名前はそのまま
+		{"openai", "gpt-4.1-mini", "gpt-4.1-mini", false},
+		{"anthropic", "claude-sonnet-4-20250514", "claude-sonnet-4-20250514", false},
+		{"openai", "gpt-5-preview", "gpt-5-preview", false},
+		{"groq", "gpt4", "gpt4", false},
+		{"unknown-provider", "gpt4", "gpt4", false},
+		{"openai", "", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.provider+"/"+tt.model, func(t *testing.T) {
+			got, guessed := NormalizeModelName(tt.provider, tt.model)
+			if got != tt.want || guessed != tt.wantGuessed {
+				t.Errorf("NormalizeModelName(%q, %q) = (%q, %v), want (%q, %v)",
+					tt.provider, tt.model, got, guessed, tt.want, tt.wantGuessed)
+			}
+		})
+	}
+}
+
+// TestModelAliases_TargetsAreKnownModels 略称の変換先は推奨モデル一覧に含まれているか
+func TestModelAliases_TargetsAreKnownModels(t *testing.T) {
+	for _, def := range CloudProviders {
+		for alias, target := range def.ModelAliases {
+			if alias != strings.ToLower(alias) {
+				t.Errorf("%s: alias %q must be lowercase", def.Key, alias)
+			}
+			found := false
+			for _, m := range def.Models {
+				if m == target {
+					found = true
+					break
+				}
+			}
+			if !found {
+				t.Errorf("%s: alias %q maps to %q which is not in Models", def.Key, alias, target)
+			}
+		}
+	}
+}
+
+// TestEstimateCost 料金表にあるモデルだけコストを概算できるか
+func TestEstimateCost(t *testing.T) {
+	// gpt-4.1: 100万トークンあたり $2 / $8
+	cost, ok := EstimateCost("openai", "gpt-4.1", 1_000_000, 500_000)
+	if !ok || cost != 6 {
+		t.Errorf("EstimateCost(gpt-4.1) = %v, %v; want 6, true", cost, ok)
+	}
+
+	// 略称は正式モデルIDの料金になる
+	if _, ok := EstimateCost("anthropic", "sonnet", 1000, 1000); !ok {
+		t.Error("expected the sonnet alias to be priced")
+	}
+
+	// 応答の日付付きスナップショットIDは元のモデルの料金
+	if cost, ok := EstimateCost("openai", "gpt-4o-2024-08-06", 1_000_000, 0); !ok || cost != 2.5 {
+		t.Errorf("EstimateCost(gpt-4o-2024-08-06) = %v, %v; want 2.5, true", cost, ok)
+	}
+	if cost, ok := EstimateCost("openai", "gpt-4.1-mini-2025-04-14", 1_000_000, 0); !ok || cost != 0.4 {
+		t.Errorf("EstimateCost(gpt-4.1-mini-2025-04-14) = %v, %v; want 0.4 (gpt-4.1-mini), true", cost, ok)
+	}
+
+	for _, tc := range []struct{ provider, model string }{
+		{"ollama", "qwen3:8b"},
+		{"openai", "some-future-model"},
+		{"openai", "gpt-4o-mini"},
+		{"perplexity", "sonar-pro"},
+	} {
+		if cost, ok := EstimateCost(tc.provider, tc.model, 1000, 1000); ok {
+			t.Errorf("EstimateCost(%s, %s) = %v, want unknown", tc.provider, tc.model, cost)
+		}
+	}
+}
+
+// TestCloudProviderDef_PricesAreForListedModels 料金表のモデルが推奨モデル一覧にあるか
+func TestCloudProviderDef_PricesAreForListedModels(t *testing.T) {
+	for _, def := range CloudProviders {
+		for model, price := range def.Prices {
+			if !slices.Contains(def.Models, model) {
+				t.Errorf("%s: priced model %q is not in Models", def.Key, model)
+			}
+			if price.Input <= 0 || price.Output <= 0 {
+				t.Errorf("%s: %q has a non-positive price %+v", def.Key, model, price)
+			}
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// ErrEmbeddingsUnsupported 埋め込みベクトルの生成に対応していないプロバイダーが返すエラー
+var ErrEmbeddingsUnsupported = errors.New("embeddings are not supported by this provider")
+
+// embeddingsUnsupported プロバイダー名付きの非対応エラーを返す
+func embeddingsUnsupported(name string) error {
+	return fmt.Errorf("%s: %w", name, ErrEmbeddingsUnsupported)
+}
+
+// SetEmbeddingModel 埋め込みに使うモデルを設定（"" = チャットと同じモデル）
+func (p *OpenAICompatProvider) SetEmbeddingModel(model string) {
+	p.embeddingModel = model
+}
+
+// embeddingModelName 埋め込みリクエストに使うモデル名
+func (p *OpenAICompatProvider) embeddingModelName() string {
+	if p.embeddingModel != "" {
+		return p.embeddingModel
+	}
+	return p.model
+}
+
+// Embed テキストごとの埋め込みベクトルを返す（OpenAI互換 /embeddings）
+func (p *OpenAICompatProvider) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	if len(texts) == 0 {
+		return nil, nil
+	}
+	body, err := p.postJSON(ctx, p.baseURL+"/embeddings", map[string]interface{}{
+		"model": p.embeddingModelName(),
+		"input": texts,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return parseOpenAIEmbeddings(body, len(texts))
+}
+
+// postJSON JSON を POST し、200 以外はエラーとして本文を返す
+func (p *OpenAICompatProvider) postJSON(ctx context.Context, url string, payload interface{}) ([]byte, error) {
+	jsonData, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(jsonData))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if p.apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+p.apiKey)
+	}
+
+	resp, err := p.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := readResponseBody(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var errResp ErrorResponse
+		if jsonErr := json.Unmarshal(body, &errResp); jsonErr == nil && errResp.Error.Message != "" {
+			return nil, fmt.Errorf("LLM error: %s", errResp.Error.Message)
+		}
+		return nil, fmt.Errorf("request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+	return body, nil
+}
+
+// parseOpenAIEmbeddings /embeddings のレスポンスを入力順のベクトルに変換する
+// （data[].index で並べ替え、件数が want と合わなければエラー）
+func parseOpenAIEmbeddings(body []byte, want int) ([][]float32, error) {
+	var resp struct {
+		Data []struct {
+			Index     int       `json:"index"`
+			Embedding []float32 `json:"embedding"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse embeddings response: %w", err)
+	}
+	if len(resp.Data) != want {
+		return nil, fmt.Errorf("embeddings response has %d vectors, expected %d", len(resp.Data), want)
+	}
+
+	vectors := make([][]float32, want)
+	for _, d := range resp.Data {
+		if d.Index < 0 || d.Index >= want || vectors[d.Index] != nil {
+			return nil, fmt.Errorf("embeddings response has an invalid index %d", d.Index)
+		}
+		if len(d.Embedding) == 0 {
+			return nil, fmt.Errorf("embeddings response has an empty vector at index %d", d.Index)
+		}
+		vectors[d.Index] = d.Embedding
+	}
+	return vectors, nil
+}
+
+// Embed テキストごとの埋め込みベクトルを返す（Ollama /api/embeddings は1件ずつ）
+func (o *OllamaProvider) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, 0, len(texts))
+	for _, text := range texts {
+		body, err := o.postJSON(ctx, o.ollamaURL+"/api/embeddings", map[string]interface{}{
+			"model":  o.embeddingModelName(),
+			"prompt": text,
+		})
+		if err != nil {
+			return nil, err
+		}
+		vector, err := parseOllamaEmbedding(body)
+		if err != nil {
+			return nil, err
+		}
+		vectors = append(vectors, vector)
+	}
+	return vectors, nil
+}
+
+// parseOllamaEmbedding /api/embeddings のレスポンスからベクトルを取り出す
+func parseOllamaEmbedding(body []byte) ([]float32, error) {
+	var resp struct {
+		Embedding []float32 `json:"embedding"`
+		Error     string    `json:"error"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse embeddings response: %w", err)
+	}
+	if resp.Error != "" {
+		return nil, fmt.Errorf("Ollama error: %s", resp.Error)
+	}
+	if len(resp.Embedding) == 0 {
+		return nil, fmt.Errorf("embeddings response has no vector (does the model support embeddings?)")
+	}
+	return resp.Embedding, nil
+}
+
+// Embed Anthropic API には埋め込みエンドポイントがない
+func (p *AnthropicProvider) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	return nil, embeddingsUnsupported(p.Info().Name)
+}
+
+// Embed 現在のプロバイダーで埋め込みを生成する
+func (c *ProviderChain) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	provider := c.GetCurrentProvider()
+	if provider == nil {
+		return nil, fmt.Errorf("no providers in chain")
+	}
+	return provider.Embed(ctx, texts)
+}
+
+// Embed メインプロバイダーで埋め込みを生成する（サイドカーとでベクトルが混ざらないように）
+func (mr *ModelRouter) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	return mr.mainProvider.Embed(ctx, texts)
+}
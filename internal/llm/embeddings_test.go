@@ -0,0 +1,103 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestParseOpenAIEmbeddings_OrdersByIndex(t *testing.T) {
+	body := []byte(`{"object":"list","data":[
+		{"object":"embedding","index":1,"embedding":[0.3,0.4]},
+		{"object":"embedding","index":0,"embedding":[0.1,0.2]}
+	],"model":"text-embedding-3-small"}`)
+
+	vectors, err := parseOpenAIEmbeddings(body, 2)
+	if err != nil {
+		t.Fatalf("parseOpenAIEmbeddings: %v", err)
+	}
+	if len(vectors) != 2 || vectors[0][0] != 0.1 || vectors[1][1] != 0.4 {
+		t.Errorf("vectors = %v, want them in input order", vectors)
+	}
+}
+
+func TestParseOpenAIEmbeddings_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want int
+	}{
+		{"not json", `<html>`, 1},
+		{"count mismatch", `{"data":[{"index":0,"embedding":[1]}]}`, 2},
+		{"index out of range", `{"data":[{"index":3,"embedding":[1]}]}`, 1},
+		{"duplicate index", `{"data":[{"index":0,"embedding":[1]},{"index":0,"embedding":[2]}]}`, 2},
+		{"empty vector", `{"data":[{"index":0,"embedding":[]}]}`, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := parseOpenAIEmbeddings([]byte(tt.body), tt.want); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestParseOllamaEmbedding(t *testing.T) {
+	vector, err := parseOllamaEmbedding([]byte(`{"embedding":[0.5,-0.25,1]}`))
+	if err != nil || len(vector) != 3 || vector[1] != -0.25 {
+		t.Errorf("parseOllamaEmbedding = %v, %v", vector, err)
+	}
+
+	for _, body := range []string{`{"embedding":[]}`, `{"error":"model not found"}`, `oops`} {
+		if _, err := parseOllamaEmbedding([]byte(body)); err == nil {
+			t.Errorf("parseOllamaEmbedding(%s): expected an error", body)
+		}
+	}
+}
+
+func TestOllamaProvider_Embed(t *testing.T) {
+	var prompts []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/embeddings" {
+			http.NotFound(w, r)
+			return
+		}
+		var req struct {
+			Model  string `json:"model"`
+			Prompt string `json:"prompt"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.Model != "nomic-embed-text" {
+			t.Errorf("model = %q, want the embedding model", req.Model)
+		}
+		prompts = append(prompts, req.Prompt)
+		w.Write([]byte(`{"embedding":[` + map[string]string{"a": "1,0", "b": "0,1"}[req.Prompt] + `]}`))
+	}))
+	defer server.Close()
+
+	provider := NewOllamaProvider(server.URL, "qwen3:8b")
+	provider.SetEmbeddingModel("nomic-embed-text")
+	vectors, err := provider.Embed(context.Background(), []string{"a", "b"})
+	if err != nil {
+		t.Fatalf("Embed: %v", err)
+	}
+	if strings.Join(prompts, ",") != "a,b" || vectors[0][0] != 1 || vectors[1][1] != 1 {
+		t.Errorf("prompts = %v, vectors = %v", prompts, vectors)
+	}
+}
+
+func TestEmbed_Unsupported(t *testing.T) {
+	_, err := NewAnthropicProvider("key", "claude-sonnet-4-5").Embed(context.Background(), []string{"x"})
+	if !errors.Is(err, ErrEmbeddingsUnsupported) {
+		t.Errorf("Anthropic Embed error = %v, want ErrEmbeddingsUnsupported", err)
+	}
+
+	chain := NewProviderChain(&mockChainProvider{name: "main"})
+	if _, err := chain.Embed(context.Background(), []string{"x"}); !errors.Is(err, ErrEmbeddingsUnsupported) || !strings.Contains(err.Error(), "main") {
+		t.Errorf("chain Embed error = %v, want the provider's unsupported error", err)
+	}
+}
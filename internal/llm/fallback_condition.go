@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"net"
 	"strings"
 	"time"
@@ -68,13 +69,18 @@ func ClassifyError(err error) ErrorClassification {
 	}
 
 	// ネットワークエラー
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		return ErrorClassNetwork
+	}
 	var netErr net.Error
 	if errors.As(err, &netErr) {
 		return ErrorClassNetwork
 	}
 	if strings.Contains(errStr, "connection refused") ||
 		strings.Contains(errStr, "no such host") ||
-		strings.Contains(errStr, "network is unreachable") {
+		strings.Contains(errStr, "network is unreachable") ||
+		strings.Contains(errStr, "connection reset") ||
+		strings.Contains(errStr, "unexpected EOF") {
 		return ErrorClassNetwork
 	}
 
@@ -109,7 +115,8 @@ func ClassifyError(err error) ErrorClassification {
 	}
 
 	// サーバーエラー (5xx)
-	if strings.HasPrefix(errStr, "HTTP 5") {
+	// プロバイダー実装は "request failed with status 503" 等の形式で返す
+	if strings.HasPrefix(errStr, "HTTP 5") || strings.Contains(errStr, "status 5") {
 		return ErrorClassServerError
 	}
 
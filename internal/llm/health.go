@@ -0,0 +1,88 @@
+package llm
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultHealthCacheTTL 接続確認の結果を再利用する期間
+	DefaultHealthCacheTTL = 15 * time.Second
+	// DefaultHealthCheckTimeout 1プロバイダーあたりの接続確認の制限時間
+	DefaultHealthCheckTimeout = 5 * time.Second
+)
+
+// healthResult 接続確認の結果と確認時刻
+type healthResult struct {
+	err     error
+	checked time.Time
+}
+
+// HealthCache プロバイダーの接続確認結果を短時間キャッシュする（/providers, /chain の表示用）
+type HealthCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	timeout time.Duration
+	now     func() time.Time
+	results map[LLMProvider]healthResult
+}
+
+// NewHealthCache 新しいキャッシュを作成（ttl <= 0 でキャッシュしない）
+func NewHealthCache(ttl time.Duration) *HealthCache {
+	return &HealthCache{
+		ttl:     ttl,
+		timeout: DefaultHealthCheckTimeout,
+		now:     time.Now,
+		results: make(map[LLMProvider]healthResult),
+	}
+}
+
+// Check providers の接続を確認し、provider ごとのエラー（nil = 接続OK）を返す。
+// TTL 内の結果は再利用し（force = true なら再確認）、確認が必要なものは並行して
+// 1件あたり timeout で打ち切るので、応答しないプロバイダーが他を待たせない
+func (h *HealthCache) Check(ctx context.Context, providers []LLMProvider, force bool) []error {
+	errs := make([]error, len(providers))
+	now := h.now()
+
+	var pending []int
+	h.mu.Lock()
+	for i, p := range providers {
+		if r, ok := h.results[p]; ok && !force && now.Sub(r.checked) < h.ttl {
+			errs[i] = r.err
+			continue
+		}
+		pending = append(pending, i)
+	}
+	h.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, i := range pending {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			checkCtx, cancel := context.WithTimeout(ctx, h.timeout)
+			defer cancel()
+			errs[i] = providers[i].CheckHealth(checkCtx)
+		}(i)
+	}
+	wg.Wait()
+
+	h.mu.Lock()
+	checked := h.now()
+	for _, i := range pending {
+		h.results[providers[i]] = healthResult{err: errs[i], checked: checked}
+	}
+	h.mu.Unlock()
+	return errs
+}
+
+// CheckAllHealth チェーンの全エントリの接続を確認する（エントリ順のエラー、nil = 接続OK）
+func (c *ProviderChain) CheckAllHealth(ctx context.Context, force bool) []error {
+	entries := c.GetEntries()
+	providers := make([]LLMProvider, len(entries))
+	for i, e := range entries {
+		providers[i] = e.Provider
+	}
+	return c.health.Check(ctx, providers, force)
+}
@@ -0,0 +1,84 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingHealthProvider counts CheckHealth calls; block makes it wait for the context
+type countingHealthProvider struct {
+	mockChainProvider
+	probes atomic.Int32
+	block  bool
+}
+
+func (p *countingHealthProvider) CheckHealth(ctx context.Context) error {
+	p.probes.Add(1)
+	if p.block {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	return p.healthErr
+}
+
+func TestProviderChain_CheckAllHealthCachesWithinTTL(t *testing.T) {
+	up := &countingHealthProvider{mockChainProvider: mockChainProvider{name: "up"}}
+	down := &countingHealthProvider{mockChainProvider: mockChainProvider{name: "down", healthErr: errors.New("connection refused")}}
+	chain := NewProviderChain(up, down)
+
+	first := chain.CheckAllHealth(context.Background(), false)
+	second := chain.CheckAllHealth(context.Background(), false)
+
+	if up.probes.Load() != 1 || down.probes.Load() != 1 {
+		t.Errorf("probes = %d, %d; back-to-back status calls within the TTL should probe once", up.probes.Load(), down.probes.Load())
+	}
+	for _, errs := range [][]error{first, second} {
+		if errs[0] != nil || errs[1] == nil {
+			t.Errorf("health = %v, want [nil, error]", errs)
+		}
+	}
+
+	chain.CheckAllHealth(context.Background(), true)
+	if up.probes.Load() != 2 {
+		t.Errorf("probes after force = %d, want 2", up.probes.Load())
+	}
+}
+
+func TestHealthCache_ExpiresAfterTTL(t *testing.T) {
+	p := &countingHealthProvider{mockChainProvider: mockChainProvider{name: "p"}}
+	cache := NewHealthCache(DefaultHealthCacheTTL)
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+
+	cache.Check(context.Background(), []LLMProvider{p}, false)
+	now = now.Add(DefaultHealthCacheTTL - time.Second)
+	cache.Check(context.Background(), []LLMProvider{p}, false)
+	if p.probes.Load() != 1 {
+		t.Fatalf("probes within TTL = %d, want 1", p.probes.Load())
+	}
+
+	now = now.Add(2 * time.Second)
+	cache.Check(context.Background(), []LLMProvider{p}, false)
+	if p.probes.Load() != 2 {
+		t.Errorf("probes after TTL = %d, want 2", p.probes.Load())
+	}
+}
+
+func TestHealthCache_DeadProviderDoesNotStallOthers(t *testing.T) {
+	dead := &countingHealthProvider{mockChainProvider: mockChainProvider{name: "dead"}, block: true}
+	alive := &countingHealthProvider{mockChainProvider: mockChainProvider{name: "alive"}}
+	cache := NewHealthCache(DefaultHealthCacheTTL)
+	cache.timeout = 50 * time.Millisecond
+
+	start := time.Now()
+	errs := cache.Check(context.Background(), []LLMProvider{dead, alive}, false)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Check took %v, the dead provider should time out", elapsed)
+	}
+	if !errors.Is(errs[0], context.DeadlineExceeded) || errs[1] != nil {
+		t.Errorf("errs = %v, want [deadline exceeded, nil]", errs)
+	}
+}
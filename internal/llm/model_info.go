@@ -0,0 +1,80 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ModelInfo モデルのメタ情報（不明な項目はゼロ値）
+type ModelInfo struct {
+	Name          string
+	Family        string   // モデルファミリー ("qwen3", "llama" 等)
+	ParameterSize string   // パラメータ数 ("8.2B" 等)
+	Quantization  string   // 量子化 ("Q4_K_M" 等)
+	ContextLength int      // 最大コンテキスト長（トークン数、0 = 不明）
+	Capabilities  []string // "completion", "tools", "vision", "embedding", "thinking" 等
+}
+
+// HasCapability capability を持つか（Capabilities が空なら不明として false）
+func (m *ModelInfo) HasCapability(capability string) bool {
+	return m != nil && slices.Contains(m.Capabilities, capability)
+}
+
+// ModelInfoProvider モデルのメタ情報を取得できるプロバイダー用（Ollama等）
+type ModelInfoProvider interface {
+	ModelInfo(ctx context.Context, name string) (*ModelInfo, error)
+}
+
+// ModelInfo /api/show でモデルのメタ情報を取得する
+func (o *OllamaProvider) ModelInfo(ctx context.Context, name string) (*ModelInfo, error) {
+	body, err := o.postJSON(ctx, o.ollamaURL+"/api/show", map[string]string{"model": name})
+	if err != nil {
+		return nil, err
+	}
+	return parseOllamaShow(body, name)
+}
+
+// parseOllamaShow /api/show のレスポンスを ModelInfo に変換する。
+// コンテキスト長は model_info の "<アーキテクチャ>.context_length" から読む
+func parseOllamaShow(body []byte, name string) (*ModelInfo, error) {
+	var resp struct {
+		Details struct {
+			Family            string `json:"family"`
+			ParameterSize     string `json:"parameter_size"`
+			QuantizationLevel string `json:"quantization_level"`
+		} `json:"details"`
+		ModelInfo    map[string]interface{} `json:"model_info"`
+		Capabilities []string               `json:"capabilities"`
+		Error        string                 `json:"error"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse model info: %w", err)
+	}
+	if resp.Error != "" {
+		return nil, fmt.Errorf("Ollama error: %s", resp.Error)
+	}
+
+	info := &ModelInfo{
+		Name:          name,
+		Family:        resp.Details.Family,
+		ParameterSize: resp.Details.ParameterSize,
+		Quantization:  resp.Details.QuantizationLevel,
+		Capabilities:  resp.Capabilities,
+	}
+
+	arch, _ := resp.ModelInfo["general.architecture"].(string)
+	if n, ok := resp.ModelInfo[arch+".context_length"].(float64); ok {
+		info.ContextLength = int(n)
+	} else {
+		for key, value := range resp.ModelInfo {
+			if n, ok := value.(float64); ok && strings.HasSuffix(key, ".context_length") {
+				info.ContextLength = int(n)
+				break
+			}
+		}
+	}
+	return info, nil
+}
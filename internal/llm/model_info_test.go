@@ -0,0 +1,74 @@
+package llm
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// ollamaShowResponse is a trimmed /api/show response for qwen3:8b
+const ollamaShowResponse = `{
+	"modelfile": "FROM qwen3:8b",
+	"details": {"format":"gguf","family":"qwen3","parameter_size":"8.2B","quantization_level":"Q4_K_M"},
+	"model_info": {
+		"general.architecture": "qwen3",
+		"general.parameter_count": 8190735360,
+		"qwen3.block_count": 36,
+		"qwen3.context_length": 40960,
+		"qwen3.embedding_length": 4096
+	},
+	"capabilities": ["completion", "tools", "thinking"]
+}`
+
+func TestParseOllamaShow(t *testing.T) {
+	info, err := parseOllamaShow([]byte(ollamaShowResponse), "qwen3:8b")
+	if err != nil {
+		t.Fatalf("parseOllamaShow: %v", err)
+	}
+	if info.Name != "qwen3:8b" || info.Family != "qwen3" || info.ParameterSize != "8.2B" || info.Quantization != "Q4_K_M" {
+		t.Errorf("details = %+v", info)
+	}
+	if info.ContextLength != 40960 {
+		t.Errorf("ContextLength = %d, want 40960", info.ContextLength)
+	}
+	if !info.HasCapability("tools") || info.HasCapability("vision") {
+		t.Errorf("capabilities = %v", info.Capabilities)
+	}
+}
+
+func TestParseOllamaShow_FallbackAndErrors(t *testing.T) {
+	// No general.architecture: any *.context_length key is used; no capabilities reported
+	info, err := parseOllamaShow([]byte(`{"model_info":{"llama.context_length":8192}}`), "old")
+	if err != nil || info.ContextLength != 8192 || info.HasCapability("tools") {
+		t.Errorf("info = %+v, err = %v", info, err)
+	}
+
+	if _, err := parseOllamaShow([]byte(`{"error":"model 'x' not found"}`), "x"); err == nil {
+		t.Error("expected the Ollama error")
+	}
+	if _, err := parseOllamaShow([]byte(`not json`), "x"); err == nil {
+		t.Error("expected a parse error")
+	}
+}
+
+func TestOllamaProvider_ModelInfo(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/show" || r.Method != http.MethodPost {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(ollamaShowResponse))
+	}))
+	defer server.Close()
+
+	var provider LLMProvider = NewOllamaProvider(server.URL, "qwen3:8b")
+	mip, ok := provider.(ModelInfoProvider)
+	if !ok {
+		t.Fatal("OllamaProvider should implement ModelInfoProvider")
+	}
+	info, err := mip.ModelInfo(context.Background(), "qwen3:8b")
+	if err != nil || info.ContextLength != 40960 {
+		t.Errorf("ModelInfo = %+v, %v", info, err)
+	}
+}
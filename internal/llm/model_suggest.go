@@ -0,0 +1,86 @@
+package llm
+
+import (
+	"sort"
+	"strings"
+)
+
+// MaxModelSuggestions 「もしかして」で提示するモデル名の最大数
+const MaxModelSuggestions = 3
+
+// SuggestModels 見つからなかったモデル名に近い利用可能モデルを近い順に返す
+// 前方一致（qwen3:8 → qwen3:8b）を優先し、残りは編集距離が名前の長さの 1/3（最低 2）以内のものに限る
+func SuggestModels(name string, available []string, max int) []string {
+	query := strings.ToLower(strings.TrimSpace(name))
+	if query == "" || max <= 0 {
+		return nil
+	}
+
+	type candidate struct {
+		name   string
+		prefix bool
+		dist   int
+	}
+
+	threshold := len([]rune(query)) / 3
+	if threshold < 2 {
+		threshold = 2
+	}
+
+	var candidates []candidate
+	for _, model := range available {
+		if model == name {
+			continue
+		}
+		lower := strings.ToLower(model)
+		// "qwen3" は "qwen3:latest" の省略形
+		prefix := strings.HasPrefix(lower, query) || strings.HasPrefix(query, strings.TrimSuffix(lower, ":latest"))
+		dist := levenshtein(query, lower)
+		if !prefix && dist > threshold {
+			continue
+		}
+		candidates = append(candidates, candidate{name: model, prefix: prefix, dist: dist})
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.prefix != b.prefix {
+			return a.prefix
+		}
+		if a.dist != b.dist {
+			return a.dist < b.dist
+		}
+		return a.name < b.name
+	})
+
+	if len(candidates) > max {
+		candidates = candidates[:max]
+	}
+	suggestions := make([]string, len(candidates))
+	for i, c := range candidates {
+		suggestions[i] = c.name
+	}
+	return suggestions
+}
+
+// levenshtein ルーン単位の編集距離
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
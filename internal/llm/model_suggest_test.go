@@ -0,0 +1,81 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestSuggestModels_Ranking(t *testing.T) {
+	available := []string{"llama3.2:3b", "qwen3:14b", "qwen3:8b", "qwen2.5:7b", "gemma3:4b"}
+
+	tests := []struct {
+		name  string
+		query string
+		want  []string
+	}{
+		{"missing tag suffix", "qwen3:8", []string{"qwen3:8b"}},
+		{"typo", "qwen3:9b", []string{"qwen3:8b", "qwen3:14b"}},
+		{"latest tag", "llama3.2", []string{"llama3.2:3b"}},
+		{"case insensitive", "Gemma3:4B", []string{"gemma3:4b"}},
+		{"nothing close", "mistral-large", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SuggestModels(tt.query, available, MaxModelSuggestions)
+			if len(got) == 0 && len(tt.want) == 0 {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SuggestModels(%q) = %v, want %v", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSuggestModels_Limit(t *testing.T) {
+	available := []string{"qwen3:8b", "qwen3:8b-q4", "qwen3:8b-q8", "qwen3:8b-fp16"}
+	got := SuggestModels("qwen3:8", available, 2)
+	if len(got) != 2 || got[0] != "qwen3:8b" {
+		t.Errorf("expected the two closest models, got %v", got)
+	}
+}
+
+func TestOllamaProvider_ListModelsCached(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/tags" {
+			http.NotFound(w, r)
+			return
+		}
+		calls++
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"models": []map[string]string{{"name": "qwen3:8b"}},
+		})
+	}))
+	defer server.Close()
+
+	provider := NewOllamaProvider(server.URL, "qwen3:8b")
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if exists, err := provider.CheckModel(ctx, "qwen3:8b"); err != nil || !exists {
+			t.Fatalf("CheckModel = %v, %v", exists, err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("expected /api/tags to be fetched once while cached, got %d", calls)
+	}
+
+	provider.invalidateModelCache()
+	if _, err := provider.ListModels(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 2 {
+		t.Errorf("expected a refetch after invalidation, got %d calls", calls)
+	}
+}
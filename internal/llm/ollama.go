@@ -5,10 +5,12 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -23,8 +25,16 @@ type OllamaProvider struct {
 	numCtxStages  []int  // num_ctx エスカレーション段階
 	autoEscalate  bool   // 自動エスカレーション有効/無効
 	currentNumCtx int    // 現在使用中の num_ctx（0=Ollama任せ）
+	numGPU        int    // GPU にオフロードするレイヤー数（-1=Ollama任せ）
+
+	modelsMu       sync.Mutex
+	modelsCache    []string  // 直近の ListModels の結果
+	modelsCachedAt time.Time // modelsCache を取得した時刻
 }
 
+// ModelListCacheTTL ListModels の結果を再利用する期間（CheckModel の連続呼び出しで /api/tags を叩き直さない）
+var ModelListCacheTTL = 10 * time.Second
+
 // normalizeBaseURL ベースURLの末尾 /v1 や / を除去してホストのみにする
 // 例: "http://localhost:1234/v1" → "http://localhost:1234"
 func normalizeBaseURL(rawURL string) string {
@@ -58,6 +68,7 @@ func NewOllamaProvider(host, model string) *OllamaProvider {
 		ollamaURL:            host,
 		numCtxStages:         DefaultNumCtxStages,
 		autoEscalate:         true,
+		numGPU:               -1,
 	}
 }
 
@@ -66,6 +77,19 @@ func (o *OllamaProvider) SetNumCtx(numCtx int) {
 	o.currentNumCtx = numCtx
 }
 
+// SetNumGPU GPU にオフロードするレイヤー数を設定（-1 で Ollama 任せに戻す、次のリクエストから反映）
+func (o *OllamaProvider) SetNumGPU(numGPU int) {
+	if numGPU < -1 {
+		numGPU = -1
+	}
+	o.numGPU = numGPU
+}
+
+// GetNumGPU 現在の num_gpu を返す（-1=Ollama任せ）
+func (o *OllamaProvider) GetNumGPU() int {
+	return o.numGPU
+}
+
 // SetAutoEscalate 自動エスカレーションの有効/無効を設定
 func (o *OllamaProvider) SetAutoEscalate(enabled bool) {
 	o.autoEscalate = enabled
@@ -165,15 +189,22 @@ func (o *OllamaProvider) chatWithNumCtx(ctx context.Context, req *ChatRequest, n
 	return o.OpenAICompatProvider.Chat(ctx, req)
 }
 
-// applyNumCtx リクエストに num_ctx を設定
+// applyNumCtx リクエストに num_ctx と num_gpu を設定
 func (o *OllamaProvider) applyNumCtx(req *ChatRequest, numCtx int) {
-	if numCtx <= 0 {
-		return
+	if numCtx > 0 {
+		setOption(req, "num_ctx", numCtx)
+	}
+	if o.numGPU >= 0 {
+		setOption(req, "num_gpu", o.numGPU)
 	}
+}
+
+// setOption req.Options に値を設定（nil なら作成）
+func setOption(req *ChatRequest, key string, value interface{}) {
 	if req.Options == nil {
 		req.Options = make(map[string]interface{})
 	}
-	req.Options["num_ctx"] = numCtx
+	req.Options[key] = value
 }
 
 // buildEscalationStages 現在の num_ctx より大きいエスカレーション段階を構築
@@ -311,6 +342,31 @@ func (o *OllamaProvider) Info() ProviderInfo {
 
 // ListModels 利用可能なモデル一覧を返す
 func (o *OllamaProvider) ListModels(ctx context.Context) ([]string, error) {
+	o.modelsMu.Lock()
+	defer o.modelsMu.Unlock()
+
+	if o.modelsCache != nil && time.Since(o.modelsCachedAt) < ModelListCacheTTL {
+		return append([]string(nil), o.modelsCache...), nil
+	}
+
+	models, err := o.fetchModels(ctx)
+	if err != nil {
+		return nil, err
+	}
+	o.modelsCache = models
+	o.modelsCachedAt = time.Now()
+	return append([]string(nil), models...), nil
+}
+
+// invalidateModelCache 次の ListModels で一覧を取得し直させる（pull 後など）
+func (o *OllamaProvider) invalidateModelCache() {
+	o.modelsMu.Lock()
+	o.modelsCache = nil
+	o.modelsMu.Unlock()
+}
+
+// fetchModels /api/tags からモデル一覧を取得する
+func (o *OllamaProvider) fetchModels(ctx context.Context) ([]string, error) {
 	url := o.ollamaURL + "/api/tags"
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
@@ -345,6 +401,15 @@ func (o *OllamaProvider) ListModels(ctx context.Context) ([]string, error) {
 	return models, nil
 }
 
+// MaxPullAttempts モデルのダウンロードを試行する最大回数（一時的なエラー時のみ再試行）
+const MaxPullAttempts = 4
+
+// pullRetryDelay 再試行までの待機時間の単位（n 回目の再試行は n 倍待つ）
+var pullRetryDelay = 2 * time.Second
+
+// errPullIncomplete "success" を受け取る前にストリームが終わった（通信断）
+var errPullIncomplete = errors.New("pull stream ended before completion")
+
 // PullProgressCallback ダウンロード進捗コールバック関数の型
 // status: 現在のステータス ("pulling manifest", "downloading ...", "verifying sha256 digest", "writing manifest", "success" 等)
 // completed: ダウンロード済みバイト数
@@ -357,7 +422,48 @@ func (o *OllamaProvider) PullModel(ctx context.Context, name string) error {
 }
 
 // PullModelWithProgress モデルをダウンロード（進捗コールバック付き）
+// 通信断などの一時的なエラーは待機して再実行する（Ollama は取得済みレイヤーから再開する）。
+// モデルが存在しない等の致命的なエラーは即座に返す
 func (o *OllamaProvider) PullModelWithProgress(ctx context.Context, name string, progressFn PullProgressCallback) error {
+	var err error
+	for attempt := 1; attempt <= MaxPullAttempts; attempt++ {
+		err = o.pullOnce(ctx, name, progressFn)
+		if err == nil {
+			o.invalidateModelCache()
+			return nil
+		}
+		if ctx.Err() != nil || !isTransientPullError(err) {
+			return err
+		}
+		if attempt == MaxPullAttempts {
+			break
+		}
+		if progressFn != nil {
+			progressFn(fmt.Sprintf("connection lost, resuming (retry %d/%d): %v", attempt, MaxPullAttempts-1, err), 0, 0)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(pullRetryDelay * time.Duration(attempt)):
+		}
+	}
+	return fmt.Errorf("failed to pull model %s after %d attempts: %w", name, MaxPullAttempts, err)
+}
+
+// isTransientPullError 再試行で回復しうるエラーか（通信断・タイムアウト・5xx・途中終了）
+func isTransientPullError(err error) bool {
+	if errors.Is(err, errPullIncomplete) {
+		return true
+	}
+	switch ClassifyError(err) {
+	case ErrorClassNetwork, ErrorClassTimeout, ErrorClassServerError:
+		return true
+	}
+	return false
+}
+
+// pullOnce /api/pull を1回実行する
+func (o *OllamaProvider) pullOnce(ctx context.Context, name string, progressFn PullProgressCallback) error {
 	url := o.ollamaURL + "/api/pull"
 	stream := progressFn != nil
 	payload := map[string]interface{}{
@@ -389,7 +495,7 @@ func (o *OllamaProvider) PullModelWithProgress(ctx context.Context, name string,
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("failed to pull model %s: %s", name, string(body))
+		return fmt.Errorf("failed to pull model %s: status %d: %s", name, resp.StatusCode, string(body))
 	}
 
 	if !stream {
@@ -417,6 +523,7 @@ func (o *OllamaProvider) PullModelWithProgress(ctx context.Context, name string,
 		Error     string `json:"error"`
 	}
 
+	succeeded := false
 	for scanner.Scan() {
 		line := scanner.Bytes()
 		if len(line) == 0 {
@@ -432,11 +539,17 @@ func (o *OllamaProvider) PullModelWithProgress(ctx context.Context, name string,
 		}
 
 		progressFn(pullResp.Status, pullResp.Completed, pullResp.Total)
+		if pullResp.Status == "success" {
+			succeeded = true
+		}
 	}
 
 	if err := scanner.Err(); err != nil {
 		return fmt.Errorf("error reading pull response: %w", err)
 	}
+	if !succeeded {
+		return errPullIncomplete
+	}
 
 	return nil
 }
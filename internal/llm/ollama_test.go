@@ -0,0 +1,175 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newPullServer serves /api/pull from the given handlers, one per request
+// (the last one repeats), and counts the requests
+func newPullServer(t *testing.T, handlers ...http.HandlerFunc) (*httptest.Server, *int) {
+	t.Helper()
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/pull" {
+			http.NotFound(w, r)
+			return
+		}
+		h := handlers[min(calls, len(handlers)-1)]
+		calls++
+		h(w, r)
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+// streamLines writes JSON lines and flushes them to the client
+func streamLines(w http.ResponseWriter, lines ...string) {
+	for _, line := range lines {
+		fmt.Fprintln(w, line)
+	}
+	w.(http.Flusher).Flush()
+}
+
+func withPullRetryDelay(t *testing.T, d time.Duration) {
+	orig := pullRetryDelay
+	pullRetryDelay = d
+	t.Cleanup(func() { pullRetryDelay = orig })
+}
+
+func TestPullModelWithProgress_ResumesAfterDrop(t *testing.T) {
+	withPullRetryDelay(t, time.Millisecond)
+	server, calls := newPullServer(t,
+		func(w http.ResponseWriter, r *http.Request) {
+			streamLines(w, `{"status":"pulling manifest"}`, `{"status":"pulling abc","total":100,"completed":40}`)
+			panic(http.ErrAbortHandler) // Drop the connection mid-download
+		},
+		func(w http.ResponseWriter, r *http.Request) {
+			streamLines(w, `{"status":"pulling abc","total":100,"completed":100}`, `{"status":"success"}`)
+		},
+	)
+
+	var statuses []string
+	var lastCompleted int64
+	provider := NewOllamaProvider(server.URL, "")
+	err := provider.PullModelWithProgress(context.Background(), "qwen3:8b", func(status string, completed, total int64) {
+		statuses = append(statuses, status)
+		if total > 0 {
+			lastCompleted = completed
+		}
+	})
+	if err != nil {
+		t.Fatalf("pull should succeed after resuming: %v", err)
+	}
+	if *calls != 2 {
+		t.Errorf("pull requests = %d, want 2", *calls)
+	}
+	if lastCompleted != 100 || statuses[len(statuses)-1] != "success" {
+		t.Errorf("progress should end complete, got statuses %q (completed %d)", statuses, lastCompleted)
+	}
+	retried := false
+	for _, s := range statuses {
+		retried = retried || strings.Contains(s, "retry 1/")
+	}
+	if !retried {
+		t.Errorf("progress callback should report the retry, got %q", statuses)
+	}
+}
+
+func TestPullModelWithProgress_FatalErrorNotRetried(t *testing.T) {
+	withPullRetryDelay(t, time.Millisecond)
+	server, calls := newPullServer(t, func(w http.ResponseWriter, r *http.Request) {
+		streamLines(w, `{"status":"pulling manifest"}`, `{"error":"pull model manifest: file does not exist"}`)
+	})
+
+	err := NewOllamaProvider(server.URL, "").PullModelWithProgress(context.Background(), "nope", func(string, int64, int64) {})
+	if err == nil || !strings.Contains(err.Error(), "file does not exist") {
+		t.Fatalf("err = %v, want the model-not-found error", err)
+	}
+	if *calls != 1 {
+		t.Errorf("pull requests = %d, fatal errors should not be retried", *calls)
+	}
+
+	server, calls = newPullServer(t, func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":"model not found"}`, http.StatusNotFound)
+	})
+	if err := NewOllamaProvider(server.URL, "").PullModel(context.Background(), "nope"); err == nil || *calls != 1 {
+		t.Errorf("404: err = %v, requests = %d, want one failed request", err, *calls)
+	}
+}
+
+func TestPullModelWithProgress_GivesUpAfterMaxAttempts(t *testing.T) {
+	withPullRetryDelay(t, time.Millisecond)
+	server, calls := newPullServer(t, func(w http.ResponseWriter, r *http.Request) {
+		streamLines(w, `{"status":"pulling abc","total":100,"completed":10}`) // Stream ends without "success"
+	})
+
+	err := NewOllamaProvider(server.URL, "").PullModelWithProgress(context.Background(), "m", func(string, int64, int64) {})
+	if err == nil || !strings.Contains(err.Error(), fmt.Sprintf("after %d attempts", MaxPullAttempts)) {
+		t.Errorf("err = %v, want a give-up error", err)
+	}
+	if *calls != MaxPullAttempts {
+		t.Errorf("pull requests = %d, want %d", *calls, MaxPullAttempts)
+	}
+}
+
+// newOptionsServer answers chat completions and records the "options" of each request
+func newOptionsServer(t *testing.T) (*httptest.Server, *[]map[string]interface{}) {
+	t.Helper()
+	var seen []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Options map[string]interface{} `json:"options"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		seen = append(seen, body.Options)
+		w.Write([]byte(`{"choices":[{"index":0,"finish_reason":"stop","message":{"role":"assistant","content":"ok"}}]}`))
+	}))
+	t.Cleanup(server.Close)
+	return server, &seen
+}
+
+func TestOllamaProvider_SettersUpdateRequestOptions(t *testing.T) {
+	server, seen := newOptionsServer(t)
+	provider := NewOllamaProvider(server.URL, "qwen3:8b")
+	chat := func() map[string]interface{} {
+		t.Helper()
+		if _, err := provider.Chat(context.Background(), &ChatRequest{Model: "qwen3:8b"}); err != nil {
+			t.Fatalf("Chat: %v", err)
+		}
+		return (*seen)[len(*seen)-1]
+	}
+
+	if opts := chat(); opts != nil {
+		t.Errorf("defaults should leave options to Ollama, got %v", opts)
+	}
+
+	provider.SetNumCtx(16384)
+	provider.SetNumGPU(20)
+	opts := chat()
+	if opts["num_ctx"] != float64(16384) || opts["num_gpu"] != float64(20) {
+		t.Errorf("options = %v, want num_ctx 16384 and num_gpu 20", opts)
+	}
+
+	// num_gpu 0 (CPU only) is an explicit value, -1 goes back to the Ollama default
+	provider.SetNumGPU(0)
+	if opts := chat(); opts["num_gpu"] != float64(0) {
+		t.Errorf("num_gpu 0 should be sent, got %v", opts)
+	}
+	provider.SetNumCtx(0)
+	provider.SetNumGPU(-1)
+	if opts := chat(); opts != nil {
+		t.Errorf("resetting both should drop the options, got %v", opts)
+	}
+	if provider.GetNumGPU() != -1 || provider.GetCurrentNumCtx() != 0 {
+		t.Errorf("getters = %d, %d after reset", provider.GetCurrentNumCtx(), provider.GetNumGPU())
+	}
+}
@@ -15,11 +15,12 @@ import (
 // OpenAICompatProvider OpenAI互換APIのベース実装
 // Ollama, llama-server, LM Studio, OpenAI, DeepSeek, Groq 等で共通利用
 type OpenAICompatProvider struct {
-	baseURL    string
-	apiKey     string
-	model      string
-	httpClient *http.Client
-	info       ProviderInfo
+	baseURL        string
+	apiKey         string
+	model          string
+	embeddingModel string // 埋め込み用モデル（"" = model と同じ）
+	httpClient     *http.Client
+	info           ProviderInfo
 }
 
 // NewOpenAICompatProvider OpenAI互換プロバイダーを作成
@@ -94,17 +95,19 @@ func (p *OpenAICompatProvider) Chat(ctx context.Context, req *ChatRequest) (*Cha
 		}
 	}
 
-	// XMLフォールバック: ネイティブtool_callsがない場合、テキストからXML形式のtool呼び出しを抽出
-	if len(response.Choices) > 0 && len(response.Choices[0].Message.ToolCalls) == 0 {
-		if response.Choices[0].Message.Content != "" && req.Tools != nil && len(req.Tools) > 0 {
+	// XMLフォールバック: ネイティブtool_callsがない場合、テキストからXML形式のtool呼び出しを抽出（n>1 の場合は候補ごと）
+	for i := range response.Choices {
+		choice := &response.Choices[i]
+		if len(choice.Message.ToolCalls) == 0 && choice.Message.Content != "" && len(req.Tools) > 0 {
 			knownTools := extractToolNames(req.Tools)
-			calls, err := ExtractToolCallsFromText(response.Choices[0].Message.Content, knownTools)
+			calls, err := ExtractToolCallsFromText(choice.Message.Content, knownTools)
 			if err == nil && len(calls) > 0 {
-				response.Choices[0].Message.ToolCalls = calls
-				response.Choices[0].FinishReason = "tool_calls"
+				choice.Message.ToolCalls = calls
+				choice.FinishReason = "tool_calls"
 			}
 		}
 	}
+	response.normalizeToolCalls()
 
 	return &response, nil
 }
@@ -101,17 +101,19 @@ func (o *OpenRouterProvider) Chat(ctx context.Context, req *ChatRequest) (*ChatR
 		}
 	}
 
-	// XMLフォールバック: ネイティブtool_callsがない場合
-	if len(response.Choices) > 0 && len(response.Choices[0].Message.ToolCalls) == 0 {
-		if response.Choices[0].Message.Content != "" && req.Tools != nil && len(req.Tools) > 0 {
+	// XMLフォールバック: ネイティブtool_callsがない場合（n>1 の場合は候補ごと）
+	for i := range response.Choices {
+		choice := &response.Choices[i]
+		if len(choice.Message.ToolCalls) == 0 && choice.Message.Content != "" && len(req.Tools) > 0 {
 			knownTools := extractToolNames(req.Tools)
-			calls, err := ExtractToolCallsFromText(response.Choices[0].Message.Content, knownTools)
+			calls, err := ExtractToolCallsFromText(choice.Message.Content, knownTools)
 			if err == nil && len(calls) > 0 {
-				response.Choices[0].Message.ToolCalls = calls
-				response.Choices[0].FinishReason = "tool_calls"
+				choice.Message.ToolCalls = calls
+				choice.FinishReason = "tool_calls"
 			}
 		}
 	}
+	response.normalizeToolCalls()
 
 	return &response, nil
 }
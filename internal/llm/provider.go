@@ -15,6 +15,9 @@ type LLMProvider interface {
 
 	// Info プロバイダー情報
 	Info() ProviderInfo
+
+	// Embed テキストごとの埋め込みベクトル（非対応なら ErrEmbeddingsUnsupported）
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
 }
 
 // ProviderType プロバイダーの種別
@@ -41,6 +44,7 @@ type Features struct {
 	NativeFunctionCalling bool // true: OpenAI式tool_calls対応
 	ModelManagement       bool // true: モデルDL/一覧が可能
 	Streaming             bool // true: SSEストリーミング対応
+	MultipleChoices       bool // true: ChatRequest.N による複数候補生成に対応
 }
 
 // ModelManager モデル管理ができるプロバイダー用（Ollama等）
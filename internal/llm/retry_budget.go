@@ -0,0 +1,99 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// RetryBudget 1ターン内で各層のリトライが共有する上限
+// nil の場合は無制限（従来どおり各層の上限のみ）
+type RetryBudget struct {
+	mu      sync.Mutex
+	max     int
+	used    int
+	reasons []string // リトライの原因（集約エラー用）
+}
+
+// NewRetryBudget 合計 max 回までリトライできるバジェットを作成
+func NewRetryBudget(max int) *RetryBudget {
+	if max < 0 {
+		max = 0
+	}
+	return &RetryBudget{max: max}
+}
+
+// Take リトライを1回消費する。残りがなければ false（呼び出し側はリトライせずに失敗する）
+func (b *RetryBudget) Take(reason string) bool {
+	if b == nil {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.used >= b.max {
+		return false
+	}
+	b.used++
+	b.reasons = append(b.reasons, reason)
+	return true
+}
+
+// Used 消費済みのリトライ回数
+func (b *RetryBudget) Used() int {
+	if b == nil {
+		return 0
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.used
+}
+
+// Exhausted 予算切れのエラーを作成（最後のエラーとこれまでのリトライ原因をまとめる）
+func (b *RetryBudget) Exhausted(lastErr error) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return &RetryBudgetError{
+		Max:     b.max,
+		Reasons: append([]string(nil), b.reasons...),
+		LastErr: lastErr,
+	}
+}
+
+// RetryBudgetError ターンのリトライ予算を使い切ったことを示す集約エラー
+type RetryBudgetError struct {
+	Max     int
+	Reasons []string
+	LastErr error
+}
+
+func (e *RetryBudgetError) Error() string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("retry budget exhausted (%d retries this turn)", e.Max))
+	if e.LastErr != nil {
+		sb.WriteString(fmt.Sprintf(": %v", e.LastErr))
+	}
+	if len(e.Reasons) > 0 {
+		sb.WriteString("; retried: ")
+		sb.WriteString(strings.Join(e.Reasons, "; "))
+	}
+	return sb.String()
+}
+
+func (e *RetryBudgetError) Unwrap() error {
+	return e.LastErr
+}
+
+type retryBudgetKey struct{}
+
+// WithRetryBudget ctx にバジェットを載せる（同じ ctx を使う層が予算を共有する）
+func WithRetryBudget(ctx context.Context, b *RetryBudget) context.Context {
+	return context.WithValue(ctx, retryBudgetKey{}, b)
+}
+
+// RetryBudgetFrom ctx のバジェットを取得（なければ nil = 無制限）
+func RetryBudgetFrom(ctx context.Context) *RetryBudget {
+	b, _ := ctx.Value(retryBudgetKey{}).(*RetryBudget)
+	return b
+}
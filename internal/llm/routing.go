@@ -8,6 +8,16 @@ import (
 	"time"
 )
 
+// TaskRole ルーティング用のタスク種別ヒント
+type TaskRole int
+
+const (
+	// TaskReasoning 通常の推論（常にメインモデル）
+	TaskReasoning TaskRole = iota
+	// TaskLightweight 要約・検証などの軽い処理（サイドカーがあればサイドカー）
+	TaskLightweight
+)
+
 // ModelRouter モデルルーター（LLMProviderベース）
 type ModelRouter struct {
 	mainProvider    LLMProvider
@@ -91,6 +101,51 @@ func (mr *ModelRouter) GetActiveProvider() LLMProvider {
 	return mr.mainProvider
 }
 
+// GetSidecarOrMain サイドカーが設定されていればサイドカー、なければメインのプロバイダーとモデル名を返す
+func (mr *ModelRouter) GetSidecarOrMain() (LLMProvider, string) {
+	mr.mu.RLock()
+	defer mr.mu.RUnlock()
+
+	if mr.sidecarProvider != nil && mr.sidecarModel != "" {
+		return mr.sidecarProvider, mr.sidecarModel
+	}
+	return mr.mainProvider, mr.mainModel
+}
+
+// SetSidecar サイドカーのプロバイダーとモデルを差し替える（provider が nil か model が空ならサイドカーなし）
+func (mr *ModelRouter) SetSidecar(provider LLMProvider, model string) {
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+
+	if provider == nil || model == "" {
+		provider, model = nil, ""
+	}
+	mr.sidecarProvider = provider
+	mr.sidecarModel = model
+	mr.sidecarLoaded = false
+	if provider == nil {
+		mr.useSidecar = false
+	}
+}
+
+// HasSidecar サイドカーが設定されているか
+func (mr *ModelRouter) HasSidecar() bool {
+	mr.mu.RLock()
+	defer mr.mu.RUnlock()
+	return mr.sidecarProvider != nil && mr.sidecarModel != ""
+}
+
+// ForTask タスク種別に応じたプロバイダーとモデル名を返す
+// 軽い処理はサイドカー（なければメイン）、推論はメインを使う
+func (mr *ModelRouter) ForTask(role TaskRole) (LLMProvider, string) {
+	if role == TaskLightweight {
+		return mr.GetSidecarOrMain()
+	}
+	mr.mu.RLock()
+	defer mr.mu.RUnlock()
+	return mr.mainProvider, mr.mainModel
+}
+
 // Chat メイン/サイドカーを自動選択してチャット
 func (mr *ModelRouter) Chat(ctx context.Context, req *ChatRequest) (*ChatResponse, error) {
 	provider := mr.GetActiveProvider()
@@ -230,6 +285,38 @@ type RouterStatus struct {
 	SidecarLoaded bool
 }
 
+// ModelParams モデルごとの推奨サンプリングパラメータ（0 は推奨なし）
+type ModelParams struct {
+	Temperature float64
+	MaxTokens   int
+}
+
+// ParamsForModel モデルの推奨 temperature / max_tokens を返す
+// サイドカーは要約・分類などの決定的なタスクに使うため temperature を低くする
+func (mr *ModelRouter) ParamsForModel(model string) ModelParams {
+	mr.mu.RLock()
+	isSidecar := model != "" && model == mr.sidecarModel
+	mr.mu.RUnlock()
+
+	if isSidecar {
+		return ModelParams{Temperature: 0.1, MaxTokens: 2048}
+	}
+
+	switch mr.GetModelTier(model) {
+	case "A", "B":
+		return ModelParams{Temperature: 0.7, MaxTokens: 8192}
+	case "C":
+		return ModelParams{Temperature: 0.5, MaxTokens: 8192}
+	case "D":
+		return ModelParams{Temperature: 0.3, MaxTokens: 4096}
+	case "E":
+		// 小さいモデルは高い temperature で崩れやすい
+		return ModelParams{Temperature: 0.2, MaxTokens: 2048}
+	default:
+		return ModelParams{}
+	}
+}
+
 // GetModelTier モデルのティアを取得
 func (mr *ModelRouter) GetModelTier(model string) string {
 	switch {
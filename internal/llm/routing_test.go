@@ -103,6 +103,24 @@ func TestModelRouter_GetActiveProvider(t *testing.T) {
 	}
 }
 
+func TestModelRouter_GetSidecarOrMain(t *testing.T) {
+	mainProvider := NewOllamaProvider("http://localhost:11434", "main-model")
+	sidecarProvider := NewOllamaProvider("http://localhost:11434", "sidecar-model")
+
+	router := NewModelRouter(mainProvider, sidecarProvider, "main-model", "sidecar-model")
+	provider, model := router.GetSidecarOrMain()
+	if provider != sidecarProvider || model != "sidecar-model" {
+		t.Errorf("GetSidecarOrMain() = %v, want sidecar-model", model)
+	}
+
+	// Without sidecar, fall back to main
+	router = NewModelRouter(mainProvider, nil, "main-model", "")
+	provider, model = router.GetSidecarOrMain()
+	if provider != mainProvider || model != "main-model" {
+		t.Errorf("GetSidecarOrMain() = %v, want main-model", model)
+	}
+}
+
 func TestModelRouter_AutoSelectModel(t *testing.T) {
 	mainProvider := NewOllamaProvider("http://localhost:11434", "main-model")
 	sidecarProvider := NewOllamaProvider("http://localhost:11434", "sidecar-model")
@@ -326,3 +344,84 @@ func TestModelRouter_ConcurrentAccess(t *testing.T) {
 		<-done
 	}
 }
+
+func TestModelRouter_ParamsForModel(t *testing.T) {
+	mainProvider := NewOllamaProvider("http://localhost:11434", "qwen3:32b")
+	sidecarProvider := NewOllamaProvider("http://localhost:11434", "qwen3:1.7b")
+	router := NewModelRouter(mainProvider, sidecarProvider, "qwen3:32b", "qwen3:1.7b")
+
+	tests := []struct {
+		model       string
+		temperature float64
+		maxTokens   int
+	}{
+		{"qwen3:72b", 0.7, 8192},
+		{"llama3:70b", 0.7, 8192},
+		{"qwen3:32b", 0.5, 8192},
+		{"qwen3:8b", 0.3, 4096},
+		{"qwen3:4b", 0.2, 2048},
+		{"qwen3:1.7b", 0.1, 2048}, // サイドカーはティアより優先
+		{"custom-model", 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.model, func(t *testing.T) {
+			params := router.ParamsForModel(tt.model)
+			if params.Temperature != tt.temperature || params.MaxTokens != tt.maxTokens {
+				t.Errorf("ParamsForModel(%q) = %+v, want temperature %v / max_tokens %d",
+					tt.model, params, tt.temperature, tt.maxTokens)
+			}
+		})
+	}
+}
+
+func TestModelRouter_ForTask(t *testing.T) {
+	mainProvider := NewOllamaProvider("http://localhost:11434", "main-model")
+	sidecarProvider := NewOllamaProvider("http://localhost:11434", "sidecar-model")
+
+	router := NewModelRouter(mainProvider, sidecarProvider, "main-model", "sidecar-model")
+	if _, model := router.ForTask(TaskLightweight); model != "sidecar-model" {
+		t.Errorf("ForTask(TaskLightweight) = %v, want sidecar-model", model)
+	}
+	if _, model := router.ForTask(TaskReasoning); model != "main-model" {
+		t.Errorf("ForTask(TaskReasoning) = %v, want main-model", model)
+	}
+
+	noSidecar := NewModelRouter(mainProvider, nil, "main-model", "")
+	if noSidecar.HasSidecar() {
+		t.Error("HasSidecar() should be false without a sidecar")
+	}
+	if _, model := noSidecar.ForTask(TaskLightweight); model != "main-model" {
+		t.Errorf("ForTask(TaskLightweight) without sidecar = %v, want main-model", model)
+	}
+}
+
+func TestModelRouter_SetSidecar(t *testing.T) {
+	mainProvider := NewOllamaProvider("http://localhost:11434", "main-model")
+	router := NewModelRouter(mainProvider, nil, "main-model", "")
+
+	sidecarProvider := NewOllamaProvider("http://localhost:11434", "sidecar-model")
+	router.SetSidecar(sidecarProvider, "sidecar-model")
+	if !router.HasSidecar() {
+		t.Fatal("HasSidecar() should be true after SetSidecar")
+	}
+	if provider, model := router.ForTask(TaskLightweight); provider != sidecarProvider || model != "sidecar-model" {
+		t.Errorf("ForTask(TaskLightweight) = %v, want sidecar-model", model)
+	}
+	if status := router.GetStatus(); status.SidecarModel != "sidecar-model" || status.SidecarLoaded {
+		t.Errorf("GetStatus() = %+v, want an unloaded sidecar-model", status)
+	}
+
+	// Clearing the sidecar routes everything to main, even while the sidecar was active
+	router.SwitchToSidecar()
+	router.SetSidecar(nil, "")
+	if router.HasSidecar() {
+		t.Error("HasSidecar() should be false after clearing")
+	}
+	if router.GetActiveModel() != "main-model" || router.GetActiveProvider() != mainProvider {
+		t.Errorf("active model = %v, want main-model after clearing", router.GetActiveModel())
+	}
+	if _, model := router.ForTask(TaskLightweight); model != "main-model" {
+		t.Errorf("ForTask(TaskLightweight) after clearing = %v, want main-model", model)
+	}
+}
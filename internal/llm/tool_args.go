@@ -0,0 +1,78 @@
+package llm
+
+import (
+	"encoding/json"
+	"strings"
+	"unicode"
+)
+
+// maxArgumentLayers arguments を剥がす最大の段数（文字列エンコード・コードフェンス）
+const maxArgumentLayers = 3
+
+// NormalizeToolArguments ツール呼び出しの arguments を JSON オブジェクトに正規化する
+// モデル・プロバイダーによっては次の形で返ってくるため、順に剥がしていく:
+//   - JSON 文字列として 1〜3 重にエンコードされたもの: "{\"command\":\"ls\"}"
+//   - Markdown のコードフェンスで囲まれたもの: "```json\n{\"command\":\"ls\"}\n```"
+//   - 空・null（引数なしのツール）→ {}
+//
+// オブジェクトに戻せない場合は元の値をそのまま返す（ツール側の引数エラーとして LLM に伝わる）
+func NormalizeToolArguments(raw json.RawMessage) json.RawMessage {
+	original := strings.TrimSpace(string(raw))
+	data := original
+	for i := 0; i <= maxArgumentLayers; i++ {
+		data = stripCodeFence(data)
+		if data == "" || data == "null" {
+			return json.RawMessage("{}")
+		}
+
+		if data[0] == '{' {
+			var obj map[string]interface{}
+			if err := json.Unmarshal([]byte(data), &obj); err != nil {
+				break
+			}
+			if data == original {
+				return json.RawMessage(data) // 正しい形式はそのまま渡す
+			}
+			// 剥がした中身は再エンコードして余分な空白やエスケープを揃える
+			normalized, err := json.Marshal(obj)
+			if err != nil {
+				break
+			}
+			return normalized
+		}
+
+		// 文字列エンコードを 1 段外す
+		var unquoted string
+		if err := json.Unmarshal([]byte(data), &unquoted); err != nil {
+			break
+		}
+		data = strings.TrimSpace(unquoted)
+	}
+	return raw
+}
+
+// stripCodeFence ```json ... ``` のような Markdown コードフェンスを外す（フェンスがなければそのまま）
+func stripCodeFence(s string) string {
+	if !strings.HasPrefix(s, "```") {
+		return s
+	}
+	body := strings.TrimPrefix(s, "```")
+	// 言語タグ（json, JSON など）を読み飛ばす
+	body = strings.TrimLeftFunc(body, func(r rune) bool {
+		return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '-'
+	})
+	body = strings.TrimSpace(body)
+	// 出力が途中で切れて閉じフェンスがない場合も中身は試す
+	body = strings.TrimSuffix(body, "```")
+	return strings.TrimSpace(body)
+}
+
+// normalizeToolCalls 全候補のツール呼び出しの arguments を正規化する
+func (r *ChatResponse) normalizeToolCalls() {
+	for i := range r.Choices {
+		calls := r.Choices[i].Message.ToolCalls
+		for j := range calls {
+			calls[j].Function.Arguments = NormalizeToolArguments(calls[j].Function.Arguments)
+		}
+	}
+}
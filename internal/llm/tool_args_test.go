@@ -0,0 +1,161 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNormalizeToolArguments(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "already a JSON object",
+			input:    `{"command":"ls"}`,
+			expected: `{"command":"ls"}`,
+		},
+		{
+			name:     "single string encoding",
+			input:    `"{\"command\":\"ls\"}"`,
+			expected: `{"command":"ls"}`,
+		},
+		{
+			name:     "double string encoding",
+			input:    `"\"{\\\"command\\\":\\\"ls\\\"}\""`,
+			expected: `{"command":"ls"}`,
+		},
+		{
+			name:     "string with unicode escapes",
+			input:    `"{\"command\":\"mkdir tetris \\u0026\\u0026 cd tetris\"}"`,
+			expected: `{"command":"mkdir tetris && cd tetris"}`,
+		},
+		{
+			name:     "complex object with newlines",
+			input:    `"{\"path\":\"test.py\",\"content\":\"print(\\\"hello\\\")\\n\"}"`,
+			expected: `{"content":"print(\"hello\")\n","path":"test.py"}`,
+		},
+		{
+			name:     "empty object",
+			input:    `{}`,
+			expected: `{}`,
+		},
+		{
+			name:     "empty object string encoded",
+			input:    `"{}"`,
+			expected: `{}`,
+		},
+		{
+			name:     "re-stringified object with whitespace",
+			input:    `"  {\n  \"path\": \"a.go\",\n  \"limit\": 20\n}\n"`,
+			expected: `{"limit":20,"path":"a.go"}`,
+		},
+		{
+			name:     "code fence with language tag",
+			input:    `"` + "```json\\n{\\\"command\\\":\\\"go test ./...\\\"}\\n```" + `"`,
+			expected: `{"command":"go test ./..."}`,
+		},
+		{
+			name:     "code fence without language tag",
+			input:    `"` + "```\\n{\\\"path\\\":\\\"main.go\\\"}\\n```" + `"`,
+			expected: `{"path":"main.go"}`,
+		},
+		{
+			name:     "code fence on one line",
+			input:    `"` + "```{\\\"path\\\":\\\"main.go\\\"}```" + `"`,
+			expected: `{"path":"main.go"}`,
+		},
+		{
+			name:     "code fence missing the closing fence",
+			input:    `"` + "```json\\n{\\\"path\\\":\\\"main.go\\\"}" + `"`,
+			expected: `{"path":"main.go"}`,
+		},
+		{
+			name:     "code fence inside a double-encoded string",
+			input:    `"\"` + "```json\\\\n{\\\\\\\"command\\\\\\\":\\\\\\\"ls\\\\\\\"}\\\\n```" + `\""`,
+			expected: `{"command":"ls"}`,
+		},
+		{
+			name:     "empty arguments",
+			input:    ``,
+			expected: `{}`,
+		},
+		{
+			name:     "empty string",
+			input:    `""`,
+			expected: `{}`,
+		},
+		{
+			name:     "null",
+			input:    `null`,
+			expected: `{}`,
+		},
+		{
+			name:     "plain string is left as-is",
+			input:    `"ls -la"`,
+			expected: `"ls -la"`,
+		},
+		{
+			name:     "broken object is left as-is",
+			input:    `"{\"command\": \"ls\""`,
+			expected: `"{\"command\": \"ls\""`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NormalizeToolArguments(json.RawMessage(tt.input))
+			if !json.Valid(got) {
+				t.Fatalf("NormalizeToolArguments(%s) = %s, which is not valid JSON", tt.input, got)
+			}
+
+			// Compare objects by content (key order may differ)
+			var wantObj, gotObj map[string]interface{}
+			if json.Unmarshal([]byte(tt.expected), &wantObj) == nil {
+				if err := json.Unmarshal(got, &gotObj); err != nil {
+					t.Fatalf("NormalizeToolArguments(%s) = %s, want object %s", tt.input, got, tt.expected)
+				}
+				wantBytes, _ := json.Marshal(wantObj)
+				gotBytes, _ := json.Marshal(gotObj)
+				if string(wantBytes) != string(gotBytes) {
+					t.Errorf("NormalizeToolArguments(%s) = %s, want %s", tt.input, got, tt.expected)
+				}
+				return
+			}
+			if string(got) != tt.expected {
+				t.Errorf("NormalizeToolArguments(%s) = %s, want %s", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestOpenAICompatChat_NormalizesToolArguments(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"choices":[{"index":0,"finish_reason":"tool_calls","message":{"role":"assistant","tool_calls":[
+			{"id":"1","type":"function","function":{"name":"bash","arguments":"` + "```json\\n{\\\"command\\\":\\\"ls\\\"}\\n```" + `"}},
+			{"id":"2","type":"function","function":{"name":"list_directory","arguments":""}}
+		]}}]}`))
+	}))
+	defer server.Close()
+
+	provider := NewOpenAICompatProvider(server.URL, "", "test", ProviderInfo{Name: "test"})
+	resp, err := provider.Chat(context.Background(), &ChatRequest{Model: "test"})
+	if err != nil {
+		t.Fatalf("Chat: %v", err)
+	}
+
+	calls := resp.Choices[0].Message.ToolCalls
+	if len(calls) != 2 {
+		t.Fatalf("tool calls = %+v, want 2", calls)
+	}
+	if got := string(calls[0].Function.Arguments); got != `{"command":"ls"}` {
+		t.Errorf("fenced arguments = %s, want {\"command\":\"ls\"}", got)
+	}
+	if got := string(calls[1].Function.Arguments); got != `{}` {
+		t.Errorf("empty arguments = %s, want {}", got)
+	}
+}
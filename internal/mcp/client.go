@@ -10,8 +10,12 @@ import (
 	"os/exec"
 	"sync"
 	"sync/atomic"
+	"time"
 )
 
+// DefaultCallTimeout MCPツール呼び出し1回あたりのデフォルトタイムアウト
+const DefaultCallTimeout = 30 * time.Second
+
 // JSON-RPC 2.0 メッセージ型
 
 // JSONRPCRequest JSON-RPC 2.0 リクエスト
@@ -69,6 +73,9 @@ type Client struct {
 	nextID  int64
 	tools   []MCPToolSchema
 	running bool
+	pending map[int64]chan *JSONRPCResponse // 応答待ちリクエスト (ID → 受信チャネル)
+	done    chan struct{}                   // プロセス終了時に close
+	exitErr error                           // 予期しない終了の理由（Stop による終了では nil）
 }
 
 // NewClient MCPクライアントを作成
@@ -112,10 +119,57 @@ func (c *Client) Start(ctx context.Context, command string, args []string, env m
 		return fmt.Errorf("process start error: %w", err)
 	}
 	c.running = true
+	c.exitErr = nil
+	c.pending = make(map[int64]chan *JSONRPCResponse)
+	c.done = make(chan struct{})
+
+	go c.readLoop()
 
 	return nil
 }
 
+// readLoop stdout からレスポンスを読み、待機中の呼び出しに振り分ける。
+// stdout が閉じたらプロセスの終了を待ち、稼働状態を更新する
+func (c *Client) readLoop() {
+	for c.stdout.Scan() {
+		line := c.stdout.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+
+		var resp JSONRPCResponse
+		if err := json.Unmarshal(line, &resp); err != nil {
+			continue // 通知やパースできないメッセージはスキップ
+		}
+
+		c.mu.Lock()
+		ch, ok := c.pending[resp.ID]
+		delete(c.pending, resp.ID)
+		c.mu.Unlock()
+
+		// ID不一致（通知・タイムアウト済みの応答など）はスキップ
+		if ok {
+			ch <- &resp
+		}
+	}
+
+	waitErr := c.cmd.Wait()
+
+	c.mu.Lock()
+	if c.running {
+		// Stop を経ずに終了した = クラッシュ
+		if waitErr == nil {
+			waitErr = fmt.Errorf("process exited")
+		}
+		c.exitErr = waitErr
+		c.running = false
+	}
+	c.pending = make(map[int64]chan *JSONRPCResponse)
+	c.mu.Unlock()
+
+	close(c.done)
+}
+
 // Initialize MCP初期化ハンドシェイク
 func (c *Client) Initialize() error {
 	params := map[string]interface{}{
@@ -155,8 +209,8 @@ func (c *Client) ListTools() ([]MCPToolSchema, error) {
 	return result.Tools, nil
 }
 
-// CallTool ツールを呼び出す
-func (c *Client) CallTool(name string, arguments json.RawMessage) (*MCPToolCallResult, error) {
+// CallTool ツールを呼び出す（ctx のキャンセル・期限で応答待ちを打ち切る）
+func (c *Client) CallTool(ctx context.Context, name string, arguments json.RawMessage) (*MCPToolCallResult, error) {
 	params := map[string]interface{}{
 		"name": name,
 	}
@@ -169,7 +223,7 @@ func (c *Client) CallTool(name string, arguments json.RawMessage) (*MCPToolCallR
 		params["arguments"] = args
 	}
 
-	resp, err := c.call("tools/call", params)
+	resp, err := c.callContext(ctx, "tools/call", params)
 	if err != nil {
 		return nil, fmt.Errorf("tools/call failed: %w", err)
 	}
@@ -185,22 +239,25 @@ func (c *Client) CallTool(name string, arguments json.RawMessage) (*MCPToolCallR
 // Stop MCPサーバーを停止
 func (c *Client) Stop() error {
 	c.mu.Lock()
-	defer c.mu.Unlock()
-
 	if !c.running {
+		c.mu.Unlock()
 		return nil
 	}
 	c.running = false
+	done := c.done
 
 	// stdin を閉じてサーバーに終了を通知
 	if c.stdin != nil {
 		c.stdin.Close()
 	}
-
-	// プロセスが終了するのを待つ（タイムアウト付きは呼び出し元で context.WithTimeout）
 	if c.cmd != nil && c.cmd.Process != nil {
 		c.cmd.Process.Kill()
-		c.cmd.Wait()
+	}
+	c.mu.Unlock()
+
+	// readLoop がプロセスの終了を回収するのを待つ
+	if done != nil {
+		<-done
 	}
 
 	return nil
@@ -223,13 +280,33 @@ func (c *Client) IsRunning() bool {
 	return c.running
 }
 
-// call JSON-RPC リクエストを送信しレスポンスを待つ
-func (c *Client) call(method string, params interface{}) (json.RawMessage, error) {
+// Done プロセス終了時に close されるチャネルを返す（Start 前は nil）
+func (c *Client) Done() <-chan struct{} {
 	c.mu.Lock()
 	defer c.mu.Unlock()
+	return c.done
+}
+
+// ExitErr サーバーが予期せず終了した場合にその理由を返す
+func (c *Client) ExitErr() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.exitErr
+}
 
+// call JSON-RPC リクエストを送信しレスポンスを待つ（初期化用、DefaultCallTimeout で打ち切り）
+func (c *Client) call(method string, params interface{}) (json.RawMessage, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultCallTimeout)
+	defer cancel()
+	return c.callContext(ctx, method, params)
+}
+
+// callContext JSON-RPC リクエストを送信し、レスポンス・ctx 終了・プロセス終了のいずれかまで待つ
+func (c *Client) callContext(ctx context.Context, method string, params interface{}) (json.RawMessage, error) {
+	c.mu.Lock()
 	if !c.running {
-		return nil, fmt.Errorf("MCP server '%s' is not running", c.name)
+		c.mu.Unlock()
+		return nil, c.notRunningError()
 	}
 
 	id := atomic.AddInt64(&c.nextID, 1)
@@ -242,45 +319,51 @@ func (c *Client) call(method string, params interface{}) (json.RawMessage, error
 
 	data, err := json.Marshal(req)
 	if err != nil {
+		c.mu.Unlock()
 		return nil, fmt.Errorf("marshal error: %w", err)
 	}
 
+	ch := make(chan *JSONRPCResponse, 1)
+	c.pending[id] = ch
+	done := c.done
+
 	// リクエスト送信（改行区切り）
 	if _, err := c.stdin.Write(append(data, '\n')); err != nil {
+		delete(c.pending, id)
+		c.mu.Unlock()
 		return nil, fmt.Errorf("write error: %w", err)
 	}
+	c.mu.Unlock()
 
-	// レスポンス読み取り
-	for c.stdout.Scan() {
-		line := c.stdout.Bytes()
-		if len(line) == 0 {
-			continue
-		}
-
-		var resp JSONRPCResponse
-		if err := json.Unmarshal(line, &resp); err != nil {
-			continue // 通知やパースできないメッセージはスキップ
-		}
-
-		// ID が一致するレスポンスを返す
-		if resp.ID == id {
-			if resp.Error != nil {
-				return nil, resp.Error
-			}
-			return resp.Result, nil
+	select {
+	case resp := <-ch:
+		if resp.Error != nil {
+			return nil, resp.Error
 		}
-		// ID不一致の場合はスキップ（通知など）
+		return resp.Result, nil
+	case <-done:
+		return nil, c.notRunningError()
+	case <-ctx.Done():
+		c.mu.Lock()
+		delete(c.pending, id)
+		c.mu.Unlock()
+		return nil, ctx.Err()
 	}
+}
 
-	if err := c.stdout.Err(); err != nil {
-		return nil, fmt.Errorf("read error: %w", err)
+// notRunningError 停止中のサーバーへの呼び出しエラー（クラッシュ時は理由を含める）
+func (c *Client) notRunningError() error {
+	if err := c.ExitErr(); err != nil {
+		return fmt.Errorf("MCP server '%s' exited unexpectedly: %v", c.name, err)
 	}
-
-	return nil, fmt.Errorf("MCP server '%s' closed connection unexpectedly", c.name)
+	return fmt.Errorf("MCP server '%s' is not running", c.name)
 }
 
 // notify JSON-RPC 通知を送信（IDなし、レスポンス不要）
 func (c *Client) notify(method string, params interface{}) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
 	if !c.running {
 		return fmt.Errorf("MCP server '%s' is not running", c.name)
 	}
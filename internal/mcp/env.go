@@ -0,0 +1,75 @@
+package mcp
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// envRefPattern ${VAR} または ${VAR:-default} 形式の参照
+var envRefPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(:-[^}]*)?\}`)
+
+// expandEnvRefs 文字列中の ${VAR} を環境変数の値で置き換える
+// 未設定の変数は ${VAR:-default} 形式でなければエラーにする（空文字のまま起動させない）
+func expandEnvRefs(s string) (string, error) {
+	var missing string
+	expanded := envRefPattern.ReplaceAllStringFunc(s, func(ref string) string {
+		m := envRefPattern.FindStringSubmatch(ref)
+		name, hasDefault := m[1], m[2] != ""
+		value, ok := os.LookupEnv(name)
+		if hasDefault && value == "" {
+			return strings.TrimPrefix(m[2], ":-")
+		}
+		if !ok && missing == "" {
+			missing = name
+		}
+		return value
+	})
+	if missing != "" {
+		return "", fmt.Errorf("環境変数 %s が設定されていません（既定値は ${%s:-default} で指定）", missing, missing)
+	}
+	return expanded, nil
+}
+
+// expandServerEnv サーバー設定の command・args・env に含まれる ${VAR} を展開する
+func expandServerEnv(name string, cfg MCPServerConfig) (MCPServerConfig, error) {
+	wrap := func(field string, err error) error {
+		return fmt.Errorf("MCP '%s' の %s: %w", name, field, err)
+	}
+
+	command, err := expandEnvRefs(cfg.Command)
+	if err != nil {
+		return cfg, wrap("command", err)
+	}
+	cfg.Command = command
+
+	if cfg.Args != nil {
+		args := make([]string, len(cfg.Args))
+		for i, arg := range cfg.Args {
+			if args[i], err = expandEnvRefs(arg); err != nil {
+				return cfg, wrap(fmt.Sprintf("args[%d]", i), err)
+			}
+		}
+		cfg.Args = args
+	}
+
+	if cfg.Env != nil {
+		keys := make([]string, 0, len(cfg.Env))
+		for key := range cfg.Env {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		env := make(map[string]string, len(cfg.Env))
+		for _, key := range keys {
+			if env[key], err = expandEnvRefs(cfg.Env[key]); err != nil {
+				return cfg, wrap("env."+key, err)
+			}
+		}
+		cfg.Env = env
+	}
+
+	return cfg, nil
+}
@@ -3,11 +3,14 @@ package mcp
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
+	"time"
 )
 
 // MCPServerConfig mcp.json 内の1サーバー設定
@@ -15,6 +18,7 @@ type MCPServerConfig struct {
 	Command string            `json:"command"`
 	Args    []string          `json:"args"`
 	Env     map[string]string `json:"env,omitempty"`
+	Timeout int               `json:"timeout,omitempty"` // ツール呼び出しタイムアウト（秒、0 = マネージャーの既定値）
 }
 
 // MCPConfigFile mcp.json のルート構造
@@ -22,22 +26,103 @@ type MCPConfigFile struct {
 	MCPServers map[string]MCPServerConfig `json:"mcpServers"`
 }
 
+const (
+	// DefaultMaxRestarts クラッシュしたサーバーを自動再起動する最大回数
+	DefaultMaxRestarts = 3
+	// DefaultRestartBackoff 自動再起動までの初回待ち時間（再起動ごとに倍増）
+	DefaultRestartBackoff = time.Second
+	// DefaultMaxConcurrentStarts StartAll で同時に起動するサーバー数の上限
+	DefaultMaxConcurrentStarts = 4
+)
+
 // Manager 複数のMCPサーバーを管理
 type Manager struct {
-	clients map[string]*Client
-	configs map[string]MCPServerConfig
-	mu      sync.RWMutex
+	clients        map[string]*Client
+	configs        map[string]MCPServerConfig
+	callTimeout    time.Duration
+	ctx            context.Context // StartAll に渡されたコンテキスト（再起動時に使用）
+	restarts       map[string]int  // サーバーごとの自動再起動回数
+	maxRestarts    int
+	restartBackoff time.Duration
+	onRestart      func(name string, tools []MCPToolSchema) // 再起動後のツール再登録
+	globalDir      string                                   // グローバル mcp.json のディレクトリ（"" = ~/.config/vibe-local-go）
+	maxStarts      int                                      // StartAll の同時起動数
+	onProgress     func(name string, done, total int, err error)
+	startFn        func(ctx context.Context, name string, cfg MCPServerConfig) (*Client, error) // テストで差し替え
+	mu             sync.RWMutex
 }
 
 // NewManager 新しいMCPマネージャーを作成
 func NewManager() *Manager {
 	return &Manager{
-		clients: make(map[string]*Client),
-		configs: make(map[string]MCPServerConfig),
+		clients:        make(map[string]*Client),
+		configs:        make(map[string]MCPServerConfig),
+		callTimeout:    DefaultCallTimeout,
+		ctx:            context.Background(),
+		restarts:       make(map[string]int),
+		maxRestarts:    DefaultMaxRestarts,
+		restartBackoff: DefaultRestartBackoff,
+		maxStarts:      DefaultMaxConcurrentStarts,
+		startFn:        startClient,
+	}
+}
+
+// SetMaxConcurrentStarts StartAll の同時起動数を設定（0 以下でデフォルトに戻す）
+func (m *Manager) SetMaxConcurrentStarts(n int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if n <= 0 {
+		n = DefaultMaxConcurrentStarts
 	}
+	m.maxStarts = n
 }
 
-// LoadConfig mcp.json を読み込み
+// OnStartProgress StartAll で1サーバーの起動が終わるたびに呼ばれるコールバックを設定
+// 呼び出しは直列化されるため、コールバック内で端末に書き込んでよい
+func (m *Manager) OnStartProgress(fn func(name string, done, total int, err error)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.onProgress = fn
+}
+
+// SetCallTimeout ツール呼び出しの既定タイムアウトを設定（0 以下でデフォルトに戻す）
+func (m *Manager) SetCallTimeout(d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if d <= 0 {
+		d = DefaultCallTimeout
+	}
+	m.callTimeout = d
+}
+
+// CallTimeout 指定サーバーのツール呼び出しタイムアウトを返す（mcp.json の timeout が優先）
+func (m *Manager) CallTimeout(serverName string) time.Duration {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if cfg, ok := m.configs[serverName]; ok && cfg.Timeout > 0 {
+		return time.Duration(cfg.Timeout) * time.Second
+	}
+	return m.callTimeout
+}
+
+// SetGlobalConfigDir グローバル mcp.json のディレクトリを変更する（VIBE_CONFIG_DIR 用、LoadConfig の前に呼ぶ）
+func (m *Manager) SetGlobalConfigDir(dir string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.globalDir = dir
+}
+
+// GlobalConfigPath グローバル mcp.json のパスを返す
+func (m *Manager) GlobalConfigPath() string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.globalConfigPath()
+}
+
+// LoadConfig mcp.json を読み込み（command・args・env 内の ${VAR} / ${VAR:-default} は環境変数で展開）
 // 探索順: プロジェクト (.vibe-local/mcp.json) → グローバル (~/.config/vibe-local-go/mcp.json)
 func (m *Manager) LoadConfig() error {
 	m.mu.Lock()
@@ -45,6 +130,8 @@ func (m *Manager) LoadConfig() error {
 
 	// 探索パス
 	paths := m.configPaths()
+	var errs []error
+	failed := make(map[string]bool) // 展開に失敗したサーバー（グローバル設定で代替しない）
 
 	for _, p := range paths {
 		data, err := os.ReadFile(p)
@@ -59,13 +146,22 @@ func (m *Manager) LoadConfig() error {
 
 		// マージ（プロジェクト設定が優先）
 		for name, serverCfg := range cfg.MCPServers {
-			if _, exists := m.configs[name]; !exists {
-				m.configs[name] = serverCfg
+			if _, exists := m.configs[name]; exists || failed[name] {
+				continue
 			}
+			// ${VAR} を展開（トークンを mcp.json に直書きしなくて済むように）
+			expanded, err := expandServerEnv(name, serverCfg)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", p, err))
+				failed[name] = true
+				continue
+			}
+			m.configs[name] = expanded
 		}
 	}
 
-	return nil
+	// 展開できなかったサーバーだけ除外し、残りは起動できるようにする
+	return errors.Join(errs...)
 }
 
 // configPaths mcp.json の探索パス一覧を返す
@@ -78,44 +174,232 @@ func (m *Manager) configPaths() []string {
 	}
 
 	// グローバル
-	if home, err := os.UserHomeDir(); err == nil {
-		paths = append(paths, filepath.Join(home, ".config", "vibe-local-go", "mcp.json"))
+	if p := m.globalConfigPath(); p != "" {
+		paths = append(paths, p)
 	}
 
 	return paths
 }
 
-// StartAll 設定済みの全MCPサーバーを起動
+// globalConfigPath グローバル mcp.json のパス（ロック保持中に呼ぶ、"" = ホームディレクトリ不明）
+func (m *Manager) globalConfigPath() string {
+	if m.globalDir != "" {
+		return filepath.Join(m.globalDir, "mcp.json")
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		return filepath.Join(home, ".config", "vibe-local-go", "mcp.json")
+	}
+	return ""
+}
+
+// StartAll 設定済みの全MCPサーバーを起動（同時起動数は SetMaxConcurrentStarts で制限）
+// 失敗したサーバーのエラーはサーバー名順に返し、起動できたサーバーはそのまま登録する
+// 起動したサーバーは監視され、クラッシュすると自動的に再起動される
 func (m *Manager) StartAll(ctx context.Context) []error {
 	m.mu.Lock()
-	defer m.mu.Unlock()
+	m.ctx = ctx
+	names := make([]string, 0, len(m.configs))
+	configs := make(map[string]MCPServerConfig, len(m.configs))
+	for name, cfg := range m.configs {
+		names = append(names, name)
+		configs[name] = cfg
+	}
+	limit, onProgress, start := m.maxStarts, m.onProgress, m.startFn
+	m.mu.Unlock()
 
-	var errs []error
+	sort.Strings(names)
 
-	for name, cfg := range m.configs {
-		client := NewClient(name)
+	// 遅いサーバーが他を待たせないよう、上限つきで並行起動する
+	type result struct {
+		client *Client
+		err    error
+	}
+	results := make([]result, len(names))
+	sem := make(chan struct{}, limit)
+	var wg sync.WaitGroup
+	var progressMu sync.Mutex
+	done := 0
+
+	for i, name := range names {
+		wg.Add(1)
+		go func(i int, name string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			client, err := start(ctx, name, configs[name])
+			<-sem
+			results[i] = result{client: client, err: err}
+
+			progressMu.Lock()
+			done++
+			if onProgress != nil {
+				onProgress(name, done, len(names), err)
+			}
+			progressMu.Unlock()
+		}(i, name)
+	}
+	wg.Wait()
+
+	// 起動できたサーバーだけ登録する（一部の失敗で全体を止めない）
+	m.mu.Lock()
+	defer m.mu.Unlock()
 
-		if err := client.Start(ctx, cfg.Command, cfg.Args, cfg.Env); err != nil {
-			errs = append(errs, fmt.Errorf("MCP '%s' 起動エラー: %w", name, err))
+	var errs []error
+	for i, name := range names {
+		r := results[i]
+		if r.err != nil {
+			errs = append(errs, r.err)
 			continue
 		}
+		m.clients[name] = r.client
+		go m.supervise(name, r.client)
+	}
 
-		if err := client.Initialize(); err != nil {
-			client.Stop()
-			errs = append(errs, fmt.Errorf("MCP '%s' 初期化エラー: %w", name, err))
-			continue
+	return errs
+}
+
+// startClient サーバーを起動し、初期化とツール一覧取得まで行う
+func startClient(ctx context.Context, name string, cfg MCPServerConfig) (*Client, error) {
+	client := NewClient(name)
+
+	if err := client.Start(ctx, cfg.Command, cfg.Args, cfg.Env); err != nil {
+		return nil, fmt.Errorf("MCP '%s' 起動エラー: %w", name, err)
+	}
+
+	if err := client.Initialize(); err != nil {
+		client.Stop()
+		return nil, fmt.Errorf("MCP '%s' 初期化エラー: %w", name, err)
+	}
+
+	if _, err := client.ListTools(); err != nil {
+		client.Stop()
+		return nil, fmt.Errorf("MCP '%s' ツール一覧取得エラー: %w", name, err)
+	}
+
+	return client, nil
+}
+
+// supervise サーバープロセスの終了を待ち、クラッシュなら backoff 付きで再起動する。
+// 再起動回数が上限に達したサーバーは警告を出して停止したままにする
+func (m *Manager) supervise(name string, client *Client) {
+	done := client.Done()
+	if done == nil {
+		return
+	}
+	<-done
+
+	// Stop による終了（StopAll / 手動再起動）は再起動しない
+	if client.ExitErr() == nil {
+		return
+	}
+
+	for {
+		m.mu.Lock()
+		if m.clients[name] != client || m.ctx.Err() != nil {
+			// 手動で再起動済み、またはシャットダウン中
+			m.mu.Unlock()
+			return
+		}
+		attempt := m.restarts[name]
+		if attempt >= m.maxRestarts {
+			m.mu.Unlock()
+			fmt.Fprintf(os.Stderr, "MCP '%s' がクラッシュを繰り返したため停止したままにします (%d 回再起動): %v\n",
+				name, attempt, client.ExitErr())
+			return
+		}
+		m.restarts[name] = attempt + 1
+		backoff := m.restartBackoff << attempt
+		ctx := m.ctx
+		m.mu.Unlock()
+
+		// シャットダウンされたら backoff を待たずに終了する
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
 		}
 
-		if _, err := client.ListTools(); err != nil {
-			client.Stop()
-			errs = append(errs, fmt.Errorf("MCP '%s' ツール一覧取得エラー: %w", name, err))
-			continue
+		newClient, err := m.replaceClient(name, client)
+		if err == nil {
+			if newClient != nil {
+				go m.supervise(name, newClient)
+			}
+			return
 		}
+		fmt.Fprintf(os.Stderr, "MCP '%s' 再起動エラー: %v\n", name, err)
+	}
+}
 
-		m.clients[name] = client
+// replaceClient 新しいプロセスを起動し、old がまだ登録中なら差し替える。
+// old が既に差し替えられていた場合は何もせず nil を返す
+func (m *Manager) replaceClient(name string, old *Client) (*Client, error) {
+	m.mu.RLock()
+	cfg, ok := m.configs[name]
+	ctx := m.ctx
+	m.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("MCP server '%s' が見つかりません", name)
 	}
 
-	return errs
+	client, err := startClient(ctx, name, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	if current := m.clients[name]; current != old {
+		m.mu.Unlock()
+		client.Stop()
+		return nil, nil
+	}
+	m.clients[name] = client
+	onRestart := m.onRestart
+	m.mu.Unlock()
+
+	if onRestart != nil {
+		onRestart(name, client.GetTools())
+	}
+	return client, nil
+}
+
+// RestartServer 指定サーバーを手動で再起動する（自動再起動の回数もリセット）
+func (m *Manager) RestartServer(name string) error {
+	m.mu.Lock()
+	if _, ok := m.configs[name]; !ok {
+		m.mu.Unlock()
+		return fmt.Errorf("MCP server '%s' が見つかりません", name)
+	}
+	old := m.clients[name]
+	m.restarts[name] = 0
+	m.mu.Unlock()
+
+	if old != nil {
+		old.Stop()
+	}
+
+	client, err := m.replaceClient(name, old)
+	if err != nil {
+		return err
+	}
+	if client != nil {
+		go m.supervise(name, client)
+	}
+	return nil
+}
+
+// RestartCount 指定サーバーの自動再起動回数を返す
+func (m *Manager) RestartCount(name string) int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.restarts[name]
+}
+
+// OnRestart 再起動後に呼ばれるコールバックを設定（ツールの再登録用）
+func (m *Manager) OnRestart(fn func(name string, tools []MCPToolSchema)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.onRestart = fn
 }
 
 // StopAll 全MCPサーバーを停止
@@ -144,7 +428,8 @@ func (m *Manager) GetAllTools() map[string][]MCPToolSchema {
 }
 
 // CallTool 指定サーバーのツールを呼び出す
-func (m *Manager) CallTool(serverName, toolName string, arguments json.RawMessage) (*MCPToolCallResult, error) {
+// サーバーごとのタイムアウトを超えても応答がなければエラーを返す
+func (m *Manager) CallTool(ctx context.Context, serverName, toolName string, arguments json.RawMessage) (*MCPToolCallResult, error) {
 	m.mu.RLock()
 	client, ok := m.clients[serverName]
 	m.mu.RUnlock()
@@ -153,7 +438,16 @@ func (m *Manager) CallTool(serverName, toolName string, arguments json.RawMessag
 		return nil, fmt.Errorf("MCP server '%s' が見つかりません", serverName)
 	}
 
-	return client.CallTool(toolName, arguments)
+	timeout := m.CallTimeout(serverName)
+	callCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	result, err := client.CallTool(callCtx, toolName, arguments)
+	if errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil {
+		return nil, fmt.Errorf("MCP tool '%s' on server '%s' timed out after %s (the server may be hung; try again or use a different approach)",
+			toolName, serverName, timeout)
+	}
+	return result, err
 }
 
 // FindToolServer ツール名からサーバーを検索
@@ -194,11 +488,18 @@ func (m *Manager) ServerCount() int {
 	return len(m.configs)
 }
 
-// RunningCount 稼働中サーバー数を返す
+// RunningCount 稼働中サーバー数を返す（異常終了したサーバーは含まない）
 func (m *Manager) RunningCount() int {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
-	return len(m.clients)
+
+	count := 0
+	for _, client := range m.clients {
+		if client.IsRunning() {
+			count++
+		}
+	}
+	return count
 }
 
 // GetServerNames 全サーバー名を返す
@@ -222,6 +523,18 @@ func (m *Manager) IsRunning(name string) bool {
 	return ok && client.IsRunning()
 }
 
+// ExitError 指定サーバーが予期せず終了していればその理由を返す
+func (m *Manager) ExitError(name string) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	client, ok := m.clients[name]
+	if !ok {
+		return nil
+	}
+	return client.ExitErr()
+}
+
 // TotalToolCount 全サーバーのツール合計数を返す
 func (m *Manager) TotalToolCount() int {
 	m.mu.RLock()
@@ -0,0 +1,437 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/zephel01/vibe-local-go/internal/tool"
+)
+
+const (
+	// helperEnv selects the behavior of the mock MCP server for tools/call
+	helperEnv = "VIBE_MCP_HELPER_MODE"
+	// helperMarkerEnv is the file the "crash-once" mode uses to remember it already crashed
+	helperMarkerEnv = "VIBE_MCP_HELPER_MARKER"
+)
+
+// TestHelperMCPServer is not a real test: it runs as the mock MCP server when
+// re-executed with helperEnv set. It answers initialize and tools/list, then
+// either never answers tools/call ("hang"), exits ("crash"), or exits only
+// the first time across restarts ("crash-once").
+func TestHelperMCPServer(t *testing.T) {
+	mode := os.Getenv(helperEnv)
+	if mode == "" {
+		return
+	}
+
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		var req JSONRPCRequest
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil || req.ID == 0 {
+			continue
+		}
+
+		var result interface{}
+		switch req.Method {
+		case "initialize":
+			result = map[string]interface{}{}
+		case "tools/list":
+			result = map[string]interface{}{
+				"tools": []MCPToolSchema{{Name: "slow", Description: "never finishes"}},
+			}
+		case "tools/call":
+			if mode == "crash" {
+				os.Exit(3)
+			}
+			if mode == "crash-once" {
+				marker := os.Getenv(helperMarkerEnv)
+				if _, err := os.Stat(marker); err != nil {
+					os.WriteFile(marker, nil, 0644)
+					os.Exit(3)
+				}
+				result = MCPToolCallResult{Content: []MCPContent{{Type: "text", Text: "recovered"}}}
+				break
+			}
+			continue // hang: never respond
+		}
+
+		data, _ := json.Marshal(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": result})
+		fmt.Println(string(data))
+	}
+	os.Exit(0)
+}
+
+// startMockServer starts a manager with one mock server named "mock";
+// configure runs before the server starts
+func startMockServer(t *testing.T, mode string, configure ...func(*Manager)) *Manager {
+	t.Helper()
+	t.Setenv(helperEnv, mode)
+	t.Setenv(helperMarkerEnv, filepath.Join(t.TempDir(), "crashed"))
+
+	m := NewManager()
+	m.configs["mock"] = MCPServerConfig{
+		Command: os.Args[0],
+		Args:    []string{"-test.run=^TestHelperMCPServer$"},
+	}
+	for _, fn := range configure {
+		fn(m)
+	}
+	if errs := m.StartAll(context.Background()); len(errs) > 0 {
+		t.Fatalf("failed to start mock server: %v", errs)
+	}
+	t.Cleanup(m.StopAll)
+	return m
+}
+
+func TestMCPToolAdapter_TimesOutOnHungServer(t *testing.T) {
+	m := startMockServer(t, "hang")
+	m.SetCallTimeout(300 * time.Millisecond)
+
+	registry := tool.NewRegistry()
+	if n := RegisterMCPTools(registry, m); n != 1 {
+		t.Fatalf("expected 1 registered tool, got %d", n)
+	}
+	adapter, ok := registry.GetTool("mcp_mock_slow")
+	if !ok {
+		t.Fatal("expected mcp_mock_slow to be registered")
+	}
+
+	start := time.Now()
+	result, err := adapter.Execute(context.Background(), json.RawMessage(`{}`))
+	elapsed := time.Since(start)
+
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if elapsed > 2*time.Second {
+		t.Errorf("expected call to return within the timeout, took %s", elapsed)
+	}
+	if !result.IsError || !strings.Contains(result.Error, "timed out after 300ms") {
+		t.Errorf("expected timeout error, got: %+v", result)
+	}
+
+	// A timed-out call doesn't take the server down
+	if !m.IsRunning("mock") || m.RunningCount() != 1 {
+		t.Error("expected server to still be running after a timeout")
+	}
+}
+
+func TestManager_DetectsCrashedServer(t *testing.T) {
+	m := startMockServer(t, "crash", func(m *Manager) { m.maxRestarts = 0 })
+
+	start := time.Now()
+	_, err := m.CallTool(context.Background(), "mock", "slow", nil)
+	if err == nil || !strings.Contains(err.Error(), "exited unexpectedly") {
+		t.Fatalf("expected crash error, got %v", err)
+	}
+	if time.Since(start) > 2*time.Second {
+		t.Errorf("expected crash to be reported without waiting for the timeout")
+	}
+
+	if m.IsRunning("mock") {
+		t.Error("expected crashed server to be marked not running")
+	}
+	if got := m.RunningCount(); got != 0 {
+		t.Errorf("RunningCount() = %d, want 0", got)
+	}
+	if m.ExitError("mock") == nil {
+		t.Error("expected exit reason for crashed server")
+	}
+}
+
+func TestManager_CallTimeoutPerServer(t *testing.T) {
+	m := NewManager()
+	m.configs["fast"] = MCPServerConfig{Command: "x", Timeout: 5}
+	m.configs["default"] = MCPServerConfig{Command: "y"}
+
+	if got := m.CallTimeout("fast"); got != 5*time.Second {
+		t.Errorf("CallTimeout(fast) = %s, want 5s", got)
+	}
+	if got := m.CallTimeout("default"); got != DefaultCallTimeout {
+		t.Errorf("CallTimeout(default) = %s, want %s", got, DefaultCallTimeout)
+	}
+}
+
+// waitFor polls cond until it holds or the deadline passes
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestManager_RestartsCrashedServerAndReregistersTools(t *testing.T) {
+	m := startMockServer(t, "crash-once", func(m *Manager) { m.restartBackoff = 10 * time.Millisecond })
+
+	registry := tool.NewRegistry()
+	RegisterMCPTools(registry, m)
+	adapter, _ := registry.GetTool("mcp_mock_slow")
+
+	result, _ := adapter.Execute(context.Background(), json.RawMessage(`{}`))
+	if !result.IsError || !strings.Contains(result.Error, "exited unexpectedly") {
+		t.Fatalf("expected crash on first call, got: %+v", result)
+	}
+
+	waitFor(t, "automatic restart", func() bool { return m.IsRunning("mock") })
+	if got := m.RestartCount("mock"); got != 1 {
+		t.Errorf("RestartCount() = %d, want 1", got)
+	}
+
+	// The re-registered tool talks to the restarted process
+	adapter, ok := registry.GetTool("mcp_mock_slow")
+	if !ok {
+		t.Fatal("expected tool to stay registered after restart")
+	}
+	result, _ = adapter.Execute(context.Background(), json.RawMessage(`{}`))
+	if result.IsError || result.Output != "recovered" {
+		t.Errorf("expected restarted server to answer, got: %+v", result)
+	}
+}
+
+func TestRegisterMCPTools_RestartDropsRemovedTools(t *testing.T) {
+	m := NewManager()
+	registry := tool.NewRegistry()
+	RegisterMCPTools(registry, m)
+	registerServerTools(registry, m, "mock", []MCPToolSchema{{Name: "keep"}, {Name: "gone"}})
+	registerServerTools(registry, m, "mock_other", []MCPToolSchema{{Name: "tool"}})
+
+	// The restarted server no longer offers "gone"
+	m.onRestart("mock", []MCPToolSchema{{Name: "keep"}})
+
+	if _, ok := registry.GetTool("mcp_mock_gone"); ok {
+		t.Error("expected the removed tool to be unregistered after restart")
+	}
+	for _, name := range []string{"mcp_mock_keep", "mcp_mock_other_tool"} {
+		if _, ok := registry.GetTool(name); !ok {
+			t.Errorf("expected %s to stay registered", name)
+		}
+	}
+}
+
+func TestManager_ShutdownInterruptsRestartBackoff(t *testing.T) {
+	t.Setenv(helperEnv, "crash")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	m := NewManager()
+	m.configs["mock"] = MCPServerConfig{
+		Command: os.Args[0],
+		Args:    []string{"-test.run=^TestHelperMCPServer$"},
+	}
+	m.restartBackoff = time.Hour
+	m.ctx = ctx
+	client, err := startClient(ctx, "mock", m.configs["mock"])
+	if err != nil {
+		t.Fatalf("failed to start mock server: %v", err)
+	}
+	m.clients["mock"] = client
+
+	supervised := make(chan struct{})
+	go func() {
+		m.supervise("mock", client)
+		close(supervised)
+	}()
+
+	m.CallTool(context.Background(), "mock", "slow", nil)
+	waitFor(t, "restart backoff", func() bool { return m.RestartCount("mock") == 1 })
+
+	cancel()
+	select {
+	case <-supervised:
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected shutdown to end the restart backoff")
+	}
+}
+
+func TestManager_LeavesCrashLoopingServerDown(t *testing.T) {
+	m := startMockServer(t, "crash", func(m *Manager) {
+		m.maxRestarts = 2
+		m.restartBackoff = 10 * time.Millisecond
+	})
+
+	for i := 1; i <= 2; i++ {
+		m.CallTool(context.Background(), "mock", "slow", nil)
+		waitFor(t, fmt.Sprintf("restart %d", i), func() bool { return m.IsRunning("mock") && m.RestartCount("mock") == i })
+	}
+
+	// The third crash exceeds the limit: the server stays down
+	m.CallTool(context.Background(), "mock", "slow", nil)
+	time.Sleep(200 * time.Millisecond)
+	if m.IsRunning("mock") {
+		t.Error("expected crash-looping server to be left down")
+	}
+	if got := m.RestartCount("mock"); got != 2 {
+		t.Errorf("RestartCount() = %d, want 2", got)
+	}
+
+	// A manual restart brings it back and resets the count
+	if err := m.RestartServer("mock"); err != nil {
+		t.Fatalf("RestartServer: %v", err)
+	}
+	if !m.IsRunning("mock") || m.RestartCount("mock") != 0 {
+		t.Errorf("expected running server with reset count, running=%v count=%d", m.IsRunning("mock"), m.RestartCount("mock"))
+	}
+
+	if err := m.RestartServer("unknown"); err == nil {
+		t.Error("expected error for unknown server")
+	}
+}
+
+func TestManager_SetGlobalConfigDir(t *testing.T) {
+	dir := t.TempDir()
+	config := `{"mcpServers": {"relocated": {"command": "echo"}}}`
+	if err := os.WriteFile(filepath.Join(dir, "mcp.json"), []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	m := NewManager()
+	m.SetGlobalConfigDir(dir)
+	if got := m.GlobalConfigPath(); got != filepath.Join(dir, "mcp.json") {
+		t.Errorf("GlobalConfigPath() = %q", got)
+	}
+	if err := m.LoadConfig(); err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if _, ok := m.configs["relocated"]; !ok {
+		t.Errorf("servers = %v, want the one from %s", m.GetServerNames(), dir)
+	}
+}
+
+func TestManager_StartAllLimitsConcurrencyAndKeepsSuccesses(t *testing.T) {
+	m := NewManager()
+	m.SetMaxConcurrentStarts(2)
+	for _, name := range []string{"a", "b", "bad-c", "d", "bad-e", "f", "g"} {
+		m.configs[name] = MCPServerConfig{Command: "mock"}
+	}
+
+	var inFlight, maxInFlight int32
+	m.startFn = func(ctx context.Context, name string, cfg MCPServerConfig) (*Client, error) {
+		n := atomic.AddInt32(&inFlight, 1)
+		for {
+			cur := atomic.LoadInt32(&maxInFlight)
+			if n <= cur || atomic.CompareAndSwapInt32(&maxInFlight, cur, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		atomic.AddInt32(&inFlight, -1)
+		if strings.HasPrefix(name, "bad") {
+			return nil, fmt.Errorf("MCP '%s' 起動エラー: boom", name)
+		}
+		return NewClient(name), nil // never started, so supervise returns immediately
+	}
+
+	var progress []int
+	m.OnStartProgress(func(name string, done, total int, err error) {
+		if total != 7 {
+			t.Errorf("progress total = %d, want 7", total)
+		}
+		progress = append(progress, done)
+	})
+
+	errs := m.StartAll(context.Background())
+
+	if got := atomic.LoadInt32(&maxInFlight); got != 2 {
+		t.Errorf("max concurrent starts = %d, want 2", got)
+	}
+	if len(errs) != 2 || !strings.Contains(errs[0].Error(), "bad-c") || !strings.Contains(errs[1].Error(), "bad-e") {
+		t.Errorf("errors = %v, want bad-c and bad-e in name order", errs)
+	}
+	for _, name := range []string{"a", "b", "d", "f", "g"} {
+		if _, ok := m.clients[name]; !ok {
+			t.Errorf("server %q should be registered despite other failures", name)
+		}
+	}
+	if len(m.clients) != 5 {
+		t.Errorf("registered %d servers, want 5", len(m.clients))
+	}
+	if len(progress) != 7 || progress[6] != 7 {
+		t.Errorf("progress = %v, want 7 updates ending at 7/7", progress)
+	}
+}
+
+// loadConfigFrom writes config as the global mcp.json and loads it from an
+// empty working directory, so no project mcp.json interferes.
+func loadConfigFrom(t *testing.T, config string) (*Manager, error) {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "mcp.json"), []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(t.TempDir())
+
+	m := NewManager()
+	m.SetGlobalConfigDir(dir)
+	return m, m.LoadConfig()
+}
+
+func TestManager_LoadConfigExpandsEnvRefs(t *testing.T) {
+	t.Setenv("VIBE_TEST_MCP_TOKEN", "secret-token")
+	t.Setenv("VIBE_TEST_MCP_ROOT", "/srv/data")
+	t.Setenv("VIBE_TEST_MCP_EMPTY", "")
+
+	m, err := loadConfigFrom(t, `{"mcpServers": {"github": {
+		"command": "npx",
+		"args": ["-y", "server-github", "--root=${VIBE_TEST_MCP_ROOT}/repos", "$HOME"],
+		"env": {
+			"GITHUB_TOKEN": "${VIBE_TEST_MCP_TOKEN}",
+			"LOG_LEVEL": "${VIBE_TEST_MCP_UNSET_LEVEL:-info}",
+			"REGION": "${VIBE_TEST_MCP_EMPTY:-us-east-1}",
+			"SUFFIX": "${VIBE_TEST_MCP_EMPTY}"
+		}
+	}}}`)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+
+	cfg := m.configs["github"]
+	wantArgs := []string{"-y", "server-github", "--root=/srv/data/repos", "$HOME"}
+	if strings.Join(cfg.Args, " ") != strings.Join(wantArgs, " ") {
+		t.Errorf("args = %q, want %q (only ${...} references are expanded)", cfg.Args, wantArgs)
+	}
+	wantEnv := map[string]string{
+		"GITHUB_TOKEN": "secret-token",
+		"LOG_LEVEL":    "info",
+		"REGION":       "us-east-1",
+		"SUFFIX":       "",
+	}
+	for key, want := range wantEnv {
+		if got := cfg.Env[key]; got != want {
+			t.Errorf("env[%s] = %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestManager_LoadConfigMissingEnvRef(t *testing.T) {
+	m, err := loadConfigFrom(t, `{"mcpServers": {
+		"broken": {"command": "npx", "env": {"API_KEY": "${VIBE_TEST_MCP_MISSING}"}},
+		"fine": {"command": "echo", "args": ["${VIBE_TEST_MCP_MISSING:-fallback}"]}
+	}}`)
+	if err == nil {
+		t.Fatal("expected an error for an unset variable without a default")
+	}
+	for _, want := range []string{"broken", "env.API_KEY", "VIBE_TEST_MCP_MISSING"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q should mention %q", err, want)
+		}
+	}
+
+	if _, ok := m.configs["broken"]; ok {
+		t.Error("the server with the unset variable should not be loaded")
+	}
+	if cfg, ok := m.configs["fine"]; !ok || len(cfg.Args) != 1 || cfg.Args[0] != "fallback" {
+		t.Errorf("other servers should still load, got %+v", m.configs)
+	}
+}
@@ -34,7 +34,7 @@ func (a *MCPToolAdapter) Name() string {
 
 // Execute ツールを実行
 func (a *MCPToolAdapter) Execute(ctx context.Context, params json.RawMessage) (*tool.Result, error) {
-	result, err := a.manager.CallTool(a.serverName, a.toolSchema.Name, params)
+	result, err := a.manager.CallTool(ctx, a.serverName, a.toolSchema.Name, params)
 	if err != nil {
 		return tool.NewErrorResult(err), nil
 	}
@@ -99,17 +99,41 @@ func (a *MCPToolAdapter) Schema() *tool.FunctionSchema {
 }
 
 // RegisterMCPTools MCPマネージャーの全ツールを tool.Registry に登録
+// サーバーが再起動した場合もツールを登録し直す
 func RegisterMCPTools(registry *tool.Registry, manager *Manager) int {
 	allTools := manager.GetAllTools()
 	count := 0
 
 	for serverName, tools := range allTools {
-		for _, t := range tools {
-			adapter := NewMCPToolAdapter(serverName, t, manager)
-			registry.Register(adapter)
-			count++
-		}
+		count += registerServerTools(registry, manager, serverName, tools)
 	}
 
+	manager.OnRestart(func(name string, tools []MCPToolSchema) {
+		// 再起動後のサーバーが提供しなくなったツールを残さない
+		unregisterServerTools(registry, name)
+		registerServerTools(registry, manager, name, tools)
+	})
+
 	return count
 }
+
+// registerServerTools 1サーバー分のツールを登録
+func registerServerTools(registry *tool.Registry, manager *Manager, serverName string, tools []MCPToolSchema) int {
+	for _, t := range tools {
+		registry.Register(NewMCPToolAdapter(serverName, t, manager))
+	}
+	return len(tools)
+}
+
+// unregisterServerTools 1サーバー分の登録済みツールを削除
+func unregisterServerTools(registry *tool.Registry, serverName string) {
+	for _, name := range registry.Names() {
+		t, ok := registry.GetTool(name)
+		if !ok {
+			continue
+		}
+		if adapter, ok := t.(*MCPToolAdapter); ok && adapter.serverName == serverName {
+			registry.Unregister(name)
+		}
+	}
+}
@@ -185,6 +185,31 @@ func (m *Manager) Stage(originalPath string, content []byte) error {
 	return nil
 }
 
+// ReadStaged はステージ済みの内容を返す（未ステージの場合は false）
+// 続けて編集する際にディスク上の古い内容ではなくステージ版を基にするために使う
+func (m *Manager) ReadStaged(originalPath string) ([]byte, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	absOriginal, err := filepath.Abs(originalPath)
+	if err != nil {
+		return nil, false
+	}
+	relPath, err := filepath.Rel(m.projectDir, absOriginal)
+	if err != nil {
+		return nil, false
+	}
+	staged, ok := m.staged[relPath]
+	if !ok {
+		return nil, false
+	}
+	content, err := os.ReadFile(staged.SandboxPath)
+	if err != nil {
+		return nil, false
+	}
+	return content, true
+}
+
 // Commit は全てのステージされたファイルをプロジェクトに反映する
 func (m *Manager) Commit() ([]string, error) {
 	m.mu.Lock()
@@ -5,7 +5,12 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
 	"sync"
+
+	"github.com/zephel01/vibe-local-go/internal/config"
 )
 
 // PermissionType represents a permission type
@@ -37,14 +42,17 @@ const (
 // PermissionRule represents a persistent permission rule
 type PermissionRule struct {
 	ToolName     string         `json:"tool_name"`
+	Command      string         `json:"command,omitempty"` // bash command pattern; empty for tool-wide rules
 	PermissionType PermissionType `json:"permission_type"`
 }
 
 // PermissionManager manages tool execution permissions
 type PermissionManager struct {
 	rules       map[string]PermissionType
+	commandRules map[string]PermissionType // bash command pattern -> permission
 	rulesFile   string
 	alwaysApprove bool // -y flag
+	networkTools map[string]bool // Tools that send data off the machine in this session (see MarkNetworkTool)
 	mu          sync.RWMutex
 }
 
@@ -52,6 +60,7 @@ type PermissionManager struct {
 func NewPermissionManager(alwaysApprove bool) (*PermissionManager, error) {
 	pm := &PermissionManager{
 		rules:         make(map[string]PermissionType),
+		commandRules:  make(map[string]PermissionType),
 		rulesFile:     getRulesFilePath(),
 		alwaysApprove: alwaysApprove,
 	}
@@ -74,6 +83,9 @@ func (pm *PermissionManager) CheckPermission(toolName string, params map[string]
 
 	// Get tool category
 	category := getToolCategory(toolName)
+	if pm.networkTools[toolName] {
+		category = ToolNetwork
+	}
 
 	// Always-approve mode (-y flag)
 	if pm.alwaysApprove {
@@ -82,6 +94,22 @@ func (pm *PermissionManager) CheckPermission(toolName string, params map[string]
 		return true, "always_approved", nil
 	}
 
+	// Command rules are consulted before the bash tool rule
+	if toolName == "bash" || toolName == "assert_command" {
+		if command, ok := params["command"].(string); ok {
+			if perm, matched := pm.matchCommandRules(command); matched {
+				switch perm {
+				case PermissionAlways:
+					return true, "command_allowed", nil
+				case PermissionDeny:
+					return false, "command_denied", fmt.Errorf("command denied by rule: %s", command)
+				case PermissionAsk:
+					return false, "command_ask", nil
+				}
+			}
+		}
+	}
+
 	// Check existing rule
 	if rule, exists := pm.rules[toolName]; exists {
 		switch rule {
@@ -107,6 +135,18 @@ func (pm *PermissionManager) CheckPermission(toolName string, params map[string]
 	}
 }
 
+// MarkNetworkTool makes a normally safe tool ask before running because it
+// sends data to a remote service in this session (e.g. semantic_search
+// embedding files with a cloud provider)
+func (pm *PermissionManager) MarkNetworkTool(toolName string) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	if pm.networkTools == nil {
+		pm.networkTools = make(map[string]bool)
+	}
+	pm.networkTools[toolName] = true
+}
+
 // SetPermission sets a permission rule
 func (pm *PermissionManager) SetPermission(toolName string, perm PermissionType) error {
 	pm.mu.Lock()
@@ -118,6 +158,172 @@ func (pm *PermissionManager) SetPermission(toolName string, perm PermissionType)
 	return pm.saveRules()
 }
 
+// SetCommandRule sets a permission rule for bash commands matching pattern.
+// Patterns containing *, ? or [ are globs matched against the whole command
+// ("git *"); other patterns match the command or a prefix of it followed by
+// arguments ("git status" matches "git status -s").
+func (pm *PermissionManager) SetCommandRule(pattern string, ptype PermissionType) error {
+	pattern = strings.TrimSpace(pattern)
+	if pattern == "" {
+		return fmt.Errorf("command pattern cannot be empty")
+	}
+	if _, err := compileCommandGlob(pattern); err != nil {
+		return fmt.Errorf("invalid command pattern %q: %w", pattern, err)
+	}
+
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	pm.commandRules[pattern] = ptype
+	return pm.saveRules()
+}
+
+// GetCommandRules returns all current bash command rules
+func (pm *PermissionManager) GetCommandRules() map[string]PermissionType {
+	pm.mu.RLock()
+	defer pm.mu.RUnlock()
+
+	rules := make(map[string]PermissionType, len(pm.commandRules))
+	for k, v := range pm.commandRules {
+		rules[k] = v
+	}
+	return rules
+}
+
+// matchCommandRules resolves the command rules for a bash command. Each part of
+// a compound command (split on ;, &&, ||, | and newlines) is checked on its own;
+// the most restrictive result wins. The command is allowed by rules only if every
+// part matches an allow rule, and commands with substitutions are never allowed
+// by rules. matched is false when the rules don't decide.
+func (pm *PermissionManager) matchCommandRules(command string) (perm PermissionType, matched bool) {
+	if len(pm.commandRules) == 0 {
+		return PermissionAsk, false
+	}
+
+	allAllowed := !strings.Contains(command, "$(") && !strings.Contains(command, "`")
+	anyAsk := false
+	for _, part := range SplitCommand(command) {
+		partPerm, ok := pm.matchCommandPart(part)
+		if !ok {
+			allAllowed = false
+			continue
+		}
+		switch partPerm {
+		case PermissionDeny:
+			return PermissionDeny, true
+		case PermissionAsk:
+			anyAsk = true
+			allAllowed = false
+		}
+	}
+
+	if anyAsk {
+		return PermissionAsk, true
+	}
+	if allAllowed {
+		return PermissionAlways, true
+	}
+	return PermissionAsk, false
+}
+
+// matchCommandPart returns the most restrictive rule matching a single command
+func (pm *PermissionManager) matchCommandPart(part string) (PermissionType, bool) {
+	best, matched := PermissionAlways, false
+	for pattern, perm := range pm.commandRules {
+		if !commandMatches(pattern, part) {
+			continue
+		}
+		if !matched || restrictiveness(perm) > restrictiveness(best) {
+			best = perm
+		}
+		matched = true
+	}
+	return best, matched
+}
+
+// restrictiveness orders permissions: deny > ask > always
+func restrictiveness(perm PermissionType) int {
+	switch perm {
+	case PermissionDeny:
+		return 2
+	case PermissionAsk:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// SplitCommand splits a shell command into its simple commands on ;, &&, ||,
+// |, & and newlines (redirections like 2>&1 are kept). Subshells and command
+// substitutions are split out as well, so "$(rm x)" yields "rm x". Quoting is
+// ignored, which can only split more than the shell would.
+func SplitCommand(command string) []string {
+	var parts []string
+	start := 0
+	for i := 0; i < len(command); i++ {
+		switch command[i] {
+		case ';', '|', '\n', '(', ')', '`':
+		case '&':
+			// 2>&1, &>file, >&2 are redirections, not separators
+			if (i > 0 && (command[i-1] == '>' || command[i-1] == '<')) || (i+1 < len(command) && command[i+1] == '>') {
+				continue
+			}
+		default:
+			continue
+		}
+		if part := strings.TrimSpace(command[start:i]); part != "" {
+			parts = append(parts, part)
+		}
+		start = i + 1
+	}
+	if part := strings.TrimSpace(command[start:]); part != "" {
+		parts = append(parts, part)
+	}
+	return parts
+}
+
+// commandMatches reports whether a single command matches pattern (glob or prefix)
+func commandMatches(pattern, command string) bool {
+	command = strings.Join(strings.Fields(command), " ")
+	if strings.ContainsAny(pattern, "*?[") {
+		re, err := compileCommandGlob(pattern)
+		return err == nil && re.MatchString(command)
+	}
+	pattern = strings.Join(strings.Fields(pattern), " ")
+	return command == pattern || strings.HasPrefix(command, pattern+" ")
+}
+
+// compileCommandGlob converts a command glob to a regexp; unlike path globs,
+// * also matches "/" so "git *" covers "git diff src/main.go"
+func compileCommandGlob(pattern string) (*regexp.Regexp, error) {
+	pattern = strings.Join(strings.Fields(pattern), " ")
+	var sb strings.Builder
+	sb.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*':
+			sb.WriteString(".*")
+		case '?':
+			sb.WriteString(".")
+		case '[':
+			end := strings.IndexByte(pattern[i+1:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated [")
+			}
+			class := pattern[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			sb.WriteString("[" + strings.ReplaceAll(class, "\\", "\\\\") + "]")
+			i += end + 1
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	sb.WriteString("$")
+	return regexp.Compile(sb.String())
+}
+
 // loadRules loads rules from file
 func (pm *PermissionManager) loadRules() error {
 	data, err := os.ReadFile(pm.rulesFile)
@@ -131,7 +337,12 @@ func (pm *PermissionManager) loadRules() error {
 	}
 
 	pm.rules = make(map[string]PermissionType)
+	pm.commandRules = make(map[string]PermissionType)
 	for _, rule := range rules {
+		if rule.Command != "" {
+			pm.commandRules[rule.Command] = rule.PermissionType
+			continue
+		}
 		pm.rules[rule.ToolName] = rule.PermissionType
 	}
 
@@ -140,7 +351,7 @@ func (pm *PermissionManager) loadRules() error {
 
 // saveRules saves rules to file
 func (pm *PermissionManager) saveRules() error {
-	rules := make([]PermissionRule, 0, len(pm.rules))
+	rules := make([]PermissionRule, 0, len(pm.rules)+len(pm.commandRules))
 	for toolName, permType := range pm.rules {
 		rules = append(rules, PermissionRule{
 			ToolName:     toolName,
@@ -148,6 +359,20 @@ func (pm *PermissionManager) saveRules() error {
 		})
 	}
 
+	// Command rules are stored after the tool rules, sorted for a stable file
+	patterns := make([]string, 0, len(pm.commandRules))
+	for pattern := range pm.commandRules {
+		patterns = append(patterns, pattern)
+	}
+	sort.Strings(patterns)
+	for _, pattern := range patterns {
+		rules = append(rules, PermissionRule{
+			ToolName:       "bash",
+			Command:        pattern,
+			PermissionType: pm.commandRules[pattern],
+		})
+	}
+
 	data, err := json.MarshalIndent(rules, "", "  ")
 	if err != nil {
 		return err
@@ -177,6 +402,11 @@ func getRulesFilePath() string {
 
 // getConfigDir returns the vibe-local config directory
 func getConfigDir() string {
+	// VIBE_CONFIG_DIR relocates the whole config root (with ~ expanded like the other config files)
+	if os.Getenv(config.EnvConfigDir) != "" {
+		return config.GlobalDir()
+	}
+
 	// Check XDG_CONFIG_HOME first
 	if configHome := os.Getenv("XDG_CONFIG_HOME"); configHome != "" {
 		return filepath.Join(configHome, "vibe-local")
@@ -198,6 +428,13 @@ func getToolCategory(toolName string) ToolCategory {
 		"read_file",
 		"glob",
 		"grep",
+		"semantic_search", // Local embeddings; MarkNetworkTool when the provider is remote
+		"list_directory",
+		"symbols",
+		"bash_output",
+		"tail",
+		"environment",
+		"plan",
 	}
 	for _, t := range safeTools {
 		if t == toolName {
@@ -209,6 +446,10 @@ func getToolCategory(toolName string) ToolCategory {
 	askTools := []string{
 		"write_file",
 		"edit_file",
+		"multi_edit",
+		"apply_patch",
+		"move_file",
+		"make_directory",
 	}
 	for _, t := range askTools {
 		if t == toolName {
@@ -227,8 +468,8 @@ func getToolCategory(toolName string) ToolCategory {
 		}
 	}
 
-	// Bash is dangerous
-	if toolName == "bash" {
+	// Bash (and assert_command, which runs commands through it) is dangerous
+	if toolName == "bash" || toolName == "assert_command" {
 		return ToolDangerous
 	}
 
@@ -272,6 +513,7 @@ func (pm *PermissionManager) ClearRules() error {
 	defer pm.mu.Unlock()
 
 	pm.rules = make(map[string]PermissionType)
+	pm.commandRules = make(map[string]PermissionType)
 	return pm.saveRules()
 }
 
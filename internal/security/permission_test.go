@@ -1,6 +1,7 @@
 package security
 
 import (
+	"path/filepath"
 	"testing"
 )
 
@@ -237,3 +238,145 @@ func TestToolCategory_String(t *testing.T) {
 		})
 	}
 }
+
+func TestPermissionManager_CommandRules(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	pm, err := NewPermissionManager(false)
+	if err != nil {
+		t.Fatalf("Failed to create permission manager: %v", err)
+	}
+
+	// Even with bash always allowed, rm must still prompt
+	if err := pm.SetPermission("bash", PermissionAlways); err != nil {
+		t.Fatalf("Failed to set permission: %v", err)
+	}
+	if err := pm.SetCommandRule("git *", PermissionAlways); err != nil {
+		t.Fatalf("Failed to set command rule: %v", err)
+	}
+	if err := pm.SetCommandRule("ls", PermissionAlways); err != nil {
+		t.Fatalf("Failed to set command rule: %v", err)
+	}
+	if err := pm.SetCommandRule("rm *", PermissionAsk); err != nil {
+		t.Fatalf("Failed to set command rule: %v", err)
+	}
+	if err := pm.SetCommandRule("curl", PermissionDeny); err != nil {
+		t.Fatalf("Failed to set command rule: %v", err)
+	}
+
+	tests := []struct {
+		command     string
+		wantAllowed bool
+		wantReason  string
+		wantErr     bool
+	}{
+		{"git status", true, "command_allowed", false},
+		{"git diff src/main.go", true, "command_allowed", false},
+		{"ls -la", true, "command_allowed", false},
+		{"git status && ls", true, "command_allowed", false},
+		{"git log 2>&1 | ls", true, "command_allowed", false},
+		{"rm -rf build", false, "command_ask", false},
+		{"git status && rm -rf build", false, "command_ask", false},
+		{"git status; curl http://example.com", false, "command_denied", true},
+		{"git log $(rm -rf build)", false, "command_ask", false},
+		{"lsof -i", true, "always_allowed", false}, // "ls" doesn't match "lsof"; falls back to the bash rule
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			allowed, reason, err := pm.CheckPermission("bash", map[string]interface{}{"command": tt.command})
+			if allowed != tt.wantAllowed {
+				t.Errorf("allowed = %v, want %v", allowed, tt.wantAllowed)
+			}
+			if reason != tt.wantReason {
+				t.Errorf("reason = %q, want %q", reason, tt.wantReason)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Errorf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	// Rules are persisted alongside the tool rules
+	reloaded, err := NewPermissionManager(false)
+	if err != nil {
+		t.Fatalf("Failed to reload permission manager: %v", err)
+	}
+	if got := reloaded.GetCommandRules(); len(got) != 4 || got["git *"] != PermissionAlways || got["rm *"] != PermissionAsk {
+		t.Errorf("reloaded command rules = %v", got)
+	}
+	if got := reloaded.GetRules(); got["bash"] != PermissionAlways {
+		t.Errorf("reloaded tool rules = %v", got)
+	}
+	if allowed, reason, _ := reloaded.CheckPermission("bash", map[string]interface{}{"command": "rm x"}); allowed || reason != "command_ask" {
+		t.Errorf("after reload rm: allowed = %v, reason = %q", allowed, reason)
+	}
+}
+
+func TestPermissionManager_CommandRulesWithoutBashRule(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	pm, err := NewPermissionManager(false)
+	if err != nil {
+		t.Fatalf("Failed to create permission manager: %v", err)
+	}
+	if err := pm.SetCommandRule("git *", PermissionAlways); err != nil {
+		t.Fatalf("Failed to set command rule: %v", err)
+	}
+
+	if allowed, _, _ := pm.CheckPermission("bash", map[string]interface{}{"command": "git status"}); !allowed {
+		t.Error("git status should be allowed by the command rule")
+	}
+	if allowed, reason, _ := pm.CheckPermission("bash", map[string]interface{}{"command": "rm -rf build"}); allowed || reason != "dangerous" {
+		t.Errorf("rm should still prompt: allowed = %v, reason = %q", allowed, reason)
+	}
+	if err := pm.SetCommandRule("  ", PermissionAlways); err == nil {
+		t.Error("expected error for empty pattern")
+	}
+}
+
+func TestGetConfigDir_VibeConfigDirOverride(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	root := t.TempDir()
+	t.Setenv("VIBE_CONFIG_DIR", root)
+
+	if got := getRulesFilePath(); got != filepath.Join(root, "permissions.json") {
+		t.Errorf("getRulesFilePath() = %q, want it under %s", got, root)
+	}
+}
+
+func TestPermissionManager_MarkNetworkTool(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	pm, err := NewPermissionManager(false)
+	if err != nil {
+		t.Fatalf("Failed to create permission manager: %v", err)
+	}
+
+	if allowed, reason, _ := pm.CheckPermission("semantic_search", nil); !allowed || reason != "safe" {
+		t.Errorf("before marking: allowed = %v, reason = %q", allowed, reason)
+	}
+
+	pm.MarkNetworkTool("semantic_search")
+	if allowed, reason, _ := pm.CheckPermission("semantic_search", nil); allowed || reason != ToolNetwork.String() {
+		t.Errorf("after marking: allowed = %v, reason = %q", allowed, reason)
+	}
+
+	// An explicit always rule still applies
+	if err := pm.SetPermission("semantic_search", PermissionAlways); err != nil {
+		t.Fatalf("Failed to set permission: %v", err)
+	}
+	if allowed, _, _ := pm.CheckPermission("semantic_search", nil); !allowed {
+		t.Error("always rule should allow a marked tool")
+	}
+}
+
+func TestGetConfigDir_VibeConfigDirExpandsHome(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("VIBE_CONFIG_DIR", "~/vibe")
+
+	if got := getRulesFilePath(); got != filepath.Join(home, "vibe", "permissions.json") {
+		t.Errorf("getRulesFilePath() = %q, want ~ expanded to %s", got, home)
+	}
+}
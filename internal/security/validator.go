@@ -2,7 +2,9 @@ package security
 
 import (
 	"os"
+	"path"
 	"path/filepath"
+	"runtime"
 	"strings"
 )
 
@@ -37,8 +39,8 @@ func (pv *PathValidator) AddAllowedPath(path string) {
 
 // Validate validates a file path
 func (pv *PathValidator) Validate(path string) error {
-	// Clean the path
-	path = filepath.Clean(path)
+	// Normalize Windows-style separators / drive letters, then clean
+	path = filepath.Clean(NormalizePath(path))
 
 	// Make absolute if relative
 	if !filepath.IsAbs(path) {
@@ -136,6 +138,61 @@ func (pv *PathValidator) isWithinAllowedPaths(path string) bool {
 	return false
 }
 
+// NormalizePath converts a path argument into the host OS form.
+// LLMs often emit Windows-style paths (src\main.go, C:\proj\main.go) or
+// mixed separators regardless of the platform; tools and PathValidator call
+// this before resolving so the same input behaves consistently everywhere.
+func NormalizePath(p string) string {
+	return normalizePathFor(runtime.GOOS, p)
+}
+
+// normalizePathFor implements NormalizePath for the given GOOS
+func normalizePathFor(goos, p string) string {
+	p = strings.TrimSpace(p)
+	if p == "" {
+		return p
+	}
+
+	if goos == "windows" {
+		p = strings.ReplaceAll(p, "\\", "/")
+		// MSYS / Git Bash style: /c/Users/... → C:/Users/...
+		if len(p) >= 3 && p[0] == '/' && isDriveLetter(p[1]) && p[2] == '/' {
+			p = p[1:2] + ":" + p[2:]
+		}
+		if hasDriveLetter(p) {
+			p = strings.ToUpper(p[:1]) + p[1:]
+		}
+		return strings.ReplaceAll(path.Clean(p), "/", "\\")
+	}
+
+	// Unix: バックスラッシュは区切り文字として扱う（同名のファイルが実在する場合を除く）
+	if strings.Contains(p, "\\") {
+		if _, err := os.Lstat(p); err != nil {
+			p = strings.ReplaceAll(p, "\\", "/")
+		}
+	}
+
+	// ドライブレターは意味を持たないため除去: C:/proj/main.go → /proj/main.go
+	if hasDriveLetter(p) {
+		p = p[2:]
+		if p == "" {
+			p = "."
+		}
+	}
+
+	return path.Clean(p)
+}
+
+// hasDriveLetter reports whether p starts with a Windows drive letter (e.g. "C:")
+func hasDriveLetter(p string) bool {
+	return len(p) >= 2 && isDriveLetter(p[0]) && p[1] == ':'
+}
+
+// isDriveLetter reports whether c is an ASCII letter
+func isDriveLetter(c byte) bool {
+	return ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
+
 // getUnsafePaths returns a list of unsafe system paths
 func getUnsafePaths() []string {
 	paths := []string{
@@ -203,7 +260,7 @@ func (pv *PathValidator) isProtectedFile(path string) bool {
 
 // ResolveAndValidate resolves path and validates it
 func (pv *PathValidator) ResolveAndValidate(path string) (string, error) {
-	path = filepath.Clean(path)
+	path = filepath.Clean(NormalizePath(path))
 
 	// Make absolute
 	if !filepath.IsAbs(path) {
@@ -3,6 +3,7 @@ package security
 import (
 	"os"
 	"path/filepath"
+	"runtime"
 	"testing"
 )
 
@@ -433,3 +434,92 @@ func TestPathValidator_SymlinkHandling(t *testing.T) {
 		t.Error("expected symlink to unsafe file to be rejected")
 	}
 }
+
+func TestNormalizePathFor(t *testing.T) {
+	tests := []struct {
+		name     string
+		goos     string
+		path     string
+		expected string
+	}{
+		{"unix plain path", "linux", "src/main.go", "src/main.go"},
+		{"unix backslash path", "linux", `src\main.go`, "src/main.go"},
+		{"unix mixed separators", "linux", `src\pkg/sub\main.go`, "src/pkg/sub/main.go"},
+		{"unix dot segments", "linux", `.\src\..\main.go`, "main.go"},
+		{"unix drive letter", "darwin", `C:\proj\main.go`, "/proj/main.go"},
+		{"unix lowercase drive letter", "linux", "d:/proj/main.go", "/proj/main.go"},
+		{"unix bare drive", "linux", "C:", "."},
+		{"unix surrounding whitespace", "linux", "  src/main.go ", "src/main.go"},
+		{"unix empty", "linux", "", ""},
+		{"windows forward slashes", "windows", "src/main.go", `src\main.go`},
+		{"windows mixed separators", "windows", `C:/proj\src/main.go`, `C:\proj\src\main.go`},
+		{"windows lowercase drive", "windows", `c:\proj\main.go`, `C:\proj\main.go`},
+		{"windows msys style", "windows", "/c/Users/dev/main.go", `C:\Users\dev\main.go`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := normalizePathFor(tt.goos, tt.path)
+			if got != tt.expected {
+				t.Errorf("normalizePathFor(%q, %q) = %q, want %q", tt.goos, tt.path, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestNormalizePath_KeepsExistingBackslashName(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("backslash is always a separator on Windows")
+	}
+
+	tmpDir := t.TempDir()
+	literal := filepath.Join(tmpDir, `odd\name.txt`)
+	if err := os.WriteFile(literal, []byte("x"), 0644); err != nil {
+		t.Fatalf("failed to create test file: %v", err)
+	}
+
+	if got := NormalizePath(literal); got != literal {
+		t.Errorf("NormalizePath(%q) = %q, want unchanged", literal, got)
+	}
+}
+
+func TestPathValidator_Validate_WindowsStylePaths(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("unix-specific confinement checks")
+	}
+
+	tmpDir := t.TempDir()
+	actualTmpDir, err := filepath.EvalSymlinks(tmpDir)
+	if err != nil {
+		t.Fatalf("failed to eval symlinks: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Join(actualTmpDir, "src"), 0755); err != nil {
+		t.Fatalf("failed to create subdirectory: %v", err)
+	}
+
+	pv := NewPathValidator(actualTmpDir)
+
+	tests := []struct {
+		name        string
+		path        string
+		expectError bool
+	}{
+		{"backslash path within base", actualTmpDir + `\src\main.go`, false},
+		{"mixed separators within base", actualTmpDir + `/src\main.go`, false},
+		{"backslash traversal out of base", actualTmpDir + `\..\..\etc\hosts`, true},
+		{"drive letter outside base", `C:\etc\passwd`, true},
+		{"drive letter root", `C:\`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := pv.Validate(tt.path)
+			if tt.expectError && err == nil {
+				t.Errorf("expected error for %q, got nil", tt.path)
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("expected no error for %q, got %v", tt.path, err)
+			}
+		})
+	}
+}
@@ -6,10 +6,14 @@ import (
 )
 
 const (
-	// CompactThreshold is the percentage at which to compact
+	// CompactThreshold is the fraction of the context window at which to compact
+	// when none is set. The agent always sets one (its ContextTrimThreshold, 0.9,
+	// unless COMPACT_THRESHOLD / --compact-at is given).
 	CompactThreshold = 0.5
 	// CompactMessageThreshold is the minimum messages to trigger compaction
 	CompactMessageThreshold = 100
+	// DefaultContextWindow is the context window used when none is set
+	DefaultContextWindow = 32768
 )
 
 // CompactionResult represents the result of a compaction operation
@@ -21,14 +25,19 @@ type CompactionResult struct {
 	Summary            string
 }
 
-// CompactIfNeeded compacts the session if needed
+// CompactIfNeeded compacts the session if needed. It does nothing when
+// automatic compaction is disabled.
 func (s *Session) CompactIfNeeded() *CompactionResult {
+	if !s.AutoCompactEnabled() {
+		return nil
+	}
+
 	// Check if compaction is needed
 	tokenCount := s.GetTokenCount()
 	messageCount := s.GetMessageCount()
 
 	// Compact if we exceed 70% of context or have 300+ messages
-	if float64(tokenCount) > float64(s.GetContextWindow())*CompactThreshold ||
+	if float64(tokenCount) > float64(s.GetContextWindow())*s.CompactionThreshold() ||
 		messageCount >= CompactMessageThreshold {
 		return s.Compact()
 	}
@@ -185,17 +194,88 @@ func (s *Session) CompactWithLLM(llmClient interface{}) *CompactionResult {
 	return s.Compact()
 }
 
-// GetContextWindow returns the context window size
+// GetContextWindow returns the context window size (DefaultContextWindow when unset)
 func (s *Session) GetContextWindow() int {
-	// This should be set from config
-	// Default to 32768
-	return 32768
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.contextWindowLocked()
 }
 
-// SetContextWindow sets the context window size
+// SetContextWindow sets the context window size; size <= 0 restores the default
 func (s *Session) SetContextWindow(size int) {
-	// Store context window size
-	// This could be a field in the Session struct
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.contextWindow = size
+}
+
+// contextWindowLocked returns the context window size (caller must hold the lock)
+func (s *Session) contextWindowLocked() int {
+	if s.contextWindow > 0 {
+		return s.contextWindow
+	}
+	return DefaultContextWindow
+}
+
+// SetCompactThreshold sets the fraction of the context window (0 < fraction <= 1)
+// at which automatic compaction triggers. Other values restore CompactThreshold.
+func (s *Session) SetCompactThreshold(fraction float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if fraction <= 0 || fraction > 1 {
+		fraction = 0
+	}
+	s.compactThreshold = fraction
+}
+
+// CompactionThreshold returns the fraction of the context window that triggers compaction
+func (s *Session) CompactionThreshold() float64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.compactionThresholdLocked()
+}
+
+// compactionThresholdLocked returns the compaction trigger (caller must hold the lock)
+func (s *Session) compactionThresholdLocked() float64 {
+	if s.compactThreshold > 0 {
+		return s.compactThreshold
+	}
+	return CompactThreshold
+}
+
+// SetAutoCompact enables or disables automatic compaction
+func (s *Session) SetAutoCompact(enabled bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.noAutoCompact = !enabled
+}
+
+// AutoCompactEnabled reports whether automatic compaction is enabled
+func (s *Session) AutoCompactEnabled() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return !s.noAutoCompact
+}
+
+// ShouldAutoCompact reports whether automatic compaction is enabled and the
+// estimated history exceeds the compaction threshold of contextWindow
+// (contextWindow <= 0 uses the session's context window).
+func (s *Session) ShouldAutoCompact(contextWindow int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.noAutoCompact {
+		return false
+	}
+	if contextWindow <= 0 {
+		contextWindow = s.contextWindowLocked()
+	}
+	return float64(s.recountTokens()) > float64(contextWindow)*s.compactionThresholdLocked()
 }
 
 // NeedsCompaction checks if session needs compaction
@@ -203,8 +283,8 @@ func (s *Session) NeedsCompaction() bool {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	contextWindow := s.GetContextWindow()
-	return float64(s.TokenEstimate) > float64(contextWindow)*CompactThreshold ||
+	contextWindow := s.contextWindowLocked()
+	return float64(s.TokenEstimate) > float64(contextWindow)*s.compactionThresholdLocked() ||
 		len(s.Messages) >= CompactMessageThreshold
 }
 
@@ -223,7 +303,7 @@ func (s *Session) GetCompactionStats() CompactionStats {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	contextWindow := s.GetContextWindow()
+	contextWindow := s.contextWindowLocked()
 	usage := EstimateContextUsage(s.TokenEstimate, contextWindow)
 
 	return CompactionStats{
@@ -286,3 +366,198 @@ func (s *Session) CompactToTarget(targetTokens int) *CompactionResult {
 
 	return result
 }
+
+// SummaryPrefix marks the message that replaces history after SummarizeWith
+const SummaryPrefix = "Summary of the conversation so far:\n"
+
+// Summarizer produces a concise summary from a plain-text transcript
+type Summarizer func(transcript string) (string, error)
+
+// BuildTranscript renders messages as plain text suitable for summarization
+func BuildTranscript(messages []Message) string {
+	var sb strings.Builder
+
+	for _, msg := range messages {
+		switch msg.Role {
+		case RoleUser:
+			sb.WriteString(fmt.Sprintf("User: %s\n", msg.Content))
+		case RoleAssistant:
+			if msg.Content != "" {
+				sb.WriteString(fmt.Sprintf("Assistant: %s\n", msg.Content))
+			}
+			for _, tc := range msg.ToolCalls {
+				sb.WriteString(fmt.Sprintf("Assistant called %s(%s)\n", tc.Function.Name, truncateForSummary(tc.Function.Arguments, 200)))
+			}
+		case RoleTool:
+			sb.WriteString(fmt.Sprintf("Tool result: %s\n", truncateForSummary(msg.Content, 500)))
+		case RoleSystem:
+			sb.WriteString(fmt.Sprintf("Note: %s\n", msg.Content))
+		}
+	}
+
+	return sb.String()
+}
+
+// SummarizeWith replaces the whole message history with a single summary message.
+// The system prompt is kept. If summarize fails, the session is left unchanged.
+func (s *Session) SummarizeWith(summarize Summarizer) (*CompactionResult, error) {
+	messages := s.GetMessages()
+	if len(messages) == 0 {
+		return nil, fmt.Errorf("no messages to summarize")
+	}
+
+	// LLM 呼び出し中はロックを保持しない
+	summary, err := summarize(BuildTranscript(messages))
+	if err != nil {
+		return nil, err
+	}
+
+	summary = strings.TrimSpace(summary)
+	if summary == "" {
+		return nil, fmt.Errorf("summarizer returned an empty summary")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	originalCount := s.TokenEstimate
+	originalMessages := len(s.Messages)
+
+	summaryMsg := Message{
+		Role:    RoleSystem,
+		Content: SummaryPrefix + summary,
+	}
+	summaryMsg.TokenCount = EstimateTokens(summaryMsg.Content)
+
+	s.Messages = []Message{summaryMsg}
+	s.TokenEstimate = len(s.SystemPrompt) + summaryMsg.TokenCount
+	s.llmCacheDirty = true
+	s.cachedLLMMessages = nil
+
+	return &CompactionResult{
+		OriginalTokenCount: originalCount,
+		NewTokenCount:      s.TokenEstimate,
+		CompactedMessages:  originalMessages - len(s.Messages),
+		RemainingMessages:  len(s.Messages),
+		Summary:            summary,
+	}, nil
+}
+
+// TrimNotePrefix marks the note that replaces exchanges dropped by TrimToFit
+const TrimNotePrefix = "[Earlier messages were trimmed to fit the context window]\n"
+
+// EstimateTotalTokens recounts the token estimate for the system prompt and all messages
+func (s *Session) EstimateTotalTokens() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.recountTokens()
+}
+
+// recountTokens refreshes per-message token counts and TokenEstimate (caller must hold the lock)
+func (s *Session) recountTokens() int {
+	total := EstimateTokens(s.SystemPrompt)
+	for i := range s.Messages {
+		s.Messages[i].TokenCount = EstimateTokens(s.Messages[i].Content)
+		for _, tc := range s.Messages[i].ToolCalls {
+			s.Messages[i].TokenCount += EstimateTokens(tc.Function.Arguments)
+		}
+		total += s.Messages[i].TokenCount
+	}
+	s.TokenEstimate = total
+	return total
+}
+
+// exchangeStarts returns the indexes where each user-initiated exchange begins.
+// Assistant tool calls and their tool results always fall inside one exchange,
+// so dropping whole exchanges never leaves a dangling tool call.
+func exchangeStarts(messages []Message) []int {
+	starts := make([]int, 0)
+	for i, msg := range messages {
+		if msg.Role == RoleUser {
+			starts = append(starts, i)
+		}
+	}
+	return starts
+}
+
+// TrimToFit drops the oldest exchanges until the estimated token count fits maxTokens.
+// Dropped messages are replaced by a short summary note. The system prompt and the
+// most recent exchange are always kept. Returns nil if no trimming was needed.
+func (s *Session) TrimToFit(maxTokens int) *CompactionResult {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	originalCount := s.recountTokens()
+	if originalCount <= maxTokens {
+		return nil
+	}
+
+	starts := exchangeStarts(s.Messages)
+	if len(starts) == 0 {
+		return nil
+	}
+	// 最新のやり取りは必ず残す
+	lastStart := starts[len(starts)-1]
+
+	// 先頭の非ユーザーメッセージ（過去の要約など）も削除対象に含める
+	cuts := make([]int, 0, len(starts))
+	for _, start := range starts {
+		if start > 0 {
+			cuts = append(cuts, start)
+		}
+	}
+
+	var (
+		cut     int
+		summary string
+		total   = originalCount
+	)
+
+	for _, candidate := range cuts {
+		if candidate > lastStart {
+			break
+		}
+
+		dropped := s.Messages[:candidate]
+		summary, _ = summarizeMessages(dropped)
+		summary = TrimNotePrefix + summary
+
+		total = originalCount + EstimateTokens(summary)
+		for _, msg := range dropped {
+			total -= msg.TokenCount
+		}
+
+		cut = candidate
+		if total <= maxTokens {
+			break
+		}
+	}
+
+	if cut == 0 {
+		return nil
+	}
+
+	originalMessages := len(s.Messages)
+	note := Message{
+		Role:       RoleSystem,
+		Content:    summary,
+		TokenCount: EstimateTokens(summary),
+	}
+
+	remaining := make([]Message, 0, len(s.Messages)-cut+1)
+	remaining = append(remaining, note)
+	remaining = append(remaining, s.Messages[cut:]...)
+	s.Messages = remaining
+	s.TokenEstimate = total
+	s.llmCacheDirty = true
+	s.cachedLLMMessages = nil
+
+	return &CompactionResult{
+		OriginalTokenCount: originalCount,
+		NewTokenCount:      s.TokenEstimate,
+		CompactedMessages:  originalMessages - len(s.Messages),
+		RemainingMessages:  len(s.Messages),
+		Summary:            summary,
+	}
+}
@@ -1,6 +1,7 @@
 package session
 
 import (
+	"fmt"
 	"strings"
 	"testing"
 )
@@ -286,6 +287,71 @@ func TestNeedsCompaction_AboveTokenThreshold(t *testing.T) {
 	}
 }
 
+func TestShouldAutoCompact_ConfiguredThreshold(t *testing.T) {
+	session := NewSession("test", "")
+	session.AddUserMessage(strings.Repeat("word ", 200))
+	tokens := session.EstimateTotalTokens()
+
+	// Usage is tokens/window; place it between 0.5 and 0.8 of a window
+	window := tokens * 100 / 65
+
+	session.SetCompactThreshold(0.8)
+	if session.ShouldAutoCompact(window) {
+		t.Errorf("65%% usage should not trigger compaction at 0.8 (tokens=%d, window=%d)", tokens, window)
+	}
+
+	session.SetCompactThreshold(0.5)
+	if !session.ShouldAutoCompact(window) {
+		t.Errorf("65%% usage should trigger compaction at 0.5 (tokens=%d, window=%d)", tokens, window)
+	}
+	if session.CompactionThreshold() != 0.5 {
+		t.Errorf("CompactionThreshold() = %v, want 0.5", session.CompactionThreshold())
+	}
+
+	session.SetAutoCompact(false)
+	if session.ShouldAutoCompact(window) {
+		t.Error("disabled auto compaction should never trigger")
+	}
+	if session.AutoCompactEnabled() {
+		t.Error("AutoCompactEnabled() = true after SetAutoCompact(false)")
+	}
+}
+
+func TestSetCompactThreshold_InvalidRestoresDefault(t *testing.T) {
+	session := NewSession("test", "")
+	for _, v := range []float64{0, -0.5, 1.5} {
+		session.SetCompactThreshold(0.3)
+		session.SetCompactThreshold(v)
+		if got := session.CompactionThreshold(); got != CompactThreshold {
+			t.Errorf("SetCompactThreshold(%v): threshold = %v, want default %v", v, got, CompactThreshold)
+		}
+	}
+}
+
+func TestSetContextWindow(t *testing.T) {
+	session := NewSession("test", "")
+	session.SetContextWindow(4096)
+	if got := session.GetContextWindow(); got != 4096 {
+		t.Errorf("GetContextWindow() = %d, want 4096", got)
+	}
+	session.SetContextWindow(0)
+	if got := session.GetContextWindow(); got != DefaultContextWindow {
+		t.Errorf("GetContextWindow() = %d, want default %d", got, DefaultContextWindow)
+	}
+}
+
+func TestCompactIfNeeded_AutoCompactDisabled(t *testing.T) {
+	session := NewSession("test", "")
+	for i := 0; i < 150; i++ {
+		session.AddUserMessage("Message")
+	}
+	session.SetAutoCompact(false)
+
+	if result := session.CompactIfNeeded(); result != nil {
+		t.Error("CompactIfNeeded should do nothing when auto compaction is disabled")
+	}
+}
+
 func TestGetCompactionThreshold(t *testing.T) {
 	threshold := GetCompactionThreshold()
 
@@ -449,3 +515,168 @@ func TestSummary_Format(t *testing.T) {
 		t.Error("Summary should contain percentage")
 	}
 }
+
+func TestSummarizeWith(t *testing.T) {
+	session := NewSession("test", "You are a helpful assistant")
+
+	for i := 0; i < 10; i++ {
+		session.AddUserMessage("Please refactor the parser")
+		session.AddAssistantMessage("Done refactoring step")
+	}
+
+	var gotTranscript string
+	result, err := session.SummarizeWith(func(transcript string) (string, error) {
+		gotTranscript = transcript
+		return "  The user is refactoring the parser.  ", nil
+	})
+	if err != nil {
+		t.Fatalf("SummarizeWith() error = %v", err)
+	}
+
+	if !strings.Contains(gotTranscript, "User: Please refactor the parser") {
+		t.Errorf("Transcript should include user messages, got %q", gotTranscript)
+	}
+
+	messages := session.GetMessages()
+	if len(messages) != 1 {
+		t.Fatalf("Messages = %d, want 1 after summarize", len(messages))
+	}
+
+	if !strings.HasPrefix(messages[0].Content, SummaryPrefix) ||
+		!strings.Contains(messages[0].Content, "The user is refactoring the parser.") {
+		t.Errorf("Summary message mismatch: %q", messages[0].Content)
+	}
+
+	if result.CompactedMessages != 19 || result.RemainingMessages != 1 {
+		t.Errorf("Result = %+v, want 19 compacted and 1 remaining", result)
+	}
+
+	if session.SystemPrompt != "You are a helpful assistant" {
+		t.Errorf("System prompt should be kept, got %q", session.SystemPrompt)
+	}
+
+	llmMessages := session.GetMessagesForLLM()
+	if len(llmMessages) != 2 || llmMessages[0]["content"] != "You are a helpful assistant" {
+		t.Errorf("LLM messages should be system prompt + summary, got %v", llmMessages)
+	}
+}
+
+func TestSummarizeWith_ErrorKeepsHistory(t *testing.T) {
+	session := NewSession("test", "")
+	session.AddUserMessage("Hello")
+	session.AddAssistantMessage("Hi")
+
+	_, err := session.SummarizeWith(func(string) (string, error) {
+		return "", fmt.Errorf("sidecar unavailable")
+	})
+	if err == nil {
+		t.Fatal("SummarizeWith() should return summarizer error")
+	}
+
+	if session.GetMessageCount() != 2 {
+		t.Errorf("Messages = %d, want 2 (unchanged)", session.GetMessageCount())
+	}
+
+	if _, err := NewSession("empty", "").SummarizeWith(func(string) (string, error) { return "x", nil }); err == nil {
+		t.Error("SummarizeWith() on empty session should return error")
+	}
+}
+
+func buildOverBudgetSession() *Session {
+	session := NewSession("test", "You are a helpful assistant")
+	long := strings.Repeat("lorem ipsum dolor sit amet ", 40)
+
+	for i := 0; i < 10; i++ {
+		session.AddUserMessage(fmt.Sprintf("request %d: %s", i, long))
+		session.AddToolCall([]ToolCall{
+			{ID: fmt.Sprintf("call_%d", i), Type: "function", Function: FunctionCall{Name: "read_file", Arguments: `{"path":"main.go"}`}},
+		})
+		session.AddToolResults([]ToolResult{{ToolCallID: fmt.Sprintf("call_%d", i), Content: long}})
+		session.AddAssistantMessage(fmt.Sprintf("answer %d", i))
+	}
+	return session
+}
+
+func TestTrimToFit_UnderBudget(t *testing.T) {
+	session := NewSession("test", "system")
+	session.AddUserMessage("Hello")
+
+	if result := session.TrimToFit(10000); result != nil {
+		t.Errorf("TrimToFit() should return nil under budget, got %+v", result)
+	}
+	if session.GetMessageCount() != 1 {
+		t.Errorf("Messages = %d, want 1", session.GetMessageCount())
+	}
+}
+
+func TestTrimToFit_OverBudget(t *testing.T) {
+	session := buildOverBudgetSession()
+	before := session.EstimateTotalTokens()
+	budget := before / 3
+
+	result := session.TrimToFit(budget)
+	if result == nil {
+		t.Fatal("TrimToFit() should trim an over-budget session")
+	}
+
+	after := session.EstimateTotalTokens()
+	if after >= before {
+		t.Errorf("tokens = %d, want fewer than %d", after, before)
+	}
+	if after > budget {
+		t.Errorf("tokens = %d, want <= budget %d", after, budget)
+	}
+
+	if session.SystemPrompt != "You are a helpful assistant" {
+		t.Errorf("System prompt should be kept, got %q", session.SystemPrompt)
+	}
+
+	messages := session.GetMessages()
+	if messages[0].Role != RoleSystem || !strings.HasPrefix(messages[0].Content, TrimNotePrefix) {
+		t.Errorf("First message should be the trim note, got %+v", messages[0])
+	}
+
+	// 最新のやり取りが残っている
+	last := messages[len(messages)-4:]
+	if !strings.HasPrefix(last[0].Content, "request 9:") || last[3].Content != "answer 9" {
+		t.Errorf("Most recent exchange should be kept, got %+v", last)
+	}
+
+	assertNoDanglingToolCalls(t, messages)
+}
+
+func TestTrimToFit_KeepsLastExchangeEvenIfTooLarge(t *testing.T) {
+	session := buildOverBudgetSession()
+
+	session.TrimToFit(1)
+
+	messages := session.GetMessages()
+	if len(messages) != 5 {
+		t.Fatalf("Messages = %d, want trim note + last exchange (5)", len(messages))
+	}
+	if !strings.HasPrefix(messages[1].Content, "request 9:") {
+		t.Errorf("Last exchange should be kept, got %q", messages[1].Content)
+	}
+	assertNoDanglingToolCalls(t, messages)
+}
+
+// assertNoDanglingToolCalls checks every tool result follows its tool call and vice versa
+func assertNoDanglingToolCalls(t *testing.T, messages []Message) {
+	t.Helper()
+
+	pending := make(map[string]bool)
+	for _, msg := range messages {
+		for _, tc := range msg.ToolCalls {
+			pending[tc.ID] = true
+		}
+		if msg.Role == RoleTool {
+			if !pending[msg.ToolID] {
+				t.Errorf("tool result %q has no preceding tool call", msg.ToolID)
+			}
+			delete(pending, msg.ToolID)
+		}
+	}
+	for id := range pending {
+		t.Errorf("tool call %q has no result", id)
+	}
+}
@@ -0,0 +1,130 @@
+package session
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ExportInfo is the metadata written at the top of an exported session
+type ExportInfo struct {
+	Model string
+	Date  time.Time
+}
+
+// sessionExport is the JSON form of an exported session
+type sessionExport struct {
+	SessionID  string    `json:"session_id"`
+	Model      string    `json:"model,omitempty"`
+	ExportedAt time.Time `json:"exported_at"`
+	Messages   []Message `json:"messages"`
+}
+
+// ExportJSON returns the session's messages with the export metadata as indented JSON
+func (s *Session) ExportJSON(info ExportInfo) ([]byte, error) {
+	export := sessionExport{
+		SessionID:  s.GetID(),
+		Model:      info.Model,
+		ExportedAt: info.Date,
+		Messages:   s.GetMessages(),
+	}
+	data, err := json.MarshalIndent(export, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// ExportMarkdown renders the session as a readable Markdown transcript:
+// a metadata header, a section per user/assistant message, and tool calls
+// and their results as fenced code blocks
+func (s *Session) ExportMarkdown(info ExportInfo) string {
+	messages := s.GetMessages()
+	id := s.GetID()
+
+	var b strings.Builder
+	title := "Session"
+	if id != "" {
+		title = "Session " + id
+	}
+	fmt.Fprintf(&b, "# %s\n\n", title)
+	if info.Model != "" {
+		fmt.Fprintf(&b, "- Model: %s\n", info.Model)
+	}
+	fmt.Fprintf(&b, "- Date: %s\n", info.Date.Format(time.RFC3339))
+	if id != "" {
+		fmt.Fprintf(&b, "- Session ID: %s\n", id)
+	}
+	fmt.Fprintf(&b, "- Messages: %d\n", len(messages))
+
+	toolNames := make(map[string]string)
+	for _, msg := range messages {
+		switch msg.Role {
+		case RoleUser:
+			b.WriteString("\n## User\n\n")
+			writeMarkdownText(&b, msg.Content)
+		case RoleAssistant:
+			b.WriteString("\n## Assistant\n\n")
+			if msg.Content != "" {
+				writeMarkdownText(&b, msg.Content)
+			}
+			for _, tc := range msg.ToolCalls {
+				toolNames[tc.ID] = tc.Function.Name
+				fmt.Fprintf(&b, "**Tool call:** `%s`\n\n", tc.Function.Name)
+				writeFenced(&b, "json", prettyArguments(tc.Function.Arguments))
+			}
+		case RoleTool:
+			heading := "Tool result"
+			if name := toolNames[msg.ToolID]; name != "" {
+				heading += fmt.Sprintf(" (`%s`)", name)
+			}
+			if msg.IsError {
+				heading += " — error"
+			}
+			fmt.Fprintf(&b, "\n### %s\n\n", heading)
+			writeFenced(&b, "", msg.Content)
+		case RoleSystem:
+			b.WriteString("\n## System\n\n")
+			writeMarkdownText(&b, msg.Content)
+		}
+	}
+	return b.String()
+}
+
+// writeMarkdownText writes text followed by a blank line
+func writeMarkdownText(b *strings.Builder, text string) {
+	b.WriteString(strings.TrimRight(text, "\n"))
+	b.WriteString("\n\n")
+}
+
+// writeFenced writes text as a fenced code block. The fence is longer than any
+// backtick run in text so embedded code blocks don't end it early
+func writeFenced(b *strings.Builder, lang, text string) {
+	fence := strings.Repeat("`", max(3, longestBacktickRun(text)+1))
+	fmt.Fprintf(b, "%s%s\n%s\n%s\n\n", fence, lang, strings.TrimRight(text, "\n"), fence)
+}
+
+// longestBacktickRun returns the length of the longest run of backticks in text
+func longestBacktickRun(text string) int {
+	longest, run := 0, 0
+	for _, r := range text {
+		if r == '`' {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	return longest
+}
+
+// prettyArguments indents JSON tool arguments; anything else is returned as is
+func prettyArguments(arguments string) string {
+	var out bytes.Buffer
+	if err := json.Indent(&out, []byte(arguments), "", "  "); err != nil {
+		return arguments
+	}
+	return out.String()
+}
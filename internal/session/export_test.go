@@ -0,0 +1,76 @@
+package session
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func exportTestSession() *Session {
+	s := NewSession("export-1", "system prompt")
+	s.AddUserMessage("Add a hello function")
+	s.AddToolCall([]ToolCall{traceCall("w", "write_file", `{"path":"hello.go","content":"package main"}`)})
+	s.AddToolResults([]ToolResult{{Content: "wrote ```hello.go```", ToolCallID: "w"}})
+	s.AddToolCall([]ToolCall{traceCall("t", "bash", `{"command":"go test"}`)})
+	s.AddToolResults([]ToolResult{{Content: "FAIL: no tests", ToolCallID: "t", IsError: true}})
+	s.AddAssistantMessage("Done. Here is the function:\n\n```go\nfunc hello() {}\n```")
+	return s
+}
+
+func TestExportMarkdown_ContainsEveryMessage(t *testing.T) {
+	s := exportTestSession()
+	date := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	md := s.ExportMarkdown(ExportInfo{Model: "qwen3:8b", Date: date})
+
+	for _, want := range []string{
+		"# Session export-1",
+		"- Model: qwen3:8b",
+		"- Date: 2026-03-01T12:00:00Z",
+		"- Session ID: export-1",
+		"## User",
+		"## Assistant",
+		"**Tool call:** `write_file`",
+		"### Tool result (`bash`) — error",
+	} {
+		if !strings.Contains(md, want) {
+			t.Errorf("markdown is missing %q:\n%s", want, md)
+		}
+	}
+	for _, msg := range s.GetMessages() {
+		if msg.Content != "" && !strings.Contains(md, msg.Content) {
+			t.Errorf("markdown is missing message content %q", msg.Content)
+		}
+	}
+	// Arguments are indented JSON in a json fence
+	if !strings.Contains(md, "```json\n{\n  \"path\": \"hello.go\"") {
+		t.Errorf("tool call arguments should be pretty-printed JSON:\n%s", md)
+	}
+	// Backticks in a tool result get a longer fence
+	if !strings.Contains(md, "````\nwrote ```hello.go```\n````") {
+		t.Errorf("tool output containing backticks should use a longer fence:\n%s", md)
+	}
+}
+
+func TestExportJSON_RoundTrips(t *testing.T) {
+	s := exportTestSession()
+	data, err := s.ExportJSON(ExportInfo{Model: "qwen3:8b", Date: time.Now()})
+	if err != nil {
+		t.Fatalf("ExportJSON: %v", err)
+	}
+
+	var export struct {
+		SessionID string    `json:"session_id"`
+		Model     string    `json:"model"`
+		Messages  []Message `json:"messages"`
+	}
+	if err := json.Unmarshal(data, &export); err != nil {
+		t.Fatalf("export is not JSON: %v", err)
+	}
+	if export.SessionID != "export-1" || export.Model != "qwen3:8b" || len(export.Messages) != len(s.GetMessages()) {
+		t.Errorf("export = %s / %s / %d messages", export.SessionID, export.Model, len(export.Messages))
+	}
+	if !export.Messages[4].IsError {
+		t.Error("tool error flag should be kept")
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"strings"
 	"sync"
 	"time"
+	"unicode/utf8"
 )
 
 const (
@@ -15,6 +16,12 @@ const (
 	MaxSessionSize = 50 * 1024 * 1024
 	// SessionDir is the directory where sessions are stored
 	SessionDir = "sessions"
+	// MaxSearchHits is the maximum number of hits returned by SearchSessions
+	MaxSearchHits = 50
+	// searchSnippetContext is the number of characters shown around a match
+	searchSnippetContext = 40
+	// backupSuffix is appended to a session file to keep the previous successful save
+	backupSuffix = ".bak"
 )
 
 // SessionIndex indexes sessions by project directory
@@ -103,17 +110,16 @@ func (pm *PersistenceManager) LoadSession(sessionID string) (*Session, error) {
 		return session, nil
 	}
 
-	// Load from file
+	// Load from file, falling back to the backup of the previous save if the
+	// main file is missing or corrupt (e.g. after a crash while saving)
 	sessionFile := filepath.Join(pm.baseDir, SessionDir, sessionID+".jsonl")
-	data, err := os.ReadFile(sessionFile)
+	session, err := readSessionFile(sessionID, sessionFile)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read session file: %w", err)
-	}
-
-	// Parse session
-	session = NewSession(sessionID, "")
-	if err := session.FromJSON(data); err != nil {
-		return nil, fmt.Errorf("failed to parse session: %w", err)
+		backup, backupErr := readSessionFile(sessionID, sessionFile+backupSuffix)
+		if backupErr != nil {
+			return nil, err
+		}
+		session = backup
 	}
 
 	// Cache in memory
@@ -124,6 +130,20 @@ func (pm *PersistenceManager) LoadSession(sessionID string) (*Session, error) {
 	return session, nil
 }
 
+// readSessionFile reads and parses a single session file
+func readSessionFile(sessionID, path string) (*Session, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read session file: %w", err)
+	}
+
+	session := NewSession(sessionID, "")
+	if err := session.FromJSON(data); err != nil {
+		return nil, fmt.Errorf("failed to parse session: %w", err)
+	}
+	return session, nil
+}
+
 // ListSessions returns all session IDs
 func (pm *PersistenceManager) ListSessions() ([]string, error) {
 	pm.mu.RLock()
@@ -145,6 +165,107 @@ func (pm *PersistenceManager) ListSessions() ([]string, error) {
 	return sessions, nil
 }
 
+// SearchHit represents a message that matched a session search
+type SearchHit struct {
+	SessionID    string
+	MessageIndex int
+	Role         MessageRole
+	Snippet      string
+}
+
+// SearchSessions scans saved sessions for messages containing query (case-insensitive).
+// Sessions that fail to read or parse are skipped. At most MaxSearchHits hits are returned.
+func (pm *PersistenceManager) SearchSessions(query string) ([]SearchHit, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, fmt.Errorf("search query cannot be empty")
+	}
+
+	sessionIDs, err := pm.ListSessions()
+	if err != nil {
+		return nil, err
+	}
+
+	lowerQuery := strings.ToLower(query)
+	hits := make([]SearchHit, 0)
+
+	for _, sessionID := range sessionIDs {
+		// キャッシュではなくディスク上の保存内容を検索する
+		data, err := os.ReadFile(filepath.Join(pm.baseDir, SessionDir, sessionID+".jsonl"))
+		if err != nil {
+			continue
+		}
+
+		var saved Session
+		if err := json.Unmarshal(data, &saved); err != nil {
+			continue
+		}
+
+		for i, msg := range saved.Messages {
+			pos := indexFold(msg.Content, lowerQuery)
+			if pos < 0 {
+				continue
+			}
+
+			hits = append(hits, SearchHit{
+				SessionID:    sessionID,
+				MessageIndex: i,
+				Role:         msg.Role,
+				Snippet:      makeSnippet(msg.Content, pos, len(lowerQuery)),
+			})
+
+			if len(hits) >= MaxSearchHits {
+				return hits, nil
+			}
+		}
+	}
+
+	return hits, nil
+}
+
+// indexFold returns the byte index of lowerQuery in s ignoring case, or -1
+func indexFold(s, lowerQuery string) int {
+	lower := strings.ToLower(s)
+	pos := strings.Index(lower, lowerQuery)
+	if pos < 0 {
+		return -1
+	}
+	// 小文字化でバイト長が変わる文字を含む場合は位置がずれるので先頭を返す
+	if len(lower) != len(s) {
+		return 0
+	}
+	return pos
+}
+
+// makeSnippet returns a single-line excerpt of s around the match at pos
+func makeSnippet(s string, pos, matchLen int) string {
+	start := pos - searchSnippetContext
+	if start < 0 {
+		start = 0
+	}
+	end := pos + matchLen + searchSnippetContext
+	if end > len(s) {
+		end = len(s)
+	}
+
+	// マルチバイト文字の途中で切らないよう境界に合わせる
+	for start > 0 && !utf8.RuneStart(s[start]) {
+		start--
+	}
+	for end < len(s) && !utf8.RuneStart(s[end]) {
+		end++
+	}
+
+	snippet := strings.Join(strings.Fields(s[start:end]), " ")
+	if start > 0 {
+		snippet = "..." + snippet
+	}
+	if end < len(s) {
+		snippet = snippet + "..."
+	}
+	return snippet
+}
+
 // GetLastSession returns the session for the current project
 func (pm *PersistenceManager) GetLastSession() (*Session, string, error) {
 	pm.mu.RLock()
@@ -186,26 +307,93 @@ func (pm *PersistenceManager) DeleteSession(sessionID string) error {
 		}
 	}
 
-	// Delete file
+	// Delete file (and its backup, so LoadSession can't fall back to it)
 	sessionFile := filepath.Join(pm.baseDir, SessionDir, sessionID+".jsonl")
-	if err := os.Remove(sessionFile); err != nil && !os.IsNotExist(err) {
-		return fmt.Errorf("failed to delete session file: %w", err)
+	for _, path := range []string{sessionFile, sessionFile + backupSuffix} {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to delete session file: %w", err)
+		}
 	}
 
 	// Save updated index
 	return pm.saveIndex()
 }
 
-// writeSessionFile writes session data atomically
+// writeSessionFile writes session data atomically, keeping the previous
+// version as a backup that LoadSession falls back to
 func writeSessionFile(path string, data []byte) error {
-	// Write to temp file
-	tmpPath := path + ".tmp"
-	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+	return writeFileAtomic(path, data, true)
+}
+
+// writeFileAtomic writes data to a synced temp file in the same directory and
+// renames it over path, so a crash never leaves a truncated file behind.
+// With keepBackup, the file being replaced is also kept as path+backupSuffix.
+func writeFileAtomic(path string, data []byte, keepBackup bool) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath) // no-op once renamed
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmpPath, 0644); err != nil {
+		return err
+	}
+
+	if keepBackup {
+		if err := backupFile(path); err != nil {
+			return fmt.Errorf("failed to back up %s: %w", filepath.Base(path), err)
+		}
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return err
+	}
+
+	syncDir(filepath.Dir(path))
+	return nil
+}
+
+// backupFile links (or copies) path to path+backupSuffix while leaving path
+// in place, so a crash before the following rename never loses the session file.
+func backupFile(path string) error {
+	backup := path + backupSuffix
+	if err := os.Remove(backup); err != nil && !os.IsNotExist(err) {
 		return err
 	}
+	err := os.Link(path, backup)
+	if err == nil || os.IsNotExist(err) {
+		return nil
+	}
 
-	// Atomic rename
-	return os.Rename(tmpPath, path)
+	// Hard links are not supported everywhere: fall back to a copy
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	return os.WriteFile(backup, data, 0644)
+}
+
+// syncDir flushes a directory entry so a completed rename survives a crash.
+// Best effort: not every platform supports syncing a directory.
+func syncDir(dir string) {
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
 }
 
 // loadIndex loads the session index
@@ -246,7 +434,7 @@ func (pm *PersistenceManager) saveIndex() error {
 	}
 
 	indexFile := filepath.Join(pm.baseDir, "session_index.json")
-	return writeSessionFile(indexFile, data)
+	return writeFileAtomic(indexFile, data, false)
 }
 
 // getProjectHash generates a hash for the current project directory
@@ -277,7 +465,8 @@ func (pm *PersistenceManager) CleanupOldSessions() error {
 	var cleaned int
 
 	for _, entry := range entries {
-		if entry.IsDir() {
+		// Backups go together with their session file below
+		if entry.IsDir() || strings.HasSuffix(entry.Name(), backupSuffix) {
 			continue
 		}
 
@@ -291,6 +480,7 @@ func (pm *PersistenceManager) CleanupOldSessions() error {
 			if err := os.Remove(sessionFile); err != nil {
 				continue
 			}
+			os.Remove(sessionFile + backupSuffix)
 
 			sessionID := strings.TrimSuffix(entry.Name(), ".jsonl")
 			delete(pm.sessions, sessionID)
@@ -509,3 +509,264 @@ func TestPersistenceErrorHandling(t *testing.T) {
 		})
 	}
 }
+
+func TestSearchSessions(t *testing.T) {
+	tmpDir := t.TempDir()
+	pm, err := NewPersistenceManager(tmpDir)
+	if err != nil {
+		t.Fatalf("NewPersistenceManager failed: %v", err)
+	}
+
+	s1 := NewSession("session-a", "test-project")
+	s1.AddUserMessage("How do I configure the Ollama host?")
+	s1.AddAssistantMessage("Set OLLAMA_HOST or pass --host.")
+	s2 := NewSession("session-b", "test-project")
+	s2.AddUserMessage("Write a fibonacci function")
+
+	for _, s := range []*Session{s1, s2} {
+		if err := pm.SaveSession(s); err != nil {
+			t.Fatalf("SaveSession failed: %v", err)
+		}
+	}
+
+	hits, err := pm.SearchSessions("ollama")
+	if err != nil {
+		t.Fatalf("SearchSessions failed: %v", err)
+	}
+
+	if len(hits) != 2 {
+		t.Fatalf("Expected 2 hits, got %d: %+v", len(hits), hits)
+	}
+
+	for i, hit := range hits {
+		if hit.SessionID != "session-a" {
+			t.Errorf("Expected hit in session-a, got %s", hit.SessionID)
+		}
+		if hit.MessageIndex != i {
+			t.Errorf("Expected message index %d, got %d", i, hit.MessageIndex)
+		}
+		if !strings.Contains(strings.ToLower(hit.Snippet), "ollama") {
+			t.Errorf("Snippet should contain the match: %q", hit.Snippet)
+		}
+	}
+
+	if hits[0].Role != RoleUser || hits[1].Role != RoleAssistant {
+		t.Errorf("Unexpected roles: %s, %s", hits[0].Role, hits[1].Role)
+	}
+}
+
+func TestSearchSessionsSkipsCorruptSessions(t *testing.T) {
+	tmpDir := t.TempDir()
+	pm, err := NewPersistenceManager(tmpDir)
+	if err != nil {
+		t.Fatalf("NewPersistenceManager failed: %v", err)
+	}
+
+	s := NewSession("good-session", "test-project")
+	s.AddUserMessage("needle in a haystack")
+	if err := pm.SaveSession(s); err != nil {
+		t.Fatalf("SaveSession failed: %v", err)
+	}
+
+	corrupt := filepath.Join(tmpDir, SessionDir, "bad-session.jsonl")
+	if err := os.WriteFile(corrupt, []byte("{not json"), 0644); err != nil {
+		t.Fatalf("Failed to write corrupt session: %v", err)
+	}
+
+	hits, err := pm.SearchSessions("NEEDLE")
+	if err != nil {
+		t.Fatalf("SearchSessions failed: %v", err)
+	}
+
+	if len(hits) != 1 || hits[0].SessionID != "good-session" {
+		t.Errorf("Expected single hit in good-session, got %+v", hits)
+	}
+}
+
+func TestSearchSessionsLimit(t *testing.T) {
+	tmpDir := t.TempDir()
+	pm, err := NewPersistenceManager(tmpDir)
+	if err != nil {
+		t.Fatalf("NewPersistenceManager failed: %v", err)
+	}
+
+	s := NewSession("busy-session", "test-project")
+	for i := 0; i < MaxSearchHits+10; i++ {
+		s.AddUserMessage(fmt.Sprintf("match %d", i))
+	}
+	if err := pm.SaveSession(s); err != nil {
+		t.Fatalf("SaveSession failed: %v", err)
+	}
+
+	hits, err := pm.SearchSessions("match")
+	if err != nil {
+		t.Fatalf("SearchSessions failed: %v", err)
+	}
+
+	if len(hits) != MaxSearchHits {
+		t.Errorf("Expected %d hits, got %d", MaxSearchHits, len(hits))
+	}
+
+	if _, err := pm.SearchSessions("   "); err == nil {
+		t.Error("Expected error for empty query")
+	}
+}
+
+func TestMakeSnippet(t *testing.T) {
+	content := strings.Repeat("a", 100) + "\nTARGET\n" + strings.Repeat("b", 100)
+	pos := strings.Index(content, "TARGET")
+
+	snippet := makeSnippet(content, pos, len("TARGET"))
+	if !strings.HasPrefix(snippet, "...") || !strings.HasSuffix(snippet, "...") {
+		t.Errorf("Expected ellipses on both sides, got %q", snippet)
+	}
+	if strings.Contains(snippet, "\n") {
+		t.Errorf("Snippet should be a single line, got %q", snippet)
+	}
+	if !strings.Contains(snippet, "TARGET") {
+		t.Errorf("Snippet should contain the match, got %q", snippet)
+	}
+}
+
+func TestSessionModesRoundTrip(t *testing.T) {
+	tmpDir := t.TempDir()
+	pm, err := NewPersistenceManager(tmpDir)
+	if err != nil {
+		t.Fatalf("NewPersistenceManager failed: %v", err)
+	}
+
+	session := NewSession("modes-session", "test-project")
+	session.AddUserMessage("Hello")
+	session.SetModes(SessionModes{AutoApprove: true, PlanMode: true, AutoTest: true})
+
+	if err := pm.SaveSession(session); err != nil {
+		t.Fatalf("SaveSession failed: %v", err)
+	}
+
+	// Use a fresh manager so the session is read from disk rather than the cache
+	pm2, err := NewPersistenceManager(tmpDir)
+	if err != nil {
+		t.Fatalf("NewPersistenceManager failed: %v", err)
+	}
+
+	loaded, err := pm2.LoadSession("modes-session")
+	if err != nil {
+		t.Fatalf("LoadSession failed: %v", err)
+	}
+
+	want := SessionModes{AutoApprove: true, PlanMode: true, AutoTest: true}
+	if got := loaded.GetModes(); got != want {
+		t.Errorf("Modes = %+v, want %+v", got, want)
+	}
+}
+
+func TestSessionModesLegacyFile(t *testing.T) {
+	tmpDir := t.TempDir()
+	pm, err := NewPersistenceManager(tmpDir)
+	if err != nil {
+		t.Fatalf("NewPersistenceManager failed: %v", err)
+	}
+
+	// Session file written before modes were persisted
+	legacy := `{"ID":"legacy-session","Messages":[{"role":"user","content":"Hi"}],"SystemPrompt":"","TokenEstimate":1}`
+	sessionFile := filepath.Join(tmpDir, SessionDir, "legacy-session.jsonl")
+	if err := os.WriteFile(sessionFile, []byte(legacy), 0644); err != nil {
+		t.Fatalf("Failed to write legacy session: %v", err)
+	}
+
+	loaded, err := pm.LoadSession("legacy-session")
+	if err != nil {
+		t.Fatalf("LoadSession failed: %v", err)
+	}
+
+	if got := loaded.GetModes(); got != (SessionModes{}) {
+		t.Errorf("Legacy session should load with default modes, got %+v", got)
+	}
+	if loaded.GetMessageCount() != 1 {
+		t.Errorf("Expected 1 message, got %d", loaded.GetMessageCount())
+	}
+}
+
+func TestLoadSessionRecoversFromBackup(t *testing.T) {
+	tmpDir := t.TempDir()
+	pm, err := NewPersistenceManager(tmpDir)
+	if err != nil {
+		t.Fatalf("NewPersistenceManager failed: %v", err)
+	}
+
+	session := NewSession("crash-test", "test-project")
+	session.AddUserMessage("first")
+	if err := pm.SaveSession(session); err != nil {
+		t.Fatalf("SaveSession failed: %v", err)
+	}
+	session.AddUserMessage("second")
+	if err := pm.SaveSession(session); err != nil {
+		t.Fatalf("SaveSession failed: %v", err)
+	}
+
+	// Saves leave only the session file and the backup of the previous save
+	entries, _ := os.ReadDir(filepath.Join(tmpDir, SessionDir))
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	if strings.Join(names, ",") != "crash-test.jsonl,crash-test.jsonl.bak" {
+		t.Errorf("session dir = %v, want the session file and its backup only", names)
+	}
+
+	// Simulate a crash that left the main file truncated
+	sessionFile := pm.GetSessionPath("crash-test")
+	data, err := os.ReadFile(sessionFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(sessionFile, data[:len(data)/2], 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// A fresh manager has no cached copy and must read from disk
+	pm2, err := NewPersistenceManager(tmpDir)
+	if err != nil {
+		t.Fatalf("NewPersistenceManager failed: %v", err)
+	}
+	loaded, err := pm2.LoadSession("crash-test")
+	if err != nil {
+		t.Fatalf("LoadSession should recover from the backup: %v", err)
+	}
+	messages := loaded.GetMessages()
+	if len(messages) != 1 || messages[0].Content != "first" {
+		t.Errorf("recovered messages = %+v, want the previous save", messages)
+	}
+
+	// Without a usable backup the original error is reported
+	os.Remove(sessionFile + ".bak")
+	pm3, _ := NewPersistenceManager(tmpDir)
+	if _, err := pm3.LoadSession("crash-test"); err == nil || !strings.Contains(err.Error(), "parse") {
+		t.Errorf("LoadSession error = %v, want a parse error", err)
+	}
+}
+
+func TestBackupFileKeepsOriginal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "s.jsonl")
+	if err := os.WriteFile(path, []byte("v1"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path+".bak", []byte("v0"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// A crash right after the backup must still find the session file
+	if err := backupFile(path); err != nil {
+		t.Fatalf("backupFile failed: %v", err)
+	}
+	for _, p := range []string{path, path + ".bak"} {
+		if data, err := os.ReadFile(p); err != nil || string(data) != "v1" {
+			t.Errorf("%s = %q, %v; want v1", filepath.Base(p), data, err)
+		}
+	}
+
+	// Nothing to back up yet is not an error
+	if err := backupFile(filepath.Join(t.TempDir(), "new.jsonl")); err != nil {
+		t.Errorf("backupFile on a missing file: %v", err)
+	}
+}
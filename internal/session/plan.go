@@ -0,0 +1,332 @@
+package session
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// PlanStatus is the progress of a plan step
+type PlanStatus string
+
+const (
+	PlanPending    PlanStatus = "pending"
+	PlanInProgress PlanStatus = "in_progress" // Some of the step's files have been written
+	PlanDone       PlanStatus = "done"
+)
+
+// PlanStep is one step of a plan registered by the plan tool
+type PlanStep struct {
+	ID          string     `json:"id"`
+	Description string     `json:"description"`
+	Files       []string   `json:"files,omitempty"`
+	DependsOn   []string   `json:"depends_on,omitempty"`
+	Status      PlanStatus `json:"status"`
+	Touched     []string   `json:"touched,omitempty"` // Files written successfully so far
+}
+
+// Plan is an ordered list of steps. Steps are kept in dependency order, so a
+// step always comes after everything it depends on.
+type Plan struct {
+	Steps []PlanStep `json:"steps"`
+}
+
+// NewPlan validates steps and orders them by dependency, keeping the given
+// order among independent steps. Missing IDs are numbered from 1.
+// Unknown dependencies and cycles are errors.
+func NewPlan(steps []PlanStep) (*Plan, error) {
+	if len(steps) == 0 {
+		return nil, fmt.Errorf("plan has no steps")
+	}
+
+	byID := make(map[string]int, len(steps))
+	normalized := make([]PlanStep, len(steps))
+	for i, step := range steps {
+		step.ID = strings.TrimSpace(step.ID)
+		if step.ID == "" {
+			step.ID = strconv.Itoa(i + 1)
+		}
+		if strings.TrimSpace(step.Description) == "" {
+			return nil, fmt.Errorf("step %s has no description", step.ID)
+		}
+		if _, dup := byID[step.ID]; dup {
+			return nil, fmt.Errorf("duplicate step id %q", step.ID)
+		}
+		step.Status = PlanPending
+		step.Touched = nil
+		byID[step.ID] = i
+		normalized[i] = step
+	}
+
+	for _, step := range normalized {
+		for _, dep := range step.DependsOn {
+			if dep == step.ID {
+				return nil, fmt.Errorf("step %s depends on itself", step.ID)
+			}
+			if _, ok := byID[dep]; !ok {
+				return nil, fmt.Errorf("step %s depends on unknown step %q", step.ID, dep)
+			}
+		}
+	}
+
+	// Topological sort; among ready steps, the earliest in the input goes first
+	ordered := make([]PlanStep, 0, len(normalized))
+	placed := make(map[string]bool, len(normalized))
+	for len(ordered) < len(normalized) {
+		progress := false
+		for _, step := range normalized {
+			if placed[step.ID] || !allIn(step.DependsOn, placed) {
+				continue
+			}
+			ordered = append(ordered, step)
+			placed[step.ID] = true
+			progress = true
+			break
+		}
+		if !progress {
+			return nil, fmt.Errorf("plan has a dependency cycle")
+		}
+	}
+
+	return &Plan{Steps: ordered}, nil
+}
+
+// allIn reports whether every id is set in done
+func allIn(ids []string, done map[string]bool) bool {
+	for _, id := range ids {
+		if !done[id] {
+			return false
+		}
+	}
+	return true
+}
+
+// doneSet returns the IDs of finished steps
+func (p *Plan) doneSet() map[string]bool {
+	done := make(map[string]bool, len(p.Steps))
+	for _, step := range p.Steps {
+		if step.Status == PlanDone {
+			done[step.ID] = true
+		}
+	}
+	return done
+}
+
+// Ready returns the unfinished steps whose dependencies are all done
+func (p *Plan) Ready() []PlanStep {
+	done := p.doneSet()
+	var ready []PlanStep
+	for _, step := range p.Steps {
+		if step.Status != PlanDone && allIn(step.DependsOn, done) {
+			ready = append(ready, step)
+		}
+	}
+	return ready
+}
+
+// Complete marks a step done. A step can't be completed before its dependencies.
+func (p *Plan) Complete(id string) error {
+	done := p.doneSet()
+	for i, step := range p.Steps {
+		if step.ID != id {
+			continue
+		}
+		if pending := missing(step.DependsOn, done); len(pending) > 0 {
+			return fmt.Errorf("step %s depends on unfinished step(s) %s", id, strings.Join(pending, ", "))
+		}
+		p.Steps[i].Status = PlanDone
+		return nil
+	}
+	return fmt.Errorf("unknown step %q", id)
+}
+
+// missing returns the ids that are not in done
+func missing(ids []string, done map[string]bool) []string {
+	var out []string
+	for _, id := range ids {
+		if !done[id] {
+			out = append(out, id)
+		}
+	}
+	return out
+}
+
+// CheckFile gates writes by dependency order: writing a file that belongs only
+// to steps whose dependencies are unfinished is an error. Files outside the
+// plan, and files of finished or ready steps, are allowed.
+func (p *Plan) CheckFile(path string) error {
+	done := p.doneSet()
+	var blocked *PlanStep
+	for i, step := range p.Steps {
+		if !step.hasFile(path) {
+			continue
+		}
+		if step.Status == PlanDone || allIn(step.DependsOn, done) {
+			return nil
+		}
+		if blocked == nil {
+			blocked = &p.Steps[i]
+		}
+	}
+	if blocked == nil {
+		return nil
+	}
+	return fmt.Errorf("%s belongs to plan step %s (%s), which depends on unfinished step(s) %s; finish those first",
+		path, blocked.ID, blocked.Description, strings.Join(missing(blocked.DependsOn, done), ", "))
+}
+
+// RecordFile notes a successful write to path on every ready step that lists it.
+// A step is done once all of its files have been written. Returns the IDs of
+// steps completed by this write.
+func (p *Plan) RecordFile(path string) []string {
+	var completed []string
+	for _, ready := range p.Ready() {
+		if !ready.hasFile(path) {
+			continue
+		}
+		step := p.step(ready.ID)
+		if !containsPath(step.Touched, path) {
+			step.Touched = append(step.Touched, path)
+		}
+		step.Status = PlanInProgress
+		if step.allFilesTouched() {
+			step.Status = PlanDone
+			completed = append(completed, step.ID)
+		}
+	}
+	return completed
+}
+
+// step returns a pointer to the step with id (nil if unknown)
+func (p *Plan) step(id string) *PlanStep {
+	for i := range p.Steps {
+		if p.Steps[i].ID == id {
+			return &p.Steps[i]
+		}
+	}
+	return nil
+}
+
+// DoneCount returns the number of finished steps
+func (p *Plan) DoneCount() int {
+	return len(p.doneSet())
+}
+
+// Render formats the plan as a checklist
+func (p *Plan) Render() string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Plan (%d/%d done):\n", p.DoneCount(), len(p.Steps)))
+	for _, step := range p.Steps {
+		mark := "[ ]"
+		switch step.Status {
+		case PlanDone:
+			mark = "[x]"
+		case PlanInProgress:
+			mark = "[~]"
+		}
+		sb.WriteString(fmt.Sprintf("  %s %s. %s", mark, step.ID, step.Description))
+		if len(step.Files) > 0 {
+			sb.WriteString(fmt.Sprintf(" (%s)", strings.Join(step.Files, ", ")))
+		}
+		if len(step.DependsOn) > 0 {
+			sb.WriteString(fmt.Sprintf(" ← after %s", strings.Join(step.DependsOn, ", ")))
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// clone returns a deep copy of the plan
+func (p *Plan) clone() *Plan {
+	if p == nil {
+		return nil
+	}
+	steps := make([]PlanStep, len(p.Steps))
+	for i, step := range p.Steps {
+		step.Files = append([]string(nil), step.Files...)
+		step.DependsOn = append([]string(nil), step.DependsOn...)
+		step.Touched = append([]string(nil), step.Touched...)
+		steps[i] = step
+	}
+	return &Plan{Steps: steps}
+}
+
+func (s *PlanStep) hasFile(path string) bool {
+	return containsPath(s.Files, path)
+}
+
+func (s *PlanStep) allFilesTouched() bool {
+	if len(s.Files) == 0 {
+		return false // Steps without files are completed explicitly
+	}
+	for _, f := range s.Files {
+		if !containsPath(s.Touched, f) {
+			return false
+		}
+	}
+	return true
+}
+
+// containsPath compares paths after cleaning and making them absolute, so
+// "./a.go" and "a.go" match
+func containsPath(paths []string, path string) bool {
+	target := normalizePlanPath(path)
+	for _, p := range paths {
+		if normalizePlanPath(p) == target {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizePlanPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return filepath.Clean(path)
+}
+
+// SetPlan replaces the session's plan
+func (s *Session) SetPlan(plan *Plan) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Plan = plan.clone()
+}
+
+// GetPlan returns a copy of the session's plan (nil when none is registered)
+func (s *Session) GetPlan() *Plan {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.Plan.clone()
+}
+
+// CompletePlanStep marks a plan step done
+func (s *Session) CompletePlanStep(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.Plan == nil {
+		return fmt.Errorf("no plan registered")
+	}
+	return s.Plan.Complete(id)
+}
+
+// CheckPlanFile returns an error when writing path would break the plan's order
+func (s *Session) CheckPlanFile(path string) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.Plan == nil {
+		return nil
+	}
+	return s.Plan.CheckFile(path)
+}
+
+// RecordPlanFile notes a successful write to path and returns the steps it completed
+func (s *Session) RecordPlanFile(path string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.Plan == nil {
+		return nil
+	}
+	return s.Plan.RecordFile(path)
+}
@@ -0,0 +1,117 @@
+package session
+
+import (
+	"strings"
+	"testing"
+)
+
+func stepIDs(steps []PlanStep) string {
+	ids := make([]string, len(steps))
+	for i, s := range steps {
+		ids[i] = s.ID
+	}
+	return strings.Join(ids, ",")
+}
+
+func TestNewPlan_OrdersStepsByDependency(t *testing.T) {
+	plan, err := NewPlan([]PlanStep{
+		{ID: "tests", Description: "Write tests", Files: []string{"handler_test.go"}, DependsOn: []string{"handler"}},
+		{ID: "model", Description: "Add model", Files: []string{"model.go"}},
+		{ID: "handler", Description: "Add handler", Files: []string{"handler.go"}, DependsOn: []string{"model"}},
+		{ID: "docs", Description: "Update docs"},
+	})
+	if err != nil {
+		t.Fatalf("NewPlan() error = %v", err)
+	}
+
+	if got := stepIDs(plan.Steps); got != "model,handler,tests,docs" {
+		t.Errorf("order = %s, want model,handler,tests,docs", got)
+	}
+	for _, step := range plan.Steps {
+		if step.Status != PlanPending {
+			t.Errorf("step %s status = %s, want pending", step.ID, step.Status)
+		}
+	}
+	if got := stepIDs(plan.Ready()); got != "model,docs" {
+		t.Errorf("ready = %s, want model,docs", got)
+	}
+
+	// Dependencies gate writes and completion
+	if err := plan.CheckFile("handler.go"); err == nil {
+		t.Error("writing handler.go before model is done should be refused")
+	}
+	if err := plan.CheckFile("unrelated.go"); err != nil {
+		t.Errorf("files outside the plan should be allowed, got %v", err)
+	}
+	if err := plan.Complete("tests"); err == nil {
+		t.Error("completing tests before handler should fail")
+	}
+
+	// Writing all of a step's files completes it and unblocks dependents
+	if done := plan.RecordFile("./model.go"); len(done) != 1 || done[0] != "model" {
+		t.Errorf("RecordFile(model.go) completed %v, want [model]", done)
+	}
+	if err := plan.CheckFile("handler.go"); err != nil {
+		t.Errorf("handler.go should be writable once model is done, got %v", err)
+	}
+	if got := stepIDs(plan.Ready()); got != "handler,docs" {
+		t.Errorf("ready = %s, want handler,docs", got)
+	}
+
+	if err := plan.Complete("docs"); err != nil {
+		t.Errorf("Complete(docs) error = %v", err)
+	}
+	if plan.DoneCount() != 2 {
+		t.Errorf("DoneCount() = %d, want 2", plan.DoneCount())
+	}
+	if rendered := plan.Render(); !strings.Contains(rendered, "Plan (2/4 done)") || !strings.Contains(rendered, "[x] model. Add model") {
+		t.Errorf("Render() = %q", rendered)
+	}
+}
+
+func TestNewPlan_RejectsInvalidDependencies(t *testing.T) {
+	tests := []struct {
+		name  string
+		steps []PlanStep
+	}{
+		{"unknown", []PlanStep{{ID: "a", Description: "a", DependsOn: []string{"missing"}}}},
+		{"self", []PlanStep{{ID: "a", Description: "a", DependsOn: []string{"a"}}}},
+		{"cycle", []PlanStep{
+			{ID: "a", Description: "a", DependsOn: []string{"b"}},
+			{ID: "b", Description: "b", DependsOn: []string{"a"}},
+		}},
+		{"duplicate", []PlanStep{{ID: "a", Description: "a"}, {ID: "a", Description: "again"}}},
+		{"empty", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewPlan(tt.steps); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestSession_PlanSurvivesJSONRoundTrip(t *testing.T) {
+	sess := NewSession("plan", "")
+	plan, err := NewPlan([]PlanStep{{Description: "first", Files: []string{"a.go"}}, {Description: "second", DependsOn: []string{"1"}}})
+	if err != nil {
+		t.Fatalf("NewPlan() error = %v", err)
+	}
+	sess.SetPlan(plan)
+	sess.RecordPlanFile("a.go")
+
+	data, err := sess.ToJSON()
+	if err != nil {
+		t.Fatalf("ToJSON() error = %v", err)
+	}
+	loaded := NewSession("", "")
+	if err := loaded.FromJSON(data); err != nil {
+		t.Fatalf("FromJSON() error = %v", err)
+	}
+
+	got := loaded.GetPlan()
+	if got == nil || stepIDs(got.Steps) != "1,2" || got.Steps[0].Status != PlanDone {
+		t.Errorf("loaded plan = %+v, want steps 1,2 with 1 done", got)
+	}
+}
@@ -2,6 +2,7 @@ package session
 
 import (
 	"encoding/json"
+	"strings"
 	"sync"
 )
 
@@ -27,6 +28,7 @@ type Message struct {
 	ToolCalls  []ToolCall   `json:"tool_calls,omitempty"`
 	ToolID     string        `json:"tool_id,omitempty"`
 	TokenCount int           `json:"token_count,omitempty"`
+	IsError    bool          `json:"is_error,omitempty"` // Tool messages only: the tool call failed
 }
 
 // ToolCall represents a tool call within a message
@@ -48,13 +50,29 @@ type Session struct {
 	Messages       []Message
 	SystemPrompt   string
 	TokenEstimate  int
+	Modes          SessionModes // 復旧時に再適用するランタイムモード
+	Plan           *Plan        `json:",omitempty"` // plan ツールで登録された計画
+	Usage          []ModelUsage `json:",omitempty"` // Token usage reported by the provider, per model
 	mu             sync.RWMutex
 
+	// Automatic compaction settings (zero values = package defaults)
+	contextWindow    int     // Context window size in tokens (0 = DefaultContextWindow)
+	compactThreshold float64 // Usage fraction that triggers compaction (0 = CompactThreshold)
+	noAutoCompact    bool    // Disables automatic compaction (manual /compact still works)
+
 	// Cache for GetMessagesForLLM (avoid O(n) rebuild every call)
 	cachedLLMMessages []map[string]interface{}
 	llmCacheDirty     bool // true when messages changed since last cache build
 }
 
+// SessionModes holds runtime toggles that are restored when a session is resumed.
+// Session files written before these fields existed load with all modes off.
+type SessionModes struct {
+	AutoApprove bool `json:"auto_approve,omitempty"`
+	PlanMode    bool `json:"plan_mode,omitempty"`
+	AutoTest    bool `json:"auto_test,omitempty"`
+}
+
 // NewSession creates a new session
 func NewSession(id string, systemPrompt string) *Session {
 	return &Session{
@@ -122,6 +140,7 @@ func (s *Session) AddToolResults(results []ToolResult) {
 			Role:    RoleTool,
 			Content: result.Content,
 			ToolID:  result.ToolCallID,
+			IsError: result.IsError,
 		}
 
 		s.Messages = append(s.Messages, msg)
@@ -131,10 +150,34 @@ func (s *Session) AddToolResults(results []ToolResult) {
 	s.compactIfNeeded()
 }
 
+// ReplayMessages adds messages from another session (e.g. one being resumed)
+// through the Add* methods, keeping tool calls and failed tool results intact
+func (s *Session) ReplayMessages(messages []Message) {
+	for _, msg := range messages {
+		switch msg.Role {
+		case RoleUser:
+			s.AddUserMessage(msg.Content)
+		case RoleAssistant:
+			if len(msg.ToolCalls) > 0 {
+				s.AddToolCall(msg.ToolCalls)
+			} else {
+				s.AddAssistantMessage(msg.Content)
+			}
+		case RoleTool:
+			s.AddToolResults([]ToolResult{{
+				Content:    msg.Content,
+				ToolCallID: msg.ToolID,
+				IsError:    msg.IsError,
+			}})
+		}
+	}
+}
+
 // ToolResult represents a tool execution result
 type ToolResult struct {
 	Content   string
 	ToolCallID string
+	IsError    bool
 }
 
 // GetMessages returns all messages in the session
@@ -291,6 +334,9 @@ func (s *Session) Clone() *Session {
 		Messages:      messages,
 		SystemPrompt:  s.SystemPrompt,
 		TokenEstimate: s.TokenEstimate,
+		Modes:         s.Modes,
+		Plan:          s.Plan.clone(),
+		Usage:         append([]ModelUsage(nil), s.Usage...),
 	}
 }
 
@@ -316,12 +362,31 @@ func (s *Session) FromJSON(data []byte) error {
 	s.Messages = session.Messages
 	s.SystemPrompt = session.SystemPrompt
 	s.TokenEstimate = session.TokenEstimate
+	s.Modes = session.Modes
+	s.Plan = session.Plan
+	s.Usage = session.Usage
 	s.llmCacheDirty = true
 	s.cachedLLMMessages = nil
 
 	return nil
 }
 
+// SetModes records the runtime modes to persist with the session
+func (s *Session) SetModes(modes SessionModes) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.Modes = modes
+}
+
+// GetModes returns the persisted runtime modes
+func (s *Session) GetModes() SessionModes {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.Modes
+}
+
 // GetID returns the session ID
 func (s *Session) GetID() string {
 	return s.ID
@@ -361,3 +426,36 @@ func (s *Session) GetLastAssistantMessage() (*Message, bool) {
 
 	return nil, false
 }
+
+// FileWatcherPrefix starts the change notifications the file watcher adds as user messages
+const FileWatcherPrefix = "[File Watcher]"
+
+// LastUserInput returns the index and content of the most recent message the
+// user sent. File watcher notifications are stored as user messages but skipped.
+func (s *Session) LastUserInput() (int, string, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for i := len(s.Messages) - 1; i >= 0; i-- {
+		msg := s.Messages[i]
+		if msg.Role == RoleUser && !strings.HasPrefix(msg.Content, FileWatcherPrefix) {
+			return i, msg.Content, true
+		}
+	}
+
+	return -1, "", false
+}
+
+// TruncateMessages drops the message at index and every message after it
+func (s *Session) TruncateMessages(index int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if index < 0 || index >= len(s.Messages) {
+		return
+	}
+	s.Messages = s.Messages[:index]
+	s.recountTokens()
+	s.llmCacheDirty = true
+	s.cachedLLMMessages = nil
+}
@@ -503,3 +503,31 @@ func containsHelper(s, substr string) bool {
 	}
 	return false
 }
+
+func TestLastUserInput_SkipsWatcherAndTruncates(t *testing.T) {
+	s := NewSession("retry", "")
+	if _, _, ok := s.LastUserInput(); ok {
+		t.Fatal("empty session should have no user input")
+	}
+
+	s.AddUserMessage("first")
+	s.AddAssistantMessage("done")
+	s.AddUserMessage("second")
+	s.AddToolCall([]ToolCall{{ID: "1", Type: "function", Function: FunctionCall{Name: "bash", Arguments: `{"command":"false"}`}}})
+	s.AddToolResults([]ToolResult{{Content: "exit status 1", ToolCallID: "1", IsError: true}})
+	s.AddUserMessage("[File Watcher] 以下のファイルが変更されました:\n\n- main.go (modified)\n")
+
+	index, input, ok := s.LastUserInput()
+	if !ok || input != "second" || index != 2 {
+		t.Fatalf("LastUserInput = %d, %q, %v; want 2, second, true", index, input, ok)
+	}
+
+	s.TruncateMessages(index)
+	messages := s.GetMessages()
+	if len(messages) != 2 || messages[1].Content != "done" {
+		t.Errorf("messages after truncate = %+v, want the first exchange only", messages)
+	}
+	if s.GetTokenCount() != EstimateTokens("first")+EstimateTokens("done") {
+		t.Errorf("token estimate should be recounted, got %d", s.GetTokenCount())
+	}
+}
@@ -0,0 +1,128 @@
+package session
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Snapshot is an in-memory copy of a session's conversation state
+type Snapshot struct {
+	Name          string
+	Messages      []Message
+	TokenEstimate int
+	CreatedAt     time.Time
+	seq           int // order within the store
+}
+
+// Snapshot captures the current message list and token state
+func (s *Session) Snapshot(name string) *Snapshot {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return &Snapshot{
+		Name:          name,
+		Messages:      copyMessages(s.Messages),
+		TokenEstimate: s.TokenEstimate,
+		CreatedAt:     time.Now(),
+	}
+}
+
+// Restore replaces the message list and token state with the snapshot's.
+// The system prompt is kept as-is.
+func (s *Session) Restore(snap *Snapshot) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.Messages = copyMessages(snap.Messages)
+	s.TokenEstimate = snap.TokenEstimate
+	s.llmCacheDirty = true
+	s.cachedLLMMessages = nil
+}
+
+// copyMessages deep-copies messages so snapshots don't share tool call slices
+func copyMessages(messages []Message) []Message {
+	out := make([]Message, len(messages))
+	for i, msg := range messages {
+		out[i] = msg
+		if msg.ToolCalls != nil {
+			out[i].ToolCalls = append([]ToolCall(nil), msg.ToolCalls...)
+		}
+	}
+	return out
+}
+
+// SnapshotStore holds named snapshots for the current run (not persisted)
+type SnapshotStore struct {
+	snapshots map[string]*Snapshot
+	counter   int
+	mu        sync.Mutex
+}
+
+// NewSnapshotStore creates an empty snapshot store
+func NewSnapshotStore() *SnapshotStore {
+	return &SnapshotStore{
+		snapshots: make(map[string]*Snapshot),
+	}
+}
+
+// Save stores a snapshot of sess under name, overwriting any existing one.
+// An empty name is replaced by "snap-N".
+func (st *SnapshotStore) Save(sess *Session, name string) *Snapshot {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	st.counter++
+	if name == "" {
+		name = fmt.Sprintf("snap-%d", st.counter)
+	}
+
+	snap := sess.Snapshot(name)
+	snap.seq = st.counter
+	st.snapshots[name] = snap
+	return snap
+}
+
+// Get returns the named snapshot. An empty name returns the most recent one.
+func (st *SnapshotStore) Get(name string) (*Snapshot, bool) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	if name != "" {
+		snap, ok := st.snapshots[name]
+		return snap, ok
+	}
+
+	var latest *Snapshot
+	for _, snap := range st.snapshots {
+		if latest == nil || snap.seq > latest.seq {
+			latest = snap
+		}
+	}
+	return latest, latest != nil
+}
+
+// List returns all snapshots in the order they were saved
+func (st *SnapshotStore) List() []*Snapshot {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	list := make([]*Snapshot, 0, len(st.snapshots))
+	for _, snap := range st.snapshots {
+		list = append(list, snap)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].seq < list[j].seq
+	})
+	return list
+}
+
+// Clear drops all snapshots
+func (st *SnapshotStore) Clear() {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	st.snapshots = make(map[string]*Snapshot)
+	st.counter = 0
+}
@@ -0,0 +1,80 @@
+package session
+
+import "testing"
+
+func TestSnapshotRestore(t *testing.T) {
+	sess := NewSession("snap", "system")
+	sess.AddUserMessage("first question")
+	sess.AddAssistantMessage("first answer")
+	sess.AddToolCall([]ToolCall{{ID: "call_1", Type: "function", Function: FunctionCall{Name: "read_file", Arguments: `{"path":"a.go"}`}}})
+
+	store := NewSnapshotStore()
+	snap := store.Save(sess, "before")
+	wantCount := sess.GetMessageCount()
+	wantTokens := sess.GetTokenCount()
+
+	// 変更を加える
+	sess.AddUserMessage("experiment")
+	sess.AddAssistantMessage("experimental answer")
+	sess.Messages[2].ToolCalls[0].Function.Name = "mutated"
+
+	restored, ok := store.Get("before")
+	if !ok || restored != snap {
+		t.Fatal("expected snapshot 'before' to be stored")
+	}
+	sess.Restore(restored)
+
+	messages := sess.GetMessages()
+	if len(messages) != wantCount {
+		t.Fatalf("message count = %d, want %d", len(messages), wantCount)
+	}
+	if messages[0].Content != "first question" || messages[1].Content != "first answer" {
+		t.Errorf("unexpected messages after restore: %+v", messages)
+	}
+	if messages[2].ToolCalls[0].Function.Name != "read_file" {
+		t.Errorf("tool call should be restored from snapshot, got %q", messages[2].ToolCalls[0].Function.Name)
+	}
+	if sess.GetTokenCount() != wantTokens {
+		t.Errorf("token count = %d, want %d", sess.GetTokenCount(), wantTokens)
+	}
+	if llm := sess.GetMessagesForLLM(); len(llm) != wantCount+1 {
+		t.Errorf("LLM messages = %d, want %d (system + restored)", len(llm), wantCount+1)
+	}
+
+	// 復元後の変更はスナップショットに影響しない
+	sess.AddUserMessage("after restore")
+	if len(snap.Messages) != wantCount {
+		t.Errorf("snapshot modified by later changes: %d messages", len(snap.Messages))
+	}
+}
+
+func TestSnapshotStore(t *testing.T) {
+	sess := NewSession("snap", "")
+	store := NewSnapshotStore()
+
+	if _, ok := store.Get(""); ok {
+		t.Error("empty store should have no latest snapshot")
+	}
+
+	first := store.Save(sess, "")
+	if first.Name != "snap-1" {
+		t.Errorf("auto name = %q, want snap-1", first.Name)
+	}
+	sess.AddUserMessage("hello")
+	store.Save(sess, "named")
+
+	latest, ok := store.Get("")
+	if !ok || latest.Name != "named" {
+		t.Errorf("latest = %+v, want 'named'", latest)
+	}
+
+	list := store.List()
+	if len(list) != 2 || list[0].Name != "snap-1" || list[1].Name != "named" {
+		t.Errorf("unexpected list order: %v", list)
+	}
+
+	store.Clear()
+	if len(store.List()) != 0 {
+		t.Error("expected no snapshots after Clear")
+	}
+}
@@ -0,0 +1,58 @@
+package session
+
+// TraceEntry is one tool call of a turn paired with its result
+type TraceEntry struct {
+	Name      string
+	Arguments string
+	Result    string // Content of the tool message ("" while HasResult is false)
+	HasResult bool
+	IsError   bool
+}
+
+// LastTurnTrace returns the tool calls of the current (or last) turn in the
+// order they were made. A turn starts at the most recent user message; if that
+// turn made no tool calls, the latest earlier turn that did is returned.
+func (s *Session) LastTurnTrace() []TraceEntry {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	end := len(s.Messages)
+	for end > 0 {
+		start := end - 1
+		for start >= 0 && s.Messages[start].Role != RoleUser {
+			start--
+		}
+		if trace := buildTrace(s.Messages[start+1 : end]); len(trace) > 0 {
+			return trace
+		}
+		end = start
+	}
+	return nil
+}
+
+// buildTrace pairs the tool calls in messages with their tool results by ID
+func buildTrace(messages []Message) []TraceEntry {
+	var trace []TraceEntry
+	index := make(map[string]int)
+	for _, msg := range messages {
+		switch {
+		case msg.Role == RoleAssistant && len(msg.ToolCalls) > 0:
+			for _, tc := range msg.ToolCalls {
+				index[tc.ID] = len(trace)
+				trace = append(trace, TraceEntry{
+					Name:      tc.Function.Name,
+					Arguments: tc.Function.Arguments,
+				})
+			}
+		case msg.Role == RoleTool:
+			i, ok := index[msg.ToolID]
+			if !ok || trace[i].HasResult {
+				continue
+			}
+			trace[i].Result = msg.Content
+			trace[i].HasResult = true
+			trace[i].IsError = msg.IsError
+		}
+	}
+	return trace
+}
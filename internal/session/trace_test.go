@@ -0,0 +1,66 @@
+package session
+
+import (
+	"testing"
+)
+
+func traceCall(id, name, args string) ToolCall {
+	return ToolCall{ID: id, Type: "function", Function: FunctionCall{Name: name, Arguments: args}}
+}
+
+func TestLastTurnTrace_OrderAndResults(t *testing.T) {
+	s := NewSession("trace", "")
+	s.AddUserMessage("first")
+	s.AddToolCall([]ToolCall{traceCall("old", "glob", `{"pattern":"*.go"}`)})
+	s.AddToolResults([]ToolResult{{Content: "main.go", ToolCallID: "old"}})
+
+	s.AddUserMessage("second")
+	s.AddToolCall([]ToolCall{
+		traceCall("a", "read_file", `{"path":"a.go"}`),
+		traceCall("b", "bash", `{"command":"false"}`),
+	})
+	s.AddToolResults([]ToolResult{
+		{Content: "package a", ToolCallID: "a"},
+		{Content: "exit status 1", ToolCallID: "b", IsError: true},
+	})
+	s.AddToolCall([]ToolCall{traceCall("c", "write_file", `{"path":"b.go"}`)})
+
+	trace := s.LastTurnTrace()
+	if len(trace) != 3 {
+		t.Fatalf("len(trace) = %d, want 3: %+v", len(trace), trace)
+	}
+
+	want := []TraceEntry{
+		{Name: "read_file", Arguments: `{"path":"a.go"}`, Result: "package a", HasResult: true},
+		{Name: "bash", Arguments: `{"command":"false"}`, Result: "exit status 1", HasResult: true, IsError: true},
+		{Name: "write_file", Arguments: `{"path":"b.go"}`},
+	}
+	for i := range want {
+		if trace[i] != want[i] {
+			t.Errorf("trace[%d] = %+v, want %+v", i, trace[i], want[i])
+		}
+	}
+}
+
+func TestLastTurnTrace_FallsBackToLastTurnWithTools(t *testing.T) {
+	s := NewSession("trace", "")
+	s.AddUserMessage("do it")
+	s.AddToolCall([]ToolCall{traceCall("x", "grep", `{"pattern":"TODO"}`)})
+	s.AddToolResults([]ToolResult{{Content: "a.go:1: TODO", ToolCallID: "x"}})
+	s.AddAssistantMessage("done")
+	s.AddUserMessage("thanks")
+	s.AddAssistantMessage("you're welcome")
+
+	trace := s.LastTurnTrace()
+	if len(trace) != 1 || trace[0].Name != "grep" || !trace[0].HasResult {
+		t.Errorf("trace = %+v, want the grep call of the earlier turn", trace)
+	}
+}
+
+func TestLastTurnTrace_Empty(t *testing.T) {
+	s := NewSession("trace", "")
+	s.AddUserMessage("hello")
+	if trace := s.LastTurnTrace(); trace != nil {
+		t.Errorf("trace = %+v, want nil", trace)
+	}
+}
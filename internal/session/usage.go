@@ -0,0 +1,75 @@
+package session
+
+// ModelUsage is the token usage accumulated for one provider/model pair
+type ModelUsage struct {
+	Provider         string `json:"provider,omitempty"`
+	Model            string `json:"model"`
+	Cloud            bool   `json:"cloud,omitempty"` // Served by a cloud provider (counts against BudgetTokens)
+	PromptTokens     int    `json:"prompt_tokens"`
+	CompletionTokens int    `json:"completion_tokens"`
+	Calls            int    `json:"calls"`
+}
+
+// Total returns prompt plus completion tokens
+func (u ModelUsage) Total() int {
+	return u.PromptTokens + u.CompletionTokens
+}
+
+// AddTokenUsage records the tokens of one LLM call made with provider/model;
+// cloud marks calls served by a cloud provider.
+// Usage survives /clear and compaction since the tokens were already spent.
+func (s *Session) AddTokenUsage(provider, model string, cloud bool, promptTokens, completionTokens int) {
+	if promptTokens <= 0 && completionTokens <= 0 {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.Usage {
+		if s.Usage[i].Provider == provider && s.Usage[i].Model == model {
+			s.Usage[i].PromptTokens += promptTokens
+			s.Usage[i].CompletionTokens += completionTokens
+			s.Usage[i].Calls++
+			return
+		}
+	}
+	s.Usage = append(s.Usage, ModelUsage{
+		Provider:         provider,
+		Model:            model,
+		Cloud:            cloud,
+		PromptTokens:     promptTokens,
+		CompletionTokens: completionTokens,
+		Calls:            1,
+	})
+}
+
+// GetTokenUsage returns a copy of the per-model usage in first-use order
+func (s *Session) GetTokenUsage() []ModelUsage {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return append([]ModelUsage(nil), s.Usage...)
+}
+
+// SetTokenUsage replaces the per-model usage (used when resuming a session)
+func (s *Session) SetTokenUsage(usage []ModelUsage) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.Usage = append([]ModelUsage(nil), usage...)
+}
+
+// TotalTokenUsage sums the usage of all models
+func (s *Session) TotalTokenUsage() ModelUsage {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var total ModelUsage
+	for _, u := range s.Usage {
+		total.PromptTokens += u.PromptTokens
+		total.CompletionTokens += u.CompletionTokens
+		total.Calls += u.Calls
+	}
+	return total
+}
@@ -0,0 +1,54 @@
+package session
+
+import (
+	"testing"
+)
+
+func TestAddTokenUsage_AccumulatesPerModel(t *testing.T) {
+	s := NewSession("test", "system")
+	s.AddTokenUsage("anthropic", "claude-sonnet-4-20250514", true, 100, 10)
+	s.AddTokenUsage("ollama", "qwen3:8b", false, 50, 5)
+	s.AddTokenUsage("anthropic", "claude-sonnet-4-20250514", true, 200, 20)
+	s.AddTokenUsage("anthropic", "claude-sonnet-4-20250514", true, 0, 0) // Providers that report no usage
+
+	usage := s.GetTokenUsage()
+	if len(usage) != 2 {
+		t.Fatalf("usage = %+v, want 2 models", usage)
+	}
+	if u := usage[0]; u.Model != "claude-sonnet-4-20250514" || u.PromptTokens != 300 || u.CompletionTokens != 30 || u.Calls != 2 {
+		t.Errorf("usage[0] = %+v", u)
+	}
+	if u := usage[1]; u.Provider != "ollama" || u.Total() != 55 || u.Calls != 1 {
+		t.Errorf("usage[1] = %+v", u)
+	}
+
+	total := s.TotalTokenUsage()
+	if total.PromptTokens != 350 || total.CompletionTokens != 35 || total.Calls != 3 {
+		t.Errorf("TotalTokenUsage() = %+v", total)
+	}
+}
+
+func TestTokenUsage_SurvivesClearAndPersistence(t *testing.T) {
+	s := NewSession("test", "system")
+	s.AddUserMessage("hello")
+	s.AddTokenUsage("openai", "gpt-4.1", true, 1000, 100)
+	s.Clear()
+
+	data, err := s.ToJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded := NewSession("", "")
+	if err := loaded.FromJSON(data); err != nil {
+		t.Fatal(err)
+	}
+	if total := loaded.TotalTokenUsage(); total.Total() != 1100 {
+		t.Errorf("usage after Clear and reload = %+v, want 1100 tokens", total)
+	}
+
+	clone := loaded.Clone()
+	clone.AddTokenUsage("openai", "gpt-4.1", true, 1, 1)
+	if loaded.TotalTokenUsage().Total() != 1100 {
+		t.Error("Clone should not share usage with the original")
+	}
+}
@@ -0,0 +1,130 @@
+package skill
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+const (
+	// MinMatchScore 自動適用に必要な最低スコア（名前の一致は 2 点、説明の語は 1 点）
+	MinMatchScore = 2
+	// MaxSkillBodySize 会話に注入する SKILL.md 本文の上限（バイト）
+	MaxSkillBodySize = 16 * 1024
+)
+
+// stopWords どのスキルにも現れうるため一致判定に使わない英単語
+var stopWords = map[string]bool{
+	"the": true, "and": true, "for": true, "with": true, "this": true, "that": true,
+	"from": true, "into": true, "use": true, "using": true, "when": true, "your": true,
+	"you": true, "are": true, "can": true, "how": true, "what": true, "will": true,
+	"file": true, "files": true, "skill": true, "please": true, "make": true,
+}
+
+// MatchSkills ユーザー入力とスキル名・説明の重なりでスキルをスコア付けし、
+// MinMatchScore 以上のものをスコアの高い順に返す（同点は読み込み順）
+func (sm *SkillManager) MatchSkills(userInput string) []*SkillMeta {
+	input := keywordSet(userInput)
+	if len(input) == 0 {
+		return nil
+	}
+
+	type scored struct {
+		meta  *SkillMeta
+		score int
+	}
+	var matches []scored
+	for _, s := range sm.skills {
+		if score := matchScore(s, input); score >= MinMatchScore {
+			matches = append(matches, scored{s, score})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].score > matches[j].score
+	})
+
+	result := make([]*SkillMeta, len(matches))
+	for i, m := range matches {
+		result[i] = m.meta
+	}
+	return result
+}
+
+// matchScore スキル 1 件のスコアを計算
+func matchScore(s *SkillMeta, input map[string]bool) int {
+	score := 0
+	seen := make(map[string]bool)
+	for kw := range keywordSet(strings.NewReplacer("-", " ", "_", " ").Replace(s.Name)) {
+		seen[kw] = true
+		if input[kw] {
+			score += 2
+		}
+	}
+	for kw := range keywordSet(s.Description) {
+		if !seen[kw] && input[kw] {
+			score++
+		}
+	}
+	return score
+}
+
+// keywordSet テキストを一致判定用のキーワード集合に分解する
+// 英数字は 3 文字以上の単語（ストップワード除く）、漢字・カタカナは 2 文字ずつの bigram
+// （ひらがなは助詞・語尾が多く誤一致の元になるため区切りとして扱う）
+func keywordSet(text string) map[string]bool {
+	set := make(map[string]bool)
+	var word, cjk []rune
+
+	flushWord := func() {
+		if w := string(word); len(word) >= 3 && !stopWords[w] {
+			set[w] = true
+		}
+		word = word[:0]
+	}
+	flushCJK := func() {
+		for i := 0; i+1 < len(cjk); i++ {
+			set[string(cjk[i:i+2])] = true
+		}
+		cjk = cjk[:0]
+	}
+
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			flushCJK()
+			word = append(word, r)
+		case r >= unicode.MaxASCII && unicode.IsLetter(r) && !unicode.Is(unicode.Hiragana, r):
+			flushWord()
+			cjk = append(cjk, r)
+		default:
+			flushWord()
+			flushCJK()
+		}
+	}
+	flushWord()
+	flushCJK()
+	return set
+}
+
+// LoadBody SKILL.md から frontmatter を除いた本文を読み込む
+// MaxSkillBodySize を超える部分は切り詰める
+func (s *SkillMeta) LoadBody() (string, error) {
+	data, err := os.ReadFile(s.SkillFile)
+	if err != nil {
+		return "", fmt.Errorf("スキル %s の読み込みエラー: %w", s.Name, err)
+	}
+
+	body := strings.TrimSpace(string(data))
+	if strings.HasPrefix(body, "---") {
+		if end := strings.Index(body[3:], "---"); end != -1 {
+			body = strings.TrimSpace(body[3+end+3:])
+		}
+	}
+
+	if len(body) > MaxSkillBodySize {
+		body = strings.ToValidUTF8(body[:MaxSkillBodySize], "") + "\n...(truncated)"
+	}
+	return body, nil
+}
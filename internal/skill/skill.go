@@ -11,7 +11,7 @@ import (
 type SkillSource string
 
 const (
-	// SourceGlobal グローバルスキル (~/.config/vibe-local-go/skills/)
+	// SourceGlobal グローバルスキル (~/.config/vibe-local-go/skills/ または $VIBE_CONFIG_DIR/skills/)
 	SourceGlobal SkillSource = "global"
 	// SourceProject プロジェクトスキル (.vibe-local/skills/)
 	SourceProject SkillSource = "project"
@@ -190,6 +190,21 @@ func (sm *SkillManager) GetSkillByName(name string) *SkillMeta {
 	return nil
 }
 
+// Lookup 名前でスキルを検索し、見つからなければ利用可能な名前を含むエラーを返す
+func (sm *SkillManager) Lookup(name string) (*SkillMeta, error) {
+	if s := sm.GetSkillByName(name); s != nil {
+		return s, nil
+	}
+	if len(sm.skills) == 0 {
+		return nil, fmt.Errorf("スキル %q が見つかりません（スキルが 1 件も読み込まれていません）", name)
+	}
+	names := make([]string, len(sm.skills))
+	for i, s := range sm.skills {
+		names[i] = s.Name
+	}
+	return nil, fmt.Errorf("スキル %q が見つかりません（利用可能: %s）", name, strings.Join(names, ", "))
+}
+
 // GetSkillMetadata システムプロンプトに注入するメタデータ文字列を生成
 func (sm *SkillManager) GetSkillMetadata() string {
 	if len(sm.skills) == 0 {
@@ -214,6 +229,11 @@ func (sm *SkillManager) GetSkillMetadata() string {
 	return sb.String()
 }
 
+// SetGlobalDir グローバルスキルディレクトリを変更する（VIBE_CONFIG_DIR 用、LoadSkills の前に呼ぶ）
+func (sm *SkillManager) SetGlobalDir(dir string) {
+	sm.globalDir = dir
+}
+
 // GlobalDir グローバルスキルディレクトリのパスを返す
 func (sm *SkillManager) GlobalDir() string {
 	return sm.globalDir
@@ -0,0 +1,135 @@
+package skill
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeSkill(t *testing.T, root, dir, content string) {
+	t.Helper()
+	skillDir := filepath.Join(root, dir)
+	if err := os.MkdirAll(skillDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(skillDir, "SKILL.md"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func newTestManager(t *testing.T) *SkillManager {
+	t.Helper()
+	root := t.TempDir()
+	writeSkill(t, root, "pdf-report", "---\nname: pdf-report\ndescription: Generate PDF reports from markdown tables\n---\n# PDF Report\n\nUse pandoc.\n")
+	writeSkill(t, root, "docker-deploy", "---\nname: docker-deploy\ndescription: Build Docker images and deploy containers\n---\nRun docker build first.\n")
+	writeSkill(t, root, "jp-test", "---\nname: jp-test\ndescription: 単体テストの作成手順\n---\nテストは table driven で書く\n")
+
+	sm := &SkillManager{globalDir: filepath.Join(root, "missing"), projectDir: root}
+	if err := sm.LoadSkills(); err != nil {
+		t.Fatalf("LoadSkills: %v", err)
+	}
+	if sm.Count() != 3 {
+		t.Fatalf("Count = %d, want 3", sm.Count())
+	}
+	return sm
+}
+
+func TestMatchSkills_Matching(t *testing.T) {
+	sm := newTestManager(t)
+
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"Can you turn this table into a PDF report?", "pdf-report"},
+		{"deploy the api with docker", "docker-deploy"},
+		{"build the Docker images and push the containers", "docker-deploy"},
+		{"この関数の単体テストを書いて", "jp-test"},
+	}
+	for _, tt := range tests {
+		matches := sm.MatchSkills(tt.input)
+		if len(matches) == 0 || matches[0].Name != tt.want {
+			t.Errorf("MatchSkills(%q) = %v, want %s first", tt.input, names(matches), tt.want)
+		}
+	}
+}
+
+func TestMatchSkills_NoMatch(t *testing.T) {
+	sm := newTestManager(t)
+
+	for _, input := range []string{
+		"",
+		"hello",
+		"fix the typo in the README",
+		"Please use this file", // Stop words only
+		"generate a haiku",     // One description word stays below the threshold
+		"今日の天気は？",
+	} {
+		if matches := sm.MatchSkills(input); len(matches) != 0 {
+			t.Errorf("MatchSkills(%q) = %v, want no match", input, names(matches))
+		}
+	}
+}
+
+func TestLoadBody_StripsFrontmatter(t *testing.T) {
+	sm := newTestManager(t)
+
+	body, err := sm.GetSkillByName("pdf-report").LoadBody()
+	if err != nil {
+		t.Fatalf("LoadBody: %v", err)
+	}
+	if body != "# PDF Report\n\nUse pandoc." {
+		t.Errorf("body = %q", body)
+	}
+	if strings.Contains(body, "description:") {
+		t.Error("frontmatter should be stripped")
+	}
+}
+
+func names(skills []*SkillMeta) []string {
+	var out []string
+	for _, s := range skills {
+		out = append(out, s.Name)
+	}
+	return out
+}
+
+func TestLookup(t *testing.T) {
+	sm := newTestManager(t)
+
+	s, err := sm.Lookup("docker-deploy")
+	if err != nil || s.Name != "docker-deploy" {
+		t.Fatalf("Lookup(docker-deploy) = %v, %v", s, err)
+	}
+
+	_, err = sm.Lookup("nope")
+	if err == nil {
+		t.Fatal("expected an error for an unknown skill")
+	}
+	for _, name := range []string{"nope", "pdf-report", "docker-deploy", "jp-test"} {
+		if !strings.Contains(err.Error(), name) {
+			t.Errorf("error %q should mention %s", err, name)
+		}
+	}
+
+	empty := &SkillManager{}
+	if _, err := empty.Lookup("any"); err == nil {
+		t.Error("expected an error when no skills are loaded")
+	}
+}
+
+func TestSetGlobalDir(t *testing.T) {
+	global := t.TempDir()
+	writeSkill(t, global, "relocated", "---\nname: relocated\ndescription: Loaded from VIBE_CONFIG_DIR\n---\nbody\n")
+
+	sm := NewSkillManager()
+	sm.SetGlobalDir(global)
+	sm.projectDir = filepath.Join(global, "missing")
+	if err := sm.LoadSkills(); err != nil {
+		t.Fatalf("LoadSkills: %v", err)
+	}
+	if sm.Count() != 1 || sm.GlobalDir() != global {
+		t.Errorf("Count = %d, GlobalDir = %q; want the skill from %s", sm.Count(), sm.GlobalDir(), global)
+	}
+}
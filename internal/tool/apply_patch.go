@@ -0,0 +1,553 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/zephel01/vibe-local-go/internal/security"
+)
+
+// MaxPatchErrorLines is the number of expected lines shown when a hunk doesn't apply
+const MaxPatchErrorLines = 8
+
+// hunkHeaderPattern matches "@@ -start[,count] +start[,count] @@"
+var hunkHeaderPattern = regexp.MustCompile(`^@@ -(\d+)(?:,\d+)? \+(\d+)(?:,\d+)? @@`)
+
+// ApplyPatchTool applies a unified diff that may touch several files.
+// Either every file is changed or none is
+type ApplyPatchTool struct {
+	writeTool  *WriteTool
+	sandbox    SandboxStager
+	validator  *security.PathValidator
+	vibeIgnore *IgnoreMatcher
+}
+
+// NewApplyPatchTool creates a new apply_patch tool
+func NewApplyPatchTool() *ApplyPatchTool {
+	return &ApplyPatchTool{
+		writeTool: NewWriteTool(),
+	}
+}
+
+// SetWriteTool は undo スタックを共有する WriteTool を設定する（パッチ全体を1回の /undo で取り消せる）
+func (t *ApplyPatchTool) SetWriteTool(wt *WriteTool) {
+	t.writeTool = wt
+}
+
+// SetSandbox はサンドボックスマネージャーを設定する
+func (t *ApplyPatchTool) SetSandbox(sb SandboxStager) {
+	t.sandbox = sb
+}
+
+// SetPathValidator は変更対象を検証するバリデーターを設定する（作業ディレクトリ外の変更を拒否）
+func (t *ApplyPatchTool) SetPathValidator(v *security.PathValidator) {
+	t.validator = v
+}
+
+// SetVibeIgnore は .vibeignore のマッチャーを設定する（該当パスの変更を拒否、nil = チェックなし）
+func (t *ApplyPatchTool) SetVibeIgnore(m *IgnoreMatcher) {
+	t.vibeIgnore = m
+}
+
+// Name returns the tool name
+func (t *ApplyPatchTool) Name() string {
+	return "apply_patch"
+}
+
+// Schema returns the tool schema
+func (t *ApplyPatchTool) Schema() *FunctionSchema {
+	return &FunctionSchema{
+		Name: "apply_patch",
+		Description: "Apply a unified diff (as produced by `diff -u` or `git diff`) to one or more files. " +
+			"Use --- /dev/null to create a file and +++ /dev/null to delete one. Context lines are matched " +
+			"near the hunk's line number, tolerating line drift and whitespace differences. " +
+			"If any hunk fails, no file is changed",
+		Parameters: &ParameterSchema{
+			Type: "object",
+			Properties: map[string]*PropertyDef{
+				"patch": {
+					Type:        "string",
+					Description: "The unified diff to apply",
+				},
+			},
+			Required: []string{"patch"},
+		},
+	}
+}
+
+// patchHunk is one @@ section of a file diff
+type patchHunk struct {
+	header   string
+	oldStart int      // 1-based line number from the header (0 when absent)
+	old      []string // Context and removed lines
+	new      []string // Context and added lines
+	context  []int    // For each new line, its index in old if it is a context line, else -1
+	noEOL    bool     // The new side ends without a trailing newline
+	added    int
+	removed  int
+}
+
+// patchFile is the diff of one file
+type patchFile struct {
+	oldPath string // "" for /dev/null (file is created)
+	newPath string // "" for /dev/null (file is deleted)
+	hunks   []patchHunk
+}
+
+// path returns the file the diff changes
+func (f *patchFile) path() string {
+	if f.newPath != "" {
+		return f.newPath
+	}
+	return f.oldPath
+}
+
+// patchChange is the planned result of a patch for one file
+type patchChange struct {
+	path         string // Path as written in the patch
+	resolvedPath string
+	oldContent   string
+	newContent   string
+	existed      bool
+	deleted      bool
+	added        int
+	removed      int
+	notes        []string
+}
+
+// Execute applies the patch to every file it names, or to none
+func (t *ApplyPatchTool) Execute(ctx context.Context, params json.RawMessage) (*Result, error) {
+	var args struct {
+		Patch string `json:"patch"`
+	}
+	if err := json.Unmarshal(params, &args); err != nil {
+		return NewErrorResult(err), nil
+	}
+
+	// Hold the change lock from reading the old contents until the undo entry is recorded
+	defer t.writeTool.lockChanges()()
+
+	changes, err := t.plan(args.Patch)
+	if err != nil {
+		return NewErrorResult(fmt.Errorf("%v\n(patch not applied; no files were changed)", err)), nil
+	}
+
+	// サンドボックスモードの場合はステージングにリダイレクト
+	if t.sandbox != nil && t.sandbox.IsEnabled() {
+		for _, c := range changes {
+			if c.deleted {
+				return NewErrorResult(fmt.Errorf("cannot delete %s in sandbox mode (patch not applied)", c.path)), nil
+			}
+		}
+		for _, c := range changes {
+			if err := t.sandbox.Stage(c.resolvedPath, []byte(c.newContent)); err != nil {
+				return NewErrorResult(fmt.Errorf("sandbox staging failed: %w", err)), nil
+			}
+		}
+		return NewResult("[sandbox] Staged patch (use /commit to apply, /diff to review)\n" + patchSummary(changes)), nil
+	}
+
+	entries := make([]UndoEntry, 0, len(changes))
+	changed := make([]string, 0, len(changes))
+	for _, c := range changes {
+		entry := UndoEntry{
+			Path:       c.resolvedPath,
+			OldContent: c.oldContent,
+			NewContent: c.newContent,
+			Deleted:    c.deleted,
+		}
+		if err := applyEntry(entry); err != nil {
+			// Restore the files already written so the patch stays all-or-nothing
+			for i := len(entries) - 1; i >= 0; i-- {
+				revertEntry(entries[i])
+			}
+			return NewErrorResult(fmt.Errorf("failed to write %s: %v (patch not applied; changed files were restored)", c.path, err)), nil
+		}
+		entries = append(entries, entry)
+		changed = append(changed, c.resolvedPath)
+	}
+
+	// パッチ全体を1つの undo エントリとして記録
+	if len(entries) == 1 {
+		t.writeTool.addToUndoStack(entries[0])
+	} else {
+		t.writeTool.addToUndoStack(UndoEntry{Path: entries[0].Path, Files: entries})
+	}
+
+	return NewResult("Applied patch\n" + patchSummary(changes)).WithChangedPaths(changed...), nil
+}
+
+// Preview checks that the patch applies and returns it for the permission prompt
+func (t *ApplyPatchTool) Preview(params json.RawMessage) (string, error) {
+	var args struct {
+		Patch string `json:"patch"`
+	}
+	if err := json.Unmarshal(params, &args); err != nil {
+		return "", err
+	}
+	if _, err := t.plan(args.Patch); err != nil {
+		return "", err
+	}
+
+	lines := strings.Split(strings.TrimRight(args.Patch, "\n"), "\n")
+	if len(lines) > MaxDiffLines {
+		return strings.Join(lines[:MaxDiffLines], "\n") + fmt.Sprintf("\n... (truncated, showing first %d of %d lines)\n", MaxDiffLines, len(lines)), nil
+	}
+	return strings.Join(lines, "\n") + "\n", nil
+}
+
+// plan parses the patch and computes every file's new content without writing anything
+func (t *ApplyPatchTool) plan(patch string) ([]*patchChange, error) {
+	files, err := parsePatch(patch)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []*patchChange
+	byPath := make(map[string]*patchChange)
+	for _, f := range files {
+		if f.oldPath != "" && f.newPath != "" && f.oldPath != f.newPath {
+			return nil, fmt.Errorf("%s → %s: renames are not supported; delete and create the file instead", f.oldPath, f.newPath)
+		}
+
+		resolvedPath, err := resolvePath(f.path())
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", f.path(), err)
+		}
+		if err := checkWorkdir(t.validator, resolvedPath); err != nil {
+			return nil, err
+		}
+		if err := checkVibeIgnoreWrite(t.vibeIgnore, resolvedPath); err != nil {
+			return nil, err
+		}
+		if managedDir := getManagedDirWarning(resolvedPath); managedDir != "" {
+			return nil, fmt.Errorf("cannot edit files in managed directory %s: %s", managedDir, f.path())
+		}
+
+		// A file may appear more than once; later diffs apply on top of earlier ones
+		c, seen := byPath[resolvedPath]
+		if !seen {
+			c = &patchChange{path: f.path(), resolvedPath: resolvedPath}
+			content, err := readCurrent(t.sandbox, resolvedPath)
+			switch {
+			case err == nil:
+				c.existed = true
+				c.oldContent = string(content)
+			case !os.IsNotExist(err):
+				return nil, fmt.Errorf("%s: %v", f.path(), err)
+			}
+			if len(content) > MaxEditFileSize {
+				return nil, fmt.Errorf("%s: file too large (%d bytes, max %d)", f.path(), len(content), MaxEditFileSize)
+			}
+			c.newContent = c.oldContent
+			byPath[resolvedPath] = c
+			changes = append(changes, c)
+		}
+
+		exists := (c.existed || seen) && !c.deleted
+		switch {
+		case f.oldPath == "" && exists && c.newContent != "":
+			return nil, fmt.Errorf("%s: the patch creates this file but it already exists", f.path())
+		case f.oldPath != "" && !exists:
+			return nil, fmt.Errorf("%s: file not found", f.path())
+		}
+
+		newContent, notes, err := applyHunks(f.path(), c.newContent, f.hunks)
+		if err != nil {
+			return nil, err
+		}
+		if f.newPath == "" {
+			if strings.TrimSpace(newContent) != "" {
+				return nil, fmt.Errorf("%s: the patch deletes this file but does not remove all of its lines", f.path())
+			}
+			newContent = ""
+		}
+		c.newContent = newContent
+		c.deleted = f.newPath == ""
+		c.notes = append(c.notes, notes...)
+		for _, h := range f.hunks {
+			c.added += h.added
+			c.removed += h.removed
+		}
+	}
+	return changes, nil
+}
+
+// patchSummary lists the changed files and any hunks that needed fuzzy matching
+func patchSummary(changes []*patchChange) string {
+	var b strings.Builder
+	for _, c := range changes {
+		switch {
+		case c.deleted:
+			fmt.Fprintf(&b, "  D %s\n", c.path)
+		case !c.existed:
+			fmt.Fprintf(&b, "  A %s (+%d)\n", c.path, c.added)
+		default:
+			fmt.Fprintf(&b, "  M %s (+%d -%d)\n", c.path, c.added, c.removed)
+		}
+		for _, note := range c.notes {
+			fmt.Fprintf(&b, "    %s\n", note)
+		}
+	}
+	return b.String()
+}
+
+// parsePatch splits a unified diff into per-file hunks. Text outside of file
+// diffs (git's "diff --git"/"index" lines, commentary) is ignored
+func parsePatch(patch string) ([]*patchFile, error) {
+	lines := strings.Split(strings.ReplaceAll(patch, "\r\n", "\n"), "\n")
+
+	var files []*patchFile
+	var current *patchFile
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		switch {
+		case strings.HasPrefix(line, "--- ") && i+1 < len(lines) && strings.HasPrefix(lines[i+1], "+++ "):
+			oldPath, newPath := parsePatchPaths(line[4:], lines[i+1][4:])
+			if oldPath == "" && newPath == "" {
+				return nil, fmt.Errorf("line %d: both sides of the file header are /dev/null", i+1)
+			}
+			current = &patchFile{oldPath: oldPath, newPath: newPath}
+			files = append(files, current)
+			i += 2
+		case strings.HasPrefix(line, "@@"):
+			if current == nil {
+				return nil, fmt.Errorf("line %d: hunk before any ---/+++ file header", i+1)
+			}
+			hunk, next := parseHunk(lines, i)
+			current.hunks = append(current.hunks, hunk)
+			i = next
+		default:
+			i++
+		}
+	}
+
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no file diffs found: expected a unified diff with ---/+++ headers and @@ hunks")
+	}
+	for _, f := range files {
+		if len(f.hunks) == 0 && f.newPath != "" {
+			return nil, fmt.Errorf("%s: no @@ hunks in the diff", f.path())
+		}
+	}
+	return files, nil
+}
+
+// parsePatchPaths returns the file paths of a ---/+++ header pair ("" for /dev/null).
+// git's a/ and b/ prefixes are removed
+func parsePatchPaths(oldField, newField string) (string, string) {
+	clean := func(field string) string {
+		if tab := strings.IndexByte(field, '\t'); tab >= 0 {
+			field = field[:tab] // diff -u appends a timestamp after a tab
+		}
+		field = strings.TrimSpace(field)
+		if field == "/dev/null" {
+			return ""
+		}
+		return field
+	}
+	oldPath, newPath := clean(oldField), clean(newField)
+
+	if (oldPath == "" || strings.HasPrefix(oldPath, "a/")) && (newPath == "" || strings.HasPrefix(newPath, "b/")) {
+		oldPath = strings.TrimPrefix(oldPath, "a/")
+		newPath = strings.TrimPrefix(newPath, "b/")
+	}
+	return oldPath, newPath
+}
+
+// parseHunk reads the hunk starting at lines[start] (its @@ header) and returns
+// it with the index of the first line after it. Line counts in the header are
+// not trusted; the hunk ends at the next header or at a line that isn't part
+// of a hunk
+func parseHunk(lines []string, start int) (patchHunk, int) {
+	hunk := patchHunk{header: lines[start]}
+	if m := hunkHeaderPattern.FindStringSubmatch(lines[start]); m != nil {
+		hunk.oldStart, _ = strconv.Atoi(m[1])
+	}
+
+	end := start + 1
+	for end < len(lines) {
+		line := lines[end]
+		if strings.HasPrefix(line, "@@") || strings.HasPrefix(line, "diff ") ||
+			(strings.HasPrefix(line, "--- ") && end+1 < len(lines) && strings.HasPrefix(lines[end+1], "+++ ")) {
+			break
+		}
+		if line != "" && !strings.ContainsRune(" +-\\", rune(line[0])) {
+			break
+		}
+		end++
+	}
+
+	// Blank lines at the end are the patch's trailing newline, not empty context lines
+	body := lines[start+1 : end]
+	for len(body) > 0 && body[len(body)-1] == "" {
+		body = body[:len(body)-1]
+	}
+
+	last := byte(0)
+	for _, line := range body {
+		if line == "" {
+			line = " " // An empty context line whose leading space was stripped
+		}
+		switch line[0] {
+		case ' ':
+			hunk.context = append(hunk.context, len(hunk.old))
+			hunk.old = append(hunk.old, line[1:])
+			hunk.new = append(hunk.new, line[1:])
+		case '-':
+			hunk.old = append(hunk.old, line[1:])
+			hunk.removed++
+		case '+':
+			hunk.context = append(hunk.context, -1)
+			hunk.new = append(hunk.new, line[1:])
+			hunk.added++
+		case '\\': // "\ No newline at end of file" refers to the line before it
+			if last == ' ' || last == '+' {
+				hunk.noEOL = true
+			}
+		}
+		last = line[0]
+	}
+	return hunk, end
+}
+
+// applyHunks applies hunks in order to content. Each hunk is matched at the
+// closest position to its header's line number after the previous hunk,
+// first exactly, then ignoring trailing and finally surrounding whitespace
+func applyHunks(path, content string, hunks []patchHunk) (string, []string, error) {
+	var lines []string
+	eol := true
+	if content != "" {
+		eol = strings.HasSuffix(content, "\n")
+		lines = strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	}
+
+	var notes []string
+	offset, searchFrom := 0, 0
+	for i, h := range hunks {
+		var pos int
+		if len(h.old) == 0 {
+			// Pure insertion: the header's old start is the line to insert after
+			pos = min(max(h.oldStart+offset, searchFrom), len(lines))
+		} else {
+			hint := h.oldStart - 1 + offset
+			var level int
+			pos, level = findHunk(lines, h.old, hint, searchFrom)
+			if pos < 0 {
+				return "", nil, hunkMismatchError(path, i, len(hunks), h, hint)
+			}
+			switch {
+			case h.oldStart > 0 && pos != hint:
+				notes = append(notes, fmt.Sprintf("hunk %d applied at line %d (offset %+d)%s", i+1, pos+1, pos-hint, matchLevelNote(level)))
+			case level > 0:
+				notes = append(notes, fmt.Sprintf("hunk %d applied at line %d%s", i+1, pos+1, matchLevelNote(level)))
+			}
+			if h.oldStart > 0 {
+				offset += pos - hint
+			}
+		}
+
+		replaced := make([]string, 0, len(lines)-len(h.old)+len(h.new))
+		replaced = append(replaced, lines[:pos]...)
+		// Context lines keep the file's text, so a whitespace-tolerant match doesn't rewrite them
+		for j, line := range h.new {
+			if k := h.context[j]; k >= 0 {
+				line = lines[pos+k]
+			}
+			replaced = append(replaced, line)
+		}
+		replaced = append(replaced, lines[pos+len(h.old):]...)
+		lines = replaced
+
+		offset += len(h.new) - len(h.old)
+		searchFrom = pos + len(h.new)
+		if h.noEOL && searchFrom == len(lines) {
+			eol = false
+		}
+	}
+
+	if len(lines) == 0 {
+		return "", notes, nil
+	}
+	result := strings.Join(lines, "\n")
+	if eol {
+		result += "\n"
+	}
+	return result, notes, nil
+}
+
+// findHunk returns the position of want in lines closest to hint, at or after
+// from, and the match level used (0 exact, 1 trailing whitespace ignored,
+// 2 surrounding whitespace ignored). It returns -1 when there is no match
+func findHunk(lines, want []string, hint, from int) (int, int) {
+	normalizers := []func(string) string{
+		func(s string) string { return s },
+		func(s string) string { return strings.TrimRight(s, " \t") },
+		strings.TrimSpace,
+	}
+	for level, normalize := range normalizers {
+		best := -1
+		for pos := from; pos+len(want) <= len(lines); pos++ {
+			if best >= 0 && abs(pos-hint) >= abs(best-hint) {
+				break // Positions only move further from hint from here on
+			}
+			if linesMatch(lines[pos:pos+len(want)], want, normalize) {
+				best = pos
+			}
+		}
+		if best >= 0 {
+			return best, level
+		}
+	}
+	return -1, 0
+}
+
+// linesMatch reports whether got and want are equal after normalize
+func linesMatch(got, want []string, normalize func(string) string) bool {
+	for i := range want {
+		if normalize(got[i]) != normalize(want[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchLevelNote describes a fuzzy match level for the result notes
+func matchLevelNote(level int) string {
+	if level > 0 {
+		return ", whitespace differences ignored"
+	}
+	return ""
+}
+
+// hunkMismatchError reports a hunk that could not be placed, with the lines it expected
+func hunkMismatchError(path string, index, total int, h patchHunk, hint int) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s: hunk %d of %d (%s) did not match the file", path, index+1, total, strings.TrimSpace(h.header))
+	if h.oldStart > 0 {
+		fmt.Fprintf(&b, " near line %d", hint+1)
+	}
+	b.WriteString("\nExpected these lines (context and removed lines):\n")
+	for i, line := range h.old {
+		if i >= MaxPatchErrorLines {
+			fmt.Fprintf(&b, "  ... (%d more)\n", len(h.old)-i)
+			break
+		}
+		fmt.Fprintf(&b, "  %s\n", line)
+	}
+	b.WriteString("Hint: read the file again and regenerate the hunk from its current content")
+	return fmt.Errorf("%s", b.String())
+}
+
+// abs returns the absolute value of n
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,156 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func runApplyPatch(t *testing.T, tool *ApplyPatchTool, patch string) *Result {
+	t.Helper()
+	params, _ := json.Marshal(map[string]string{"patch": patch})
+	result, err := tool.Execute(context.Background(), params)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	return result
+}
+
+func readTestFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read %s: %v", path, err)
+	}
+	return string(data)
+}
+
+func TestApplyPatchTool_TwoFilePatch(t *testing.T) {
+	dir := t.TempDir()
+	mainPath := filepath.Join(dir, "main.go")
+	newPath := filepath.Join(dir, "util.go")
+	original := "package main\n\nfunc main() {\n\tprintln(\"hi\")\n}\n"
+	if err := os.WriteFile(mainPath, []byte(original), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	patch := "--- a/" + mainPath + "\n" +
+		"+++ b/" + mainPath + "\n" +
+		"@@ -3,3 +3,3 @@\n" +
+		" func main() {\n" +
+		"-\tprintln(\"hi\")\n" +
+		"+\tprintln(greeting())\n" +
+		" }\n" +
+		"--- /dev/null\n" +
+		"+++ b/" + newPath + "\n" +
+		"@@ -0,0 +1,3 @@\n" +
+		"+package main\n" +
+		"+\n" +
+		"+func greeting() string { return \"hi\" }\n"
+
+	tool := NewApplyPatchTool()
+	result := runApplyPatch(t, tool, patch)
+	if result.IsError {
+		t.Fatalf("expected success, got %s", result.Error)
+	}
+	if len(result.ChangedPaths) != 2 {
+		t.Errorf("ChangedPaths = %v, want both patched files", result.ChangedPaths)
+	}
+
+	if got := readTestFile(t, mainPath); got != "package main\n\nfunc main() {\n\tprintln(greeting())\n}\n" {
+		t.Errorf("main.go = %q", got)
+	}
+	if got := readTestFile(t, newPath); got != "package main\n\nfunc greeting() string { return \"hi\" }\n" {
+		t.Errorf("util.go = %q", got)
+	}
+
+	// One /undo reverts the whole patch
+	if err := tool.writeTool.Undo(); err != nil {
+		t.Fatalf("Undo: %v", err)
+	}
+	if got := readTestFile(t, mainPath); got != original {
+		t.Errorf("main.go after undo = %q", got)
+	}
+	if _, err := os.Stat(newPath); !os.IsNotExist(err) {
+		t.Error("util.go should be removed by undo")
+	}
+}
+
+func TestApplyPatchTool_HunkMismatchChangesNothing(t *testing.T) {
+	dir := t.TempDir()
+	first := filepath.Join(dir, "a.txt")
+	second := filepath.Join(dir, "b.txt")
+	os.WriteFile(first, []byte("one\ntwo\nthree\n"), 0644)
+	os.WriteFile(second, []byte("alpha\nbeta\n"), 0644)
+
+	patch := "--- a/" + first + "\n" +
+		"+++ b/" + first + "\n" +
+		"@@ -1,3 +1,3 @@\n" +
+		" one\n" +
+		"-two\n" +
+		"+TWO\n" +
+		" three\n" +
+		"--- a/" + second + "\n" +
+		"+++ b/" + second + "\n" +
+		"@@ -1,2 +1,2 @@\n" +
+		" alpha\n" +
+		"-gamma\n" +
+		"+GAMMA\n"
+
+	result := runApplyPatch(t, NewApplyPatchTool(), patch)
+	if !result.IsError {
+		t.Fatalf("expected the patch to be rejected, got %s", result.Output)
+	}
+	if !strings.Contains(result.Error, "b.txt: hunk 1 of 1") {
+		t.Errorf("error should name the failing hunk, got %s", result.Error)
+	}
+	if got := readTestFile(t, first); got != "one\ntwo\nthree\n" {
+		t.Errorf("a.txt must be unchanged, got %q", got)
+	}
+	if got := readTestFile(t, second); got != "alpha\nbeta\n" {
+		t.Errorf("b.txt must be unchanged, got %q", got)
+	}
+}
+
+func TestApplyPatchTool_FuzzyContextAndDelete(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "drift.txt")
+	doomed := filepath.Join(dir, "old.txt")
+	// Two extra lines at the top and trailing spaces the patch doesn't have
+	os.WriteFile(target, []byte("x\ny\nfirst  \nsecond\nthird\n"), 0644)
+	os.WriteFile(doomed, []byte("bye\n"), 0644)
+
+	patch := "--- a/" + target + "\n" +
+		"+++ b/" + target + "\n" +
+		"@@ -1,3 +1,3 @@\n" +
+		" first\n" +
+		"-second\n" +
+		"+2nd\n" +
+		" third\n" +
+		"--- a/" + doomed + "\n" +
+		"+++ /dev/null\n" +
+		"@@ -1 +0,0 @@\n" +
+		"-bye\n"
+
+	tool := NewApplyPatchTool()
+	result := runApplyPatch(t, tool, patch)
+	if result.IsError {
+		t.Fatalf("expected success, got %s", result.Error)
+	}
+	if got := readTestFile(t, target); got != "x\ny\nfirst  \n2nd\nthird\n" {
+		t.Errorf("drift.txt = %q", got)
+	}
+	if _, err := os.Stat(doomed); !os.IsNotExist(err) {
+		t.Error("old.txt should be deleted")
+	}
+
+	if err := tool.writeTool.Undo(); err != nil {
+		t.Fatalf("Undo: %v", err)
+	}
+	if got := readTestFile(t, doomed); got != "bye\n" {
+		t.Errorf("old.txt should be restored by undo, got %q", got)
+	}
+}
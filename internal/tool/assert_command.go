@@ -0,0 +1,180 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+// MaxAssertOutputLength caps the command output shown when an assertion fails
+const MaxAssertOutputLength = 2000
+
+// AssertCommandTool runs a command and checks its exit code and output,
+// returning a PASS/FAIL verdict instead of the raw output
+type AssertCommandTool struct {
+	bash *BashTool
+}
+
+// NewAssertCommandTool creates a new assert_command tool.
+// Commands run through bash so its no-network mode and venv settings apply
+func NewAssertCommandTool(bash *BashTool) *AssertCommandTool {
+	if bash == nil {
+		bash = NewBashTool()
+	}
+	return &AssertCommandTool{bash: bash}
+}
+
+// Name returns the tool name
+func (t *AssertCommandTool) Name() string {
+	return "assert_command"
+}
+
+// Dependencies returns the external prerequisites checked by Registry.Preflight
+func (t *AssertCommandTool) Dependencies() []Dependency {
+	return t.bash.Dependencies()
+}
+
+// Schema returns the tool schema
+func (t *AssertCommandTool) Schema() *FunctionSchema {
+	return &FunctionSchema{
+		Name:        "assert_command",
+		Description: "Run a shell command and check its result. Returns PASS, or FAIL with the reasons and the tail of the output. Use this to verify work (e.g. run the tests and expect exit code 0, or run a script and expect some text) instead of reading the raw output of bash",
+		Parameters: &ParameterSchema{
+			Type: "object",
+			Properties: map[string]*PropertyDef{
+				"command": {
+					Type:        "string",
+					Description: "The bash command to run",
+				},
+				"expect_exit_code": {
+					Type:        "integer",
+					Description: "Expected exit code",
+					Default:     0,
+				},
+				"expect_output": {
+					Type:        "string",
+					Description: "Text the output (stdout and stderr) must contain",
+				},
+				"expect_regex": {
+					Type:        "string",
+					Description: "Regular expression (Go syntax) the output must match",
+				},
+				"timeout": {
+					Type:        "integer",
+					Description: "Timeout in seconds (default: 120, max: 600)",
+					Default:     120,
+				},
+			},
+			Required: []string{"command"},
+		},
+	}
+}
+
+// Execute runs the command and evaluates the expectations
+func (t *AssertCommandTool) Execute(ctx context.Context, params json.RawMessage) (*Result, error) {
+	var args struct {
+		Command        string `json:"command"`
+		ExpectExitCode *int   `json:"expect_exit_code"`
+		ExpectOutput   string `json:"expect_output"`
+		ExpectRegex    string `json:"expect_regex"`
+		Timeout        int    `json:"timeout"`
+	}
+
+	if err := json.Unmarshal(params, &args); err != nil {
+		return NewErrorResult(err), nil
+	}
+
+	args.Command = strings.TrimSpace(args.Command)
+	if args.Command == "" {
+		return NewErrorResult(fmt.Errorf("command cannot be empty")), nil
+	}
+
+	var pattern *regexp.Regexp
+	if args.ExpectRegex != "" {
+		var err error
+		if pattern, err = regexp.Compile(args.ExpectRegex); err != nil {
+			return NewErrorResult(fmt.Errorf("invalid expect_regex: %w", err)), nil
+		}
+	}
+
+	wantExit := 0
+	if args.ExpectExitCode != nil {
+		wantExit = *args.ExpectExitCode
+	}
+
+	if err := t.bash.checkNetwork(args.Command); err != nil {
+		return NewErrorResult(err), nil
+	}
+
+	timeout := bashTimeout(args.Timeout)
+	output, timedOut, err := t.bash.runShell(ctx, t.bash.prepareCommand(args.Command), timeout, nil)
+
+	exitCode := 0
+	if err != nil {
+		var exitErr *exec.ExitError
+		switch {
+		case ctx.Err() != nil:
+			return NewErrorResult(fmt.Errorf("command interrupted: %w", ctx.Err())), nil
+		case timedOut:
+			return NewResult(formatAssertResult([]string{fmt.Sprintf("command timed out after %s", timeout)}, nil, output)), nil
+		case errors.As(err, &exitErr):
+			exitCode = exitErr.ExitCode()
+		default:
+			return NewErrorResult(fmt.Errorf("failed to run command: %w", err)), nil
+		}
+	}
+
+	var failures, passes []string
+	check := func(ok bool, pass, fail string) {
+		if ok {
+			passes = append(passes, pass)
+		} else {
+			failures = append(failures, fail)
+		}
+	}
+
+	check(exitCode == wantExit,
+		fmt.Sprintf("exit code %d", exitCode),
+		fmt.Sprintf("exit code %d, expected %d", exitCode, wantExit))
+	if args.ExpectOutput != "" {
+		check(strings.Contains(output, args.ExpectOutput),
+			fmt.Sprintf("output contains %q", args.ExpectOutput),
+			fmt.Sprintf("output does not contain %q", args.ExpectOutput))
+	}
+	if pattern != nil {
+		check(pattern.MatchString(output),
+			fmt.Sprintf("output matches /%s/", args.ExpectRegex),
+			fmt.Sprintf("output does not match /%s/", args.ExpectRegex))
+	}
+
+	return NewResult(formatAssertResult(failures, passes, output)), nil
+}
+
+// formatAssertResult renders the verdict. The output is only included on
+// failure, trimmed to its last MaxAssertOutputLength bytes
+func formatAssertResult(failures, passes []string, output string) string {
+	if len(failures) == 0 {
+		return "PASS: " + strings.Join(passes, "; ")
+	}
+
+	var sb strings.Builder
+	sb.WriteString("FAIL: " + strings.Join(failures, "; "))
+	if len(passes) > 0 {
+		sb.WriteString("\n(ok: " + strings.Join(passes, "; ") + ")")
+	}
+
+	output = strings.TrimRight(output, "\n")
+	if output == "" {
+		sb.WriteString("\nOutput: (empty)")
+		return sb.String()
+	}
+	if len(output) > MaxAssertOutputLength {
+		output = "...\n" + output[len(output)-MaxAssertOutputLength:]
+	}
+	sb.WriteString("\nOutput:\n" + output)
+	return sb.String()
+}
@@ -0,0 +1,110 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func runAssert(t *testing.T, params string) *Result {
+	t.Helper()
+	result, err := NewAssertCommandTool(nil).Execute(context.Background(), json.RawMessage(params))
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	return result
+}
+
+func TestAssertCommand_Pass(t *testing.T) {
+	tests := []struct {
+		name   string
+		params string
+	}{
+		{"exit code only", `{"command": "true"}`},
+		{"substring", `{"command": "echo hello world", "expect_output": "lo wo"}`},
+		{"regex", `{"command": "echo version 1.2.3", "expect_regex": "\\d+\\.\\d+\\.\\d+"}`},
+		{"stderr is included", `{"command": "echo oops >&2", "expect_output": "oops"}`},
+		{"expected non-zero exit", `{"command": "echo missing; exit 3", "expect_exit_code": 3, "expect_output": "missing"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := runAssert(t, tt.params)
+			if result.IsError || !strings.HasPrefix(result.Output, "PASS: ") {
+				t.Errorf("got %q, want PASS", result.Output)
+			}
+			if strings.Contains(result.Output, "Output:") {
+				t.Errorf("passing result should not include the output: %q", result.Output)
+			}
+		})
+	}
+}
+
+func TestAssertCommand_Fail(t *testing.T) {
+	tests := []struct {
+		name   string
+		params string
+		want   []string
+	}{
+		{"unexpected exit code", `{"command": "echo boom; exit 2"}`,
+			[]string{"FAIL: exit code 2, expected 0", "boom"}},
+		{"expected failure but succeeded", `{"command": "true", "expect_exit_code": 1}`,
+			[]string{"FAIL: exit code 0, expected 1", "Output: (empty)"}},
+		{"missing substring", `{"command": "echo hello", "expect_output": "goodbye"}`,
+			[]string{`FAIL: output does not contain "goodbye"`, "(ok: exit code 0)", "hello"}},
+		{"regex mismatch", `{"command": "echo abc", "expect_regex": "^\\d+$"}`,
+			[]string{`output does not match /^\d+$/`}},
+		{"several failures", `{"command": "echo abc; exit 1", "expect_output": "xyz"}`,
+			[]string{`FAIL: exit code 1, expected 0; output does not contain "xyz"`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := runAssert(t, tt.params)
+			if result.IsError {
+				t.Fatalf("a failed assertion should not be a tool error: %q", result.Output)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(result.Output, want) {
+					t.Errorf("output %q does not contain %q", result.Output, want)
+				}
+			}
+		})
+	}
+}
+
+func TestAssertCommand_TruncatesOutputOnFailure(t *testing.T) {
+	result := runAssert(t, `{"command": "head -c 10000 /dev/zero | tr '\\0' 'x'; echo; echo LAST; exit 1"}`)
+	if !strings.HasSuffix(result.Output, "LAST") {
+		t.Errorf("expected the tail of the output to be kept, got ...%q", result.Output[max(0, len(result.Output)-20):])
+	}
+	if len(result.Output) > MaxAssertOutputLength+200 {
+		t.Errorf("output length = %d, want at most about %d", len(result.Output), MaxAssertOutputLength)
+	}
+}
+
+func TestAssertCommand_Timeout(t *testing.T) {
+	result := runAssert(t, `{"command": "sleep 5", "timeout": 1}`)
+	if !strings.HasPrefix(result.Output, "FAIL: command timed out after 1s") {
+		t.Errorf("got %q, want a timeout failure", result.Output)
+	}
+}
+
+func TestAssertCommand_InvalidInput(t *testing.T) {
+	for _, params := range []string{
+		`{"command": "  "}`,
+		`{"command": "true", "expect_regex": "("}`,
+	} {
+		if result := runAssert(t, params); !result.IsError || result.Error == "" {
+			t.Errorf("%s: expected an error result, got %+v", params, result)
+		}
+	}
+}
+
+func TestAssertCommand_RespectsNoNetwork(t *testing.T) {
+	bash := NewBashTool()
+	bash.SetNoNetwork(true)
+	result, _ := NewAssertCommandTool(bash).Execute(context.Background(), json.RawMessage(`{"command": "curl https://example.com"}`))
+	if !result.IsError || !strings.Contains(result.Error, "no-network") {
+		t.Errorf("expected the no-network refusal, got %+v", result)
+	}
+}
@@ -4,7 +4,9 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -12,6 +14,7 @@ import (
 	"runtime"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -20,10 +23,8 @@ const (
 	DefaultBashTimeout = 120 * time.Second
 	// MaxBashTimeout is the maximum timeout for bash commands
 	MaxBashTimeout = 600 * time.Second
-	// MaxOutputLength is the maximum output length to return
+	// MaxOutputLength is the default maximum output length (bytes) returned by bash, read_file, grep and web_fetch
 	MaxOutputLength = 30000
-	// TruncatePrefixLength is the prefix length when truncating
-	TruncatePrefixLength = 15000
 	// MaxBgTasks is the maximum number of background tasks
 	MaxBgTasks = 50
 	// BgTaskCleanupInterval is the interval for cleaning up old tasks
@@ -36,6 +37,8 @@ type BashTool struct {
 	sandboxDir string // サンドボックスディレクトリのパス（PATH参照用、cmd.Dirには使わない）
 	autoVenv   bool   // Python実行時に自動で.venvをactivateするか
 	venvDir    string // 仮想環境ディレクトリパス（デフォルト: .venv）
+	noNetwork  bool   // ネットワークにアクセスするコマンドを実行前に拒否するか
+	maxOutput  int    // 出力の上限バイト数（0 = MaxOutputLength）
 }
 
 // NewBashTool creates a new bash tool
@@ -52,6 +55,17 @@ func (t *BashTool) SetSandboxDir(dir string) {
 	t.sandboxDir = dir
 }
 
+// SetMaxOutput は出力の上限バイト数を設定する（0 以下 = MaxOutputLength）
+func (t *BashTool) SetMaxOutput(maxBytes int) {
+	t.maxOutput = maxBytes
+}
+
+// SetNoNetwork はネットワーク禁止モードを設定する
+// 有効時は外部ホストにアクセスするコマンド（CheckNetworkCommand）を実行前に拒否する
+func (t *BashTool) SetNoNetwork(enabled bool) {
+	t.noNetwork = enabled
+}
+
 // SetAutoVenv は自動venv機能を設定する
 func (t *BashTool) SetAutoVenv(enabled bool, venvDir string) {
 	t.autoVenv = enabled
@@ -65,6 +79,14 @@ func (t *BashTool) Name() string {
 	return "bash"
 }
 
+// Dependencies returns the external prerequisites checked by Registry.Preflight
+func (t *BashTool) Dependencies() []Dependency {
+	if runtime.GOOS == "windows" {
+		return []Dependency{CommandDependency("cmd.exe")}
+	}
+	return []Dependency{CommandDependency("bash")}
+}
+
 // Schema returns the tool schema
 func (t *BashTool) Schema() *FunctionSchema {
 	return &FunctionSchema{
@@ -87,6 +109,11 @@ func (t *BashTool) Schema() *FunctionSchema {
 					Description: "Run command in background (returns task ID)",
 					Default:     false,
 				},
+				"stream_output": {
+					Type:        "boolean",
+					Description: "Show output lines to the user while a foreground command runs (default: true). The full output is returned either way",
+					Default:     true,
+				},
 			},
 			Required: []string{"command"},
 		},
@@ -99,6 +126,7 @@ func (t *BashTool) Execute(ctx context.Context, params json.RawMessage) (*Result
 		Command          string `json:"command"`
 		Timeout         int    `json:"timeout"`
 		RunInBackground  bool   `json:"run_in_background"`
+		StreamOutput     *bool  `json:"stream_output"`
 	}
 
 	if err := json.Unmarshal(params, &args); err != nil {
@@ -111,29 +139,80 @@ func (t *BashTool) Execute(ctx context.Context, params json.RawMessage) (*Result
 		return NewErrorResult(fmt.Errorf("command cannot be empty")), nil
 	}
 
-	// Set timeout
-	timeout := DefaultBashTimeout
-	if args.Timeout > 0 && args.Timeout <= int(MaxBashTimeout.Seconds()) {
-		timeout = time.Duration(args.Timeout) * time.Second
+	// No-network mode: refuse remote fetchers before anything runs
+	if err := t.checkNetwork(args.Command); err != nil {
+		return NewErrorResult(err), nil
 	}
 
+	timeout := bashTimeout(args.Timeout)
+
 	// Check for background execution
 	if args.RunInBackground {
 		return t.executeInBackground(args.Command, timeout)
 	}
 
+	// Stream lines to the user as they're produced unless turned off
+	var onLine func(string)
+	if args.StreamOutput == nil || *args.StreamOutput {
+		onLine = OutputLinesFrom(ctx)
+	}
+
+	// Execute command synchronously
+	return t.executeSync(ctx, t.prepareCommand(args.Command), timeout, onLine)
+}
+
+// checkNetwork refuses commands that reach remote hosts in no-network mode
+func (t *BashTool) checkNetwork(command string) error {
+	if t.noNetwork {
+		if blocked, reason := CheckNetworkCommand(command); blocked {
+			return fmt.Errorf("network access is disabled (no-network mode): %s. Only localhost/127.0.0.1 targets are allowed; use local files or ask the user to run it", reason)
+		}
+	}
+	return nil
+}
+
+// bashTimeout converts the timeout parameter (seconds) to a duration,
+// falling back to the default when it is unset or out of range
+func bashTimeout(seconds int) time.Duration {
+	if seconds > 0 && seconds <= int(MaxBashTimeout.Seconds()) {
+		return time.Duration(seconds) * time.Second
+	}
+	return DefaultBashTimeout
+}
+
+// prepareCommand applies the python3 rewrite and the automatic venv activation
+func (t *BashTool) prepareCommand(command string) string {
 	// python を python3 に置換（macOS互換性）
-	command := replacePythonWithPython3(args.Command)
+	command = replacePythonWithPython3(command)
 
 	// Python自動venv: コマンドがPython関連なら.venvのactivateを前置
-	command = t.wrapWithVenvIfNeeded(command)
+	return t.wrapWithVenvIfNeeded(command)
+}
 
-	// Execute command synchronously
-	return t.executeSync(ctx, command, timeout)
+// executeSync executes a command synchronously; onLine (may be nil) receives output lines as they arrive
+func (t *BashTool) executeSync(ctx context.Context, command string, timeout time.Duration, onLine func(string)) (*Result, error) {
+	output, _, err := t.runShell(ctx, command, timeout, onLine)
+
+	// Truncate output if too long
+	output = truncateOutput(output, t.maxOutput)
+
+	// Check if command failed
+	if err != nil {
+		hint := inferErrorHint(output, command)
+		if hint != "" {
+			return NewErrorResultWithID("", fmt.Errorf("Command failed: %v\nOutput:\n%s\n\nHint: %s", err, output, hint)), nil
+		}
+		return NewErrorResultWithID("", fmt.Errorf("Command failed: %v\nOutput:\n%s", err, output)), nil
+	}
+
+	return NewResultWithID("", output), nil
 }
 
-// executeSync executes a command synchronously
-func (t *BashTool) executeSync(ctx context.Context, command string, timeout time.Duration) (*Result, error) {
+// runShell runs command in the platform shell and returns its combined
+// stdout and stderr, whether it was killed by the timeout, and the error
+// from exec (e.g. *exec.ExitError). When onLine is set, each stdout/stderr
+// line is also passed to it as soon as it's written
+func (t *BashTool) runShell(ctx context.Context, command string, timeout time.Duration, onLine func(string)) (string, bool, error) {
 	// Create context with timeout
 	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
@@ -162,9 +241,20 @@ func (t *BashTool) executeSync(ctx context.Context, command string, timeout time
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
+	var stdoutLines, stderrLines *lineWriter
+	if onLine != nil {
+		// Separate writers so a partial stdout line never merges with stderr
+		stdoutLines, stderrLines = newLineWriter(onLine), newLineWriter(onLine)
+		cmd.Stdout = io.MultiWriter(&stdout, stdoutLines)
+		cmd.Stderr = io.MultiWriter(&stderr, stderrLines)
+	}
 
 	// Execute
 	err := cmd.Run()
+	if onLine != nil {
+		stdoutLines.Flush()
+		stderrLines.Flush()
+	}
 
 	// Combine output
 	output := stdout.String()
@@ -176,19 +266,7 @@ func (t *BashTool) executeSync(ctx context.Context, command string, timeout time
 		output += stderrStr
 	}
 
-	// Truncate output if too long
-	output = truncateOutput(output)
-
-	// Check if command failed
-	if err != nil {
-		hint := inferErrorHint(output, command)
-		if hint != "" {
-			return NewErrorResultWithID("", fmt.Errorf("Command failed: %v\nOutput:\n%s\n\nHint: %s", err, output, hint)), nil
-		}
-		return NewErrorResultWithID("", fmt.Errorf("Command failed: %v\nOutput:\n%s", err, output)), nil
-	}
-
-	return NewResultWithID("", output), nil
+	return output, errors.Is(ctx.Err(), context.DeadlineExceeded), err
 }
 
 // wrapWithVenvIfNeeded はPythonコマンドを検出した場合に.venvのactivateを前置する
@@ -312,27 +390,6 @@ func replacePythonWithPython3(command string) string {
 	return result
 }
 
-// truncateOutput truncates output to maximum length
-func truncateOutput(output string) string {
-	if len(output) <= MaxOutputLength {
-		return output
-	}
-
-	prefix := output[:TruncatePrefixLength]
-	suffix := output[len(output)-TruncatePrefixLength:]
-
-	// Try to truncate at newline boundaries for cleaner display
-	if lastNewline := strings.LastIndex(prefix, "\n"); lastNewline > 0 {
-		prefix = prefix[:lastNewline]
-	}
-	if firstNewline := strings.Index(suffix, "\n"); firstNewline > 0 {
-		suffix = suffix[firstNewline+1:]
-	}
-
-	omittedChars := len(output) - len(prefix) - len(suffix)
-	return fmt.Sprintf("%s\n\n... [%d characters omitted] ...\n\n%s", prefix, omittedChars, suffix)
-}
-
 // sanitizeEnv removes sensitive environment variables
 func sanitizeEnv() []string {
 	env := os.Environ()
@@ -374,6 +431,42 @@ type BackgroundTask struct {
 	Output    string
 	Error     error
 	Done      bool
+
+	output    *syncBuffer // 実行中の出力（完了前の途中経過取得用）
+	maxOutput int         // 出力の上限バイト数（起動した BashTool の設定）
+}
+
+// Snapshot returns a consistent copy of the task state.
+// While the task is still running, Output holds the output captured so far.
+func (task *BackgroundTask) Snapshot() BackgroundTask {
+	bgTaskMutex.Lock()
+	defer bgTaskMutex.Unlock()
+
+	snap := *task
+	if !snap.Done && task.output != nil {
+		snap.Output = truncateOutput(task.output.String(), task.maxOutput)
+	}
+	return snap
+}
+
+// syncBuffer is a goroutine-safe buffer for capturing command output
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+// Write appends p to the buffer
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+// String returns the buffered output
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
 }
 
 // backgroundTaskManager manages background tasks
@@ -406,6 +499,8 @@ func (t *BashTool) executeInBackground(command string, timeout time.Duration) (*
 		Command:   command,
 		StartTime: time.Now(),
 		Done:      false,
+		output:    &syncBuffer{},
+		maxOutput: t.maxOutput,
 	}
 	bgTaskMap.Store(taskID, task)
 
@@ -430,15 +525,16 @@ func (t *BashTool) executeInBackground(command string, timeout time.Duration) (*
 		cmd := exec.CommandContext(ctx, shellCmd, shellArgs...)
 		cmd.Env = sanitizeEnv()
 
-		var output bytes.Buffer
-		cmd.Stdout = &output
-		cmd.Stderr = &output
+		cmd.Stdout = task.output
+		cmd.Stderr = task.output
 
 		err := cmd.Run()
 
-		task.Output = truncateOutput(output.String())
+		bgTaskMutex.Lock()
+		task.Output = truncateOutput(task.output.String(), task.maxOutput)
 		task.Error = err
 		task.Done = true
+		bgTaskMutex.Unlock()
 	}()
 
 	return NewResult(fmt.Sprintf("Background task started with ID: %s", taskID)), nil
@@ -470,8 +566,26 @@ func cleanupOldBackgroundTasks() {
 	})
 }
 
-// CheckDangerousCommand checks if a command contains dangerous patterns
+// allowDangerous disables the CheckDangerousCommand pattern block (--allow-dangerous)
+var allowDangerous atomic.Bool
+
+// SetAllowDangerous turns the dangerous-command pattern block off (true) or back on (false)
+func SetAllowDangerous(enabled bool) {
+	allowDangerous.Store(enabled)
+}
+
+// AllowDangerous reports whether the dangerous-command pattern block is disabled
+func AllowDangerous() bool {
+	return allowDangerous.Load()
+}
+
+// CheckDangerousCommand checks if a command contains dangerous patterns.
+// Nothing is reported as dangerous while SetAllowDangerous(true) is in effect
 func CheckDangerousCommand(command string) (bool, string) {
+	if allowDangerous.Load() {
+		return false, ""
+	}
+
 	dangerousPatterns := []struct {
 		pattern string
 		reason  string
@@ -0,0 +1,85 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// BashOutputTool retrieves the output of background bash tasks
+type BashOutputTool struct{}
+
+// NewBashOutputTool creates a new bash output tool
+func NewBashOutputTool() *BashOutputTool {
+	return &BashOutputTool{}
+}
+
+// Name returns the tool name
+func (t *BashOutputTool) Name() string {
+	return "bash_output"
+}
+
+// Schema returns the tool schema
+func (t *BashOutputTool) Schema() *FunctionSchema {
+	return &FunctionSchema{
+		Name:        "bash_output",
+		Description: "Get the current output and status of a background bash task started with run_in_background",
+		Parameters: &ParameterSchema{
+			Type: "object",
+			Properties: map[string]*PropertyDef{
+				"task_id": {
+					Type:        "string",
+					Description: "The background task ID returned by bash (e.g., bg_1700000000000000000)",
+				},
+			},
+			Required: []string{"task_id"},
+		},
+	}
+}
+
+// Execute returns the output of a background task
+func (t *BashOutputTool) Execute(ctx context.Context, params json.RawMessage) (*Result, error) {
+	var args struct {
+		TaskID string `json:"task_id"`
+	}
+
+	if err := json.Unmarshal(params, &args); err != nil {
+		return NewErrorResult(err), nil
+	}
+
+	args.TaskID = strings.TrimSpace(args.TaskID)
+	if args.TaskID == "" {
+		return NewErrorResult(fmt.Errorf("task_id cannot be empty")), nil
+	}
+
+	task, ok := GetBackgroundTask(args.TaskID)
+	if !ok {
+		return NewErrorResult(fmt.Errorf("background task not found: %s (tasks are removed %v after completion)", args.TaskID, BgTaskCleanupInterval)), nil
+	}
+
+	snap := task.Snapshot()
+
+	status := "running"
+	if snap.Done {
+		status = "done"
+	}
+
+	var output strings.Builder
+	output.WriteString(fmt.Sprintf("Task: %s\n", snap.ID))
+	output.WriteString(fmt.Sprintf("Command: %s\n", snap.Command))
+	output.WriteString(fmt.Sprintf("Status: %s (elapsed %s)\n", status, time.Since(snap.StartTime).Round(time.Second)))
+	if snap.Error != nil {
+		output.WriteString(fmt.Sprintf("Error: %v\n", snap.Error))
+	}
+
+	output.WriteString("\nOutput:\n")
+	if snap.Output == "" {
+		output.WriteString("(no output yet)")
+	} else {
+		output.WriteString(truncateOutput(snap.Output, snap.maxOutput))
+	}
+
+	return NewResult(output.String()), nil
+}
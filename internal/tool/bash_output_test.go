@@ -0,0 +1,91 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBashOutputTool_Execute_CompletedTask(t *testing.T) {
+	bash := NewBashTool()
+	ctx := context.Background()
+
+	result, err := bash.Execute(ctx, json.RawMessage(`{"command": "echo background-done", "run_in_background": true}`))
+	if err != nil {
+		t.Fatalf("failed to start background task: %v", err)
+	}
+	taskID := strings.TrimSpace(strings.TrimPrefix(result.Output, "Background task started with ID: "))
+
+	// Wait for task to complete
+	time.Sleep(200 * time.Millisecond)
+
+	tool := NewBashOutputTool()
+	result, err = tool.Execute(ctx, json.RawMessage(`{"task_id": "`+taskID+`"}`))
+	if err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+
+	if result.IsError {
+		t.Fatalf("expected success, got error: %s", result.Error)
+	}
+
+	if !strings.Contains(result.Output, "Status: done") {
+		t.Errorf("expected done status, got '%s'", result.Output)
+	}
+
+	if !strings.Contains(result.Output, "background-done") {
+		t.Errorf("expected task output, got '%s'", result.Output)
+	}
+}
+
+func TestBashOutputTool_Execute_RunningTask(t *testing.T) {
+	bash := NewBashTool()
+	ctx := context.Background()
+
+	result, err := bash.Execute(ctx, json.RawMessage(`{"command": "echo partial; sleep 2", "run_in_background": true}`))
+	if err != nil {
+		t.Fatalf("failed to start background task: %v", err)
+	}
+	taskID := strings.TrimSpace(strings.TrimPrefix(result.Output, "Background task started with ID: "))
+
+	time.Sleep(200 * time.Millisecond)
+
+	tool := NewBashOutputTool()
+	result, _ = tool.Execute(ctx, json.RawMessage(`{"task_id": "`+taskID+`"}`))
+
+	if !strings.Contains(result.Output, "Status: running") {
+		t.Errorf("expected running status, got '%s'", result.Output)
+	}
+
+	if !strings.Contains(result.Output, "partial") {
+		t.Errorf("expected partial output while running, got '%s'", result.Output)
+	}
+}
+
+func TestBashOutputTool_Execute_NotFound(t *testing.T) {
+	tool := NewBashOutputTool()
+
+	result, err := tool.Execute(context.Background(), json.RawMessage(`{"task_id": "bg_missing"}`))
+	if err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+
+	if !result.IsError {
+		t.Error("expected error result for unknown task")
+	}
+
+	if !strings.Contains(result.Error, "not found") {
+		t.Errorf("expected not found error, got '%s'", result.Error)
+	}
+}
+
+func TestBashOutputTool_Execute_EmptyTaskID(t *testing.T) {
+	tool := NewBashOutputTool()
+
+	result, _ := tool.Execute(context.Background(), json.RawMessage(`{"task_id": ""}`))
+	if !result.IsError {
+		t.Error("expected error result for empty task_id")
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"os"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -205,6 +206,25 @@ func TestCheckDangerousCommand(t *testing.T) {
 	}
 }
 
+func TestCheckDangerousCommand_AllowDangerous(t *testing.T) {
+	t.Cleanup(func() { SetAllowDangerous(false) })
+
+	// Blocked by default
+	if dangerous, _ := CheckDangerousCommand("dd if=/dev/zero of=/dev/sda"); !dangerous {
+		t.Fatal("expected dd to a device to be blocked by default")
+	}
+
+	SetAllowDangerous(true)
+	if dangerous, reason := CheckDangerousCommand("dd if=/dev/zero of=/dev/sda"); dangerous {
+		t.Errorf("expected the block to be bypassed with allow-dangerous, got %q", reason)
+	}
+
+	SetAllowDangerous(false)
+	if dangerous, _ := CheckDangerousCommand("rm -rf /"); !dangerous {
+		t.Error("expected the block to come back once allow-dangerous is turned off")
+	}
+}
+
 func TestTruncateOutput(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -219,7 +239,7 @@ func TestTruncateOutput(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := truncateOutput(tt.input)
+			result := truncateOutput(tt.input, 0)
 
 			if tt.expectTruncate {
 				if !strings.Contains(result, "omitted") {
@@ -420,3 +440,74 @@ func TestBashTool_Execute_CommandFails(t *testing.T) {
 		t.Error("expected error result for failing command")
 	}
 }
+
+func TestBashTool_StreamsOutputLines(t *testing.T) {
+	tool := NewBashTool()
+
+	var mu sync.Mutex
+	var lines []string
+	var arrivals []time.Time
+	ctx := WithOutputLines(context.Background(), func(line string) {
+		mu.Lock()
+		defer mu.Unlock()
+		lines = append(lines, line)
+		arrivals = append(arrivals, time.Now())
+	})
+
+	params := json.RawMessage(`{"command": "for i in 1 2 3; do echo line$i; sleep 0.3; done; printf tail"}`)
+	result, err := tool.Execute(ctx, params)
+	finished := time.Now()
+	if err != nil || result.IsError {
+		t.Fatalf("expected success, got %v / %+v", err, result)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := []string{"line1", "line2", "line3", "tail"}
+	if strings.Join(lines, ",") != strings.Join(want, ",") {
+		t.Fatalf("streamed lines = %q, want %q", lines, want)
+	}
+	// The first line arrives while the command is still sleeping, not at the end
+	if finished.Sub(arrivals[0]) < 500*time.Millisecond {
+		t.Errorf("first line arrived %v before the command finished; expected it to be streamed early", finished.Sub(arrivals[0]))
+	}
+	if !arrivals[0].Before(arrivals[1]) || !arrivals[1].Before(arrivals[2]) {
+		t.Errorf("lines should arrive one by one, got %v", arrivals)
+	}
+	// The full output is still returned
+	if result.Output != "line1\nline2\nline3\ntail" {
+		t.Errorf("result output = %q", result.Output)
+	}
+}
+
+func TestBashTool_StreamOutputDisabled(t *testing.T) {
+	tool := NewBashTool()
+	called := false
+	ctx := WithOutputLines(context.Background(), func(string) { called = true })
+
+	result, err := tool.Execute(ctx, json.RawMessage(`{"command": "echo hi", "stream_output": false}`))
+	if err != nil || result.IsError {
+		t.Fatalf("expected success, got %v / %+v", err, result)
+	}
+	if called {
+		t.Error("stream_output: false should not stream lines")
+	}
+	if strings.TrimSpace(result.Output) != "hi" {
+		t.Errorf("result output = %q", result.Output)
+	}
+}
+
+func TestLineWriter_SplitsAndFlushes(t *testing.T) {
+	var lines []string
+	w := newLineWriter(func(line string) { lines = append(lines, line) })
+
+	w.Write([]byte("par"))
+	w.Write([]byte("tial\nnext\r\n10%\r50%\r100%\nend"))
+	if strings.Join(lines, "|") != "partial|next|100%" {
+		t.Fatalf("lines before flush = %q", lines)
+	}
+	w.Flush()
+	if lines[len(lines)-1] != "end" {
+		t.Errorf("Flush should emit the trailing partial line, got %q", lines)
+	}
+}
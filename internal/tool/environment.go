@@ -0,0 +1,225 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// EnvironmentProbeTimeout bounds each interpreter version command
+const EnvironmentProbeTimeout = 5 * time.Second
+
+// interpreterProbe is how an interpreter's version is detected
+type interpreterProbe struct {
+	Name string
+	Args []string // Arguments that print the version (stdout or stderr)
+}
+
+// environmentInterpreters are probed in this order
+var environmentInterpreters = []interpreterProbe{
+	{"go", []string{"version"}},
+	{"python3", []string{"--version"}},
+	{"python", []string{"--version"}},
+	{"node", []string{"--version"}},
+	{"deno", []string{"--version"}},
+	{"ruby", []string{"--version"}},
+	{"rustc", []string{"--version"}},
+	{"java", []string{"-version"}},
+}
+
+// environmentCommands are common tools reported as present or missing on PATH
+var environmentCommands = []string{
+	"git", "make", "gcc", "clang", "cargo", "npm", "yarn", "pnpm", "pip3", "uv",
+	"docker", "kubectl", "curl", "wget", "rg", "jq",
+}
+
+// versionPattern matches the first dotted version number in command output
+var versionPattern = regexp.MustCompile(`\d+(\.\d+)+`)
+
+// Interpreter is a detected language runtime
+type Interpreter struct {
+	Name    string
+	Path    string // "" when not on PATH
+	Version string // "" when not found or the version couldn't be parsed
+}
+
+// EnvironmentInfo is a snapshot of the machine the agent runs on
+type EnvironmentInfo struct {
+	OS           string
+	Arch         string
+	MemoryGB     float64 // 0 when unknown
+	Shell        string
+	WorkDir      string
+	Interpreters []Interpreter
+	Available    []string // environmentCommands found on PATH
+	Missing      []string // environmentCommands not on PATH
+}
+
+// EnvironmentTool reports the OS, memory, interpreters and common tools.
+// Detection runs once per tool instance; refresh re-runs it.
+type EnvironmentTool struct {
+	memoryGB float64
+	lookPath func(file string) (string, error)
+	run      func(ctx context.Context, name string, args ...string) (string, error)
+
+	mu     sync.Mutex
+	cached *EnvironmentInfo
+}
+
+// NewEnvironmentTool creates a new environment tool
+func NewEnvironmentTool() *EnvironmentTool {
+	return &EnvironmentTool{
+		lookPath: exec.LookPath,
+		run:      runCombined,
+	}
+}
+
+// SetMemoryGB sets the total memory reported by the tool (0 = unknown)
+func (t *EnvironmentTool) SetMemoryGB(gb float64) {
+	t.memoryGB = gb
+}
+
+// Name returns the tool name
+func (t *EnvironmentTool) Name() string {
+	return "environment"
+}
+
+// Schema returns the tool schema
+func (t *EnvironmentTool) Schema() *FunctionSchema {
+	return &FunctionSchema{
+		Name:        "environment",
+		Description: "Show the OS/arch, memory, installed interpreters (go, python, node, ...) with versions, and which common tools (git, docker, npm, ...) are on PATH. Call this once instead of probing with several bash commands",
+		Parameters: &ParameterSchema{
+			Type: "object",
+			Properties: map[string]*PropertyDef{
+				"refresh": {
+					Type:        "boolean",
+					Description: "Detect again instead of returning the cached result (e.g. after installing something)",
+					Default:     false,
+				},
+			},
+		},
+	}
+}
+
+// Execute returns the environment snapshot
+func (t *EnvironmentTool) Execute(ctx context.Context, params json.RawMessage) (*Result, error) {
+	var args struct {
+		Refresh bool `json:"refresh"`
+	}
+	if len(params) > 0 {
+		if err := json.Unmarshal(params, &args); err != nil {
+			return NewErrorResult(err), nil
+		}
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.cached == nil || args.Refresh {
+		info := t.detect(ctx)
+		if err := ctx.Err(); err != nil {
+			return NewErrorResult(err), nil
+		}
+		t.cached = info
+	}
+	return NewResult(t.cached.String()), nil
+}
+
+// detect collects a new snapshot
+func (t *EnvironmentTool) detect(ctx context.Context) *EnvironmentInfo {
+	info := &EnvironmentInfo{
+		OS:       runtime.GOOS,
+		Arch:     runtime.GOARCH,
+		MemoryGB: t.memoryGB,
+		Shell:    os.Getenv("SHELL"),
+	}
+	info.WorkDir, _ = os.Getwd()
+
+	for _, probe := range environmentInterpreters {
+		info.Interpreters = append(info.Interpreters, t.detectInterpreter(ctx, probe))
+	}
+
+	for _, name := range environmentCommands {
+		if _, err := t.lookPath(name); err == nil {
+			info.Available = append(info.Available, name)
+		} else {
+			info.Missing = append(info.Missing, name)
+		}
+	}
+	return info
+}
+
+// detectInterpreter finds an interpreter on PATH and parses its version output
+func (t *EnvironmentTool) detectInterpreter(ctx context.Context, probe interpreterProbe) Interpreter {
+	interp := Interpreter{Name: probe.Name}
+	path, err := t.lookPath(probe.Name)
+	if err != nil {
+		return interp
+	}
+	interp.Path = path
+
+	probeCtx, cancel := context.WithTimeout(ctx, EnvironmentProbeTimeout)
+	defer cancel()
+	out, err := t.run(probeCtx, path, probe.Args...)
+	if err != nil && out == "" {
+		return interp
+	}
+	interp.Version = parseVersion(out)
+	return interp
+}
+
+// parseVersion extracts the first dotted version number from version output
+// ("go version go1.25.0 linux/amd64" → "1.25.0", "v20.11.1" → "20.11.1")
+func parseVersion(output string) string {
+	return versionPattern.FindString(output)
+}
+
+// runCombined runs a command and returns its combined stdout and stderr
+func runCombined(ctx context.Context, name string, args ...string) (string, error) {
+	out, err := exec.CommandContext(ctx, name, args...).CombinedOutput()
+	return string(out), err
+}
+
+// String formats the snapshot for the model
+func (e *EnvironmentInfo) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "OS: %s/%s\n", e.OS, e.Arch)
+	if e.MemoryGB > 0 {
+		fmt.Fprintf(&sb, "Memory: %.1f GB\n", e.MemoryGB)
+	}
+	if e.Shell != "" {
+		fmt.Fprintf(&sb, "Shell: %s\n", e.Shell)
+	}
+	if e.WorkDir != "" {
+		fmt.Fprintf(&sb, "Working directory: %s\n", e.WorkDir)
+	}
+
+	sb.WriteString("\nInterpreters:\n")
+	for _, interp := range e.Interpreters {
+		switch {
+		case interp.Path == "":
+			fmt.Fprintf(&sb, "  %-8s not found\n", interp.Name)
+		case interp.Version == "":
+			fmt.Fprintf(&sb, "  %-8s unknown version (%s)\n", interp.Name, interp.Path)
+		default:
+			fmt.Fprintf(&sb, "  %-8s %s (%s)\n", interp.Name, interp.Version, interp.Path)
+		}
+	}
+
+	sb.WriteString("\n")
+	if len(e.Available) > 0 {
+		fmt.Fprintf(&sb, "Tools on PATH: %s\n", strings.Join(e.Available, ", "))
+	}
+	if len(e.Missing) > 0 {
+		fmt.Fprintf(&sb, "Not found: %s\n", strings.Join(e.Missing, ", "))
+	}
+	return sb.String()
+}
@@ -0,0 +1,120 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newStubEnvironmentTool returns a tool whose PATH holds the keys of outputs
+// (plus extra commands) and whose version commands print the mapped output
+func newStubEnvironmentTool(outputs map[string]string, extra ...string) (*EnvironmentTool, *int) {
+	onPath := make(map[string]bool)
+	for name := range outputs {
+		onPath[name] = true
+	}
+	for _, name := range extra {
+		onPath[name] = true
+	}
+
+	runs := 0
+	t := NewEnvironmentTool()
+	t.lookPath = func(file string) (string, error) {
+		if onPath[file] {
+			return "/usr/bin/" + file, nil
+		}
+		return "", errors.New("not found")
+	}
+	t.run = func(ctx context.Context, name string, args ...string) (string, error) {
+		runs++
+		out, ok := outputs[filepath.Base(name)]
+		if !ok {
+			return "", errors.New("no stub")
+		}
+		return out, nil
+	}
+	return t, &runs
+}
+
+func TestParseVersion(t *testing.T) {
+	tests := map[string]string{
+		"go version go1.25.0 linux/amd64\n": "1.25.0",
+		"Python 3.12.1\n":                   "3.12.1",
+		"v20.11.1\n":                        "20.11.1",
+		"ruby 3.3.0 (2023-12-25 revision 5124f9ac75) [x86_64]\n": "3.3.0",
+		"rustc 1.76.0 (07dca489a 2024-02-04)\n":                  "1.76.0",
+		"openjdk version \"21.0.2\" 2024-01-16\nOpenJDK Runtime": "21.0.2",
+		"deno 1.40.5 (release, x86_64-unknown-linux-gnu)\nv8 12": "1.40.5",
+		"no version here": "",
+	}
+	for output, want := range tests {
+		if got := parseVersion(output); got != want {
+			t.Errorf("parseVersion(%q) = %q, want %q", output, got, want)
+		}
+	}
+}
+
+func TestEnvironmentTool_DetectsInterpreterVersions(t *testing.T) {
+	envTool, _ := newStubEnvironmentTool(map[string]string{
+		"go":      "go version go1.25.0 linux/amd64\n",
+		"python3": "Python 3.12.1\n",
+		"node":    "v20.11.1\n",
+		"java":    "openjdk version \"21.0.2\" 2024-01-16\n",
+	}, "git", "docker")
+	envTool.SetMemoryGB(15.5)
+
+	result, err := envTool.Execute(context.Background(), json.RawMessage(`{}`))
+	if err != nil || result.IsError {
+		t.Fatalf("Execute failed: %v %s", err, result.Error)
+	}
+
+	for _, want := range []string{
+		"Memory: 15.5 GB",
+		"go       1.25.0 (/usr/bin/go)",
+		"python3  3.12.1 (/usr/bin/python3)",
+		"node     20.11.1 (/usr/bin/node)",
+		"java     21.0.2 (/usr/bin/java)",
+		"python   not found",
+		"ruby     not found",
+		"Tools on PATH: git, docker",
+	} {
+		if !strings.Contains(result.Output, want) {
+			t.Errorf("output missing %q:\n%s", want, result.Output)
+		}
+	}
+	if !strings.Contains(result.Output, "Not found: ") || !strings.Contains(result.Output, "kubectl") {
+		t.Errorf("missing tools should be listed:\n%s", result.Output)
+	}
+}
+
+func TestEnvironmentTool_UnparsableVersion(t *testing.T) {
+	envTool, _ := newStubEnvironmentTool(map[string]string{"node": "garbage"})
+
+	result, _ := envTool.Execute(context.Background(), json.RawMessage(`{}`))
+	if !strings.Contains(result.Output, "node     unknown version (/usr/bin/node)") {
+		t.Errorf("unparsable version should be reported as unknown:\n%s", result.Output)
+	}
+}
+
+func TestEnvironmentTool_CachesUntilRefresh(t *testing.T) {
+	envTool, runs := newStubEnvironmentTool(map[string]string{"go": "go version go1.25.0 linux/amd64"})
+
+	envTool.Execute(context.Background(), json.RawMessage(`{}`))
+	first := *runs
+	if first == 0 {
+		t.Fatal("version commands should run on the first call")
+	}
+
+	envTool.Execute(context.Background(), json.RawMessage(`{}`))
+	if *runs != first {
+		t.Errorf("second call ran %d more commands, want cached result", *runs-first)
+	}
+
+	envTool.Execute(context.Background(), json.RawMessage(`{"refresh": true}`))
+	if *runs == first {
+		t.Error("refresh should detect again")
+	}
+}
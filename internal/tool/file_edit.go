@@ -1,13 +1,15 @@
 package tool
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"os"
+	"regexp"
 	"strings"
 	"unicode/utf8"
+
+	"github.com/zephel01/vibe-local-go/internal/security"
 )
 
 const (
@@ -19,8 +21,10 @@ const (
 
 // EditTool edits files by replacing strings
 type EditTool struct {
-	writeTool *WriteTool
-	sandbox   SandboxStager
+	writeTool  *WriteTool
+	sandbox    SandboxStager
+	validator  *security.PathValidator
+	vibeIgnore *IgnoreMatcher
 }
 
 // NewEditTool creates a new edit tool
@@ -30,11 +34,26 @@ func NewEditTool() *EditTool {
 	}
 }
 
+// SetWriteTool は undo スタックを共有する WriteTool を設定する（/undo, /redo で扱えるようにする）
+func (t *EditTool) SetWriteTool(wt *WriteTool) {
+	t.writeTool = wt
+}
+
 // SetSandbox はサンドボックスマネージャーを設定する
 func (t *EditTool) SetSandbox(sb SandboxStager) {
 	t.sandbox = sb
 }
 
+// SetPathValidator は編集対象を検証するバリデーターを設定する（作業ディレクトリ外の編集を拒否）
+func (t *EditTool) SetPathValidator(v *security.PathValidator) {
+	t.validator = v
+}
+
+// SetVibeIgnore は .vibeignore のマッチャーを設定する（該当パスの編集を拒否、nil = チェックなし）
+func (t *EditTool) SetVibeIgnore(m *IgnoreMatcher) {
+	t.vibeIgnore = m
+}
+
 // Name returns the tool name
 func (t *EditTool) Name() string {
 	return "edit_file"
@@ -65,6 +84,11 @@ func (t *EditTool) Schema() *FunctionSchema {
 					Description: "Replace all occurrences (default: false)",
 					Default:     false,
 				},
+				"regex": {
+					Type:        "boolean",
+					Description: "Treat old_string as a Go regular expression; new_string may use $1 / ${name} capture references. Without replace_all only the first match is replaced (default: false)",
+					Default:     false,
+				},
 			},
 			Required: []string{"path", "old_string", "new_string"},
 		},
@@ -73,45 +97,116 @@ func (t *EditTool) Schema() *FunctionSchema {
 
 // Execute edits a file
 func (t *EditTool) Execute(ctx context.Context, params json.RawMessage) (*Result, error) {
+	// Hold the change lock from reading the old content until the undo entry is recorded
+	defer t.writeTool.lockChanges()()
+
+	edit, err := t.prepare(params)
+	if err != nil {
+		return NewErrorResult(err), nil
+	}
+	path, resolvedPath, newContent := edit.path, edit.resolvedPath, edit.newContent
+
+	// Generate diff
+	diff := generateUnifiedDiff(path, edit.oldContent, newContent)
+
+	// サンドボックスモードの場合はステージングにリダイレクト
+	if t.sandbox != nil && t.sandbox.IsEnabled() {
+		if err := t.sandbox.Stage(resolvedPath, []byte(newContent)); err != nil {
+			return NewErrorResult(fmt.Errorf("sandbox staging failed: %w", err)), nil
+		}
+		output := fmt.Sprintf("[sandbox] Staged edit → %s (use /commit to apply, /diff to review)\n\nDiff:\n%s", path, diff)
+		return NewResult(output), nil
+	}
+
+	// 通常モード: 直接書き込み
+	tmpFile := resolvedPath + ".tmp"
+	if err := os.WriteFile(tmpFile, []byte(newContent), 0644); err != nil {
+		return NewErrorResult(err), nil
+	}
+
+	if err := os.Rename(tmpFile, resolvedPath); err != nil {
+		os.Remove(tmpFile)
+		return NewErrorResult(err), nil
+	}
+
+	t.writeTool.addToUndoStack(UndoEntry{
+		Path:       resolvedPath,
+		OldContent: edit.oldContent,
+		NewContent: newContent,
+	})
+
+	// Return result with diff
+	output := fmt.Sprintf("Successfully edited %s\n\nDiff:\n%s", path, diff)
+	return NewResult(output).WithChangedPaths(resolvedPath), nil
+}
+
+// Preview returns the diff edit_file would apply, without writing anything
+func (t *EditTool) Preview(params json.RawMessage) (string, error) {
+	edit, err := t.prepare(params)
+	if err != nil {
+		return "", err
+	}
+	return generateUnifiedDiff(edit.path, edit.oldContent, edit.newContent), nil
+}
+
+// pendingEdit is an edit that has been validated but not yet written
+type pendingEdit struct {
+	path         string // Path as given by the model (used in diffs)
+	resolvedPath string
+	oldContent   string
+	newContent   string
+}
+
+// prepare validates an edit and computes the file's new content without writing it
+func (t *EditTool) prepare(params json.RawMessage) (*pendingEdit, error) {
 	var args struct {
 		Path       string `json:"path"`
 		OldString  string `json:"old_string"`
 		NewString  string `json:"new_string"`
 		ReplaceAll bool   `json:"replace_all"`
+		Regex      bool   `json:"regex"`
 	}
 
 	if err := json.Unmarshal(params, &args); err != nil {
-		return NewErrorResult(err), nil
+		return nil, err
 	}
 
 	if args.Path == "" {
-		return NewErrorResult(fmt.Errorf("path cannot be empty")), nil
+		return nil, fmt.Errorf("path cannot be empty")
 	}
 
 	if args.OldString == "" {
-		return NewErrorResult(fmt.Errorf("old_string cannot be empty")), nil
+		return nil, fmt.Errorf("old_string cannot be empty")
 	}
 
 	// Resolve path
 	resolvedPath, err := resolvePath(args.Path)
 	if err != nil {
-		return NewErrorResult(err), nil
+		return nil, err
+	}
+
+	// Confine edits to the working directory
+	if err := checkWorkdir(t.validator, resolvedPath); err != nil {
+		return nil, err
+	}
+	if err := checkVibeIgnoreWrite(t.vibeIgnore, resolvedPath); err != nil {
+		return nil, err
 	}
 
 	// Check for managed/dependency directories
 	if managedDir := getManagedDirWarning(resolvedPath); managedDir != "" {
-		return NewErrorResult(fmt.Errorf("cannot edit files in managed directory %s: %s\nHint: edit files in the project root or your own subdirectories", managedDir, args.Path)), nil
+		return nil, fmt.Errorf("cannot edit files in managed directory %s: %s\nHint: edit files in the project root or your own subdirectories", managedDir, args.Path)
 	}
 
-	// Read file
-	content, err := os.ReadFile(resolvedPath)
+	// Read file (staged version first in sandbox mode)
+	content, err := readCurrent(t.sandbox, resolvedPath)
 	if err != nil {
-		return NewErrorResult(err), nil
+		return nil, err
 	}
 
 	// Check file size
 	if len(content) > MaxEditFileSize {
-		return NewErrorResult(fmt.Errorf("file too large (%d bytes, max %d)", len(content), MaxEditFileSize)), nil
+		return nil, fmt.Errorf("file too large (%d bytes, max %d)", len(content), MaxEditFileSize)
 	}
 
 	// Normalize content (Unicode NFC)
@@ -121,49 +216,54 @@ func (t *EditTool) Execute(ctx context.Context, params json.RawMessage) (*Result
 	newString := args.NewString
 
 	// Perform replacement
-	if args.ReplaceAll {
+	if args.Regex {
+		newContent, err = replaceRegex(newContent, args.OldString, newString, args.ReplaceAll)
+		if err != nil {
+			return nil, err
+		}
+	} else if args.ReplaceAll {
 		newContent = strings.ReplaceAll(newContent, oldString, newString)
 	} else {
 		// Check for multiple occurrences
 		count := strings.Count(newContent, oldString)
 		if count > 1 {
-			return NewErrorResult(fmt.Errorf("old_string appears %d times; use replace_all=true or provide more unique context", count)), nil
+			return nil, fmt.Errorf("old_string appears %d times; use replace_all=true or provide more unique context", count)
 		}
 
 		// Single replacement
 		if count == 0 {
-			return NewErrorResult(fmt.Errorf("old_string not found in file")), nil
+			return nil, fmt.Errorf("old_string not found in file")
 		}
 
 		newContent = strings.Replace(newContent, oldString, newString, 1)
 	}
 
-	// Generate diff
-	diff := generateUnifiedDiff(args.Path, oldContent, newContent)
+	return &pendingEdit{
+		path:         args.Path,
+		resolvedPath: resolvedPath,
+		oldContent:   oldContent,
+		newContent:   newContent,
+	}, nil
+}
 
-	// サンドボックスモードの場合はステージングにリダイレクト
-	if t.sandbox != nil && t.sandbox.IsEnabled() {
-		if err := t.sandbox.Stage(resolvedPath, []byte(newContent)); err != nil {
-			return NewErrorResult(fmt.Errorf("sandbox staging failed: %w", err)), nil
-		}
-		output := fmt.Sprintf("[sandbox] Staged edit → %s (use /commit to apply, /diff to review)\n\nDiff:\n%s", args.Path, diff)
-		return NewResult(output), nil
+// replaceRegex replaces the first match of pattern (every match with all) with
+// replacement, expanding $1 / ${name} capture references
+func replaceRegex(content, pattern, replacement string, all bool) (string, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return "", fmt.Errorf("invalid regex in old_string: %v", err)
 	}
 
-	// 通常モード: 直接書き込み
-	tmpFile := resolvedPath + ".tmp"
-	if err := os.WriteFile(tmpFile, []byte(newContent), 0644); err != nil {
-		return NewErrorResult(err), nil
+	loc := re.FindStringSubmatchIndex(content)
+	if loc == nil {
+		return "", fmt.Errorf("old_string pattern did not match anything in file")
 	}
 
-	if err := os.Rename(tmpFile, resolvedPath); err != nil {
-		os.Remove(tmpFile)
-		return NewErrorResult(err), nil
+	if all {
+		return re.ReplaceAllString(content, replacement), nil
 	}
-
-	// Return result with diff
-	output := fmt.Sprintf("Successfully edited %s\n\nDiff:\n%s", args.Path, diff)
-	return NewResult(output), nil
+	expanded := re.ExpandString(nil, replacement, content, loc)
+	return content[:loc[0]] + string(expanded) + content[loc[1]:], nil
 }
 
 // normalizeString normalizes a string to Unicode NFC
@@ -174,60 +274,15 @@ func normalizeString(s string) string {
 	return s
 }
 
-// generateUnifiedDiff generates a unified diff
+// generateUnifiedDiff generates a unified diff, truncated to MaxDiffLines
 func generateUnifiedDiff(filename string, oldText, newText string) string {
-	oldLines := strings.Split(oldText, "\n")
-	newLines := strings.Split(newText, "\n")
-
-	var diff bytes.Buffer
-	maxLines := MaxDiffLines
-
-	// Find diff
-	i, j := 0, 0
-	for i < len(oldLines) && j < len(newLines) {
-		if oldLines[i] == newLines[j] {
-			i++
-			j++
-			continue
-		}
-
-		// Found a difference
-		startOld := max(0, i-3)
-		startNew := max(0, j-3)
-
-		diff.WriteString(fmt.Sprintf("--- %s\n", filename))
-		diff.WriteString(fmt.Sprintf("+++ %s\n", filename))
-		diff.WriteString(fmt.Sprintf("@@ -%d,%d +%d,%d @@\n",
-			startOld+1, min(3, len(oldLines)-startOld),
-			startNew+1, min(3, len(newLines)-startNew)))
-
-		// Print context
-		for k := startOld; k < i; k++ {
-			diff.WriteString(" " + oldLines[k] + "\n")
-		}
-
-		// Print deletions
-		for k := i; k < min(i+3, len(oldLines)); k++ {
-			diff.WriteString("-" + oldLines[k] + "\n")
-		}
-
-		// Print additions
-		for k := j; k < min(j+3, len(newLines)); k++ {
-			diff.WriteString("+" + newLines[k] + "\n")
-		}
-
-		// Skip ahead
-		i += 3
-		j += 3
-		break
-	}
+	diffStr := UnifiedDiff(filename, filename, oldText, newText)
 
 	// Truncate if too long
-	diffStr := diff.String()
 	lines := strings.Split(diffStr, "\n")
-	if len(lines) > maxLines {
-		diffStr = strings.Join(lines[:maxLines], "\n")
-		diffStr += fmt.Sprintf("\n... (truncated, showing first %d of %d lines)", maxLines, len(lines))
+	if len(lines) > MaxDiffLines {
+		diffStr = strings.Join(lines[:MaxDiffLines], "\n")
+		diffStr += fmt.Sprintf("\n... (truncated, showing first %d of %d lines)", MaxDiffLines, len(lines))
 	}
 
 	return diffStr
@@ -11,10 +11,14 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"unicode/utf8"
+
+	"github.com/zephel01/vibe-local-go/internal/security"
 )
 
 const (
-	// MaxTextFileSize is the maximum file size for text files
+	// MaxTextFileSize is the maximum file size for files read whole (notebooks, PDFs);
+	// plain text is streamed line by line and has no size limit
 	MaxTextFileSize = 100 * 1024 * 1024 // 100MB
 	// MaxImageFileSize is the maximum file size for images
 	MaxImageFileSize = 10 * 1024 * 1024 // 10MB
@@ -22,11 +26,19 @@ const (
 	DefaultLineLimit = 2000
 	// MaxLineLimit is the maximum number of lines to read
 	MaxLineLimit = 20000
+	// MaxLineLength is the longest single line the text reader accepts
+	MaxLineLength = 1024 * 1024 // 1MB
+	// DefaultByteLength is the number of bytes read in byte-range mode when byte_length is unset
+	DefaultByteLength = 256
+	// MaxByteLength is the largest byte range returned at once
+	MaxByteLength = 4096
 )
 
 // ReadTool reads file contents
 type ReadTool struct {
-	baseDir string
+	baseDir    string
+	maxOutput  int // Output size limit in bytes (0 = MaxOutputLength)
+	vibeIgnore *IgnoreMatcher
 }
 
 // NewReadTool creates a new read tool
@@ -34,6 +46,16 @@ func NewReadTool() *ReadTool {
 	return &ReadTool{}
 }
 
+// SetMaxOutput は出力の上限バイト数を設定する（0 以下 = MaxOutputLength、画像は対象外）
+func (t *ReadTool) SetMaxOutput(maxBytes int) {
+	t.maxOutput = maxBytes
+}
+
+// SetVibeIgnore は .vibeignore のマッチャーを設定する（該当パスの読み込みを拒否、nil = チェックなし）
+func (t *ReadTool) SetVibeIgnore(m *IgnoreMatcher) {
+	t.vibeIgnore = m
+}
+
 // Name returns the tool name
 func (t *ReadTool) Name() string {
 	return "read_file"
@@ -61,6 +83,14 @@ func (t *ReadTool) Schema() *FunctionSchema {
 					Description: "Maximum number of lines to read",
 					Default:     DefaultLineLimit,
 				},
+				"byte_offset": {
+					Type:        "integer",
+					Description: "Byte-range mode: starting byte (0-based). Setting byte_offset or byte_length reads raw bytes instead of lines; non-UTF-8 content is shown as a hexdump (useful for file headers / magic numbers)",
+				},
+				"byte_length": {
+					Type:        "integer",
+					Description: fmt.Sprintf("Byte-range mode: number of bytes to read (default %d, max %d)", DefaultByteLength, MaxByteLength),
+				},
 			},
 			Required: []string{"path"},
 		},
@@ -73,6 +103,9 @@ func (t *ReadTool) Execute(ctx context.Context, params json.RawMessage) (*Result
 		Path   string `json:"path"`
 		Offset int    `json:"offset"`
 		Limit  int    `json:"limit"`
+		// Byte-range mode (nil/0 = line mode)
+		ByteOffset *int64 `json:"byte_offset"`
+		ByteLength int    `json:"byte_length"`
 	}
 
 	if err := json.Unmarshal(params, &args); err != nil {
@@ -96,6 +129,9 @@ func (t *ReadTool) Execute(ctx context.Context, params json.RawMessage) (*Result
 	if err != nil {
 		return NewErrorResult(err), nil
 	}
+	if err := checkVibeIgnore(t.vibeIgnore, resolvedPath); err != nil {
+		return NewErrorResult(err), nil
+	}
 
 	// Get file info
 	info, err := os.Stat(resolvedPath)
@@ -108,14 +144,23 @@ func (t *ReadTool) Execute(ctx context.Context, params json.RawMessage) (*Result
 		return NewErrorResult(fmt.Errorf("path is a directory: %s", args.Path)), nil
 	}
 
-	// Check file size
-	if info.Size() > MaxTextFileSize {
-		return NewErrorResult(fmt.Errorf("file too large (%d bytes, max %d)", info.Size(), MaxTextFileSize)), nil
+	// Byte-range mode works on any file type
+	if args.ByteOffset != nil || args.ByteLength > 0 {
+		offset := int64(0)
+		if args.ByteOffset != nil {
+			offset = *args.ByteOffset
+		}
+		return t.readBytes(resolvedPath, info.Size(), offset, args.ByteLength)
 	}
 
 	// Determine file type
 	ext := strings.ToLower(filepath.Ext(args.Path))
 
+	// Files that are read whole must fit the size limit (text is streamed)
+	if (ext == ".ipynb" || ext == ".pdf") && info.Size() > MaxTextFileSize {
+		return NewErrorResult(fmt.Errorf("file too large (%d bytes, max %d)", info.Size(), MaxTextFileSize)), nil
+	}
+
 	// Check if image
 	if isImageFile(ext) {
 		return t.readImage(resolvedPath)
@@ -135,7 +180,8 @@ func (t *ReadTool) Execute(ctx context.Context, params json.RawMessage) (*Result
 	return t.readText(resolvedPath, args.Offset, args.Limit)
 }
 
-// readText reads a text file
+// readText streams a text file, skipping to offset and stopping after limit lines
+// so memory stays bounded regardless of file size
 func (t *ReadTool) readText(path string, offset, limit int) (*Result, error) {
 	file, err := os.Open(path)
 	if err != nil {
@@ -152,14 +198,29 @@ func (t *ReadTool) readText(path string, offset, limit int) (*Result, error) {
 	file.Seek(0, io.SeekStart)
 
 	// Read lines
-	var lines []string
-	scanner := readLines(file, offset, limit)
+	lines := make([]string, 0, min(limit, 1024))
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), MaxLineLength)
 
+	current := 0
+	more := false
 	for scanner.Scan() {
+		if current < offset {
+			current++
+			continue
+		}
+		if len(lines) >= limit {
+			more = true
+			break
+		}
 		lines = append(lines, scanner.Text())
+		current++
 	}
 
 	if err := scanner.Err(); err != nil {
+		if err == bufio.ErrTooLong {
+			return NewErrorResult(fmt.Errorf("line %d exceeds %d bytes", current+1, MaxLineLength)), nil
+		}
 		return NewErrorResult(err), nil
 	}
 
@@ -172,10 +233,108 @@ func (t *ReadTool) readText(path string, offset, limit int) (*Result, error) {
 	for i, line := range lines {
 		output.WriteString(fmt.Sprintf("%5d | %s\n", offset+i+1, line))
 	}
+	if more {
+		output.WriteString(fmt.Sprintf("... (more lines follow; use offset=%d to continue)\n", offset+len(lines)))
+	}
+
+	return NewResult(truncateOutput(output.String(), t.maxOutput)), nil
+}
+
+// readBytes reads length bytes from offset. Valid UTF-8 text is returned as is,
+// anything else as a hexdump with absolute offsets.
+func (t *ReadTool) readBytes(path string, size, offset int64, length int) (*Result, error) {
+	if offset < 0 {
+		return NewErrorResult(fmt.Errorf("byte_offset cannot be negative")), nil
+	}
+	// Nothing to read: report it instead of formatting an empty range
+	if size == 0 && offset == 0 {
+		return NewResult("(empty file, 0 bytes)\n"), nil
+	}
+	if offset >= size {
+		return NewErrorResult(fmt.Errorf("byte_offset %d is past the end of the file (%d bytes)", offset, size)), nil
+	}
+	if length <= 0 {
+		length = DefaultByteLength
+	}
+	if length > MaxByteLength {
+		length = MaxByteLength
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return NewErrorResult(fmt.Errorf("file '%s' not found. Try: bash ls to see available files", path)), nil
+	}
+	defer file.Close()
+
+	buf := make([]byte, length)
+	n, err := file.ReadAt(buf, offset)
+	if err != nil && err != io.EOF {
+		return NewErrorResult(err), nil
+	}
+	data := buf[:n]
+
+	var output strings.Builder
+	output.WriteString(fmt.Sprintf("(bytes %d-%d of %d)\n", offset, offset+int64(n), size))
+	if isPlainText(data) {
+		output.Write(data)
+		if n > 0 && data[n-1] != '\n' {
+			output.WriteString("\n")
+		}
+	} else {
+		output.WriteString(hexDump(data, offset))
+	}
+	if end := offset + int64(n); end < size {
+		output.WriteString(fmt.Sprintf("... (%d more bytes; use byte_offset=%d to continue)\n", size-end, end))
+	}
 
 	return NewResult(output.String()), nil
 }
 
+// isPlainText reports whether data is UTF-8 without control characters other than whitespace
+func isPlainText(data []byte) bool {
+	if !utf8.Valid(data) {
+		return false
+	}
+	for _, b := range data {
+		if b < 0x20 && b != '\n' && b != '\r' && b != '\t' {
+			return false
+		}
+		if b == 0x7f {
+			return false
+		}
+	}
+	return true
+}
+
+// hexDump formats data like `hexdump -C`, numbering lines from base
+func hexDump(data []byte, base int64) string {
+	var sb strings.Builder
+	for line := 0; line < len(data); line += 16 {
+		chunk := data[line:min(line+16, len(data))]
+		sb.WriteString(fmt.Sprintf("%08x  ", base+int64(line)))
+		for i := 0; i < 16; i++ {
+			if i < len(chunk) {
+				sb.WriteString(fmt.Sprintf("%02x ", chunk[i]))
+			} else {
+				sb.WriteString("   ")
+			}
+			if i == 7 {
+				sb.WriteString(" ")
+			}
+		}
+		sb.WriteString(" |")
+		for _, b := range chunk {
+			if b >= 0x20 && b < 0x7f {
+				sb.WriteByte(b)
+			} else {
+				sb.WriteByte('.')
+			}
+		}
+		sb.WriteString("|\n")
+	}
+	return sb.String()
+}
+
 // readImage reads an image file and returns base64
 func (t *ReadTool) readImage(path string) (*Result, error) {
 	file, err := os.Open(path)
@@ -227,10 +386,10 @@ func (t *ReadTool) readJSON(path string) (*Result, error) {
 	var pretty bytes.Buffer
 	if err := json.Indent(&pretty, data, "", "  "); err != nil {
 		// Invalid JSON, return raw
-		return NewResult(string(data)), nil
+		return NewResult(truncateOutput(string(data), t.maxOutput)), nil
 	}
 
-	return NewResult(pretty.String()), nil
+	return NewResult(truncateOutput(pretty.String(), t.maxOutput)), nil
 }
 
 // readPDF reads a PDF file and extracts text
@@ -247,7 +406,7 @@ func (t *ReadTool) readPDF(path string) (*Result, error) {
 	output.WriteString(fmt.Sprintf("PDF file: %s\n\n", path))
 	output.WriteString(text)
 
-	return NewResult(output.String()), nil
+	return NewResult(truncateOutput(output.String(), t.maxOutput)), nil
 }
 
 // isBinary checks if a file is binary
@@ -286,8 +445,8 @@ func isImageFile(ext string) bool {
 // For paths that don't exist yet (e.g. write_file creating new files),
 // it resolves the closest existing ancestor and appends the remaining components.
 func resolvePath(path string) (string, error) {
-	// Clean path
-	path = filepath.Clean(path)
+	// Normalize Windows-style separators / drive letters, then clean
+	path = filepath.Clean(security.NormalizePath(path))
 
 	// Make absolute if relative
 	if !filepath.IsAbs(path) {
@@ -339,48 +498,3 @@ func resolveNonExistentPath(path string) (string, error) {
 		}
 	}
 }
-
-// lineScanner implements a scanner with offset and limit
-type lineScanner struct {
-	lines   []string
-	offset  int
-	limit   int
-	current int
-}
-
-func readLines(file *os.File, offset, limit int) *lineScanner {
-	lines := make([]string, 0, limit)
-	scanner := bufio.NewScanner(file)
-
-	current := 0
-	for scanner.Scan() && len(lines) < limit {
-		if current >= offset {
-			lines = append(lines, scanner.Text())
-		}
-		current++
-	}
-
-	return &lineScanner{
-		lines:   lines,
-		offset:  offset,
-		limit:   limit,
-		current: 0,
-	}
-}
-
-// Scan implements scanner interface
-func (ls *lineScanner) Scan() bool {
-	return ls.current < len(ls.lines)
-}
-
-// Text returns current line
-func (ls *lineScanner) Text() string {
-	line := ls.lines[ls.current]
-	ls.current++
-	return line
-}
-
-// Err returns error
-func (ls *lineScanner) Err() error {
-	return nil
-}
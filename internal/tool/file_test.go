@@ -1,12 +1,18 @@
 package tool
 
 import (
+	"bufio"
 	"context"
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
+	"sync"
 	"testing"
+
+	"github.com/zephel01/vibe-local-go/internal/security"
 )
 
 func TestNewReadTool(t *testing.T) {
@@ -117,6 +123,60 @@ func TestReadTool_Execute_WithOffsetAndLimit(t *testing.T) {
 	}
 }
 
+func TestReadTool_Execute_LargeFileWindow(t *testing.T) {
+	tool := NewReadTool()
+
+	// ~24MB: large enough that reading it whole would show up in allocations
+	path := filepath.Join(t.TempDir(), "big.log")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("failed to create test file: %v", err)
+	}
+	w := bufio.NewWriter(f)
+	const totalLines = 300000
+	for i := 1; i <= totalLines; i++ {
+		fmt.Fprintf(w, "%08d %s\n", i, strings.Repeat("x", 70))
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatalf("failed to write test content: %v", err)
+	}
+	f.Close()
+	info, _ := os.Stat(path)
+
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+
+	params := json.RawMessage(fmt.Sprintf(`{"path": %q, "offset": 150000, "limit": 5}`, path))
+	result, err := tool.Execute(context.Background(), params)
+
+	runtime.ReadMemStats(&after)
+
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if result.IsError {
+		t.Fatalf("expected success, got error: %s", result.Error)
+	}
+
+	if !strings.Contains(result.Output, "showing lines 150001-150005") {
+		t.Errorf("expected showing lines info, got: %s", result.Output)
+	}
+	if !strings.Contains(result.Output, "00150001 ") || !strings.Contains(result.Output, "00150005 ") {
+		t.Errorf("expected requested window, got: %s", result.Output)
+	}
+	if strings.Contains(result.Output, "00150000 ") || strings.Contains(result.Output, "00150006 ") {
+		t.Errorf("expected only the requested window, got: %s", result.Output)
+	}
+	if !strings.Contains(result.Output, "use offset=150005") {
+		t.Errorf("expected continuation hint, got: %s", result.Output)
+	}
+
+	if allocated := after.TotalAlloc - before.TotalAlloc; allocated > uint64(info.Size())/4 {
+		t.Errorf("allocated %d bytes reading a %d byte file; expected streaming", allocated, info.Size())
+	}
+}
+
 func TestReadTool_Execute_NonExistentFile(t *testing.T) {
 	tool := NewReadTool()
 	ctx := context.Background()
@@ -172,6 +232,34 @@ func TestReadTool_Execute_Directory(t *testing.T) {
 	}
 }
 
+func TestReadTool_Execute_WindowsStylePath(t *testing.T) {
+	tool := NewReadTool()
+
+	tmpDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(tmpDir, "src", "pkg"), 0755); err != nil {
+		t.Fatalf("failed to create test dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "src", "pkg", "main.go"), []byte("package main"), 0644); err != nil {
+		t.Fatalf("failed to create test file: %v", err)
+	}
+
+	// Backslash and mixed separators should resolve to the same file
+	for _, p := range []string{tmpDir + `\src\pkg\main.go`, tmpDir + `/src\pkg/main.go`} {
+		params, _ := json.Marshal(map[string]string{"path": p})
+		result, err := tool.Execute(context.Background(), params)
+		if err != nil {
+			t.Errorf("expected no error, got %v", err)
+		}
+		if result.IsError {
+			t.Errorf("expected success for %q, got error: %s", p, result.Error)
+			continue
+		}
+		if !strings.Contains(result.Output, "package main") {
+			t.Errorf("expected file content for %q, got '%s'", p, result.Output)
+		}
+	}
+}
+
 func TestNewWriteTool(t *testing.T) {
 	tool := NewWriteTool()
 
@@ -332,6 +420,169 @@ func TestWriteTool_Undo(t *testing.T) {
 	}
 }
 
+func TestWriteTool_UndoRedoEdit(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "main.go")
+	if err := os.WriteFile(path, []byte("package main\n\nfunc a() {}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	writeTool := NewWriteTool()
+	editTool := NewEditTool()
+	editTool.SetWriteTool(writeTool)
+	ctx := context.Background()
+
+	params, _ := json.Marshal(map[string]string{"path": path, "old_string": "func a()", "new_string": "func b()"})
+	if result, _ := editTool.Execute(ctx, params); result.IsError {
+		t.Fatalf("edit failed: %s", result.Error)
+	}
+	edited := "package main\n\nfunc b() {}\n"
+
+	if err := writeTool.Undo(); err != nil {
+		t.Fatalf("Undo: %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "package main\n\nfunc a() {}\n" {
+		t.Errorf("after undo = %q, want the original", string(data))
+	}
+	if len(writeTool.GetRedoStack()) != 1 {
+		t.Fatalf("redo stack = %d entries, want 1", len(writeTool.GetRedoStack()))
+	}
+
+	if err := writeTool.Redo(); err != nil {
+		t.Fatalf("Redo: %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != edited {
+		t.Errorf("after redo = %q, want %q", string(data), edited)
+	}
+	if len(writeTool.GetRedoStack()) != 0 || len(writeTool.GetUndoStack()) != 1 {
+		t.Errorf("redo should move the entry back to the undo stack (undo %d, redo %d)",
+			len(writeTool.GetUndoStack()), len(writeTool.GetRedoStack()))
+	}
+
+	// Undo again, then make a new edit: the undone change can no longer be redone
+	if err := writeTool.Undo(); err != nil {
+		t.Fatalf("Undo: %v", err)
+	}
+	params, _ = json.Marshal(map[string]string{"path": path, "old_string": "func a()", "new_string": "func c()"})
+	if result, _ := editTool.Execute(ctx, params); result.IsError {
+		t.Fatalf("edit failed: %s", result.Error)
+	}
+	if len(writeTool.GetRedoStack()) != 0 {
+		t.Error("a new edit should clear the redo stack")
+	}
+	if err := writeTool.Redo(); err == nil || !strings.Contains(err.Error(), "nothing to redo") {
+		t.Errorf("Redo after a new edit = %v, want 'nothing to redo'", err)
+	}
+}
+
+func TestWriteTool_ConcurrentChangesKeepUndoStackConsistent(t *testing.T) {
+	dir := t.TempDir()
+	written := filepath.Join(dir, "written.txt")
+	edited := filepath.Join(dir, "edited.txt")
+	for _, path := range []string{written, edited} {
+		if err := os.WriteFile(path, []byte("END\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	writeTool := NewWriteTool()
+	editTool := NewEditTool()
+	editTool.SetWriteTool(writeTool)
+	ctx := context.Background()
+
+	// Writers overwrite one file while editors append to another, all sharing the undo stack
+	const workers, rounds = 8, 10
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < rounds; i++ {
+				params, _ := json.Marshal(map[string]string{"path": written, "content": fmt.Sprintf("write %d-%d\n", w, i)})
+				if result, _ := writeTool.Execute(ctx, params); result.IsError {
+					t.Errorf("write failed: %s", result.Error)
+				}
+				params, _ = json.Marshal(map[string]string{"path": edited, "old_string": "END", "new_string": fmt.Sprintf("edit %d-%d\nEND", w, i)})
+				if result, _ := editTool.Execute(ctx, params); result.IsError {
+					t.Errorf("edit failed: %s", result.Error)
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	if data, _ := os.ReadFile(edited); strings.Count(string(data), "edit ") != workers*rounds {
+		t.Errorf("edited file has %d appends, want %d", strings.Count(string(data), "edit "), workers*rounds)
+	}
+
+	stack := writeTool.GetUndoStack()
+	if len(stack) != MaxUndoStack {
+		t.Fatalf("undo stack = %d entries, want %d", len(stack), MaxUndoStack)
+	}
+
+	// Each entry for a file must start from the content the previous one left behind
+	first := make(map[string]string)
+	last := make(map[string]string)
+	for i, entry := range stack {
+		if prev, ok := last[entry.Path]; ok && entry.OldContent != prev {
+			t.Errorf("entry %d for %s: OldContent = %q, want %q", i, filepath.Base(entry.Path), entry.OldContent, prev)
+		}
+		if _, ok := first[entry.Path]; !ok {
+			first[entry.Path] = entry.OldContent
+		}
+		last[entry.Path] = entry.NewContent
+	}
+
+	// Undoing every entry restores each file to the state before its oldest entry
+	for range stack {
+		if err := writeTool.Undo(); err != nil {
+			t.Fatalf("Undo: %v", err)
+		}
+	}
+	for path, want := range first {
+		if data, _ := os.ReadFile(path); string(data) != want {
+			t.Errorf("%s after undoing everything = %q, want %q", filepath.Base(path), data, want)
+		}
+	}
+}
+
+func TestWriteTool_RedoNewFileAndDirectory(t *testing.T) {
+	dir := t.TempDir()
+	writeTool := NewWriteTool()
+	mkdirTool := NewMakeDirectoryTool()
+	mkdirTool.SetWriteTool(writeTool)
+	ctx := context.Background()
+
+	nested := filepath.Join(dir, "a", "b")
+	params, _ := json.Marshal(map[string]string{"path": nested})
+	if result, _ := mkdirTool.Execute(ctx, params); result.IsError {
+		t.Fatalf("mkdir failed: %s", result.Error)
+	}
+	file := filepath.Join(nested, "new.txt")
+	params, _ = json.Marshal(map[string]string{"path": file, "content": "hello"})
+	if result, _ := writeTool.Execute(ctx, params); result.IsError {
+		t.Fatalf("write failed: %s", result.Error)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := writeTool.Undo(); err != nil {
+			t.Fatalf("Undo %d: %v", i, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "a")); !os.IsNotExist(err) {
+		t.Fatal("undo should remove the created directories")
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := writeTool.Redo(); err != nil {
+			t.Fatalf("Redo %d: %v", i, err)
+		}
+	}
+	if data, err := os.ReadFile(file); err != nil || string(data) != "hello" {
+		t.Errorf("after redo: %q, %v", string(data), err)
+	}
+}
+
 func TestWriteTool_UndoNewFile(t *testing.T) {
 	tool := NewWriteTool()
 
@@ -411,6 +662,31 @@ func TestWriteTool_UndoNothingToUndo(t *testing.T) {
 	}
 }
 
+func TestWriteTool_Execute_WindowsStylePath(t *testing.T) {
+	tool := NewWriteTool()
+
+	tmpDir := t.TempDir()
+	params, _ := json.Marshal(map[string]string{
+		"path":    tmpDir + `\out\result.txt`,
+		"content": "hello",
+	})
+	result, err := tool.Execute(context.Background(), params)
+	if err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+	if result.IsError {
+		t.Fatalf("expected success, got error: %s", result.Error)
+	}
+
+	data, err := os.ReadFile(filepath.Join(tmpDir, "out", "result.txt"))
+	if err != nil {
+		t.Fatalf("expected file under converted path: %v", err)
+	}
+	if string(data) != "hello" {
+		t.Errorf("expected 'hello', got '%s'", string(data))
+	}
+}
+
 func TestNewEditTool(t *testing.T) {
 	tool := NewEditTool()
 
@@ -690,3 +966,272 @@ func TestIsBinary(t *testing.T) {
 		t.Error("binary file not detected as binary")
 	}
 }
+
+func TestReadTool_Execute_ByteRangeHexdump(t *testing.T) {
+	tool := NewReadTool()
+	path := filepath.Join(t.TempDir(), "image.png")
+	header := []byte{0x89, 'P', 'N', 'G', 0x0d, 0x0a, 0x1a, 0x0a, 0x00, 0x00, 0x00, 0x0d, 'I', 'H', 'D', 'R', 0x00, 0x01, 0xff, 0xfe}
+	if err := os.WriteFile(path, header, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	params, _ := json.Marshal(map[string]interface{}{"path": path, "byte_offset": 0, "byte_length": 18})
+	result, err := tool.Execute(context.Background(), params)
+	if err != nil || result.IsError {
+		t.Fatalf("expected success, got %v %s", err, result.Error)
+	}
+
+	want := "(bytes 0-18 of 20)\n" +
+		"00000000  89 50 4e 47 0d 0a 1a 0a  00 00 00 0d 49 48 44 52  |.PNG........IHDR|\n" +
+		"00000010  00 01                                             |..|\n" +
+		"... (2 more bytes; use byte_offset=18 to continue)\n"
+	if result.Output != want {
+		t.Errorf("unexpected hexdump:\ngot:\n%s\nwant:\n%s", result.Output, want)
+	}
+
+	// Offsets in the dump are absolute
+	params, _ = json.Marshal(map[string]interface{}{"path": path, "byte_offset": 16, "byte_length": 4})
+	result, _ = tool.Execute(context.Background(), params)
+	if !strings.Contains(result.Output, "00000010  00 01 ff fe") {
+		t.Errorf("expected dump starting at 0x10, got:\n%s", result.Output)
+	}
+}
+
+func TestReadTool_Execute_ByteRangeText(t *testing.T) {
+	tool := NewReadTool()
+	path := filepath.Join(t.TempDir(), "notes.txt")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\necho hello\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	params, _ := json.Marshal(map[string]interface{}{"path": path, "byte_length": 9})
+	result, err := tool.Execute(context.Background(), params)
+	if err != nil || result.IsError {
+		t.Fatalf("expected success, got %v %s", err, result.Error)
+	}
+	if !strings.HasPrefix(result.Output, "(bytes 0-9 of 21)\n#!/bin/sh\n") {
+		t.Errorf("expected raw text, got:\n%s", result.Output)
+	}
+
+	params, _ = json.Marshal(map[string]interface{}{"path": path, "byte_offset": 100})
+	result, _ = tool.Execute(context.Background(), params)
+	if !result.IsError {
+		t.Error("expected error for an offset past the end")
+	}
+}
+
+func TestReadTool_Execute_ByteRangeEmptyFile(t *testing.T) {
+	tool := NewReadTool()
+	path := filepath.Join(t.TempDir(), "empty.bin")
+	if err := os.WriteFile(path, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	params, _ := json.Marshal(map[string]interface{}{"path": path, "byte_length": 16})
+	result, err := tool.Execute(context.Background(), params)
+	if err != nil || result.IsError {
+		t.Fatalf("expected success, got %v %s", err, result.Error)
+	}
+	if result.Output != "(empty file, 0 bytes)\n" {
+		t.Errorf("unexpected output for an empty file:\n%s", result.Output)
+	}
+
+	params, _ = json.Marshal(map[string]interface{}{"path": path, "byte_offset": 5})
+	result, _ = tool.Execute(context.Background(), params)
+	if !result.IsError || strings.Contains(result.Output, "bytes 5-") {
+		t.Errorf("expected error for an offset into an empty file, got %q", result.Output)
+	}
+}
+
+func newWorkdirValidator(t *testing.T) (*security.PathValidator, string) {
+	t.Helper()
+	workdir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	workdir = filepath.Join(workdir, "project")
+	if err := os.Mkdir(workdir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	return security.NewPathValidator(workdir), workdir
+}
+
+func TestWriteTool_Execute_OutsideWorkdirRejected(t *testing.T) {
+	validator, workdir := newWorkdirValidator(t)
+	tool := NewWriteTool()
+	tool.SetPathValidator(validator)
+
+	escape := filepath.Join(workdir, "../../etc/x")
+	params, _ := json.Marshal(map[string]string{"path": escape, "content": "pwned"})
+	result, err := tool.Execute(context.Background(), params)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.IsError {
+		t.Fatal("expected write outside the working directory to be rejected")
+	}
+	if _, err := os.Stat(filepath.Clean(escape)); !os.IsNotExist(err) {
+		t.Errorf("file should not have been created outside the working directory")
+	}
+
+	// A symlink inside the tree that points outside is rejected too
+	outside := filepath.Dir(workdir)
+	if err := os.Symlink(outside, filepath.Join(workdir, "link")); err != nil {
+		t.Fatal(err)
+	}
+	params, _ = json.Marshal(map[string]string{"path": filepath.Join(workdir, "link", "x.txt"), "content": "pwned"})
+	result, _ = tool.Execute(context.Background(), params)
+	if !result.IsError {
+		t.Error("expected write through a symlink leaving the working directory to be rejected")
+	}
+
+	params, _ = json.Marshal(map[string]string{"path": filepath.Join(workdir, "src", "main.go"), "content": "package main\n"})
+	result, _ = tool.Execute(context.Background(), params)
+	if result.IsError {
+		t.Fatalf("expected write inside the working directory to succeed: %s", result.Error)
+	}
+}
+
+func TestEditTool_Execute_OutsideWorkdirRejected(t *testing.T) {
+	validator, workdir := newWorkdirValidator(t)
+	tool := NewEditTool()
+	tool.SetPathValidator(validator)
+
+	outsideFile := filepath.Join(filepath.Dir(workdir), "outside.txt")
+	if err := os.WriteFile(outsideFile, []byte("hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	params, _ := json.Marshal(map[string]string{"path": filepath.Join(workdir, "../outside.txt"), "old_string": "hello", "new_string": "bye"})
+	result, _ := tool.Execute(context.Background(), params)
+	if !result.IsError {
+		t.Fatal("expected edit outside the working directory to be rejected")
+	}
+	if data, _ := os.ReadFile(outsideFile); string(data) != "hello" {
+		t.Errorf("outside file was modified: %q", string(data))
+	}
+
+	insideFile := filepath.Join(workdir, "inside.txt")
+	if err := os.WriteFile(insideFile, []byte("hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	params, _ = json.Marshal(map[string]string{"path": insideFile, "old_string": "hello", "new_string": "bye"})
+	result, _ = tool.Execute(context.Background(), params)
+	if result.IsError {
+		t.Fatalf("expected edit inside the working directory to succeed: %s", result.Error)
+	}
+}
+
+func TestEditTool_Preview_DoesNotWrite(t *testing.T) {
+	tool := NewEditTool()
+	path := filepath.Join(t.TempDir(), "main.go")
+	if err := os.WriteFile(path, []byte("package main\n\nvar x = 1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	params, _ := json.Marshal(map[string]string{"path": path, "old_string": "x = 1", "new_string": "x = 2"})
+	diff, err := tool.Preview(params)
+	if err != nil {
+		t.Fatalf("Preview: %v", err)
+	}
+	if !strings.Contains(diff, "-var x = 1\n") || !strings.Contains(diff, "+var x = 2\n") {
+		t.Errorf("unexpected diff:\n%s", diff)
+	}
+
+	data, _ := os.ReadFile(path)
+	if string(data) != "package main\n\nvar x = 1\n" {
+		t.Errorf("Preview modified the file: %q", string(data))
+	}
+
+	params, _ = json.Marshal(map[string]string{"path": path, "old_string": "missing", "new_string": "x"})
+	if _, err := tool.Preview(params); err == nil {
+		t.Error("expected an error when old_string is not found")
+	}
+}
+
+func TestWriteTool_Preview_NewFileShowsAdditions(t *testing.T) {
+	tool := NewWriteTool()
+	path := filepath.Join(t.TempDir(), "hello.py")
+
+	params, _ := json.Marshal(map[string]string{"path": path, "content": "import sys\nprint('hi')\n"})
+	diff, err := tool.Preview(params)
+	if err != nil {
+		t.Fatalf("Preview: %v", err)
+	}
+
+	want := "--- /dev/null\n+++ " + path + "\n@@ -0,0 +1,2 @@\n+import sys\n+print('hi')\n"
+	if diff != want {
+		t.Errorf("diff = %q, want %q", diff, want)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("Preview should not create the file")
+	}
+}
+
+func TestEditTool_Execute_RegexCaptureGroups(t *testing.T) {
+	tool := NewEditTool()
+	path := filepath.Join(t.TempDir(), "calls.go")
+	if err := os.WriteFile(path, []byte("log.Printf(\"a\", x)\nlog.Printf(\"b\", y)\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Without replace_all only the first match is replaced
+	params, _ := json.Marshal(map[string]interface{}{
+		"path":       path,
+		"old_string": `log\.Printf\("(\w+)", (\w+)\)`,
+		"new_string": `logger.Info("$1", "value", ${2})`,
+		"regex":      true,
+	})
+	result, err := tool.Execute(context.Background(), params)
+	if err != nil || result.IsError {
+		t.Fatalf("expected success, got %v %s", err, result.Error)
+	}
+	data, _ := os.ReadFile(path)
+	if string(data) != "logger.Info(\"a\", \"value\", x)\nlog.Printf(\"b\", y)\n" {
+		t.Errorf("unexpected content after first-match replace:\n%s", data)
+	}
+
+	params, _ = json.Marshal(map[string]interface{}{
+		"path":        path,
+		"old_string":  `log\.Printf\("(\w+)", (\w+)\)`,
+		"new_string":  `logger.Info("$1", "value", ${2})`,
+		"regex":       true,
+		"replace_all": true,
+	})
+	if result, _ := tool.Execute(context.Background(), params); result.IsError {
+		t.Fatalf("replace_all failed: %s", result.Error)
+	}
+	data, _ = os.ReadFile(path)
+	if string(data) != "logger.Info(\"a\", \"value\", x)\nlogger.Info(\"b\", \"value\", y)\n" {
+		t.Errorf("unexpected content after replace_all:\n%s", data)
+	}
+}
+
+func TestEditTool_Execute_RegexErrors(t *testing.T) {
+	tool := NewEditTool()
+	path := filepath.Join(t.TempDir(), "file.txt")
+	if err := os.WriteFile(path, []byte("value = (1)\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	params, _ := json.Marshal(map[string]interface{}{"path": path, "old_string": `(1`, "new_string": "2", "regex": true})
+	result, _ := tool.Execute(context.Background(), params)
+	if !result.IsError || !strings.Contains(result.Error, "invalid regex") {
+		t.Errorf("expected invalid regex error, got %q", result.Error)
+	}
+
+	params, _ = json.Marshal(map[string]interface{}{"path": path, "old_string": `\d{3}`, "new_string": "2", "regex": true})
+	result, _ = tool.Execute(context.Background(), params)
+	if !result.IsError || !strings.Contains(result.Error, "did not match") {
+		t.Errorf("expected no-match error, got %q", result.Error)
+	}
+
+	// Without regex the same text is a literal string
+	params, _ = json.Marshal(map[string]interface{}{"path": path, "old_string": `(1)`, "new_string": "(2)"})
+	if result, _ := tool.Execute(context.Background(), params); result.IsError {
+		t.Fatalf("literal edit failed: %s", result.Error)
+	}
+	data, _ := os.ReadFile(path)
+	if string(data) != "value = (2)\n" {
+		t.Errorf("content = %q", string(data))
+	}
+}
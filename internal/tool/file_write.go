@@ -8,6 +8,8 @@ import (
 	"path/filepath"
 	"strings"
 	"sync"
+
+	"github.com/zephel01/vibe-local-go/internal/security"
 )
 
 const (
@@ -22,14 +24,42 @@ const (
 type SandboxStager interface {
 	IsEnabled() bool
 	Stage(originalPath string, content []byte) error
+	ReadStaged(originalPath string) ([]byte, bool)
+}
+
+// readCurrent はファイルの現在の内容を返す
+// サンドボックス有効時はステージ済みの内容を優先する（未コミットの編集を積み重ねるため）
+func readCurrent(sb SandboxStager, path string) ([]byte, error) {
+	if sb != nil && sb.IsEnabled() {
+		if content, ok := sb.ReadStaged(path); ok {
+			return content, nil
+		}
+	}
+	return os.ReadFile(path)
+}
+
+// checkWorkdir rejects paths that escape the validator's allowed directories.
+// A nil validator allows any path (--allow-outside-workdir).
+func checkWorkdir(v *security.PathValidator, path string) error {
+	if v == nil {
+		return nil
+	}
+	if err := v.Validate(path); err != nil {
+		return fmt.Errorf("cannot modify %s: %v\nHint: only files inside the working directory can be changed (start with --allow-outside-workdir to lift this)", path, err)
+	}
+	return nil
 }
 
 // WriteTool writes content to files
 type WriteTool struct {
 	baseDir    string
 	undoStack  []UndoEntry
-	undoMutex  sync.Mutex
+	redoStack  []UndoEntry // Entries reverted by Undo, most recent last
+	undoMutex  sync.Mutex  // Guards undoStack and redoStack
+	changeMu   sync.Mutex  // Serializes file changes with their undo entries (taken before undoMutex)
 	sandbox    SandboxStager
+	validator  *security.PathValidator
+	vibeIgnore *IgnoreMatcher
 }
 
 // NewWriteTool creates a new write tool
@@ -44,6 +74,16 @@ func (t *WriteTool) SetSandbox(sb SandboxStager) {
 	t.sandbox = sb
 }
 
+// SetPathValidator は書き込み先を検証するバリデーターを設定する（作業ディレクトリ外への書き込みを拒否）
+func (t *WriteTool) SetPathValidator(v *security.PathValidator) {
+	t.validator = v
+}
+
+// SetVibeIgnore は .vibeignore のマッチャーを設定する（該当パスの書き込みを拒否、nil = チェックなし）
+func (t *WriteTool) SetVibeIgnore(m *IgnoreMatcher) {
+	t.vibeIgnore = m
+}
+
 // Name returns the tool name
 func (t *WriteTool) Name() string {
 	return "write_file"
@@ -97,6 +137,14 @@ func (t *WriteTool) Execute(ctx context.Context, params json.RawMessage) (*Resul
 		return NewErrorResult(err), nil
 	}
 
+	// Confine writes to the working directory
+	if err := checkWorkdir(t.validator, resolvedPath); err != nil {
+		return NewErrorResult(err), nil
+	}
+	if err := checkVibeIgnoreWrite(t.vibeIgnore, resolvedPath); err != nil {
+		return NewErrorResult(err), nil
+	}
+
 	// Check for protected paths
 	if isProtectedPath(resolvedPath) {
 		return NewErrorResult(fmt.Errorf("cannot write to protected path: %s", args.Path)), nil
@@ -113,19 +161,7 @@ func (t *WriteTool) Execute(ctx context.Context, params json.RawMessage) (*Resul
 	}
 
 	// Fix escaped newlines (\\n -> \n) - handle cases where LLM double-escapes
-	content := args.Content
-	// Replace literal backslash-n with actual newlines
-	// This handles cases where LLM returns "\\n" instead of "\n"
-	for {
-		newContent := strings.ReplaceAll(content, "\\n", "\n")
-		// Also handle other common escapes
-		newContent = strings.ReplaceAll(newContent, "\\t", "\t")
-		newContent = strings.ReplaceAll(newContent, "\\r", "\r")
-		if newContent == content {
-			break
-		}
-		content = newContent
-	}
+	content := unescapeContent(args.Content)
 
 	// サンドボックスモードの場合はステージングにリダイレクト
 	if t.sandbox != nil && t.sandbox.IsEnabled() {
@@ -136,6 +172,7 @@ func (t *WriteTool) Execute(ctx context.Context, params json.RawMessage) (*Resul
 	}
 
 	// 通常モード: 直接書き込み
+	defer t.lockChanges()()
 
 	// Create parent directories
 	parentDir := filepath.Dir(resolvedPath)
@@ -173,7 +210,54 @@ func (t *WriteTool) Execute(ctx context.Context, params json.RawMessage) (*Resul
 		NewContent: content,
 	})
 
-	return NewResult(fmt.Sprintf("Successfully wrote %d bytes to %s", len(content), args.Path)), nil
+	return NewResult(fmt.Sprintf("Successfully wrote %d bytes to %s", len(content), args.Path)).WithChangedPaths(resolvedPath), nil
+}
+
+// Preview returns the diff write_file would apply, without writing anything
+func (t *WriteTool) Preview(params json.RawMessage) (string, error) {
+	var args struct {
+		Path    string `json:"path"`
+		Content string `json:"content"`
+	}
+
+	if err := json.Unmarshal(params, &args); err != nil {
+		return "", err
+	}
+
+	if args.Path == "" {
+		return "", fmt.Errorf("path cannot be empty")
+	}
+
+	resolvedPath, err := resolvePath(args.Path)
+	if err != nil {
+		return "", err
+	}
+
+	content := unescapeContent(args.Content)
+	oldData, err := readCurrent(t.sandbox, resolvedPath)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return "", err
+		}
+		return fileChangeDiff(args.Path, "", false, content), nil
+	}
+	return fileChangeDiff(args.Path, string(oldData), true, content), nil
+}
+
+// unescapeContent replaces literal escape sequences with the characters they stand for.
+// This handles cases where LLM returns "\\n" instead of "\n"
+func unescapeContent(content string) string {
+	for {
+		newContent := strings.ReplaceAll(content, "\\n", "\n")
+		// Also handle other common escapes
+		newContent = strings.ReplaceAll(newContent, "\\t", "\t")
+		newContent = strings.ReplaceAll(newContent, "\\r", "\r")
+		if newContent == content {
+			break
+		}
+		content = newContent
+	}
+	return content
 }
 
 // getManagedDirWarning checks if path is inside a managed/dependency directory.
@@ -241,11 +325,27 @@ func fileExists(path string) bool {
 	return err == nil
 }
 
-// addToUndoStack adds an entry to the undo stack
+// lockChanges serializes changes made by the tools sharing this undo stack and
+// returns the unlock function. Holding it from reading a file's old content to
+// recording the undo entry keeps concurrent writes to the same file from
+// recording stale OldContent or clobbering each other's temp files.
+func (t *WriteTool) lockChanges() func() {
+	t.changeMu.Lock()
+	return t.changeMu.Unlock
+}
+
+// addToUndoStack adds an entry for a new change to the undo stack. A new
+// change makes the undone changes on the redo stack stale, so it is cleared.
 func (t *WriteTool) addToUndoStack(entry UndoEntry) {
 	t.undoMutex.Lock()
 	defer t.undoMutex.Unlock()
 
+	t.pushUndo(entry)
+	t.redoStack = nil
+}
+
+// pushUndo appends to the undo stack, dropping the oldest entry when full (caller must hold undoMutex)
+func (t *WriteTool) pushUndo(entry UndoEntry) {
 	// Limit stack size
 	if len(t.undoStack) >= MaxUndoStack {
 		t.undoStack = t.undoStack[1:]
@@ -254,8 +354,10 @@ func (t *WriteTool) addToUndoStack(entry UndoEntry) {
 	t.undoStack = append(t.undoStack, entry)
 }
 
-// Undo reverts the last write operation
+// Undo reverts the last write operation. The reverted change moves to the
+// redo stack so Redo can apply it again.
 func (t *WriteTool) Undo() error {
+	defer t.lockChanges()()
 	t.undoMutex.Lock()
 	defer t.undoMutex.Unlock()
 
@@ -263,8 +365,64 @@ func (t *WriteTool) Undo() error {
 		return fmt.Errorf("nothing to undo")
 	}
 
+	// Keep the entry if reverting fails (e.g. a created directory is no longer empty) so it can be retried
 	entry := t.undoStack[len(t.undoStack)-1]
+	if err := revertEntry(entry); err != nil {
+		return err
+	}
 	t.undoStack = t.undoStack[:len(t.undoStack)-1]
+	t.redoStack = append(t.redoStack, entry)
+	return nil
+}
+
+// Redo re-applies the most recently undone change and returns it to the undo stack
+func (t *WriteTool) Redo() error {
+	defer t.lockChanges()()
+	t.undoMutex.Lock()
+	defer t.undoMutex.Unlock()
+
+	if len(t.redoStack) == 0 {
+		return fmt.Errorf("nothing to redo")
+	}
+
+	entry := t.redoStack[len(t.redoStack)-1]
+	if err := applyEntry(entry); err != nil {
+		return err
+	}
+	t.redoStack = t.redoStack[:len(t.redoStack)-1]
+	t.pushUndo(entry)
+	return nil
+}
+
+// revertEntry restores the state from before entry's change
+func revertEntry(entry UndoEntry) error {
+	if len(entry.Files) > 0 {
+		for i := len(entry.Files) - 1; i >= 0; i-- {
+			if err := revertEntry(entry.Files[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// Directory entries only remove what make_directory created
+	if entry.IsDir {
+		return removeEmptyDirTree(entry.Path)
+	}
+
+	if entry.Deleted {
+		if err := os.MkdirAll(filepath.Dir(entry.Path), 0755); err != nil {
+			return err
+		}
+		return writeFileAtomic(entry.Path, entry.OldContent)
+	}
+
+	if entry.MovedFrom != "" {
+		if err := os.MkdirAll(filepath.Dir(entry.MovedFrom), 0755); err != nil {
+			return err
+		}
+		return os.Rename(entry.Path, entry.MovedFrom)
+	}
 
 	// Write old content back
 	if entry.OldContent == "" {
@@ -272,13 +430,53 @@ func (t *WriteTool) Undo() error {
 		return os.Remove(entry.Path)
 	}
 
-	// Atomic write
-	tmpFile := entry.Path + ".tmp"
-	if err := os.WriteFile(tmpFile, []byte(entry.OldContent), 0644); err != nil {
+	return writeFileAtomic(entry.Path, entry.OldContent)
+}
+
+// applyEntry redoes entry's change
+func applyEntry(entry UndoEntry) error {
+	if len(entry.Files) > 0 {
+		for _, file := range entry.Files {
+			if err := applyEntry(file); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if entry.Deleted {
+		return os.Remove(entry.Path)
+	}
+
+	if entry.MovedFrom != "" {
+		if err := os.MkdirAll(filepath.Dir(entry.Path), 0755); err != nil {
+			return err
+		}
+		return os.Rename(entry.MovedFrom, entry.Path)
+	}
+
+	if entry.IsDir {
+		dir := entry.DirPath
+		if dir == "" {
+			dir = entry.Path
+		}
+		return os.MkdirAll(dir, 0755)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(entry.Path), 0755); err != nil {
+		return err
+	}
+	return writeFileAtomic(entry.Path, entry.NewContent)
+}
+
+// writeFileAtomic writes content through a temp file and a rename
+func writeFileAtomic(path, content string) error {
+	tmpFile := path + ".tmp"
+	if err := os.WriteFile(tmpFile, []byte(content), 0644); err != nil {
 		return err
 	}
 
-	if err := os.Rename(tmpFile, entry.Path); err != nil {
+	if err := os.Rename(tmpFile, path); err != nil {
 		os.Remove(tmpFile)
 		return err
 	}
@@ -296,9 +494,24 @@ func (t *WriteTool) GetUndoStack() []UndoEntry {
 	return stack
 }
 
+// GetRedoStack returns the changes that Redo can re-apply, most recent last
+func (t *WriteTool) GetRedoStack() []UndoEntry {
+	t.undoMutex.Lock()
+	defer t.undoMutex.Unlock()
+
+	stack := make([]UndoEntry, len(t.redoStack))
+	copy(stack, t.redoStack)
+	return stack
+}
+
 // UndoEntry represents an undo entry
 type UndoEntry struct {
 	Path       string
 	OldContent string
 	NewContent string
+	IsDir      bool   // Path is a directory created by make_directory
+	DirPath    string // For IsDir: the full directory path that was requested (re-created by Redo)
+	Deleted    bool   // The change deleted Path (apply_patch)
+	MovedFrom  string // For moves: the original path; Path is the destination (move_file)
+	Files      []UndoEntry // Changes to several files undone and redone together (apply_patch)
 }
@@ -6,21 +6,34 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
 	"strings"
 	"time"
 
 	"github.com/bmatcuk/doublestar/v4"
+	"github.com/zephel01/vibe-local-go/internal/security"
 )
 
 const (
-	// MaxResults is the maximum number of results to return
-	MaxResults = 200
+	// DefaultGlobLimit is the default number of results to return
+	DefaultGlobLimit = 1000
+	// MaxGlobLimit is the upper bound for the limit parameter
+	MaxGlobLimit = 10000
+)
+
+// Glob sort orders
+const (
+	GlobSortName  = "name"  // Path, ascending
+	GlobSortMtime = "mtime" // Modification time, newest first
+	GlobSortSize  = "size"  // File size, largest first
 )
 
 // GlobTool searches for files matching patterns
 type GlobTool struct {
-	baseDir string
+	baseDir    string
+	maxDepth   int // Directory depth limit for ** patterns (0 = DefaultMaxWalkDepth)
+	vibeIgnore *IgnoreMatcher
 }
 
 // NewGlobTool creates a new glob tool
@@ -28,6 +41,16 @@ func NewGlobTool() *GlobTool {
 	return &GlobTool{}
 }
 
+// SetMaxDepth sets the directory depth limit for recursive patterns (<= 0 = default)
+func (t *GlobTool) SetMaxDepth(depth int) {
+	t.maxDepth = depth
+}
+
+// SetVibeIgnore sets the .vibeignore matcher; matching paths are left out of the results (nil = none)
+func (t *GlobTool) SetVibeIgnore(m *IgnoreMatcher) {
+	t.vibeIgnore = m
+}
+
 // Name returns the tool name
 func (t *GlobTool) Name() string {
 	return "glob"
@@ -45,13 +68,38 @@ func (t *GlobTool) Schema() *FunctionSchema {
 					Type:        "string",
 					Description: "Glob pattern (e.g., '*.go', 'src/**/*.js', 'test?')",
 				},
+				"patterns": {
+					Type:        "array",
+					Description: "Several glob patterns at once; files matching any of them are returned (combined with pattern)",
+					Items:       &PropertyDef{Type: "string"},
+				},
+				"exclude": {
+					Type:        "array",
+					Description: "Glob patterns relative to path to leave out (e.g., ['**/*_test.go'])",
+					Items:       &PropertyDef{Type: "string"},
+				},
 				"path": {
 					Type:        "string",
 					Description: "Directory to search in (default: current directory)",
 					Default:     ".",
 				},
+				"sort": {
+					Type:        "string",
+					Description: "Result order: 'name' (path), 'mtime' (newest first), 'size' (largest first)",
+					Enum:        []string{GlobSortName, GlobSortMtime, GlobSortSize},
+					Default:     GlobSortName,
+				},
+				"limit": {
+					Type:        "integer",
+					Description: "Maximum number of files to return",
+					Default:     DefaultGlobLimit,
+				},
+				"respect_gitignore": {
+					Type:        "boolean",
+					Description: "Skip files excluded by .gitignore in the search root (default: true)",
+					Default:     true,
+				},
 			},
-			Required: []string{"pattern"},
 		},
 	}
 }
@@ -59,19 +107,55 @@ func (t *GlobTool) Schema() *FunctionSchema {
 // Execute searches for files
 func (t *GlobTool) Execute(ctx context.Context, params json.RawMessage) (*Result, error) {
 	var args struct {
-		Pattern string `json:"pattern"`
-		Path    string `json:"path"`
+		Pattern  string   `json:"pattern"`
+		Patterns []string `json:"patterns"`
+		Exclude  []string `json:"exclude"`
+		Path     string   `json:"path"`
+		Sort     string   `json:"sort"`
+		Limit    int      `json:"limit"`
+		// nil = default (true)
+		RespectGitignore *bool `json:"respect_gitignore"`
 	}
 
 	if err := json.Unmarshal(params, &args); err != nil {
 		return NewErrorResult(err), nil
 	}
 
-	if args.Pattern == "" {
-		return NewErrorResult(fmt.Errorf("pattern cannot be empty")), nil
+	// pattern and patterns are combined; empty entries are ignored
+	var includes []string
+	for _, p := range append([]string{args.Pattern}, args.Patterns...) {
+		if p != "" {
+			includes = append(includes, p)
+		}
+	}
+	if len(includes) == 0 {
+		return NewErrorResult(fmt.Errorf("pattern cannot be empty (set pattern or patterns)")), nil
+	}
+	for _, p := range args.Exclude {
+		if !doublestar.ValidatePattern(p) {
+			return NewErrorResult(fmt.Errorf("invalid exclude pattern '%s'", p)), nil
+		}
+	}
+	label := strings.Join(includes, "', '")
+	if len(args.Exclude) > 0 {
+		label += "' excluding '" + strings.Join(args.Exclude, "', '")
 	}
 
-	// Set default path
+	// Set defaults
+	if args.Sort == "" {
+		args.Sort = GlobSortName
+	}
+	if args.Sort != GlobSortName && args.Sort != GlobSortMtime && args.Sort != GlobSortSize {
+		return NewErrorResult(fmt.Errorf("invalid sort '%s' (expected name, mtime or size)", args.Sort)), nil
+	}
+	if args.Limit <= 0 {
+		args.Limit = DefaultGlobLimit
+	}
+	if args.Limit > MaxGlobLimit {
+		args.Limit = MaxGlobLimit
+	}
+
+	args.Path = security.NormalizePath(args.Path)
 	if args.Path == "" {
 		args.Path = "."
 	}
@@ -82,44 +166,128 @@ func (t *GlobTool) Execute(ctx context.Context, params json.RawMessage) (*Result
 		return NewErrorResult(err), nil
 	}
 
-	// Convert glob pattern
-	matches, err := t.globSearch(searchPath, args.Pattern)
-	if err != nil || len(matches) == 0 {
-		suggestedPattern := inferFilePattern(args.Pattern)
-		return NewErrorResult(fmt.Errorf("no files match '%s'. Try: bash ls %s", args.Pattern, suggestedPattern)), nil
+	// Load .gitignore from the search root; .vibeignore'd paths are always left out
+	var gitignore *IgnoreMatcher
+	if args.RespectGitignore == nil || *args.RespectGitignore {
+		gitignore = LoadIgnore(searchPath)
 	}
+	ignore := newSearchFilter(gitignore, t.vibeIgnore, searchPath)
+
+	matches, stats := t.globAll(searchPath, includes, args.Exclude, ignore)
+	notice := stats.notice(t.depthLimit())
+	if len(matches) == 0 {
+		suggestedPattern := inferFilePattern(includes[0])
+		return NewErrorResult(fmt.Errorf("no files match '%s'. Try: bash ls %s%s", label, suggestedPattern, notice)), nil
+	}
+
+	sortMatches(matches, args.Sort)
 
 	// Format output
 	var output strings.Builder
-	output.WriteString(fmt.Sprintf("Found %d files matching '%s':\n\n", len(matches), args.Pattern))
+	output.WriteString(fmt.Sprintf("Found %d files matching '%s':\n\n", len(matches), label))
 
 	for i, match := range matches {
-		if i >= MaxResults {
-			output.WriteString(fmt.Sprintf("... (showing first %d of %d results)", MaxResults, len(matches)))
+		if i >= args.Limit {
+			output.WriteString(fmt.Sprintf("... (%d more files omitted; showing first %d of %d, sorted by %s)",
+				len(matches)-args.Limit, args.Limit, len(matches), args.Sort))
 			break
 		}
 		output.WriteString(match.Path + "\n")
 	}
+	output.WriteString(notice)
 
 	return NewResult(output.String()), nil
 }
 
-// globSearch performs the actual glob search
-func (t *GlobTool) globSearch(basePath, pattern string) ([]FileMatch, error) {
+// depthLimit returns the effective directory depth limit
+func (t *GlobTool) depthLimit() int {
+	if t.maxDepth > 0 {
+		return t.maxDepth
+	}
+	return DefaultMaxWalkDepth
+}
+
+// globAll unions the matches of every include pattern (each file once) and drops
+// files whose path relative to basePath matches an exclude pattern.
+// Patterns that fail to search are skipped, like a pattern with no matches.
+func (t *GlobTool) globAll(basePath string, includes, excludes []string, ignore *searchFilter) ([]FileMatch, *walkStats) {
+	var matches []FileMatch
+	var stats *walkStats
+	seen := make(map[string]bool)
+
+	for _, pattern := range includes {
+		found, s, err := t.globSearch(basePath, pattern, ignore)
+		stats = mergeWalkStats(stats, s)
+		if err != nil {
+			continue
+		}
+		for _, m := range found {
+			if seen[m.Path] || isExcluded(basePath, m.Path, excludes) {
+				continue
+			}
+			seen[m.Path] = true
+			matches = append(matches, m)
+		}
+	}
+	return matches, stats
+}
+
+// isExcluded reports whether path (absolute) matches one of the exclude patterns
+func isExcluded(basePath, path string, excludes []string) bool {
+	if len(excludes) == 0 {
+		return false
+	}
+	relPath, err := filepath.Rel(basePath, path)
+	if err != nil {
+		return false
+	}
+	relPath = filepath.ToSlash(relPath)
+	for _, pattern := range excludes {
+		if matched, _ := matchPattern(relPath, pattern); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeWalkStats combines the stats of several walks over the same tree (nil = nothing skipped)
+func mergeWalkStats(a, b *walkStats) *walkStats {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+	merged := &walkStats{depthLimited: max(a.depthLimited, b.depthLimited)}
+	merged.cycles = append(merged.cycles, a.cycles...)
+	for _, c := range b.cycles {
+		if !slices.Contains(merged.cycles, c) {
+			merged.cycles = append(merged.cycles, c)
+		}
+	}
+	return merged
+}
+
+// globSearch performs the actual glob search; ignore excludes .gitignore'd and .vibeignore'd paths.
+// stats (nil for non-recursive patterns) reports directories the walk skipped.
+func (t *GlobTool) globSearch(basePath, pattern string, ignore *searchFilter) ([]FileMatch, *walkStats, error) {
 	var matches []FileMatch
 
 	// Handle recursive patterns (**)
 	if strings.Contains(pattern, "**") {
-		return t.globRecursive(basePath, pattern)
+		return t.globRecursive(basePath, pattern, ignore)
 	}
 
 	// Non-recursive pattern
 	files, err := filepath.Glob(filepath.Join(basePath, pattern))
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	for _, file := range files {
+		if ignore.Match(file, false) || underSkipDir(basePath, file) {
+			continue
+		}
 		if info, err := os.Stat(file); err == nil && !info.IsDir() {
 			matches = append(matches, FileMatch{
 				Path:    file,
@@ -129,22 +297,22 @@ func (t *GlobTool) globSearch(basePath, pattern string) ([]FileMatch, error) {
 		}
 	}
 
-	return matches, nil
+	return matches, nil, nil
 }
 
 // globRecursive handles recursive glob patterns
-func (t *GlobTool) globRecursive(basePath, pattern string) ([]FileMatch, error) {
+func (t *GlobTool) globRecursive(basePath, pattern string, ignore *searchFilter) ([]FileMatch, *walkStats, error) {
 	var matches []FileMatch
 
 	// Split pattern by **
 	parts := strings.Split(pattern, "**")
 	if len(parts) < 2 {
 		// Fallback to non-recursive
-		return t.globSearch(basePath, pattern)
+		return t.globSearch(basePath, pattern, ignore)
 	}
 
-	// Walk directory tree
-	err := filepath.WalkDir(basePath, func(path string, d os.DirEntry, err error) error {
+	// Walk directory tree (follows symlinked directories inside the root, bounded by the depth limit)
+	stats, err := walkTree(basePath, t.maxDepth, func(path string, d os.DirEntry, err error) error {
 		if err != nil {
 			return nil
 		}
@@ -154,17 +322,25 @@ func (t *GlobTool) globRecursive(basePath, pattern string) ([]FileMatch, error)
 			return filepath.SkipDir
 		}
 
-		// Skip directories
-		if d.IsDir() {
-			return nil
-		}
-
 		// Get relative path
 		relPath, err := filepath.Rel(basePath, path)
 		if err != nil {
 			return nil
 		}
 
+		// Skip .gitignore'd and .vibeignore'd entries (whole directories at once)
+		if ignore.Match(relPath, d.IsDir()) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		// Skip directories
+		if d.IsDir() {
+			return nil
+		}
+
 		// Check if matches pattern
 		matched, err := matchPattern(relPath, pattern)
 		if err != nil {
@@ -185,15 +361,28 @@ func (t *GlobTool) globRecursive(basePath, pattern string) ([]FileMatch, error)
 	})
 
 	if err != nil {
-		return nil, err
+		return nil, stats, err
 	}
 
-	// Sort by modification time (newest first)
-	sort.Slice(matches, func(i, j int) bool {
-		return matches[i].ModTime.After(matches[j].ModTime)
-	})
+	return matches, stats, nil
+}
 
-	return matches, nil
+// sortMatches orders matches by the given sort key; ties fall back to path order
+func sortMatches(matches []FileMatch, by string) {
+	sort.SliceStable(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		switch by {
+		case GlobSortMtime:
+			if !a.ModTime.Equal(b.ModTime) {
+				return a.ModTime.After(b.ModTime)
+			}
+		case GlobSortSize:
+			if a.Size != b.Size {
+				return a.Size > b.Size
+			}
+		}
+		return a.Path < b.Path
+	})
 }
 
 // matchPattern checks if a path matches a glob pattern
@@ -203,6 +392,21 @@ func matchPattern(path, pattern string) (bool, error) {
 	return matched, err
 }
 
+// underSkipDir reports whether path lies inside a skipped directory below basePath,
+// so non-recursive patterns leave out the same trees the recursive walk prunes
+func underSkipDir(basePath, path string) bool {
+	rel, err := filepath.Rel(basePath, filepath.Dir(path))
+	if err != nil || rel == "." {
+		return false
+	}
+	for _, dir := range strings.Split(rel, string(filepath.Separator)) {
+		if isSkipDir(dir) {
+			return true
+		}
+	}
+	return false
+}
+
 // isSkipDir checks if a directory should be skipped
 func isSkipDir(path string) bool {
 	basename := filepath.Base(path)
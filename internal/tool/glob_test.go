@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestNewGlobTool(t *testing.T) {
@@ -37,10 +38,14 @@ func TestGlobTool_Schema(t *testing.T) {
 		t.Fatal("expected parameters to be non-nil")
 	}
 
-	// Check required fields
-	required := schema.Parameters.Required
-	if len(required) != 1 || required[0] != "pattern" {
-		t.Errorf("expected required ['pattern'], got %v", required)
+	// pattern and patterns are alternatives, so neither is required
+	if required := schema.Parameters.Required; len(required) != 0 {
+		t.Errorf("expected no required fields, got %v", required)
+	}
+	for _, name := range []string{"patterns", "exclude"} {
+		if prop, ok := schema.Parameters.Properties[name]; !ok || prop.Type != "array" || prop.Items == nil || prop.Items.Type != "string" {
+			t.Errorf("expected '%s' to be an array of strings, got %+v", name, prop)
+		}
 	}
 
 	// Check pattern property
@@ -153,6 +158,33 @@ func TestGlobTool_Execute_RecursivePattern(t *testing.T) {
 	}
 }
 
+func TestGlobTool_Execute_WindowsStylePath(t *testing.T) {
+	tool := NewGlobTool()
+
+	tmpDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(tmpDir, "src"), 0755); err != nil {
+		t.Fatalf("failed to create test dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "src", "a.go"), []byte("package a"), 0644); err != nil {
+		t.Fatalf("failed to create test file: %v", err)
+	}
+
+	params, _ := json.Marshal(map[string]string{
+		"pattern": "*.go",
+		"path":    tmpDir + `\src`,
+	})
+	result, err := tool.Execute(context.Background(), params)
+	if err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+	if result.IsError {
+		t.Fatalf("expected success, got error: %s", result.Error)
+	}
+	if !strings.Contains(result.Output, "a.go") {
+		t.Errorf("expected a.go in results, got '%s'", result.Output)
+	}
+}
+
 func TestGlobTool_Execute_EmptyPattern(t *testing.T) {
 	tool := NewGlobTool()
 	ctx := context.Background()
@@ -419,3 +451,343 @@ func TestGlobTool_Execute_RecursivePatternWithDoublestar(t *testing.T) {
 	}
 }
 
+
+// createStaggeredFiles creates files whose modification times increase in the given order
+func createStaggeredFiles(t *testing.T, dir string, names []string) {
+	t.Helper()
+	base := time.Now().Add(-time.Hour)
+	for i, name := range names {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(strings.Repeat("x", i+1)), 0644); err != nil {
+			t.Fatalf("failed to create test file: %v", err)
+		}
+		mtime := base.Add(time.Duration(i) * time.Minute)
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatalf("failed to set mtime: %v", err)
+		}
+	}
+}
+
+// globOutputOrder returns the base names of listed files in output order
+func globOutputOrder(output string) []string {
+	var names []string
+	for _, line := range strings.Split(output, "\n") {
+		if filepath.IsAbs(line) {
+			names = append(names, filepath.Base(line))
+		}
+	}
+	return names
+}
+
+func TestGlobTool_Execute_SortByMtime(t *testing.T) {
+	tool := NewGlobTool()
+	tmpDir := t.TempDir()
+	// oldest → newest
+	createStaggeredFiles(t, tmpDir, []string{"b.txt", "c.txt", "a.txt"})
+
+	params, _ := json.Marshal(map[string]interface{}{"pattern": "*.txt", "path": tmpDir, "sort": "mtime"})
+	result, err := tool.Execute(context.Background(), params)
+	if err != nil || result.IsError {
+		t.Fatalf("unexpected error: %v %s", err, result.Error)
+	}
+
+	got := strings.Join(globOutputOrder(result.Output), ",")
+	if got != "a.txt,c.txt,b.txt" {
+		t.Errorf("mtime order = %s, want a.txt,c.txt,b.txt (newest first)", got)
+	}
+}
+
+func TestGlobTool_Execute_SortByMtimeRecursive(t *testing.T) {
+	tool := NewGlobTool()
+	tmpDir := t.TempDir()
+	subDir := filepath.Join(tmpDir, "sub")
+	if err := os.Mkdir(subDir, 0755); err != nil {
+		t.Fatalf("failed to create subdir: %v", err)
+	}
+	createStaggeredFiles(t, subDir, []string{"new.go", "old.go"})
+	// Make old.go older than new.go
+	past := time.Now().Add(-2 * time.Hour)
+	if err := os.Chtimes(filepath.Join(subDir, "old.go"), past, past); err != nil {
+		t.Fatalf("failed to set mtime: %v", err)
+	}
+
+	params, _ := json.Marshal(map[string]interface{}{"pattern": "**/*.go", "path": tmpDir, "sort": "mtime"})
+	result, err := tool.Execute(context.Background(), params)
+	if err != nil || result.IsError {
+		t.Fatalf("unexpected error: %v %s", err, result.Error)
+	}
+
+	got := strings.Join(globOutputOrder(result.Output), ",")
+	if got != "new.go,old.go" {
+		t.Errorf("mtime order = %s, want new.go,old.go", got)
+	}
+}
+
+func TestGlobTool_Execute_SortByNameAndSize(t *testing.T) {
+	tool := NewGlobTool()
+	tmpDir := t.TempDir()
+	// sizes: b=1, c=2, a=3
+	createStaggeredFiles(t, tmpDir, []string{"b.txt", "c.txt", "a.txt"})
+
+	params, _ := json.Marshal(map[string]interface{}{"pattern": "*.txt", "path": tmpDir})
+	result, _ := tool.Execute(context.Background(), params)
+	if got := strings.Join(globOutputOrder(result.Output), ","); got != "a.txt,b.txt,c.txt" {
+		t.Errorf("default order = %s, want a.txt,b.txt,c.txt", got)
+	}
+
+	params, _ = json.Marshal(map[string]interface{}{"pattern": "*.txt", "path": tmpDir, "sort": "size"})
+	result, _ = tool.Execute(context.Background(), params)
+	if got := strings.Join(globOutputOrder(result.Output), ","); got != "a.txt,c.txt,b.txt" {
+		t.Errorf("size order = %s, want a.txt,c.txt,b.txt (largest first)", got)
+	}
+}
+
+func TestGlobTool_Execute_Limit(t *testing.T) {
+	tool := NewGlobTool()
+	tmpDir := t.TempDir()
+	createStaggeredFiles(t, tmpDir, []string{"a.txt", "b.txt", "c.txt", "d.txt", "e.txt"})
+
+	params, _ := json.Marshal(map[string]interface{}{"pattern": "*.txt", "path": tmpDir, "limit": 2})
+	result, err := tool.Execute(context.Background(), params)
+	if err != nil || result.IsError {
+		t.Fatalf("unexpected error: %v %s", err, result.Error)
+	}
+
+	if got := globOutputOrder(result.Output); len(got) != 2 {
+		t.Errorf("expected 2 files listed, got %v", got)
+	}
+	if !strings.Contains(result.Output, "3 more files omitted") {
+		t.Errorf("expected omitted note, got: %s", result.Output)
+	}
+}
+
+func TestGlobTool_Execute_InvalidSort(t *testing.T) {
+	tool := NewGlobTool()
+	params := json.RawMessage(`{"pattern": "*.txt", "sort": "random"}`)
+	result, err := tool.Execute(context.Background(), params)
+	if err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+	if !result.IsError || !strings.Contains(result.Error, "invalid sort") {
+		t.Errorf("expected invalid sort error, got: %+v", result)
+	}
+}
+
+func TestGlobTool_Execute_RespectsGitignore(t *testing.T) {
+	tool := NewGlobTool()
+	root := createIgnoreRepo(t)
+
+	params, _ := json.Marshal(map[string]interface{}{"pattern": "**/*.go", "path": root})
+	result, err := tool.Execute(context.Background(), params)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if result.IsError {
+		t.Fatalf("expected success, got error: %s", result.Error)
+	}
+	for _, want := range []string{"main.go", "lib.go", "generated.go"} {
+		if !strings.Contains(result.Output, want) {
+			t.Errorf("expected %s in results, got: %s", want, result.Output)
+		}
+	}
+	for _, unwanted := range []string{"out.go", "gen.go", "api.go", "HEAD.go"} {
+		if strings.Contains(result.Output, unwanted) {
+			t.Errorf("did not expect ignored file %s in results, got: %s", unwanted, result.Output)
+		}
+	}
+
+	// Non-recursive patterns are filtered too
+	params, _ = json.Marshal(map[string]interface{}{"pattern": "*.log", "path": root})
+	result, _ = tool.Execute(context.Background(), params)
+	if !strings.Contains(result.Output, "keep.log") || strings.Contains(result.Output, "app.log") {
+		t.Errorf("expected only keep.log, got: %s", result.Output)
+	}
+
+	params, _ = json.Marshal(map[string]interface{}{"pattern": "**/*.go", "path": root, "respect_gitignore": false})
+	result, _ = tool.Execute(context.Background(), params)
+	for _, want := range []string{"out.go", "gen.go", "api.go"} {
+		if !strings.Contains(result.Output, want) {
+			t.Errorf("expected %s with respect_gitignore=false, got: %s", want, result.Output)
+		}
+	}
+
+	// .git stays hidden without .gitignore, for non-recursive patterns too
+	for _, pattern := range []string{"**/*.go", "*/*.go"} {
+		params, _ = json.Marshal(map[string]interface{}{"pattern": pattern, "path": root, "respect_gitignore": false})
+		result, _ = tool.Execute(context.Background(), params)
+		if strings.Contains(result.Output, "HEAD.go") {
+			t.Errorf("did not expect .git contents for %s with respect_gitignore=false, got: %s", pattern, result.Output)
+		}
+	}
+}
+
+func TestGlobTool_Execute_MaxDepth(t *testing.T) {
+	tmpDir := t.TempDir()
+	deep := filepath.Join(tmpDir, "a", "b", "c", "d")
+	if err := os.MkdirAll(deep, 0755); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(tmpDir, "top.txt"), []byte("x"), 0644)
+	os.WriteFile(filepath.Join(tmpDir, "a", "b", "mid.txt"), []byte("x"), 0644)
+	os.WriteFile(filepath.Join(deep, "deep.txt"), []byte("x"), 0644)
+
+	tool := NewGlobTool()
+	tool.SetMaxDepth(3)
+	params, _ := json.Marshal(map[string]interface{}{"pattern": "**/*.txt", "path": tmpDir})
+	result, err := tool.Execute(context.Background(), params)
+	if err != nil || result.IsError {
+		t.Fatalf("expected success, got %v %s", err, result.Error)
+	}
+
+	if !strings.Contains(result.Output, "top.txt") || !strings.Contains(result.Output, "mid.txt") {
+		t.Errorf("expected shallow files in output, got: %s", result.Output)
+	}
+	if strings.Contains(result.Output, "deep.txt") {
+		t.Errorf("deep.txt is below the depth limit and should be skipped: %s", result.Output)
+	}
+	if !strings.Contains(result.Output, "max depth 3 reached") {
+		t.Errorf("expected depth notice, got: %s", result.Output)
+	}
+}
+
+func TestGlobTool_Execute_SymlinkCycle(t *testing.T) {
+	tmpDir := t.TempDir()
+	sub := filepath.Join(tmpDir, "sub")
+	if err := os.MkdirAll(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(sub, "file.txt"), []byte("x"), 0644)
+	if err := os.Symlink(tmpDir, filepath.Join(sub, "loop")); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+
+	tool := NewGlobTool()
+	params, _ := json.Marshal(map[string]interface{}{"pattern": "**/*.txt", "path": tmpDir})
+	result, err := tool.Execute(context.Background(), params)
+	if err != nil || result.IsError {
+		t.Fatalf("expected success, got %v %s", err, result.Error)
+	}
+
+	if !strings.Contains(result.Output, "Found 1 files") {
+		t.Errorf("expected the file once, got: %s", result.Output)
+	}
+	if !strings.Contains(result.Output, "symlink cycle") || !strings.Contains(result.Output, filepath.Join("sub", "loop")) {
+		t.Errorf("expected cycle notice naming sub/loop, got: %s", result.Output)
+	}
+}
+
+func TestGlobTool_Execute_SymlinkToSiblingReportedOnce(t *testing.T) {
+	root := t.TempDir()
+	real := filepath.Join(root, "pkg")
+	if err := os.MkdirAll(real, 0755); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(real, "file.txt"), []byte("x"), 0644)
+	// Sorts before "pkg", so the link is listed first
+	if err := os.Symlink(real, filepath.Join(root, "0link")); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+
+	tool := NewGlobTool()
+	params, _ := json.Marshal(map[string]interface{}{"pattern": "**/*.txt", "path": root})
+	result, err := tool.Execute(context.Background(), params)
+	if err != nil || result.IsError {
+		t.Fatalf("expected success, got %v %s", err, result.Error)
+	}
+
+	if !strings.Contains(result.Output, "Found 1 files") {
+		t.Errorf("expected the file once, got: %s", result.Output)
+	}
+	if !strings.Contains(result.Output, filepath.Join("pkg", "file.txt")) || strings.Contains(result.Output, "0link") {
+		t.Errorf("expected the file under its real path, got: %s", result.Output)
+	}
+}
+
+// globTestTree creates files (slash-separated paths) under a temp dir and returns it
+func globTestTree(t *testing.T, files ...string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for _, f := range files {
+		path := filepath.Join(dir, filepath.FromSlash(f))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("test"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestGlobTool_Execute_ExcludePatterns(t *testing.T) {
+	dir := globTestTree(t, "main.go", "main_test.go", "pkg/util.go", "pkg/util_test.go", "README.md")
+
+	params, _ := json.Marshal(map[string]interface{}{
+		"patterns": []string{"**/*.go"},
+		"exclude":  []string{"**/*_test.go"},
+		"path":     dir,
+	})
+	result, err := NewGlobTool().Execute(context.Background(), params)
+	if err != nil || result.IsError {
+		t.Fatalf("Execute: %v %s", err, result.Error)
+	}
+
+	for _, want := range []string{"main.go", filepath.Join("pkg", "util.go")} {
+		if !strings.Contains(result.Output, filepath.Join(dir, want)+"\n") {
+			t.Errorf("expected %s in results:\n%s", want, result.Output)
+		}
+	}
+	if strings.Contains(result.Output, filepath.Join(dir, "main_test.go")) ||
+		strings.Contains(result.Output, filepath.Join(dir, "pkg", "util_test.go")) ||
+		strings.Contains(result.Output, "README.md") {
+		t.Errorf("test files and non-Go files should be left out:\n%s", result.Output)
+	}
+	if !strings.Contains(result.Output, "Found 2 files") {
+		t.Errorf("expected 2 files:\n%s", result.Output)
+	}
+}
+
+func TestGlobTool_Execute_MultiplePatterns(t *testing.T) {
+	dir := globTestTree(t, "main.go", "go.mod", "docs/guide.md", "web/app.ts")
+
+	// pattern and patterns combine; a file matched twice is listed once
+	params, _ := json.Marshal(map[string]interface{}{
+		"pattern":  "*.go",
+		"patterns": []string{"**/*.md", "go.mod", "main.*"},
+		"path":     dir,
+	})
+	result, err := NewGlobTool().Execute(context.Background(), params)
+	if err != nil || result.IsError {
+		t.Fatalf("Execute: %v %s", err, result.Error)
+	}
+
+	if !strings.Contains(result.Output, "Found 3 files") {
+		t.Errorf("expected main.go, go.mod and docs/guide.md once each:\n%s", result.Output)
+	}
+	if strings.Count(result.Output, filepath.Join(dir, "main.go")) != 1 {
+		t.Errorf("main.go should be listed once:\n%s", result.Output)
+	}
+	if strings.Contains(result.Output, "app.ts") {
+		t.Errorf("app.ts matches no pattern:\n%s", result.Output)
+	}
+}
+
+func TestGlobTool_Execute_ExcludeEverything(t *testing.T) {
+	dir := globTestTree(t, "a_test.go")
+
+	params, _ := json.Marshal(map[string]interface{}{
+		"pattern": "*.go",
+		"exclude": []string{"*_test.go"},
+		"path":    dir,
+	})
+	result, _ := NewGlobTool().Execute(context.Background(), params)
+	if !result.IsError || !strings.Contains(result.Error, "excluding '*_test.go'") {
+		t.Errorf("expected a no-match error naming the exclude, got %+v", result)
+	}
+
+	params, _ = json.Marshal(map[string]interface{}{"pattern": "*.go", "exclude": []string{"[unclosed"}, "path": dir})
+	result, _ = NewGlobTool().Execute(context.Background(), params)
+	if !result.IsError || !strings.Contains(result.Error, "invalid exclude pattern") {
+		t.Errorf("expected an invalid exclude error, got %+v", result)
+	}
+}
@@ -10,6 +10,8 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+
+	"github.com/zephel01/vibe-local-go/internal/security"
 )
 
 const (
@@ -21,7 +23,10 @@ const (
 
 // GrepTool searches for text patterns in files
 type GrepTool struct {
-	baseDir string
+	baseDir    string
+	maxDepth   int // Directory depth limit (0 = DefaultMaxWalkDepth)
+	maxOutput  int // Output size limit in bytes (0 = MaxOutputLength)
+	vibeIgnore *IgnoreMatcher
 }
 
 // NewGrepTool creates a new grep tool
@@ -29,6 +34,21 @@ func NewGrepTool() *GrepTool {
 	return &GrepTool{}
 }
 
+// SetMaxDepth sets the directory depth limit (<= 0 = default)
+func (t *GrepTool) SetMaxDepth(depth int) {
+	t.maxDepth = depth
+}
+
+// SetVibeIgnore sets the .vibeignore matcher; matching paths are left out of the results (nil = none)
+func (t *GrepTool) SetVibeIgnore(m *IgnoreMatcher) {
+	t.vibeIgnore = m
+}
+
+// SetMaxOutput sets the output size limit in bytes (<= 0 = MaxOutputLength)
+func (t *GrepTool) SetMaxOutput(maxBytes int) {
+	t.maxOutput = maxBytes
+}
+
 // Name returns the tool name
 func (t *GrepTool) Name() string {
 	return "grep"
@@ -71,6 +91,11 @@ func (t *GrepTool) Schema() *FunctionSchema {
 					Description: "Glob pattern to filter files (e.g., '*.go')",
 					Default:     "*",
 				},
+				"respect_gitignore": {
+					Type:        "boolean",
+					Description: "Skip files excluded by .gitignore in the search root (default: true)",
+					Default:     true,
+				},
 			},
 			Required: []string{"pattern"},
 		},
@@ -86,6 +111,8 @@ func (t *GrepTool) Execute(ctx context.Context, params json.RawMessage) (*Result
 		ContextLines int    `json:"context_lines"`
 		MaxMatches   int    `json:"max_matches"`
 		FilePattern  string `json:"file_pattern"`
+		// nil = default (true)
+		RespectGitignore *bool `json:"respect_gitignore"`
 	}
 
 	if err := json.Unmarshal(params, &args); err != nil {
@@ -97,6 +124,7 @@ func (t *GrepTool) Execute(ctx context.Context, params json.RawMessage) (*Result
 	}
 
 	// Set defaults
+	args.Path = security.NormalizePath(args.Path)
 	if args.Path == "" {
 		args.Path = "."
 	}
@@ -124,8 +152,15 @@ func (t *GrepTool) Execute(ctx context.Context, params json.RawMessage) (*Result
 		return NewErrorResult(fmt.Errorf("invalid regex pattern: %w", err)), nil
 	}
 
+	// Load .gitignore from the search root; .vibeignore'd paths are always left out
+	var gitignore *IgnoreMatcher
+	if args.RespectGitignore == nil || *args.RespectGitignore {
+		gitignore = LoadIgnore(args.Path)
+	}
+	ignore := newSearchFilter(gitignore, t.vibeIgnore, args.Path)
+
 	// Perform search
-	results, err := t.grepSearch(args.Path, args.FilePattern, re, args.Mode, args.ContextLines, args.MaxMatches)
+	results, stats, err := t.grepSearch(args.Path, args.FilePattern, re, args.Mode, args.ContextLines, args.MaxMatches, ignore)
 	if err != nil {
 		return NewErrorResult(err), nil
 	}
@@ -149,16 +184,26 @@ func (t *GrepTool) Execute(ctx context.Context, params json.RawMessage) (*Result
 			output.WriteString(fmt.Sprintf("%s: %d\n", match.FilePath, match.Count))
 		}
 	}
+	output.WriteString(stats.notice(t.depthLimit()))
 
-	return NewResult(output.String()), nil
+	return NewResult(truncateOutput(output.String(), t.maxOutput)), nil
+}
+
+// depthLimit returns the effective directory depth limit
+func (t *GrepTool) depthLimit() int {
+	if t.maxDepth > 0 {
+		return t.maxDepth
+	}
+	return DefaultMaxWalkDepth
 }
 
 // grepSearch performs the actual grep search
-func (t *GrepTool) grepSearch(searchPath, filePattern string, re *regexp.Regexp, mode string, contextLines, maxMatches int) ([]GrepMatch, error) {
+// ignore excludes paths matched by .gitignore or .vibeignore; stats reports directories the walk skipped
+func (t *GrepTool) grepSearch(searchPath, filePattern string, re *regexp.Regexp, mode string, contextLines, maxMatches int, ignore *searchFilter) ([]GrepMatch, *walkStats, error) {
 	var results []GrepMatch
 
-	// Walk directory
-	err := filepath.WalkDir(searchPath, func(path string, d os.DirEntry, err error) error {
+	// Walk directory (follows symlinked directories inside the root, bounded by the depth limit)
+	stats, err := walkTree(searchPath, t.maxDepth, func(path string, d os.DirEntry, err error) error {
 		if err != nil {
 			return nil
 		}
@@ -168,6 +213,9 @@ func (t *GrepTool) grepSearch(searchPath, filePattern string, re *regexp.Regexp,
 			if isSkipDir(path) {
 				return filepath.SkipDir
 			}
+			if rel, err := filepath.Rel(searchPath, path); err == nil && ignore.Match(rel, true) {
+				return filepath.SkipDir
+			}
 			return nil
 		}
 
@@ -177,6 +225,10 @@ func (t *GrepTool) grepSearch(searchPath, filePattern string, re *regexp.Regexp,
 			return nil
 		}
 
+		if ignore.Match(relPath, false) {
+			return nil
+		}
+
 		matched, err := filepath.Match(filePattern, relPath)
 		if err != nil || !matched {
 			return nil
@@ -212,10 +264,10 @@ func (t *GrepTool) grepSearch(searchPath, filePattern string, re *regexp.Regexp,
 		if len(results) > maxMatches {
 			results = results[:maxMatches]
 		}
-		return results, nil
+		return results, stats, nil
 	}
 
-	return results, err
+	return results, stats, err
 }
 
 // searchFile searches a single file
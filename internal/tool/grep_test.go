@@ -489,3 +489,122 @@ func TestGrepTool_Execute_MaxMatches(t *testing.T) {
 		t.Error("expected matches in results")
 	}
 }
+
+func TestGrepTool_Execute_RespectsGitignore(t *testing.T) {
+	tool := NewGrepTool()
+	root := createIgnoreRepo(t)
+
+	// file_pattern matches one directory level at a time, so search each depth
+	search := func(respect bool) string {
+		var output strings.Builder
+		for _, pattern := range []string{"*", "*/*", "*/*/*"} {
+			params, _ := json.Marshal(map[string]interface{}{
+				"pattern": "needle", "path": root, "mode": "files_with_matches",
+				"file_pattern": pattern, "respect_gitignore": respect,
+			})
+			result, err := tool.Execute(context.Background(), params)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			output.WriteString(result.Output)
+		}
+		return output.String()
+	}
+
+	output := search(true)
+	for _, want := range []string{"main.go", "keep.log", "lib.go", "generated.go"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("expected %s in results, got: %s", want, output)
+		}
+	}
+	for _, unwanted := range []string{"app.log", "out.go", "gen.go", "api.go", "HEAD.go"} {
+		if strings.Contains(output, unwanted) {
+			t.Errorf("did not expect ignored file %s in results, got: %s", unwanted, output)
+		}
+	}
+
+	// Opting out searches ignored files too
+	output = search(false)
+	for _, want := range []string{"app.log", "out.go", "gen.go", "api.go"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("expected %s with respect_gitignore=false, got: %s", want, output)
+		}
+	}
+	if strings.Contains(output, "HEAD.go") {
+		t.Errorf("did not expect .git contents with respect_gitignore=false, got: %s", output)
+	}
+}
+
+func TestGrepTool_Execute_MaxDepth(t *testing.T) {
+	tmpDir := t.TempDir()
+	deep := filepath.Join(tmpDir, "a", "b", "c")
+	if err := os.MkdirAll(deep, 0755); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(tmpDir, "top.txt"), []byte("needle\n"), 0644)
+	os.WriteFile(filepath.Join(deep, "deep.txt"), []byte("needle\n"), 0644)
+
+	tool := NewGrepTool()
+	tool.SetMaxDepth(2)
+	params, _ := json.Marshal(map[string]interface{}{"pattern": "needle", "path": tmpDir})
+	result, err := tool.Execute(context.Background(), params)
+	if err != nil || result.IsError {
+		t.Fatalf("expected success, got %v %s", err, result.Error)
+	}
+
+	if !strings.Contains(result.Output, "Found 1 matches") || strings.Contains(result.Output, "deep.txt") {
+		t.Errorf("expected only the shallow match, got: %s", result.Output)
+	}
+	if !strings.Contains(result.Output, "max depth 2 reached") {
+		t.Errorf("expected depth notice, got: %s", result.Output)
+	}
+}
+
+func TestGrepTool_Execute_SymlinkCycle(t *testing.T) {
+	tmpDir := t.TempDir()
+	sub := filepath.Join(tmpDir, "sub")
+	if err := os.MkdirAll(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(tmpDir, "file.txt"), []byte("needle\n"), 0644)
+	if err := os.Symlink(tmpDir, filepath.Join(sub, "loop")); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+
+	tool := NewGrepTool()
+	params, _ := json.Marshal(map[string]interface{}{"pattern": "needle", "path": tmpDir})
+	result, err := tool.Execute(context.Background(), params)
+	if err != nil || result.IsError {
+		t.Fatalf("expected success, got %v %s", err, result.Error)
+	}
+
+	if !strings.Contains(result.Output, "Found 1 matches") {
+		t.Errorf("expected the match once, got: %s", result.Output)
+	}
+	if !strings.Contains(result.Output, "skipped 1 symlink cycle") {
+		t.Errorf("expected cycle notice, got: %s", result.Output)
+	}
+}
+
+func TestGrepTool_Execute_SkipsSymlinkOutsideRoot(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+	os.WriteFile(filepath.Join(outside, "passwd"), []byte("needle outside\n"), 0644)
+	os.WriteFile(filepath.Join(root, "file.txt"), []byte("needle inside\n"), 0644)
+	if err := os.Symlink(outside, filepath.Join(root, "etclink")); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+
+	tool := NewGrepTool()
+	params, _ := json.Marshal(map[string]interface{}{"pattern": "needle", "path": root})
+	result, err := tool.Execute(context.Background(), params)
+	if err != nil || result.IsError {
+		t.Fatalf("expected success, got %v %s", err, result.Error)
+	}
+	if strings.Contains(result.Output, "needle outside") {
+		t.Errorf("grep followed a symlink out of the root: %s", result.Output)
+	}
+	if !strings.Contains(result.Output, "needle inside") || !strings.Contains(result.Output, "outside the search root") {
+		t.Errorf("expected the inside match and a notice, got: %s", result.Output)
+	}
+}
@@ -0,0 +1,223 @@
+package tool
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+// VibeIgnoreFile is the project file listing paths the agent must not touch
+const VibeIgnoreFile = ".vibeignore"
+
+// IgnoreMatcher decides whether paths under a search root are excluded by
+// the root's .gitignore (or .vibeignore). For .gitignore the .git directory is always excluded.
+type IgnoreMatcher struct {
+	root    string
+	rules   []ignoreRule
+	skipGit bool // Exclude .git even without a rule
+}
+
+// ignoreRule is a single parsed .gitignore line
+type ignoreRule struct {
+	pattern  string
+	negate   bool // "!pattern" re-includes a path
+	dirOnly  bool // "pattern/" matches directories only
+	anchored bool // pattern contains a slash: match against the path from the root
+}
+
+// LoadIgnore reads root/.gitignore. A missing file yields a matcher that only skips .git
+func LoadIgnore(root string) *IgnoreMatcher {
+	m := &IgnoreMatcher{root: root, skipGit: true}
+	m.rules = readIgnoreRules(filepath.Join(root, ".gitignore"))
+	return m
+}
+
+// LoadVibeIgnore reads root/.vibeignore (same syntax as .gitignore). It returns
+// nil when the file doesn't exist or has no rules, so callers can skip the checks
+func LoadVibeIgnore(root string) *IgnoreMatcher {
+	rules := readIgnoreRules(filepath.Join(root, VibeIgnoreFile))
+	if len(rules) == 0 {
+		return nil
+	}
+	// Tools compare symlink-resolved paths, so resolve the root the same way
+	if resolved, err := filepath.EvalSymlinks(root); err == nil {
+		root = resolved
+	}
+	if abs, err := filepath.Abs(root); err == nil {
+		root = abs
+	}
+	return &IgnoreMatcher{root: root, rules: rules}
+}
+
+// readIgnoreRules parses an ignore file; a missing file has no rules
+func readIgnoreRules(path string) []ignoreRule {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	var rules []ignoreRule
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if rule, ok := parseIgnoreLine(scanner.Text()); ok {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// checkVibeIgnore returns an error when path (absolute) is listed in .vibeignore.
+// A nil matcher allows everything
+func checkVibeIgnore(m *IgnoreMatcher, path string) error {
+	if m == nil {
+		return nil
+	}
+	isDir := false
+	if info, err := os.Stat(path); err == nil {
+		isDir = info.IsDir()
+	}
+	if m.Match(path, isDir) {
+		return fmt.Errorf("path is vibe-ignored: %s\nHint: it is listed in %s; leave it alone (start with --no-vibeignore to lift this)", path, VibeIgnoreFile)
+	}
+	return nil
+}
+
+// checkVibeIgnoreWrite is checkVibeIgnore for tools that change files. The
+// .vibeignore file itself is also refused, so the agent can't lift its own limits
+func checkVibeIgnoreWrite(m *IgnoreMatcher, path string) error {
+	if err := checkVibeIgnore(m, path); err != nil {
+		return err
+	}
+	if m != nil && path == filepath.Join(m.root, VibeIgnoreFile) {
+		return fmt.Errorf("path is protected: %s\nHint: %s is maintained by the user; don't change it", path, VibeIgnoreFile)
+	}
+	return nil
+}
+
+// parseIgnoreLine parses one .gitignore line; comments and blank lines are skipped
+func parseIgnoreLine(line string) (ignoreRule, bool) {
+	line = strings.TrimRight(line, " \t\r")
+	if line == "" || strings.HasPrefix(line, "#") {
+		return ignoreRule{}, false
+	}
+
+	var rule ignoreRule
+	if strings.HasPrefix(line, "!") {
+		rule.negate = true
+		line = line[1:]
+	}
+	line = strings.TrimPrefix(line, `\`) // "\#foo" / "\!foo" are literal
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if strings.Contains(line, "/") {
+		rule.anchored = true
+		line = strings.TrimPrefix(line, "/")
+	}
+	if line == "" {
+		return ignoreRule{}, false
+	}
+
+	rule.pattern = line
+	return rule, true
+}
+
+// Match reports whether path (absolute, or relative to the root) is ignored.
+// A path is also ignored when any of its parent directories is.
+func (m *IgnoreMatcher) Match(path string, isDir bool) bool {
+	if m == nil {
+		return false
+	}
+
+	rel := path
+	if filepath.IsAbs(path) {
+		r, err := filepath.Rel(m.root, path)
+		if err != nil || strings.HasPrefix(r, "..") {
+			return false
+		}
+		rel = r
+	}
+	rel = filepath.ToSlash(rel)
+	if rel == "." || rel == "" {
+		return false
+	}
+
+	parts := strings.Split(rel, "/")
+	for i := range parts {
+		dir := i < len(parts)-1 || isDir
+		if m.skipGit && dir && parts[i] == ".git" {
+			return true
+		}
+		if m.matchRules(strings.Join(parts[:i+1], "/"), parts[i], dir) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchRules applies the rules in order; the last matching rule wins
+func (m *IgnoreMatcher) matchRules(rel, base string, isDir bool) bool {
+	ignored := false
+	for _, rule := range m.rules {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+		target := base
+		if rule.anchored {
+			target = rel
+		}
+		if ok, _ := doublestar.Match(rule.pattern, target); ok {
+			ignored = !rule.negate
+		}
+	}
+	return ignored
+}
+
+// searchFilter excludes paths from a glob/grep walk by the search root's
+// .gitignore and the project's .vibeignore
+type searchFilter struct {
+	gitignore  *IgnoreMatcher // nil when .gitignore isn't respected
+	vibeIgnore *IgnoreMatcher // nil when there is no .vibeignore
+	root       string         // Search root as given
+	vibeRoot   string         // Search root, absolute with symlinks resolved (how .vibeignore paths are compared)
+}
+
+// newSearchFilter creates the filter for a walk of root; either matcher may be nil
+func newSearchFilter(gitignore, vibeIgnore *IgnoreMatcher, root string) *searchFilter {
+	f := &searchFilter{gitignore: gitignore, vibeIgnore: vibeIgnore, root: root, vibeRoot: root}
+	if abs, err := filepath.Abs(root); err == nil {
+		f.vibeRoot = abs
+	}
+	if resolved, err := filepath.EvalSymlinks(f.vibeRoot); err == nil {
+		f.vibeRoot = resolved
+	}
+	return f
+}
+
+// Match reports whether path (absolute, or relative to the search root) is excluded
+func (f *searchFilter) Match(path string, isDir bool) bool {
+	if f == nil {
+		return false
+	}
+	if f.gitignore.Match(path, isDir) {
+		return true
+	}
+	if f.vibeIgnore == nil {
+		return false
+	}
+	rel := path
+	if filepath.IsAbs(path) {
+		r, err := filepath.Rel(f.root, path)
+		if err != nil {
+			return false
+		}
+		rel = r
+	}
+	return f.vibeIgnore.Match(filepath.Join(f.vibeRoot, rel), isDir)
+}
@@ -0,0 +1,300 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// createIgnoreRepo builds a temp tree whose .gitignore excludes tmp/, *.log (except keep.log) and /docs/generated
+func createIgnoreRepo(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+
+	files := map[string]string{
+		".gitignore":              "# scratch output\ntmp/\n*.log\n!keep.log\n/docs/generated\n",
+		"main.go":                 "package main // needle\n",
+		"app.log":                 "needle in log\n",
+		"keep.log":                "needle kept\n",
+		"tmp/out.go":              "package tmp // needle\n",
+		"src/tmp/gen.go":          "package gen // needle\n",
+		"src/lib.go":              "package src // needle\n",
+		"docs/generated/api.go":   "package api // needle\n",
+		"docs/guide/generated.go": "package guide // needle\n",
+		".git/HEAD.go":            "needle\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to create %s: %v", name, err)
+		}
+	}
+	return root
+}
+
+func TestIgnoreMatcher_Match(t *testing.T) {
+	root := createIgnoreRepo(t)
+	m := LoadIgnore(root)
+
+	tests := []struct {
+		path  string
+		isDir bool
+		want  bool
+	}{
+		{"main.go", false, false},
+		{"app.log", false, true},
+		{"keep.log", false, false},
+		{"src/debug.log", false, true},
+		{"tmp", true, true},
+		{"tmp/out.go", false, true},
+		{"src/tmp/gen.go", false, true},
+		{"tmp", false, false}, // dir-only rule doesn't match a file
+		{"docs/generated/api.go", false, true},
+		{"docs/guide/generated.go", false, false}, // anchored rule only matches from the root
+		{".git", true, true},
+		{".git/HEAD.go", false, true},
+		{filepath.Join(root, "app.log"), false, true},
+		{filepath.Join(root, "src", "lib.go"), false, false},
+	}
+
+	for _, tt := range tests {
+		if got := m.Match(tt.path, tt.isDir); got != tt.want {
+			t.Errorf("Match(%q, dir=%v) = %v, want %v", tt.path, tt.isDir, got, tt.want)
+		}
+	}
+}
+
+func TestIgnoreMatcher_NoGitignore(t *testing.T) {
+	m := LoadIgnore(t.TempDir())
+
+	if m.Match("tmp/out.go", false) {
+		t.Error("expected no rules without a .gitignore")
+	}
+	if !m.Match(".git/config", false) {
+		t.Error("expected .git to be skipped even without a .gitignore")
+	}
+
+	var nilMatcher *IgnoreMatcher
+	if nilMatcher.Match(".git/config", false) {
+		t.Error("expected nil matcher to match nothing")
+	}
+}
+
+// createVibeIgnoreRepo builds a temp tree whose .vibeignore lists secrets/ and *.pem
+func createVibeIgnoreRepo(t *testing.T) (string, *IgnoreMatcher) {
+	t.Helper()
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		VibeIgnoreFile:        "secrets/\n*.pem\n",
+		"main.go":             "package main // needle\n",
+		"secrets/token.txt":   "needle\n",
+		"certs/server.pem":    "needle\n",
+		"certs/server.pem.md": "needle\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	m := LoadVibeIgnore(root)
+	if m == nil {
+		t.Fatal("expected a matcher for a non-empty .vibeignore")
+	}
+	return root, m
+}
+
+// executeTool runs a tool with args and fails the test on a Go error
+func executeTool(t *testing.T, tool Tool, args map[string]interface{}) *Result {
+	t.Helper()
+	params, _ := json.Marshal(args)
+	result, err := tool.Execute(context.Background(), params)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	return result
+}
+
+func expectVibeIgnored(t *testing.T, result *Result) {
+	t.Helper()
+	if !result.IsError || !strings.Contains(result.Error, "path is vibe-ignored") {
+		t.Errorf("expected a vibe-ignored error, got %+v", result)
+	}
+}
+
+func TestLoadVibeIgnore_MissingFile(t *testing.T) {
+	if m := LoadVibeIgnore(t.TempDir()); m != nil {
+		t.Error("expected nil without a .vibeignore")
+	}
+}
+
+func TestVibeIgnore_ReadTool(t *testing.T) {
+	root, m := createVibeIgnoreRepo(t)
+	tool := NewReadTool()
+	tool.SetVibeIgnore(m)
+
+	expectVibeIgnored(t, executeTool(t, tool, map[string]interface{}{"path": filepath.Join(root, "secrets", "token.txt")}))
+	expectVibeIgnored(t, executeTool(t, tool, map[string]interface{}{"path": filepath.Join(root, "certs", "server.pem")}))
+	if result := executeTool(t, tool, map[string]interface{}{"path": filepath.Join(root, "main.go")}); result.IsError {
+		t.Errorf("expected other files to be readable, got %s", result.Error)
+	}
+}
+
+func TestVibeIgnore_WriteTool(t *testing.T) {
+	root, m := createVibeIgnoreRepo(t)
+	tool := NewWriteTool()
+	tool.SetVibeIgnore(m)
+
+	target := filepath.Join(root, "secrets", "new.txt")
+	expectVibeIgnored(t, executeTool(t, tool, map[string]interface{}{"path": target, "content": "x"}))
+	if _, err := os.Stat(target); !os.IsNotExist(err) {
+		t.Error("ignored file must not be created")
+	}
+}
+
+func TestVibeIgnore_EditTool(t *testing.T) {
+	root, m := createVibeIgnoreRepo(t)
+	tool := NewEditTool()
+	tool.SetVibeIgnore(m)
+
+	target := filepath.Join(root, "secrets", "token.txt")
+	expectVibeIgnored(t, executeTool(t, tool, map[string]interface{}{"path": target, "old_string": "needle", "new_string": "pin"}))
+	if data, _ := os.ReadFile(target); string(data) != "needle\n" {
+		t.Errorf("ignored file must be unchanged, got %q", data)
+	}
+}
+
+func TestVibeIgnore_GlobTool(t *testing.T) {
+	root, m := createVibeIgnoreRepo(t)
+	tool := NewGlobTool()
+	tool.SetVibeIgnore(m)
+
+	// Ignored paths stay hidden even when .gitignore isn't respected
+	result := executeTool(t, tool, map[string]interface{}{"pattern": "**/*", "path": root, "respect_gitignore": false})
+	if result.IsError {
+		t.Fatalf("expected matches, got %s", result.Error)
+	}
+	if strings.Contains(result.Output, "token.txt") || strings.Contains(result.Output, "server.pem\n") {
+		t.Errorf("ignored files should be left out:\n%s", result.Output)
+	}
+	if !strings.Contains(result.Output, "main.go") || !strings.Contains(result.Output, "server.pem.md") {
+		t.Errorf("other files should be listed:\n%s", result.Output)
+	}
+}
+
+func TestVibeIgnore_GrepTool(t *testing.T) {
+	root, m := createVibeIgnoreRepo(t)
+	tool := NewGrepTool()
+	tool.SetVibeIgnore(m)
+
+	result := executeTool(t, tool, map[string]interface{}{"pattern": "needle", "path": root, "mode": "files_with_matches"})
+	if result.IsError {
+		t.Fatalf("expected matches, got %s", result.Error)
+	}
+	if strings.Contains(result.Output, "token.txt") || strings.Contains(result.Output, "server.pem\n") {
+		t.Errorf("ignored files should not be searched:\n%s", result.Output)
+	}
+	if !strings.Contains(result.Output, "main.go") {
+		t.Errorf("other files should be searched:\n%s", result.Output)
+	}
+
+	// Searching an ignored file directly finds nothing
+	result = executeTool(t, tool, map[string]interface{}{"pattern": "needle", "path": filepath.Join(root, "secrets", "token.txt")})
+	if strings.Contains(result.Output, "token.txt") {
+		t.Errorf("an ignored file given as the path should not be searched:\n%s", result.Output)
+	}
+}
+
+func TestVibeIgnore_WriteToolProtectsVibeIgnore(t *testing.T) {
+	root, m := createVibeIgnoreRepo(t)
+	tool := NewWriteTool()
+	tool.SetVibeIgnore(m)
+
+	target := filepath.Join(root, VibeIgnoreFile)
+	result := executeTool(t, tool, map[string]interface{}{"path": target, "content": ""})
+	if !result.IsError || !strings.Contains(result.Error, "path is protected") {
+		t.Errorf("expected .vibeignore to be protected, got %+v", result)
+	}
+	if data, _ := os.ReadFile(target); string(data) != "secrets/\n*.pem\n" {
+		t.Errorf(".vibeignore must be unchanged, got %q", data)
+	}
+}
+
+func TestVibeIgnore_MakeDirectoryTool(t *testing.T) {
+	root, m := createVibeIgnoreRepo(t)
+	tool := NewMakeDirectoryTool()
+	tool.SetVibeIgnore(m)
+
+	target := filepath.Join(root, "secrets", "nested")
+	expectVibeIgnored(t, executeTool(t, tool, map[string]interface{}{"path": target}))
+	if _, err := os.Stat(target); !os.IsNotExist(err) {
+		t.Error("ignored directory must not be created")
+	}
+}
+
+func TestVibeIgnore_TailTool(t *testing.T) {
+	root, m := createVibeIgnoreRepo(t)
+	tool := NewTailTool()
+	tool.SetVibeIgnore(m)
+
+	expectVibeIgnored(t, executeTool(t, tool, map[string]interface{}{"path": filepath.Join(root, "secrets", "token.txt")}))
+	if result := executeTool(t, tool, map[string]interface{}{"path": filepath.Join(root, "main.go")}); result.IsError {
+		t.Errorf("expected other files to be readable, got %s", result.Error)
+	}
+}
+
+func TestVibeIgnore_ListTool(t *testing.T) {
+	root, m := createVibeIgnoreRepo(t)
+	tool := NewListTool()
+	tool.SetVibeIgnore(m)
+
+	result := executeTool(t, tool, map[string]interface{}{"path": root})
+	if result.IsError {
+		t.Fatalf("expected a listing, got %s", result.Error)
+	}
+	if strings.Contains(result.Output, "secrets") || strings.Contains(result.Output, "server.pem\n") {
+		t.Errorf("ignored entries should be left out:\n%s", result.Output)
+	}
+	if !strings.Contains(result.Output, "main.go") || !strings.Contains(result.Output, "server.pem.md") {
+		t.Errorf("other entries should be listed:\n%s", result.Output)
+	}
+
+	expectVibeIgnored(t, executeTool(t, tool, map[string]interface{}{"path": filepath.Join(root, "secrets")}))
+}
+
+func TestVibeIgnore_SymbolsTool(t *testing.T) {
+	root, m := createVibeIgnoreRepo(t)
+	for name, content := range map[string]string{
+		"visible.go":        "package main\n\nfunc Shared() {}\n",
+		"secrets/hidden.go": "package secrets\n\nfunc Shared() {}\n",
+	} {
+		if err := os.WriteFile(filepath.Join(root, filepath.FromSlash(name)), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tool := NewSymbolsTool()
+	tool.SetVibeIgnore(m)
+
+	result := executeTool(t, tool, map[string]interface{}{"name": "Shared", "path": root})
+	if result.IsError {
+		t.Fatalf("expected definitions, got %s", result.Error)
+	}
+	if strings.Contains(result.Output, "hidden.go") {
+		t.Errorf("definitions in ignored files should not be reported:\n%s", result.Output)
+	}
+	if !strings.Contains(result.Output, "visible.go") {
+		t.Errorf("other definitions should be reported:\n%s", result.Output)
+	}
+}
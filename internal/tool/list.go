@@ -0,0 +1,215 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/zephel01/vibe-local-go/internal/security"
+)
+
+const (
+	// DefaultListDepth is the default number of directory levels shown
+	DefaultListDepth = 3
+	// MaxListDepth caps the depth parameter
+	MaxListDepth = 10
+	// MaxListEntries caps the number of entries in one listing
+	MaxListEntries = 500
+)
+
+// listSkipDirs are never descended into, even without a .gitignore
+var listSkipDirs = map[string]bool{
+	".git":         true,
+	"node_modules": true,
+}
+
+// ListTool shows a directory as an indented tree
+type ListTool struct {
+	vibeIgnore *IgnoreMatcher
+}
+
+// NewListTool creates a new list directory tool
+func NewListTool() *ListTool {
+	return &ListTool{}
+}
+
+// SetVibeIgnore sets the .vibeignore matcher; matching entries are left out of the tree (nil = none)
+func (t *ListTool) SetVibeIgnore(m *IgnoreMatcher) {
+	t.vibeIgnore = m
+}
+
+// Name returns the tool name
+func (t *ListTool) Name() string {
+	return "list_directory"
+}
+
+// Schema returns the tool schema
+func (t *ListTool) Schema() *FunctionSchema {
+	return &FunctionSchema{
+		Name:        "list_directory",
+		Description: "List a directory as an indented tree of files and subdirectories (.git, node_modules and .gitignore'd entries are skipped). Use this instead of 'bash ls -R' to understand project structure",
+		Parameters: &ParameterSchema{
+			Type: "object",
+			Properties: map[string]*PropertyDef{
+				"path": {
+					Type:        "string",
+					Description: "The directory to list (default: current directory)",
+				},
+				"depth": {
+					Type:        "integer",
+					Description: fmt.Sprintf("How many directory levels to show (default: %d, max: %d)", DefaultListDepth, MaxListDepth),
+					Default:     DefaultListDepth,
+				},
+			},
+		},
+	}
+}
+
+// Execute lists the directory
+func (t *ListTool) Execute(ctx context.Context, params json.RawMessage) (*Result, error) {
+	var args struct {
+		Path  string `json:"path"`
+		Depth int    `json:"depth"`
+	}
+
+	if err := json.Unmarshal(params, &args); err != nil {
+		return NewErrorResult(err), nil
+	}
+
+	if args.Depth <= 0 {
+		args.Depth = DefaultListDepth
+	}
+	if args.Depth > MaxListDepth {
+		args.Depth = MaxListDepth
+	}
+
+	args.Path = security.NormalizePath(args.Path)
+	if args.Path == "" {
+		args.Path = "."
+	}
+
+	root, err := filepath.Abs(args.Path)
+	if err != nil {
+		return NewErrorResult(err), nil
+	}
+
+	info, err := os.Stat(root)
+	if err != nil {
+		return NewErrorResult(err), nil
+	}
+	if !info.IsDir() {
+		return NewErrorResult(fmt.Errorf("not a directory: %s", args.Path)), nil
+	}
+	if resolved, err := filepath.EvalSymlinks(root); err == nil {
+		if err := checkVibeIgnore(t.vibeIgnore, resolved); err != nil {
+			return NewErrorResult(err), nil
+		}
+	}
+
+	l := &treeLister{
+		ctx:    ctx,
+		root:   root,
+		depth:  args.Depth,
+		ignore: newSearchFilter(LoadIgnore(root), t.vibeIgnore, root),
+	}
+	l.out.WriteString(filepath.Base(root) + "/\n")
+	if err := l.list(root, 1); err != nil {
+		return NewErrorResult(err), nil
+	}
+
+	l.out.WriteString(fmt.Sprintf("\n%d directories, %d files\n", l.dirs, l.files))
+	if l.truncated {
+		l.out.WriteString(fmt.Sprintf("Note: listing truncated at %d entries; list a subdirectory or lower depth to see more\n", MaxListEntries))
+	}
+	return NewResult(l.out.String()), nil
+}
+
+// treeLister builds the tree output for ListTool
+type treeLister struct {
+	ctx       context.Context
+	root      string
+	depth     int
+	ignore    *searchFilter
+	out       strings.Builder
+	entries   int
+	dirs      int
+	files     int
+	truncated bool
+}
+
+// list writes the entries of dir at the given level (1 = children of the root)
+func (l *treeLister) list(dir string, level int) error {
+	if err := l.ctx.Err(); err != nil {
+		return err
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if dir == l.root {
+			return err
+		}
+		return nil // Unreadable subdirectories are left empty
+	}
+
+	indent := strings.Repeat("  ", level)
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		isDir := entry.IsDir()
+		if entry.Type()&os.ModeSymlink != 0 {
+			// Symlinks are shown but never followed (avoids cycles)
+			if info, err := os.Stat(path); err == nil && info.IsDir() {
+				if l.skip(path, entry.Name(), true) || !l.add() {
+					continue
+				}
+				l.dirs++
+				l.out.WriteString(indent + entry.Name() + "/ -> (symlink)\n")
+				continue
+			}
+		}
+
+		if l.skip(path, entry.Name(), isDir) {
+			continue
+		}
+		if !l.add() {
+			return nil
+		}
+
+		if !isDir {
+			l.files++
+			l.out.WriteString(indent + entry.Name() + "\n")
+			continue
+		}
+
+		l.dirs++
+		if level >= l.depth {
+			l.out.WriteString(indent + entry.Name() + "/ ...\n")
+			continue
+		}
+		l.out.WriteString(indent + entry.Name() + "/\n")
+		if err := l.list(path, level+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// skip reports whether an entry is left out of the listing
+func (l *treeLister) skip(path, name string, isDir bool) bool {
+	if isDir && listSkipDirs[name] {
+		return true
+	}
+	return l.ignore.Match(path, isDir)
+}
+
+// add counts an entry against MaxListEntries; false once the cap is reached
+func (l *treeLister) add() bool {
+	if l.entries >= MaxListEntries {
+		l.truncated = true
+		return false
+	}
+	l.entries++
+	return true
+}
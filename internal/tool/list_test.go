@@ -0,0 +1,123 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeTree(t *testing.T, root string, files ...string) {
+	t.Helper()
+	for _, f := range files {
+		path := filepath.Join(root, f)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestListTool_Execute_Tree(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, "main.go", "pkg/util/strings.go", "pkg/util/deep/more/x.go", "README.md")
+
+	params, _ := json.Marshal(map[string]interface{}{"path": root})
+	result, err := NewListTool().Execute(context.Background(), params)
+	if err != nil || result.IsError {
+		t.Fatalf("expected success, got %v %s", err, result.Error)
+	}
+
+	want := filepath.Base(root) + "/\n" +
+		"  README.md\n" +
+		"  main.go\n" +
+		"  pkg/\n" +
+		"    util/\n" +
+		"      deep/ ...\n" +
+		"      strings.go\n" +
+		"\n3 directories, 3 files\n"
+	if result.Output != want {
+		t.Errorf("unexpected tree:\ngot:\n%s\nwant:\n%s", result.Output, want)
+	}
+}
+
+func TestListTool_Execute_DepthLimit(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, "a/b/c/d.txt", "top.txt")
+
+	params, _ := json.Marshal(map[string]interface{}{"path": root, "depth": 1})
+	result, _ := NewListTool().Execute(context.Background(), params)
+	if result.IsError {
+		t.Fatalf("list failed: %s", result.Error)
+	}
+	if !strings.Contains(result.Output, "  a/ ...\n") {
+		t.Errorf("expected a/ to be collapsed at depth 1:\n%s", result.Output)
+	}
+	if strings.Contains(result.Output, "b/") {
+		t.Errorf("depth 1 should not show nested directories:\n%s", result.Output)
+	}
+
+	params, _ = json.Marshal(map[string]interface{}{"path": root, "depth": 3})
+	result, _ = NewListTool().Execute(context.Background(), params)
+	if !strings.Contains(result.Output, "      c/ ...\n") || strings.Contains(result.Output, "d.txt") {
+		t.Errorf("depth 3 should show c/ but not its files:\n%s", result.Output)
+	}
+}
+
+func TestListTool_Execute_SkipsIgnoredDirectories(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root,
+		"src/app.js",
+		"node_modules/left-pad/index.js",
+		".git/HEAD",
+		"dist/bundle.js",
+		"debug.log",
+	)
+	if err := os.WriteFile(filepath.Join(root, ".gitignore"), []byte("dist/\n*.log\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	params, _ := json.Marshal(map[string]interface{}{"path": root})
+	result, _ := NewListTool().Execute(context.Background(), params)
+	if result.IsError {
+		t.Fatalf("list failed: %s", result.Error)
+	}
+
+	for _, excluded := range []string{"node_modules", ".git/", "dist", "debug.log"} {
+		if strings.Contains(result.Output, excluded) {
+			t.Errorf("%s should be excluded:\n%s", excluded, result.Output)
+		}
+	}
+	if !strings.Contains(result.Output, "app.js") || !strings.Contains(result.Output, ".gitignore") {
+		t.Errorf("expected regular entries to be listed:\n%s", result.Output)
+	}
+}
+
+func TestListTool_Execute_Truncates(t *testing.T) {
+	root := t.TempDir()
+	for i := 0; i < MaxListEntries+10; i++ {
+		writeTree(t, root, filepath.Join("many", fmt.Sprintf("f%03d.txt", i)))
+	}
+
+	params, _ := json.Marshal(map[string]interface{}{"path": root})
+	result, _ := NewListTool().Execute(context.Background(), params)
+	if !strings.Contains(result.Output, "listing truncated") {
+		t.Errorf("expected truncation note, got tail:\n%s", result.Output[len(result.Output)-200:])
+	}
+}
+
+func TestListTool_Execute_NotADirectory(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, "file.txt")
+
+	params, _ := json.Marshal(map[string]interface{}{"path": filepath.Join(root, "file.txt")})
+	result, _ := NewListTool().Execute(context.Background(), params)
+	if !result.IsError {
+		t.Error("expected an error for a file path")
+	}
+}
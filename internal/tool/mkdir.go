@@ -0,0 +1,168 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/zephel01/vibe-local-go/internal/security"
+)
+
+// MakeDirectoryTool creates directories (with parents) without going through bash
+type MakeDirectoryTool struct {
+	writeTool  *WriteTool
+	validator  *security.PathValidator
+	vibeIgnore *IgnoreMatcher
+}
+
+// NewMakeDirectoryTool creates a new make_directory tool
+func NewMakeDirectoryTool() *MakeDirectoryTool {
+	return &MakeDirectoryTool{
+		writeTool: NewWriteTool(),
+	}
+}
+
+// SetWriteTool は undo スタックを共有する WriteTool を設定する（/undo で取り消せるようにする）
+func (t *MakeDirectoryTool) SetWriteTool(wt *WriteTool) {
+	t.writeTool = wt
+}
+
+// SetPathValidator は作成先を検証するバリデーターを設定する（作業ディレクトリ外への作成を拒否）
+func (t *MakeDirectoryTool) SetPathValidator(v *security.PathValidator) {
+	t.validator = v
+}
+
+// SetVibeIgnore は .vibeignore のマッチャーを設定する（該当パスへの作成を拒否、nil = チェックなし）
+func (t *MakeDirectoryTool) SetVibeIgnore(m *IgnoreMatcher) {
+	t.vibeIgnore = m
+}
+
+// Name returns the tool name
+func (t *MakeDirectoryTool) Name() string {
+	return "make_directory"
+}
+
+// Schema returns the tool schema
+func (t *MakeDirectoryTool) Schema() *FunctionSchema {
+	return &FunctionSchema{
+		Name:        "make_directory",
+		Description: "Create a directory, including any missing parent directories. Succeeds without changes if it already exists. Use this instead of 'bash mkdir -p'",
+		Parameters: &ParameterSchema{
+			Type: "object",
+			Properties: map[string]*PropertyDef{
+				"path": {
+					Type:        "string",
+					Description: "The directory path to create",
+				},
+			},
+			Required: []string{"path"},
+		},
+	}
+}
+
+// Execute creates the directory
+func (t *MakeDirectoryTool) Execute(ctx context.Context, params json.RawMessage) (*Result, error) {
+	var args struct {
+		Path string `json:"path"`
+	}
+
+	if err := json.Unmarshal(params, &args); err != nil {
+		return NewErrorResult(err), nil
+	}
+
+	if args.Path == "" {
+		return NewErrorResult(fmt.Errorf("path cannot be empty")), nil
+	}
+
+	resolvedPath, err := resolvePath(args.Path)
+	if err != nil {
+		return NewErrorResult(err), nil
+	}
+
+	// Confine new directories to the working directory
+	if err := checkWorkdir(t.validator, resolvedPath); err != nil {
+		return NewErrorResult(err), nil
+	}
+	if err := checkVibeIgnoreWrite(t.vibeIgnore, resolvedPath); err != nil {
+		return NewErrorResult(err), nil
+	}
+
+	if isProtectedPath(resolvedPath) {
+		return NewErrorResult(fmt.Errorf("cannot create directory in protected path: %s", args.Path)), nil
+	}
+
+	if managedDir := getManagedDirWarning(resolvedPath); managedDir != "" {
+		return NewErrorResult(fmt.Errorf("cannot create directory in managed directory %s: %s", managedDir, args.Path)), nil
+	}
+
+	if t.writeTool != nil {
+		defer t.writeTool.lockChanges()()
+	}
+
+	if info, err := os.Stat(resolvedPath); err == nil {
+		if !info.IsDir() {
+			return NewErrorResult(fmt.Errorf("path exists and is not a directory: %s", args.Path)), nil
+		}
+		return NewResult(fmt.Sprintf("Directory already exists: %s", args.Path)), nil
+	}
+
+	// Remember the topmost directory that doesn't exist yet so undo removes
+	// exactly what this call created
+	created := firstMissingDir(resolvedPath)
+
+	if err := os.MkdirAll(resolvedPath, 0755); err != nil {
+		return NewErrorResult(err), nil
+	}
+
+	if t.writeTool != nil {
+		t.writeTool.addToUndoStack(UndoEntry{
+			Path:    created,
+			IsDir:   true,
+			DirPath: resolvedPath,
+		})
+	}
+
+	return NewResult(fmt.Sprintf("Created directory %s", args.Path)), nil
+}
+
+// firstMissingDir returns the outermost ancestor of path (or path itself) that doesn't exist
+func firstMissingDir(path string) string {
+	missing := path
+	for dir := filepath.Dir(path); dir != missing; dir = filepath.Dir(dir) {
+		if _, err := os.Stat(dir); err == nil {
+			break
+		}
+		missing = dir
+	}
+	return missing
+}
+
+// removeEmptyDirTree removes a directory tree that contains only directories.
+// Nothing is removed if any file has been created inside it since.
+func removeEmptyDirTree(root string) error {
+	var dirs []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return fmt.Errorf("directory %s is not empty", root)
+		}
+		dirs = append(dirs, path)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Children are visited after their parents, so remove in reverse
+	for i := len(dirs) - 1; i >= 0; i-- {
+		if err := os.Remove(dirs[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func runMakeDirectory(t *testing.T, tool *MakeDirectoryTool, path string) *Result {
+	t.Helper()
+	params, _ := json.Marshal(map[string]interface{}{"path": path})
+	result, err := tool.Execute(context.Background(), params)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	return result
+}
+
+func TestMakeDirectoryTool_CreatesNestedDirsAndUndo(t *testing.T) {
+	writeTool := NewWriteTool()
+	tool := NewMakeDirectoryTool()
+	tool.SetWriteTool(writeTool)
+
+	root := t.TempDir()
+	target := filepath.Join(root, "a", "b", "c")
+
+	result := runMakeDirectory(t, tool, target)
+	if result.IsError {
+		t.Fatalf("expected success, got error: %s", result.Error)
+	}
+	if info, err := os.Stat(target); err != nil || !info.IsDir() {
+		t.Fatalf("expected %s to be a directory, stat err: %v", target, err)
+	}
+
+	stack := writeTool.GetUndoStack()
+	if len(stack) != 1 || !stack[0].IsDir {
+		t.Fatalf("expected one directory undo entry, got %+v", stack)
+	}
+	if filepath.Base(stack[0].Path) != "a" {
+		t.Errorf("undo entry should point at the topmost created dir, got %s", stack[0].Path)
+	}
+
+	if err := writeTool.Undo(); err != nil {
+		t.Fatalf("failed to undo: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "a")); !os.IsNotExist(err) {
+		t.Error("expected created directories to be removed after undo")
+	}
+	if _, err := os.Stat(root); err != nil {
+		t.Errorf("pre-existing parent must survive undo: %v", err)
+	}
+}
+
+func TestMakeDirectoryTool_ExistingDirIsIdempotent(t *testing.T) {
+	writeTool := NewWriteTool()
+	tool := NewMakeDirectoryTool()
+	tool.SetWriteTool(writeTool)
+
+	dir := t.TempDir()
+	result := runMakeDirectory(t, tool, dir)
+	if result.IsError {
+		t.Fatalf("expected success for existing dir, got error: %s", result.Error)
+	}
+	if len(writeTool.GetUndoStack()) != 0 {
+		t.Error("existing directory should not record an undo entry")
+	}
+
+	file := filepath.Join(dir, "file.txt")
+	if err := os.WriteFile(file, []byte("x"), 0644); err != nil {
+		t.Fatalf("failed to create test file: %v", err)
+	}
+	if result := runMakeDirectory(t, tool, file); !result.IsError {
+		t.Error("expected error when path is an existing file")
+	}
+}
+
+func TestMakeDirectoryTool_UndoKeepsNonEmptyDir(t *testing.T) {
+	writeTool := NewWriteTool()
+	tool := NewMakeDirectoryTool()
+	tool.SetWriteTool(writeTool)
+
+	target := filepath.Join(t.TempDir(), "out")
+	if result := runMakeDirectory(t, tool, target); result.IsError {
+		t.Fatalf("expected success, got error: %s", result.Error)
+	}
+	if err := os.WriteFile(filepath.Join(target, "keep.txt"), []byte("x"), 0644); err != nil {
+		t.Fatalf("failed to create test file: %v", err)
+	}
+
+	if err := writeTool.Undo(); err == nil {
+		t.Error("expected undo to refuse removing a non-empty directory")
+	}
+	if _, err := os.Stat(filepath.Join(target, "keep.txt")); err != nil {
+		t.Errorf("file inside directory must survive undo: %v", err)
+	}
+	if len(writeTool.GetUndoStack()) != 1 {
+		t.Fatal("a failed undo should keep its entry on the stack")
+	}
+
+	// Once the directory is empty again the same entry can be undone
+	if err := os.Remove(filepath.Join(target, "keep.txt")); err != nil {
+		t.Fatalf("failed to remove test file: %v", err)
+	}
+	if err := writeTool.Undo(); err != nil {
+		t.Fatalf("failed to undo: %v", err)
+	}
+	if _, err := os.Stat(target); !os.IsNotExist(err) {
+		t.Error("expected the directory to be removed after retrying undo")
+	}
+}
+
+func TestMakeDirectoryTool_OutsideWorkdirRejected(t *testing.T) {
+	validator, workdir := newWorkdirValidator(t)
+	tool := NewMakeDirectoryTool()
+	tool.SetPathValidator(validator)
+
+	escape := filepath.Join(workdir, "..", "escaped")
+	if result := runMakeDirectory(t, tool, escape); !result.IsError {
+		t.Error("expected a directory outside the working directory to be rejected")
+	}
+	if _, err := os.Stat(escape); !os.IsNotExist(err) {
+		t.Error("directory should not have been created outside the working directory")
+	}
+
+	if result := runMakeDirectory(t, tool, filepath.Join(workdir, "src", "pkg")); result.IsError {
+		t.Errorf("expected a directory inside the working directory to be created: %s", result.Error)
+	}
+}
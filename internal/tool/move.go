@@ -0,0 +1,190 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/zephel01/vibe-local-go/internal/security"
+)
+
+// MoveTool moves or renames a file or directory without going through bash
+type MoveTool struct {
+	writeTool  *WriteTool
+	sandbox    SandboxStager
+	validator  *security.PathValidator
+	vibeIgnore *IgnoreMatcher
+}
+
+// NewMoveTool creates a new move_file tool
+func NewMoveTool() *MoveTool {
+	return &MoveTool{
+		writeTool: NewWriteTool(),
+	}
+}
+
+// SetWriteTool は undo スタックを共有する WriteTool を設定する（/undo で移動を取り消せるようにする）
+func (t *MoveTool) SetWriteTool(wt *WriteTool) {
+	t.writeTool = wt
+}
+
+// SetSandbox はサンドボックスマネージャーを設定する（サンドボックス有効時は移動を拒否）
+func (t *MoveTool) SetSandbox(sb SandboxStager) {
+	t.sandbox = sb
+}
+
+// SetPathValidator は移動元・移動先を検証するバリデーターを設定する（作業ディレクトリ外への移動を拒否）
+func (t *MoveTool) SetPathValidator(v *security.PathValidator) {
+	t.validator = v
+}
+
+// SetVibeIgnore は .vibeignore のマッチャーを設定する（該当パスの移動を拒否、nil = チェックなし）
+func (t *MoveTool) SetVibeIgnore(m *IgnoreMatcher) {
+	t.vibeIgnore = m
+}
+
+// Name returns the tool name
+func (t *MoveTool) Name() string {
+	return "move_file"
+}
+
+// Schema returns the tool schema
+func (t *MoveTool) Schema() *FunctionSchema {
+	return &FunctionSchema{
+		Name:        "move_file",
+		Description: "Move or rename a file or directory, creating missing parent directories of the destination. Refuses to replace an existing file unless overwrite is true. Use this instead of 'bash mv'",
+		Parameters: &ParameterSchema{
+			Type: "object",
+			Properties: map[string]*PropertyDef{
+				"source": {
+					Type:        "string",
+					Description: "The file or directory to move",
+				},
+				"destination": {
+					Type:        "string",
+					Description: "The new path",
+				},
+				"overwrite": {
+					Type:        "boolean",
+					Description: "Replace the destination if it is an existing file (default: false)",
+					Default:     false,
+				},
+			},
+			Required: []string{"source", "destination"},
+		},
+	}
+}
+
+// Execute moves source to destination
+func (t *MoveTool) Execute(ctx context.Context, params json.RawMessage) (*Result, error) {
+	var args struct {
+		Source      string `json:"source"`
+		Destination string `json:"destination"`
+		Overwrite   bool   `json:"overwrite"`
+	}
+
+	if err := json.Unmarshal(params, &args); err != nil {
+		return NewErrorResult(err), nil
+	}
+
+	if args.Source == "" || args.Destination == "" {
+		return NewErrorResult(fmt.Errorf("source and destination cannot be empty")), nil
+	}
+
+	if t.sandbox != nil && t.sandbox.IsEnabled() {
+		return NewErrorResult(fmt.Errorf("cannot move files in sandbox mode; write the new file and leave the old one, or turn the sandbox off")), nil
+	}
+
+	source, err := t.checkPath(args.Source)
+	if err != nil {
+		return NewErrorResult(err), nil
+	}
+	destination, err := t.checkPath(args.Destination)
+	if err != nil {
+		return NewErrorResult(err), nil
+	}
+	if source == destination {
+		return NewErrorResult(fmt.Errorf("source and destination are the same path: %s", args.Source)), nil
+	}
+
+	if t.writeTool != nil {
+		defer t.writeTool.lockChanges()()
+	}
+
+	srcInfo, err := os.Lstat(source)
+	if err != nil {
+		return NewErrorResult(fmt.Errorf("source '%s' not found. Try: list_directory to see available files", args.Source)), nil
+	}
+	if srcInfo.IsDir() {
+		if strings.HasPrefix(destination, source+string(filepath.Separator)) {
+			return NewErrorResult(fmt.Errorf("cannot move directory %s into itself", args.Source)), nil
+		}
+	}
+
+	// Changes undone and redone together: replacing the destination, then the move itself
+	var changes []UndoEntry
+	if dstInfo, err := os.Lstat(destination); err == nil {
+		if !args.Overwrite {
+			return NewErrorResult(fmt.Errorf("destination already exists: %s (set overwrite: true to replace it)", args.Destination)), nil
+		}
+		if dstInfo.IsDir() {
+			return NewErrorResult(fmt.Errorf("destination is a directory: %s (only files can be overwritten)", args.Destination)), nil
+		}
+		if dstInfo.Size() > MaxEditFileSize {
+			return NewErrorResult(fmt.Errorf("destination too large to overwrite with undo (%d bytes, max %d): %s", dstInfo.Size(), MaxEditFileSize, args.Destination)), nil
+		}
+		old, err := os.ReadFile(destination)
+		if err != nil {
+			return NewErrorResult(err), nil
+		}
+		changes = append(changes, UndoEntry{Path: destination, OldContent: string(old), Deleted: true})
+	} else if !os.IsNotExist(err) {
+		return NewErrorResult(err), nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(destination), 0755); err != nil {
+		return NewErrorResult(err), nil
+	}
+	if err := os.Rename(source, destination); err != nil {
+		return NewErrorResult(fmt.Errorf("failed to move %s: %v", args.Source, err)), nil
+	}
+	changes = append(changes, UndoEntry{Path: destination, MovedFrom: source})
+
+	if t.writeTool != nil {
+		if len(changes) == 1 {
+			t.writeTool.addToUndoStack(changes[0])
+		} else {
+			// MovedFrom on the group only labels it; Files does the work
+			t.writeTool.addToUndoStack(UndoEntry{Path: destination, MovedFrom: source, Files: changes})
+		}
+	}
+
+	if len(changes) > 1 {
+		return NewResult(fmt.Sprintf("Moved %s to %s (replaced the existing file)", args.Source, args.Destination)).WithChangedPaths(destination, source), nil
+	}
+	return NewResult(fmt.Sprintf("Moved %s to %s", args.Source, args.Destination)).WithChangedPaths(destination, source), nil
+}
+
+// checkPath resolves path and rejects protected, managed and out-of-workdir locations
+func (t *MoveTool) checkPath(path string) (string, error) {
+	resolved, err := resolvePath(path)
+	if err != nil {
+		return "", err
+	}
+	if isProtectedPath(resolved) {
+		return "", fmt.Errorf("cannot move files in protected path: %s", path)
+	}
+	if managedDir := getManagedDirWarning(resolved); managedDir != "" {
+		return "", fmt.Errorf("cannot move files in managed directory %s: %s", managedDir, path)
+	}
+	if err := checkWorkdir(t.validator, resolved); err != nil {
+		return "", err
+	}
+	if err := checkVibeIgnoreWrite(t.vibeIgnore, resolved); err != nil {
+		return "", err
+	}
+	return resolved, nil
+}
@@ -0,0 +1,130 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func runMove(t *testing.T, tool *MoveTool, args map[string]interface{}) *Result {
+	t.Helper()
+	params, _ := json.Marshal(args)
+	result, err := tool.Execute(context.Background(), params)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	return result
+}
+
+func TestMoveTool_MoveWithinTreeAndUndo(t *testing.T) {
+	validator, workdir := newWorkdirValidator(t)
+	writeTool := NewWriteTool()
+	tool := NewMoveTool()
+	tool.SetWriteTool(writeTool)
+	tool.SetPathValidator(validator)
+
+	source := filepath.Join(workdir, "old.go")
+	destination := filepath.Join(workdir, "pkg", "util", "new.go")
+	os.WriteFile(source, []byte("package util\n"), 0644)
+
+	result := runMove(t, tool, map[string]interface{}{"source": source, "destination": destination})
+	if result.IsError {
+		t.Fatalf("expected success, got error: %s", result.Error)
+	}
+	if len(result.ChangedPaths) != 2 || filepath.Base(result.ChangedPaths[0]) != "new.go" {
+		t.Errorf("ChangedPaths = %v, want the destination and the source", result.ChangedPaths)
+	}
+	if _, err := os.Stat(source); !os.IsNotExist(err) {
+		t.Error("source should no longer exist")
+	}
+	if data, err := os.ReadFile(destination); err != nil || string(data) != "package util\n" {
+		t.Fatalf("destination = %q, %v", data, err)
+	}
+
+	if err := writeTool.Undo(); err != nil {
+		t.Fatalf("failed to undo: %v", err)
+	}
+	if data, err := os.ReadFile(source); err != nil || string(data) != "package util\n" {
+		t.Errorf("undo should move the file back, got %q, %v", data, err)
+	}
+	if _, err := os.Stat(destination); !os.IsNotExist(err) {
+		t.Error("destination should be gone after undo")
+	}
+
+	if err := writeTool.Redo(); err != nil {
+		t.Fatalf("failed to redo: %v", err)
+	}
+	if _, err := os.Stat(destination); err != nil {
+		t.Errorf("redo should move the file again: %v", err)
+	}
+}
+
+func TestMoveTool_OutsideWorkdirRejected(t *testing.T) {
+	validator, workdir := newWorkdirValidator(t)
+	tool := NewMoveTool()
+	tool.SetPathValidator(validator)
+
+	source := filepath.Join(workdir, "keep.txt")
+	os.WriteFile(source, []byte("data"), 0644)
+	escape := filepath.Join(filepath.Dir(workdir), "stolen.txt")
+
+	result := runMove(t, tool, map[string]interface{}{"source": source, "destination": escape})
+	if !result.IsError {
+		t.Fatal("expected a move outside the working directory to be rejected")
+	}
+	if _, err := os.Stat(escape); !os.IsNotExist(err) {
+		t.Error("nothing should be created outside the working directory")
+	}
+	if _, err := os.Stat(source); err != nil {
+		t.Errorf("source must be left in place: %v", err)
+	}
+
+	// Moving a file in from outside is rejected as well
+	outside := filepath.Join(filepath.Dir(workdir), "outside.txt")
+	os.WriteFile(outside, []byte("x"), 0644)
+	result = runMove(t, tool, map[string]interface{}{"source": outside, "destination": filepath.Join(workdir, "in.txt")})
+	if !result.IsError {
+		t.Error("expected a source outside the working directory to be rejected")
+	}
+}
+
+func TestMoveTool_OverwriteProtection(t *testing.T) {
+	dir := t.TempDir()
+	writeTool := NewWriteTool()
+	tool := NewMoveTool()
+	tool.SetWriteTool(writeTool)
+
+	source := filepath.Join(dir, "a.txt")
+	destination := filepath.Join(dir, "b.txt")
+	os.WriteFile(source, []byte("new"), 0644)
+	os.WriteFile(destination, []byte("old"), 0644)
+
+	result := runMove(t, tool, map[string]interface{}{"source": source, "destination": destination})
+	if !result.IsError {
+		t.Fatal("expected an existing destination to be refused without overwrite")
+	}
+	if data, _ := os.ReadFile(destination); string(data) != "old" {
+		t.Errorf("destination must be untouched, got %q", data)
+	}
+
+	result = runMove(t, tool, map[string]interface{}{"source": source, "destination": destination, "overwrite": true})
+	if result.IsError {
+		t.Fatalf("expected overwrite to succeed, got error: %s", result.Error)
+	}
+	if data, _ := os.ReadFile(destination); string(data) != "new" {
+		t.Errorf("destination = %q, want the moved content", data)
+	}
+
+	// Undo restores both the moved file and the replaced one
+	if err := writeTool.Undo(); err != nil {
+		t.Fatalf("failed to undo: %v", err)
+	}
+	if data, _ := os.ReadFile(source); string(data) != "new" {
+		t.Errorf("source after undo = %q", data)
+	}
+	if data, _ := os.ReadFile(destination); string(data) != "old" {
+		t.Errorf("replaced destination after undo = %q", data)
+	}
+}
@@ -0,0 +1,232 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/zephel01/vibe-local-go/internal/security"
+)
+
+// MultiEditTool applies several string replacements to one file atomically
+type MultiEditTool struct {
+	writeTool  *WriteTool
+	sandbox    SandboxStager
+	validator  *security.PathValidator
+	vibeIgnore *IgnoreMatcher
+}
+
+// NewMultiEditTool creates a new multi edit tool
+func NewMultiEditTool() *MultiEditTool {
+	return &MultiEditTool{
+		writeTool: NewWriteTool(),
+	}
+}
+
+// SetWriteTool は undo スタックを共有する WriteTool を設定する（/undo で取り消せるようにする）
+func (t *MultiEditTool) SetWriteTool(wt *WriteTool) {
+	t.writeTool = wt
+}
+
+// SetSandbox はサンドボックスマネージャーを設定する
+func (t *MultiEditTool) SetSandbox(sb SandboxStager) {
+	t.sandbox = sb
+}
+
+// SetPathValidator は編集対象を検証するバリデーターを設定する（作業ディレクトリ外の編集を拒否）
+func (t *MultiEditTool) SetPathValidator(v *security.PathValidator) {
+	t.validator = v
+}
+
+// SetVibeIgnore は .vibeignore のマッチャーを設定する（該当パスの編集を拒否、nil = チェックなし）
+func (t *MultiEditTool) SetVibeIgnore(m *IgnoreMatcher) {
+	t.vibeIgnore = m
+}
+
+// Name returns the tool name
+func (t *MultiEditTool) Name() string {
+	return "multi_edit"
+}
+
+// Schema returns the tool schema
+func (t *MultiEditTool) Schema() *FunctionSchema {
+	return &FunctionSchema{
+		Name:        "multi_edit",
+		Description: "Apply multiple string replacements to one file in a single atomic operation. Edits are applied in order; if any edit fails, the file is left unchanged",
+		Parameters: &ParameterSchema{
+			Type: "object",
+			Properties: map[string]*PropertyDef{
+				"path": {
+					Type:        "string",
+					Description: "The file path to edit",
+				},
+				"edits": {
+					Type:        "array",
+					Description: "Ordered list of edits. Each edit sees the result of the previous ones",
+					Items: &PropertyDef{
+						Type: "object",
+						Properties: map[string]*PropertyDef{
+							"old_string": {
+								Type:        "string",
+								Description: "The string to replace",
+							},
+							"new_string": {
+								Type:        "string",
+								Description: "The replacement string",
+							},
+							"replace_all": {
+								Type:        "boolean",
+								Description: "Replace all occurrences (default: false)",
+								Default:     false,
+							},
+						},
+						Required: []string{"old_string", "new_string"},
+					},
+				},
+			},
+			Required: []string{"path", "edits"},
+		},
+	}
+}
+
+// EditOperation is a single replacement within a multi_edit call
+type EditOperation struct {
+	OldString  string `json:"old_string"`
+	NewString  string `json:"new_string"`
+	ReplaceAll bool   `json:"replace_all"`
+}
+
+// Execute applies all edits or none
+func (t *MultiEditTool) Execute(ctx context.Context, params json.RawMessage) (*Result, error) {
+	var args struct {
+		Path  string          `json:"path"`
+		Edits []EditOperation `json:"edits"`
+	}
+
+	if err := json.Unmarshal(params, &args); err != nil {
+		return NewErrorResult(err), nil
+	}
+
+	if args.Path == "" {
+		return NewErrorResult(fmt.Errorf("path cannot be empty")), nil
+	}
+
+	if len(args.Edits) == 0 {
+		return NewErrorResult(fmt.Errorf("edits cannot be empty")), nil
+	}
+
+	// Resolve path
+	resolvedPath, err := resolvePath(args.Path)
+	if err != nil {
+		return NewErrorResult(err), nil
+	}
+
+	// Confine edits to the working directory
+	if err := checkWorkdir(t.validator, resolvedPath); err != nil {
+		return NewErrorResult(err), nil
+	}
+	if err := checkVibeIgnoreWrite(t.vibeIgnore, resolvedPath); err != nil {
+		return NewErrorResult(err), nil
+	}
+
+	// Check for managed/dependency directories
+	if managedDir := getManagedDirWarning(resolvedPath); managedDir != "" {
+		return NewErrorResult(fmt.Errorf("cannot edit files in managed directory %s: %s\nHint: edit files in the project root or your own subdirectories", managedDir, args.Path)), nil
+	}
+
+	// Hold the change lock from reading the old content until the undo entry is recorded
+	defer t.writeTool.lockChanges()()
+
+	// Read file (staged version first in sandbox mode)
+	content, err := readCurrent(t.sandbox, resolvedPath)
+	if err != nil {
+		return NewErrorResult(err), nil
+	}
+
+	// Check file size
+	if len(content) > MaxEditFileSize {
+		return NewErrorResult(fmt.Errorf("file too large (%d bytes, max %d)", len(content), MaxEditFileSize)), nil
+	}
+
+	oldContent := string(content)
+	newContent, diffs, err := applyEdits(args.Path, oldContent, args.Edits)
+	if err != nil {
+		// 1件でも失敗したらファイルには一切書き込まない
+		return NewErrorResult(fmt.Errorf("%v (no changes were written)", err)), nil
+	}
+
+	diff := strings.Join(diffs, "\n")
+
+	// サンドボックスモードの場合はステージングにリダイレクト
+	if t.sandbox != nil && t.sandbox.IsEnabled() {
+		if err := t.sandbox.Stage(resolvedPath, []byte(newContent)); err != nil {
+			return NewErrorResult(fmt.Errorf("sandbox staging failed: %w", err)), nil
+		}
+		output := fmt.Sprintf("[sandbox] Staged %d edits → %s (use /commit to apply, /diff to review)\n\nDiff:\n%s", len(args.Edits), args.Path, diff)
+		return NewResult(output), nil
+	}
+
+	// 通常モード: 直接書き込み（一時ファイル経由でアトミックに置換）
+	// 置換後も元ファイルのパーミッション（実行ビット等）を保つ
+	perm := os.FileMode(0644)
+	if info, err := os.Stat(resolvedPath); err == nil {
+		perm = info.Mode().Perm()
+	}
+	tmpFile := resolvedPath + ".tmp"
+	if err := os.WriteFile(tmpFile, []byte(newContent), perm); err != nil {
+		return NewErrorResult(err), nil
+	}
+	if err := os.Chmod(tmpFile, perm); err != nil {
+		os.Remove(tmpFile)
+		return NewErrorResult(err), nil
+	}
+
+	if err := os.Rename(tmpFile, resolvedPath); err != nil {
+		os.Remove(tmpFile)
+		return NewErrorResult(err), nil
+	}
+
+	// 全編集をまとめて1つの undo エントリとして記録
+	t.writeTool.addToUndoStack(UndoEntry{
+		Path:       resolvedPath,
+		OldContent: oldContent,
+		NewContent: newContent,
+	})
+
+	output := fmt.Sprintf("Successfully applied %d edits to %s\n\nDiff:\n%s", len(args.Edits), args.Path, diff)
+	return NewResult(output).WithChangedPaths(resolvedPath), nil
+}
+
+// applyEdits applies edits in order to content in memory.
+// It returns the final content and one diff per edit, or an error naming the failing edit.
+func applyEdits(filename, content string, edits []EditOperation) (string, []string, error) {
+	diffs := make([]string, 0, len(edits))
+
+	for i, edit := range edits {
+		if edit.OldString == "" {
+			return "", nil, fmt.Errorf("edit %d: old_string cannot be empty", i+1)
+		}
+
+		oldString := normalizeString(edit.OldString)
+		count := strings.Count(content, oldString)
+		if count == 0 {
+			return "", nil, fmt.Errorf("edit %d: old_string not found in file", i+1)
+		}
+
+		before := content
+		if edit.ReplaceAll {
+			content = strings.ReplaceAll(content, oldString, edit.NewString)
+		} else {
+			if count > 1 {
+				return "", nil, fmt.Errorf("edit %d: old_string appears %d times; use replace_all=true or provide more unique context", i+1, count)
+			}
+			content = strings.Replace(content, oldString, edit.NewString, 1)
+		}
+
+		diffs = append(diffs, generateUnifiedDiff(filename, before, content))
+	}
+
+	return content, diffs, nil
+}
@@ -0,0 +1,207 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeMultiEditTestFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "target.go")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to create test file: %v", err)
+	}
+	return path
+}
+
+func runMultiEdit(t *testing.T, tool *MultiEditTool, path string, edits []EditOperation) *Result {
+	t.Helper()
+	params, _ := json.Marshal(map[string]interface{}{"path": path, "edits": edits})
+	result, err := tool.Execute(context.Background(), params)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	return result
+}
+
+func TestMultiEditTool_Schema(t *testing.T) {
+	tool := NewMultiEditTool()
+	schema := tool.Schema()
+
+	if schema.Name != "multi_edit" {
+		t.Errorf("expected name 'multi_edit', got '%s'", schema.Name)
+	}
+
+	edits, ok := schema.Parameters.Properties["edits"]
+	if !ok {
+		t.Fatal("expected 'edits' property")
+	}
+	if edits.Type != "array" || edits.Items == nil {
+		t.Fatalf("expected 'edits' to be an array with item schema, got %+v", edits)
+	}
+	if _, ok := edits.Items.Properties["old_string"]; !ok {
+		t.Error("expected edit items to have 'old_string'")
+	}
+}
+
+func TestMultiEditTool_Execute_AppliesAllEdits(t *testing.T) {
+	tool := NewMultiEditTool()
+	path := writeMultiEditTestFile(t, "func a() {}\nfunc b() {}\nvar x = 1\nvar y = 1\n")
+
+	result := runMultiEdit(t, tool, path, []EditOperation{
+		{OldString: "func a()", NewString: "func alpha()"},
+		{OldString: "func b()", NewString: "func beta()"},
+		{OldString: "= 1", NewString: "= 2", ReplaceAll: true},
+	})
+
+	if result.IsError {
+		t.Fatalf("expected success, got error: %s", result.Error)
+	}
+
+	data, _ := os.ReadFile(path)
+	expected := "func alpha() {}\nfunc beta() {}\nvar x = 2\nvar y = 2\n"
+	if string(data) != expected {
+		t.Errorf("expected content %q, got %q", expected, string(data))
+	}
+
+	if !strings.Contains(result.Output, "3 edits") {
+		t.Errorf("expected edit count in output, got '%s'", result.Output)
+	}
+	if !strings.Contains(result.Output, "+func alpha()") || !strings.Contains(result.Output, "+func beta()") {
+		t.Errorf("expected combined diff for every edit, got '%s'", result.Output)
+	}
+}
+
+func TestMultiEditTool_Execute_KeepsFileMode(t *testing.T) {
+	tool := NewMultiEditTool()
+	path := writeMultiEditTestFile(t, "#!/bin/sh\necho old\n")
+	if err := os.Chmod(path, 0755); err != nil {
+		t.Fatalf("chmod: %v", err)
+	}
+
+	result := runMultiEdit(t, tool, path, []EditOperation{{OldString: "old", NewString: "new"}})
+	if result.IsError {
+		t.Fatalf("expected success, got error: %s", result.Error)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("stat: %v", err)
+	}
+	if info.Mode().Perm() != 0755 {
+		t.Errorf("expected mode 0755 to be kept, got %o", info.Mode().Perm())
+	}
+}
+
+func TestMultiEditTool_Execute_EditsApplyInOrder(t *testing.T) {
+	tool := NewMultiEditTool()
+	path := writeMultiEditTestFile(t, "name := \"old\"\n")
+
+	// The second edit only matches the output of the first
+	result := runMultiEdit(t, tool, path, []EditOperation{
+		{OldString: "\"old\"", NewString: "\"intermediate\""},
+		{OldString: "\"intermediate\"", NewString: "\"final\""},
+	})
+
+	if result.IsError {
+		t.Fatalf("expected success, got error: %s", result.Error)
+	}
+
+	data, _ := os.ReadFile(path)
+	if string(data) != "name := \"final\"\n" {
+		t.Errorf("expected edits applied in order, got %q", string(data))
+	}
+}
+
+func TestMultiEditTool_Execute_AllOrNothing(t *testing.T) {
+	original := "alpha\nbeta\ngamma\n"
+
+	tests := []struct {
+		name    string
+		edits   []EditOperation
+		wantErr string
+	}{
+		{
+			"missing old_string",
+			[]EditOperation{{OldString: "alpha", NewString: "ALPHA"}, {OldString: "delta", NewString: "DELTA"}},
+			"edit 2: old_string not found",
+		},
+		{
+			"ambiguous old_string",
+			[]EditOperation{{OldString: "alpha", NewString: "beta"}, {OldString: "beta", NewString: "BETA"}},
+			"edit 2: old_string appears 2 times",
+		},
+		{
+			"empty old_string",
+			[]EditOperation{{OldString: "gamma", NewString: "GAMMA"}, {OldString: "", NewString: "x"}},
+			"edit 2: old_string cannot be empty",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tool := NewMultiEditTool()
+			path := writeMultiEditTestFile(t, original)
+
+			result := runMultiEdit(t, tool, path, tt.edits)
+			if !result.IsError {
+				t.Fatal("expected error result")
+			}
+			if !strings.Contains(result.Error, tt.wantErr) {
+				t.Errorf("expected error containing %q, got '%s'", tt.wantErr, result.Error)
+			}
+
+			data, _ := os.ReadFile(path)
+			if string(data) != original {
+				t.Errorf("expected file to be unchanged, got %q", string(data))
+			}
+			if len(tool.writeTool.GetUndoStack()) != 0 {
+				t.Error("expected no undo entry for a failed multi_edit")
+			}
+		})
+	}
+}
+
+func TestMultiEditTool_Execute_SingleUndoEntry(t *testing.T) {
+	writeTool := NewWriteTool()
+	tool := NewMultiEditTool()
+	tool.SetWriteTool(writeTool)
+
+	original := "one\ntwo\nthree\n"
+	path := writeMultiEditTestFile(t, original)
+
+	result := runMultiEdit(t, tool, path, []EditOperation{
+		{OldString: "one", NewString: "1"},
+		{OldString: "two", NewString: "2"},
+	})
+	if result.IsError {
+		t.Fatalf("expected success, got error: %s", result.Error)
+	}
+
+	if stack := writeTool.GetUndoStack(); len(stack) != 1 {
+		t.Fatalf("expected 1 undo entry, got %d", len(stack))
+	}
+
+	if err := writeTool.Undo(); err != nil {
+		t.Fatalf("undo failed: %v", err)
+	}
+
+	data, _ := os.ReadFile(path)
+	if string(data) != original {
+		t.Errorf("expected undo to restore original content, got %q", string(data))
+	}
+}
+
+func TestMultiEditTool_Execute_EmptyEdits(t *testing.T) {
+	tool := NewMultiEditTool()
+	path := writeMultiEditTestFile(t, "content\n")
+
+	result := runMultiEdit(t, tool, path, nil)
+	if !result.IsError {
+		t.Error("expected error result for empty edits")
+	}
+}
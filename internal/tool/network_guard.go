@@ -0,0 +1,232 @@
+package tool
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/zephel01/vibe-local-go/internal/security"
+)
+
+// networkCommandKind decides how the target of a network command is found
+type networkCommandKind int
+
+const (
+	netURL        networkCommandKind = iota // Every non-flag argument is a URL or host (curl, wget)
+	netHost                                 // The first non-flag argument is the host (ssh, nc)
+	netRemotePath                           // Only host:path / user@host arguments are remote (scp, rsync)
+	netRegistry                             // Fetches from a remote registry unless given local paths (pip install)
+)
+
+// networkCommand is a command that reaches the network when run
+type networkCommand struct {
+	kind       networkCommandKind
+	subcommand []string        // Only these subcommands reach the network (empty = any)
+	valueFlags map[string]bool // Flags whose next argument is a value, not a target
+}
+
+// networkCommands is the list of commands blocked in no-network mode
+var networkCommands = map[string]networkCommand{
+	"curl":   {kind: netURL, valueFlags: flagSet("-o", "--output", "-d", "--data", "--data-raw", "--data-binary", "-H", "--header", "-X", "--request", "-u", "--user", "-T", "--upload-file", "-b", "--cookie", "-c", "--cookie-jar", "-e", "--referer", "-A", "--user-agent", "-F", "--form", "-w", "--write-out", "-m", "--max-time", "--connect-timeout")},
+	"wget":   {kind: netURL, valueFlags: flagSet("-O", "-o", "-P", "--output-document", "--output-file", "--directory-prefix", "-T", "--timeout", "-U", "--user-agent", "--header")},
+	"http":   {kind: netURL},
+	"https":  {kind: netURL},
+	"nc":     {kind: netHost, valueFlags: flagSet("-p", "-s", "-w", "-i", "-x")},
+	"ncat":   {kind: netHost, valueFlags: flagSet("-p", "-s", "-w", "-i", "-x")},
+	"netcat": {kind: netHost, valueFlags: flagSet("-p", "-s", "-w", "-i", "-x")},
+	"telnet": {kind: netHost},
+	"ftp":    {kind: netHost},
+	"ssh":    {kind: netHost, valueFlags: flagSet("-p", "-i", "-l", "-o", "-F", "-L", "-R", "-D", "-J", "-b", "-c", "-E", "-m", "-S", "-W", "-w")},
+	"sftp":   {kind: netHost, valueFlags: flagSet("-P", "-i", "-o", "-F", "-b", "-c", "-J", "-S")},
+	"scp":    {kind: netRemotePath, valueFlags: flagSet("-P", "-i", "-o", "-F", "-c", "-J", "-l", "-S")},
+	"rsync":  {kind: netRemotePath, valueFlags: flagSet("-e", "--rsh", "--exclude", "--include", "--port")},
+	"pip":    {kind: netRegistry, subcommand: []string{"install", "download"}, valueFlags: flagSet("-r", "--requirement", "-c", "--constraint", "-i", "--index-url", "--extra-index-url", "-t", "--target")},
+	"pip3":   {kind: netRegistry, subcommand: []string{"install", "download"}, valueFlags: flagSet("-r", "--requirement", "-c", "--constraint", "-i", "--index-url", "--extra-index-url", "-t", "--target")},
+	"git":    {kind: netRegistry, subcommand: []string{"clone", "fetch", "pull", "push", "ls-remote", "submodule"}, valueFlags: flagSet("-b", "--branch", "--depth", "-o", "--origin")},
+	"npm":    {kind: netRegistry, subcommand: []string{"install", "i", "add", "update", "publish"}},
+	"yarn":   {kind: netRegistry, subcommand: []string{"add", "install", "upgrade"}},
+	"pnpm":   {kind: netRegistry, subcommand: []string{"add", "install", "i", "update"}},
+	"go":     {kind: netRegistry, subcommand: []string{"get"}},
+}
+
+// commandWrappers run the command that follows them
+var commandWrappers = map[string]bool{
+	"sudo": true, "env": true, "time": true, "nohup": true, "exec": true, "command": true, "nice": true,
+}
+
+func flagSet(flags ...string) map[string]bool {
+	set := make(map[string]bool, len(flags))
+	for _, f := range flags {
+		set[f] = true
+	}
+	return set
+}
+
+// CheckNetworkCommand reports whether command runs a network fetcher against a
+// remote host. Targets on localhost (localhost, 127.0.0.1, ::1) are allowed.
+// The reason names the command and the remote target.
+func CheckNetworkCommand(command string) (bool, string) {
+	for _, part := range security.SplitCommand(command) {
+		if blocked, reason := checkNetworkSimpleCommand(part); blocked {
+			return true, reason
+		}
+	}
+	return false, ""
+}
+
+// checkNetworkSimpleCommand checks one command without pipes or separators
+func checkNetworkSimpleCommand(part string) (bool, string) {
+	tokens := strings.Fields(part)
+	for i := range tokens {
+		tokens[i] = strings.Trim(tokens[i], `"'`)
+	}
+
+	// Skip env assignments and wrappers such as sudo
+	for len(tokens) > 0 && (strings.Contains(tokens[0], "=") || commandWrappers[tokens[0]]) {
+		tokens = tokens[1:]
+	}
+	if len(tokens) == 0 {
+		return false, ""
+	}
+
+	name := filepath.Base(tokens[0])
+	args := tokens[1:]
+
+	// python -m pip / uv pip
+	if (strings.HasPrefix(name, "python") && len(args) >= 2 && args[0] == "-m") || (name == "uv" && len(args) >= 1) {
+		if name == "uv" {
+			name, args = args[0], args[1:]
+		} else {
+			name, args = args[1], args[2:]
+		}
+	}
+
+	spec, ok := networkCommands[name]
+	if !ok {
+		return false, ""
+	}
+
+	if len(spec.subcommand) > 0 {
+		if len(args) == 0 || !containsString(spec.subcommand, args[0]) {
+			return false, ""
+		}
+		name += " " + args[0]
+		args = args[1:]
+	}
+
+	operands := nonFlagArgs(args, spec.valueFlags)
+	switch spec.kind {
+	case netURL, netHost:
+		if spec.kind == netHost && len(operands) > 1 {
+			operands = operands[:1]
+		}
+		for _, target := range operands {
+			if !isLocalHost(hostOf(target)) {
+				return true, fmt.Sprintf("%s reaches %s", name, target)
+			}
+		}
+	case netRemotePath:
+		for _, target := range operands {
+			if isRemotePath(target) && !isLocalHost(hostOf(target)) {
+				return true, fmt.Sprintf("%s reaches %s", name, target)
+			}
+		}
+	case netRegistry:
+		// Only local paths and localhost URLs stay off the network
+		if len(operands) == 0 {
+			return true, fmt.Sprintf("%s fetches from a remote registry", name)
+		}
+		for _, target := range operands {
+			if isLocalPath(target) {
+				continue
+			}
+			if strings.Contains(target, "://") && isLocalHost(hostOf(target)) {
+				continue
+			}
+			return true, fmt.Sprintf("%s fetches %s from a remote registry", name, target)
+		}
+	}
+	return false, ""
+}
+
+// nonFlagArgs returns the arguments that aren't flags or flag values
+func nonFlagArgs(args []string, valueFlags map[string]bool) []string {
+	var operands []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if strings.HasPrefix(arg, "-") {
+			if valueFlags[arg] {
+				i++ // Skip the flag's value
+			}
+			continue
+		}
+		if arg == "" {
+			continue
+		}
+		// Redirections: "> out.txt" skips the file name, ">out.txt" / "2>&1" are self-contained
+		if op := strings.TrimLeft(arg, "0123456789"); strings.HasPrefix(op, ">") || strings.HasPrefix(op, "<") {
+			if strings.Trim(op, "<>&") == "" && !strings.HasSuffix(op, "&") {
+				i++
+			}
+			continue
+		}
+		operands = append(operands, arg)
+	}
+	return operands
+}
+
+// hostOf extracts the host from a URL, host:port, user@host or host:path argument
+func hostOf(target string) string {
+	host := target
+	if i := strings.Index(host, "://"); i >= 0 {
+		host = host[i+3:]
+	}
+	if i := strings.IndexByte(host, '/'); i >= 0 {
+		host = host[:i]
+	}
+	if i := strings.LastIndexByte(host, '@'); i >= 0 {
+		host = host[i+1:]
+	}
+	if strings.HasPrefix(host, "[") {
+		if i := strings.IndexByte(host, ']'); i >= 0 {
+			return strings.ToLower(host[1:i])
+		}
+	}
+	if strings.Count(host, ":") == 1 {
+		host = host[:strings.IndexByte(host, ':')]
+	}
+	return strings.ToLower(host)
+}
+
+// isLocalHost reports whether host is the loopback interface
+func isLocalHost(host string) bool {
+	return host == "localhost" || host == "::1" || host == "0.0.0.0" || strings.HasPrefix(host, "127.")
+}
+
+// isRemotePath reports whether an scp/rsync operand names a remote host
+func isRemotePath(target string) bool {
+	if strings.Contains(target, "://") {
+		return true
+	}
+	colon := strings.IndexByte(target, ':')
+	slash := strings.IndexByte(target, '/')
+	return colon > 0 && (slash < 0 || colon < slash)
+}
+
+// isLocalPath reports whether a package argument is a local path (., ./pkg, /abs, file.whl)
+func isLocalPath(target string) bool {
+	if strings.Contains(target, "://") {
+		return false
+	}
+	return strings.HasPrefix(target, ".") || strings.HasPrefix(target, "/") || strings.HasPrefix(target, "~") ||
+		strings.HasSuffix(target, ".whl") || strings.HasSuffix(target, ".tar.gz")
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,78 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestCheckNetworkCommand(t *testing.T) {
+	tests := []struct {
+		command     string
+		wantBlocked bool
+	}{
+		{"curl example.com", true},
+		{"curl https://example.com/install.sh -o install.sh", true},
+		{"wget -q http://example.com/file.tar.gz", true},
+		{"nc example.com 80", true},
+		{"ssh user@example.com ls", true},
+		{"scp build.tar.gz deploy@example.com:/srv", true},
+		{"pip install requests", true},
+		{"python3 -m pip install -r requirements.txt", true},
+		{"git clone https://github.com/example/repo", true},
+		{"ls && curl example.com", true},
+		{"sudo wget example.com", true},
+		{"echo $(curl -s example.com)", true},
+
+		{"curl localhost:8080", false},
+		{"curl -s http://127.0.0.1:3000/health > out.json", false},
+		{"curl -X POST -d '{}' http://localhost:8080/api", false},
+		{"nc -z localhost 5432", false},
+		{"ssh localhost", false},
+		{"scp a.txt b.txt", false},
+		{"rsync -a src/ dst/", false},
+		{"pip install -e .", false},
+		{"pip list", false},
+		{"git status", false},
+		{"curl --version", false},
+		{"go build ./...", false},
+		{"echo curl example.com", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			blocked, reason := CheckNetworkCommand(tt.command)
+			if blocked != tt.wantBlocked {
+				t.Errorf("CheckNetworkCommand(%q) = %v (%s), want %v", tt.command, blocked, reason, tt.wantBlocked)
+			}
+			if blocked && reason == "" {
+				t.Error("expected a reason for a blocked command")
+			}
+		})
+	}
+}
+
+func TestBashTool_NoNetwork(t *testing.T) {
+	tool := NewBashTool()
+	tool.SetNoNetwork(true)
+
+	params, _ := json.Marshal(map[string]string{"command": "curl example.com"})
+	result, err := tool.Execute(context.Background(), params)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !result.IsError || !strings.Contains(result.Error, "network access is disabled") {
+		t.Fatalf("expected no-network error, got %+v", result)
+	}
+	if !strings.Contains(result.Error, "example.com") {
+		t.Errorf("expected the error to name the target, got %q", result.Error)
+	}
+
+	// Commands that don't touch the network still run
+	params, _ = json.Marshal(map[string]string{"command": "echo ok"})
+	result, _ = tool.Execute(context.Background(), params)
+	if result.IsError || !strings.Contains(result.Output, "ok") {
+		t.Errorf("expected echo to run, got %+v", result)
+	}
+}
@@ -2,20 +2,37 @@ package tool
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"os"
 	"strings"
+
+	"github.com/zephel01/vibe-local-go/internal/security"
 )
 
 // NotebookEditTool edits Jupyter notebook (.ipynb) cells
-type NotebookEditTool struct{}
+type NotebookEditTool struct {
+	validator  *security.PathValidator
+	vibeIgnore *IgnoreMatcher
+}
 
 // NewNotebookEditTool creates a new notebook edit tool
 func NewNotebookEditTool() *NotebookEditTool {
 	return &NotebookEditTool{}
 }
 
+// SetPathValidator は編集対象を検証するバリデーターを設定する（作業ディレクトリ外の編集を拒否）
+func (t *NotebookEditTool) SetPathValidator(v *security.PathValidator) {
+	t.validator = v
+}
+
+// SetVibeIgnore は .vibeignore のマッチャーを設定する（該当パスの編集を拒否、nil = チェックなし）
+func (t *NotebookEditTool) SetVibeIgnore(m *IgnoreMatcher) {
+	t.vibeIgnore = m
+}
+
 // Name returns the tool name
 func (t *NotebookEditTool) Name() string {
 	return "notebook_edit"
@@ -25,7 +42,7 @@ func (t *NotebookEditTool) Name() string {
 func (t *NotebookEditTool) Schema() *FunctionSchema {
 	return &FunctionSchema{
 		Name:        "notebook_edit",
-		Description: "Edit a Jupyter notebook (.ipynb) cell. Supports replace, insert, and delete operations.",
+		Description: "Edit a Jupyter notebook (.ipynb) cell. Supports replace, insert (a new code or markdown cell at cell_number), and delete operations.",
 		Parameters: &ParameterSchema{
 			Type: "object",
 			Properties: map[string]*PropertyDef{
@@ -37,12 +54,17 @@ func (t *NotebookEditTool) Schema() *FunctionSchema {
 					Type:        "integer",
 					Description: "0-based index of the cell to edit",
 				},
-				"edit_mode": {
+				"operation": {
 					Type:        "string",
-					Description: "Edit mode: replace, insert, or delete",
+					Description: "Operation: replace, insert, or delete",
 					Enum:        []string{"replace", "insert", "delete"},
 					Default:     "replace",
 				},
+				"edit_mode": {
+					Type:        "string",
+					Description: "Same as operation (kept for compatibility)",
+					Enum:        []string{"replace", "insert", "delete"},
+				},
 				"new_source": {
 					Type:        "string",
 					Description: "New source content for the cell (required for replace/insert, ignored for delete)",
@@ -58,11 +80,31 @@ func (t *NotebookEditTool) Schema() *FunctionSchema {
 	}
 }
 
+// notebookSource is a cell's source. nbformat allows either a list of lines
+// or a single string; both are read as a list of lines
+type notebookSource []string
+
+// UnmarshalJSON accepts a list of lines or a single string
+func (s *notebookSource) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		*s = splitSource(text)
+		return nil
+	}
+	var lines []string
+	if err := json.Unmarshal(data, &lines); err != nil {
+		return err
+	}
+	*s = lines
+	return nil
+}
+
 // notebookCell represents a single cell in a Jupyter notebook
 type notebookCell struct {
-	CellType string        `json:"cell_type"`
-	Source   []string       `json:"source"`
-	Metadata json.RawMessage `json:"metadata,omitempty"`
+	CellType    string          `json:"cell_type"`
+	Source      notebookSource  `json:"source"`
+	Metadata    json.RawMessage `json:"metadata,omitempty"`
+	Attachments json.RawMessage `json:"attachments,omitempty"` // markdown/raw cells
 	// code cell fields
 	ExecutionCount *int            `json:"execution_count,omitempty"`
 	Outputs        json.RawMessage `json:"outputs,omitempty"`
@@ -70,6 +112,40 @@ type notebookCell struct {
 	ID string `json:"id,omitempty"`
 }
 
+// MarshalJSON writes the fields nbformat requires for the cell type: code
+// cells always have execution_count (null when not run) and outputs, other
+// cells have neither. Keys are sorted, as Jupyter writes them
+func (c notebookCell) MarshalJSON() ([]byte, error) {
+	source := c.Source
+	if source == nil {
+		source = notebookSource{}
+	}
+	metadata := c.Metadata
+	if len(metadata) == 0 {
+		metadata = json.RawMessage("{}")
+	}
+	m := map[string]interface{}{
+		"cell_type": c.CellType,
+		"source":    []string(source),
+		"metadata":  metadata,
+	}
+	if c.ID != "" {
+		m["id"] = c.ID
+	}
+	if len(c.Attachments) > 0 && c.CellType != "code" {
+		m["attachments"] = c.Attachments
+	}
+	if c.CellType == "code" {
+		outputs := c.Outputs
+		if len(outputs) == 0 {
+			outputs = json.RawMessage("[]")
+		}
+		m["execution_count"] = c.ExecutionCount
+		m["outputs"] = outputs
+	}
+	return json.Marshal(m)
+}
+
 // notebook represents a Jupyter notebook structure
 type notebook struct {
 	Cells         []notebookCell  `json:"cells"`
@@ -84,6 +160,7 @@ func (t *NotebookEditTool) Execute(ctx context.Context, params json.RawMessage)
 		Path       string `json:"path"`
 		CellNumber int    `json:"cell_number"`
 		EditMode   string `json:"edit_mode"`
+		Operation  string `json:"operation"`
 		NewSource  string `json:"new_source"`
 		CellType   string `json:"cell_type"`
 	}
@@ -96,7 +173,12 @@ func (t *NotebookEditTool) Execute(ctx context.Context, params json.RawMessage)
 		return NewErrorResult(fmt.Errorf("path is required")), nil
 	}
 
-	if args.EditMode == "" {
+	switch {
+	case args.Operation != "" && args.EditMode != "" && args.Operation != args.EditMode:
+		return NewErrorResult(fmt.Errorf("operation %q and edit_mode %q disagree", args.Operation, args.EditMode)), nil
+	case args.Operation != "":
+		args.EditMode = args.Operation
+	case args.EditMode == "":
 		args.EditMode = "replace"
 	}
 
@@ -105,7 +187,7 @@ func (t *NotebookEditTool) Execute(ctx context.Context, params json.RawMessage)
 	case "replace", "insert", "delete":
 		// valid
 	default:
-		return NewErrorResult(fmt.Errorf("invalid edit_mode: %s (must be replace, insert, or delete)", args.EditMode)), nil
+		return NewErrorResult(fmt.Errorf("invalid operation: %s (must be replace, insert, or delete)", args.EditMode)), nil
 	}
 
 	// Resolve path
@@ -114,6 +196,14 @@ func (t *NotebookEditTool) Execute(ctx context.Context, params json.RawMessage)
 		return NewErrorResult(fmt.Errorf("cannot resolve path: %v", err)), nil
 	}
 
+	// Confine edits to the working directory
+	if err := checkWorkdir(t.validator, resolvedPath); err != nil {
+		return NewErrorResult(err), nil
+	}
+	if err := checkVibeIgnoreWrite(t.vibeIgnore, resolvedPath); err != nil {
+		return NewErrorResult(err), nil
+	}
+
 	// Read notebook
 	data, err := os.ReadFile(resolvedPath)
 	if err != nil {
@@ -153,7 +243,7 @@ func (t *NotebookEditTool) replaceCell(nb *notebook, path string, cellNum int, s
 		if cellType == "markdown" {
 			// Clear code-specific fields
 			nb.Cells[cellNum].ExecutionCount = nil
-			nb.Cells[cellNum].Outputs = json.RawMessage("[]")
+			nb.Cells[cellNum].Outputs = nil
 		} else if cellType == "code" {
 			// Ensure code cell has outputs array
 			if nb.Cells[cellNum].Outputs == nil {
@@ -172,7 +262,7 @@ func (t *NotebookEditTool) replaceCell(nb *notebook, path string, cellNum int, s
 		return NewErrorResult(err), nil
 	}
 
-	return NewResult(fmt.Sprintf("Replaced cell %d in %s", cellNum, path)), nil
+	return NewResult(fmt.Sprintf("Replaced cell %d in %s", cellNum, path)).WithChangedPaths(path), nil
 }
 
 // insertCell inserts a new cell at the specified position
@@ -194,6 +284,9 @@ func (t *NotebookEditTool) insertCell(nb *notebook, path string, cellNum int, so
 	if cellType == "code" {
 		newCell.Outputs = json.RawMessage("[]")
 	}
+	if nb.requiresCellIDs() {
+		newCell.ID = nb.newCellID()
+	}
 
 	// Insert at position
 	cells := make([]notebookCell, 0, len(nb.Cells)+1)
@@ -206,7 +299,7 @@ func (t *NotebookEditTool) insertCell(nb *notebook, path string, cellNum int, so
 		return NewErrorResult(err), nil
 	}
 
-	return NewResult(fmt.Sprintf("Inserted new %s cell at position %d in %s", cellType, cellNum, path)), nil
+	return NewResult(fmt.Sprintf("Inserted new %s cell at position %d in %s", cellType, cellNum, path)).WithChangedPaths(path), nil
 }
 
 // deleteCell deletes a cell at the specified position
@@ -225,7 +318,7 @@ func (t *NotebookEditTool) deleteCell(nb *notebook, path string, cellNum int) (*
 		return NewErrorResult(err), nil
 	}
 
-	return NewResult(fmt.Sprintf("Deleted cell %d from %s (now %d cells)", cellNum, path, len(nb.Cells))), nil
+	return NewResult(fmt.Sprintf("Deleted cell %d from %s (now %d cells)", cellNum, path, len(nb.Cells))).WithChangedPaths(path), nil
 }
 
 // splitSource splits source string into lines for Jupyter format
@@ -247,13 +340,44 @@ func splitSource(source string) []string {
 	return result
 }
 
-// writeNotebook writes a notebook back to disk with proper formatting
+// requiresCellIDs reports whether the notebook's format (4.5+) requires cell ids
+func (nb *notebook) requiresCellIDs() bool {
+	return nb.NBFormat > 4 || (nb.NBFormat == 4 && nb.NBFormatMinor >= 5)
+}
+
+// newCellID returns a random cell id not used by any cell of the notebook
+func (nb *notebook) newCellID() string {
+	for {
+		b := make([]byte, 4)
+		rand.Read(b)
+		id := hex.EncodeToString(b)
+		used := false
+		for _, c := range nb.Cells {
+			used = used || c.ID == id
+		}
+		if !used {
+			return id
+		}
+	}
+}
+
+// writeNotebook writes a notebook back to disk with proper formatting.
+// The encoded notebook is decoded again first so a broken structure is
+// reported instead of written
 func writeNotebook(nb *notebook, path string) error {
 	data, err := json.MarshalIndent(nb, "", " ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal notebook: %v", err)
 	}
 
+	var check notebook
+	if err := json.Unmarshal(data, &check); err != nil {
+		return fmt.Errorf("edited notebook does not round-trip: %v", err)
+	}
+	if len(check.Cells) != len(nb.Cells) {
+		return fmt.Errorf("edited notebook does not round-trip: %d cells written, %d read back", len(nb.Cells), len(check.Cells))
+	}
+
 	// Jupyter notebooks end with a newline
 	data = append(data, '\n')
 
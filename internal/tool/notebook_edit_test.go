@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -105,6 +106,9 @@ func TestNotebookEditTool_ReplaceCell(t *testing.T) {
 	if result.IsError {
 		t.Fatalf("unexpected error result: %s", result.Error)
 	}
+	if len(result.ChangedPaths) != 1 || filepath.Base(result.ChangedPaths[0]) != filepath.Base(path) {
+		t.Errorf("ChangedPaths = %v, want the notebook", result.ChangedPaths)
+	}
 
 	nb := readNotebookFile(t, path)
 	if len(nb.Cells) != 3 {
@@ -422,6 +426,141 @@ func TestNotebookEditTool_PreservesMetadata(t *testing.T) {
 	}
 }
 
+func TestNotebookEditTool_InsertAtStart(t *testing.T) {
+	dir := t.TempDir()
+	path := createTestNotebook(t, dir)
+	tool := NewNotebookEditTool()
+
+	params, _ := json.Marshal(map[string]interface{}{
+		"path":        path,
+		"cell_number": 0,
+		"operation":   "insert",
+		"new_source":  "import os",
+	})
+
+	result, err := tool.Execute(context.Background(), params)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.IsError {
+		t.Fatalf("unexpected error result: %s", result.Error)
+	}
+
+	nb := readNotebookFile(t, path)
+	if len(nb.Cells) != 4 {
+		t.Fatalf("expected 4 cells after insert, got %d", len(nb.Cells))
+	}
+	if nb.Cells[0].CellType != "code" || joinSource(nb.Cells[0].Source) != "import os" {
+		t.Errorf("cell 0 = %s %q, want the inserted code cell", nb.Cells[0].CellType, joinSource(nb.Cells[0].Source))
+	}
+	if nb.Cells[0].ID == "" {
+		t.Error("cells inserted into an nbformat 4.5 notebook need an id")
+	}
+	if joinSource(nb.Cells[1].Source) != "print(\"hello\")" {
+		t.Errorf("old cell 0 should move to 1, got %q", joinSource(nb.Cells[1].Source))
+	}
+}
+
+func TestNotebookEditTool_DeleteFinalCell(t *testing.T) {
+	dir := t.TempDir()
+	path := createTestNotebook(t, dir)
+	tool := NewNotebookEditTool()
+
+	params, _ := json.Marshal(map[string]interface{}{
+		"path":        path,
+		"cell_number": 2,
+		"operation":   "delete",
+	})
+
+	result, err := tool.Execute(context.Background(), params)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.IsError {
+		t.Fatalf("unexpected error result: %s", result.Error)
+	}
+
+	nb := readNotebookFile(t, path)
+	if len(nb.Cells) != 2 {
+		t.Fatalf("expected 2 cells after delete, got %d", len(nb.Cells))
+	}
+	if nb.Cells[1].CellType != "markdown" {
+		t.Errorf("expected the markdown cell to be last, got '%s'", nb.Cells[1].CellType)
+	}
+}
+
+func TestNotebookEditTool_ConflictingOperation(t *testing.T) {
+	dir := t.TempDir()
+	path := createTestNotebook(t, dir)
+	tool := NewNotebookEditTool()
+
+	params, _ := json.Marshal(map[string]interface{}{
+		"path":        path,
+		"cell_number": 0,
+		"operation":   "delete",
+		"edit_mode":   "replace",
+	})
+
+	result, _ := tool.Execute(context.Background(), params)
+	if !result.IsError {
+		t.Error("expected error when operation and edit_mode disagree")
+	}
+	if nb := readNotebookFile(t, path); len(nb.Cells) != 3 {
+		t.Errorf("notebook should be unchanged, got %d cells", len(nb.Cells))
+	}
+}
+
+func TestNotebookEditTool_KeepsValidStructure(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "run.ipynb")
+	// A notebook as Jupyter writes it: executed code cell, string source
+	os.WriteFile(path, []byte(`{
+ "cells": [
+  {"cell_type": "code", "execution_count": 7, "id": "a1", "metadata": {}, "outputs": [{"output_type": "stream", "name": "stdout", "text": ["1\n"]}], "source": "print(1)"},
+  {"cell_type": "markdown", "id": "b2", "metadata": {}, "source": ["# Notes\n"]}
+ ],
+ "metadata": {},
+ "nbformat": 4,
+ "nbformat_minor": 5
+}
+`), 0644)
+	tool := NewNotebookEditTool()
+
+	for _, args := range []map[string]interface{}{
+		{"path": path, "cell_number": 2, "operation": "insert", "new_source": "x = 1"},
+		{"path": path, "cell_number": 1, "operation": "replace", "new_source": "Plain", "cell_type": "markdown"},
+	} {
+		params, _ := json.Marshal(args)
+		if result, _ := tool.Execute(context.Background(), params); result.IsError {
+			t.Fatalf("%v: %s", args, result.Error)
+		}
+	}
+
+	data, _ := os.ReadFile(path)
+	var raw struct {
+		Cells []map[string]json.RawMessage `json:"cells"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil || len(raw.Cells) != 3 {
+		t.Fatalf("written notebook = %d cells, err %v", len(raw.Cells), err)
+	}
+
+	if string(raw.Cells[0]["execution_count"]) != "7" {
+		t.Errorf("execution count of the untouched cell = %s, want 7", raw.Cells[0]["execution_count"])
+	}
+	if !strings.Contains(string(raw.Cells[0]["outputs"]), "stdout") {
+		t.Errorf("outputs of the untouched cell were lost: %s", raw.Cells[0]["outputs"])
+	}
+	for _, key := range []string{"outputs", "execution_count"} {
+		if _, ok := raw.Cells[1][key]; ok {
+			t.Errorf("markdown cell must not have %q", key)
+		}
+	}
+	// nbformat requires both keys on code cells, execution_count null until run
+	if string(raw.Cells[2]["execution_count"]) != "null" || string(raw.Cells[2]["outputs"]) != "[]" {
+		t.Errorf("inserted code cell = execution_count %s, outputs %s", raw.Cells[2]["execution_count"], raw.Cells[2]["outputs"])
+	}
+}
+
 // joinSource joins source lines back into a single string (strips trailing newlines from intermediate lines)
 func joinSource(source []string) string {
 	if len(source) == 0 {
@@ -441,3 +580,27 @@ func trimTrailingNewline(s string) string {
 	}
 	return s
 }
+
+func TestNotebookEditTool_OutsideWorkdirRejected(t *testing.T) {
+	validator, workdir := newWorkdirValidator(t)
+	tool := NewNotebookEditTool()
+	tool.SetPathValidator(validator)
+
+	path := createTestNotebook(t, filepath.Dir(workdir))
+	before, _ := os.ReadFile(path)
+	params, _ := json.Marshal(map[string]interface{}{
+		"path":        filepath.Join(workdir, "..", "test.ipynb"),
+		"cell_number": 0,
+		"new_source":  "import os",
+	})
+	result, err := tool.Execute(context.Background(), params)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.IsError {
+		t.Fatal("expected a notebook outside the working directory to be rejected")
+	}
+	if after, _ := os.ReadFile(path); string(after) != string(before) {
+		t.Error("notebook outside the working directory was modified")
+	}
+}
@@ -0,0 +1,85 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+// ErrOffline is returned by network tools while offline mode is enabled
+var ErrOffline = errors.New("offline mode: network access is disabled")
+
+// IsNetworkTool reports whether a tool declares a network dependency
+func IsNetworkTool(t Tool) bool {
+	dt, ok := t.(DependentTool)
+	if !ok {
+		return false
+	}
+	for _, dep := range dt.Dependencies() {
+		if strings.HasPrefix(dep.Name, "network:") {
+			return true
+		}
+	}
+	return false
+}
+
+// offlineTool stands in for a network tool while offline mode is enabled
+type offlineTool struct {
+	inner Tool
+}
+
+func (t *offlineTool) Name() string {
+	return t.inner.Name()
+}
+
+func (t *offlineTool) Schema() *FunctionSchema {
+	return t.inner.Schema()
+}
+
+// Execute never reaches the network
+func (t *offlineTool) Execute(ctx context.Context, params json.RawMessage) (*Result, error) {
+	return NewErrorResult(ErrOffline), nil
+}
+
+// Dependencies makes Preflight report the tool as unavailable without probing the network
+func (t *offlineTool) Dependencies() []Dependency {
+	return []Dependency{{
+		Name:  "offline",
+		Check: func() error { return ErrOffline },
+	}}
+}
+
+// SetOffline enables or disables offline mode. While offline, network tools are
+// left out of GetSchemas and calling them returns ErrOffline; tools registered
+// later are covered too.
+func (r *Registry) SetOffline(offline bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.offline = offline
+	for _, cfg := range r.tools {
+		applyOffline(cfg, offline)
+	}
+	r.schemaCache = nil
+}
+
+// IsOffline reports whether offline mode is enabled
+func (r *Registry) IsOffline() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.offline
+}
+
+// applyOffline swaps a network tool for its offline stand-in (or back)
+func applyOffline(cfg *ToolConfig, offline bool) {
+	if ot, ok := cfg.Tool.(*offlineTool); ok {
+		if !offline {
+			cfg.Tool = ot.inner
+		}
+		return
+	}
+	if offline && IsNetworkTool(cfg.Tool) {
+		cfg.Tool = &offlineTool{inner: cfg.Tool}
+	}
+}
@@ -0,0 +1,96 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRegistry_OfflineDisablesNetworkTools(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register(NewReadTool())
+	registry.Register(NewGrepTool())
+	registry.SetOffline(true)
+	// Tools registered after SetOffline are covered too
+	registry.Register(NewWebFetchTool())
+	registry.Register(NewWebSearchTool())
+
+	schemaNames := make(map[string]bool)
+	for _, schema := range registry.GetSchemas() {
+		schemaNames[schema.Name] = true
+	}
+	for _, name := range []string{"web_fetch", "web_search"} {
+		if schemaNames[name] {
+			t.Errorf("%s should not be offered to the model in offline mode", name)
+		}
+	}
+	for _, name := range []string{"read_file", "grep"} {
+		if !schemaNames[name] {
+			t.Errorf("%s should still be offered in offline mode", name)
+		}
+	}
+
+	ctx := context.Background()
+	for name, params := range map[string]string{
+		"web_fetch":  `{"url": "https://example.com"}`,
+		"web_search": `{"query": "golang"}`,
+	} {
+		cfg, ok := registry.Get(name)
+		if !ok {
+			t.Fatalf("%s should stay registered", name)
+		}
+		result, err := cfg.Tool.Execute(ctx, json.RawMessage(params))
+		if err != nil {
+			t.Fatalf("%s: expected no error, got %v", name, err)
+		}
+		if !result.IsError || !strings.Contains(result.Error, "offline mode") {
+			t.Errorf("%s: expected offline mode error, got %+v", name, result)
+		}
+	}
+
+	// Preflight reports the tools as unavailable without probing the network
+	degraded := registry.Preflight()
+	if !strings.Contains(degraded["web_fetch"], "offline mode") || !strings.Contains(degraded["web_search"], "offline mode") {
+		t.Errorf("degraded = %v, want web tools marked offline", degraded)
+	}
+
+	// Local tools keep working
+	dir := t.TempDir()
+	path := filepath.Join(dir, "notes.txt")
+	if err := os.WriteFile(path, []byte("offline needle\n"), 0644); err != nil {
+		t.Fatalf("failed to create test file: %v", err)
+	}
+
+	readCfg, _ := registry.Get("read_file")
+	params, _ := json.Marshal(map[string]interface{}{"path": path})
+	if result, _ := readCfg.Tool.Execute(ctx, params); result.IsError || !strings.Contains(result.Output, "offline needle") {
+		t.Errorf("read_file should work offline, got %+v", result)
+	}
+
+	grepCfg, _ := registry.Get("grep")
+	params, _ = json.Marshal(map[string]interface{}{"pattern": "needle", "path": dir})
+	if result, _ := grepCfg.Tool.Execute(ctx, params); result.IsError || !strings.Contains(result.Output, "notes.txt") {
+		t.Errorf("grep should work offline, got %+v", result)
+	}
+}
+
+func TestRegistry_SetOfflineFalseRestoresNetworkTools(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register(NewWebFetchTool())
+	registry.SetOffline(true)
+	registry.SetOffline(false)
+
+	if registry.IsOffline() {
+		t.Error("IsOffline() should be false")
+	}
+	tool, _ := registry.GetTool("web_fetch")
+	if _, ok := tool.(*WebFetchTool); !ok {
+		t.Errorf("web_fetch = %T, want the original tool back", tool)
+	}
+	if len(registry.GetSchemas()) != 1 {
+		t.Error("web_fetch should be offered again after leaving offline mode")
+	}
+}
@@ -0,0 +1,72 @@
+package tool
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"sync"
+)
+
+type outputLinesKey struct{}
+
+// WithOutputLines attaches a handler that tools supporting live output (bash)
+// call with each line as the command produces it
+func WithOutputLines(ctx context.Context, fn func(line string)) context.Context {
+	return context.WithValue(ctx, outputLinesKey{}, fn)
+}
+
+// OutputLinesFrom returns the line handler on ctx (nil = no live output)
+func OutputLinesFrom(ctx context.Context) func(line string) {
+	fn, _ := ctx.Value(outputLinesKey{}).(func(line string))
+	return fn
+}
+
+// lineWriter is an io.Writer that passes each complete line to fn.
+// A trailing partial line is held until the next write or Flush
+type lineWriter struct {
+	mu      sync.Mutex
+	fn      func(line string)
+	partial bytes.Buffer
+}
+
+// newLineWriter creates a lineWriter calling fn
+func newLineWriter(fn func(line string)) *lineWriter {
+	return &lineWriter{fn: fn}
+}
+
+// Write implements io.Writer
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.partial.Write(p)
+	for {
+		i := bytes.IndexByte(w.partial.Bytes(), '\n')
+		if i < 0 {
+			break
+		}
+		line := string(w.partial.Next(i + 1))
+		w.emit(line[:i])
+	}
+	return len(p), nil
+}
+
+// Flush passes on a final line that has no trailing newline
+func (w *lineWriter) Flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.partial.Len() > 0 {
+		w.emit(w.partial.String())
+		w.partial.Reset()
+	}
+}
+
+// emit sends one line; progress bars redraw with \r, so only the last redraw is shown
+func (w *lineWriter) emit(line string) {
+	line = strings.TrimRight(line, "\r")
+	if i := strings.LastIndexByte(line, '\r'); i >= 0 {
+		line = line[i+1:]
+	}
+	w.fn(line)
+}
@@ -0,0 +1,120 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/zephel01/vibe-local-go/internal/session"
+)
+
+// PlanTool lets the model register a structured plan (steps with target files
+// and dependencies) that is tracked on the session
+type PlanTool struct {
+	session *session.Session
+}
+
+// NewPlanTool creates a new plan tool backed by sess
+func NewPlanTool(sess *session.Session) *PlanTool {
+	return &PlanTool{session: sess}
+}
+
+// Name returns the tool name
+func (t *PlanTool) Name() string {
+	return "plan"
+}
+
+// Schema returns the tool schema
+func (t *PlanTool) Schema() *FunctionSchema {
+	return &FunctionSchema{
+		Name: "plan",
+		Description: "Register a step-by-step plan before multi-step work, then follow it. " +
+			"Steps list the files they change and the steps they depend on; writing a step's files before its dependencies are done is refused. " +
+			"A step is marked done automatically once all its files are written; use action 'complete' for steps without files.",
+		Parameters: &ParameterSchema{
+			Type: "object",
+			Properties: map[string]*PropertyDef{
+				"action": {
+					Type:        "string",
+					Description: "'create' registers a new plan (replacing any existing one), 'complete' marks a step done, 'show' returns the current plan",
+					Enum:        []string{"create", "complete", "show"},
+				},
+				"steps": {
+					Type:        "array",
+					Description: "Steps for 'create', in the order you intend to do them",
+					Items: &PropertyDef{
+						Type: "object",
+						Properties: map[string]*PropertyDef{
+							"id": {
+								Type:        "string",
+								Description: "Short step id (defaults to the step number)",
+							},
+							"description": {
+								Type:        "string",
+								Description: "What the step does",
+							},
+							"files": {
+								Type:        "array",
+								Description: "Files the step creates or modifies",
+								Items:       &PropertyDef{Type: "string"},
+							},
+							"depends_on": {
+								Type:        "array",
+								Description: "IDs of steps that must be done first",
+								Items:       &PropertyDef{Type: "string"},
+							},
+						},
+						Required: []string{"description"},
+					},
+				},
+				"step_id": {
+					Type:        "string",
+					Description: "Step to mark done for 'complete'",
+				},
+			},
+			Required: []string{"action"},
+		},
+	}
+}
+
+// Execute creates, updates or shows the plan
+func (t *PlanTool) Execute(ctx context.Context, params json.RawMessage) (*Result, error) {
+	var args struct {
+		Action string             `json:"action"`
+		Steps  []session.PlanStep `json:"steps"`
+		StepID string             `json:"step_id"`
+	}
+
+	if err := json.Unmarshal(params, &args); err != nil {
+		return NewErrorResult(err), nil
+	}
+
+	switch args.Action {
+	case "create":
+		plan, err := session.NewPlan(args.Steps)
+		if err != nil {
+			return NewErrorResult(err), nil
+		}
+		t.session.SetPlan(plan)
+		return NewResult(plan.Render()), nil
+
+	case "complete":
+		if args.StepID == "" {
+			return NewErrorResult(fmt.Errorf("step_id is required for 'complete'")), nil
+		}
+		if err := t.session.CompletePlanStep(args.StepID); err != nil {
+			return NewErrorResult(err), nil
+		}
+		return NewResult(t.session.GetPlan().Render()), nil
+
+	case "show", "":
+		plan := t.session.GetPlan()
+		if plan == nil {
+			return NewResult("No plan registered. Use action 'create' to register one."), nil
+		}
+		return NewResult(plan.Render()), nil
+
+	default:
+		return NewErrorResult(fmt.Errorf("unknown action %q (use create, complete or show)", args.Action)), nil
+	}
+}
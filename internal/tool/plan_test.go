@@ -0,0 +1,41 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/zephel01/vibe-local-go/internal/session"
+)
+
+func TestPlanTool_CreateAndComplete(t *testing.T) {
+	sess := session.NewSession("test", "")
+	tool := NewPlanTool(sess)
+	ctx := context.Background()
+
+	params := json.RawMessage(`{"action": "create", "steps": [
+		{"id": "b", "description": "use it", "depends_on": ["a"]},
+		{"id": "a", "description": "build it", "files": ["lib.go"]}
+	]}`)
+	result, err := tool.Execute(ctx, params)
+	if err != nil || result.IsError {
+		t.Fatalf("create failed: %v %s", err, result.Error)
+	}
+	if !strings.Contains(result.Output, "[ ] a. build it (lib.go)") || strings.Index(result.Output, "a. build it") > strings.Index(result.Output, "b. use it") {
+		t.Errorf("rendered plan = %q, want a before b", result.Output)
+	}
+
+	result, _ = tool.Execute(ctx, json.RawMessage(`{"action": "complete", "step_id": "b"}`))
+	if !result.IsError {
+		t.Error("completing b before a should fail")
+	}
+
+	result, _ = tool.Execute(ctx, json.RawMessage(`{"action": "complete", "step_id": "a"}`))
+	if result.IsError {
+		t.Fatalf("complete a failed: %s", result.Error)
+	}
+	if plan := sess.GetPlan(); plan == nil || plan.DoneCount() != 1 {
+		t.Errorf("session plan = %+v, want one step done", plan)
+	}
+}
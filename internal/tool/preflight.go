@@ -0,0 +1,193 @@
+package tool
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+const (
+	// NetworkProbeTimeout bounds each network reachability check during preflight
+	NetworkProbeTimeout = 2 * time.Second
+	// InternetProbeHost is dialed (or its HTTPS proxy) to decide whether the internet is reachable
+	InternetProbeHost = "duckduckgo.com"
+)
+
+// Dependency is an external prerequisite a tool needs at runtime
+type Dependency struct {
+	Name       string       // Identifies the dependency; checks with the same name run once
+	Check      func() error // Returns an error describing why the dependency is missing
+	Background bool         // Checked without holding up Preflight (slow probes such as the network)
+}
+
+// DependentTool is implemented by tools that rely on external commands or the network
+type DependentTool interface {
+	Dependencies() []Dependency
+}
+
+// CommandDependency requires an executable on PATH
+func CommandDependency(command string) Dependency {
+	return Dependency{
+		Name: "command:" + command,
+		Check: func() error {
+			if _, err := exec.LookPath(command); err != nil {
+				return fmt.Errorf("%s not found in PATH", command)
+			}
+			return nil
+		},
+	}
+}
+
+// NetworkDependency requires a TCP connection to host:443, or to the proxy
+// HTTPS_PROXY / NO_PROXY select for it. It is checked in the background
+func NetworkDependency(host string) Dependency {
+	return Dependency{
+		Name:       "network:" + host,
+		Background: true,
+		Check: func() error {
+			addr, via := networkProbeAddr(host, http.ProxyFromEnvironment)
+			conn, err := net.DialTimeout("tcp", addr, NetworkProbeTimeout)
+			if err != nil {
+				if via != "" {
+					return fmt.Errorf("network unreachable (%s via proxy %s)", host, via)
+				}
+				return fmt.Errorf("network unreachable (%s)", host)
+			}
+			conn.Close()
+			return nil
+		},
+	}
+}
+
+// networkProbeAddr returns the address to dial for an HTTPS request to host
+// and the proxy proxyFunc picks for it ("" = direct)
+func networkProbeAddr(host string, proxyFunc func(*http.Request) (*url.URL, error)) (addr, via string) {
+	target := &url.URL{Scheme: "https", Host: host}
+	proxy, err := proxyFunc(&http.Request{URL: target})
+	if err != nil || proxy == nil {
+		return net.JoinHostPort(host, "443"), ""
+	}
+	addr = proxy.Host
+	if proxy.Port() == "" {
+		port := "80"
+		if proxy.Scheme == "https" {
+			port = "443"
+		}
+		addr = net.JoinHostPort(proxy.Hostname(), port)
+	}
+	return addr, proxy.Host
+}
+
+// Preflight checks the dependencies of every registered tool and marks tools
+// whose dependencies are missing as degraded. Checks run concurrently and each
+// distinct dependency is probed once. Background dependencies (the network) are
+// still being probed when Preflight returns; their tools are marked degraded
+// once the probe fails (see WaitPreflight). Returns tool name → reason for the
+// tools found degraded before returning.
+func (r *Registry) Preflight() map[string]string {
+	r.mu.Lock()
+	deps := make(map[string]Dependency)
+	background := make(map[string]Dependency)
+	toolDeps := make(map[string][]string)
+	for name, cfg := range r.tools {
+		cfg.Degraded = ""
+		dt, ok := cfg.Tool.(DependentTool)
+		if !ok {
+			continue
+		}
+		for _, dep := range dt.Dependencies() {
+			if dep.Background {
+				background[dep.Name] = dep
+			} else {
+				deps[dep.Name] = dep
+			}
+			toolDeps[name] = append(toolDeps[name], dep.Name)
+		}
+	}
+	r.schemaCache = nil
+	r.mu.Unlock()
+
+	if len(background) > 0 {
+		r.background.Add(1)
+		go func() {
+			defer r.background.Done()
+			r.markDegraded(toolDeps, probeDependencies(background))
+		}()
+	}
+
+	return r.markDegraded(toolDeps, probeDependencies(deps))
+}
+
+// WaitPreflight waits for the background dependency checks started by Preflight
+func (r *Registry) WaitPreflight() {
+	r.background.Wait()
+}
+
+// probeDependencies checks each dependency in parallel and returns the failures by name
+func probeDependencies(deps map[string]Dependency) map[string]error {
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	failures := make(map[string]error)
+	for name, dep := range deps {
+		wg.Add(1)
+		go func(name string, dep Dependency) {
+			defer wg.Done()
+			if err := dep.Check(); err != nil {
+				mu.Lock()
+				failures[name] = err
+				mu.Unlock()
+			}
+		}(name, dep)
+	}
+	wg.Wait()
+	return failures
+}
+
+// markDegraded marks the tools that depend on a failed dependency as degraded
+// and returns tool name → reason for the newly degraded tools
+func (r *Registry) markDegraded(toolDeps map[string][]string, failures map[string]error) map[string]string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	degraded := make(map[string]string)
+	for name, cfg := range r.tools {
+		if cfg.Degraded != "" {
+			continue
+		}
+		for _, depName := range toolDeps[name] {
+			if err, failed := failures[depName]; failed {
+				cfg.Degraded = err.Error()
+				degraded[name] = cfg.Degraded
+				break
+			}
+		}
+	}
+	if len(degraded) > 0 {
+		r.schemaCache = nil // Descriptions change for degraded tools
+	}
+	return degraded
+}
+
+// DegradedReason returns why a tool is degraded, or "" and false when it is available
+func (r *Registry) DegradedReason(name string) (string, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	cfg, ok := r.tools[name]
+	if !ok || cfg.Degraded == "" {
+		return "", false
+	}
+	return cfg.Degraded, true
+}
+
+// degradedSchema returns a copy of schema whose description warns the model
+// that the tool is currently unavailable
+func degradedSchema(schema *FunctionSchema, reason string) *FunctionSchema {
+	s := *schema
+	s.Description = fmt.Sprintf("[UNAVAILABLE: %s — do not call this tool] %s", reason, schema.Description)
+	return &s
+}
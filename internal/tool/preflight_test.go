@@ -0,0 +1,126 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// depTool is a minimal tool with configurable dependencies
+type depTool struct {
+	name string
+	deps []Dependency
+}
+
+func (d *depTool) Name() string { return d.name }
+
+func (d *depTool) Execute(ctx context.Context, params json.RawMessage) (*Result, error) {
+	return NewResult("ok"), nil
+}
+
+func (d *depTool) Schema() *FunctionSchema {
+	return &FunctionSchema{Name: d.name, Description: "does " + d.name}
+}
+
+func (d *depTool) Dependencies() []Dependency { return d.deps }
+
+func TestRegistry_PreflightMarksMissingDependencyDegraded(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register(&depTool{name: "runner", deps: []Dependency{CommandDependency("vibe-nonexistent-toolchain-xyz")}})
+	registry.Register(&depTool{name: "present", deps: []Dependency{{Name: "always", Check: func() error { return nil }}}})
+	registry.Register(NewGlobTool())
+
+	degraded := registry.Preflight()
+
+	if len(degraded) != 1 || !strings.Contains(degraded["runner"], "vibe-nonexistent-toolchain-xyz not found") {
+		t.Errorf("degraded = %v, want only runner", degraded)
+	}
+	if reason, ok := registry.DegradedReason("runner"); !ok || reason == "" {
+		t.Error("expected runner to be degraded")
+	}
+	for _, name := range []string{"present", "glob"} {
+		if _, ok := registry.DegradedReason(name); ok {
+			t.Errorf("expected %s to be available", name)
+		}
+	}
+
+	// The model sees the degraded state in the tool description
+	for _, schema := range registry.GetSchemas() {
+		switch schema.Name {
+		case "runner":
+			if !strings.HasPrefix(schema.Description, "[UNAVAILABLE: ") || !strings.HasSuffix(schema.Description, "does runner") {
+				t.Errorf("runner description = %q, want unavailable prefix", schema.Description)
+			}
+		case "present":
+			if schema.Description != "does present" {
+				t.Errorf("present description = %q, want unchanged", schema.Description)
+			}
+		}
+	}
+}
+
+func TestRegistry_PreflightProbesSharedDependencyOnce(t *testing.T) {
+	calls := 0
+	shared := Dependency{Name: "network:test", Check: func() error {
+		calls++
+		return fmt.Errorf("network unreachable (test)")
+	}}
+
+	registry := NewRegistry()
+	registry.Register(&depTool{name: "fetch", deps: []Dependency{shared}})
+	registry.Register(&depTool{name: "search", deps: []Dependency{shared}})
+
+	degraded := registry.Preflight()
+	if calls != 1 {
+		t.Errorf("shared dependency checked %d times, want 1", calls)
+	}
+	if len(degraded) != 2 {
+		t.Errorf("degraded = %v, want fetch and search", degraded)
+	}
+}
+
+func TestRegistry_PreflightChecksBackgroundDependenciesLater(t *testing.T) {
+	release := make(chan struct{})
+	slow := Dependency{Name: "network:test", Background: true, Check: func() error {
+		<-release
+		return fmt.Errorf("network unreachable (test)")
+	}}
+
+	registry := NewRegistry()
+	registry.Register(&depTool{name: "fetch", deps: []Dependency{slow}})
+
+	// Preflight returns without waiting for the slow probe
+	if degraded := registry.Preflight(); len(degraded) != 0 {
+		t.Errorf("degraded = %v, want none before the probe finishes", degraded)
+	}
+	if _, ok := registry.DegradedReason("fetch"); ok {
+		t.Error("fetch should be available until the probe fails")
+	}
+
+	close(release)
+	registry.WaitPreflight()
+	if reason, ok := registry.DegradedReason("fetch"); !ok || !strings.Contains(reason, "network unreachable") {
+		t.Errorf("DegradedReason(fetch) = %q, %v; want degraded after the probe", reason, ok)
+	}
+}
+
+func TestNetworkProbeAddr_UsesProxy(t *testing.T) {
+	direct := func(*http.Request) (*url.URL, error) { return nil, nil }
+	if addr, via := networkProbeAddr("example.com", direct); addr != "example.com:443" || via != "" {
+		t.Errorf("without a proxy: %q via %q", addr, via)
+	}
+
+	proxy, _ := url.Parse("http://proxy.internal:3128")
+	if addr, via := networkProbeAddr("example.com", http.ProxyURL(proxy)); addr != "proxy.internal:3128" || via != "proxy.internal:3128" {
+		t.Errorf("with a proxy: %q via %q", addr, via)
+	}
+
+	proxy, _ = url.Parse("http://proxy.internal")
+	if addr, _ := networkProbeAddr("example.com", http.ProxyURL(proxy)); addr != "proxy.internal:80" {
+		t.Errorf("proxy without a port: %q, want the scheme's default port", addr)
+	}
+}
@@ -0,0 +1,46 @@
+package tool
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Previewer is implemented by tools that can show their change before it is applied
+type Previewer interface {
+	// Preview returns a diff of what Execute would change, without writing anything
+	Preview(params json.RawMessage) (string, error)
+}
+
+// fileChangeDiff returns the diff between a file's current content and newContent.
+// A file that doesn't exist yet is shown entirely as additions.
+func fileChangeDiff(filename string, oldContent string, exists bool, newContent string) string {
+	if !exists {
+		return newFileDiff(filename, newContent)
+	}
+	if oldContent == newContent {
+		return "(no changes)\n"
+	}
+	return generateUnifiedDiff(filename, oldContent, newContent)
+}
+
+// newFileDiff renders the content of a file being created as a diff of additions
+func newFileDiff(filename string, content string) string {
+	lines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	if content == "" {
+		lines = nil
+	}
+
+	var diff strings.Builder
+	diff.WriteString("--- /dev/null\n")
+	diff.WriteString(fmt.Sprintf("+++ %s\n", filename))
+	diff.WriteString(fmt.Sprintf("@@ -0,0 +1,%d @@\n", len(lines)))
+	for i, line := range lines {
+		if i >= MaxDiffLines {
+			diff.WriteString(fmt.Sprintf("... (truncated, showing first %d of %d lines)\n", MaxDiffLines, len(lines)))
+			break
+		}
+		diff.WriteString("+" + line + "\n")
+	}
+	return diff.String()
+}
@@ -22,6 +22,8 @@ type Tool interface {
 type Registry struct {
 	tools      map[string]*ToolConfig
 	schemaCache []*FunctionSchema
+	offline    bool // Network tools are replaced by offline stand-ins (see SetOffline)
+	background sync.WaitGroup // Background dependency checks started by Preflight
 	mu         sync.RWMutex
 }
 
@@ -45,10 +47,21 @@ func (r *Registry) RegisterWithOptions(name string, tool Tool, opts ...ToolOptio
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
+	applyOffline(cfg, r.offline)
 	r.tools[name] = cfg
 	r.schemaCache = nil // Invalidate cache
 }
 
+// Unregister removes a tool by name (no-op if it isn't registered)
+func (r *Registry) Unregister(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.tools[name]; ok {
+		delete(r.tools, name)
+		r.schemaCache = nil // Invalidate cache
+	}
+}
+
 // Get retrieves a tool config by name
 func (r *Registry) Get(name string) (*ToolConfig, bool) {
 	r.mu.RLock()
@@ -70,6 +83,17 @@ func (r *Registry) GetTool(name string) (Tool, bool) {
 	return cfg.Tool, true
 }
 
+// GetWriteTool retrieves the registered write_file tool as its concrete type
+// (used by slash commands that need access to the undo stack)
+func (r *Registry) GetWriteTool() (*WriteTool, bool) {
+	t, ok := r.GetTool("write_file")
+	if !ok {
+		return nil, false
+	}
+	wt, ok := t.(*WriteTool)
+	return wt, ok
+}
+
 // GetMetadata retrieves tool metadata by name
 func (r *Registry) GetMetadata(name string) (*ToolMetadata, bool) {
 	r.mu.RLock()
@@ -107,7 +131,14 @@ func (r *Registry) GetSchemas() []*FunctionSchema {
 	// Build schema cache
 	schemas := make([]*FunctionSchema, 0, len(r.tools))
 	for _, cfg := range r.tools {
-		schemas = append(schemas, cfg.Tool.Schema())
+		if _, off := cfg.Tool.(*offlineTool); off {
+			continue // Offline mode: don't offer network tools to the model
+		}
+		schema := cfg.Tool.Schema()
+		if cfg.Degraded != "" {
+			schema = degradedSchema(schema, cfg.Degraded)
+		}
+		schemas = append(schemas, schema)
 	}
 
 	// Cache the result
@@ -136,6 +167,9 @@ type Result struct {
 
 	// Error contains the error message if IsError is true
 	Error string `json:"error,omitempty"`
+
+	// ChangedPaths lists the files the tool changed on disk (used to trigger auto-test)
+	ChangedPaths []string `json:"changed_paths,omitempty"`
 }
 
 // FunctionSchema represents an OpenAI function calling schema
@@ -171,6 +205,12 @@ func NewResult(output string) *Result {
 	}
 }
 
+// WithChangedPaths records the files the tool changed and returns the result
+func (r *Result) WithChangedPaths(paths ...string) *Result {
+	r.ChangedPaths = paths
+	return r
+}
+
 // NewErrorResult creates a new error result
 func NewErrorResult(err error) *Result {
 	return &Result{
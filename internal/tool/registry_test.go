@@ -107,6 +107,30 @@ func TestRegistry_Get(t *testing.T) {
 	}
 }
 
+func TestRegistry_GetWriteTool(t *testing.T) {
+	reg := NewRegistry()
+
+	if _, ok := reg.GetWriteTool(); ok {
+		t.Error("expected no write tool in empty registry")
+	}
+
+	// A non-WriteTool registered under the same name should not match
+	reg.Register(&mockTool{name: "write_file"})
+	if _, ok := reg.GetWriteTool(); ok {
+		t.Error("expected mock tool not to be returned as *WriteTool")
+	}
+
+	writeTool := NewWriteTool()
+	reg.Register(writeTool)
+	wt, ok := reg.GetWriteTool()
+	if !ok {
+		t.Fatal("expected write tool to be found")
+	}
+	if wt != writeTool {
+		t.Error("expected the registered write tool instance")
+	}
+}
+
 func TestRegistry_Names(t *testing.T) {
 	reg := NewRegistry()
 
@@ -195,6 +219,25 @@ func TestRegistry_GetSchemas_Caching(t *testing.T) {
 	}
 }
 
+func TestRegistry_Unregister(t *testing.T) {
+	reg := NewRegistry()
+	reg.Register(&mockTool{name: "tool1"})
+	reg.Register(&mockTool{name: "tool2"})
+	if len(reg.GetSchemas()) != 2 {
+		t.Fatal("expected 2 schemas after registration")
+	}
+
+	reg.Unregister("tool1")
+	reg.Unregister("missing")
+
+	if _, ok := reg.GetTool("tool1"); ok {
+		t.Error("expected tool1 to be removed")
+	}
+	if reg.Count() != 1 || len(reg.GetSchemas()) != 1 {
+		t.Errorf("expected 1 tool and 1 schema, got %d and %d", reg.Count(), len(reg.GetSchemas()))
+	}
+}
+
 func TestRegistry_Count(t *testing.T) {
 	reg := NewRegistry()
 
@@ -0,0 +1,113 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/zephel01/vibe-local-go/internal/sandbox"
+)
+
+func newTestSandbox(t *testing.T) (*sandbox.Manager, string) {
+	t.Helper()
+	root := t.TempDir()
+	mgr, err := sandbox.NewManager(root, true)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	return mgr, root
+}
+
+func TestWriteTool_SandboxStagesUntilCommit(t *testing.T) {
+	mgr, root := newTestSandbox(t)
+	writeTool := NewWriteTool()
+	writeTool.SetSandbox(mgr)
+
+	target := filepath.Join(root, "main.py")
+	params, _ := json.Marshal(map[string]string{"path": target, "content": "print('hi')\n"})
+	result, err := writeTool.Execute(context.Background(), params)
+	if err != nil || result.IsError {
+		t.Fatalf("write failed: %v %s", err, result.Error)
+	}
+	if !strings.Contains(result.Output, "[sandbox]") {
+		t.Errorf("expected staged output, got %q", result.Output)
+	}
+
+	if _, err := os.Stat(target); !os.IsNotExist(err) {
+		t.Fatalf("staged file should not exist on disk before commit, stat err: %v", err)
+	}
+	if mgr.StagedCount() != 1 {
+		t.Fatalf("StagedCount = %d, want 1", mgr.StagedCount())
+	}
+	if len(writeTool.GetUndoStack()) != 0 {
+		t.Error("staged writes should not be pushed on the undo stack")
+	}
+
+	if _, err := mgr.Commit(); err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+	data, err := os.ReadFile(target)
+	if err != nil {
+		t.Fatalf("file should exist after commit: %v", err)
+	}
+	if string(data) != "print('hi')\n" {
+		t.Errorf("content = %q", string(data))
+	}
+}
+
+func TestEditTool_SandboxEditsStagedContent(t *testing.T) {
+	mgr, root := newTestSandbox(t)
+	writeTool := NewWriteTool()
+	writeTool.SetSandbox(mgr)
+	editTool := NewEditTool()
+	editTool.SetSandbox(mgr)
+
+	target := filepath.Join(root, "app.go")
+	if err := os.WriteFile(target, []byte("one\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	params, _ := json.Marshal(map[string]string{"path": target, "content": "one\ntwo\n"})
+	if result, _ := writeTool.Execute(context.Background(), params); result.IsError {
+		t.Fatalf("write failed: %s", result.Error)
+	}
+
+	// The edit must see the staged "two", which is not on disk yet
+	params, _ = json.Marshal(map[string]string{"path": target, "old_string": "two", "new_string": "three"})
+	result, _ := editTool.Execute(context.Background(), params)
+	if result.IsError {
+		t.Fatalf("edit failed: %s", result.Error)
+	}
+
+	if staged, ok := mgr.ReadStaged(target); !ok || string(staged) != "one\nthree\n" {
+		t.Errorf("staged content = %q, want %q", string(staged), "one\nthree\n")
+	}
+	data, _ := os.ReadFile(target)
+	if string(data) != "one\n" {
+		t.Errorf("disk content changed before commit: %q", string(data))
+	}
+
+	if err := mgr.Discard(); err != nil {
+		t.Fatalf("Discard: %v", err)
+	}
+	data, _ = os.ReadFile(target)
+	if string(data) != "one\n" {
+		t.Errorf("disk content after discard = %q, want original", string(data))
+	}
+
+	// Staged content is only applied on commit
+	params, _ = json.Marshal(map[string]string{"path": target, "old_string": "one", "new_string": "uno"})
+	if result, _ := editTool.Execute(context.Background(), params); result.IsError {
+		t.Fatalf("edit failed: %s", result.Error)
+	}
+	if _, err := mgr.Commit(); err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+	data, _ = os.ReadFile(target)
+	if string(data) != "uno\n" {
+		t.Errorf("content after commit = %q, want %q", string(data), "uno\n")
+	}
+}
@@ -0,0 +1,400 @@
+package tool
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/zephel01/vibe-local-go/internal/security"
+)
+
+const (
+	// MaxSemanticFileSize is the largest file semantic_search embeds
+	MaxSemanticFileSize = 256 * 1024
+	// MaxSemanticFiles caps how many files one search considers
+	MaxSemanticFiles = 2000
+	// SemanticChunkLength is how much of each file (in bytes) is embedded
+	SemanticChunkLength = 4000
+	// DefaultSemanticResults is the default number of files returned
+	DefaultSemanticResults = 10
+	// MaxSemanticCacheEntries caps the embeddings kept in the cache file;
+	// entries not used in this session are dropped first
+	MaxSemanticCacheEntries = 20000
+	// semanticBatchSize is the number of texts sent per embedding request
+	semanticBatchSize = 32
+)
+
+// EmbedFunc returns one embedding vector per input text
+type EmbedFunc func(ctx context.Context, texts []string) ([][]float32, error)
+
+// SemanticSearchTool ranks project files by the cosine similarity between
+// the embedding of a query and the embeddings of the files' contents
+type SemanticSearchTool struct {
+	mu         sync.Mutex
+	embed      EmbedFunc
+	model      string // Embedding model; keys the cache so vectors of different models never mix
+	cacheDir   string // Where embeddings are persisted ("" = memory only)
+	maxDepth   int    // Directory depth limit (0 = DefaultMaxWalkDepth)
+	vibeIgnore *IgnoreMatcher
+	cache      map[string][]float32
+	used       map[string]bool // Cache keys looked up in this session (kept when pruning)
+	loaded     bool            // Whether cache has been read from cacheDir
+}
+
+// semanticFile is a candidate file and the text embedded for it
+type semanticFile struct {
+	path string
+	text string
+	key  string
+}
+
+// semanticMatch is a ranked search result
+type semanticMatch struct {
+	path  string
+	score float64
+}
+
+// NewSemanticSearchTool creates a new semantic search tool.
+// It reports an error until an embedder is set with SetEmbedder
+func NewSemanticSearchTool() *SemanticSearchTool {
+	return &SemanticSearchTool{cache: make(map[string][]float32), used: make(map[string]bool)}
+}
+
+// SetEmbedder sets the function that embeds texts and the name of the model it uses
+func (t *SemanticSearchTool) SetEmbedder(model string, fn EmbedFunc) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.embed = fn
+	t.model = model
+}
+
+// SetCacheDir sets the directory where file embeddings are cached ("" = memory only)
+func (t *SemanticSearchTool) SetCacheDir(dir string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.cacheDir = dir
+	t.loaded = false
+}
+
+// SetMaxDepth sets the directory depth limit (<= 0 = default)
+func (t *SemanticSearchTool) SetMaxDepth(depth int) {
+	t.maxDepth = depth
+}
+
+// SetVibeIgnore sets the .vibeignore matcher; matching paths are never embedded (nil = none)
+func (t *SemanticSearchTool) SetVibeIgnore(m *IgnoreMatcher) {
+	t.vibeIgnore = m
+}
+
+// Name returns the tool name
+func (t *SemanticSearchTool) Name() string {
+	return "semantic_search"
+}
+
+// Schema returns the tool schema
+func (t *SemanticSearchTool) Schema() *FunctionSchema {
+	return &FunctionSchema{
+		Name:        "semantic_search",
+		Description: "Find the project files most related to a natural-language description (e.g. \"where are HTTP retries handled\"), ranked by embedding similarity. Use grep instead when you know the exact text to look for",
+		Parameters: &ParameterSchema{
+			Type: "object",
+			Properties: map[string]*PropertyDef{
+				"query": {
+					Type:        "string",
+					Description: "What to look for, in natural language",
+				},
+				"path": {
+					Type:        "string",
+					Description: "Directory to search (default: current directory)",
+				},
+				"max_results": {
+					Type:        "integer",
+					Description: "Maximum number of files to return",
+					Default:     DefaultSemanticResults,
+				},
+			},
+			Required: []string{"query"},
+		},
+	}
+}
+
+// Execute embeds the query and the project files and returns the closest files
+func (t *SemanticSearchTool) Execute(ctx context.Context, params json.RawMessage) (*Result, error) {
+	var args struct {
+		Query      string `json:"query"`
+		Path       string `json:"path"`
+		MaxResults int    `json:"max_results"`
+	}
+
+	if err := json.Unmarshal(params, &args); err != nil {
+		return NewErrorResult(err), nil
+	}
+
+	args.Query = strings.TrimSpace(args.Query)
+	if args.Query == "" {
+		return NewErrorResult(fmt.Errorf("query cannot be empty")), nil
+	}
+	args.Path = security.NormalizePath(args.Path)
+	if args.Path == "" {
+		args.Path = "."
+	}
+	if args.MaxResults <= 0 {
+		args.MaxResults = DefaultSemanticResults
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.embed == nil {
+		return NewErrorResult(fmt.Errorf("semantic search is unavailable: no embedding provider is configured")), nil
+	}
+
+	queryVecs, err := t.embed(ctx, []string{args.Query})
+	if err != nil {
+		return NewErrorResult(fmt.Errorf("failed to embed query: %w", err)), nil
+	}
+	if len(queryVecs) != 1 || len(queryVecs[0]) == 0 {
+		return NewErrorResult(fmt.Errorf("failed to embed query: no vector returned")), nil
+	}
+	query := queryVecs[0]
+
+	files, truncated, stats, err := t.collectFiles(args.Path)
+	if err != nil {
+		return NewErrorResult(err), nil
+	}
+
+	t.loadCache()
+	embedded, err := t.embedFiles(ctx, files, len(query))
+	if err != nil {
+		return NewErrorResult(fmt.Errorf("failed to embed files: %w", err)), nil
+	}
+
+	matches := make([]semanticMatch, 0, len(files))
+	for _, f := range files {
+		matches = append(matches, semanticMatch{path: f.path, score: cosineSimilarity(query, t.cache[f.key])})
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].score > matches[j].score })
+	if len(matches) > args.MaxResults {
+		matches = matches[:args.MaxResults]
+	}
+
+	var output strings.Builder
+	output.WriteString(fmt.Sprintf("Top %d of %d files for %q (cosine similarity):\n\n", len(matches), len(files), args.Query))
+	for _, m := range matches {
+		output.WriteString(fmt.Sprintf("%.3f  %s\n", m.score, m.path))
+	}
+	output.WriteString(fmt.Sprintf("\n(%d embedded, %d from cache)\n", embedded, len(files)-embedded))
+	if truncated {
+		output.WriteString(fmt.Sprintf("Note: only the first %d files were considered; narrow the search with path\n", MaxSemanticFiles))
+	}
+	if embedded > 0 {
+		if err := t.saveCache(); err != nil {
+			output.WriteString(fmt.Sprintf("Note: failed to save the embedding cache: %v\n", err))
+		}
+	}
+	output.WriteString(stats.notice(t.depthLimit()))
+
+	return NewResult(output.String()), nil
+}
+
+// collectFiles walks root for text files to rank, honoring .gitignore and .vibeignore
+func (t *SemanticSearchTool) collectFiles(root string) ([]semanticFile, bool, *walkStats, error) {
+	ignore := LoadIgnore(root)
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return nil, false, nil, err
+	}
+	if err := checkVibeIgnore(t.vibeIgnore, absRoot); err != nil {
+		return nil, false, nil, err
+	}
+	var files []semanticFile
+	truncated := false
+
+	stats, err := walkTree(root, t.maxDepth, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return nil
+		}
+		vibeIgnored := checkVibeIgnore(t.vibeIgnore, filepath.Join(absRoot, rel)) != nil
+		if d.IsDir() {
+			if isSkipDir(path) || ignore.Match(rel, true) || (rel != "." && vibeIgnored) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if ignore.Match(rel, false) || vibeIgnored {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil || info.Size() == 0 || info.Size() > MaxSemanticFileSize {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil || !isPlainText(data) {
+			return nil
+		}
+
+		if len(files) >= MaxSemanticFiles {
+			truncated = true
+			return filepath.SkipAll
+		}
+		text := truncateUTF8(string(data), SemanticChunkLength)
+		files = append(files, semanticFile{path: path, text: text, key: t.cacheKey(text)})
+		return nil
+	})
+	if err != nil {
+		return nil, false, stats, err
+	}
+	return files, truncated, stats, nil
+}
+
+// embedFiles embeds the files missing from the cache (or cached with a
+// different dimension than the query) and returns how many were embedded
+func (t *SemanticSearchTool) embedFiles(ctx context.Context, files []semanticFile, dim int) (int, error) {
+	var pending []semanticFile
+	seen := make(map[string]bool)
+	for _, f := range files {
+		t.used[f.key] = true
+		if vec, ok := t.cache[f.key]; (ok && len(vec) == dim) || seen[f.key] {
+			continue
+		}
+		seen[f.key] = true
+		pending = append(pending, f)
+	}
+
+	for start := 0; start < len(pending); start += semanticBatchSize {
+		batch := pending[start:min(start+semanticBatchSize, len(pending))]
+		texts := make([]string, len(batch))
+		for i, f := range batch {
+			texts[i] = f.text
+		}
+		vectors, err := t.embed(ctx, texts)
+		if err != nil {
+			return start, err
+		}
+		if len(vectors) != len(batch) {
+			return start, fmt.Errorf("got %d vectors for %d files", len(vectors), len(batch))
+		}
+		for i, f := range batch {
+			t.cache[f.key] = vectors[i]
+		}
+	}
+	return len(pending), nil
+}
+
+// cacheKey hashes the embedded text together with the model name
+func (t *SemanticSearchTool) cacheKey(text string) string {
+	sum := sha256.Sum256([]byte(t.model + "\x00" + text))
+	return hex.EncodeToString(sum[:])
+}
+
+// cachePath returns the cache file ("" = memory only)
+func (t *SemanticSearchTool) cachePath() string {
+	if t.cacheDir == "" {
+		return ""
+	}
+	return filepath.Join(t.cacheDir, "embeddings.json")
+}
+
+// loadCache merges the cache file into memory once (a missing or corrupt file is ignored)
+func (t *SemanticSearchTool) loadCache() {
+	if t.loaded {
+		return
+	}
+	t.loaded = true
+	path := t.cachePath()
+	if path == "" {
+		return
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+	var stored map[string][]float32
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return
+	}
+	for key, vec := range stored {
+		if _, ok := t.cache[key]; !ok {
+			t.cache[key] = vec
+		}
+	}
+}
+
+// saveCache writes the in-memory cache to the cache file atomically
+func (t *SemanticSearchTool) saveCache() error {
+	path := t.cachePath()
+	if path == "" {
+		return nil
+	}
+	t.pruneCache(MaxSemanticCacheEntries)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	data, err := json.Marshal(t.cache)
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// pruneCache drops cached embeddings until at most limit remain,
+// starting with those not used in this session
+func (t *SemanticSearchTool) pruneCache(limit int) {
+	for key := range t.cache {
+		if len(t.cache) <= limit {
+			return
+		}
+		if !t.used[key] {
+			delete(t.cache, key)
+		}
+	}
+	for key := range t.cache {
+		if len(t.cache) <= limit {
+			return
+		}
+		delete(t.cache, key)
+	}
+}
+
+// depthLimit returns the effective directory depth limit
+func (t *SemanticSearchTool) depthLimit() int {
+	if t.maxDepth > 0 {
+		return t.maxDepth
+	}
+	return DefaultMaxWalkDepth
+}
+
+// cosineSimilarity returns the cosine of the angle between a and b
+// (0 when either is empty, all zeros, or their lengths differ)
+func cosineSimilarity(a, b []float32) float64 {
+	if len(a) == 0 || len(a) != len(b) {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
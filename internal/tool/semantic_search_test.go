@@ -0,0 +1,205 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// keywordEmbedder embeds a text as keyword counts, so files that share
+// words with the query rank higher. It records every text it embeds
+type keywordEmbedder struct {
+	keywords []string
+	embedded []string
+}
+
+func (e *keywordEmbedder) embed(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		e.embedded = append(e.embedded, text)
+		vec := make([]float32, len(e.keywords))
+		for j, kw := range e.keywords {
+			vec[j] = float32(strings.Count(strings.ToLower(text), kw))
+		}
+		vectors[i] = vec
+	}
+	return vectors, nil
+}
+
+func writeSemanticFixture(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	files := map[string]string{
+		"retry.go":       "func retry() { backoff backoff retry }",
+		"server.go":      "func serve() { http listener }",
+		"notes.txt":      "shopping list",
+		"image.bin":      "\x00\x01\x02",
+		"ignored/gen.go": "retry retry retry",
+		".gitignore":     "ignored/\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func runSemanticSearch(t *testing.T, search *SemanticSearchTool, query, path string) string {
+	t.Helper()
+	params, _ := json.Marshal(map[string]interface{}{"query": query, "path": path, "max_results": 2})
+	result, err := search.Execute(context.Background(), params)
+	if err != nil || result.IsError {
+		t.Fatalf("Execute failed: %v %s", err, result.Error)
+	}
+	return result.Output
+}
+
+func TestSemanticSearch_RanksBySimilarity(t *testing.T) {
+	dir := writeSemanticFixture(t)
+	embedder := &keywordEmbedder{keywords: []string{"retry", "backoff", "http"}}
+	search := NewSemanticSearchTool()
+	search.SetEmbedder("test-model", embedder.embed)
+
+	output := runSemanticSearch(t, search, "retry with backoff", dir)
+	lines := strings.Split(output, "\n")
+	if len(lines) < 3 || !strings.HasSuffix(lines[2], "retry.go") {
+		t.Fatalf("retry.go should rank first:\n%s", output)
+	}
+	if strings.Contains(output, "gen.go") || strings.Contains(output, "image.bin") {
+		t.Errorf("ignored and binary files should be skipped:\n%s", output)
+	}
+	if !strings.Contains(output, "Top 2 of 4 files") {
+		t.Errorf("expected 4 candidate files (.gitignore, notes, retry, server):\n%s", output)
+	}
+}
+
+func TestSemanticSearch_CachesEmbeddingsOnDisk(t *testing.T) {
+	dir := writeSemanticFixture(t)
+	cacheDir := t.TempDir()
+
+	first := &keywordEmbedder{keywords: []string{"retry", "http"}}
+	search := NewSemanticSearchTool()
+	search.SetEmbedder("test-model", first.embed)
+	search.SetCacheDir(cacheDir)
+	runSemanticSearch(t, search, "retry", dir)
+	if len(first.embedded) != 5 { // query + 4 files
+		t.Fatalf("first search embedded %d texts, want 5", len(first.embedded))
+	}
+
+	// A new tool instance reads the cache from disk and only embeds the query and changed files
+	os.WriteFile(filepath.Join(dir, "server.go"), []byte("func serve() { http http }"), 0644)
+	second := &keywordEmbedder{keywords: []string{"retry", "http"}}
+	search = NewSemanticSearchTool()
+	search.SetEmbedder("test-model", second.embed)
+	search.SetCacheDir(cacheDir)
+	output := runSemanticSearch(t, search, "http", dir)
+	if len(second.embedded) != 2 || second.embedded[1] != "func serve() { http http }" {
+		t.Errorf("second search embedded %q, want the query and the changed file", second.embedded)
+	}
+	if !strings.Contains(output, "(1 embedded, 3 from cache)") {
+		t.Errorf("output should report cache use:\n%s", output)
+	}
+
+	// A different model must not reuse the cached vectors
+	third := &keywordEmbedder{keywords: []string{"retry", "http"}}
+	search.SetEmbedder("other-model", third.embed)
+	runSemanticSearch(t, search, "http", dir)
+	if len(third.embedded) != 5 {
+		t.Errorf("search with another model embedded %d texts, want 5", len(third.embedded))
+	}
+}
+
+func TestSemanticSearch_SkipsVibeIgnored(t *testing.T) {
+	root, m := createVibeIgnoreRepo(t)
+	embedder := &keywordEmbedder{keywords: []string{"needle"}}
+	search := NewSemanticSearchTool()
+	search.SetEmbedder("test-model", embedder.embed)
+	search.SetVibeIgnore(m)
+
+	output := runSemanticSearch(t, search, "needle", root)
+	if strings.Contains(output, "token.txt") || strings.Contains(output, "server.pem\n") {
+		t.Errorf("vibe-ignored files should not be ranked:\n%s", output)
+	}
+	// query + .vibeignore, main.go and server.pem.md
+	if len(embedder.embedded) != 4 || !strings.Contains(output, "of 3 files") {
+		t.Errorf("vibe-ignored files should not be embedded: %q\n%s", embedder.embedded, output)
+	}
+
+	params, _ := json.Marshal(map[string]interface{}{"query": "needle", "path": filepath.Join(root, "secrets")})
+	result, _ := search.Execute(context.Background(), params)
+	expectVibeIgnored(t, result)
+}
+
+func TestSemanticSearch_PruneCache(t *testing.T) {
+	search := NewSemanticSearchTool()
+	for _, key := range []string{"a", "b", "c", "d"} {
+		search.cache[key] = []float32{1}
+	}
+	search.used["a"] = true
+	search.used["c"] = true
+
+	search.pruneCache(2)
+	if len(search.cache) != 2 || search.cache["a"] == nil || search.cache["c"] == nil {
+		t.Errorf("entries used in this session should be kept: %v", search.cache)
+	}
+
+	search.pruneCache(1)
+	if len(search.cache) != 1 {
+		t.Errorf("cache should shrink to the limit: %v", search.cache)
+	}
+}
+
+func TestSemanticSearch_Errors(t *testing.T) {
+	search := NewSemanticSearchTool()
+	result, _ := search.Execute(context.Background(), json.RawMessage(`{"query": "x"}`))
+	if !result.IsError || !strings.Contains(result.Error, "no embedding provider") {
+		t.Errorf("without an embedder: %+v", result)
+	}
+
+	search.SetEmbedder("m", func(ctx context.Context, texts []string) ([][]float32, error) {
+		return nil, errors.New("embeddings are not supported by this provider")
+	})
+	result, _ = search.Execute(context.Background(), json.RawMessage(`{"query": "x"}`))
+	if !result.IsError || !strings.Contains(result.Error, "not supported") {
+		t.Errorf("unsupported provider: %+v", result)
+	}
+
+	result, _ = search.Execute(context.Background(), json.RawMessage(`{"query": "  "}`))
+	if !result.IsError {
+		t.Error("empty query should be an error")
+	}
+}
+
+func TestCosineSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b []float32
+		want float64
+	}{
+		{[]float32{1, 0}, []float32{1, 0}, 1},
+		{[]float32{1, 0}, []float32{0, 1}, 0},
+		{[]float32{1, 1}, []float32{-1, -1}, -1},
+		{[]float32{1, 0}, []float32{1, 0, 0}, 0},
+		{[]float32{0, 0}, []float32{1, 0}, 0},
+	}
+	for _, tt := range tests {
+		if got := cosineSimilarity(tt.a, tt.b); got < tt.want-1e-9 || got > tt.want+1e-9 {
+			t.Errorf("cosineSimilarity(%v, %v) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestTruncateUTF8(t *testing.T) {
+	if got := truncateUTF8("あいう", 4); got != "あ" {
+		t.Errorf("truncateUTF8 = %q, want a whole character", got)
+	}
+	if got := truncateUTF8("abc", 10); got != "abc" {
+		t.Errorf("truncateUTF8 = %q", got)
+	}
+}
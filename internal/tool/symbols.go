@@ -0,0 +1,485 @@
+package tool
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zephel01/vibe-local-go/internal/security"
+)
+
+const (
+	// MaxSymbolFileSize is the maximum file size indexed by the symbols tool
+	MaxSymbolFileSize = 2 * 1024 * 1024 // 2MB
+	// MaxSymbolResults is the maximum number of definitions returned per query
+	MaxSymbolResults = 100
+)
+
+// Symbol is a single definition found in a source file
+type Symbol struct {
+	Name string
+	Kind string // "func", "method", "struct", "interface", "type", "class", ...
+	File string // path relative to the index root
+	Line int
+}
+
+// symbolPattern matches a definition line. Kind is used when kindGroup is 0.
+type symbolPattern struct {
+	re        *regexp.Regexp
+	nameGroup int
+	kindGroup int
+	kind      string
+}
+
+var (
+	pythonSymbolPatterns = []symbolPattern{
+		{re: regexp.MustCompile(`^\s*class\s+([A-Za-z_]\w*)`), nameGroup: 1, kind: "class"},
+		{re: regexp.MustCompile(`^\s*(?:async\s+)?def\s+([A-Za-z_]\w*)`), nameGroup: 1, kind: "func"},
+	}
+
+	jsSymbolPatterns = []symbolPattern{
+		{re: regexp.MustCompile(`^\s*(?:export\s+)?(?:default\s+)?(?:abstract\s+)?class\s+([A-Za-z_$][\w$]*)`), nameGroup: 1, kind: "class"},
+		{re: regexp.MustCompile(`^\s*(?:export\s+)?(?:default\s+)?(?:async\s+)?function\s*\*?\s*([A-Za-z_$][\w$]*)`), nameGroup: 1, kind: "func"},
+		{re: regexp.MustCompile(`^\s*(?:export\s+)?(interface|type|enum)\s+([A-Za-z_$][\w$]*)`), nameGroup: 2, kindGroup: 1},
+		{re: regexp.MustCompile(`^\s*(?:export\s+)?(?:const|let|var)\s+([A-Za-z_$][\w$]*)\s*=\s*(?:async\s+)?(?:\([^)]*\)|[A-Za-z_$][\w$]*)\s*=>`), nameGroup: 1, kind: "func"},
+	}
+
+	rustSymbolPatterns = []symbolPattern{
+		{re: regexp.MustCompile(`^\s*(?:pub(?:\([^)]*\))?\s+)?(?:const\s+)?(?:async\s+)?(?:unsafe\s+)?fn\s+([A-Za-z_]\w*)`), nameGroup: 1, kind: "func"},
+		{re: regexp.MustCompile(`^\s*(?:pub(?:\([^)]*\))?\s+)?(struct|enum|trait|type)\s+([A-Za-z_]\w*)`), nameGroup: 2, kindGroup: 1},
+	}
+
+	javaSymbolPatterns = []symbolPattern{
+		{re: regexp.MustCompile(`^\s*(?:(?:public|private|protected|abstract|final|static|sealed)\s+)*(class|interface|enum|record)\s+([A-Za-z_]\w*)`), nameGroup: 2, kindGroup: 1},
+	}
+
+	rubySymbolPatterns = []symbolPattern{
+		{re: regexp.MustCompile(`^\s*(class|module)\s+([A-Z]\w*)`), nameGroup: 2, kindGroup: 1},
+		{re: regexp.MustCompile(`^\s*def\s+(?:self\.)?([A-Za-z_]\w*[?!=]?)`), nameGroup: 1, kind: "func"},
+	}
+
+	goFuncRe      = regexp.MustCompile(`^func\s+([A-Za-z_]\w*)`)
+	goMethodRe    = regexp.MustCompile(`^func\s+\([^)]*\)\s*([A-Za-z_]\w*)`)
+	goTypeRe      = regexp.MustCompile(`^type\s+([A-Za-z_]\w*)(?:\[[^\]]*\])?\s+(.*)$`)
+	goTypeSpecRe  = regexp.MustCompile(`^\t([A-Za-z_]\w*)(?:\[[^\]]*\])?\s+(.*)$`)
+	goTypeBlockRe = regexp.MustCompile(`^type\s*\($`)
+)
+
+// symbolPatternsByExt maps file extensions to definition patterns (Go is handled separately)
+var symbolPatternsByExt = map[string][]symbolPattern{
+	".py":   pythonSymbolPatterns,
+	".js":   jsSymbolPatterns,
+	".jsx":  jsSymbolPatterns,
+	".mjs":  jsSymbolPatterns,
+	".ts":   jsSymbolPatterns,
+	".tsx":  jsSymbolPatterns,
+	".rs":   rustSymbolPatterns,
+	".java": javaSymbolPatterns,
+	".kt":   javaSymbolPatterns,
+	".rb":   rubySymbolPatterns,
+}
+
+// symbolIndex is the cached definition index for one root directory
+type symbolIndex struct {
+	files  map[string][]Symbol    // relative path -> definitions
+	stamps map[string]symbolStamp // relative path -> size and mtime when indexed
+	stale  map[string]bool        // relative paths to re-index on next query
+}
+
+// symbolStamp identifies the version of a file that was indexed
+type symbolStamp struct {
+	size    int64
+	modTime time.Time
+}
+
+// SymbolsTool looks up symbol definitions using a cached per-project index
+type SymbolsTool struct {
+	mu         sync.Mutex
+	indexes    map[string]*symbolIndex // absolute root -> index
+	vibeIgnore *IgnoreMatcher
+}
+
+// NewSymbolsTool creates a new symbols tool
+func NewSymbolsTool() *SymbolsTool {
+	return &SymbolsTool{
+		indexes: make(map[string]*symbolIndex),
+	}
+}
+
+// SetVibeIgnore sets the .vibeignore matcher; definitions in matching files are not reported (nil = none)
+func (t *SymbolsTool) SetVibeIgnore(m *IgnoreMatcher) {
+	t.vibeIgnore = m
+}
+
+// Name returns the tool name
+func (t *SymbolsTool) Name() string {
+	return "symbols"
+}
+
+// Schema returns the tool schema
+func (t *SymbolsTool) Schema() *FunctionSchema {
+	return &FunctionSchema{
+		Name:        "symbols",
+		Description: "Find where functions, types and classes are defined (file:line). More precise than grep for code navigation.",
+		Parameters: &ParameterSchema{
+			Type: "object",
+			Properties: map[string]*PropertyDef{
+				"name": {
+					Type:        "string",
+					Description: "Symbol name to look up (exact match; falls back to case-insensitive partial match)",
+				},
+				"kind": {
+					Type:        "string",
+					Description: "Optional kind filter: func, method, struct, interface, type, class, enum, trait, module",
+				},
+				"path": {
+					Type:        "string",
+					Description: "Project directory to index (default: current directory)",
+					Default:     ".",
+				},
+			},
+			Required: []string{"name"},
+		},
+	}
+}
+
+// Execute looks up symbol definitions
+func (t *SymbolsTool) Execute(ctx context.Context, params json.RawMessage) (*Result, error) {
+	var args struct {
+		Name string `json:"name"`
+		Kind string `json:"kind"`
+		Path string `json:"path"`
+	}
+
+	if err := json.Unmarshal(params, &args); err != nil {
+		return NewErrorResult(err), nil
+	}
+
+	args.Name = strings.TrimSpace(args.Name)
+	if args.Name == "" {
+		return NewErrorResult(fmt.Errorf("name cannot be empty")), nil
+	}
+
+	args.Path = security.NormalizePath(args.Path)
+	if args.Path == "" {
+		args.Path = "."
+	}
+
+	info, err := os.Stat(args.Path)
+	if err != nil {
+		return NewErrorResult(fmt.Errorf("path not found: %s", args.Path)), nil
+	}
+	if !info.IsDir() {
+		return NewErrorResult(fmt.Errorf("path is not a directory: %s", args.Path)), nil
+	}
+
+	symbols, err := t.Lookup(ctx, args.Path, args.Name, args.Kind)
+	if err != nil {
+		return NewErrorResult(err), nil
+	}
+
+	exact := len(symbols) > 0
+	if !exact {
+		symbols, err = t.lookupPartial(ctx, args.Path, args.Name, args.Kind)
+		if err != nil {
+			return NewErrorResult(err), nil
+		}
+	}
+
+	if len(symbols) == 0 {
+		return NewResult(fmt.Sprintf("No definitions found for %q", args.Name)), nil
+	}
+
+	var output strings.Builder
+	if exact {
+		output.WriteString(fmt.Sprintf("Found %d definitions of %q:\n\n", len(symbols), args.Name))
+	} else {
+		output.WriteString(fmt.Sprintf("No exact match for %q; %d partial matches:\n\n", args.Name, len(symbols)))
+	}
+	for i, sym := range symbols {
+		if i >= MaxSymbolResults {
+			output.WriteString(fmt.Sprintf("... (%d more)\n", len(symbols)-MaxSymbolResults))
+			break
+		}
+		output.WriteString(fmt.Sprintf("%s:%d: %s %s\n", filepath.Join(args.Path, sym.File), sym.Line, sym.Kind, sym.Name))
+	}
+
+	return NewResult(output.String()), nil
+}
+
+// Lookup returns definitions whose name exactly matches name (and kind, if non-empty)
+func (t *SymbolsTool) Lookup(ctx context.Context, root, name, kind string) ([]Symbol, error) {
+	return t.find(ctx, root, kind, func(s Symbol) bool { return s.Name == name })
+}
+
+// lookupPartial returns definitions whose name contains name, case-insensitively
+func (t *SymbolsTool) lookupPartial(ctx context.Context, root, name, kind string) ([]Symbol, error) {
+	lower := strings.ToLower(name)
+	return t.find(ctx, root, kind, func(s Symbol) bool {
+		return strings.Contains(strings.ToLower(s.Name), lower)
+	})
+}
+
+// find returns all indexed definitions under root accepted by match, sorted by file and line
+func (t *SymbolsTool) find(ctx context.Context, root, kind string, match func(Symbol) bool) ([]Symbol, error) {
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	idx, err := t.indexFor(ctx, absRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	ignore := newSearchFilter(nil, t.vibeIgnore, absRoot)
+	var results []Symbol
+	for file, syms := range idx.files {
+		if ignore.Match(file, false) {
+			continue
+		}
+		for _, s := range syms {
+			if kind != "" && s.Kind != kind {
+				continue
+			}
+			if match(s) {
+				results = append(results, s)
+			}
+		}
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].File != results[j].File {
+			return results[i].File < results[j].File
+		}
+		return results[i].Line < results[j].Line
+	})
+	return results, nil
+}
+
+// Invalidate drops cached definitions for the given paths so they are re-indexed
+// on the next query. With no paths, every cached index is discarded.
+// The file watcher calls this when files change.
+func (t *SymbolsTool) Invalidate(paths ...string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if len(paths) == 0 {
+		t.indexes = make(map[string]*symbolIndex)
+		return
+	}
+
+	for _, p := range paths {
+		absPath, err := filepath.Abs(p)
+		if err != nil {
+			continue
+		}
+		for root, idx := range t.indexes {
+			rel, err := filepath.Rel(root, absPath)
+			if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+				continue
+			}
+			idx.stale[rel] = true
+		}
+	}
+}
+
+// indexFor returns the index for absRoot, building it or refreshing it as needed.
+// Files are compared by size and mtime on every query, so edits made without
+// the file watcher (bash, other editors) are picked up too.
+// Caller must hold t.mu.
+func (t *SymbolsTool) indexFor(ctx context.Context, absRoot string) (*symbolIndex, error) {
+	idx, err := buildSymbolIndex(ctx, absRoot, t.indexes[absRoot])
+	if err != nil {
+		return nil, err
+	}
+	t.indexes[absRoot] = idx
+	return idx, nil
+}
+
+// buildSymbolIndex walks root and extracts definitions from every supported source
+// file. Definitions from prev are reused for files whose size and mtime are unchanged
+// and that were not invalidated (prev may be nil)
+func buildSymbolIndex(ctx context.Context, root string, prev *symbolIndex) (*symbolIndex, error) {
+	idx := &symbolIndex{
+		files:  make(map[string][]Symbol),
+		stamps: make(map[string]symbolStamp),
+		stale:  make(map[string]bool),
+	}
+
+	err := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+
+		if d.IsDir() {
+			if path != root && isSkipDir(path) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil || !isSymbolFile(rel) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		stamp := symbolStamp{size: info.Size(), modTime: info.ModTime()}
+		if prev != nil && !prev.stale[rel] {
+			if old, ok := prev.stamps[rel]; ok && old == stamp {
+				if syms, ok := prev.files[rel]; ok {
+					idx.files[rel] = syms
+				}
+				idx.stamps[rel] = stamp
+				return nil
+			}
+		}
+		if syms, ok := indexSymbolFile(root, rel); ok {
+			idx.files[rel] = syms
+		}
+		idx.stamps[rel] = stamp
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return idx, nil
+}
+
+// isSymbolFile reports whether the symbols tool indexes files with rel's extension
+func isSymbolFile(rel string) bool {
+	ext := strings.ToLower(filepath.Ext(rel))
+	_, supported := symbolPatternsByExt[ext]
+	return ext == ".go" || supported
+}
+
+// indexSymbolFile extracts definitions from root/rel. ok is false for missing,
+// oversized or unsupported files.
+func indexSymbolFile(root, rel string) ([]Symbol, bool) {
+	if !isSymbolFile(rel) {
+		return nil, false
+	}
+	ext := strings.ToLower(filepath.Ext(rel))
+	patterns := symbolPatternsByExt[ext]
+
+	path := filepath.Join(root, rel)
+	info, err := os.Stat(path)
+	if err != nil || info.IsDir() || info.Size() > MaxSymbolFileSize {
+		return nil, false
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, false
+	}
+	defer file.Close()
+
+	var syms []Symbol
+	if ext == ".go" {
+		syms = extractGoSymbols(file, rel)
+	} else {
+		syms = extractPatternSymbols(file, rel, patterns)
+	}
+	return syms, true
+}
+
+// extractGoSymbols extracts funcs, methods and types (including grouped type blocks) from Go source
+func extractGoSymbols(file *os.File, rel string) []Symbol {
+	var syms []Symbol
+	inTypeBlock := false
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := scanner.Text()
+
+		if inTypeBlock {
+			if strings.HasPrefix(line, ")") {
+				inTypeBlock = false
+				continue
+			}
+			if m := goTypeSpecRe.FindStringSubmatch(line); m != nil {
+				syms = append(syms, Symbol{Name: m[1], Kind: goTypeKind(m[2]), File: rel, Line: lineNum})
+			}
+			continue
+		}
+
+		switch {
+		case goTypeBlockRe.MatchString(line):
+			inTypeBlock = true
+		case strings.HasPrefix(line, "type "):
+			if m := goTypeRe.FindStringSubmatch(line); m != nil {
+				syms = append(syms, Symbol{Name: m[1], Kind: goTypeKind(m[2]), File: rel, Line: lineNum})
+			}
+		case strings.HasPrefix(line, "func "):
+			if m := goMethodRe.FindStringSubmatch(line); m != nil {
+				syms = append(syms, Symbol{Name: m[1], Kind: "method", File: rel, Line: lineNum})
+			} else if m := goFuncRe.FindStringSubmatch(line); m != nil {
+				syms = append(syms, Symbol{Name: m[1], Kind: "func", File: rel, Line: lineNum})
+			}
+		}
+	}
+
+	return syms
+}
+
+// goTypeKind classifies a Go type definition by its underlying type
+func goTypeKind(def string) string {
+	def = strings.TrimPrefix(strings.TrimSpace(def), "= ")
+	switch {
+	case strings.HasPrefix(def, "struct"):
+		return "struct"
+	case strings.HasPrefix(def, "interface"):
+		return "interface"
+	default:
+		return "type"
+	}
+}
+
+// extractPatternSymbols extracts definitions line by line using regex patterns
+func extractPatternSymbols(file *os.File, rel string, patterns []symbolPattern) []Symbol {
+	var syms []Symbol
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := scanner.Text()
+
+		for _, p := range patterns {
+			m := p.re.FindStringSubmatch(line)
+			if m == nil {
+				continue
+			}
+			kind := p.kind
+			if p.kindGroup > 0 {
+				kind = m[p.kindGroup]
+			}
+			syms = append(syms, Symbol{Name: m[p.nameGroup], Kind: kind, File: rel, Line: lineNum})
+			break
+		}
+	}
+
+	return syms
+}
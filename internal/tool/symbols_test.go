@@ -0,0 +1,244 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const symbolsTestGoSource = `package sample
+
+type Agent struct {
+	name string
+}
+
+type (
+	Runner interface {
+		Run() error
+	}
+	ID string
+)
+
+type Alias = Agent
+
+func NewAgent(name string) *Agent {
+	return &Agent{name: name}
+}
+
+func (a *Agent) Run() error {
+	return nil
+}
+
+func Map[T any](xs []T) []T { return xs }
+`
+
+func writeSymbolsFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestExtractGoSymbols(t *testing.T) {
+	dir := t.TempDir()
+	writeSymbolsFile(t, dir, "sample.go", symbolsTestGoSource)
+
+	syms, ok := indexSymbolFile(dir, "sample.go")
+	if !ok {
+		t.Fatal("expected sample.go to be indexed")
+	}
+
+	want := map[string]Symbol{
+		"Agent":    {Kind: "struct", Line: 3},
+		"Runner":   {Kind: "interface", Line: 8},
+		"ID":       {Kind: "type", Line: 11},
+		"Alias":    {Kind: "type", Line: 14},
+		"NewAgent": {Kind: "func", Line: 16},
+		"Run":      {Kind: "method", Line: 20},
+		"Map":      {Kind: "func", Line: 24},
+	}
+
+	got := make(map[string]Symbol)
+	for _, s := range syms {
+		if _, dup := got[s.Name]; dup {
+			t.Errorf("duplicate symbol %q", s.Name)
+		}
+		got[s.Name] = s
+	}
+
+	if len(got) != len(want) {
+		t.Errorf("got %d symbols, want %d: %+v", len(got), len(want), syms)
+	}
+	for name, w := range want {
+		g, ok := got[name]
+		if !ok {
+			t.Errorf("missing symbol %q", name)
+			continue
+		}
+		if g.Kind != w.Kind || g.Line != w.Line {
+			t.Errorf("%s = %s@%d, want %s@%d", name, g.Kind, g.Line, w.Kind, w.Line)
+		}
+	}
+}
+
+func TestExtractPatternSymbols_Python(t *testing.T) {
+	dir := t.TempDir()
+	writeSymbolsFile(t, dir, "app.py", "class Server:\n    def start(self):\n        pass\n\nasync def main():\n    pass\n")
+
+	syms, ok := indexSymbolFile(dir, "app.py")
+	if !ok {
+		t.Fatal("expected app.py to be indexed")
+	}
+	if len(syms) != 3 {
+		t.Fatalf("got %d symbols, want 3: %+v", len(syms), syms)
+	}
+	if syms[0].Name != "Server" || syms[0].Kind != "class" {
+		t.Errorf("syms[0] = %+v, want class Server", syms[0])
+	}
+	if syms[2].Name != "main" || syms[2].Line != 5 {
+		t.Errorf("syms[2] = %+v, want main at line 5", syms[2])
+	}
+}
+
+func TestSymbolsTool_Lookup(t *testing.T) {
+	dir := t.TempDir()
+	writeSymbolsFile(t, dir, "internal/agent/agent.go", symbolsTestGoSource)
+	writeSymbolsFile(t, dir, "node_modules/lib/agent.js", "function NewAgent() {}\n")
+
+	st := NewSymbolsTool()
+	syms, err := st.Lookup(context.Background(), dir, "NewAgent", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(syms) != 1 {
+		t.Fatalf("got %d results, want 1 (skip dirs excluded): %+v", len(syms), syms)
+	}
+	if syms[0].File != filepath.Join("internal", "agent", "agent.go") || syms[0].Line != 16 {
+		t.Errorf("got %s:%d, want internal/agent/agent.go:16", syms[0].File, syms[0].Line)
+	}
+
+	// kind filter
+	syms, err = st.Lookup(context.Background(), dir, "Run", "interface")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(syms) != 0 {
+		t.Errorf("Run is a method, not an interface: %+v", syms)
+	}
+}
+
+func TestSymbolsTool_Execute(t *testing.T) {
+	dir := t.TempDir()
+	writeSymbolsFile(t, dir, "sample.go", symbolsTestGoSource)
+
+	st := NewSymbolsTool()
+
+	params, _ := json.Marshal(map[string]string{"name": "NewAgent", "path": dir})
+	result, err := st.Execute(context.Background(), params)
+	if err != nil || result.IsError {
+		t.Fatalf("unexpected error: %v %s", err, result.Error)
+	}
+	if !strings.Contains(result.Output, filepath.Join(dir, "sample.go")+":16: func NewAgent") {
+		t.Errorf("unexpected output: %s", result.Output)
+	}
+
+	// partial fallback
+	params, _ = json.Marshal(map[string]string{"name": "newag", "path": dir})
+	result, _ = st.Execute(context.Background(), params)
+	if !strings.Contains(result.Output, "partial matches") || !strings.Contains(result.Output, "NewAgent") {
+		t.Errorf("expected partial match output, got: %s", result.Output)
+	}
+
+	params, _ = json.Marshal(map[string]string{"name": ""})
+	result, _ = st.Execute(context.Background(), params)
+	if !result.IsError {
+		t.Error("expected error for empty name")
+	}
+}
+
+func TestSymbolsTool_Invalidate(t *testing.T) {
+	dir := t.TempDir()
+	path := writeSymbolsFile(t, dir, "a.go", "package a\n\nfunc Old() {}\n")
+
+	st := NewSymbolsTool()
+	ctx := context.Background()
+
+	if syms, _ := st.Lookup(ctx, dir, "Old", ""); len(syms) != 1 {
+		t.Fatalf("expected Old to be indexed, got %+v", syms)
+	}
+
+	// サイズと更新時刻が同じならキャッシュを使う（Invalidate で再インデックス）
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeSymbolsFile(t, dir, "a.go", "package a\n\nfunc New() {}\n")
+	if err := os.Chtimes(path, info.ModTime(), info.ModTime()); err != nil {
+		t.Fatal(err)
+	}
+	if syms, _ := st.Lookup(ctx, dir, "New", ""); len(syms) != 0 {
+		t.Fatalf("expected cached index before invalidation, got %+v", syms)
+	}
+
+	st.Invalidate(path)
+	if syms, _ := st.Lookup(ctx, dir, "New", ""); len(syms) != 1 {
+		t.Errorf("expected New after invalidation, got %+v", syms)
+	}
+	if syms, _ := st.Lookup(ctx, dir, "Old", ""); len(syms) != 0 {
+		t.Errorf("expected Old to be gone after invalidation, got %+v", syms)
+	}
+
+	// 新規作成・削除も反映
+	created := writeSymbolsFile(t, dir, "b.go", "package a\n\ntype Fresh struct{}\n")
+	os.Remove(path)
+	st.Invalidate(created, path)
+	if syms, _ := st.Lookup(ctx, dir, "Fresh", "struct"); len(syms) != 1 {
+		t.Errorf("expected Fresh after creation, got %+v", syms)
+	}
+	if syms, _ := st.Lookup(ctx, dir, "New", ""); len(syms) != 0 {
+		t.Errorf("expected deleted file to be dropped, got %+v", syms)
+	}
+}
+
+func TestSymbolsTool_RefreshesChangedFilesWithoutWatcher(t *testing.T) {
+	dir := t.TempDir()
+	path := writeSymbolsFile(t, dir, "a.go", "package a\n\nfunc Old() {}\n")
+
+	st := NewSymbolsTool()
+	ctx := context.Background()
+	if syms, _ := st.Lookup(ctx, dir, "Old", ""); len(syms) != 1 {
+		t.Fatalf("expected Old to be indexed, got %+v", syms)
+	}
+
+	// Edited (e.g. by bash) without Invalidate: the size or mtime changes
+	writeSymbolsFile(t, dir, "a.go", "package a\n\nfunc Renamed() {}\n")
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if syms, _ := st.Lookup(ctx, dir, "Renamed", ""); len(syms) != 1 {
+		t.Errorf("expected the edited file to be re-indexed, got %+v", syms)
+	}
+	if syms, _ := st.Lookup(ctx, dir, "Old", ""); len(syms) != 0 {
+		t.Errorf("expected stale definitions to be dropped, got %+v", syms)
+	}
+
+	// New and deleted files are picked up too
+	writeSymbolsFile(t, dir, "b.go", "package a\n\ntype Fresh struct{}\n")
+	os.Remove(path)
+	if syms, _ := st.Lookup(ctx, dir, "Fresh", "struct"); len(syms) != 1 {
+		t.Errorf("expected Fresh after creation, got %+v", syms)
+	}
+	if syms, _ := st.Lookup(ctx, dir, "Renamed", ""); len(syms) != 0 {
+		t.Errorf("expected the deleted file to be dropped, got %+v", syms)
+	}
+}
@@ -0,0 +1,275 @@
+package tool
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultTailLines is the default number of trailing lines to show
+	DefaultTailLines = 50
+	// MaxTailLines is the upper bound for the lines parameter
+	MaxTailLines = 2000
+	// MaxTailFollowSeconds caps follow_seconds (must stay below the agent's tool timeout)
+	MaxTailFollowSeconds = 25
+	// MaxTailBytes caps the total output collected by one tail call
+	MaxTailBytes = 256 * 1024
+	// TailPollInterval is how often the file is checked for growth while following
+	TailPollInterval = 200 * time.Millisecond
+)
+
+// TailTool shows the end of a file and optionally follows it for a bounded window
+type TailTool struct {
+	vibeIgnore *IgnoreMatcher
+}
+
+// NewTailTool creates a new tail tool
+func NewTailTool() *TailTool {
+	return &TailTool{}
+}
+
+// SetVibeIgnore sets the .vibeignore matcher; matching files are refused (nil = none)
+func (t *TailTool) SetVibeIgnore(m *IgnoreMatcher) {
+	t.vibeIgnore = m
+}
+
+// Name returns the tool name
+func (t *TailTool) Name() string {
+	return "tail"
+}
+
+// Schema returns the tool schema
+func (t *TailTool) Schema() *FunctionSchema {
+	return &FunctionSchema{
+		Name:        "tail",
+		Description: "Show the last lines of a file (e.g., a log). With follow_seconds, keep watching and return lines appended during that window. Use this instead of 'bash tail -f', which never returns",
+		Parameters: &ParameterSchema{
+			Type: "object",
+			Properties: map[string]*PropertyDef{
+				"path": {
+					Type:        "string",
+					Description: "The file path to tail",
+				},
+				"lines": {
+					Type:        "integer",
+					Description: "Number of trailing lines to show",
+					Default:     DefaultTailLines,
+				},
+				"follow_seconds": {
+					Type:        "integer",
+					Description: fmt.Sprintf("Seconds to watch for appended lines (0 = don't follow, max %d)", MaxTailFollowSeconds),
+					Default:     0,
+				},
+			},
+			Required: []string{"path"},
+		},
+	}
+}
+
+// Execute tails the file
+func (t *TailTool) Execute(ctx context.Context, params json.RawMessage) (*Result, error) {
+	var args struct {
+		Path          string `json:"path"`
+		Lines         int    `json:"lines"`
+		FollowSeconds int    `json:"follow_seconds"`
+	}
+
+	if err := json.Unmarshal(params, &args); err != nil {
+		return NewErrorResult(err), nil
+	}
+
+	if args.Path == "" {
+		return NewErrorResult(fmt.Errorf("path cannot be empty")), nil
+	}
+
+	if args.Lines <= 0 {
+		args.Lines = DefaultTailLines
+	}
+	if args.Lines > MaxTailLines {
+		args.Lines = MaxTailLines
+	}
+	if args.FollowSeconds < 0 {
+		args.FollowSeconds = 0
+	}
+	if args.FollowSeconds > MaxTailFollowSeconds {
+		args.FollowSeconds = MaxTailFollowSeconds
+	}
+
+	// Resolve path
+	resolvedPath, err := resolvePath(args.Path)
+	if err != nil {
+		return NewErrorResult(err), nil
+	}
+	if err := checkVibeIgnore(t.vibeIgnore, resolvedPath); err != nil {
+		return NewErrorResult(err), nil
+	}
+
+	file, err := os.Open(resolvedPath)
+	if err != nil {
+		return NewErrorResult(fmt.Errorf("file '%s' not found. Try: bash ls to see available files", args.Path)), nil
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return NewErrorResult(err), nil
+	}
+	if info.IsDir() {
+		return NewErrorResult(fmt.Errorf("path is a directory: %s", args.Path)), nil
+	}
+	if isBinary(file) {
+		return NewErrorResult(fmt.Errorf("file appears to be binary")), nil
+	}
+
+	lines, err := lastLines(file, info.Size(), args.Lines, MaxTailBytes)
+	if err != nil {
+		return NewErrorResult(err), nil
+	}
+
+	var output strings.Builder
+	output.WriteString(fmt.Sprintf("==> %s (last %d lines) <==\n", args.Path, len(lines)))
+	for _, line := range lines {
+		output.WriteString(line + "\n")
+	}
+
+	if args.FollowSeconds == 0 {
+		return NewResult(output.String()), nil
+	}
+
+	remaining := MaxTailBytes - output.Len()
+	followed, truncated := followFile(ctx, file, info.Size(), time.Duration(args.FollowSeconds)*time.Second, remaining)
+
+	output.WriteString(fmt.Sprintf("\n--- followed for %ds: %d new lines ---\n", args.FollowSeconds, len(followed)))
+	for _, line := range followed {
+		output.WriteString(line + "\n")
+	}
+	if truncated {
+		output.WriteString(fmt.Sprintf("... (output truncated at %d bytes)\n", MaxTailBytes))
+	}
+	if ctx.Err() != nil {
+		output.WriteString("... (follow interrupted)\n")
+	}
+
+	return NewResult(output.String()), nil
+}
+
+// lastLines reads the last n lines of a file by scanning backwards from the end,
+// so only the tail of a large file is loaded (at most maxBytes)
+func lastLines(file *os.File, size int64, n int, maxBytes int) ([]string, error) {
+	const chunkSize = 8192
+
+	var buf []byte
+	offset := size
+	for offset > 0 && bytes.Count(buf, []byte("\n")) <= n && len(buf) < maxBytes {
+		readSize := int64(chunkSize)
+		if offset < readSize {
+			readSize = offset
+		}
+		offset -= readSize
+
+		chunk := make([]byte, readSize)
+		if _, err := file.ReadAt(chunk, offset); err != nil && err != io.EOF {
+			return nil, err
+		}
+		buf = append(chunk, buf...)
+	}
+
+	if len(buf) > maxBytes {
+		buf = buf[len(buf)-maxBytes:]
+	}
+
+	text := strings.TrimSuffix(string(buf), "\n")
+	if text == "" {
+		return nil, nil
+	}
+	lines := strings.Split(text, "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return lines, nil
+}
+
+// followFile polls the file for appended data until the window elapses or ctx is done.
+// Returns complete lines read (a trailing partial line is included at the end) and
+// whether maxBytes was reached.
+func followFile(ctx context.Context, file *os.File, offset int64, window time.Duration, maxBytes int) ([]string, bool) {
+	var (
+		lines     []string
+		pending   []byte
+		collected int
+	)
+
+	deadline := time.NewTimer(window)
+	defer deadline.Stop()
+	ticker := time.NewTicker(TailPollInterval)
+	defer ticker.Stop()
+
+	readNew := func() bool {
+		if collected >= maxBytes {
+			return true
+		}
+		info, err := file.Stat()
+		if err != nil {
+			return false
+		}
+		// Truncated or rotated in place: start over from the beginning
+		if info.Size() < offset {
+			offset = 0
+			pending = nil
+		}
+		if info.Size() == offset {
+			return false
+		}
+
+		size := info.Size() - offset
+		if budget := int64(maxBytes - collected); size > budget {
+			size = budget
+		}
+		data := make([]byte, size)
+		n, err := file.ReadAt(data, offset)
+		if err != nil && err != io.EOF {
+			return false
+		}
+		offset += int64(n)
+		collected += n
+
+		pending = append(pending, data[:n]...)
+		for {
+			idx := bytes.IndexByte(pending, '\n')
+			if idx < 0 {
+				break
+			}
+			lines = append(lines, string(pending[:idx]))
+			pending = pending[idx+1:]
+		}
+		return collected >= maxBytes
+	}
+
+	truncated := false
+loop:
+	for {
+		select {
+		case <-ctx.Done():
+			break loop
+		case <-deadline.C:
+			truncated = readNew()
+			break loop
+		case <-ticker.C:
+			if readNew() {
+				truncated = true
+				break loop
+			}
+		}
+	}
+
+	if len(pending) > 0 {
+		lines = append(lines, string(pending))
+	}
+	return lines, truncated
+}
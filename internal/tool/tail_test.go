@@ -0,0 +1,133 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeNumberedLines(t *testing.T, path string, n int) {
+	t.Helper()
+	var sb strings.Builder
+	for i := 1; i <= n; i++ {
+		sb.WriteString(fmt.Sprintf("line %d\n", i))
+	}
+	if err := os.WriteFile(path, []byte(sb.String()), 0644); err != nil {
+		t.Fatalf("failed to create test file: %v", err)
+	}
+}
+
+func TestTailTool_LastLines(t *testing.T) {
+	tool := NewTailTool()
+	path := filepath.Join(t.TempDir(), "app.log")
+	writeNumberedLines(t, path, 5000)
+
+	params, _ := json.Marshal(map[string]interface{}{"path": path, "lines": 3})
+	result, err := tool.Execute(context.Background(), params)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if result.IsError {
+		t.Fatalf("expected success, got error: %s", result.Error)
+	}
+
+	if !strings.Contains(result.Output, "line 4998\nline 4999\nline 5000\n") {
+		t.Errorf("expected last 3 lines, got: %s", result.Output)
+	}
+	if strings.Contains(result.Output, "line 4997\n") {
+		t.Errorf("did not expect line 4997, got: %s", result.Output)
+	}
+}
+
+func TestTailTool_FollowCapturesAppends(t *testing.T) {
+	tool := NewTailTool()
+	path := filepath.Join(t.TempDir(), "app.log")
+	writeNumberedLines(t, path, 2)
+
+	go func() {
+		time.Sleep(300 * time.Millisecond)
+		f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			return
+		}
+		defer f.Close()
+		f.WriteString("appended 1\n")
+		time.Sleep(300 * time.Millisecond)
+		f.WriteString("appended 2\n")
+	}()
+
+	params, _ := json.Marshal(map[string]interface{}{"path": path, "lines": 10, "follow_seconds": 1})
+	start := time.Now()
+	result, err := tool.Execute(context.Background(), params)
+	elapsed := time.Since(start)
+
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if result.IsError {
+		t.Fatalf("expected success, got error: %s", result.Error)
+	}
+	if elapsed < time.Second || elapsed > 3*time.Second {
+		t.Errorf("expected to return when the 1s window elapses, took %s", elapsed)
+	}
+	if !strings.Contains(result.Output, "2 new lines") {
+		t.Errorf("expected 2 new lines, got: %s", result.Output)
+	}
+	if !strings.Contains(result.Output, "appended 1\nappended 2\n") {
+		t.Errorf("expected appended lines, got: %s", result.Output)
+	}
+}
+
+func TestTailTool_FollowRespectsCancellation(t *testing.T) {
+	tool := NewTailTool()
+	path := filepath.Join(t.TempDir(), "app.log")
+	writeNumberedLines(t, path, 1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+
+	params, _ := json.Marshal(map[string]interface{}{"path": path, "follow_seconds": 10})
+	start := time.Now()
+	result, err := tool.Execute(ctx, params)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if time.Since(start) > 2*time.Second {
+		t.Errorf("expected follow to stop on cancellation, took %s", time.Since(start))
+	}
+	if !strings.Contains(result.Output, "follow interrupted") {
+		t.Errorf("expected interruption note, got: %s", result.Output)
+	}
+}
+
+func TestTailTool_InvalidPath(t *testing.T) {
+	tool := NewTailTool()
+	dir := t.TempDir()
+
+	tests := []struct {
+		name   string
+		params string
+		errMsg string
+	}{
+		{"empty", `{"path": ""}`, "path cannot be empty"},
+		{"missing", fmt.Sprintf(`{"path": %q}`, filepath.Join(dir, "missing.log")), "not found"},
+		{"directory", fmt.Sprintf(`{"path": %q}`, dir), "is a directory"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := tool.Execute(context.Background(), json.RawMessage(tt.params))
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if !result.IsError || !strings.Contains(result.Error, tt.errMsg) {
+				t.Errorf("expected error containing %q, got: %+v", tt.errMsg, result)
+			}
+		})
+	}
+}
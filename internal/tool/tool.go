@@ -44,6 +44,7 @@ type ToolConfig struct {
 	FailureStrategy ToolFailureStrategy
 	MaxRetries      int
 	RetryBackoff    time.Duration
+	Degraded        string // Why the tool's dependencies are missing ("" = available, see Registry.Preflight)
 }
 
 // DefaultToolConfig creates a default tool configuration
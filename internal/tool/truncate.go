@@ -0,0 +1,57 @@
+package tool
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// truncateOutput keeps the head and tail of output within maxBytes (<= 0 = MaxOutputLength)
+// and reports how many bytes were omitted in between. Cuts fall on line boundaries
+// where possible and never inside a UTF-8 character
+func truncateOutput(output string, maxBytes int) string {
+	if maxBytes <= 0 {
+		maxBytes = MaxOutputLength
+	}
+	if len(output) <= maxBytes {
+		return output
+	}
+
+	half := maxBytes / 2
+	prefix := truncateUTF8(output, half)
+	suffix := output[tailStart(output, half):]
+
+	// Try to truncate at newline boundaries for cleaner display
+	if lastNewline := strings.LastIndex(prefix, "\n"); lastNewline > len(prefix)/2 {
+		prefix = prefix[:lastNewline]
+	}
+	if firstNewline := strings.Index(suffix, "\n"); firstNewline >= 0 && firstNewline < len(suffix)/2 {
+		suffix = suffix[firstNewline+1:]
+	}
+
+	omitted := len(output) - len(prefix) - len(suffix)
+	return fmt.Sprintf("%s\n\n... [%d bytes omitted] ...\n\n%s", prefix, omitted, suffix)
+}
+
+// truncateUTF8 cuts s to at most n bytes without splitting a character
+func truncateUTF8(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+// tailStart returns the offset of the last n bytes of s, moved forward to a character start
+func tailStart(s string, n int) int {
+	if n >= len(s) {
+		return 0
+	}
+	start := len(s) - n
+	for start < len(s) && !utf8.RuneStart(s[start]) {
+		start++
+	}
+	return start
+}
@@ -0,0 +1,59 @@
+package tool
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestTruncateOutput_WithinLimitUnchanged(t *testing.T) {
+	input := strings.Repeat("あ", 100)
+	if got := truncateOutput(input, len(input)); got != input {
+		t.Errorf("output at the limit should be unchanged, got %q", got)
+	}
+}
+
+func TestTruncateOutput_RuneSafe(t *testing.T) {
+	// 3-byte characters with no newlines: every odd cut point is mid-rune
+	input := strings.Repeat("日本語", 1000)
+	for _, limit := range []int{100, 101, 102, 1001} {
+		got := truncateOutput(input, limit)
+		if !utf8.ValidString(got) {
+			t.Fatalf("limit %d: truncated output is not valid UTF-8", limit)
+		}
+		if !strings.Contains(got, "bytes omitted") {
+			t.Errorf("limit %d: expected an omitted-bytes note, got %q", limit, got)
+		}
+		head, tail, _ := strings.Cut(got, "\n\n... [")
+		_, tail, _ = strings.Cut(tail, "] ...\n\n")
+		if len(head)+len(tail) > limit {
+			t.Errorf("limit %d: kept %d bytes", limit, len(head)+len(tail))
+		}
+		if !strings.HasPrefix(input, head) || !strings.HasSuffix(input, tail) {
+			t.Errorf("limit %d: expected the head and tail of the input", limit)
+		}
+	}
+}
+
+func TestTruncateOutput_ReportsOmittedBytes(t *testing.T) {
+	input := strings.Repeat("line of text\n", 100)
+	got := truncateOutput(input, 200)
+
+	if !strings.HasPrefix(got, "line of text\n") || !strings.HasSuffix(got, "line of text\n") {
+		t.Errorf("expected whole lines kept at both ends, got %q", got)
+	}
+	head, rest, _ := strings.Cut(got, "\n\n... [")
+	_, tail, _ := strings.Cut(rest, "] ...\n\n")
+	want := len(input) - len(head) - len(tail)
+	if !strings.Contains(got, fmt.Sprintf("[%d bytes omitted]", want)) {
+		t.Errorf("expected %d bytes omitted, got %q", want, got)
+	}
+}
+
+func TestTruncateOutput_DefaultLimit(t *testing.T) {
+	input := strings.Repeat("a", MaxOutputLength+1)
+	if got := truncateOutput(input, 0); !strings.Contains(got, "bytes omitted") {
+		t.Error("a limit of 0 should fall back to MaxOutputLength")
+	}
+}
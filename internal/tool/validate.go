@@ -0,0 +1,218 @@
+package tool
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ValidateArguments checks JSON tool-call arguments against the tool's schema:
+// required properties, property types and enum values (nested objects and array
+// items included). The error lists every problem together with the expected
+// type and description so the model can fix the call in one retry.
+// Unknown properties are allowed. Numeric strings are rejected for number and
+// integer properties because tools decode those straight into Go numbers.
+// Without a schema only the JSON syntax is checked.
+func ValidateArguments(schema *FunctionSchema, arguments string) error {
+	if strings.TrimSpace(arguments) == "" {
+		arguments = "{}"
+	}
+
+	var args interface{}
+	if err := json.Unmarshal([]byte(arguments), &args); err != nil {
+		return fmt.Errorf("invalid JSON arguments: %w; send the arguments as a JSON object", err)
+	}
+	if schema == nil || schema.Parameters == nil {
+		return nil
+	}
+	obj, ok := args.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("invalid arguments for %s: expected a JSON object, got %s", schema.Name, jsonTypeName(args))
+	}
+
+	var problems []string
+	validateObject("", schema.Parameters.Properties, schema.Parameters.Required, obj, &problems)
+	if len(problems) == 0 {
+		return nil
+	}
+	return fmt.Errorf("invalid arguments for %s: %s. Expected parameters: %s",
+		schema.Name, strings.Join(problems, "; "), describeParameters(schema.Parameters))
+}
+
+// validateObject checks one object level; path is the dotted prefix for nested properties
+func validateObject(path string, props map[string]*PropertyDef, required []string, obj map[string]interface{}, problems *[]string) {
+	for _, name := range required {
+		if v, ok := obj[name]; !ok || v == nil {
+			msg := fmt.Sprintf("missing required property %q", path+name)
+			if def := props[name]; def != nil {
+				msg += " (" + describeProperty(def) + ")"
+			}
+			*problems = append(*problems, msg)
+		}
+	}
+
+	isRequired := make(map[string]bool, len(required))
+	for _, name := range required {
+		isRequired[name] = true
+	}
+
+	names := make([]string, 0, len(obj))
+	for name := range obj {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		def := props[name]
+		if def == nil || obj[name] == nil {
+			continue
+		}
+		// Tools treat "" for an optional enum as its default (small models often send it)
+		if s, ok := obj[name].(string); ok && s == "" && len(def.Enum) > 0 && !isRequired[name] {
+			continue
+		}
+		validateValue(path+name, def, obj[name], problems)
+	}
+}
+
+// validateValue checks a single value against its property definition
+func validateValue(path string, def *PropertyDef, v interface{}, problems *[]string) {
+	if def.Type != "" && !matchesType(def.Type, v) {
+		*problems = append(*problems, fmt.Sprintf("property %q must be %s, got %s", path, withArticle(def.Type), jsonTypeName(v)))
+		return
+	}
+	if len(def.Enum) > 0 {
+		s, ok := v.(string)
+		if !ok || !containsString(def.Enum, s) {
+			*problems = append(*problems, fmt.Sprintf("property %q must be one of [%s], got %s", path, strings.Join(def.Enum, ", "), formatValue(v)))
+			return
+		}
+	}
+
+	switch val := v.(type) {
+	case map[string]interface{}:
+		if len(def.Properties) > 0 || len(def.Required) > 0 {
+			validateObject(path+".", def.Properties, def.Required, val, problems)
+		}
+	case []interface{}:
+		if def.Items != nil {
+			for i, item := range val {
+				if item != nil {
+					validateValue(fmt.Sprintf("%s[%d]", path, i), def.Items, item, problems)
+				}
+			}
+		}
+	}
+}
+
+// matchesType reports whether a decoded JSON value has the given schema type.
+// Unknown types (e.g. from MCP servers) always match.
+func matchesType(typ string, v interface{}) bool {
+	switch typ {
+	case "string":
+		_, ok := v.(string)
+		return ok
+	case "number":
+		_, ok := v.(float64)
+		return ok
+	case "integer":
+		val, ok := v.(float64)
+		return ok && val == float64(int64(val))
+	case "boolean":
+		_, ok := v.(bool)
+		return ok
+	case "array":
+		_, ok := v.([]interface{})
+		return ok
+	case "object":
+		_, ok := v.(map[string]interface{})
+		return ok
+	}
+	return true
+}
+
+// jsonTypeName describes the JSON type of a decoded value for error messages
+func jsonTypeName(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "a string"
+	case float64:
+		if val == float64(int64(val)) {
+			return "an integer"
+		}
+		return "a number"
+	case bool:
+		return "a boolean"
+	case []interface{}:
+		return "an array"
+	case map[string]interface{}:
+		return "an object"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// withArticle returns "a string", "an integer", ...
+func withArticle(typ string) string {
+	switch typ {
+	case "integer", "array", "object":
+		return "an " + typ
+	}
+	return "a " + typ
+}
+
+// formatValue renders a value compactly for error messages
+func formatValue(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	if len(data) > 60 {
+		return string(data[:57]) + "..."
+	}
+	return string(data)
+}
+
+// describeProperty returns "string: The file path to read"
+func describeProperty(def *PropertyDef) string {
+	desc := def.Type
+	if len(def.Enum) > 0 {
+		desc += " one of [" + strings.Join(def.Enum, ", ") + "]"
+	}
+	if def.Description != "" {
+		if desc != "" {
+			desc += ": "
+		}
+		desc += def.Description
+	}
+	return desc
+}
+
+// describeParameters lists the top-level parameters, required ones first
+func describeParameters(params *ParameterSchema) string {
+	required := make(map[string]bool, len(params.Required))
+	for _, name := range params.Required {
+		required[name] = true
+	}
+	names := make([]string, 0, len(params.Properties))
+	for name := range params.Properties {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if required[names[i]] != required[names[j]] {
+			return required[names[i]]
+		}
+		return names[i] < names[j]
+	})
+
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		part := name + " (" + params.Properties[name].Type
+		if required[name] {
+			part += ", required"
+		}
+		parts = append(parts, part+")")
+	}
+	return strings.Join(parts, ", ")
+}
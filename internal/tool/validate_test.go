@@ -0,0 +1,123 @@
+package tool
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateArguments_ReadFileMissingPath(t *testing.T) {
+	schema := NewReadTool().Schema()
+
+	err := ValidateArguments(schema, `{"offset": 10}`)
+	if err == nil {
+		t.Fatal("read_file without path should be rejected")
+	}
+	msg := err.Error()
+	for _, want := range []string{
+		"invalid arguments for read_file",
+		`missing required property "path"`,
+		"The file path to read",
+		"path (string, required)",
+	} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("error %q should contain %q", msg, want)
+		}
+	}
+
+	// null counts as missing
+	if err := ValidateArguments(schema, `{"path": null}`); err == nil {
+		t.Error("null path should be rejected")
+	}
+
+	if err := ValidateArguments(schema, `{"path": "main.go", "limit": 20}`); err != nil {
+		t.Errorf("valid call rejected: %v", err)
+	}
+}
+
+func TestValidateArguments_TypesAndEnums(t *testing.T) {
+	schema := NewSchemaBuilder("example").
+		AddString("path", "target file", true).
+		AddInteger("limit", "max lines", false).
+		AddBoolean("recursive", "walk subdirectories", false).
+		AddEnum("mode", "output mode", []string{"files", "content"}, false).
+		AddArray("tags", "labels", "string", false).
+		Build()
+
+	tests := []struct {
+		name string
+		args string
+		want string // "" = valid
+	}{
+		{"valid", `{"path": "a", "limit": 5, "recursive": true, "mode": "files", "tags": ["x"]}`, ""},
+		{"empty arguments", ``, `missing required property "path"`},
+		{"numeric string for integer", `{"path": "a", "limit": "5"}`, `property "limit" must be an integer, got a string`},
+		{"unknown property", `{"path": "a", "extra": 1}`, ""},
+		{"wrong type", `{"path": 3}`, `property "path" must be a string, got an integer`},
+		{"fractional integer", `{"path": "a", "limit": 1.5}`, `property "limit" must be an integer, got a number`},
+		{"boolean as string", `{"path": "a", "recursive": "yes"}`, `property "recursive" must be a boolean, got a string`},
+		{"enum", `{"path": "a", "mode": "lines"}`, `property "mode" must be one of [files, content], got "lines"`},
+		{"empty optional enum", `{"path": "a", "mode": ""}`, ""},
+		{"array item", `{"path": "a", "tags": ["x", 2]}`, `property "tags[1]" must be a string`},
+		{"not an object", `["a"]`, "expected a JSON object, got an array"},
+		{"invalid JSON", `{path: a}`, "invalid JSON arguments"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateArguments(schema, tt.args)
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateArguments_EmptyEnum(t *testing.T) {
+	// glob's sort is optional: "" means the default order
+	if err := ValidateArguments(NewGlobTool().Schema(), `{"pattern": "*.go", "sort": ""}`); err != nil {
+		t.Errorf("empty optional enum rejected: %v", err)
+	}
+
+	// A required enum still has to be one of its values
+	schema := NewSchemaBuilder("example").
+		AddEnum("mode", "output mode", []string{"files", "content"}, true).
+		Build()
+	if err := ValidateArguments(schema, `{"mode": ""}`); err == nil {
+		t.Error("empty required enum should be rejected")
+	}
+}
+
+func TestValidateArguments_ReportsAllProblems(t *testing.T) {
+	schema := &FunctionSchema{
+		Name: "nested",
+		Parameters: &ParameterSchema{
+			Type: "object",
+			Properties: map[string]*PropertyDef{
+				"a":      {Type: "string"},
+				"b":      {Type: "string"},
+				"option": {Type: "object", Properties: map[string]*PropertyDef{"level": {Type: "integer"}}, Required: []string{"level"}},
+			},
+			Required: []string{"a", "b"},
+		},
+	}
+
+	err := ValidateArguments(schema, `{"option": {}}`)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	for _, want := range []string{`"a"`, `"b"`, `"option.level"`} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q should mention %s", err, want)
+		}
+	}
+
+	// Without a schema only JSON syntax is checked
+	if err := ValidateArguments(&FunctionSchema{Name: "free"}, `{"anything": 1}`); err != nil {
+		t.Errorf("schema without parameters: %v", err)
+	}
+}
@@ -0,0 +1,183 @@
+package tool
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultMaxWalkDepth is the default directory depth limit for recursive searches
+const DefaultMaxWalkDepth = 64
+
+// walkStats records what a tree walk left out
+type walkStats struct {
+	depthLimited int      // Directories not entered because of the depth limit
+	cycles       []string // Symlinked directories skipped because they loop back to an ancestor
+	outside      []string // Symlinked directories skipped because they resolve outside the root
+}
+
+// notice describes skipped parts of the tree for the tool output ("" when nothing was skipped)
+func (s *walkStats) notice(maxDepth int) string {
+	if s == nil {
+		return ""
+	}
+	var notes []string
+	if s.depthLimited > 0 {
+		notes = append(notes, fmt.Sprintf("max depth %d reached in %d directories; results may be incomplete", maxDepth, s.depthLimited))
+	}
+	if len(s.cycles) > 0 {
+		notes = append(notes, fmt.Sprintf("skipped %d symlink cycle(s): %s", len(s.cycles), strings.Join(s.cycles, ", ")))
+	}
+	if len(s.outside) > 0 {
+		notes = append(notes, fmt.Sprintf("skipped %d symlinked director(ies) outside the search root: %s", len(s.outside), strings.Join(s.outside, ", ")))
+	}
+	if len(notes) == 0 {
+		return ""
+	}
+	return "\nNote: " + strings.Join(notes, "; ") + "\n"
+}
+
+// treeWalker walks a directory tree for walkTree
+type treeWalker struct {
+	maxDepth int
+	fn       fs.WalkDirFunc
+	stats    *walkStats
+	rootReal string          // Resolved root; symlinked directories are only followed inside it
+	visited  map[string]bool // Resolved paths of the directories entered so far
+	links    []symlinkedDir  // Symlinked directories waiting until the real tree has been walked
+}
+
+// symlinkedDir is a symlink to a directory found during the walk
+type symlinkedDir struct {
+	path       string
+	depth      int
+	parentReal string // Resolved path of the directory containing the link
+}
+
+// walkTree walks root like filepath.WalkDir, with two differences: symlinked
+// directories inside root are followed once the real tree has been walked,
+// unless they point back to one of their ancestors (a cycle) or to a directory
+// that was already visited, and directories deeper than maxDepth below root
+// are not entered.
+// maxDepth <= 0 uses DefaultMaxWalkDepth.
+func walkTree(root string, maxDepth int, fn fs.WalkDirFunc) (*walkStats, error) {
+	if maxDepth <= 0 {
+		maxDepth = DefaultMaxWalkDepth
+	}
+	w := &treeWalker{maxDepth: maxDepth, fn: fn, stats: &walkStats{}, visited: make(map[string]bool)}
+	rootReal := root
+	if real, err := filepath.EvalSymlinks(root); err == nil {
+		w.rootReal = real
+		rootReal = real
+	}
+
+	info, err := os.Stat(root)
+	if err != nil {
+		err = fn(root, nil, err)
+	} else {
+		err = w.walk(root, fs.FileInfoToDirEntry(info), 0, rootReal)
+	}
+	if err == nil {
+		err = w.walkLinks()
+	}
+	if err == filepath.SkipDir || err == filepath.SkipAll {
+		err = nil
+	}
+	return w.stats, err
+}
+
+// walk visits path and, for directories, its entries. real is the resolved
+// path of path; symlinked directories found below it are queued in w.links.
+func (w *treeWalker) walk(path string, d fs.DirEntry, depth int, real string) error {
+	if err := w.fn(path, d, nil); err != nil {
+		if err == filepath.SkipDir && d.IsDir() {
+			return nil
+		}
+		return err
+	}
+	if !d.IsDir() {
+		return nil
+	}
+
+	if depth >= w.maxDepth {
+		w.stats.depthLimited++
+		return nil
+	}
+	w.visited[real] = true
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		if err := w.fn(path, d, err); err != nil && err != filepath.SkipDir {
+			return err
+		}
+		return nil
+	}
+
+	for _, entry := range entries {
+		child := filepath.Join(path, entry.Name())
+
+		// Symlinks to directories are walked after the real tree (see walkLinks),
+		// so a link never hides the directory it points to
+		if entry.Type()&fs.ModeSymlink != 0 {
+			if info, err := os.Stat(child); err == nil && info.IsDir() {
+				w.links = append(w.links, symlinkedDir{path: child, depth: depth + 1, parentReal: real})
+				continue
+			}
+		}
+
+		if err := w.walk(child, entry, depth+1, filepath.Join(real, entry.Name())); err != nil {
+			if err == filepath.SkipDir {
+				return nil // Returned for a file: skip the rest of this directory
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// walkLinks follows the queued symlinked directories that resolve inside the
+// root to a directory not visited yet. Links leading out of the root, back to
+// an ancestor, or to a directory already walked are skipped.
+func (w *treeWalker) walkLinks() error {
+	for len(w.links) > 0 {
+		link := w.links[0]
+		w.links = w.links[1:]
+
+		real, err := filepath.EvalSymlinks(link.path)
+		if err != nil {
+			continue
+		}
+		switch {
+		case !w.insideRoot(real):
+			w.stats.outside = append(w.stats.outside, link.path)
+			continue
+		case isAncestorOrSelf(real, link.parentReal):
+			w.stats.cycles = append(w.stats.cycles, link.path)
+			continue
+		case w.visited[real]:
+			continue // Already walked through its real path or another link
+		}
+
+		info, err := os.Stat(link.path)
+		if err != nil {
+			continue
+		}
+		if err := w.walk(link.path, fs.FileInfoToDirEntry(info), link.depth, real); err != nil && err != filepath.SkipDir {
+			return err
+		}
+	}
+	return nil
+}
+
+// insideRoot reports whether the resolved path real is the walk root or below it
+func (w *treeWalker) insideRoot(real string) bool {
+	return w.rootReal != "" && isAncestorOrSelf(w.rootReal, real)
+}
+
+// isAncestorOrSelf reports whether dir is path or one of its parent directories
+func isAncestorOrSelf(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
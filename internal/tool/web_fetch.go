@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"html"
 	"io"
 	"net"
 	"net/http"
@@ -13,9 +14,17 @@ import (
 	"time"
 )
 
+// Web fetch output formats
+const (
+	WebFetchFormatRaw      = "raw"      // Body as fetched
+	WebFetchFormatText     = "text"     // Tags stripped, whitespace collapsed
+	WebFetchFormatMarkdown = "markdown" // Headings, links and lists kept as markdown
+)
+
 // WebFetchTool fetches web pages and converts HTML to text
 type WebFetchTool struct {
 	httpClient *http.Client
+	maxOutput  int // Output size limit in bytes (0 = MaxOutputLength)
 }
 
 // NewWebFetchTool creates a new web fetch tool
@@ -27,16 +36,26 @@ func NewWebFetchTool() *WebFetchTool {
 	}
 }
 
+// SetMaxOutput sets the output size limit in bytes (<= 0 = MaxOutputLength)
+func (t *WebFetchTool) SetMaxOutput(maxBytes int) {
+	t.maxOutput = maxBytes
+}
+
 // Name returns the tool name
 func (t *WebFetchTool) Name() string {
 	return "web_fetch"
 }
 
+// Dependencies returns the external prerequisites checked by Registry.Preflight
+func (t *WebFetchTool) Dependencies() []Dependency {
+	return []Dependency{NetworkDependency(InternetProbeHost)}
+}
+
 // Schema returns the OpenAI function calling schema
 func (t *WebFetchTool) Schema() *FunctionSchema {
 	return &FunctionSchema{
 		Name:        "web_fetch",
-		Description: "Fetch a web page and convert HTML to plain text",
+		Description: "Fetch a web page and convert HTML to plain text or markdown",
 		Parameters: &ParameterSchema{
 			Type: "object",
 			Properties: map[string]*PropertyDef{
@@ -44,6 +63,12 @@ func (t *WebFetchTool) Schema() *FunctionSchema {
 					Type:        "string",
 					Description: "The URL to fetch",
 				},
+				"format": {
+					Type:        "string",
+					Description: "Output format: 'text' (strip HTML), 'markdown' (keep headings, links, lists), 'raw' (unmodified body)",
+					Enum:        []string{WebFetchFormatText, WebFetchFormatMarkdown, WebFetchFormatRaw},
+					Default:     WebFetchFormatText,
+				},
 				"headers": {
 					Type:        "string",
 					Description: "Optional custom HTTP headers as JSON string",
@@ -67,6 +92,7 @@ func (t *WebFetchTool) Execute(ctx context.Context, params json.RawMessage) (*Re
 	// Parse parameters
 	var p struct {
 		URL             string  `json:"url"`
+		Format          string  `json:"format"`
 		Headers         string  `json:"headers"`
 		FollowRedirect  bool    `json:"follow_redirect"`
 		Timeout         float64 `json:"timeout"`
@@ -87,6 +113,17 @@ func (t *WebFetchTool) Execute(ctx context.Context, params json.RawMessage) (*Re
 		}, nil
 	}
 
+	// Validate format
+	if p.Format == "" {
+		p.Format = WebFetchFormatText
+	}
+	if p.Format != WebFetchFormatText && p.Format != WebFetchFormatMarkdown && p.Format != WebFetchFormatRaw {
+		return &Result{
+			Output:  fmt.Sprintf("Invalid format '%s' (expected text, markdown or raw)", p.Format),
+			IsError: true,
+		}, nil
+	}
+
 	// Validate and set timeout
 	timeout := 30 * time.Second
 	if p.Timeout > 0 {
@@ -172,20 +209,24 @@ func (t *WebFetchTool) Execute(ctx context.Context, params json.RawMessage) (*Re
 		}, nil
 	}
 
-	// Convert HTML to text
-	text := t.htmlToText(string(data))
-
-	// Truncate to 30,000 characters
-	if len(text) > 30000 {
-		text = text[:30000]
-	}
-
 	return &Result{
-		Output:  text,
+		Output:  truncateOutput(formatContent(string(data), p.Format), t.maxOutput),
 		IsError: false,
 	}, nil
 }
 
+// formatContent converts a fetched body to the requested format
+func formatContent(body, format string) string {
+	switch format {
+	case WebFetchFormatRaw:
+		return body
+	case WebFetchFormatMarkdown:
+		return htmlToMarkdown(body)
+	default:
+		return htmlToText(body)
+	}
+}
+
 // checkSSRF checks if the URL resolves to a private IP address
 func (t *WebFetchTool) checkSSRF(urlStr string) error {
 	u, err := url.Parse(urlStr)
@@ -268,37 +309,83 @@ func (t *WebFetchTool) readResponseBody(body io.ReadCloser) ([]byte, error) {
 	return data, nil
 }
 
-// htmlToText converts HTML to plain text
-func (t *WebFetchTool) htmlToText(html string) string {
-	// Remove script and style tags with their content
-	html = regexp.MustCompile(`(?i)<script[^>]*>.*?</script>`).ReplaceAllString(html, "")
-	html = regexp.MustCompile(`(?i)<style[^>]*>.*?</style>`).ReplaceAllString(html, "")
-
-	// Remove HTML comments
-	html = regexp.MustCompile(`<!--.*?-->`).ReplaceAllString(html, "")
+var (
+	htmlNonContentRe = regexp.MustCompile(`(?is)<(script|style|noscript|head)(?:\s[^>]*)?>.*?</(script|style|noscript|head)>`)
+	htmlCommentRe    = regexp.MustCompile(`(?s)<!--.*?-->`)
+	htmlBlockRe      = regexp.MustCompile(`(?i)</?(p|div|br|hr|h[1-6]|li|ul|ol|dl|dt|dd|blockquote|pre|table|tr|td|th|section|article|header|footer|nav|main|aside)(\s[^>]*)?/?>`)
+	htmlTagRe        = regexp.MustCompile(`<[^>]+>`)
+	htmlHeadingRe    = regexp.MustCompile(`(?is)<h([1-6])(?:\s[^>]*)?>(.*?)</h[1-6]>`)
+	htmlLinkRe       = regexp.MustCompile(`(?is)<a\s[^>]*?href\s*=\s*["']([^"']*)["'][^>]*>(.*?)</a>`)
+	htmlOrderedRe    = regexp.MustCompile(`(?is)<ol(?:\s[^>]*)?>(.*?)</ol>`)
+	htmlListItemRe   = regexp.MustCompile(`(?i)\s*<li(?:\s[^>]*)?>`)
+	htmlListItemEnd  = regexp.MustCompile(`(?i)\s*</li\s*>`)
+	spacesRe         = regexp.MustCompile(`[ \t\r\f\v]+`)
+	blankLinesRe     = regexp.MustCompile(`\n{3,}`)
+)
 
-	// Replace common block elements with newlines
-	html = regexp.MustCompile(`(?i)</?(p|div|br|h[1-6]|li|ul|ol|blockquote|table|tr|td|th|section|article|header|footer)[^>]*>`).ReplaceAllString(html, "\n")
+// htmlToText converts HTML to plain text: tags stripped, whitespace collapsed
+func htmlToText(body string) string {
+	body = stripNonContent(body)
+	body = htmlBlockRe.ReplaceAllString(body, "\n")
+	body = htmlTagRe.ReplaceAllString(body, "")
+	return collapseWhitespace(html.UnescapeString(body))
+}
 
-	// Decode common HTML entities
-	html = strings.NewReplacer(
-		"&nbsp;", " ",
-		"&quot;", "\"",
-		"&apos;", "'",
-		"&#39;", "'",
-		"&amp;", "&",
-		"&lt;", "<",
-		"&gt;", ">",
-	).Replace(html)
+// htmlToMarkdown does a lightweight HTML to markdown conversion of headings, links and lists
+func htmlToMarkdown(body string) string {
+	body = stripNonContent(body)
 
-	// Remove remaining HTML tags
-	html = regexp.MustCompile(`<[^>]+>`).ReplaceAllString(html, "")
+	// Links first so link text inside headings/list items is kept
+	body = htmlLinkRe.ReplaceAllStringFunc(body, func(m string) string {
+		sub := htmlLinkRe.FindStringSubmatch(m)
+		text := inlineText(sub[2])
+		if text == "" {
+			return ""
+		}
+		return fmt.Sprintf("[%s](%s)", text, html.UnescapeString(sub[1]))
+	})
+
+	body = htmlHeadingRe.ReplaceAllStringFunc(body, func(m string) string {
+		sub := htmlHeadingRe.FindStringSubmatch(m)
+		level := int(sub[1][0] - '0')
+		return "\n\n" + strings.Repeat("#", level) + " " + inlineText(sub[2]) + "\n\n"
+	})
+
+	// Number items of ordered lists; remaining items become bullets
+	body = htmlOrderedRe.ReplaceAllStringFunc(body, func(m string) string {
+		n := 0
+		return htmlListItemRe.ReplaceAllStringFunc(m, func(string) string {
+			n++
+			return fmt.Sprintf("\n%d. ", n)
+		})
+	})
+	body = htmlListItemRe.ReplaceAllString(body, "\n- ")
+	body = htmlListItemEnd.ReplaceAllString(body, "")
+
+	body = htmlBlockRe.ReplaceAllString(body, "\n")
+	body = htmlTagRe.ReplaceAllString(body, "")
+	return collapseWhitespace(html.UnescapeString(body))
+}
 
-	// Collapse multiple whitespaces
-	html = regexp.MustCompile(`\s+`).ReplaceAllString(html, " ")
+// stripNonContent removes scripts, styles, the document head and comments
+func stripNonContent(body string) string {
+	body = htmlNonContentRe.ReplaceAllString(body, "")
+	return htmlCommentRe.ReplaceAllString(body, "")
+}
 
-	// Trim whitespace
-	html = strings.TrimSpace(html)
+// inlineText flattens an HTML fragment to a single line of text
+func inlineText(fragment string) string {
+	text := html.UnescapeString(htmlTagRe.ReplaceAllString(fragment, ""))
+	return strings.Join(strings.Fields(text), " ")
+}
 
-	return html
+// collapseWhitespace collapses runs of spaces, trims each line and keeps at most one blank line
+func collapseWhitespace(text string) string {
+	text = strings.ReplaceAll(text, "\u00a0", " ")
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(spacesRe.ReplaceAllString(line, " "))
+	}
+	text = blankLinesRe.ReplaceAllString(strings.Join(lines, "\n"), "\n\n")
+	return strings.TrimSpace(text)
 }
@@ -0,0 +1,83 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+const sampleHTML = `<!DOCTYPE html>
+<html>
+<head><title>Sample</title><style>body { color: red; }</style></head>
+<body>
+  <header><nav><a href="/">Home</a></nav></header>
+  <h1>Getting   Started</h1>
+  <p>Install the <a href="https://example.com/docs?a=1&amp;b=2">latest <b>release</b></a> first.</p>
+  <script>var tracking = "ignore me";</script>
+  <!-- build: 1234 -->
+  <h2>Steps</h2>
+  <ol>
+    <li>Download</li>
+    <li>Run &lt;install&gt;</li>
+  </ol>
+  <ul><li>Fast</li><li>Small</li></ul>
+</body>
+</html>`
+
+func TestFormatContent_Raw(t *testing.T) {
+	if got := formatContent(sampleHTML, WebFetchFormatRaw); got != sampleHTML {
+		t.Errorf("raw format should return body unchanged, got: %s", got)
+	}
+}
+
+func TestFormatContent_Text(t *testing.T) {
+	got := formatContent(sampleHTML, WebFetchFormatText)
+
+	for _, want := range []string{"Getting Started", "Install the latest release first.", "Run <install>", "Download", "Small"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected %q in text output, got:\n%s", want, got)
+		}
+	}
+	for _, unwanted := range []string{"<", "color: red", "tracking", "build: 1234", "Sample"} {
+		if strings.Contains(strings.ReplaceAll(got, "Run <install>", ""), unwanted) {
+			t.Errorf("did not expect %q in text output, got:\n%s", unwanted, got)
+		}
+	}
+	if strings.Contains(got, "  ") || strings.Contains(got, "\n\n\n") {
+		t.Errorf("expected collapsed whitespace, got:\n%q", got)
+	}
+}
+
+func TestFormatContent_Markdown(t *testing.T) {
+	got := formatContent(sampleHTML, WebFetchFormatMarkdown)
+
+	for _, want := range []string{
+		"# Getting Started\n",
+		"## Steps\n",
+		"[latest release](https://example.com/docs?a=1&b=2)",
+		"[Home](/)",
+		"1. Download\n2. Run <install>",
+		"- Fast\n- Small",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected %q in markdown output, got:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "tracking") || strings.Contains(got, "<b>") {
+		t.Errorf("expected scripts and tags removed, got:\n%s", got)
+	}
+}
+
+func TestWebFetchTool_InvalidFormat(t *testing.T) {
+	tool := NewWebFetchTool()
+	params := json.RawMessage(`{"url": "https://example.com", "format": "pdf"}`)
+
+	result, err := tool.Execute(context.Background(), params)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !result.IsError || !strings.Contains(result.Output, "Invalid format") {
+		t.Errorf("expected invalid format error, got: %+v", result)
+	}
+}
@@ -19,17 +19,27 @@ type WebSearchTool struct {
 	lastQueryTime time.Time
 	queryCount    int
 	mu            sync.Mutex
+	cache         *searchCache // Results of recent queries (hits skip the network and the rate limit)
+	search        func(ctx context.Context, query string, maxResults int) ([]SearchResult, error)
 }
 
 // NewWebSearchTool creates a new web search tool
 func NewWebSearchTool() *WebSearchTool {
-	return &WebSearchTool{
+	t := &WebSearchTool{
 		httpClient: &http.Client{
 			Timeout: 30 * time.Second,
 		},
 		lastQueryTime: time.Time{},
 		queryCount:    0,
+		cache:         newSearchCache(DefaultSearchCacheSize, SearchCacheTTL),
 	}
+	t.search = t.searchDuckDuckGo
+	return t
+}
+
+// SetCacheSize sets how many queries are cached (0 disables the cache)
+func (t *WebSearchTool) SetCacheSize(n int) {
+	t.cache.resize(n)
 }
 
 // Name returns the tool name
@@ -37,6 +47,11 @@ func (t *WebSearchTool) Name() string {
 	return "web_search"
 }
 
+// Dependencies returns the external prerequisites checked by Registry.Preflight
+func (t *WebSearchTool) Dependencies() []Dependency {
+	return []Dependency{NetworkDependency(InternetProbeHost)}
+}
+
 // Schema returns the OpenAI function calling schema
 func (t *WebSearchTool) Schema() *FunctionSchema {
 	return &FunctionSchema{
@@ -70,6 +85,7 @@ type SearchResult struct {
 type SearchResponse struct {
 	Results []SearchResult `json:"results"`
 	Count   int            `json:"count"`
+	Cached  bool           `json:"cached,omitempty"` // Served from the session cache (see SearchCacheTTL)
 }
 
 // Execute executes the web search
@@ -105,10 +121,21 @@ func (t *WebSearchTool) Execute(ctx context.Context, params json.RawMessage) (*R
 		}
 	}
 
+	// Repeated queries are answered from the cache
+	cacheKey := searchCacheKey(query, maxResults)
+	if results, ok := t.cache.get(cacheKey); ok {
+		return formatSearchResponse(results, true), nil
+	}
+
 	// Check rate limiting
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
+	// A call waiting on the lock may have just cached the same query
+	if results, ok := t.cache.get(cacheKey); ok {
+		return formatSearchResponse(results, true), nil
+	}
+
 	if t.queryCount >= 50 {
 		return &Result{
 			Output:  "Rate limit exceeded. Maximum 50 queries per session.",
@@ -128,18 +155,24 @@ func (t *WebSearchTool) Execute(ctx context.Context, params json.RawMessage) (*R
 	t.lastQueryTime = time.Now()
 
 	// Perform search
-	results, err := t.searchDuckDuckGo(ctx, query, maxResults)
+	results, err := t.search(ctx, query, maxResults)
 	if err != nil {
 		return &Result{
 			Output:  fmt.Sprintf("Search failed: %v", err),
 			IsError: true,
 		}, nil
 	}
+	t.cache.put(cacheKey, results)
+
+	return formatSearchResponse(results, false), nil
+}
 
-	// Format response
+// formatSearchResponse renders results as the tool's JSON output
+func formatSearchResponse(results []SearchResult, cached bool) *Result {
 	response := SearchResponse{
 		Results: results,
 		Count:   len(results),
+		Cached:  cached,
 	}
 
 	jsonBytes, err := json.Marshal(response)
@@ -147,13 +180,13 @@ func (t *WebSearchTool) Execute(ctx context.Context, params json.RawMessage) (*R
 		return &Result{
 			Output:  fmt.Sprintf("Failed to format response: %v", err),
 			IsError: true,
-		}, nil
+		}
 	}
 
 	return &Result{
 		Output:  string(jsonBytes),
 		IsError: false,
-	}, nil
+	}
 }
 
 // searchDuckDuckGo performs the actual DuckDuckGo search
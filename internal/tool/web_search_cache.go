@@ -0,0 +1,111 @@
+package tool
+
+import (
+	"container/list"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultSearchCacheSize is the number of queries web_search keeps cached
+	DefaultSearchCacheSize = 32
+	// SearchCacheTTL is how long a cached search result stays valid
+	SearchCacheTTL = 10 * time.Minute
+)
+
+// searchCache is a thread-safe LRU cache of search results whose entries expire after ttl
+type searchCache struct {
+	mu      sync.Mutex
+	size    int // Maximum entries (0 = caching disabled)
+	ttl     time.Duration
+	now     func() time.Time
+	order   *list.List // Front = most recently used
+	entries map[string]*list.Element
+}
+
+// searchCacheEntry is an element of searchCache.order
+type searchCacheEntry struct {
+	key     string
+	results []SearchResult
+	expires time.Time
+}
+
+func newSearchCache(size int, ttl time.Duration) *searchCache {
+	return &searchCache{
+		size:    size,
+		ttl:     ttl,
+		now:     time.Now,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+// searchCacheKey normalizes a query (case and whitespace) together with the result limit
+func searchCacheKey(query string, maxResults int) string {
+	return fmt.Sprintf("%d:%s", maxResults, strings.Join(strings.Fields(strings.ToLower(query)), " "))
+}
+
+// get returns the cached results for key if present and not expired
+func (c *searchCache) get(key string) ([]SearchResult, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*searchCacheEntry)
+	if !c.now().Before(entry.expires) {
+		c.order.Remove(elem)
+		delete(c.entries, key)
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	return entry.results, true
+}
+
+// put stores results for key, evicting the least recently used entry when full
+func (c *searchCache) put(key string, results []SearchResult) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.size <= 0 {
+		return
+	}
+	expires := c.now().Add(c.ttl)
+	if elem, ok := c.entries[key]; ok {
+		entry := elem.Value.(*searchCacheEntry)
+		entry.results, entry.expires = results, expires
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&searchCacheEntry{key: key, results: results, expires: expires})
+	c.evict()
+}
+
+// resize changes the maximum number of entries (0 disables caching)
+func (c *searchCache) resize(size int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.size = max(size, 0)
+	c.evict()
+}
+
+// len returns the number of cached entries
+func (c *searchCache) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// evict drops least recently used entries beyond size (caller must hold mu)
+func (c *searchCache) evict() {
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*searchCacheEntry).key)
+	}
+}
@@ -0,0 +1,128 @@
+package tool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newStubWebSearchTool returns a tool whose searches are answered by a stub
+// that counts its calls instead of contacting DuckDuckGo
+func newStubWebSearchTool() (*WebSearchTool, *int) {
+	calls := 0
+	t := NewWebSearchTool()
+	t.search = func(ctx context.Context, query string, maxResults int) ([]SearchResult, error) {
+		calls++
+		return []SearchResult{{Title: "Result for " + query, URL: "https://example.com"}}, nil
+	}
+	return t, &calls
+}
+
+func searchFor(t *testing.T, tool *WebSearchTool, query string) SearchResponse {
+	t.Helper()
+	params, _ := json.Marshal(map[string]string{"query": query})
+	result, err := tool.Execute(context.Background(), params)
+	if err != nil || result.IsError {
+		t.Fatalf("Execute(%q) failed: %v %s", query, err, result.Output)
+	}
+	var resp SearchResponse
+	if err := json.Unmarshal([]byte(result.Output), &resp); err != nil {
+		t.Fatalf("invalid output %q: %v", result.Output, err)
+	}
+	return resp
+}
+
+func TestWebSearch_CachesRepeatedQuery(t *testing.T) {
+	search, calls := newStubWebSearchTool()
+
+	first := searchFor(t, search, "golang generics")
+	if first.Cached || *calls != 1 {
+		t.Fatalf("first query: cached=%v calls=%d, want a network call", first.Cached, *calls)
+	}
+
+	// Same query up to case and whitespace
+	second := searchFor(t, search, "  Golang   GENERICS ")
+	if *calls != 1 {
+		t.Errorf("second identical query made %d network calls, want 1", *calls)
+	}
+	if !second.Cached || second.Count != 1 || second.Results[0].Title != "Result for golang generics" {
+		t.Errorf("second query = %+v, want the cached result", second)
+	}
+	if search.queryCount != 1 {
+		t.Errorf("queryCount = %d, cache hits should not count against the rate limit", search.queryCount)
+	}
+}
+
+func TestWebSearch_CacheExpires(t *testing.T) {
+	search, calls := newStubWebSearchTool()
+	now := time.Now()
+	search.cache.now = func() time.Time { return now }
+
+	searchFor(t, search, "query")
+	now = now.Add(SearchCacheTTL - time.Second)
+	if resp := searchFor(t, search, "query"); !resp.Cached || *calls != 1 {
+		t.Fatalf("query within TTL: cached=%v calls=%d", resp.Cached, *calls)
+	}
+
+	now = now.Add(2 * time.Second)
+	search.lastQueryTime = time.Time{} // Skip the 2-second rate limit wait
+	if resp := searchFor(t, search, "query"); resp.Cached || *calls != 2 {
+		t.Errorf("query after TTL: cached=%v calls=%d, want a new network call", resp.Cached, *calls)
+	}
+}
+
+func TestWebSearch_FailuresAreNotCached(t *testing.T) {
+	search := NewWebSearchTool()
+	calls := 0
+	search.search = func(ctx context.Context, query string, maxResults int) ([]SearchResult, error) {
+		calls++
+		return nil, errors.New("network down")
+	}
+
+	params := json.RawMessage(`{"query": "flaky"}`)
+	search.Execute(context.Background(), params)
+	search.lastQueryTime = time.Time{}
+	result, _ := search.Execute(context.Background(), params)
+	if calls != 2 || !result.IsError || !strings.Contains(result.Output, "network down") {
+		t.Errorf("calls = %d, output = %q; failures should be retried, not cached", calls, result.Output)
+	}
+}
+
+func TestSearchCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	cache := newSearchCache(2, time.Minute)
+	cache.put("a", []SearchResult{{Title: "a"}})
+	cache.put("b", []SearchResult{{Title: "b"}})
+	cache.get("a") // a is now more recent than b
+	cache.put("c", []SearchResult{{Title: "c"}})
+
+	if _, ok := cache.get("b"); ok {
+		t.Error("b should have been evicted")
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, ok := cache.get(key); !ok {
+			t.Errorf("%s should still be cached", key)
+		}
+	}
+
+	cache.resize(1)
+	if cache.len() != 1 {
+		t.Errorf("len after resize(1) = %d, want 1", cache.len())
+	}
+	cache.resize(0)
+	cache.put("d", nil)
+	if cache.len() != 0 {
+		t.Errorf("len with caching disabled = %d, want 0", cache.len())
+	}
+}
+
+func TestSearchCacheKey(t *testing.T) {
+	if searchCacheKey("Go  Modules", 10) != searchCacheKey("go modules", 10) {
+		t.Error("keys should ignore case and extra whitespace")
+	}
+	if searchCacheKey("go", 10) == searchCacheKey("go", 5) {
+		t.Error("keys should include max_results")
+	}
+}
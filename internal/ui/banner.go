@@ -23,10 +23,20 @@ type BannerOptions struct {
 	CWD           string
 	ChainInfo     string // プロバイダーチェーン情報（例: "Ollama→main / OpenAI→fallback"）
 	OllamaNumCtx  int    // Ollama num_ctx override (0=default)
+	Mode          string // config.BannerFull / BannerMinimal / BannerNone（空はfull扱い）
 }
 
 // ShowBanner 起動時バナーを表示（Python版準拠）
+// Mode が minimal なら1行、none なら何も表示しない
 func (t *Terminal) ShowBanner(opts BannerOptions) {
+	switch opts.Mode {
+	case config.BannerNone:
+		return
+	case config.BannerMinimal:
+		t.showMinimalBanner(opts)
+		return
+	}
+
 	// ASCII art ロゴ
 	t.PrintColored(ColorCyan, `  ██╗   ██╗██╗██████╗ ███████╗     ██╗      ██████╗  ██████╗ █████╗ ██╗
   ██║   ██║██║██╔══██╗██╔════╝     ██║     ██╔═══██╗██╔════╝██╔══██╗██║
@@ -44,6 +54,12 @@ func (t *Terminal) ShowBanner(opts BannerOptions) {
 		t.PrintColored(ColorGray, fmt.Sprintf("  v%s  // Powered by %s\n", opts.Version, providerDisplayName(opts.Provider)))
 	}
 
+	t.ShowBannerInfo(opts)
+}
+
+// ShowBannerInfo バナーのステータス部分（モデル・モード・エンジン等）のみを表示
+// バナーを抑制している場合でも /status から同じ情報を確認できる
+func (t *Terminal) ShowBannerInfo(opts BannerOptions) {
 	// ステータス区切り線
 	t.PrintColored(ColorGray, "  "+strings.Repeat("─", 48)+"\n")
 
@@ -103,6 +119,12 @@ func (t *Terminal) ShowBanner(opts BannerOptions) {
 	t.PrintColored(ColorGray, "  "+strings.Repeat("─", 48)+"\n")
 }
 
+// showMinimalBanner プロバイダーとモデルのみを1行で表示
+func (t *Terminal) showMinimalBanner(opts BannerOptions) {
+	t.PrintColored(ColorCyan, fmt.Sprintf("%s %s", providerIcon(opts.Provider), providerDisplayName(opts.Provider)))
+	t.Printf(" • %s\n", opts.ModelName)
+}
+
 // providerDisplayName プロバイダーの表示名を返す
 func providerDisplayName(provider string) string {
 	names := map[string]string{
@@ -0,0 +1,82 @@
+package ui
+
+import (
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/zephel01/vibe-local-go/internal/config"
+)
+
+// captureStdout fn 実行中に標準出力へ書かれた内容を返す
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("os.Pipe: %v", err)
+	}
+	orig := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = orig }()
+
+	fn()
+
+	w.Close()
+	out, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("read captured output: %v", err)
+	}
+	return string(out)
+}
+
+func testBannerOptions(mode string) BannerOptions {
+	return BannerOptions{
+		Version:       "1.0.0",
+		ModelName:     "qwen3:8b",
+		ContextWindow: 32768,
+		Provider:      "ollama",
+		EngineHost:    "http://localhost:11434",
+		CWD:           "/tmp/project",
+		Mode:          mode,
+	}
+}
+
+func TestShowBanner_None(t *testing.T) {
+	term := &Terminal{}
+	out := captureStdout(t, func() {
+		term.ShowBanner(testBannerOptions(config.BannerNone))
+	})
+	if out != "" {
+		t.Errorf("expected no banner output, got %q", out)
+	}
+}
+
+func TestShowBanner_Minimal(t *testing.T) {
+	term := &Terminal{}
+	out := captureStdout(t, func() {
+		term.ShowBanner(testBannerOptions(config.BannerMinimal))
+	})
+
+	lines := strings.Split(strings.TrimRight(out, "\n"), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("expected 1 line, got %d: %q", len(lines), out)
+	}
+	if !strings.Contains(lines[0], "Ollama") || !strings.Contains(lines[0], "qwen3:8b") {
+		t.Errorf("minimal banner should show provider and model, got %q", lines[0])
+	}
+}
+
+func TestShowBanner_Full(t *testing.T) {
+	term := &Terminal{}
+	out := captureStdout(t, func() {
+		term.ShowBanner(testBannerOptions(config.BannerFull))
+	})
+
+	for _, want := range []string{"qwen3:8b", "/tmp/project", "http://localhost:11434"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("full banner missing %q", want)
+		}
+	}
+}
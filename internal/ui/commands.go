@@ -17,6 +17,7 @@ type CommandHandler struct {
 	terminal   *Terminal
 	commands   map[string]*SlashCommand
 	aliases    map[string]string // エイリアス: "exit" -> "quit"
+	submitted  *string           // コマンド実行後にエージェントへ送る入力（/retry など）
 }
 
 // NewCommandHandler 新しいコマンドハンドラを作成
@@ -116,6 +117,21 @@ func (ch *CommandHandler) SetAlias(alias, target string) {
 	ch.aliases[alias] = target
 }
 
+// Submit コマンド実行後にエージェントへ送る入力を予約する（/retry など）
+func (ch *CommandHandler) Submit(input string) {
+	ch.submitted = &input
+}
+
+// TakeSubmitted 予約された入力を取り出す（取り出すと予約は消える）
+func (ch *CommandHandler) TakeSubmitted() (string, bool) {
+	if ch.submitted == nil {
+		return "", false
+	}
+	input := *ch.submitted
+	ch.submitted = nil
+	return input, true
+}
+
 // CommandNames 登録済みコマンド名の一覧を返す（"/" 付き）
 func (ch *CommandHandler) CommandNames() []string {
 	names := make([]string, 0, len(ch.commands))
@@ -166,26 +182,49 @@ func (ch *CommandHandler) ShowHelp() {
 	ch.terminal.PrintColored(ColorCyan, "  ━━ Commands ━━━━━━━━━━━━━━━━━━━━━━━━\n")
 	ch.terminal.Printf("  /help              ヘルプを表示\n")
 	ch.terminal.Printf("  /exit, /quit, /q   終了\n")
-	ch.terminal.Printf("  /clear             会話をクリア\n")
+	ch.terminal.Printf("  /clear [-y]        会話をクリア（-y で確認なし）\n")
 	ch.terminal.Printf("  /model <name>      モデルを切替\n")
+	ch.terminal.Printf("  /model info        コンテキスト長・対応機能などモデルの詳細を表示\n")
 	ch.terminal.Printf("  /models            モデル一覧・選択切替\n")
+	ch.terminal.Printf("  /sidecar [name]    サイドカーモデルを表示・設定（off で解除）\n")
+	ch.terminal.Printf("  /ollama [set ...]  Ollama の num_ctx / num_gpu を表示・変更\n")
 	ch.terminal.Printf("  /status            セッション情報\n")
 	ch.terminal.Printf("  /save              セッションを保存\n")
 	ch.terminal.Printf("  /tokens            トークン使用量を表示\n")
+	ch.terminal.Printf("  /usage             累計トークン数とコストの概算を表示\n")
+	ch.terminal.Printf("  /cost              コストの概算とクラウドのトークン予算を表示\n")
 	ch.terminal.Printf("  /init              CLAUDE.md テンプレート作成\n")
+	ch.terminal.Printf("  /undo              直前のファイル書き込みを取り消す\n")
+	ch.terminal.Printf("  /redo              /undo で取り消した変更をやり直す\n")
+	ch.terminal.Printf("  /search <query>    保存済みセッションを検索\n")
+	ch.terminal.Printf("  /summarize         会話を要約して履歴を置き換え\n")
+	ch.terminal.Printf("  /compact [mode]    履歴を圧縮（aggressive で最新のやり取り以外を圧縮）\n")
+	ch.terminal.Printf("  /snapshot [name]   会話状態をメモリに保存（list で一覧）\n")
+	ch.terminal.Printf("  /restore [name]    スナップショットに戻す（省略時は直近）\n")
+	ch.terminal.Printf("  /choices <N>       次の応答で N 個の候補から選択\n")
+	ch.terminal.Printf("  /save-output [f]   直近の出力をファイルに保存（N で末尾N行）\n")
+	ch.terminal.Printf("  /trace             直近のターンのツール呼び出しを順に表示\n")
+	ch.terminal.Printf("  /retry [keep]      直前のメッセージを再実行（keep で失敗したやり取りを残す）\n")
+	ch.terminal.Printf("  /export <file>     会話を Markdown で保存（--format json で JSON）\n")
 	ch.terminal.Printf("  /yes               自動承認 ON\n")
 	ch.terminal.Printf("  /no                自動承認 OFF\n")
 	ch.terminal.Printf("  /config            設定を表示\n")
+	ch.terminal.Printf("  /config effective  各設定値と出どころ (default/config/env/flag) を表示\n")
 	ch.terminal.Printf("  /debug             デバッグモード切替\n")
 	ch.terminal.Printf("  /provider          プロバイダー管理（追加・編集・削除）\n")
 	ch.terminal.Printf("  /providers         プロバイダー接続状況・一覧表示\n")
+	ch.terminal.Printf("  /providers refresh ローカルプロバイダーを再検出してチェーンに追加\n")
+	ch.terminal.Printf("  /providers --force 接続を再確認（通常は15秒間 結果を再利用）\n")
 	ch.terminal.Printf("  /switch            プロバイダー切替\n")
 	ch.terminal.Printf("  \"\"\"                複数行入力（\"\"\"で終了）\n")
 	ch.terminal.Printf("  行末 \\             次の行に継続\n")
 	ch.terminal.PrintColored(ColorCyan, "  ━━ Skills ━━━━━━━━━━━━━━━━━━━━━━━━━\n")
 	ch.terminal.Printf("  /skills            スキル一覧を表示\n")
+	ch.terminal.Printf("  /skill <name|off>  スキルを以降の会話に読み込む / 解除\n")
 	ch.terminal.PrintColored(ColorCyan, "  ━━ MCP ━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n")
 	ch.terminal.Printf("  /mcp               MCPサーバー状況・ツール一覧\n")
+	ch.terminal.Printf("  /mcp restart <n>   MCPサーバーを再起動\n")
+	ch.terminal.Printf("  /tools             ツール一覧と利用可否（依存不足は ⚠）\n")
 	ch.terminal.PrintColored(ColorCyan, "  ━━ Web Tools ━━━━━━━━━━━━━━━━━━━━━━\n")
 	ch.terminal.Printf("  /web_fetch <url>   ウェブページを取得（HTML→テキスト変換）\n")
 	ch.terminal.Printf("  /web_search <q>    DuckDuckGoで検索\n")
@@ -193,6 +232,8 @@ func (ch *CommandHandler) ShowHelp() {
 	ch.terminal.Printf("  /autotest [on|off] ファイル編集後の自動テスト\n")
 	ch.terminal.PrintColored(ColorCyan, "  ━━ Plan Mode ━━━━━━━━━━━━━━━━━━━━━━\n")
 	ch.terminal.Printf("  /plan [on|off]     計画モード（ON時は書込み禁止）\n")
+	ch.terminal.Printf("  /dryrun [on|off]   ツール呼び出しを実行せずに表示\n")
+	ch.terminal.Printf("  /loopdetect [on|off] 同じツール呼び出しの繰り返し検出\n")
 	ch.terminal.PrintColored(ColorCyan, "  ━━ Sandbox ━━━━━━━━━━━━━━━━━━━━━━━━\n")
 	ch.terminal.Printf("  /sandbox [on|off]  サンドボックス切替\n")
 	ch.terminal.Printf("  /commit [file]     ステージを本番に反映\n")
@@ -201,6 +242,7 @@ func (ch *CommandHandler) ShowHelp() {
 	ch.terminal.Printf("  /staged            ステージ一覧\n")
 	ch.terminal.PrintColored(ColorCyan, "  ━━ File Watch ━━━━━━━━━━━━━━━━━━━━━\n")
 	ch.terminal.Printf("  /watch <pattern>   ファイル監視開始 (e.g. *.go)\n")
+	ch.terminal.Printf("  /watch --debounce N 同一ファイルの連続変更をまとめる間隔 (ms、既定 300)\n")
 	ch.terminal.Printf("  /watch             監視状態を表示\n")
 	ch.terminal.Printf("  /watch off         ファイル監視を停止\n")
 	ch.terminal.PrintColored(ColorCyan, "  ━━ Provider Chain ━━━━━━━━━━━━━━━━━\n")
@@ -209,8 +251,9 @@ func (ch *CommandHandler) ShowHelp() {
 	ch.terminal.PrintColored(ColorCyan, "  ━━ Keyboard ━━━━━━━━━━━━━━━━━━━━━━━\n")
 	ch.terminal.Printf("  Ctrl+J / Alt+Enter 改行を挿入（複数行入力）\n")
 	ch.terminal.Printf("  Enter              入力を送信\n")
-	ch.terminal.Printf("  Ctrl+C             現在のタスクを停止\n")
-	ch.terminal.Printf("  Ctrl+C x2          終了 (1.5秒以内)\n")
+	ch.terminal.Printf("  Ctrl+C             現在のターンを中断（プロンプトに戻る）\n")
+	ch.terminal.Printf("  Ctrl+C x2          終了 (2秒以内)\n")
+	ch.terminal.Printf("  Ctrl+\\             実行中のツールだけを中断（ターンは継続）\n")
 	ch.terminal.Printf("  Ctrl+D             終了\n")
 	ch.terminal.Printf("  ↑/↓               入力履歴（複数行時は行内移動）\n")
 	ch.terminal.Printf("  Tab                コマンド補完\n")
@@ -221,7 +264,11 @@ func (ch *CommandHandler) ShowHelp() {
 	ch.terminal.Printf("  --resume <id>      セッション指定復旧\n")
 	ch.terminal.Printf("  --model NAME       モデル指定\n")
 	ch.terminal.Printf("  --list-sessions    セッション一覧\n")
+	ch.terminal.Printf("  --search-sessions Q セッション検索\n")
 	ch.terminal.Printf("  -p \"prompt\"        ワンショットモード\n")
+	ch.terminal.Printf("  --minimal          バナーを1行表示（プロバイダー/モデルのみ）\n")
+	ch.terminal.Printf("  --no-banner        バナーとウェルカムを非表示\n")
+	ch.terminal.Printf("  --json-output      -p の結果をJSONで出力（他の出力はstderr）\n")
 	ch.terminal.PrintColored(ColorCyan, "  ━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n")
 }
 
@@ -0,0 +1,154 @@
+package ui
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// secretHistoryKeywords これを含む入力は履歴ファイルに保存しない（小文字で比較）
+var secretHistoryKeywords = []string{
+	"password", "passwd", "bearer ",
+}
+
+// secretHistoryPatterns キーやトークンそのものに見える文字列
+var secretHistoryPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)(api[_-]?key|token|secret|passw(or)?d)\s*[=:]`),      // KEY=value / key: value
+	regexp.MustCompile(`(?i)(^|\s)--?(api[_-]?key|token|secret|password)(\s|$)`), // --api-key value（--max-tokens 等は除く）
+	regexp.MustCompile(`sk-[A-Za-z0-9_-]{16,}`),                                  // OpenAI / Anthropic 等の API キー
+	regexp.MustCompile(`gh[pousr]_[A-Za-z0-9]{20,}`),                             // GitHub トークン
+	regexp.MustCompile(`xox[abpr]-[A-Za-z0-9-]{10,}`),                            // Slack トークン
+	regexp.MustCompile(`AKIA[0-9A-Z]{16}`),                                       // AWS アクセスキー
+	regexp.MustCompile(`AIza[0-9A-Za-z_-]{35}`),                                  // Google API キー
+}
+
+// isSecretHistoryLine 入力が API キーやトークンを含みそうかを判定する
+func isSecretHistoryLine(line string) bool {
+	lower := strings.ToLower(line)
+	for _, kw := range secretHistoryKeywords {
+		if strings.Contains(lower, kw) {
+			return true
+		}
+	}
+	for _, re := range secretHistoryPatterns {
+		if re.MatchString(line) {
+			return true
+		}
+	}
+	return false
+}
+
+// LoadHistory 履歴ファイルを読み込んで入力履歴に追加する（ファイルがなければ何もしない）。
+// 重複は新しい方だけを残し、maxHistory 件に制限する
+func (le *LineEditor) LoadHistory(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	defer f.Close()
+
+	var loaded []string
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if line := decodeHistoryLine(scanner.Text()); line != "" {
+			loaded = append(loaded, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	le.history = dedupeHistory(append(loaded, le.history...), le.maxHistory)
+	le.historyIndex = len(le.history)
+	return nil
+}
+
+// SaveHistory 入力履歴をファイルに書き出す。
+// API キーやトークンを含みそうな入力は保存しない
+func (le *LineEditor) SaveHistory(path string) error {
+	entries := make([]string, 0, len(le.history))
+	for _, line := range le.history {
+		if strings.TrimSpace(line) == "" || isSecretHistoryLine(line) {
+			continue
+		}
+		entries = append(entries, line)
+	}
+	entries = dedupeHistory(entries, le.maxHistory)
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	var sb strings.Builder
+	for _, line := range entries {
+		sb.WriteString(encodeHistoryLine(line))
+		sb.WriteByte('\n')
+	}
+	// 途中で終了しても既存の履歴を壊さないよう一時ファイルから置き換える
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(sb.String()), 0600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// dedupeHistory 同じ入力は最後の 1 件だけを残し、新しい方から max 件に制限する
+func dedupeHistory(history []string, max int) []string {
+	seen := make(map[string]bool, len(history))
+	reversed := make([]string, 0, len(history))
+	for i := len(history) - 1; i >= 0; i-- {
+		line := history[i]
+		if seen[line] {
+			continue
+		}
+		seen[line] = true
+		reversed = append(reversed, line)
+		if max > 0 && len(reversed) >= max {
+			break
+		}
+	}
+	result := make([]string, len(reversed))
+	for i, line := range reversed {
+		result[len(reversed)-1-i] = line
+	}
+	return result
+}
+
+// encodeHistoryLine 複数行の入力を 1 行にする（\ → \\, 改行 → \n）
+func encodeHistoryLine(line string) string {
+	line = strings.ReplaceAll(line, `\`, `\\`)
+	return strings.ReplaceAll(line, "\n", `\n`)
+}
+
+// decodeHistoryLine encodeHistoryLine の逆変換
+func decodeHistoryLine(line string) string {
+	var sb strings.Builder
+	escaped := false
+	for _, r := range line {
+		switch {
+		case escaped && r == 'n':
+			sb.WriteByte('\n')
+			escaped = false
+		case escaped:
+			sb.WriteRune(r)
+			escaped = false
+		case r == '\\':
+			escaped = true
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	if escaped {
+		sb.WriteByte('\\')
+	}
+	return sb.String()
+}
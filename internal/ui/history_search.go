@@ -0,0 +1,176 @@
+package ui
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+// historySearch Ctrl+R のインクリメンタル逆方向検索の状態
+type historySearch struct {
+	history []string // 古い順の入力履歴
+	query   []rune   // 検索文字列
+	index   int      // 現在の一致の履歴インデックス（-1 = 一致なし）
+	failed  bool     // 直前の検索で一致が見つからなかった
+}
+
+// newHistorySearch 履歴の逆方向検索を開始する
+func newHistorySearch(history []string) *historySearch {
+	return &historySearch{history: history, index: -1}
+}
+
+// match 現在の一致を返す
+func (s *historySearch) match() (string, bool) {
+	if s.index < 0 {
+		return "", false
+	}
+	return s.history[s.index], true
+}
+
+// find from から古い方へ query を含む履歴を探す。skip と同じ内容の履歴は飛ばす
+func (s *historySearch) find(from int, skip string) bool {
+	if len(s.query) == 0 {
+		return false
+	}
+	q := string(s.query)
+	for i := from; i >= 0; i-- {
+		entry := s.history[i]
+		if entry != skip && strings.Contains(entry, q) {
+			s.index = i
+			return true
+		}
+	}
+	return false
+}
+
+// setQuery 検索文字列を変更し、現在の一致（なければ最新の履歴）から古い方へ探し直す
+func (s *historySearch) setQuery(query []rune) {
+	s.query = query
+	if len(query) == 0 {
+		s.index = -1
+		s.failed = false
+		return
+	}
+	from := len(s.history) - 1
+	if s.index >= 0 {
+		from = s.index
+	}
+	s.failed = !s.find(from, "")
+}
+
+// appendQuery 検索文字列の末尾に入力を追加する
+func (s *historySearch) appendQuery(text string) {
+	s.setQuery(append(append([]rune(nil), s.query...), []rune(text)...))
+}
+
+// backspace 検索文字列の末尾を 1 文字削除し、最新の履歴から探し直す
+func (s *historySearch) backspace() {
+	if len(s.query) == 0 {
+		return
+	}
+	s.index = -1
+	s.setQuery(s.query[:len(s.query)-1])
+}
+
+// next 繰り返しの Ctrl+R: 現在の一致より古い次の一致に進む（なければ現在の一致に留まる）
+func (s *historySearch) next() {
+	cur, ok := s.match()
+	from := len(s.history) - 1
+	if ok {
+		from = s.index - 1
+	}
+	s.failed = !s.find(from, cur)
+}
+
+// prompt 検索中に表示するプロンプト
+func (s *historySearch) prompt() string {
+	if s.failed {
+		return fmt.Sprintf("(failed reverse-i-search)`%s': ", string(s.query))
+	}
+	return fmt.Sprintf("(reverse-i-search)`%s': ", string(s.query))
+}
+
+// searchAction 検索中の入力に対する動作
+type searchAction int
+
+const (
+	searchContinue searchAction = iota // 検索を続ける
+	searchAccept                       // 一致を確定して通常の編集に戻る
+	searchCancel                       // 元の入力に戻る
+)
+
+// pasteStartMarker ブラケットペースト開始シーケンス ESC[200~
+var pasteStartMarker = []byte("\x1b[200~")
+
+// handleInput 検索中に 1 回の読み込みで届いた入力を処理する。
+// ブラケットペーストが始まった場合は確定して検索を抜け、ペースト全体を pending として返す
+// （呼び出し元が通常のペーストとして処理する）
+func (s *historySearch) handleInput(in []byte) (action searchAction, pending []byte) {
+	switch {
+	case bytes.HasPrefix(in, pasteStartMarker):
+		return searchAccept, in
+	case in[0] == 18: // Ctrl+R: 次の一致
+		s.next()
+	case in[0] == 7 || in[0] == 3: // Ctrl+G / Ctrl+C: キャンセル
+		return searchCancel, nil
+	case in[0] == 27: // Escape 単体はキャンセル、矢印などのシーケンスは確定
+		if len(in) == 1 {
+			return searchCancel, nil
+		}
+		return searchAccept, nil
+	case in[0] == 127 || in[0] == 8: // Backspace
+		s.backspace()
+	case in[0] < 32: // Enter や他の編集キー: 確定して通常の編集に戻る
+		return searchAccept, nil
+	default:
+		if text := string(in); utf8.ValidString(text) {
+			s.appendQuery(text)
+		}
+	}
+	return searchContinue, nil
+}
+
+// reverseSearch raw モードで Ctrl+R の検索を行い、確定後の入力バッファを返す。
+// Enter や他の編集キーで一致を確定し、Ctrl+G / Escape / Ctrl+C で元の入力に戻る。
+// 検索を抜けたときに読んだが処理していない入力（ペースト）は pending で返す
+func (le *LineEditor) reverseSearch(buf []rune, cursor int) ([]rune, int, []byte) {
+	s := newHistorySearch(le.history)
+	render := func() {
+		if m, ok := s.match(); ok {
+			r := []rune(m)
+			pos := len(r)
+			if i := strings.Index(m, string(s.query)); i >= 0 {
+				pos = utf8.RuneCountInString(m[:i])
+			}
+			le.redrawMultiLine(s.prompt(), r, pos)
+			return
+		}
+		le.redrawMultiLine(s.prompt(), nil, 0)
+	}
+	render()
+
+	for {
+		b := make([]byte, 4096) // ペーストの先頭が届いても取りこぼさない大きさ
+		n, err := os.Stdin.Read(b)
+		if err != nil {
+			return buf, cursor, nil
+		}
+		if n == 0 {
+			continue
+		}
+
+		switch action, pending := s.handleInput(b[:n]); action {
+		case searchAccept:
+			if m, ok := s.match(); ok {
+				r := []rune(m)
+				return r, len(r), pending
+			}
+			return buf, cursor, pending
+		case searchCancel:
+			return buf, cursor, nil
+		}
+		render()
+	}
+}
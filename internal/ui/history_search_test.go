@@ -0,0 +1,80 @@
+package ui
+
+import "testing"
+
+func TestHistorySearch_CyclesMatches(t *testing.T) {
+	history := []string{"git status", "go test ./...", "git commit -m wip", "ls", "git status", "go build"}
+	s := newHistorySearch(history)
+
+	// 入力するたびに最新の一致が表示される
+	s.appendQuery("g")
+	if m, _ := s.match(); m != "go build" {
+		t.Errorf("match for %q = %q, want %q", "g", m, "go build")
+	}
+	s.appendQuery("it")
+	if m, _ := s.match(); m != "git status" || s.index != 4 {
+		t.Errorf("match for %q = %q (index %d), want the newest git status", "git", m, s.index)
+	}
+
+	// Ctrl+R で古い一致へ進む（表示中と同じ内容の履歴は飛ばす）
+	want := []string{"git commit -m wip", "git status"}
+	for _, w := range want {
+		s.next()
+		if m, _ := s.match(); m != w {
+			t.Fatalf("next match = %q, want %q", m, w)
+		}
+	}
+	s.next()
+	if !s.failed {
+		t.Error("search past the oldest match should fail")
+	}
+	if m, _ := s.match(); m != "git status" || s.index != 0 {
+		t.Errorf("failed search should keep the last match, got %q", m)
+	}
+	if got := s.prompt(); got != "(failed reverse-i-search)`git': " {
+		t.Errorf("prompt = %q", got)
+	}
+
+	// 文字を消すと最新の履歴から探し直す
+	s.backspace()
+	if m, _ := s.match(); m != "git status" || s.failed {
+		t.Errorf("match after backspace = %q (failed %v), want %q", m, s.failed, "git status")
+	}
+}
+
+func TestHistorySearch_NoMatch(t *testing.T) {
+	s := newHistorySearch([]string{"ls", "pwd"})
+	if _, ok := s.match(); ok {
+		t.Error("empty query should not match")
+	}
+	s.appendQuery("docker")
+	if _, ok := s.match(); ok || !s.failed {
+		t.Errorf("query without a match: ok = %v, failed = %v", ok, s.failed)
+	}
+	s.next()
+	if _, ok := s.match(); ok {
+		t.Error("Ctrl+R without a match should stay empty")
+	}
+}
+
+func TestHistorySearch_PasteLeavesSearchWithoutConsumingInput(t *testing.T) {
+	s := newHistorySearch([]string{"git status"})
+	s.appendQuery("git")
+
+	in := []byte("\x1b[200~hello\nworld\x1b[201~")
+	action, pending := s.handleInput(in)
+	if action != searchAccept {
+		t.Errorf("action = %v, want searchAccept", action)
+	}
+	if string(pending) != string(in) {
+		t.Errorf("pending = %q, want the whole paste %q", pending, in)
+	}
+
+	// 矢印などの他のシーケンスは確定するだけで入力を残さない
+	if action, pending := s.handleInput([]byte("\x1b[C")); action != searchAccept || pending != nil {
+		t.Errorf("arrow key = (%v, %q), want accept without pending input", action, pending)
+	}
+	if action, _ := s.handleInput([]byte{27}); action != searchCancel {
+		t.Errorf("lone Escape = %v, want searchCancel", action)
+	}
+}
@@ -0,0 +1,78 @@
+package ui
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// DoubleInterruptWindow 2回目の Ctrl+C で終了とみなす間隔
+const DoubleInterruptWindow = 2 * time.Second
+
+// InterruptAction Ctrl+C を受けたときに行う処理
+type InterruptAction int
+
+const (
+	// InterruptNone Ctrl+C は押されていない
+	InterruptNone InterruptAction = iota
+	// InterruptCancelTurn 実行中のターンを中断した（プロンプトに戻る）
+	InterruptCancelTurn
+	// InterruptArmed 中断するターンがない（window 内にもう一度押すと終了）
+	InterruptArmed
+	// InterruptExit window 内の2回目：終了する
+	InterruptExit
+)
+
+// TurnInterrupter Ctrl+C の状態管理。1回目は実行中のターンだけを中断し、
+// window 内に続けて押されたら終了を返す
+type TurnInterrupter struct {
+	mu         sync.Mutex
+	window     time.Duration
+	now        func() time.Time
+	lastPress  time.Time
+	cancelTurn context.CancelFunc
+}
+
+// NewTurnInterrupter 新しい TurnInterrupter を作成（window <= 0 で DoubleInterruptWindow）
+func NewTurnInterrupter(window time.Duration) *TurnInterrupter {
+	if window <= 0 {
+		window = DoubleInterruptWindow
+	}
+	return &TurnInterrupter{window: window, now: time.Now}
+}
+
+// BeginTurn parent から1ターン分のコンテキストを作る。ターンが終わったら end を呼ぶ
+func (t *TurnInterrupter) BeginTurn(parent context.Context) (ctx context.Context, end func()) {
+	ctx, cancel := context.WithCancel(parent)
+
+	t.mu.Lock()
+	t.cancelTurn = cancel
+	t.mu.Unlock()
+
+	return ctx, func() {
+		t.mu.Lock()
+		t.cancelTurn = nil
+		t.mu.Unlock()
+		cancel()
+	}
+}
+
+// Interrupt Ctrl+C を1回処理し、行った処理を返す
+func (t *TurnInterrupter) Interrupt() InterruptAction {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	if !t.lastPress.IsZero() && now.Sub(t.lastPress) <= t.window {
+		t.lastPress = time.Time{}
+		return InterruptExit
+	}
+	t.lastPress = now
+
+	if t.cancelTurn != nil {
+		t.cancelTurn()
+		t.cancelTurn = nil
+		return InterruptCancelTurn
+	}
+	return InterruptArmed
+}
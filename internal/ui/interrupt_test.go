@@ -0,0 +1,84 @@
+package ui
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// newTestInterrupter returns an interrupter whose clock is advanced by the returned func
+func newTestInterrupter() (*TurnInterrupter, func(time.Duration)) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	ti := NewTurnInterrupter(DoubleInterruptWindow)
+	ti.now = func() time.Time { return now }
+	return ti, func(d time.Duration) { now = now.Add(d) }
+}
+
+func TestTurnInterrupter_FirstPressCancelsTurnOnly(t *testing.T) {
+	ti, _ := newTestInterrupter()
+	root, cancelRoot := context.WithCancel(context.Background())
+	defer cancelRoot()
+
+	turnCtx, end := ti.BeginTurn(root)
+	if got := ti.Interrupt(); got != InterruptCancelTurn {
+		t.Fatalf("first press = %v, want InterruptCancelTurn", got)
+	}
+	if turnCtx.Err() == nil {
+		t.Error("the turn context should be cancelled")
+	}
+	if root.Err() != nil {
+		t.Error("the root context must survive a turn cancel")
+	}
+	end()
+}
+
+func TestTurnInterrupter_DoubleTapExits(t *testing.T) {
+	ti, advance := newTestInterrupter()
+	_, end := ti.BeginTurn(context.Background())
+	defer end()
+
+	ti.Interrupt()
+	advance(DoubleInterruptWindow - time.Millisecond)
+	if got := ti.Interrupt(); got != InterruptExit {
+		t.Errorf("second press within the window = %v, want InterruptExit", got)
+	}
+}
+
+func TestTurnInterrupter_SlowSecondPressDoesNotExit(t *testing.T) {
+	ti, advance := newTestInterrupter()
+
+	if got := ti.Interrupt(); got != InterruptArmed {
+		t.Fatalf("press without a turn = %v, want InterruptArmed", got)
+	}
+	advance(DoubleInterruptWindow + time.Millisecond)
+
+	// A new turn started after the window: the press cancels it again
+	turnCtx, end := ti.BeginTurn(context.Background())
+	if got := ti.Interrupt(); got != InterruptCancelTurn {
+		t.Errorf("press after the window = %v, want InterruptCancelTurn", got)
+	}
+	if turnCtx.Err() == nil {
+		t.Error("the new turn should be cancelled")
+	}
+	end()
+
+	// The exit resets the state: the next press starts over
+	advance(time.Second)
+	if got := ti.Interrupt(); got != InterruptExit {
+		t.Fatalf("press 1s later = %v, want InterruptExit", got)
+	}
+	if got := ti.Interrupt(); got != InterruptArmed {
+		t.Errorf("press after an exit = %v, want InterruptArmed", got)
+	}
+}
+
+func TestTurnInterrupter_EndedTurnIsNotCancelled(t *testing.T) {
+	ti, advance := newTestInterrupter()
+	_, end := ti.BeginTurn(context.Background())
+	end()
+
+	advance(time.Minute)
+	if got := ti.Interrupt(); got != InterruptArmed {
+		t.Errorf("press after the turn ended = %v, want InterruptArmed", got)
+	}
+}
@@ -1,10 +1,15 @@
 package ui
 
 import (
+	"bytes"
 	"fmt"
 	"os"
+	"os/signal"
+	"path/filepath"
 	"sort"
 	"strings"
+	"sync"
+	"unicode"
 	"unicode/utf8"
 
 	"golang.org/x/term"
@@ -13,12 +18,13 @@ import (
 // LineEditor インタラクティブなライン編集機能（複数行対応）
 // - ←/→ カーソル移動
 // - ↑/↓ 複数行内移動 / 履歴ナビゲーション
-// - Tab スラッシュコマンド補完
+// - Tab スラッシュコマンド / ファイルパス補完
 // - Home/End カーソルジャンプ
 // - Ctrl+A/E 現在行の先頭/末尾 (Emacs風)
 // - Ctrl+U 行クリア
 // - Ctrl+W 単語削除
 // - Ctrl+K カーソル以降削除
+// - Ctrl+R 履歴のインクリメンタル逆方向検索
 // - Ctrl+J / Alt+Enter 改行挿入（複数行入力）
 // - Enter 入力確定・送信
 // - ブラケットペーストモード対応（複数行ペーストを正しく処理）
@@ -28,13 +34,25 @@ type LineEditor struct {
 	maxHistory    int
 	completions   []string // タブ補完候補（"/help", "/models" 等）
 	contPrompt    string   // 継続行のプロンプト（"... "）
+	onInterrupt   func()   // 入力中の Ctrl+C で呼ぶ（nil = 行クリアのみ）
 
 	// 描画状態追跡（redrawMultiLine で使用）
 	prevLineCount  int // 前回描画時の総行数
 	prevCursorLine int // 前回描画時のカーソル行（表示上の行番号）
+	width          int // 前回描画時のターミナル幅（0 = 不明）
+
+	// 編集中の表示内容（リサイズ時の再描画用、mu で保護）
+	mu          sync.Mutex
+	editing     bool
+	shownPrompt string
+	shownBuf    []rune
+	shownCursor int
 
 	// ブラケットペーストモード
-	pasteMode bool // true = ペースト中（CR/LFを改行文字として扱う）
+	pasteMode    bool   // true = ペースト中（CR/LFを改行文字として扱う）
+	pastePending []byte // 読み込みの境界で途切れた終了マーカー候補・UTF-8 の断片
+	pasteCR      bool   // 直前のペーストデータが CR で終わった（次の LF は CRLF の一部）
+	pasted       bool   // 今回の入力にペーストが含まれていた
 }
 
 // NewLineEditor 新しいLineEditorを作成
@@ -52,6 +70,12 @@ func (le *LineEditor) SetCompletions(completions []string) {
 	le.completions = completions
 }
 
+// SetInterruptHandler 入力中に Ctrl+C が押されたときに呼ぶ関数を設定する。
+// raw モード中に呼ばれるので、fn では出力せず状態の記録だけを行う
+func (le *LineEditor) SetInterruptHandler(fn func()) {
+	le.onInterrupt = fn
+}
+
 // AddHistory 履歴に追加
 func (le *LineEditor) AddHistory(line string) {
 	if line == "" {
@@ -127,6 +151,7 @@ func lineCount(buf []rune) int {
 // ReadLine プロンプトを表示してインタラクティブに入力を読む（複数行対応）
 func (le *LineEditor) ReadLine(prompt string) (string, error) {
 	fd := int(os.Stdin.Fd())
+	le.pasted = false
 
 	// ターミナルでなければ従来のbufio方式にフォールバック
 	if !term.IsTerminal(fd) {
@@ -146,9 +171,30 @@ func (le *LineEditor) ReadLine(prompt string) (string, error) {
 	savedInput := ""                  // 履歴ナビ前の入力を保存
 
 	// 描画状態をリセット
-	le.prevLineCount = 1
-	le.prevCursorLine = 0
-	le.pasteMode = false
+	le.beginEdit(prompt)
+	le.resetPaste()
+
+	// ウィンドウサイズ変更（SIGWINCH）で幅を取り直し、入力全体をきれいに再描画する
+	winch := make(chan os.Signal, 1)
+	notifyResize(winch)
+	stopResize := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-winch:
+				if w, ok := stdoutWidth(); ok {
+					le.handleResize(w)
+				}
+			case <-stopResize:
+				return
+			}
+		}
+	}()
+	defer func() {
+		signal.Stop(winch)
+		close(stopResize)
+		le.endEdit()
+	}()
 
 	// ブラケットペーストモードを有効化
 	// ペースト時に \033[200~ ... \033[201~ で囲まれる
@@ -158,10 +204,18 @@ func (le *LineEditor) ReadLine(prompt string) (string, error) {
 	// プロンプト表示
 	fmt.Print(prompt)
 
+	var pending []byte // 履歴検索が読んだが処理しなかった入力（次のループで処理する）
 	for {
 		// 4096バイト: ペーストの大量データに対応
 		b := make([]byte, 4096)
-		n, err := os.Stdin.Read(b)
+		var n int
+		var err error
+		if len(pending) > 0 {
+			n = copy(b, pending)
+			pending = nil
+		} else {
+			n, err = os.Stdin.Read(b)
+		}
 		if err != nil {
 			fmt.Print("\r\n")
 			return string(buf), err
@@ -173,18 +227,17 @@ func (le *LineEditor) ReadLine(prompt string) (string, error) {
 		// ── ペーストモード中: バッファ全体をスキャンして処理 ──
 		if le.pasteMode {
 			buf, cursor = le.processPasteData(buf, cursor, b[:n])
-			// ペーストが終了した場合のみ再描画（processPasteData 内で pasteMode=false にされる）
-			le.redrawMultiLine(prompt, buf, cursor)
+			// チャンクごとには描画せず、ペーストが終了したときに一度だけ再描画する
+			// （processPasteData 内で pasteMode=false にされる）
+			if !le.pasteMode {
+				le.redrawMultiLine(prompt, buf, cursor)
+			}
 			continue
 		}
 
 		switch {
 		case b[0] == 13: // Enter (CR) → 送信
-			nLines := lineCount(buf)
-			linesBelow := nLines - 1 - le.prevCursorLine
-			if linesBelow > 0 {
-				fmt.Printf("\033[%dB", linesBelow)
-			}
+			le.moveBelowInput(buf)
 			fmt.Print("\r\n")
 			result := string(buf)
 			return result, nil
@@ -198,12 +251,11 @@ func (le *LineEditor) ReadLine(prompt string) (string, error) {
 
 		case b[0] == 3: // Ctrl+C
 			// 最終行に移動
-			nLines := lineCount(buf)
-			linesBelow := nLines - 1 - le.prevCursorLine
-			if linesBelow > 0 {
-				fmt.Printf("\033[%dB", linesBelow)
-			}
+			le.moveBelowInput(buf)
 			fmt.Print("^C\r\n")
+			if le.onInterrupt != nil {
+				le.onInterrupt()
+			}
 			return "", nil
 
 		case b[0] == 4: // Ctrl+D (EOF)
@@ -271,9 +323,16 @@ func (le *LineEditor) ReadLine(prompt string) (string, error) {
 			le.redrawMultiLine(prompt, buf, cursor)
 
 		case b[0] == 12: // Ctrl+L (画面クリア)
+			le.mu.Lock()
 			fmt.Print("\033[2J\033[H") // clear screen + move to top
 			le.prevLineCount = 1
 			le.prevCursorLine = 0
+			le.mu.Unlock()
+			le.redrawMultiLine(prompt, buf, cursor)
+
+		case b[0] == 18: // Ctrl+R (履歴の逆方向検索)
+			buf, cursor, pending = le.reverseSearch(buf, cursor)
+			le.historyIndex = len(le.history)
 			le.redrawMultiLine(prompt, buf, cursor)
 
 		case b[0] == 9: // Tab
@@ -314,11 +373,15 @@ func (le *LineEditor) ReadLine(prompt string) (string, error) {
 					}
 					if n >= 6 && b[3] == '0' && b[4] == '0' && b[5] == '~' {
 						// ESC[200~ → ペースト開始
+						le.resetPaste()
 						le.pasteMode = true
+						le.pasted = true
 						// バッファの残りがあればペーストデータとして処理
 						if n > 6 {
 							buf, cursor = le.processPasteData(buf, cursor, b[6:n])
-							le.redrawMultiLine(prompt, buf, cursor)
+							if !le.pasteMode {
+								le.redrawMultiLine(prompt, buf, cursor)
+							}
 						}
 						continue
 					}
@@ -491,8 +554,8 @@ func (le *LineEditor) ReadLine(prompt string) (string, error) {
 func (le *LineEditor) handleTab(buf []rune, cursor int) ([]rune, int) {
 	input := string(buf[:cursor])
 
-	// スラッシュコマンドの補完
-	if strings.HasPrefix(input, "/") {
+	// スラッシュコマンドの補完（一致するコマンドがなければパス補完を試す）
+	if strings.HasPrefix(input, "/") && !strings.ContainsAny(input, " \n") {
 		prefix := input
 		candidates := make([]string, 0)
 		for _, cmd := range le.completions {
@@ -503,7 +566,7 @@ func (le *LineEditor) handleTab(buf []rune, cursor int) ([]rune, int) {
 		sort.Strings(candidates)
 
 		if len(candidates) == 0 {
-			return buf, cursor
+			return le.completePath(buf, cursor)
 		}
 
 		if len(candidates) == 1 {
@@ -537,9 +600,111 @@ func (le *LineEditor) handleTab(buf []rune, cursor int) ([]rune, int) {
 		return buf, cursor
 	}
 
+	return le.completePath(buf, cursor)
+}
+
+// completePath カーソル直前のトークンがパスらしければ（/ を含む）ファイルシステムから補完する。
+// 候補の扱いはコマンド補完と同じ: 1 件なら補完し、複数なら共通部分まで補完するか候補を表示する
+func (le *LineEditor) completePath(buf []rune, cursor int) ([]rune, int) {
+	start := cursor
+	for start > 0 && !unicode.IsSpace(buf[start-1]) {
+		start--
+	}
+	token := string(buf[start:cursor])
+	if !strings.Contains(token, "/") {
+		return buf, cursor
+	}
+
+	candidates := pathCandidates(token)
+	if len(candidates) == 0 {
+		return buf, cursor
+	}
+
+	replace := func(completed string) ([]rune, int) {
+		newBuf := append([]rune(nil), buf[:start]...)
+		newBuf = append(newBuf, []rune(completed)...)
+		newCursor := len(newBuf)
+		newBuf = append(newBuf, buf[cursor:]...)
+		return newBuf, newCursor
+	}
+
+	if len(candidates) == 1 {
+		// 唯一の候補: ファイルなら補完 + スペース、ディレクトリは続けて入力できるよう / で止める
+		completed := candidates[0]
+		if !strings.HasSuffix(completed, "/") {
+			completed += " "
+		}
+		return replace(completed)
+	}
+
+	common := candidates[0]
+	for _, c := range candidates[1:] {
+		common = commonPrefix(common, c)
+	}
+	if len([]rune(common)) > len([]rune(token)) {
+		return replace(common)
+	}
+
+	// 候補を表示（ディレクトリ部分は省略）
+	fmt.Print("\r\n")
+	for _, c := range candidates {
+		fmt.Printf("  %s", pathCandidateName(c))
+	}
+	fmt.Print("\r\n")
 	return buf, cursor
 }
 
+// pathCandidates token（"dir/na" など）に前方一致するパスを名前順で返す。
+// 相対パスはカレントディレクトリから探し、ディレクトリには / を付ける。
+// 隠しファイルは token の名前部分が . で始まるときだけ候補にする
+func pathCandidates(token string) []string {
+	dirPart, base := "", token
+	if i := strings.LastIndex(token, "/"); i >= 0 {
+		dirPart, base = token[:i+1], token[i+1:]
+	}
+	dir := dirPart
+	if dir == "" {
+		dir = "."
+	} else if strings.HasPrefix(dir, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			dir = filepath.Join(home, dir[2:])
+		}
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var candidates []string
+	for _, e := range entries {
+		name := e.Name()
+		if !strings.HasPrefix(name, base) || (strings.HasPrefix(name, ".") && !strings.HasPrefix(base, ".")) {
+			continue
+		}
+		candidate := dirPart + name
+		if e.IsDir() {
+			candidate += "/"
+		} else if e.Type()&os.ModeSymlink != 0 {
+			if info, err := os.Stat(filepath.Join(dir, name)); err == nil && info.IsDir() {
+				candidate += "/"
+			}
+		}
+		candidates = append(candidates, candidate)
+	}
+	sort.Strings(candidates)
+	return candidates
+}
+
+// pathCandidateName 候補一覧に表示する名前（最後の要素。ディレクトリは / 付き）
+func pathCandidateName(candidate string) string {
+	trimmed := strings.TrimSuffix(candidate, "/")
+	name := trimmed[strings.LastIndex(trimmed, "/")+1:]
+	if trimmed != candidate {
+		name += "/"
+	}
+	return name
+}
+
 // commonPrefix 2つの文字列の共通プレフィックスを返す
 func commonPrefix(a, b string) string {
 	ra := []rune(a)
@@ -560,84 +725,189 @@ func commonPrefix(a, b string) string {
 // pasteEndMarker ブラケットペースト終了シーケンス ESC[201~
 var pasteEndMarker = []byte{0x1B, '[', '2', '0', '1', '~'}
 
+// resetPaste ペーストの状態を初期化する
+func (le *LineEditor) resetPaste() {
+	le.pasteMode = false
+	le.pastePending = nil
+	le.pasteCR = false
+}
+
+// LastInputPasted 直前の入力にブラケットペーストが含まれていたかを返す
+func (le *LineEditor) LastInputPasted() bool {
+	return le.pasted
+}
+
 // processPasteData ペーストモード中のバイトデータを処理する
-// ESC[201~ が見つかったらペーストモードを終了し、残りのデータは破棄する
-// CR/LF/CRLF は全て改行として挿入する
+// ESC[201~ が見つかったらペーストモードを終了し、残りのデータは破棄する。
+// 読み込みの境界で途切れた終了マーカーや UTF-8 の断片は次の呼び出しまで保留する。
+// CR/LF/CRLF は全て改行として挿入し、タブ以外の制御文字は表示を壊すので捨てる。
+// それ以外（バッククォートや "\033[" のような文字列）はそのまま挿入する
 func (le *LineEditor) processPasteData(buf []rune, cursor int, data []byte) ([]rune, int) {
-	// ESC[201~ (ペースト終了マーカー) を探す
-	endIdx := -1
-	for i := 0; i <= len(data)-len(pasteEndMarker); i++ {
-		match := true
-		for j := 0; j < len(pasteEndMarker); j++ {
-			if data[i+j] != pasteEndMarker[j] {
-				match = false
-				break
-			}
-		}
-		if match {
-			endIdx = i
-			break
-		}
+	if len(le.pastePending) > 0 {
+		data = append(le.pastePending, data...)
+		le.pastePending = nil
 	}
 
 	var textData []byte
-	if endIdx >= 0 {
+	if endIdx := bytes.Index(data, pasteEndMarker); endIdx >= 0 {
 		// 終了マーカーが見つかった: マーカーの前までがペーストデータ
 		textData = data[:endIdx]
 		le.pasteMode = false
 	} else {
-		// 終了マーカーなし: 全データがペーストテキスト
-		textData = data
+		// 終了マーカーなし: 末尾のマーカー候補を保留し、残りをペーストテキストとする
+		keep := partialMarkerLen(data)
+		textData = data[:len(data)-keep]
+		le.pastePending = append([]byte(nil), data[len(data)-keep:]...)
 	}
 
 	// テキストデータをルーンに変換してバッファに挿入
-	prevWasCR := false
+	inserted := make([]rune, 0, len(textData))
 	for len(textData) > 0 {
+		if le.pasteMode && !utf8.FullRune(textData) {
+			// マルチバイト文字の途中で途切れた: 次のチャンクとつなげて処理する
+			le.pastePending = append(append([]byte(nil), textData...), le.pastePending...)
+			break
+		}
 		r, size := utf8.DecodeRune(textData)
 		textData = textData[size:]
 		if r == utf8.RuneError && size == 1 {
 			continue
 		}
 		// CR+LF / CR / LF → 改行
-		if r == '\n' {
-			if prevWasCR {
-				prevWasCR = false
-				continue // CR+LF: CR で既に挿入済み
-			}
-			buf = append(buf, 0)
-			copy(buf[cursor+1:], buf[cursor:])
-			buf[cursor] = '\n'
-			cursor++
-			continue
+		if r == '\n' && le.pasteCR {
+			le.pasteCR = false
+			continue // CR+LF: CR で既に挿入済み
 		}
+		le.pasteCR = r == '\r'
 		if r == '\r' {
-			buf = append(buf, 0)
-			copy(buf[cursor+1:], buf[cursor:])
-			buf[cursor] = '\n'
-			cursor++
-			prevWasCR = true
-			continue
+			r = '\n'
 		}
-		prevWasCR = false
-		// ESC (0x1B) やその他の制御文字はスキップ
-		if r < 32 {
+		// ESC (0x1B) などタブと改行以外の制御文字はスキップ
+		if (r < 32 && r != '\t' && r != '\n') || r == 127 {
 			continue
 		}
-		buf = append(buf, 0)
-		copy(buf[cursor+1:], buf[cursor:])
-		buf[cursor] = r
-		cursor++
+		inserted = append(inserted, r)
 	}
 
+	// 1 文字ずつずらさず、まとめて挿入する
+	if len(inserted) > 0 {
+		buf = append(buf[:cursor], append(inserted, buf[cursor:]...)...)
+		cursor += len(inserted)
+	}
 	return buf, cursor
 }
 
+// partialMarkerLen data の末尾が終了マーカーの先頭部分と一致する長さを返す
+func partialMarkerLen(data []byte) int {
+	for n := len(pasteEndMarker) - 1; n > 0; n-- {
+		if len(data) >= n && bytes.Equal(data[len(data)-n:], pasteEndMarker[:n]) {
+			return n
+		}
+	}
+	return 0
+}
+
 // ── 描画 ──
 
+// beginEdit 入力開始時に描画状態を初期化する
+func (le *LineEditor) beginEdit(prompt string) {
+	le.mu.Lock()
+	defer le.mu.Unlock()
+
+	le.prevLineCount = 1
+	le.prevCursorLine = 0
+	if w, ok := stdoutWidth(); ok {
+		le.width = w
+	}
+	le.editing = true
+	le.shownPrompt = prompt
+	le.shownBuf = nil
+	le.shownCursor = 0
+}
+
+// endEdit 入力終了（以降のリサイズでは再描画しない）
+func (le *LineEditor) endEdit() {
+	le.mu.Lock()
+	defer le.mu.Unlock()
+	le.editing = false
+}
+
+// moveBelowInput 入力確定時にカーソルを最終行へ移動する
+func (le *LineEditor) moveBelowInput(buf []rune) {
+	le.mu.Lock()
+	defer le.mu.Unlock()
+
+	le.editing = false
+	linesBelow := lineCount(buf) - 1 - le.prevCursorLine
+	if linesBelow > 0 {
+		fmt.Printf("\033[%dB", linesBelow)
+	}
+}
+
+// handleResize ターミナル幅が変わったら表示中の入力を消して全体を再描画する
+// 前回の描画は旧幅で折り返されていたため prevCursorLine は当てにならない。
+// 新しい幅で折り返した場合のカーソル行だけ上に戻り、画面末尾まで消去してから描き直す。
+func (le *LineEditor) handleResize(width int) {
+	le.mu.Lock()
+	defer le.mu.Unlock()
+
+	if width <= 0 || width == le.width {
+		return
+	}
+	le.width = width
+	if !le.editing {
+		return
+	}
+
+	if up := cursorRow(le.shownPrompt, le.contPrompt, le.shownBuf, le.shownCursor, width); up > 0 {
+		fmt.Printf("\033[%dA", up)
+	}
+	fmt.Print("\r\033[J")
+	le.prevLineCount = 1
+	le.prevCursorLine = 0
+	le.draw(le.shownPrompt, le.shownBuf, le.shownCursor)
+}
+
+// cursorRow カーソルがプロンプト行から何行下に表示されるか（width で折り返した場合）
+func cursorRow(prompt, contPrompt string, buf []rune, cursor, width int) int {
+	lines := getLines(buf)
+	curLine, curCol := cursorLineAndCol(buf, cursor)
+
+	promptWidth := func(i int) int {
+		if i == 0 {
+			return displayWidth([]rune(prompt))
+		}
+		return displayWidth([]rune(contPrompt))
+	}
+
+	row := 0
+	for i := 0; i < curLine; i++ {
+		w := promptWidth(i) + displayWidth(buf[lines[i].start:lines[i].end])
+		if w > 0 {
+			row += (w + width - 1) / width
+		} else {
+			row++
+		}
+	}
+	offset := promptWidth(curLine) + displayWidth(buf[lines[curLine].start:lines[curLine].start+curCol])
+	return row + offset/width
+}
+
 // redrawMultiLine 複数行対応の再描画
+func (le *LineEditor) redrawMultiLine(prompt string, buf []rune, cursor int) {
+	le.mu.Lock()
+	defer le.mu.Unlock()
+
+	le.shownPrompt = prompt
+	le.shownBuf = append(le.shownBuf[:0], buf...)
+	le.shownCursor = cursor
+	le.draw(prompt, buf, cursor)
+}
+
+// draw 入力を描画する（le.mu を保持して呼ぶ）
 // prevCursorLine / prevLineCount で「前回ターミナルカーソルが表示行何行目にいたか」を追跡し、
 // 正確に先頭行に戻ってから再描画する。
-func (le *LineEditor) redrawMultiLine(prompt string, buf []rune, cursor int) {
+func (le *LineEditor) draw(prompt string, buf []rune, cursor int) {
 	lines := getLines(buf)
 	curLine, curCol := cursorLineAndCol(buf, cursor)
 	nLines := len(lines)
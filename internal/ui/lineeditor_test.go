@@ -0,0 +1,188 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLineEditor_ResizeForcesFullRedraw(t *testing.T) {
+	le := NewLineEditor()
+	le.beginEdit("> ")
+	le.width = 80
+
+	// 100 文字の入力: 幅 80 では 2 行、幅 40 では 3 行に折り返される
+	buf := []rune(strings.Repeat("a", 100))
+	captureStdout(t, func() {
+		le.redrawMultiLine("> ", buf, len(buf))
+	})
+
+	out := captureStdout(t, func() {
+		le.handleResize(40)
+	})
+
+	// 新しい幅でのカーソル行 (102/40 = 2) だけ上に戻り、画面末尾まで消去してから描き直す
+	want := "\033[2A\r\033[J"
+	if !strings.HasPrefix(out, want) {
+		t.Errorf("resize output should start with %q, got %q", want, out)
+	}
+	if !strings.Contains(out, "> "+string(buf)) {
+		t.Errorf("resize should redraw the whole buffer, got %q", out)
+	}
+	if le.width != 40 {
+		t.Errorf("width = %d, want 40", le.width)
+	}
+	if le.prevLineCount != 1 || le.prevCursorLine != 0 {
+		t.Errorf("draw state = (%d, %d), want (1, 0)", le.prevLineCount, le.prevCursorLine)
+	}
+
+	// 幅が変わらなければ何もしない
+	if out := captureStdout(t, func() { le.handleResize(40) }); out != "" {
+		t.Errorf("same width should not redraw, got %q", out)
+	}
+
+	// 入力終了後のリサイズは幅だけ更新する
+	le.endEdit()
+	if out := captureStdout(t, func() { le.handleResize(120) }); out != "" {
+		t.Errorf("resize after input ended should not redraw, got %q", out)
+	}
+	if le.width != 120 {
+		t.Errorf("width = %d, want 120", le.width)
+	}
+}
+
+func TestCursorRow(t *testing.T) {
+	tests := []struct {
+		name   string
+		buf    string
+		cursor int
+		width  int
+		want   int
+	}{
+		{"single short line", "hello", 5, 80, 0},
+		{"wrapped line", strings.Repeat("a", 100), 100, 40, 2},
+		{"cursor at start of wrapped line", strings.Repeat("a", 100), 0, 40, 0},
+		{"second logical line", "first\nsecond", 12, 80, 1},
+		{"wrapped first line pushes second down", strings.Repeat("a", 50) + "\nb", 52, 40, 2},
+		{"empty line still takes a row", "\n\nx", 3, 80, 2},
+		{"wide characters", strings.Repeat("あ", 30), 30, 40, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := cursorRow("> ", "... ", []rune(tt.buf), tt.cursor, tt.width)
+			if got != tt.want {
+				t.Errorf("cursorRow = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProcessPasteData_LargeCodeFenceVerbatim(t *testing.T) {
+	var sb strings.Builder
+	for i := 0; sb.Len() < 8*1024; i++ {
+		fmt.Fprintf(&sb, "```go\nfunc f%d() {\n\tfmt.Println(\"`\\033[31m` 日本語 [201\")\n}\n```\n", i)
+	}
+	input := sb.String()
+
+	le := NewLineEditor()
+	le.pasteMode = true
+	buf := []rune("> ")
+	cursor := len(buf)
+
+	// 端末からの読み込みと同様に小さなチャンクに分割する（マーカーや UTF-8 の途中でも切れる）
+	data := append([]byte(input), pasteEndMarker...)
+	for len(data) > 0 {
+		n := 7
+		if n > len(data) {
+			n = len(data)
+		}
+		buf, cursor = le.processPasteData(buf, cursor, data[:n])
+		data = data[n:]
+		if len(data) > 0 && !le.pasteMode {
+			t.Fatalf("paste ended early with %d bytes left", len(data))
+		}
+	}
+
+	if le.pasteMode {
+		t.Fatal("paste should end at the end marker")
+	}
+	if got := string(buf); got != "> "+input {
+		t.Errorf("buffer differs from the pasted input (got %d bytes, want %d)", len(got), len("> "+input))
+	}
+	if cursor != len(buf) {
+		t.Errorf("cursor = %d, want %d", cursor, len(buf))
+	}
+}
+
+func TestProcessPasteData_NormalizesCRLF(t *testing.T) {
+	le := NewLineEditor()
+	le.pasteMode = true
+	// CR と LF がチャンクの境界で分かれても改行は 1 つ
+	buf, cursor := le.processPasteData(nil, 0, []byte("a\r"))
+	buf, cursor = le.processPasteData(buf, cursor, []byte("\nb\rc\x1b[201~"))
+	if got := string(buf); got != "a\nb\nc" {
+		t.Errorf("buffer = %q, want %q", got, "a\nb\nc")
+	}
+	if cursor != len(buf) || le.pasteMode {
+		t.Errorf("cursor = %d, pasteMode = %v", cursor, le.pasteMode)
+	}
+}
+
+func TestHandleTab_CompletesPartialDirectoryPath(t *testing.T) {
+	dir := t.TempDir()
+	for _, d := range []string{"internal/ui", "internal/tool", "docs"} {
+		if err := os.MkdirAll(filepath.Join(dir, d), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, "internal", "ui", "lineeditor.go"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(dir)
+
+	le := NewLineEditor()
+	le.SetCompletions([]string{"/help", "/model"})
+	tab := func(input string) string {
+		t.Helper()
+		var buf []rune
+		var cursor int
+		captureStdout(t, func() {
+			buf, cursor = le.handleTab([]rune(input), len([]rune(input)))
+		})
+		return string(buf[:cursor]) + "|" + string(buf[cursor:])
+	}
+
+	tests := []struct {
+		input string
+		want  string
+	}{
+		// 唯一のディレクトリは / で止まり、続けて補完できる
+		{"read ./inte", "read ./internal/|"},
+		{"read internal/u", "read internal/ui/|"},
+		// ファイルは補完後にスペースを付ける
+		{"read internal/ui/l", "read internal/ui/lineeditor.go |"},
+		// 複数候補で共通部分がなければ変更しない
+		{"read internal/", "read internal/|"},
+		// / を含まないトークンはパス補完しない
+		{"read inte", "read inte|"},
+		// スラッシュコマンドの補完は従来通り
+		{"/he", "/help |"},
+	}
+	for _, tt := range tests {
+		if got := tab(tt.input); got != tt.want {
+			t.Errorf("tab(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+
+	// 入力途中（カーソルの後ろに文字がある）でもトークンだけを置き換える
+	buf := []rune("cat ./do and more")
+	captureStdout(t, func() {
+		buf, _ = le.handleTab(buf, len([]rune("cat ./do")))
+	})
+	if got := string(buf); got != "cat ./docs/ and more" {
+		t.Errorf("mid-line completion = %q", got)
+	}
+}
@@ -23,7 +23,113 @@ func NewMarkdownRenderer(terminal *Terminal, width int) *MarkdownRenderer {
 	}
 }
 
-// Render マークダウンをレンダリング
+// minWrapWidth これより狭い幅では折り返さない（インデントだけで埋まるため）
+const minWrapWidth = 20
+
+// listMarkerRe 箇条書き・番号付きリスト・引用のマーカー（折り返し行のぶら下げインデント用）
+var listMarkerRe = regexp.MustCompile(`^(?:[-*+]|\d+[.)]|>)\s+`)
+
+// WrapProse 地の文を width 表示桁で折り返す。
+// コードブロック（``` とインデント4つ）と表の行はそのまま残す。width が minWrapWidth 未満（0 = 無効）なら折り返さない
+func WrapProse(text string, width int) string {
+	if width < minWrapWidth {
+		return text
+	}
+
+	lines := strings.Split(text, "\n")
+	out := make([]string, 0, len(lines))
+	inCode := false
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") {
+			inCode = !inCode
+			out = append(out, line)
+			continue
+		}
+		if inCode || strings.HasPrefix(trimmed, "|") ||
+			strings.HasPrefix(line, "    ") || strings.HasPrefix(line, "\t") ||
+			displayWidth([]rune(line)) <= width {
+			out = append(out, line)
+			continue
+		}
+		out = append(out, wrapLine(line, width)...)
+	}
+	return strings.Join(out, "\n")
+}
+
+// wrapLine 1行を折り返す。空白で区切れない場合（日本語など）は文字単位で区切り、
+// 空白を含まない長い英単語（URL等）は分割しない
+func wrapLine(line string, width int) []string {
+	indentLen := len(line) - len(strings.TrimLeft(line, " "))
+	indentLen += len(listMarkerRe.FindString(line[indentLen:]))
+	first := line[:indentLen]
+	hanging := strings.Repeat(" ", displayWidth([]rune(first)))
+
+	avail := width - len(hanging)
+	if avail < minWrapWidth/2 {
+		return []string{line}
+	}
+
+	var result []string
+	prefix := first
+	body := []rune(line[indentLen:])
+	for displayWidth(body) > avail {
+		// avail に収まる最長の位置
+		fit, w := 0, 0
+		for fit < len(body) {
+			rw := displayWidth(body[fit : fit+1])
+			if w+rw > avail {
+				break
+			}
+			w += rw
+			fit++
+		}
+
+		// 収まる範囲内の最後の空白で区切る
+		brk := -1
+		for j := fit; j > 0; j-- {
+			if body[j] == ' ' {
+				brk = j
+				break
+			}
+		}
+
+		var head []rune
+		switch {
+		case brk > 0:
+			head, body = body[:brk], body[brk+1:]
+		case hasWide(body[:fit]):
+			head, body = body[:fit], body[fit:]
+		default:
+			// 長い単語はそのまま1行に出す
+			end := fit
+			for end < len(body) && body[end] != ' ' {
+				end++
+			}
+			head, body = body[:end], body[min(end+1, len(body)):]
+		}
+
+		result = append(result, prefix+strings.TrimRight(string(head), " "))
+		body = []rune(strings.TrimLeft(string(body), " "))
+		prefix = hanging
+	}
+	if len(body) > 0 {
+		result = append(result, prefix+string(body))
+	}
+	return result
+}
+
+// hasWide 全角文字を含むか
+func hasWide(rs []rune) bool {
+	for _, r := range rs {
+		if isCJK(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// Render マークダウンをレンダリング（コードブロック以外は mr.width で折り返す）
 func (mr *MarkdownRenderer) Render(text string) {
 	// コードブロックを検出
 	blocks := mr.parseCodeBlocks(text)
@@ -33,7 +139,7 @@ func (mr *MarkdownRenderer) Render(text string) {
 	for _, block := range blocks {
 		// コードブロック前のテキストをレンダリング
 		before := remaining[:block.Start]
-		mr.renderInline(before)
+		mr.renderInline(WrapProse(before, mr.width))
 
 		// コードブロックをレンダリング
 		mr.renderCodeBlock(block)
@@ -43,7 +149,7 @@ func (mr *MarkdownRenderer) Render(text string) {
 	}
 
 	// 最後の部分をレンダリング
-	mr.renderInline(remaining)
+	mr.renderInline(WrapProse(remaining, mr.width))
 }
 
 // CodeBlock コードブロック
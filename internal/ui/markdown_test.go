@@ -0,0 +1,67 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWrapProse_WrapsParagraphsAndKeepsCodeBlocks(t *testing.T) {
+	long := strings.Repeat("word ", 12) // 60 columns of prose
+	code := "    x := someFunction(argumentOne, argumentTwo, argumentThree, argumentFour)"
+	text := long + "\n\n```go\n" + strings.Repeat("y", 60) + "\n```\n" + code
+
+	got := WrapProse(text, 30)
+	lines := strings.Split(got, "\n")
+
+	inFence := false
+	for _, line := range lines {
+		if strings.HasPrefix(line, "```") {
+			inFence = !inFence
+			continue
+		}
+		if inFence || line == code {
+			continue
+		}
+		if w := displayWidth([]rune(line)); w > 30 {
+			t.Errorf("prose line %q is %d columns, want <= 30", line, w)
+		}
+	}
+	if !strings.Contains(got, "\n"+strings.Repeat("y", 60)+"\n") {
+		t.Errorf("fenced code line was wrapped:\n%s", got)
+	}
+	if !strings.Contains(got, "\n"+code) {
+		t.Errorf("indented code line was wrapped:\n%s", got)
+	}
+	if strings.Join(strings.Fields(got), " ") != strings.Join(strings.Fields(text), " ") {
+		t.Errorf("wrapping changed the words:\n%s", got)
+	}
+}
+
+func TestWrapProse_ListItemsUseHangingIndent(t *testing.T) {
+	got := WrapProse("- "+strings.Repeat("item ", 10), 24)
+	lines := strings.Split(got, "\n")
+	if len(lines) < 2 {
+		t.Fatalf("expected list item to wrap, got %q", got)
+	}
+	for _, line := range lines[1:] {
+		if !strings.HasPrefix(line, "  ") {
+			t.Errorf("continuation line %q should be indented under the marker", line)
+		}
+	}
+}
+
+func TestWrapProse_WrapsCJKWithoutSpaces(t *testing.T) {
+	got := WrapProse(strings.Repeat("日本語", 10), 20)
+	for _, line := range strings.Split(got, "\n") {
+		if w := displayWidth([]rune(line)); w > 20 {
+			t.Errorf("line %q is %d columns, want <= 20", line, w)
+		}
+	}
+}
+
+func TestWrapProse_DisabledForZeroWidth(t *testing.T) {
+	text := strings.Repeat("word ", 40)
+	if got := WrapProse(text, 0); got != text {
+		t.Errorf("WrapProse(text, 0) changed the text: %q", got)
+	}
+}
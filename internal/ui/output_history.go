@@ -0,0 +1,92 @@
+package ui
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// DefaultOutputHistoryLines is the number of output lines the terminal keeps for /save-output
+const DefaultOutputHistoryLines = 5000
+
+// ansiEscapeRe matches ANSI CSI escape sequences (colors, cursor movement, line clearing)
+var ansiEscapeRe = regexp.MustCompile(`\x1b\[[0-9;?]*[A-Za-z]`)
+
+// OutputHistory is a bounded ring buffer of terminal output lines.
+// Colors are stripped and carriage returns discard the current line,
+// so spinner and status-line redraws don't end up in the history.
+type OutputHistory struct {
+	mu      sync.Mutex
+	lines   []string
+	start   int // index of the oldest line once the buffer is full
+	partial strings.Builder
+	max     int
+}
+
+// NewOutputHistory creates a history that keeps at most maxLines lines
+func NewOutputHistory(maxLines int) *OutputHistory {
+	if maxLines <= 0 {
+		maxLines = DefaultOutputHistoryLines
+	}
+	return &OutputHistory{
+		lines: make([]string, 0, min(maxLines, 256)),
+		max:   maxLines,
+	}
+}
+
+// Write records terminal output; it implements io.Writer and never fails
+func (h *OutputHistory) Write(p []byte) (int, error) {
+	text := ansiEscapeRe.ReplaceAllString(string(p), "")
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, r := range text {
+		switch r {
+		case '\n':
+			h.appendLine(h.partial.String())
+			h.partial.Reset()
+		case '\r':
+			h.partial.Reset()
+		default:
+			h.partial.WriteRune(r)
+		}
+	}
+	return len(p), nil
+}
+
+// appendLine adds a completed line, overwriting the oldest one when full
+func (h *OutputHistory) appendLine(line string) {
+	if len(h.lines) < h.max {
+		h.lines = append(h.lines, line)
+		return
+	}
+	h.lines[h.start] = line
+	h.start = (h.start + 1) % h.max
+}
+
+// Lines returns the last n lines in output order (n <= 0 returns everything kept).
+// An unterminated trailing line is included.
+func (h *OutputHistory) Lines(n int) []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	all := make([]string, 0, len(h.lines)+1)
+	all = append(all, h.lines[h.start:]...)
+	all = append(all, h.lines[:h.start]...)
+	if h.partial.Len() > 0 {
+		all = append(all, h.partial.String())
+	}
+
+	if n > 0 && len(all) > n {
+		all = all[len(all)-n:]
+	}
+	return all
+}
+
+// Len returns the number of completed lines kept
+func (h *OutputHistory) Len() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.lines)
+}
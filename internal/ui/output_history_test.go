@@ -0,0 +1,58 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestTerminal_OutputHistoryCapturesPrintsInOrder(t *testing.T) {
+	term := NewTerminal()
+
+	captureStdout(t, func() {
+		term.Println("assistant: hello")
+		term.PrintColored(ColorRed, "Error: boom\n")
+		term.Printf("tool %s done\n", "bash")
+		term.Print("partial")
+	})
+
+	got := term.GetOutputHistory().Lines(0)
+	want := []string{"assistant: hello", "Error: boom", "tool bash done", "partial"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("history = %q, want %q", got, want)
+	}
+
+	if last := term.GetOutputHistory().Lines(2); strings.Join(last, "|") != "tool bash done|partial" {
+		t.Errorf("Lines(2) = %q, want last two lines", last)
+	}
+}
+
+func TestOutputHistory_DropsRedrawnLines(t *testing.T) {
+	h := NewOutputHistory(10)
+
+	fmt.Fprint(h, "before\n")
+	fmt.Fprint(h, "\r\033[K  ⠋ Thinking... (1s)")
+	fmt.Fprint(h, "\r\033[K  ⠙ Thinking... (2s)")
+	fmt.Fprint(h, "\r\033[K")
+	fmt.Fprint(h, "after\n")
+
+	got := h.Lines(0)
+	if strings.Join(got, "|") != "before|after" {
+		t.Errorf("history = %q, want spinner redraws dropped", got)
+	}
+}
+
+func TestOutputHistory_IsBounded(t *testing.T) {
+	h := NewOutputHistory(3)
+	for i := 1; i <= 5; i++ {
+		fmt.Fprintf(h, "line %d\n", i)
+	}
+
+	if h.Len() != 3 {
+		t.Errorf("Len() = %d, want 3", h.Len())
+	}
+	got := h.Lines(0)
+	if strings.Join(got, "|") != "line 3|line 4|line 5" {
+		t.Errorf("history = %q, want oldest lines dropped", got)
+	}
+}
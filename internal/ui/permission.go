@@ -25,8 +25,14 @@ type PermissionResult struct {
 	Remember PermissionType
 }
 
-// AskPermission prompts the user for permission to execute a tool
-func (t *Terminal) AskPermission(toolName string, params string) (*PermissionResult, error) {
+// AskPermission prompts the user for permission to execute a tool.
+// When diff is not empty (write/edit tools), the change is shown before the prompt.
+func (t *Terminal) AskPermission(toolName string, params string, diff string) (*PermissionResult, error) {
+	if diff != "" {
+		t.PrintColored(ColorCyan, fmt.Sprintf("━━━ %s: 変更内容 ━━━\n", toolName))
+		t.ShowDiff(diff)
+	}
+
 	prompt := fmt.Sprintf("Allow %s? (y/n/always/deny): ", toolName)
 	t.PrintColored(ColorYellow, prompt)
 
@@ -69,9 +75,33 @@ func (t *Terminal) AskPermission(toolName string, params string) (*PermissionRes
 }
 
 // AskPermission prompts the user for permission (standalone function)
-func AskPermission(toolName string, params string) (*PermissionResult, error) {
+func AskPermission(toolName string, params string, diff string) (*PermissionResult, error) {
 	term := NewTerminal()
-	return term.AskPermission(toolName, params)
+	return term.AskPermission(toolName, params, diff)
+}
+
+// ShowDiff prints a unified diff with additions in green and deletions in red
+func (t *Terminal) ShowDiff(diff string) {
+	for _, line := range strings.SplitAfter(diff, "\n") {
+		if line == "" {
+			continue
+		}
+		switch {
+		case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+			t.PrintColored(Bold, line)
+		case strings.HasPrefix(line, "@@"):
+			t.PrintColored(ColorCyan, line)
+		case strings.HasPrefix(line, "+"):
+			t.PrintColored(ColorGreen, line)
+		case strings.HasPrefix(line, "-"):
+			t.PrintColored(ColorRed, line)
+		default:
+			t.Print(line)
+		}
+	}
+	if !strings.HasSuffix(diff, "\n") {
+		t.Print("\n")
+	}
 }
 
 // AskYesNo prompts the user with a yes/no question
@@ -103,3 +133,24 @@ func AskYesNo(question string) (bool, error) {
 	term := NewTerminal()
 	return term.AskYesNo(question)
 }
+
+// AskChoice presents numbered candidates and returns the 0-based index the user picked
+func (t *Terminal) AskChoice(candidates []string) (int, error) {
+	for i, c := range candidates {
+		t.PrintColoredf(ColorCyan, "── [%d] ──\n", i+1)
+		t.Println(c)
+	}
+	t.PrintColored(ColorYellow, fmt.Sprintf("Choose a response (1-%d): ", len(candidates)))
+
+	reader := bufio.NewReader(os.Stdin)
+	response, err := reader.ReadString('\n')
+	if err != nil {
+		return 0, fmt.Errorf("failed to read input: %w", err)
+	}
+
+	var n int
+	if _, err := fmt.Sscanf(strings.TrimSpace(response), "%d", &n); err != nil || n < 1 || n > len(candidates) {
+		return 0, fmt.Errorf("invalid response: %s (expected 1-%d)", strings.TrimSpace(response), len(candidates))
+	}
+	return n - 1, nil
+}
@@ -0,0 +1,82 @@
+package ui
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+// withStdin feeds input to code that reads os.Stdin
+func withStdin(t *testing.T, input string, fn func()) {
+	t.Helper()
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("os.Pipe: %v", err)
+	}
+	if _, err := w.WriteString(input); err != nil {
+		t.Fatalf("write stdin: %v", err)
+	}
+	w.Close()
+
+	orig := os.Stdin
+	os.Stdin = r
+	defer func() { os.Stdin = orig }()
+
+	fn()
+}
+
+func TestTerminal_AskPermissionShowsColoredDiff(t *testing.T) {
+	term := NewTerminal()
+	diff := "--- main.go\n+++ main.go\n@@ -1,3 +1,3 @@\n package main\n-var x = 1\n+var x = 2\n"
+
+	var result *PermissionResult
+	var err error
+	out := captureStdout(t, func() {
+		withStdin(t, "y\n", func() {
+			result, err = term.AskPermission("edit_file", `{"path":"main.go"}`, diff)
+		})
+	})
+
+	if err != nil {
+		t.Fatalf("AskPermission: %v", err)
+	}
+	if !result.Allowed {
+		t.Error("expected the change to be allowed")
+	}
+
+	for _, want := range []string{
+		ColorCyan + "@@ -1,3 +1,3 @@\n" + ColorReset,
+		ColorRed + "-var x = 1\n" + ColorReset,
+		ColorGreen + "+var x = 2\n" + ColorReset,
+		Bold + "+++ main.go\n" + ColorReset,
+		" package main\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("prompt output missing %q:\n%s", want, out)
+		}
+	}
+
+	// The diff comes before the question
+	if strings.Index(out, "+var x = 2") > strings.Index(out, "Allow edit_file?") {
+		t.Errorf("diff should be shown before the prompt:\n%s", out)
+	}
+}
+
+func TestTerminal_AskPermissionWithoutDiff(t *testing.T) {
+	term := NewTerminal()
+
+	var result *PermissionResult
+	out := captureStdout(t, func() {
+		withStdin(t, "n\n", func() {
+			result, _ = term.AskPermission("bash", `{"command":"ls"}`, "")
+		})
+	})
+
+	if result == nil || result.Allowed {
+		t.Error("expected the call to be denied")
+	}
+	if strings.Contains(out, "━━━") {
+		t.Errorf("no diff header expected without a diff:\n%s", out)
+	}
+}
@@ -38,6 +38,12 @@ func (t *Terminal) ReadMultilineAware(prompt string) (string, error) {
 	// LineEditor already handles Ctrl+J / Alt+Enter for inline newlines.
 	// The returned line may contain \n characters.
 
+	// Pasted text (e.g. a code fence or a shell snippet ending with \) is taken
+	// as is; """ and \ are only input helpers for typed text.
+	if t.lineEditor.LastInputPasted() {
+		return line, nil
+	}
+
 	// Check for """ triple-quote mode
 	if strings.HasPrefix(line, `"""`) {
 		rest := strings.TrimPrefix(line, `"""`)
@@ -0,0 +1,14 @@
+//go:build !windows
+
+package ui
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// notifyResize ターミナルのサイズ変更（SIGWINCH）を ch に通知する
+func notifyResize(ch chan<- os.Signal) {
+	signal.Notify(ch, syscall.SIGWINCH)
+}
@@ -0,0 +1,8 @@
+//go:build windows
+
+package ui
+
+import "os"
+
+// notifyResize Windows には SIGWINCH がないため何もしない
+func notifyResize(ch chan<- os.Signal) {}
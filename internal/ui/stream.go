@@ -128,11 +128,15 @@ func (s *ToolSpinner) Start(message string) {
 		s.message = message
 		return
 	}
+	if s.terminal.IsQuiet() {
+		return
+	}
 
 	s.running = true
 	s.message = message
 	s.startTime = time.Now()
 	s.stopped = make(chan struct{})
+	stopped := s.stopped
 
 	go func() {
 		frames := []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
@@ -142,20 +146,22 @@ func (s *ToolSpinner) Start(message string) {
 
 		for {
 			select {
-			case <-s.stopped:
+			case <-stopped:
 				return
 			case <-ticker.C:
+				// Draw under the lock so PrintLine output never lands mid-frame
 				s.mu.Lock()
-				elapsed := time.Since(s.startTime)
-				msg := s.message
-				s.mu.Unlock()
-
-				// Format elapsed time
-				elapsedStr := formatElapsed(elapsed)
-
+				select {
+				case <-stopped:
+					s.mu.Unlock()
+					return
+				default:
+				}
+				elapsedStr := formatElapsed(time.Since(s.startTime))
 				s.terminal.ClearLine()
 				s.terminal.PrintColored(ColorCyan,
-					fmt.Sprintf("  %s %s (%s)", frames[i], msg, elapsedStr))
+					fmt.Sprintf("  %s %s (%s)", frames[i], s.message, elapsedStr))
+				s.mu.Unlock()
 				i = (i + 1) % len(frames)
 			}
 		}
@@ -176,6 +182,18 @@ func (s *ToolSpinner) Stop() {
 	s.terminal.ClearLine()
 }
 
+// PrintLine prints a line of tool output above the spinner; the spinner is
+// redrawn below it on the next tick
+func (s *ToolSpinner) PrintLine(line string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.running {
+		s.terminal.ClearLine()
+	}
+	s.terminal.PrintColored(ColorGray, line+"\n")
+}
+
 // Update updates the spinner message without restarting the timer
 func (s *ToolSpinner) Update(message string) {
 	s.mu.Lock()
@@ -2,12 +2,15 @@ package ui
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"runtime"
 	"strings"
 	"sync"
 	"time"
 	"unicode/utf8"
+
+	"golang.org/x/term"
 )
 
 // ANSI color codes
@@ -32,6 +35,10 @@ type Terminal struct {
 	enableColors bool
 	width        int
 	lineEditor   *LineEditor
+	out          io.Writer      // nil = os.Stdout
+	quiet        bool           // 装飾出力を stderr に回し、スピナー等を抑制
+	history      *OutputHistory // 直近の出力（/save-output 用）
+	outputWidth  int            // アシスタント出力の折り返し幅（0 = 端末幅）
 }
 
 // NewTerminal creates a new terminal
@@ -39,6 +46,7 @@ func NewTerminal() *Terminal {
 	t := &Terminal{
 		enableColors: true,
 		lineEditor:   NewLineEditor(),
+		history:      NewOutputHistory(DefaultOutputHistoryLines),
 	}
 	t.detectTerminalWidth()
 	return t
@@ -49,36 +57,70 @@ func (t *Terminal) GetLineEditor() *LineEditor {
 	return t.lineEditor
 }
 
+// SetQuiet enables quiet mode: all terminal output goes to stderr without colors,
+// and spinners/status lines are suppressed so stdout stays machine-readable
+func (t *Terminal) SetQuiet(quiet bool) {
+	t.quiet = quiet
+	if quiet {
+		t.out = os.Stderr
+		t.enableColors = false
+	} else {
+		t.out = nil
+	}
+}
+
+// IsQuiet returns whether quiet mode is enabled
+func (t *Terminal) IsQuiet() bool {
+	return t.quiet
+}
+
+// GetOutputHistory returns the buffer of recent terminal output (nil if not recording)
+func (t *Terminal) GetOutputHistory() *OutputHistory {
+	return t.history
+}
+
+// writer returns the destination for terminal output (also recorded in the history)
+func (t *Terminal) writer() io.Writer {
+	out := t.out
+	if out == nil {
+		out = os.Stdout
+	}
+	if t.history != nil {
+		return io.MultiWriter(out, t.history)
+	}
+	return out
+}
+
 // Print prints text to stdout
 func (t *Terminal) Print(text string) {
-	fmt.Print(text)
+	fmt.Fprint(t.writer(), text)
 }
 
 // Println prints text with a newline
 func (t *Terminal) Println(text string) {
-	fmt.Println(text)
+	fmt.Fprintln(t.writer(), text)
 }
 
 // Printf prints formatted text
 func (t *Terminal) Printf(format string, args ...interface{}) {
-	fmt.Printf(format, args...)
+	fmt.Fprintf(t.writer(), format, args...)
 }
 
 // PrintColored prints text with color
 func (t *Terminal) PrintColored(color, text string) {
 	if t.enableColors {
-		fmt.Print(color + text + ColorReset)
+		fmt.Fprint(t.writer(), color+text+ColorReset)
 	} else {
-		fmt.Print(text)
+		fmt.Fprint(t.writer(), text)
 	}
 }
 
 // PrintColoredf prints formatted text with color
 func (t *Terminal) PrintColoredf(color, format string, args ...interface{}) {
 	if t.enableColors {
-		fmt.Printf(color+format+ColorReset, args...)
+		fmt.Fprintf(t.writer(), color+format+ColorReset, args...)
 	} else {
-		fmt.Printf(format, args...)
+		fmt.Fprintf(t.writer(), format, args...)
 	}
 }
 
@@ -102,13 +144,52 @@ func (t *Terminal) PrintInfo(text string) {
 	t.PrintColored(ColorCyan, "ℹ "+text+"\n")
 }
 
-// detectTerminalWidth detects the terminal width
+// detectTerminalWidth detects the terminal width (80 when stdout is not a terminal)
 func (t *Terminal) detectTerminalWidth() {
-	// Try to get terminal width via TIOCGWINSZ
-	// For simplicity, default to 80 characters
 	t.width = 80
+	if w, ok := stdoutWidth(); ok {
+		t.width = w
+	}
+}
 
-	// TODO: Implement actual terminal width detection using syscalls
+// stdoutWidth returns the current width of stdout if it is a terminal
+func stdoutWidth() (int, bool) {
+	w, _, err := term.GetSize(int(os.Stdout.Fd()))
+	if err != nil || w <= 0 {
+		return 0, false
+	}
+	return w, true
+}
+
+// SetOutputWidth sets the max width for wrapping assistant prose (0 = terminal width)
+func (t *Terminal) SetOutputWidth(width int) {
+	if width < 0 {
+		width = 0
+	}
+	t.outputWidth = width
+}
+
+// OutputWidth returns the width assistant prose is wrapped to: the configured
+// width capped by the current terminal width. 0 means no wrapping (e.g. output
+// is piped and no width is configured, or quiet mode).
+func (t *Terminal) OutputWidth() int {
+	if t.quiet {
+		return 0
+	}
+	termWidth, isTerm := stdoutWidth()
+	switch {
+	case !isTerm:
+		return t.outputWidth
+	case t.outputWidth > 0 && t.outputWidth < termWidth:
+		return t.outputWidth
+	default:
+		return termWidth
+	}
+}
+
+// PrintAssistant prints assistant prose wrapped to OutputWidth; code blocks are left unwrapped
+func (t *Terminal) PrintAssistant(text string) {
+	t.Println(WrapProse(text, t.OutputWidth()))
 }
 
 // GetTerminalWidth returns the terminal width
@@ -229,12 +310,18 @@ func supportsColors() bool {
 
 // ClearLine clears the current line
 func (t *Terminal) ClearLine() {
-	fmt.Print("\r\033[K")
+	if t.quiet {
+		return
+	}
+	fmt.Fprint(t.writer(), "\r\033[K")
 }
 
 // ClearScreen clears the screen
 func (t *Terminal) ClearScreen() {
-	fmt.Print("\033[2J\033[H")
+	if t.quiet {
+		return
+	}
+	fmt.Fprint(t.writer(), "\033[2J\033[H")
 }
 
 // StatusLineUpdater displays a status line with real-time updates
@@ -259,7 +346,7 @@ func NewStatusLineUpdater(terminal *Terminal) *StatusLineUpdater {
 
 // Start starts displaying the status line with the given message
 func (s *StatusLineUpdater) Start(message string) {
-	if s.isRunning {
+	if s.isRunning || s.terminal.IsQuiet() {
 		return
 	}
 
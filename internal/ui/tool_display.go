@@ -36,7 +36,7 @@ func formatToolSummary(toolName string, params json.RawMessage) string {
 	}
 
 	switch toolName {
-	case "Bash", "bash":
+	case "Bash", "bash", "assert_command":
 		if cmd, ok := paramsMap["command"].(string); ok {
 			// 長いコマンドは短縮
 			if len(cmd) > 80 {
@@ -56,14 +56,46 @@ func formatToolSummary(toolName string, params json.RawMessage) string {
 			}
 			return fmt.Sprintf("%s (%d bytes)", path, contentLen)
 		}
+	case "make_directory":
+		if path, ok := paramsMap["path"].(string); ok {
+			return path
+		}
 	case "edit_file", "EditFile":
 		if path, ok := paramsMap["path"].(string); ok {
 			return path
 		}
+	case "move_file":
+		source, _ := paramsMap["source"].(string)
+		destination, _ := paramsMap["destination"].(string)
+		if source != "" && destination != "" {
+			return source + " → " + destination
+		}
+	case "apply_patch":
+		if patch, ok := paramsMap["patch"].(string); ok {
+			files := 0
+			for _, line := range strings.Split(patch, "\n") {
+				if strings.HasPrefix(line, "+++ ") {
+					files++
+				}
+			}
+			return fmt.Sprintf("%d files", files)
+		}
 	case "glob", "Glob":
 		if pattern, ok := paramsMap["pattern"].(string); ok {
 			return pattern
 		}
+	case "list_directory":
+		if path, ok := paramsMap["path"].(string); ok {
+			return path
+		}
+	case "symbols":
+		if name, ok := paramsMap["name"].(string); ok {
+			return name
+		}
+	case "semantic_search":
+		if query, ok := paramsMap["query"].(string); ok {
+			return query
+		}
 	case "grep", "Grep":
 		if pattern, ok := paramsMap["pattern"].(string); ok {
 			if path, ok := paramsMap["path"].(string); ok {
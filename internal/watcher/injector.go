@@ -4,6 +4,9 @@ import (
 	"fmt"
 	"os"
 	"strings"
+
+	"github.com/zephel01/vibe-local-go/internal/session"
+	"github.com/zephel01/vibe-local-go/internal/tool"
 )
 
 // ChangeNotifier is the interface for notifying about file changes
@@ -32,11 +35,22 @@ func (inj *Injector) InjectChanges(events []FileEvent) {
 	}
 
 	var msg strings.Builder
-	msg.WriteString("[File Watcher] 以下のファイルが変更されました:\n\n")
+	msg.WriteString(session.FileWatcherPrefix + " 以下のファイルが変更されました:\n\n")
 
 	for _, event := range events {
 		msg.WriteString(fmt.Sprintf("- %s (%s)\n", event.Path, event.EventType))
 
+		// 前回の内容がキャッシュされていれば差分を含める
+		if event.HasContent {
+			diff := eventDiff(event)
+			if diff == "" {
+				msg.WriteString("  (内容の変更なし)\n")
+			} else {
+				msg.WriteString(fmt.Sprintf("\n```diff\n%s```\n\n", diff))
+			}
+			continue
+		}
+
 		// For modified files, try to include content preview
 		if event.EventType == EventModified || event.EventType == EventCreated {
 			content := readFilePreview(event.Path, MaxDiffLines)
@@ -51,6 +65,24 @@ func (inj *Injector) InjectChanges(events []FileEvent) {
 	inj.notifier.AddUserMessage(msg.String())
 }
 
+// eventDiff returns the unified diff for an event, truncated to MaxDiffLines
+func eventDiff(event FileEvent) string {
+	oldName, newName := event.Path, event.Path
+	switch event.EventType {
+	case EventCreated:
+		oldName = "/dev/null"
+	case EventDeleted:
+		newName = "/dev/null"
+	}
+
+	diff := tool.UnifiedDiff(oldName, newName, event.OldContent, event.NewContent)
+	lines := strings.SplitAfter(diff, "\n")
+	if len(lines) > MaxDiffLines+1 {
+		return strings.Join(lines[:MaxDiffLines], "") + fmt.Sprintf("... (%d lines truncated)\n", len(lines)-1-MaxDiffLines)
+	}
+	return diff
+}
+
 // readFilePreview reads up to maxLines from a file
 func readFilePreview(path string, maxLines int) string {
 	data, err := os.ReadFile(path)
@@ -6,6 +6,8 @@ import (
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/zephel01/vibe-local-go/internal/session"
 )
 
 // mockNotifier implements ChangeNotifier for testing
@@ -33,8 +35,9 @@ func TestInjector_InjectChanges(t *testing.T) {
 	}
 
 	msg := notifier.messages[0]
-	if !strings.Contains(msg, "File Watcher") {
-		t.Error("message should contain 'File Watcher'")
+	// LastUserInput skips watcher notifications by this prefix
+	if !strings.HasPrefix(msg, session.FileWatcherPrefix) {
+		t.Errorf("message should start with %q", session.FileWatcherPrefix)
 	}
 	if !strings.Contains(msg, "main.go") {
 		t.Error("message should contain 'main.go'")
@@ -151,3 +154,79 @@ func TestReadFilePreview_Binary(t *testing.T) {
 		t.Errorf("expected '(binary file)', got '%s'", preview)
 	}
 }
+
+func TestInjector_EditInjectsDiff(t *testing.T) {
+	dir := t.TempDir()
+	path := writeTestFile(t, dir, "main.go", "package main\n\nfunc main() {\n}\n")
+
+	fw := NewFileWatcher(dir)
+	fw.pollInterval = 20 * time.Millisecond
+	fw.SetDebounce(50 * time.Millisecond)
+	if err := fw.Start([]string{"*.go"}); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer fw.Stop()
+
+	writeTestFile(t, dir, "main.go", "package main\n\nfunc main() {\n\tprintln(\"hello\")\n}\n")
+	future := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, future, future); err != nil {
+		t.Fatal(err)
+	}
+
+	var events []FileEvent
+	select {
+	case events = <-fw.Events():
+	case <-time.After(3 * time.Second):
+		t.Fatal("timeout waiting for file change event")
+	}
+
+	notifier := &mockNotifier{}
+	NewInjector(notifier).InjectChanges(events)
+	if len(notifier.messages) != 1 {
+		t.Fatalf("expected 1 message, got %d", len(notifier.messages))
+	}
+
+	msg := notifier.messages[0]
+	for _, want := range []string{"```diff", "--- " + path, "+++ " + path, "@@ -1,4 +1,5 @@", "+\tprintln(\"hello\")", " func main() {"} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("message missing %q:\n%s", want, msg)
+		}
+	}
+}
+
+func TestInjector_CreatedAndDeletedDiffs(t *testing.T) {
+	notifier := &mockNotifier{}
+	inj := NewInjector(notifier)
+
+	inj.InjectChanges([]FileEvent{
+		{Path: "/p/new.go", EventType: EventCreated, NewContent: "package p\n", HasContent: true},
+		{Path: "/p/old.go", EventType: EventDeleted, OldContent: "package old\n", HasContent: true},
+		{Path: "/p/touched.go", EventType: EventModified, OldContent: "x\n", NewContent: "x\n", HasContent: true},
+	})
+
+	msg := notifier.messages[0]
+	for _, want := range []string{
+		"--- /dev/null\n+++ /p/new.go\n@@ -0,0 +1,1 @@\n+package p\n",
+		"--- /p/old.go\n+++ /dev/null\n@@ -1,1 +0,0 @@\n-package old\n",
+		"内容の変更なし",
+	} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("message missing %q:\n%s", want, msg)
+		}
+	}
+}
+
+func TestEventDiff_Truncated(t *testing.T) {
+	var lines []string
+	for i := 0; i < MaxDiffLines*2; i++ {
+		lines = append(lines, "line")
+	}
+	diff := eventDiff(FileEvent{Path: "/p/big.txt", EventType: EventCreated, NewContent: strings.Join(lines, "\n"), HasContent: true})
+
+	if !strings.Contains(diff, "lines truncated") {
+		t.Error("long diff should mention truncation")
+	}
+	if n := strings.Count(diff, "\n"); n > MaxDiffLines+1 {
+		t.Errorf("diff has %d lines, want at most %d", n, MaxDiffLines+1)
+	}
+}
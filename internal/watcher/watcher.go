@@ -13,11 +13,15 @@ const (
 	// DefaultPollInterval is the default interval between polling checks
 	DefaultPollInterval = 500 * time.Millisecond
 
-	// DefaultDebounce is the debounce duration for file change batching
-	DefaultDebounce = 500 * time.Millisecond
+	// DefaultDebounce is how long the watcher waits after the last change before
+	// delivering a batch; changes to the same file within it coalesce into one event
+	DefaultDebounce = 300 * time.Millisecond
 
 	// MaxDiffLines is the maximum number of diff lines to include
 	MaxDiffLines = 100
+
+	// MaxCachedFileSize is the largest file whose contents are cached for diffs
+	MaxCachedFileSize = 256 * 1024
 )
 
 // FileEvent represents a file change event
@@ -25,6 +29,13 @@ type FileEvent struct {
 	Path      string
 	EventType EventType
 	ModTime   time.Time
+
+	// OldContent and NewContent are the file's text before and after the change
+	// ("" for the missing side of a create or delete). They are only valid when
+	// HasContent is set; binary or oversized files are not cached.
+	OldContent string
+	NewContent string
+	HasContent bool
 }
 
 // EventType represents the type of file event
@@ -75,6 +86,7 @@ type FileWatcher struct {
 
 	// Internal state
 	fileStates map[string]time.Time // path -> last modified time
+	contents   map[string]string    // path -> last seen text (cacheable files only)
 	events     chan []FileEvent
 	stop       chan struct{}
 	running    bool
@@ -90,11 +102,31 @@ func NewFileWatcher(baseDir string) *FileWatcher {
 		pollInterval: DefaultPollInterval,
 		debounce:     DefaultDebounce,
 		fileStates:   make(map[string]time.Time),
+		contents:     make(map[string]string),
 		events:       make(chan []FileEvent, 10),
 		stop:         make(chan struct{}),
 	}
 }
 
+// SetDebounce sets the debounce window; d <= 0 restores DefaultDebounce.
+// It takes effect on the next Start.
+func (fw *FileWatcher) SetDebounce(d time.Duration) {
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+
+	if d <= 0 {
+		d = DefaultDebounce
+	}
+	fw.debounce = d
+}
+
+// Debounce returns the debounce window
+func (fw *FileWatcher) Debounce() time.Duration {
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+	return fw.debounce
+}
+
 // Events returns the channel of batched file events
 func (fw *FileWatcher) Events() <-chan []FileEvent {
 	return fw.events
@@ -164,51 +196,115 @@ func (fw *FileWatcher) WatchedFileCount() int {
 	return len(fw.fileStates)
 }
 
-// pollLoop runs the polling loop
+// pollLoop runs the polling loop. Changes are collected until no new change
+// has been seen for the debounce window, then delivered as one batch with at
+// most one event per file. Polling runs at least three times per debounce
+// window so a change made before the window ends is seen in time to extend it.
 func (fw *FileWatcher) pollLoop() {
-	ticker := time.NewTicker(fw.pollInterval)
+	fw.mu.Lock()
+	debounce := fw.debounce
+	interval := fw.pollInterval
+	fw.mu.Unlock()
+	if d := debounce / 3; d > 0 && d < interval {
+		interval = d
+	}
+
+	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
-	var pendingEvents []FileEvent
-	var debounceTimer *time.Timer
+	pending := newEventBatch()
+	var lastChange time.Time // Zero when nothing is pending
 
 	for {
 		select {
 		case <-fw.stop:
-			if debounceTimer != nil {
-				debounceTimer.Stop()
-			}
 			return
 
 		case <-ticker.C:
-			events := fw.detectChanges()
-			if len(events) > 0 {
-				pendingEvents = append(pendingEvents, events...)
-
-				// Reset debounce timer
-				if debounceTimer != nil {
-					debounceTimer.Stop()
+			if events := fw.detectChanges(); len(events) > 0 {
+				for _, e := range events {
+					pending.add(e)
 				}
-				debounceTimer = time.AfterFunc(fw.debounce, func() {
-					fw.mu.Lock()
-					batch := pendingEvents
-					pendingEvents = nil
-					fw.mu.Unlock()
-
-					if len(batch) > 0 {
-						// Non-blocking send
-						select {
-						case fw.events <- batch:
-						default:
-							// Drop if channel full
-						}
-					}
-				})
+				// Restart the debounce window
+				lastChange = time.Now()
+				continue
+			}
+
+			// Deliver once the pending changes have been quiet for the whole window
+			if lastChange.IsZero() || time.Since(lastChange) < debounce {
+				continue
+			}
+			lastChange = time.Time{}
+			batch := pending.drain()
+			if len(batch) == 0 {
+				continue
+			}
+			// Non-blocking send
+			select {
+			case fw.events <- batch:
+			default:
+				// Drop if channel full
 			}
 		}
 	}
 }
 
+// eventBatch coalesces events per file, keeping the order files first changed in
+type eventBatch struct {
+	order []string
+	first map[string]FileEvent // First event seen for each file
+	last  map[string]FileEvent // Latest event for each file
+}
+
+func newEventBatch() *eventBatch {
+	return &eventBatch{
+		first: make(map[string]FileEvent),
+		last:  make(map[string]FileEvent),
+	}
+}
+
+// add records e for its file
+func (b *eventBatch) add(e FileEvent) {
+	if _, ok := b.first[e.Path]; !ok {
+		b.order = append(b.order, e.Path)
+		b.first[e.Path] = e
+	}
+	b.last[e.Path] = e
+}
+
+// drain returns one event per file describing its final state relative to the
+// start of the batch, and empties the batch. A file created and deleted again
+// within the batch is dropped.
+func (b *eventBatch) drain() []FileEvent {
+	var result []FileEvent
+	for _, path := range b.order {
+		first, e := b.first[path], b.last[path]
+		existedBefore := first.EventType != EventCreated
+		existsNow := e.EventType != EventDeleted
+
+		// Diff from the content before the first change to the content after the last
+		oldKnown := !existedBefore || first.HasContent
+		newKnown := !existsNow || e.HasContent
+		e.OldContent = first.OldContent
+		e.HasContent = oldKnown && newKnown
+
+		switch {
+		case !existedBefore && !existsNow:
+			continue
+		case !existedBefore:
+			e.EventType = EventCreated
+		case existsNow:
+			e.EventType = EventModified
+		}
+		result = append(result, e)
+	}
+
+	b.order = nil
+	b.first = make(map[string]FileEvent)
+	b.last = make(map[string]FileEvent)
+	return result
+}
+
 // detectChanges checks for file changes since last scan
 func (fw *FileWatcher) detectChanges() []FileEvent {
 	fw.mu.Lock()
@@ -231,19 +327,27 @@ func (fw *FileWatcher) detectChanges() []FileEvent {
 			modTime := info.ModTime()
 			if lastMod, exists := fw.fileStates[path]; exists {
 				if modTime.After(lastMod) {
+					oldContent, hadOld := fw.contents[path]
+					newContent, hasNew := fw.cacheContent(path)
 					events = append(events, FileEvent{
-						Path:      path,
-						EventType: EventModified,
-						ModTime:   modTime,
+						Path:       path,
+						EventType:  EventModified,
+						ModTime:    modTime,
+						OldContent: oldContent,
+						NewContent: newContent,
+						HasContent: hadOld && hasNew,
 					})
 					fw.fileStates[path] = modTime
 				}
 			} else {
 				// New file
+				newContent, hasNew := fw.cacheContent(path)
 				events = append(events, FileEvent{
-					Path:      path,
-					EventType: EventCreated,
-					ModTime:   modTime,
+					Path:       path,
+					EventType:  EventCreated,
+					ModTime:    modTime,
+					NewContent: newContent,
+					HasContent: hasNew,
 				})
 				fw.fileStates[path] = modTime
 			}
@@ -253,18 +357,52 @@ func (fw *FileWatcher) detectChanges() []FileEvent {
 	// Check for deleted files
 	for path := range fw.fileStates {
 		if !currentFiles[path] {
+			oldContent, hadOld := fw.contents[path]
 			events = append(events, FileEvent{
-				Path:      path,
-				EventType: EventDeleted,
-				ModTime:   time.Now(),
+				Path:       path,
+				EventType:  EventDeleted,
+				ModTime:    time.Now(),
+				OldContent: oldContent,
+				HasContent: hadOld,
 			})
 			delete(fw.fileStates, path)
+			delete(fw.contents, path)
 		}
 	}
 
 	return events
 }
 
+// cacheContent reads path and stores its text as the last seen version.
+// Binary and oversized files are not cached and report false.
+func (fw *FileWatcher) cacheContent(path string) (string, bool) {
+	content, ok := readTextFile(path)
+	if !ok {
+		delete(fw.contents, path)
+		return "", false
+	}
+	fw.contents[path] = content
+	return content, true
+}
+
+// readTextFile reads a text file of at most MaxCachedFileSize bytes
+func readTextFile(path string) (string, bool) {
+	info, err := os.Stat(path)
+	if err != nil || info.Size() > MaxCachedFileSize {
+		return "", false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || len(data) > MaxCachedFileSize {
+		return "", false
+	}
+	for _, b := range data[:minInt(len(data), 8000)] {
+		if b == 0 {
+			return "", false
+		}
+	}
+	return string(data), true
+}
+
 // scanFiles builds the initial file state map
 func (fw *FileWatcher) scanFiles() error {
 	for _, pattern := range fw.patterns {
@@ -275,6 +413,7 @@ func (fw *FileWatcher) scanFiles() error {
 				continue
 			}
 			fw.fileStates[path] = info.ModTime()
+			fw.cacheContent(path)
 		}
 	}
 	return nil
@@ -1,6 +1,7 @@
 package watcher
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 	"testing"
@@ -176,6 +177,102 @@ func TestFileWatcher_DetectDeletion(t *testing.T) {
 	}
 }
 
+func TestFileWatcher_DebounceCoalescesRapidWrites(t *testing.T) {
+	dir := t.TempDir()
+	path := writeTestFile(t, dir, "rapid.go", "package main")
+
+	// Default poll interval (500ms) and debounce (300ms): the writes span a
+	// poll boundary, so they only coalesce if polling keeps up with the window
+	fw := NewFileWatcher(dir)
+
+	if err := fw.Start([]string{"*.go"}); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer fw.Stop()
+
+	// Five writes 150ms apart, each inside the debounce window of the one before
+	base := time.Now().Add(time.Minute)
+	var last time.Time
+	for i := 1; i <= 5; i++ {
+		writeTestFile(t, dir, "rapid.go", fmt.Sprintf("package main\n// edit %d", i))
+		last = base.Add(time.Duration(i) * time.Second)
+		if err := os.Chtimes(path, last, last); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(150 * time.Millisecond)
+	}
+
+	select {
+	case events := <-fw.Events():
+		if len(events) != 1 {
+			t.Fatalf("expected 1 coalesced event, got %d: %v", len(events), events)
+		}
+		if events[0].Path != path || events[0].EventType != EventModified {
+			t.Errorf("event = %+v, want modified %s", events[0], path)
+		}
+		if !events[0].ModTime.Equal(last) {
+			t.Errorf("ModTime = %v, want the final write %v", events[0].ModTime, last)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("timeout waiting for the coalesced event")
+	}
+
+	select {
+	case events := <-fw.Events():
+		t.Errorf("expected a single batch, got another: %v", events)
+	case <-time.After(500 * time.Millisecond):
+	}
+}
+
+func TestEventBatch_FinalState(t *testing.T) {
+	tests := []struct {
+		name   string
+		events []EventType
+		want   []EventType // nil = dropped
+	}{
+		{"modified twice", []EventType{EventModified, EventModified}, []EventType{EventModified}},
+		{"created then modified", []EventType{EventCreated, EventModified}, []EventType{EventCreated}},
+		{"created then deleted", []EventType{EventCreated, EventDeleted}, nil},
+		{"deleted then recreated", []EventType{EventDeleted, EventCreated}, []EventType{EventModified}},
+		{"modified then deleted", []EventType{EventModified, EventDeleted}, []EventType{EventDeleted}},
+	}
+
+	for _, tt := range tests {
+		b := newEventBatch()
+		for _, et := range tt.events {
+			b.add(FileEvent{Path: "/p/a.go", EventType: et})
+		}
+		got := b.drain()
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: got %d events, want %d", tt.name, len(got), len(tt.want))
+			continue
+		}
+		for i := range got {
+			if got[i].EventType != tt.want[i] {
+				t.Errorf("%s: event type = %s, want %s", tt.name, got[i].EventType, tt.want[i])
+			}
+		}
+		if len(b.drain()) != 0 {
+			t.Errorf("%s: drain should empty the batch", tt.name)
+		}
+	}
+}
+
+func TestSetDebounce(t *testing.T) {
+	fw := NewFileWatcher("/tmp")
+	if fw.Debounce() != DefaultDebounce {
+		t.Errorf("default debounce = %v, want %v", fw.Debounce(), DefaultDebounce)
+	}
+	fw.SetDebounce(500 * time.Millisecond)
+	if fw.Debounce() != 500*time.Millisecond {
+		t.Errorf("Debounce() = %v, want 500ms", fw.Debounce())
+	}
+	fw.SetDebounce(0)
+	if fw.Debounce() != DefaultDebounce {
+		t.Errorf("SetDebounce(0) should restore the default, got %v", fw.Debounce())
+	}
+}
+
 func TestFileWatcher_ExcludePatterns(t *testing.T) {
 	dir := t.TempDir()
 
@@ -266,3 +363,22 @@ func writeTestFile(t *testing.T, dir, name, content string) string {
 	}
 	return path
 }
+
+func TestEventBatch_DiffsAgainstFirstContent(t *testing.T) {
+	b := newEventBatch()
+	b.add(FileEvent{Path: "/p/a.go", EventType: EventModified, OldContent: "v1", NewContent: "v2", HasContent: true})
+	b.add(FileEvent{Path: "/p/a.go", EventType: EventModified, OldContent: "v2", NewContent: "v3", HasContent: true})
+	b.add(FileEvent{Path: "/p/b.go", EventType: EventCreated, NewContent: "new", HasContent: true})
+	b.add(FileEvent{Path: "/p/b.go", EventType: EventModified, OldContent: "new", NewContent: "big", HasContent: false})
+
+	got := b.drain()
+	if len(got) != 2 {
+		t.Fatalf("got %d events, want 2", len(got))
+	}
+	if got[0].OldContent != "v1" || got[0].NewContent != "v3" || !got[0].HasContent {
+		t.Errorf("a.go = %+v, want v1 -> v3", got[0])
+	}
+	if got[1].HasContent {
+		t.Errorf("b.go should have no diff once its content is unknown: %+v", got[1])
+	}
+}